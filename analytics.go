@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+const defaultClickHouseTable = "junit2otlp_tests"
+
+// bigQueryEndpoint the endpoint of the BigQuery API
+var bigQueryEndpoint = "https://bigquery.googleapis.com/bigquery/v2"
+
+// getClickHouseURL the precedence order is: flag > CLICKHOUSE_URL > disabled
+func getClickHouseURL() string {
+	return strings.TrimSuffix(getOtlpEnvVar(clickHouseURLFlag, "CLICKHOUSE_URL", ""), "/")
+}
+
+// getBigQueryTable the precedence order is: flag > BIGQUERY_TABLE > disabled
+func getBigQueryTable() string {
+	return getOtlpEnvVar(bigQueryTableFlag, "BIGQUERY_TABLE", "")
+}
+
+// clickHouseWriter inserts the rows of the test cases in a ClickHouse table, with its HTTP interface. The columns
+// of the table not present in the rows take their default values, and the attributes without a column are skipped,
+// so the table only needs the columns to be queried
+type clickHouseWriter struct {
+	url      string
+	table    string
+	user     string
+	password string
+	client   *http.Client
+}
+
+// newClickHouseWriter creates the writer, reading the credentials from the CLICKHOUSE_USER and
+// CLICKHOUSE_PASSWORD environment variables, so they are not exposed as flags
+func newClickHouseWriter(url string, table string) *clickHouseWriter {
+	return &clickHouseWriter{
+		url:      url,
+		table:    table,
+		user:     os.Getenv("CLICKHOUSE_USER"),
+		password: os.Getenv("CLICKHOUSE_PASSWORD"),
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (w *clickHouseWriter) writeRows(ctx context.Context, rows []map[string]any) error {
+	body := &bytes.Buffer{}
+	encoder := json.NewEncoder(body)
+	for _, row := range rows {
+		if err := encoder.Encode(row); err != nil {
+			return err
+		}
+	}
+
+	query := url.Values{}
+	query.Set("query", "INSERT INTO "+w.table+" FORMAT JSONEachRow")
+	query.Set("input_format_skip_unknown_fields", "1")
+	query.Set("date_time_input_format", "best_effort")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url+"/?"+query.Encode(), body)
+	if err != nil {
+		return err
+	}
+	if w.user != "" {
+		req.Header.Set("X-ClickHouse-User", w.user)
+		req.Header.Set("X-ClickHouse-Key", w.password)
+	}
+
+	_, err = doAnalyticsRequest(w.client, req, "clickhouse")
+	return err
+}
+
+func (w *clickHouseWriter) close(ctx context.Context) error {
+	return nil
+}
+
+// bigQueryWriter inserts the rows of the test cases in a BigQuery table, with the streaming API. The attributes
+// without a column in the table are skipped, and the ID of the span is used to deduplicate the retried rows
+type bigQueryWriter struct {
+	project string
+	dataset string
+	table   string
+	token   string
+	client  *http.Client
+}
+
+// newBigQueryWriter creates the writer for the project.dataset.table table, reading the OAuth access token
+// from the BIGQUERY_ACCESS_TOKEN or GOOGLE_OAUTH_ACCESS_TOKEN environment variables,
+// i.e. the output of gcloud auth print-access-token
+func newBigQueryWriter(table string) (*bigQueryWriter, error) {
+	parts := strings.Split(table, ".")
+	if len(parts) != 3 || slices.Contains(parts, "") {
+		return nil, fmt.Errorf("invalid BigQuery table %q: the format is project.dataset.table", table)
+	}
+
+	token := firstEnv("BIGQUERY_ACCESS_TOKEN", "GOOGLE_OAUTH_ACCESS_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("the BIGQUERY_ACCESS_TOKEN environment variable is required to insert the rows in BigQuery")
+	}
+
+	return &bigQueryWriter{
+		project: parts[0],
+		dataset: parts[1],
+		table:   parts[2],
+		token:   token,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// bigQueryInsertResponse the subset of the response of the streaming API needed to detect the rejected rows
+type bigQueryInsertResponse struct {
+	InsertErrors []struct {
+		Index  int `json:"index"`
+		Errors []struct {
+			Reason  string `json:"reason"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	} `json:"insertErrors"`
+}
+
+func (w *bigQueryWriter) writeRows(ctx context.Context, rows []map[string]any) error {
+	insertRows := make([]map[string]any, 0, len(rows))
+	for _, row := range rows {
+		insertRows = append(insertRows, map[string]any{"insertId": row[rowSpanID], "json": row})
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"kind":                "bigquery#tableDataInsertAllRequest",
+		"ignoreUnknownValues": true,
+		"rows":                insertRows,
+	})
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("%s/projects/%s/datasets/%s/tables/%s/insertAll", bigQueryEndpoint, url.PathEscape(w.project), url.PathEscape(w.dataset), url.PathEscape(w.table))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+w.token)
+
+	data, err := doAnalyticsRequest(w.client, req, "bigquery")
+	if err != nil {
+		return err
+	}
+
+	result := bigQueryInsertResponse{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("failed to parse the response of bigquery: %w", err)
+	}
+
+	if len(result.InsertErrors) == 0 {
+		return nil
+	}
+
+	reason := ""
+	if errs := result.InsertErrors[0].Errors; len(errs) > 0 {
+		reason = errs[0].Reason + ": " + errs[0].Message
+	}
+
+	return fmt.Errorf("bigquery rejected %d of %d test case rows: %s", len(result.InsertErrors), len(rows), reason)
+}
+
+func (w *bigQueryWriter) close(ctx context.Context) error {
+	return nil
+}
+
+// doAnalyticsRequest sends the request to the backend, returning the body of the response, or an error
+// with it if the backend did not accept the request
+func doAnalyticsRequest(client *http.Client, req *http.Request, backend string) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert the test cases in %s: %w", backend, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the response of %s: %w", backend, err)
+	}
+
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("%s responded with %s: %s", backend, resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	return data, nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRowExporter(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	res := resource.NewSchemaless(attribute.Key(ScmBranch).String("main"))
+
+	spans := tracetest.SpanStubs{
+		{Name: "suite", StartTime: start, EndTime: start.Add(time.Second), Resource: res},
+		{
+			Name:       "TestLogin",
+			StartTime:  start,
+			EndTime:    start.Add(250 * time.Millisecond),
+			Attributes: []attribute.KeyValue{attribute.Key(TestStatus).String("passed")},
+			Resource:   res,
+		},
+	}.Snapshots()
+
+	t.Run("ClickHouse", func(t *testing.T) {
+		t.Setenv("CLICKHOUSE_USER", "tests")
+		t.Setenv("CLICKHOUSE_PASSWORD", "secret")
+
+		rows := []map[string]any{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "INSERT INTO tests FORMAT JSONEachRow", r.URL.Query().Get("query"))
+			require.Equal(t, "1", r.URL.Query().Get("input_format_skip_unknown_fields"))
+			require.Equal(t, "tests", r.Header.Get("X-ClickHouse-User"))
+			require.Equal(t, "secret", r.Header.Get("X-ClickHouse-Key"))
+
+			scanner := bufio.NewScanner(r.Body)
+			for scanner.Scan() {
+				row := map[string]any{}
+				require.NoError(t, json.Unmarshal(scanner.Bytes(), &row))
+				rows = append(rows, row)
+			}
+		}))
+		defer server.Close()
+
+		exporter := &rowExporter{writer: newClickHouseWriter(server.URL, "tests")}
+		require.NoError(t, exporter.ExportSpans(context.Background(), spans))
+
+		require.Len(t, rows, 1)
+		require.Equal(t, "TestLogin", rows[0][rowName])
+		require.Equal(t, "2024-01-01T12:00:00Z", rows[0][rowTimestamp])
+		require.Equal(t, float64(250), rows[0][rowDurationMs])
+		require.Equal(t, "passed", rows[0]["tests_case_status"])
+		require.Equal(t, "main", rows[0]["scm_branch"])
+	})
+
+	t.Run("ClickHouse error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Code: 60. DB::Exception: Table default.tests does not exist", http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		exporter := &rowExporter{writer: newClickHouseWriter(server.URL, "tests")}
+		require.ErrorContains(t, exporter.ExportSpans(context.Background(), spans), "clickhouse responded with 404 Not Found: Code: 60.")
+	})
+
+	t.Run("BigQuery", func(t *testing.T) {
+		t.Setenv("BIGQUERY_ACCESS_TOKEN", "token")
+
+		request := map[string]any{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/projects/project/datasets/ci/tables/tests/insertAll", r.URL.Path)
+			require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			require.NoError(t, json.Unmarshal(body, &request))
+
+			w.Write([]byte(`{"kind":"bigquery#tableDataInsertAllResponse"}`))
+		}))
+		defer server.Close()
+
+		endpoint := bigQueryEndpoint
+		bigQueryEndpoint = server.URL
+		defer func() { bigQueryEndpoint = endpoint }()
+
+		writer, err := newBigQueryWriter("project.ci.tests")
+		require.NoError(t, err)
+		require.NoError(t, (&rowExporter{writer: writer}).ExportSpans(context.Background(), spans))
+
+		require.Equal(t, true, request["ignoreUnknownValues"])
+		rows := request["rows"].([]any)
+		require.Len(t, rows, 1)
+		row := rows[0].(map[string]any)
+		require.Equal(t, spans[1].SpanContext().SpanID().String(), row["insertId"])
+		require.Equal(t, "TestLogin", row["json"].(map[string]any)[rowName])
+	})
+
+	t.Run("BigQuery rejected rows", func(t *testing.T) {
+		t.Setenv("BIGQUERY_ACCESS_TOKEN", "token")
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"insertErrors":[{"index":0,"errors":[{"reason":"invalid","message":"no such field"}]}]}`))
+		}))
+		defer server.Close()
+
+		endpoint := bigQueryEndpoint
+		bigQueryEndpoint = server.URL
+		defer func() { bigQueryEndpoint = endpoint }()
+
+		writer, err := newBigQueryWriter("project.ci.tests")
+		require.NoError(t, err)
+		err = (&rowExporter{writer: writer}).ExportSpans(context.Background(), spans)
+		require.EqualError(t, err, "bigquery rejected 1 of 1 test case rows: invalid: no such field")
+	})
+
+	t.Run("BigQuery configuration", func(t *testing.T) {
+		t.Setenv("BIGQUERY_ACCESS_TOKEN", "")
+		t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "")
+
+		_, err := newBigQueryWriter("project.tests")
+		require.ErrorContains(t, err, "the format is project.dataset.table")
+
+		_, err = newBigQueryWriter("project.ci.tests")
+		require.ErrorContains(t, err, "BIGQUERY_ACCESS_TOKEN")
+	})
+}
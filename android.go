@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// androidDeviceProperty property of the suites in the Android connected tests reports, with the device
+// and the version of Android, i.e. "Pixel_3a_API_30(AVD) - 11"
+const androidDeviceProperty = "device"
+
+// firebaseDeviceDirRegex matches the directories of the Firebase Test Lab result bundles, one per device,
+// named after the model, the API level, the locale and the orientation, i.e. "Pixel2-28-en-portrait"
+var firebaseDeviceDirRegex = regexp.MustCompile(`^(.+)-(\d+)-([A-Za-z_]+)-(portrait|landscape)$`)
+
+// device the device where the tests of a suite ran, i.e. for Android instrumentation tests
+type device struct {
+	Model     string
+	OSVersion string
+}
+
+// key returns the identifier of the device
+func (d *device) key() string {
+	return d.Model + "/" + d.OSVersion
+}
+
+// attributes returns the resource attributes of the device
+func (d *device) attributes() []attribute.KeyValue {
+	attributes := []attribute.KeyValue{attribute.Key(DeviceModelIdentifier).String(d.Model)}
+	if d.OSVersion != "" {
+		attributes = append(attributes, attribute.Key(OSVersion).String(d.OSVersion))
+	}
+
+	return attributes
+}
+
+// suiteDevice detects the device of the suite: from the device property of the Android connected tests
+// reports, or from the directory of the device in the Firebase Test Lab result bundles. Nil if it's unknown
+func suiteDevice(path string, rawSuite *xmlElement) *device {
+	if value := rawSuite.Properties()[androidDeviceProperty]; value != "" {
+		model, osVersion, _ := strings.Cut(value, " - ")
+		return &device{Model: strings.TrimSpace(model), OSVersion: strings.TrimSpace(osVersion)}
+	}
+
+	if path == "" {
+		return nil
+	}
+
+	for dir := filepath.Dir(path); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if matches := firebaseDeviceDirRegex.FindStringSubmatch(filepath.Base(dir)); matches != nil {
+			return &device{Model: matches[1], OSVersion: matches[2]}
+		}
+	}
+
+	return nil
+}
+
+// initDeviceTracerProviders creates a tracer provider for each device in the report, with the device attributes
+// merged into the resource, so that the suites of each device are reported with its resource, in the same trace
+func initDeviceTracerProviders(ctx context.Context, res *resource.Resource, diagnostics *exportDiagnostics, outputs *spanOutputs, report *junitReport, exporterOptions ...otlpOption) (map[string]*sdktrace.TracerProvider, error) {
+	providers := map[string]*sdktrace.TracerProvider{}
+
+	for _, d := range report.devices {
+		if d == nil {
+			continue
+		}
+
+		if _, ok := providers[d.key()]; ok {
+			continue
+		}
+
+		deviceRes, err := resource.Merge(res, resource.NewSchemaless(d.attributes()...))
+		if err != nil {
+			return nil, err
+		}
+
+		provider, err := newTracerProvider(ctx, deviceRes, diagnostics, outputs, exporterOptions...)
+		if err != nil {
+			return nil, err
+		}
+
+		providers[d.key()] = provider
+	}
+
+	return providers, nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const androidReport = `<?xml version='1.0' encoding='UTF-8' ?>
+<testsuite name="com.example.ExampleInstrumentedTest" tests="1" failures="0" errors="0" skipped="0" time="0.05" hostname="localhost">
+  <properties>
+    <property name="device" value="Pixel_3a_API_30(AVD) - 11" />
+    <property name="flavor" value="" />
+    <property name="project" value=":app" />
+  </properties>
+  <testcase name="useAppContext" classname="com.example.ExampleInstrumentedTest" time="0.05" />
+</testsuite>`
+
+const firebaseReport = `<?xml version='1.0' encoding='UTF-8' ?>
+<testsuite name="" tests="1" failures="0" errors="0" skipped="0" time="1.2" hostname="localhost">
+  <testcase name="useAppContext" classname="com.example.ExampleInstrumentedTest" time="1.2" />
+</testsuite>`
+
+func TestSuiteDevice(t *testing.T) {
+	t.Run("Android connected tests", func(t *testing.T) {
+		report, err := ingestReport([]byte(androidReport))
+		require.NoError(t, err)
+		require.Equal(t, []*device{{Model: "Pixel_3a_API_30(AVD)", OSVersion: "11"}}, report.devices)
+	})
+
+	t.Run("Firebase Test Lab result bundle", func(t *testing.T) {
+		report, err := ingestReportFrom("results/2024-01-01/Pixel2-28-en-portrait/test_result_1.xml", []byte(firebaseReport))
+		require.NoError(t, err)
+		require.Equal(t, []*device{{Model: "Pixel2", OSVersion: "28"}}, report.devices)
+	})
+
+	t.Run("Unknown device", func(t *testing.T) {
+		report, err := ingestReportFrom("build/test-results/TEST-sample.xml", []byte(firebaseReport))
+		require.NoError(t, err)
+		require.Equal(t, []*device{nil}, report.devices)
+	})
+}
+
+func TestIngestInputs(t *testing.T) {
+	dir := t.TempDir()
+	for _, model := range []string{"Pixel2-28-en-portrait", "NexusLowRes-30-en_US-landscape"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, model), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, model, "test_result_1.xml"), []byte(firebaseReport), 0o644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "logcat"), []byte("not a report"), 0o644))
+
+	report, err := ingestInputs([]string{dir, "TEST-sample2.xml"})
+	require.NoError(t, err)
+
+	sample, err := ingestInputs([]string{"TEST-sample2.xml"})
+	require.NoError(t, err)
+
+	require.Len(t, report.suites, 2+len(sample.suites))
+	require.Len(t, report.rawSuites, len(report.suites))
+	require.Len(t, report.devices, len(report.suites))
+	require.Equal(t, &device{Model: "NexusLowRes", OSVersion: "30"}, report.devices[0])
+	require.Equal(t, &device{Model: "Pixel2", OSVersion: "28"}, report.devices[1])
+	require.Nil(t, report.devices[2])
+}
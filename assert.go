@@ -0,0 +1,471 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/joshdk/go-junit"
+)
+
+// assertion an expression over the results of the run, i.e. 'failed == 0 && duration_p95 < 60s', which
+// makes the tool exit with an error if it's not satisfied, so it can be used as a policy gate in the pipeline
+type assertion struct {
+	source string
+	root   assertNode
+}
+
+// assertValue the value of an expression: a number, with the durations in seconds, or a boolean
+type assertValue struct {
+	number  float64
+	boolean bool
+	isBool  bool
+}
+
+type assertNode interface {
+	eval(vars map[string]assertValue) (assertValue, error)
+}
+
+// parseAssertion parses the expression, returning nil if it's empty
+func parseAssertion(source string) (*assertion, error) {
+	if strings.TrimSpace(source) == "" {
+		return nil, nil
+	}
+
+	tokens, err := tokenizeAssertion(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid assertion %q: %w", source, err)
+	}
+
+	p := &assertParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid assertion %q: %w", source, err)
+	}
+
+	return &assertion{source: source, root: root}, nil
+}
+
+// check evaluates the assertion over the report, returning an error with the values of the variables
+// if it's not satisfied
+func (a *assertion) check(report *junitReport) error {
+	vars := assertVariables(report)
+
+	value, err := a.root.eval(vars)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate the assertion %q: %w", a.source, err)
+	}
+	if !value.isBool {
+		return fmt.Errorf("the assertion %q is not a condition", a.source)
+	}
+
+	if !value.boolean {
+		return fmt.Errorf("assertion failed: %s (%s)", a.source, describeAssertVariables(vars))
+	}
+
+	return nil
+}
+
+// assertVariables the variables available in the assertions, computed from the report
+func assertVariables(report *junitReport) map[string]assertValue {
+	summary := summarize(report)
+
+	durations := []time.Duration{}
+	timeouts := 0
+	for _, suite := range report.suites {
+		walkSuiteTests(suite, func(suite junit.Suite, test junit.Test) {
+			durations = append(durations, test.Duration)
+			if isTimeout(test, suiteTimeout(suite)) {
+				timeouts++
+			}
+		})
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+	number := func(n float64) assertValue { return assertValue{number: n} }
+
+	vars := map[string]assertValue{
+		"total":        number(float64(summary.Total)),
+		"passed":       number(float64(summary.Passed)),
+		"failed":       number(float64(summary.Failed)),
+		"errors":       number(float64(summary.Errors)),
+		"skipped":      number(float64(summary.Skipped)),
+		"suites":       number(float64(summary.Suites)),
+		"timeouts":     number(float64(timeouts)),
+		"pass_rate":    number(summary.PassRate()),
+		"duration":     number(summary.Duration.Seconds()),
+		"duration_max": number(percentile(durations, 100).Seconds()),
+		"inconsistent": {boolean: summary.Inconsistent, isBool: true},
+	}
+
+	for _, p := range []int{50, 90, 95, 99} {
+		vars["duration_p"+strconv.Itoa(p)] = number(percentile(durations, p).Seconds())
+	}
+
+	return vars
+}
+
+// walkSuiteTests calls the function for each test case of the suite, including the nested suites
+func walkSuiteTests(suite junit.Suite, fn func(junit.Suite, junit.Test)) {
+	for _, test := range suite.Tests {
+		fn(suite, test)
+	}
+
+	for _, nested := range suite.Suites {
+		walkSuiteTests(nested, fn)
+	}
+}
+
+// percentile returns the nearest-rank percentile of the sorted durations, or zero if there are none
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(float64(p) / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
+
+func describeAssertVariables(vars map[string]assertValue) string {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	values := make([]string, 0, len(names))
+	for _, name := range names {
+		values = append(values, name+"="+vars[name].String())
+	}
+
+	return strings.Join(values, ", ")
+}
+
+func (v assertValue) String() string {
+	if v.isBool {
+		return strconv.FormatBool(v.boolean)
+	}
+
+	return strconv.FormatFloat(v.number, 'g', -1, 64)
+}
+
+type assertToken struct {
+	kind string
+	text string
+}
+
+const (
+	tokenNumber     = "number"
+	tokenIdentifier = "identifier"
+	tokenOperator   = "operator"
+)
+
+// assertUnits the units of the number literals, converting the durations to seconds and the percentages to ratios
+var assertUnits = map[string]float64{
+	"":   1,
+	"ms": 0.001,
+	"s":  1,
+	"m":  60,
+	"h":  3600,
+	"%":  0.01,
+}
+
+// tokenizeAssertion splits the expression into numbers, with their units, identifiers and operators
+func tokenizeAssertion(source string) ([]assertToken, error) {
+	tokens := []assertToken{}
+	runes := []rune(source)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || runes[i] == '%') {
+				i++
+			}
+			tokens = append(tokens, assertToken{kind: tokenNumber, text: string(runes[start:i])})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, assertToken{kind: tokenIdentifier, text: string(runes[start:i])})
+		default:
+			operator := ""
+			for _, candidate := range []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "(", ")", "+", "-", "*", "/"} {
+				if strings.HasPrefix(string(runes[i:]), candidate) {
+					operator = candidate
+					break
+				}
+			}
+			if operator == "" {
+				return nil, fmt.Errorf("unexpected character %q", r)
+			}
+
+			tokens = append(tokens, assertToken{kind: tokenOperator, text: operator})
+			i += len(operator)
+		}
+	}
+
+	return tokens, nil
+}
+
+// assertParser a recursive descent parser of the assertions, with the usual precedence of the operators
+type assertParser struct {
+	tokens []assertToken
+	pos    int
+}
+
+func (p *assertParser) peek(operators ...string) (string, bool) {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokenOperator {
+		return "", false
+	}
+
+	for _, operator := range operators {
+		if p.tokens[p.pos].text == operator {
+			return operator, true
+		}
+	}
+
+	return "", false
+}
+
+func (p *assertParser) parseBinary(next func() (assertNode, error), operators ...string) (assertNode, error) {
+	left, err := next()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		operator, ok := p.peek(operators...)
+		if !ok {
+			return left, nil
+		}
+		p.pos++
+
+		right, err := next()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &assertBinary{operator: operator, left: left, right: right}
+	}
+}
+
+func (p *assertParser) parseOr() (assertNode, error) {
+	return p.parseBinary(p.parseAnd, "||")
+}
+
+func (p *assertParser) parseAnd() (assertNode, error) {
+	return p.parseBinary(p.parseComparison, "&&")
+}
+
+func (p *assertParser) parseComparison() (assertNode, error) {
+	return p.parseBinary(p.parseSum, "==", "!=", "<=", ">=", "<", ">")
+}
+
+func (p *assertParser) parseSum() (assertNode, error) {
+	return p.parseBinary(p.parseProduct, "+", "-")
+}
+
+func (p *assertParser) parseProduct() (assertNode, error) {
+	return p.parseBinary(p.parseUnary, "*", "/")
+}
+
+func (p *assertParser) parseUnary() (assertNode, error) {
+	if operator, ok := p.peek("!", "-"); ok {
+		p.pos++
+
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return &assertUnary{operator: operator, operand: operand}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *assertParser) parsePrimary() (assertNode, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of the expression")
+	}
+
+	token := p.tokens[p.pos]
+	p.pos++
+
+	switch token.kind {
+	case tokenNumber:
+		return parseAssertNumber(token.text)
+	case tokenIdentifier:
+		switch token.text {
+		case "true", "false":
+			return &assertLiteral{value: assertValue{boolean: token.text == "true", isBool: true}}, nil
+		}
+
+		return &assertVariable{name: token.text}, nil
+	}
+
+	if token.text != "(" {
+		return nil, fmt.Errorf("unexpected %q", token.text)
+	}
+
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := p.peek(")"); !ok {
+		return nil, fmt.Errorf("missing closing parenthesis")
+	}
+	p.pos++
+
+	return node, nil
+}
+
+// parseAssertNumber parses a number literal, with an optional unit: ms, s, m or h for durations, or %
+func parseAssertNumber(text string) (assertNode, error) {
+	end := strings.IndexFunc(text, func(r rune) bool { return !unicode.IsDigit(r) && r != '.' })
+	if end < 0 {
+		end = len(text)
+	}
+
+	number, err := strconv.ParseFloat(text[:end], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number %q", text)
+	}
+
+	factor, ok := assertUnits[text[end:]]
+	if !ok {
+		return nil, fmt.Errorf("invalid unit %q of the number %s: valid units are ms, s, m, h and %%", text[end:], text)
+	}
+
+	return &assertLiteral{value: assertValue{number: number * factor}}, nil
+}
+
+type assertLiteral struct {
+	value assertValue
+}
+
+func (n *assertLiteral) eval(map[string]assertValue) (assertValue, error) {
+	return n.value, nil
+}
+
+type assertVariable struct {
+	name string
+}
+
+func (n *assertVariable) eval(vars map[string]assertValue) (assertValue, error) {
+	value, ok := vars[n.name]
+	if !ok {
+		return assertValue{}, fmt.Errorf("unknown variable %q", n.name)
+	}
+
+	return value, nil
+}
+
+type assertUnary struct {
+	operator string
+	operand  assertNode
+}
+
+func (n *assertUnary) eval(vars map[string]assertValue) (assertValue, error) {
+	value, err := n.operand.eval(vars)
+	if err != nil {
+		return assertValue{}, err
+	}
+
+	if n.operator == "!" {
+		if !value.isBool {
+			return assertValue{}, fmt.Errorf("the operand of ! is not a condition")
+		}
+
+		return assertValue{boolean: !value.boolean, isBool: true}, nil
+	}
+
+	if value.isBool {
+		return assertValue{}, fmt.Errorf("the operand of - is not a number")
+	}
+
+	return assertValue{number: -value.number}, nil
+}
+
+type assertBinary struct {
+	operator string
+	left     assertNode
+	right    assertNode
+}
+
+func (n *assertBinary) eval(vars map[string]assertValue) (assertValue, error) {
+	left, err := n.left.eval(vars)
+	if err != nil {
+		return assertValue{}, err
+	}
+
+	right, err := n.right.eval(vars)
+	if err != nil {
+		return assertValue{}, err
+	}
+
+	switch n.operator {
+	case "&&", "||":
+		if !left.isBool || !right.isBool {
+			return assertValue{}, fmt.Errorf("the operands of %s are not conditions", n.operator)
+		}
+
+		if n.operator == "&&" {
+			return assertValue{boolean: left.boolean && right.boolean, isBool: true}, nil
+		}
+		return assertValue{boolean: left.boolean || right.boolean, isBool: true}, nil
+	case "==", "!=":
+		if left.isBool != right.isBool {
+			return assertValue{}, fmt.Errorf("the operands of %s are not of the same type", n.operator)
+		}
+
+		equal := left == right
+		return assertValue{boolean: equal == (n.operator == "=="), isBool: true}, nil
+	}
+
+	if left.isBool || right.isBool {
+		return assertValue{}, fmt.Errorf("the operands of %s are not numbers", n.operator)
+	}
+
+	switch n.operator {
+	case "<":
+		return assertValue{boolean: left.number < right.number, isBool: true}, nil
+	case "<=":
+		return assertValue{boolean: left.number <= right.number, isBool: true}, nil
+	case ">":
+		return assertValue{boolean: left.number > right.number, isBool: true}, nil
+	case ">=":
+		return assertValue{boolean: left.number >= right.number, isBool: true}, nil
+	case "+":
+		return assertValue{number: left.number + right.number}, nil
+	case "-":
+		return assertValue{number: left.number - right.number}, nil
+	case "*":
+		return assertValue{number: left.number * right.number}, nil
+	default:
+		if right.number == 0 {
+			return assertValue{}, fmt.Errorf("division by zero")
+		}
+		return assertValue{number: left.number / right.number}, nil
+	}
+}
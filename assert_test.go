@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssertion(t *testing.T) {
+	data := []byte(`<testsuites>
+	<testsuite name="suite">
+		<testcase name="fast" time="1"/>
+		<testcase name="slow" time="90"/>
+		<testcase name="failed" time="2"><failure message="boom"/></testcase>
+		<testcase name="skipped"><skipped/></testcase>
+	</testsuite>
+</testsuites>`)
+
+	report, err := ingestReport(data)
+	require.NoError(t, err)
+
+	satisfied := []string{
+		"failed == 1",
+		"total == 4 && skipped == 1",
+		"duration_max > 60s && duration_p50 <= 2s",
+		"duration == 93",
+		"pass_rate >= 66% && pass_rate < 0.7",
+		"failed + errors < 2 || inconsistent",
+		"!(failed == 0)",
+		"duration_p95 >= 1.5m",
+		"inconsistent == false",
+	}
+	for _, expression := range satisfied {
+		t.Run(expression, func(t *testing.T) {
+			gate, err := parseAssertion(expression)
+			require.NoError(t, err)
+			require.NoError(t, gate.check(report))
+		})
+	}
+
+	t.Run("Not satisfied", func(t *testing.T) {
+		gate, err := parseAssertion("failed == 0 && duration_p95 < 60s")
+		require.NoError(t, err)
+
+		err = gate.check(report)
+		require.ErrorContains(t, err, "assertion failed: failed == 0 && duration_p95 < 60s")
+		require.ErrorContains(t, err, "failed=1")
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		gate, err := parseAssertion(" ")
+		require.NoError(t, err)
+		require.Nil(t, gate)
+	})
+
+	invalid := []string{"failed ==", "failed == 0)", "(failed == 0", "failed = 0", "duration < 5d", "failed # 0"}
+	for _, expression := range invalid {
+		t.Run("Invalid "+expression, func(t *testing.T) {
+			_, err := parseAssertion(expression)
+			require.Error(t, err)
+		})
+	}
+
+	t.Run("Evaluation errors", func(t *testing.T) {
+		for _, expression := range []string{"unknown > 0", "failed", "failed && true", "failed / 0 > 1"} {
+			gate, err := parseAssertion(expression)
+			require.NoError(t, err)
+			require.Error(t, gate.check(report), expression)
+		}
+	})
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"aead.dev/minisign"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+// the types of the in-toto statement of the attestation, and of its predicate with the evidence of the tests
+const (
+	inTotoStatementType     = "https://in-toto.io/Statement/v1"
+	testEvidencePredicateV1 = "https://github.com/mdelapenya/junit2otlp/attestation/test-evidence/v1"
+)
+
+// inTotoStatement an in-toto statement, whose subjects are the reports of the run
+type inTotoStatement struct {
+	Type          string          `json:"_type"`
+	Subject       []inTotoSubject `json:"subject"`
+	PredicateType string          `json:"predicateType"`
+	Predicate     testEvidence    `json:"predicate"`
+}
+
+type inTotoSubject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// testEvidence the predicate of the attestation: the summary of the run, its CI and SCM attributes, and the reports
+// it was computed from, as exported, with the trace of the run to find its telemetry
+type testEvidence struct {
+	Service  string               `json:"service,omitempty"`
+	TraceID  string               `json:"traceId"`
+	Exported time.Time            `json:"exported"`
+	Summary  map[string]any       `json:"summary,omitempty"`
+	CI       map[string]any       `json:"ci,omitempty"`
+	SCM      map[string]any       `json:"scm,omitempty"`
+	Reports  []testEvidenceReport `json:"reports"`
+}
+
+type testEvidenceReport struct {
+	Name   string `json:"name"`
+	Format string `json:"format"`
+	SHA256 string `json:"sha256"`
+}
+
+// attestationExporter writes an in-toto attestation of the exported run once all of its spans are exported, from
+// the attributes of the spans, so it attests the telemetry that was actually exported. It's signed with a minisign
+// signature next to it if there is a signing key
+type attestationExporter struct {
+	path       string
+	signingKey *minisign.PrivateKey
+
+	mu       sync.Mutex
+	evidence testEvidence
+	reports  map[testEvidenceReport]bool
+}
+
+func newAttestationExporter(path string, signingKeyPath string) (*attestationExporter, error) {
+	signingKey, err := loadSigningKey(signingKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &attestationExporter{path: path, signingKey: signingKey, reports: map[testEvidenceReport]bool{}}, nil
+}
+
+// ExportSpans collects the summary and the CI attributes of the span of the run, the SCM attributes of any span,
+// and the provenance of the suites
+func (e *attestationExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, span := range spans {
+		isRun := false
+		report := testEvidenceReport{}
+
+		for _, attr := range span.Attributes() {
+			key := string(attr.Key)
+
+			switch {
+			case key == TestsRunTotal:
+				isRun = true
+			case strings.HasPrefix(key, "scm."):
+				if e.evidence.SCM == nil {
+					e.evidence.SCM = map[string]any{}
+				}
+				e.evidence.SCM[key] = attr.Value.AsInterface()
+			case key == ReportSourceFile:
+				report.Name = attr.Value.AsString()
+			case key == ReportSourceFormat:
+				report.Format = attr.Value.AsString()
+			case key == ReportSourceSHA256:
+				report.SHA256 = attr.Value.AsString()
+			}
+		}
+
+		if report.SHA256 != "" {
+			// the reports read from the standard input have no file
+			if report.Name == "" {
+				report.Name = "stdin"
+			}
+			e.reports[report] = true
+		}
+
+		if e.evidence.TraceID == "" || isRun {
+			e.evidence.TraceID = span.SpanContext().TraceID().String()
+		}
+
+		if !isRun {
+			continue
+		}
+
+		e.evidence.Summary, e.evidence.CI = map[string]any{}, map[string]any{}
+		for _, attr := range span.Attributes() {
+			key := string(attr.Key)
+			switch {
+			case strings.HasPrefix(key, "tests.run."):
+				e.evidence.Summary[key] = attr.Value.AsInterface()
+			case strings.HasPrefix(key, "cicd."):
+				e.evidence.CI[key] = attr.Value.AsInterface()
+			}
+		}
+
+		if value, ok := span.Resource().Set().Value(semconv.ServiceNameKey); ok {
+			e.evidence.Service = value.AsString()
+		}
+	}
+
+	return nil
+}
+
+// Shutdown writes the attestation, with the reports sorted by name
+func (e *attestationExporter) Shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(e.reports) == 0 {
+		return errors.New("the attestation is not written: the run has no reports")
+	}
+
+	statement := inTotoStatement{
+		Type:          inTotoStatementType,
+		Subject:       []inTotoSubject{},
+		PredicateType: testEvidencePredicateV1,
+		Predicate:     e.evidence,
+	}
+	statement.Predicate.Exported = time.Now().UTC()
+
+	for report := range e.reports {
+		statement.Predicate.Reports = append(statement.Predicate.Reports, report)
+	}
+	sort.Slice(statement.Predicate.Reports, func(i, j int) bool {
+		a, b := statement.Predicate.Reports[i], statement.Predicate.Reports[j]
+		return a.Name < b.Name || (a.Name == b.Name && a.SHA256 < b.SHA256)
+	})
+
+	for _, report := range statement.Predicate.Reports {
+		statement.Subject = append(statement.Subject, inTotoSubject{Name: report.Name, Digest: map[string]string{"sha256": report.SHA256}})
+	}
+
+	data, err := json.MarshalIndent(statement, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if err := os.WriteFile(e.path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write the attestation: %w", err)
+	}
+
+	if e.signingKey == nil {
+		return nil
+	}
+
+	if err := os.WriteFile(e.path+".minisig", minisign.Sign(*e.signingKey, data), 0o644); err != nil {
+		return fmt.Errorf("failed to write the signature of the attestation: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"aead.dev/minisign"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestAttestation(t *testing.T) {
+	traceID := trace.TraceID{0x01}
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: trace.SpanID{0x01}})
+	res := resource.NewSchemaless(attribute.String("service.name", "attested"))
+
+	suite := func(file string, sha string) tracetest.SpanStub {
+		return tracetest.SpanStub{
+			Name:        "suite",
+			SpanContext: spanContext,
+			Resource:    res,
+			Attributes: []attribute.KeyValue{
+				attribute.String(ScmBranch, "main"),
+				attribute.String(ReportSourceFile, file),
+				attribute.String(ReportSourceFormat, reportFormatJUnit),
+				attribute.String(ReportSourceSHA256, sha),
+			},
+		}
+	}
+
+	run := tracetest.SpanStub{
+		Name:        "run",
+		SpanContext: spanContext,
+		Resource:    res,
+		Attributes: []attribute.KeyValue{
+			attribute.Int(TestsRunTotal, 3),
+			attribute.Int(TestsRunFailed, 1),
+			attribute.String(CICDPipelineName, "build"),
+			attribute.String(ScmBranch, "main"),
+		},
+	}
+
+	t.Run("Statement", func(t *testing.T) {
+		publicKey, privateKey, err := minisign.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		path := filepath.Join(t.TempDir(), "attestation.json")
+		exporter := &attestationExporter{path: path, signingKey: &privateKey, reports: map[testEvidenceReport]bool{}}
+
+		spans := tracetest.SpanStubs{suite("b.xml", "bb"), suite("a.xml", "aa"), suite("b.xml", "bb"), suite("", "cc"), run}
+		require.NoError(t, exporter.ExportSpans(context.Background(), spans.Snapshots()))
+		require.NoError(t, exporter.Shutdown(context.Background()))
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		statement := inTotoStatement{}
+		require.NoError(t, json.Unmarshal(data, &statement))
+
+		require.Equal(t, inTotoStatementType, statement.Type)
+		require.Equal(t, testEvidencePredicateV1, statement.PredicateType)
+		require.Equal(t, []inTotoSubject{
+			{Name: "a.xml", Digest: map[string]string{"sha256": "aa"}},
+			{Name: "b.xml", Digest: map[string]string{"sha256": "bb"}},
+			{Name: "stdin", Digest: map[string]string{"sha256": "cc"}},
+		}, statement.Subject)
+
+		evidence := statement.Predicate
+		require.Equal(t, "attested", evidence.Service)
+		require.Equal(t, traceID.String(), evidence.TraceID)
+		require.Equal(t, map[string]any{TestsRunTotal: 3.0, TestsRunFailed: 1.0}, evidence.Summary)
+		require.Equal(t, map[string]any{CICDPipelineName: "build"}, evidence.CI)
+		require.Equal(t, map[string]any{ScmBranch: "main"}, evidence.SCM)
+		require.Equal(t, testEvidenceReport{Name: "a.xml", Format: reportFormatJUnit, SHA256: "aa"}, evidence.Reports[0])
+
+		signature, err := os.ReadFile(path + ".minisig")
+		require.NoError(t, err)
+		require.True(t, minisign.Verify(publicKey, data, signature))
+	})
+
+	t.Run("Without reports", func(t *testing.T) {
+		exporter, err := newAttestationExporter(filepath.Join(t.TempDir(), "attestation.json"), "")
+		require.NoError(t, err)
+
+		require.NoError(t, exporter.ExportSpans(context.Background(), tracetest.SpanStubs{run}.Snapshots()))
+		require.ErrorContains(t, exporter.Shutdown(context.Background()), "no reports")
+	})
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// the outcomes of the exports recorded by the audit log
+const (
+	auditExported = "exported"
+	auditFailed   = "failed"
+)
+
+// auditBundleDestination the destination of the payloads written to a bundle by the pack command
+const auditBundleDestination = "bundle"
+
+// auditRecord a line of the audit log, for each payload sent to the collector, or written to a bundle
+type auditRecord struct {
+	Time        time.Time `json:"time"`
+	Service     string    `json:"service"`
+	Signal      string    `json:"signal"`
+	Destination string    `json:"destination"`
+	Protocol    string    `json:"protocol,omitempty"`
+	Outcome     string    `json:"outcome"`
+	Error       string    `json:"error,omitempty"`
+	TraceIDs    []string  `json:"trace_ids,omitempty"`
+	Spans       int       `json:"spans,omitempty"`
+	Metrics     int       `json:"metrics,omitempty"`
+	// PayloadSHA256 the hash of the payload: the deterministic protobuf encoding of the request of the traces, and
+	// the JSON encoding of the metrics, as they are spooled
+	PayloadSHA256 string `json:"payload_sha256"`
+}
+
+// auditLog the append-only log of the exports, a JSON record per line. The file is only opened to append to it, and
+// each record is appended with a single write, so the records of concurrent invocations are not interleaved
+type auditLog struct {
+	mu      sync.Mutex
+	file    *os.File
+	service string
+}
+
+// openAuditLog opens the audit log to append to it, if its path is set
+func openAuditLog(path string) (*auditLog, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the audit log: %w", err)
+	}
+
+	return &auditLog{file: file, service: getOtlpServiceName()}, nil
+}
+
+func (l *auditLog) close() error {
+	if l == nil {
+		return nil
+	}
+
+	return l.file.Close()
+}
+
+// record appends the record of an export. The failures to write it are reported to the error handler, as the export
+// happened anyway
+func (l *auditLog) record(record auditRecord, payload []byte, err error) {
+	sum := sha256.Sum256(payload)
+	record.Time = time.Now().UTC()
+	record.Service = l.service
+	record.PayloadSHA256 = hex.EncodeToString(sum[:])
+	record.Outcome = auditExported
+	if err != nil {
+		record.Outcome, record.Error = auditFailed, err.Error()
+	}
+
+	data, marshalErr := json.Marshal(record)
+	if marshalErr != nil {
+		otel.Handle(marshalErr)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		otel.Handle(fmt.Errorf("failed to write the audit log: %w", err))
+	}
+}
+
+// traceClient returns the client recording its uploads in the audit log, if any
+func (l *auditLog) traceClient(client otlptrace.Client, destination string, protocol string) otlptrace.Client {
+	if l == nil {
+		return client
+	}
+
+	return &auditingTraceClient{Client: client, log: l, destination: destination, protocol: protocol}
+}
+
+// metricExporter returns the exporter recording its exports in the audit log, if any
+func (l *auditLog) metricExporter(exporter sdkmetric.Exporter, destination string, protocol string) sdkmetric.Exporter {
+	if l == nil {
+		return exporter
+	}
+
+	return &auditingMetricExporter{Exporter: exporter, log: l, destination: destination, protocol: protocol}
+}
+
+// auditingTraceClient records the uploads of the traces, with their trace IDs
+type auditingTraceClient struct {
+	otlptrace.Client
+	log         *auditLog
+	destination string
+	protocol    string
+}
+
+func (c *auditingTraceClient) UploadTraces(ctx context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	err := c.Client.UploadTraces(ctx, protoSpans)
+
+	payload, marshalErr := payloadEncoding.Marshal(&coltracepb.ExportTraceServiceRequest{ResourceSpans: protoSpans})
+	if marshalErr != nil {
+		otel.Handle(marshalErr)
+		return err
+	}
+
+	record := auditRecord{Signal: strings.ToLower(signalTraces), Destination: c.destination, Protocol: c.protocol, TraceIDs: []string{}}
+	seen := map[string]bool{}
+	for _, rs := range protoSpans {
+		for _, ss := range rs.GetScopeSpans() {
+			for _, span := range ss.GetSpans() {
+				record.Spans++
+
+				traceID := hex.EncodeToString(span.GetTraceId())
+				if !seen[traceID] {
+					seen[traceID] = true
+					record.TraceIDs = append(record.TraceIDs, traceID)
+				}
+			}
+		}
+	}
+
+	c.log.record(record, payload, err)
+
+	return err
+}
+
+// auditingMetricExporter records the exports of the metrics
+type auditingMetricExporter struct {
+	sdkmetric.Exporter
+	log         *auditLog
+	destination string
+	protocol    string
+}
+
+func (e *auditingMetricExporter) Export(ctx context.Context, metrics *metricdata.ResourceMetrics) error {
+	err := e.Exporter.Export(ctx, metrics)
+
+	payload, marshalErr := json.Marshal(spoolResourceMetrics(metrics))
+	if marshalErr != nil {
+		otel.Handle(marshalErr)
+		return err
+	}
+
+	record := auditRecord{Signal: strings.ToLower(signalMetrics), Destination: e.destination, Protocol: e.protocol}
+	for _, scope := range metrics.ScopeMetrics {
+		record.Metrics += len(scope.Metrics)
+	}
+
+	e.log.record(record, payload, err)
+
+	return err
+}
+
+// otlpDestination returns the endpoint of the signal the exporter sends to, with the default one of the SDK for the
+// protocol if none is set
+func otlpDestination(signal string, settings otlpSettings, protocol string) string {
+	if settings.endpoint != "" {
+		return settings.endpoint
+	}
+
+	if endpoint := firstEnv("OTEL_EXPORTER_OTLP_"+signal+"_ENDPOINT", "OTEL_EXPORTER_OTLP_ENDPOINT"); endpoint != "" {
+		return endpoint
+	}
+
+	if protocol == otlpProtocolHTTP {
+		return "localhost:4318"
+	}
+
+	return "localhost:4317"
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func readAuditRecords(t *testing.T, path string) []auditRecord {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	records := []auditRecord{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		record := auditRecord{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	require.NoError(t, scanner.Err())
+
+	return records
+}
+
+func TestAuditLog(t *testing.T) {
+	t.Setenv("OTEL_SERVICE_NAME", "audited")
+
+	t.Run("Disabled", func(t *testing.T) {
+		audit, err := openAuditLog("")
+		require.NoError(t, err)
+		require.Nil(t, audit)
+		require.NoError(t, audit.close())
+
+		client := &recordingTraceClient{}
+		require.Same(t, client, audit.traceClient(client, "localhost:4317", otlpProtocolGRPC))
+	})
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	t.Run("Traces", func(t *testing.T) {
+		audit, err := openAuditLog(path)
+		require.NoError(t, err)
+		defer audit.close()
+
+		rs := testResourceSpans()
+		rs.ScopeSpans[0].Spans = append(rs.ScopeSpans[0].Spans, rs.ScopeSpans[0].Spans[0])
+		spans := []*tracepb.ResourceSpans{rs}
+
+		client := audit.traceClient(&recordingTraceClient{}, "collector:4317", otlpProtocolGRPC)
+		require.NoError(t, client.UploadTraces(context.Background(), spans))
+
+		failing := audit.traceClient(unavailableTraceClient{}, "collector:4317", otlpProtocolGRPC)
+		require.Error(t, failing.UploadTraces(context.Background(), spans))
+	})
+
+	t.Run("Metrics", func(t *testing.T) {
+		// the log is appended to by each invocation
+		audit, err := openAuditLog(path)
+		require.NoError(t, err)
+		defer audit.close()
+
+		exporter := audit.metricExporter(unavailableMetricExporter{}, auditBundleDestination, "")
+		require.Error(t, exporter.Export(context.Background(), testResourceMetrics()))
+	})
+
+	records := readAuditRecords(t, path)
+	require.Len(t, records, 3)
+
+	exported := records[0]
+	require.Equal(t, "audited", exported.Service)
+	require.Equal(t, "traces", exported.Signal)
+	require.Equal(t, "collector:4317", exported.Destination)
+	require.Equal(t, otlpProtocolGRPC, exported.Protocol)
+	require.Equal(t, auditExported, exported.Outcome)
+	require.Equal(t, 2, exported.Spans)
+	require.Equal(t, []string{hex.EncodeToString(make([]byte, 16))}, exported.TraceIDs)
+	require.Len(t, exported.PayloadSHA256, sha256.Size*2)
+	require.False(t, exported.Time.IsZero())
+
+	failed := records[1]
+	require.Equal(t, auditFailed, failed.Outcome)
+	require.Equal(t, "connection refused", failed.Error)
+	require.Equal(t, exported.PayloadSHA256, failed.PayloadSHA256)
+
+	metrics := records[2]
+	require.Equal(t, "metrics", metrics.Signal)
+	require.Equal(t, auditBundleDestination, metrics.Destination)
+	require.Positive(t, metrics.Metrics)
+}
+
+func TestOtlpDestination(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+
+	require.Equal(t, "localhost:4317", otlpDestination(signalTraces, otlpSettings{}, otlpProtocolGRPC))
+	require.Equal(t, "localhost:4318", otlpDestination(signalTraces, otlpSettings{}, otlpProtocolHTTP))
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "https://collector:4318")
+	require.Equal(t, "https://collector:4318", otlpDestination(signalTraces, otlpSettings{}, otlpProtocolHTTP))
+
+	require.Equal(t, "unix:///run/otelcol.sock", otlpDestination(signalTraces, otlpSettings{endpoint: "unix:///run/otelcol.sock"}, otlpProtocolHTTP))
+}
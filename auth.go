@@ -0,0 +1,232 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// the schemes of the Authorization header of the requests to the server
+const (
+	authSchemeBearer = "Bearer"
+	authSchemeHMAC   = "HMAC-SHA256"
+)
+
+// authTimestampHeader the header with the Unix time of the signed requests, included in the signature
+const authTimestampHeader = "X-Junit2otlp-Timestamp"
+
+// maxSignatureAge the maximum difference between the timestamp of a signed request and the time of the server,
+// so a captured request cannot be replayed later
+const maxSignatureAge = 5 * time.Minute
+
+var (
+	errUnauthorized = errors.New("unauthorized")
+	errForbidden    = errors.New("forbidden")
+)
+
+// authConfig the API tokens accepted by the server mode. If there are no tokens, the requests are not authenticated
+type authConfig struct {
+	Tokens []*apiToken `yaml:"tokens"`
+}
+
+// apiToken a client of the server: a bearer token, or a secret to sign the requests with HMAC-SHA256, read from
+// the configuration file or from an environment variable, the tenants it can send reports to, besides the default
+// one, and the attributes added to the spans of its reports, i.e. the team or the project
+type apiToken struct {
+	Name       string            `yaml:"name"`
+	Token      string            `yaml:"token"`
+	TokenEnv   string            `yaml:"token_env"`
+	Secret     string            `yaml:"secret"`
+	SecretEnv  string            `yaml:"secret_env"`
+	Tenants    []string          `yaml:"tenants"`
+	Attributes map[string]string `yaml:"attributes"`
+}
+
+// validate validates the tokens, whose tenants must be the ones of the tenancy section
+func (a *authConfig) validate(tenancy *tenancyConfig) error {
+	names := map[string]bool{}
+	for i, token := range a.Tokens {
+		if token == nil || token.Name == "" {
+			return fmt.Errorf("the token %d has no name", i)
+		}
+
+		if names[token.Name] {
+			return fmt.Errorf("duplicated token %q", token.Name)
+		}
+		names[token.Name] = true
+
+		if token.TokenEnv != "" {
+			token.Token = os.Getenv(token.TokenEnv)
+		}
+		if token.SecretEnv != "" {
+			token.Secret = os.Getenv(token.SecretEnv)
+		}
+
+		if (token.Token == "") == (token.Secret == "") {
+			return fmt.Errorf("the token %q must have either a bearer token or a secret", token.Name)
+		}
+
+		for _, tenant := range token.Tenants {
+			if _, ok := tenancy.Tenants[tenant]; !ok {
+				return fmt.Errorf("unknown tenant %q of the token %q", tenant, token.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (a *authConfig) enabled() bool {
+	return len(a.Tokens) > 0
+}
+
+// authenticate returns the token of the request to the tenant, from its Authorization header: either a bearer
+// token, or the name of the token and the hex encoded HMAC-SHA256 signature of the request, i.e.
+// "HMAC-SHA256 ci:5d41...". The comparisons take constant time. The tokens not allowed to send reports to the
+// tenant are forbidden
+func (a *authConfig) authenticate(header http.Header, tenant string, attributes map[string]string, body []byte, now time.Time) (*apiToken, error) {
+	auth, err := a.authenticateHeader(header, tenant, attributes, now)
+	if err != nil {
+		return nil, err
+	}
+
+	auth.Write(body)
+	return auth.verify()
+}
+
+// authenticateHeader checks the Authorization header of the request before its body is read, so the clients
+// without credentials cannot make the server buffer their reports: the bearer tokens are verified at once, and the
+// signed requests once their body, written to the returned requestAuth while it's read, is complete
+func (a *authConfig) authenticateHeader(header http.Header, tenant string, attributes map[string]string, now time.Time) (*requestAuth, error) {
+	auth, err := a.credentials(header, tenant, attributes, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if auth.mac == nil {
+		if _, err := auth.verify(); err != nil {
+			return nil, err
+		}
+	}
+
+	return auth, nil
+}
+
+// credentials returns the token of the credentials of the Authorization header, with the MAC of the signed requests
+func (a *authConfig) credentials(header http.Header, tenant string, attributes map[string]string, now time.Time) (*requestAuth, error) {
+	scheme, credentials, _ := strings.Cut(header.Get("Authorization"), " ")
+	credentials = strings.TrimSpace(credentials)
+
+	switch {
+	case strings.EqualFold(scheme, authSchemeBearer):
+		for _, token := range a.Tokens {
+			if token.Token != "" && subtle.ConstantTimeCompare([]byte(token.Token), []byte(credentials)) == 1 {
+				return &requestAuth{token: token, tenant: tenant}, nil
+			}
+		}
+
+		return nil, fmt.Errorf("%w: invalid bearer token", errUnauthorized)
+	case strings.EqualFold(scheme, authSchemeHMAC):
+		name, signature, _ := strings.Cut(credentials, ":")
+		index := slices.IndexFunc(a.Tokens, func(token *apiToken) bool { return token.Name == name && token.Secret != "" })
+		if index < 0 {
+			return nil, fmt.Errorf("%w: unknown token %q", errUnauthorized, name)
+		}
+		token := a.Tokens[index]
+
+		timestamp := header.Get(authTimestampHeader)
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid %s header %q", errUnauthorized, authTimestampHeader, timestamp)
+		}
+
+		if age := now.Sub(time.Unix(seconds, 0)); age > maxSignatureAge || age < -maxSignatureAge {
+			return nil, fmt.Errorf("%w: the signature is older than %s", errUnauthorized, maxSignatureAge)
+		}
+
+		mac := newReportMAC(token.Secret, timestamp, tenant, attributes)
+		return &requestAuth{token: token, tenant: tenant, mac: mac, signature: strings.ToLower(signature)}, nil
+	default:
+		return nil, fmt.Errorf("%w: the %s or %s Authorization header is missing", errUnauthorized, authSchemeBearer, authSchemeHMAC)
+	}
+}
+
+// requestAuth the credentials of a request to the tenant. The body of the signed requests is written to their MAC
+// while it's read, so it's not kept to verify the signature
+type requestAuth struct {
+	token     *apiToken
+	tenant    string
+	mac       hash.Hash
+	signature string
+}
+
+// Write writes the body of the request to the MAC of its signature, if it's signed
+func (r *requestAuth) Write(p []byte) (int, error) {
+	if r.mac == nil {
+		return len(p), nil
+	}
+
+	return r.mac.Write(p)
+}
+
+// verify returns the token of the request once its body is read, if its signature is the one of the body and
+// the token can send reports to the tenant
+func (r *requestAuth) verify() (*apiToken, error) {
+	if r.mac != nil && !hmac.Equal([]byte(hex.EncodeToString(r.mac.Sum(nil))), []byte(r.signature)) {
+		return nil, fmt.Errorf("%w: invalid signature", errUnauthorized)
+	}
+
+	if r.tenant != "" && !slices.Contains(r.token.Tenants, r.tenant) {
+		return nil, fmt.Errorf("%w: the token %q cannot send reports to the tenant %q", errForbidden, r.token.Name, r.tenant)
+	}
+
+	return r.token, nil
+}
+
+// signReport returns the hex encoded HMAC-SHA256 signature of the report, with the timestamp, the tenant and the
+// attributes of the request, so a captured request cannot be sent to another tenant or with other attributes.
+// The signed string is the timestamp, the tenant, the attributes URL encoded and sorted by name, i.e.
+// "project=web&team=checkout", and the body, separated by newlines
+func signReport(secret string, timestamp string, tenant string, attributes map[string]string, body []byte) string {
+	mac := newReportMAC(secret, timestamp, tenant, attributes)
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newReportMAC returns the MAC of the signature of a report, with everything but its body already written
+func newReportMAC(secret string, timestamp string, tenant string, attributes map[string]string) hash.Hash {
+	values := url.Values{}
+	for name, value := range attributes {
+		values.Set(name, value)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + tenant + "\n" + values.Encode() + "\n"))
+
+	return mac
+}
+
+// tokenAttributes returns the attributes of the token, sorted by name
+func tokenAttributes(attributes map[string]string) []attribute.KeyValue {
+	result := make([]attribute.KeyValue, 0, len(attributes))
+	for _, name := range slices.Sorted(maps.Keys(attributes)) {
+		result = append(result, attribute.Key(name).String(attributes[name]))
+	}
+
+	return result
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestAuthConfig(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	body := []byte(`<testsuite name="a"/>`)
+
+	t.Setenv("JUNIT2OTLP_TEST_SECRET", "s3cr3t")
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+tenancy:
+  header: X-Team
+  tenants:
+    checkout: {}
+    payments: {}
+auth:
+  tokens:
+    - name: checkout
+      token: abc123
+      tenants: [checkout]
+      attributes:
+        team: checkout
+        project: web
+    - name: ci
+      secret_env: JUNIT2OTLP_TEST_SECRET
+      tenants: [checkout]
+`), 0o644))
+
+	cfg, err := loadConfig(path)
+	require.NoError(t, err)
+	require.True(t, cfg.Auth.enabled())
+
+	attributes := map[string]string{"project": "web", "team": "checkout"}
+
+	signed := func(name string, secret string, timestamp time.Time) http.Header {
+		ts := strconv.FormatInt(timestamp.Unix(), 10)
+		header := http.Header{}
+		header.Set("Authorization", authSchemeHMAC+" "+name+":"+signReport(secret, ts, "checkout", attributes, body))
+		header.Set(authTimestampHeader, ts)
+		return header
+	}
+
+	t.Run("Bearer token", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("Authorization", "Bearer abc123")
+
+		token, err := cfg.Auth.authenticate(header, "", nil, body, now)
+		require.NoError(t, err)
+		require.Equal(t, "checkout", token.Name)
+		require.Equal(t, []attribute.KeyValue{
+			attribute.String("project", "web"),
+			attribute.String("team", "checkout"),
+		}, tokenAttributes(token.Attributes))
+
+		token, err = cfg.Auth.authenticate(header, "checkout", nil, body, now)
+		require.NoError(t, err)
+		require.Equal(t, "checkout", token.Name)
+
+		_, err = cfg.Auth.authenticate(header, "payments", nil, body, now)
+		require.ErrorIs(t, err, errForbidden)
+
+		header.Set("Authorization", "Bearer abc")
+		_, err = cfg.Auth.authenticate(header, "", nil, body, now)
+		require.ErrorIs(t, err, errUnauthorized)
+	})
+
+	t.Run("Signed request", func(t *testing.T) {
+		token, err := cfg.Auth.authenticate(signed("ci", "s3cr3t", now.Add(-time.Minute)), "checkout", attributes, body, now)
+		require.NoError(t, err)
+		require.Equal(t, "ci", token.Name)
+
+		_, err = cfg.Auth.authenticate(signed("ci", "other", now), "checkout", attributes, body, now)
+		require.ErrorContains(t, err, "invalid signature")
+
+		_, err = cfg.Auth.authenticate(signed("ci", "s3cr3t", now.Add(-10*time.Minute)), "checkout", attributes, body, now)
+		require.ErrorContains(t, err, "the signature is older than 5m0s")
+
+		// the bearer tokens cannot sign the requests
+		_, err = cfg.Auth.authenticate(signed("checkout", "abc123", now), "checkout", attributes, body, now)
+		require.ErrorContains(t, err, `unknown token "checkout"`)
+
+		_, err = cfg.Auth.authenticate(signed("ci", "s3cr3t", now), "checkout", attributes, []byte(`<testsuite name="b"/>`), now)
+		require.ErrorContains(t, err, "invalid signature")
+
+		// a captured request cannot be replayed to another tenant or with other attributes
+		_, err = cfg.Auth.authenticate(signed("ci", "s3cr3t", now), "payments", attributes, body, now)
+		require.ErrorContains(t, err, "invalid signature")
+
+		_, err = cfg.Auth.authenticate(signed("ci", "s3cr3t", now), "checkout", map[string]string{"project": "web", "team": "payments"}, body, now)
+		require.ErrorContains(t, err, "invalid signature")
+
+		_, err = cfg.Auth.authenticate(signed("ci", "s3cr3t", now), "checkout", nil, body, now)
+		require.ErrorContains(t, err, "invalid signature")
+	})
+
+	t.Run("Tenant not allowed", func(t *testing.T) {
+		ts := strconv.FormatInt(now.Unix(), 10)
+		header := http.Header{}
+		header.Set("Authorization", authSchemeHMAC+" ci:"+signReport("s3cr3t", ts, "payments", nil, body))
+		header.Set(authTimestampHeader, ts)
+
+		_, err := cfg.Auth.authenticate(header, "payments", nil, body, now)
+		require.ErrorIs(t, err, errForbidden)
+		require.ErrorContains(t, err, `the token "ci" cannot send reports to the tenant "payments"`)
+	})
+
+	t.Run("Missing credentials", func(t *testing.T) {
+		_, err := cfg.Auth.authenticate(http.Header{}, "", nil, body, now)
+		require.ErrorIs(t, err, errUnauthorized)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, tokens := range []string{
+			`[{token: abc}]`,
+			`[{name: a, token: abc}, {name: a, token: def}]`,
+			`[{name: a}]`,
+			`[{name: a, token: abc, secret: def}]`,
+			`[{name: a, token_env: JUNIT2OTLP_TEST_UNSET}]`,
+			`[{name: a, token: abc, tenants: [payments]}]`,
+		} {
+			require.NoError(t, os.WriteFile(path, []byte("auth:\n  tokens: "+tokens), 0o644))
+			_, err := loadConfig(path)
+			require.ErrorContains(t, err, "invalid auth in the config file", tokens)
+		}
+	})
+}
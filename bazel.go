@@ -0,0 +1,443 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joshdk/go-junit"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const reportFormatBazel = "bazel"
+
+// bazelTestLogsDirs the directories where Bazel writes the outputs of the tests, i.e. the test.xml reports: the
+// bazel-testlogs symlink in the workspace, and the testlogs directory of each configuration in bazel-out
+var bazelTestLogsDirs = []string{"bazel-testlogs", "testlogs"}
+
+// bazelRunDirRegex the directories of the shards and the runs of the tests, i.e. shard_1_of_4 and run_2_of_3
+var bazelRunDirRegex = regexp.MustCompile(`^(shard|run)_(\d+)_of_(\d+)$`)
+
+// bepInt an int64 of the Build Event Protocol, encoded as a string in JSON, or as a number by the older versions
+type bepInt int64
+
+func (i *bepInt) UnmarshalJSON(data []byte) error {
+	value, err := strconv.ParseInt(strings.Trim(string(data), `"`), 10, 64)
+	if err != nil {
+		return err
+	}
+
+	*i = bepInt(value)
+	return nil
+}
+
+// bepEvent an event of the Build Event Protocol, written by bazel with --build_event_json_file, one JSON object
+// per line. Only the events of the build and the tests are decoded
+type bepEvent struct {
+	ID struct {
+		Started         *struct{}    `json:"started"`
+		TestResult      *bepTestID   `json:"testResult"`
+		TestSummary     *bepTestID   `json:"testSummary"`
+		TargetCompleted *bepTargetID `json:"targetCompleted"`
+	} `json:"id"`
+	Started *struct {
+		UUID             string `json:"uuid"`
+		StartTimeMillis  bepInt `json:"startTimeMillis"`
+		StartTime        string `json:"startTime"`
+		BuildToolVersion string `json:"buildToolVersion"`
+		Command          string `json:"command"`
+	} `json:"started"`
+	TestResult *struct {
+		Status                      string `json:"status"`
+		StatusDetails               string `json:"statusDetails"`
+		CachedLocally               bool   `json:"cachedLocally"`
+		TestAttemptStartMillisEpoch bepInt `json:"testAttemptStartMillisEpoch"`
+		TestAttemptStart            string `json:"testAttemptStart"`
+		TestAttemptDurationMillis   bepInt `json:"testAttemptDurationMillis"`
+		TestAttemptDuration         string `json:"testAttemptDuration"`
+		TestActionOutput            []struct {
+			Name string `json:"name"`
+			URI  string `json:"uri"`
+		} `json:"testActionOutput"`
+		ExecutionInfo struct {
+			Strategy       string `json:"strategy"`
+			CachedRemotely bool   `json:"cachedRemotely"`
+			Hostname       string `json:"hostname"`
+		} `json:"executionInfo"`
+	} `json:"testResult"`
+	TestSummary *struct {
+		OverallStatus  string `json:"overallStatus"`
+		ShardCount     int    `json:"shardCount"`
+		TotalNumCached int    `json:"totalNumCached"`
+	} `json:"testSummary"`
+	Completed *struct {
+		Success bool `json:"success"`
+	} `json:"completed"`
+	Aborted *struct {
+		Reason string `json:"reason"`
+	} `json:"aborted"`
+	Finished *struct {
+		ExitCode struct {
+			Name string `json:"name"`
+			Code int    `json:"code"`
+		} `json:"exitCode"`
+		FinishTimeMillis bepInt `json:"finishTimeMillis"`
+		FinishTime       string `json:"finishTime"`
+	} `json:"finished"`
+	BuildMetrics *struct {
+		ActionSummary struct {
+			ActionsExecuted bepInt `json:"actionsExecuted"`
+		} `json:"actionSummary"`
+	} `json:"buildMetrics"`
+}
+
+type bepTestID struct {
+	Label   string `json:"label"`
+	Run     int    `json:"run"`
+	Shard   int    `json:"shard"`
+	Attempt int    `json:"attempt"`
+}
+
+type bepTargetID struct {
+	Label string `json:"label"`
+}
+
+// bazelTarget the results of the attempts of a test target, by run and shard
+type bazelTarget struct {
+	label    string
+	results  []*bepEvent
+	summary  *bepEvent
+	attempts map[[2]int]int
+}
+
+// isBazelEventStream checks if the first JSON object of the data is an event of the Build Event Protocol
+func isBazelEventStream(data []byte) bool {
+	event := map[string]json.RawMessage{}
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(&event); err != nil {
+		return false
+	}
+
+	id := map[string]json.RawMessage{}
+	_, hasChildren := event["children"]
+	return json.Unmarshal(event["id"], &id) == nil && len(id) == 1 && (hasChildren || event["lastMessage"] != nil)
+}
+
+// parseBazelEvents converts the events of the Build Event Protocol to jUnit suites, one per test target, with the
+// test cases of the test.xml report of its last attempt of each run and shard, if it can be read, or a test case
+// for the target otherwise. The labels of the targets, their shards and attempts are kept as properties, and the
+// invocation, the command, the exit code and the targets of the build are the attributes of the run
+func parseBazelEvents(_ string, data []byte) (*junitReport, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+
+	report := &junitReport{framework: &testFramework{Name: reportFormatBazel}}
+	targets := []*bazelTarget{}
+	byLabel := map[string]*bazelTarget{}
+	target := func(label string) *bazelTarget {
+		if t, ok := byLabel[label]; ok {
+			return t
+		}
+
+		t := &bazelTarget{label: label, attempts: map[[2]int]int{}}
+		byLabel[label] = t
+		targets = append(targets, t)
+		return t
+	}
+
+	var built, failed int
+	var start, finish time.Time
+	for {
+		event := &bepEvent{}
+		err := decoder.Decode(event)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, jsonParseError("Bazel", data, err)
+		}
+
+		switch {
+		case event.Started != nil:
+			report.framework.Version = event.Started.BuildToolVersion
+			report.attributes = append(report.attributes,
+				attribute.Key(BazelInvocationID).String(event.Started.UUID),
+				attribute.Key(BazelCommand).String(event.Started.Command),
+			)
+			start = bepTime(event.Started.StartTimeMillis, event.Started.StartTime)
+		case event.ID.TestResult != nil && event.TestResult != nil:
+			t := target(event.ID.TestResult.Label)
+			t.results = append(t.results, event)
+			key := [2]int{event.ID.TestResult.Run, event.ID.TestResult.Shard}
+			t.attempts[key] = max(t.attempts[key], event.ID.TestResult.Attempt)
+		case event.ID.TestSummary != nil && event.TestSummary != nil:
+			target(event.ID.TestSummary.Label).summary = event
+		case event.ID.TargetCompleted != nil && (event.Completed != nil || event.Aborted != nil):
+			if event.Completed != nil && event.Completed.Success {
+				built++
+			} else {
+				failed++
+			}
+		case event.Finished != nil:
+			report.attributes = append(report.attributes, attribute.Key(BazelExitCode).String(event.Finished.ExitCode.Name))
+			finish = bepTime(event.Finished.FinishTimeMillis, event.Finished.FinishTime)
+		case event.BuildMetrics != nil:
+			report.attributes = append(report.attributes, attribute.Key(BazelActionsExecuted).Int64(int64(event.BuildMetrics.ActionSummary.ActionsExecuted)))
+		}
+	}
+
+	report.attributes = append(report.attributes,
+		attribute.Key(BazelTargetsBuilt).Int(built),
+		attribute.Key(BazelTargetsFailed).Int(failed),
+	)
+	if !start.IsZero() && !finish.IsZero() {
+		report.attributes = append(report.attributes, attribute.Key(BazelBuildDuration).Int64(finish.Sub(start).Milliseconds()))
+	}
+
+	for _, t := range targets {
+		report.suites = append(report.suites, bazelSuite(t))
+	}
+
+	return report, nil
+}
+
+// bazelSuite returns the suite of the test target, with the test cases of the last attempt of each run and shard
+func bazelSuite(t *bazelTarget) junit.Suite {
+	suite := junit.Suite{
+		Name:       t.label,
+		Package:    bazelPackage(t.label),
+		Properties: map[string]string{BazelTargetLabel: t.label},
+	}
+
+	if t.summary != nil {
+		suite.Properties[BazelTestStatus] = t.summary.TestSummary.OverallStatus
+		suite.Properties[BazelTestShards] = strconv.Itoa(max(t.summary.TestSummary.ShardCount, 1))
+		suite.Properties[BazelTestCached] = strconv.FormatBool(t.summary.TestSummary.TotalNumCached > 0)
+	}
+
+	var first time.Time
+	for _, event := range t.results {
+		id, result := event.ID.TestResult, event.TestResult
+		if start := bepTime(result.TestAttemptStartMillisEpoch, result.TestAttemptStart); !start.IsZero() && (first.IsZero() || start.Before(first)) {
+			first = start
+		}
+
+		if id.Attempt != t.attempts[[2]int{id.Run, id.Shard}] {
+			continue
+		}
+
+		properties := map[string]string{
+			BazelTargetLabel: t.label,
+			BazelTestRun:     strconv.Itoa(id.Run),
+			BazelTestShard:   strconv.Itoa(id.Shard),
+			TestRetries:      strconv.Itoa(max(id.Attempt-1, 0)),
+			BazelTestCached:  strconv.FormatBool(result.CachedLocally || result.ExecutionInfo.CachedRemotely),
+		}
+		if result.ExecutionInfo.Strategy != "" {
+			properties[BazelTestStrategy] = result.ExecutionInfo.Strategy
+		}
+		if result.Status == "FLAKY" || (result.Status == "PASSED" && id.Attempt > 1) {
+			properties[TestFlaky] = "true"
+		}
+
+		tests := bazelReportTests(event)
+		if len(tests) == 0 {
+			tests = []junit.Test{bazelTest(t.label, event)}
+		}
+
+		for _, test := range tests {
+			test.Properties = mergeProperties(test.Properties, properties)
+			suite.Tests = append(suite.Tests, test)
+		}
+	}
+
+	if !first.IsZero() {
+		suite.Properties[suiteTimestampAttribute] = first.UTC().Format(time.RFC3339Nano)
+	}
+
+	suite.Aggregate()
+	return suite
+}
+
+// bazelTest returns the test case of the attempt of the target, from its status, when its report can't be read
+func bazelTest(label string, event *bepEvent) junit.Test {
+	result := event.TestResult
+	test := junit.Test{
+		Name:      label,
+		Classname: bazelPackage(label),
+		Duration:  bepDuration(result.TestAttemptDurationMillis, result.TestAttemptDuration),
+		Status:    junit.StatusPassed,
+		Message:   result.StatusDetails,
+	}
+
+	switch result.Status {
+	case "PASSED", "FLAKY":
+	case "NO_STATUS", "TOOL_HALTED_BEFORE_TESTING":
+		test.Status = junit.StatusSkipped
+	case "FAILED_TO_BUILD", "REMOTE_FAILURE", "INCOMPLETE":
+		test.Status = junit.StatusError
+		test.Error = junit.Error{Message: strings.ToLower(result.Status), Body: result.StatusDetails}
+	default:
+		// FAILED and TIMEOUT
+		test.Status = junit.StatusFailed
+		test.Error = junit.Error{Message: strings.ToLower(result.Status), Body: result.StatusDetails}
+	}
+
+	if test.Message == "" && test.Error != nil {
+		test.Message = strings.ToLower(result.Status)
+	}
+
+	return test
+}
+
+// bazelReportTests returns the test cases of the test.xml report of the attempt, if it's a local file that can be
+// read, i.e. not in a remote cache
+func bazelReportTests(event *bepEvent) []junit.Test {
+	for _, output := range event.TestResult.TestActionOutput {
+		if output.Name != "test.xml" {
+			continue
+		}
+
+		uri, err := url.Parse(output.URI)
+		if err != nil || uri.Scheme != "file" {
+			return nil
+		}
+
+		data, err := os.ReadFile(uri.Path)
+		if err != nil {
+			return nil
+		}
+
+		report, err := ingestReportFrom(uri.Path, data)
+		if err != nil {
+			return nil
+		}
+
+		tests := []junit.Test{}
+		for _, suite := range report.suites {
+			tests = append(tests, suiteTests(suite)...)
+		}
+
+		return tests
+	}
+
+	return nil
+}
+
+// suiteTests returns the test cases of the suite and its nested suites
+func suiteTests(suite junit.Suite) []junit.Test {
+	tests := slices.Clone(suite.Tests)
+	for _, nested := range suite.Suites {
+		tests = append(tests, suiteTests(nested)...)
+	}
+
+	return tests
+}
+
+// mergeProperties returns the properties with the extra ones, copying them
+func mergeProperties(properties map[string]string, extra map[string]string) map[string]string {
+	merged := make(map[string]string, len(properties)+len(extra))
+	for k, v := range properties {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+
+	return merged
+}
+
+// bepTime returns the time of the event, from its milliseconds since the epoch, or its timestamp in the newer
+// versions, or the zero time if it has none
+func bepTime(millis bepInt, timestamp string) time.Time {
+	if millis > 0 {
+		return time.UnixMilli(int64(millis))
+	}
+
+	t, _ := time.Parse(time.RFC3339Nano, timestamp)
+	return t
+}
+
+// bepDuration returns the duration of the event, from its milliseconds, or its duration in the newer versions,
+// i.e. "1.500s"
+func bepDuration(millis bepInt, duration string) time.Duration {
+	if millis > 0 {
+		return time.Duration(millis) * time.Millisecond
+	}
+
+	d, _ := time.ParseDuration(duration)
+	return d
+}
+
+// bazelPackage returns the package of the label, i.e. //foo/bar for //foo/bar:baz
+func bazelPackage(label string) string {
+	pkg, _, _ := strings.Cut(label, ":")
+	return pkg
+}
+
+// bazelTargetLabel returns the label of the test target from the path of its test.xml report in the test logs of
+// Bazel, i.e. //foo/bar:baz_test for bazel-testlogs/foo/bar/baz_test/test.xml, with the properties of its shard
+// and run, or an empty label if the path is not in the test logs
+func bazelTargetLabel(path string) (string, map[string]string) {
+	parts := strings.Split(filepath.ToSlash(filepath.Clean(path)), "/")
+
+	root := -1
+	for i, part := range parts[:max(len(parts)-1, 0)] {
+		if slices.Contains(bazelTestLogsDirs, part) {
+			root = i
+		}
+	}
+	if root < 0 {
+		return "", nil
+	}
+
+	properties := map[string]string{}
+	dirs := []string{}
+	for _, part := range parts[root+1 : len(parts)-1] {
+		if match := bazelRunDirRegex.FindStringSubmatch(part); match != nil {
+			key := BazelTestShard
+			if match[1] == "run" {
+				key = BazelTestRun
+			}
+			properties[key] = match[2]
+			continue
+		}
+		if part == "test_attempts" {
+			continue
+		}
+
+		dirs = append(dirs, part)
+	}
+	if len(dirs) == 0 {
+		return "", nil
+	}
+
+	label := fmt.Sprintf("//%s:%s", strings.Join(dirs[:len(dirs)-1], "/"), dirs[len(dirs)-1])
+	properties[BazelTargetLabel] = label
+
+	return label, properties
+}
+
+// addBazelTargetLabel adds the label of the test target to the suites of the report read from the test logs of
+// Bazel, and their test cases
+func addBazelTargetLabel(report *junitReport, path string) {
+	label, properties := bazelTargetLabel(path)
+	if label == "" {
+		return
+	}
+
+	for i := range report.suites {
+		report.suites[i].Properties = mergeProperties(report.suites[i].Properties, properties)
+		for j := range report.suites[i].Tests {
+			report.suites[i].Tests[j].Properties = mergeProperties(report.suites[i].Tests[j].Properties, properties)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestParseBazelEvents(t *testing.T) {
+	testXML := filepath.Join(t.TempDir(), "test.xml")
+	require.NoError(t, os.WriteFile(testXML, []byte(`<testsuites><testsuite name="CartTest">
+		<testcase name="testAdd" classname="CartTest" time="0.2"/>
+		<testcase name="testRemove" classname="CartTest" time="0.3"><failure message="expected 1">at CartTest.java:12</failure></testcase>
+	</testsuite></testsuites>`), 0o644))
+
+	events := strings.Join([]string{
+		`{"id":{"started":{}},"children":[{"pattern":{"pattern":["//..."]}}],"started":{"uuid":"b2f5c3a0","startTimeMillis":"1714557600000","buildToolVersion":"7.1.1","command":"test"}}`,
+		`{"id":{"targetCompleted":{"label":"//lib:lib"}},"completed":{"success":true}}`,
+		`{"id":{"targetCompleted":{"label":"//app:broken"}},"aborted":{"reason":"ANALYSIS_FAILURE"}}`,
+		`{"id":{"testResult":{"label":"//shop:cart_test","run":1,"shard":1,"attempt":1}},"testResult":{"status":"FAILED","testAttemptStartMillisEpoch":"1714557601000","testAttemptDurationMillis":"500","testActionOutput":[{"name":"test.log","uri":"file:///tmp/test.log"},{"name":"test.xml","uri":"file://` + filepath.ToSlash(testXML) + `"}],"executionInfo":{"strategy":"linux-sandbox"}}}`,
+		`{"id":{"testResult":{"label":"//shop:flaky_test","run":1,"shard":1,"attempt":1}},"testResult":{"status":"FAILED","testAttemptStart":"2024-05-01T10:00:02Z","testAttemptDuration":"1.500s","testActionOutput":[{"name":"test.xml","uri":"bytestream://cache/blobs/abc/42"}]}}`,
+		`{"id":{"testResult":{"label":"//shop:flaky_test","run":1,"shard":1,"attempt":2}},"testResult":{"status":"PASSED","testAttemptStart":"2024-05-01T10:00:04Z","testAttemptDuration":"1.200s","executionInfo":{"strategy":"remote","cachedRemotely":true}}}`,
+		`{"id":{"testResult":{"label":"//shop:timeout_test","run":1,"shard":2,"attempt":1}},"testResult":{"status":"TIMEOUT","statusDetails":"timed out after 60s","testAttemptDurationMillis":"60000"}}`,
+		`{"id":{"testSummary":{"label":"//shop:flaky_test"}},"testSummary":{"overallStatus":"FLAKY","totalNumCached":1}}`,
+		`{"id":{"testSummary":{"label":"//shop:timeout_test"}},"testSummary":{"overallStatus":"TIMEOUT","shardCount":2}}`,
+		`{"id":{"buildMetrics":{}},"buildMetrics":{"actionSummary":{"actionsExecuted":"128"}}}`,
+		`{"id":{"buildFinished":{}},"finished":{"exitCode":{"name":"TESTS_FAILED","code":3},"finishTimeMillis":"1714557700000"},"lastMessage":true}`,
+	}, "\n")
+
+	format, err := detectReportFormat([]byte(events))
+	require.NoError(t, err)
+	require.Equal(t, reportFormatBazel, format)
+
+	report, err := parseReport(reportFormatAuto, "", []byte(events))
+	require.NoError(t, err)
+	require.Equal(t, &testFramework{Name: reportFormatBazel, Version: "7.1.1"}, report.framework)
+
+	// the build is described by the attributes of the run
+	require.Contains(t, report.attributes, attribute.Key(BazelInvocationID).String("b2f5c3a0"))
+	require.Contains(t, report.attributes, attribute.Key(BazelCommand).String("test"))
+	require.Contains(t, report.attributes, attribute.Key(BazelExitCode).String("TESTS_FAILED"))
+	require.Contains(t, report.attributes, attribute.Key(BazelActionsExecuted).Int64(128))
+	require.Contains(t, report.attributes, attribute.Key(BazelTargetsBuilt).Int(1))
+	require.Contains(t, report.attributes, attribute.Key(BazelTargetsFailed).Int(1))
+	require.Contains(t, report.attributes, attribute.Key(BazelBuildDuration).Int64(100000))
+
+	require.Len(t, report.suites, 3)
+
+	// the test cases of the local test.xml report
+	cart := report.suites[0]
+	require.Equal(t, "//shop:cart_test", cart.Name)
+	require.Equal(t, "//shop", cart.Package)
+	require.Equal(t, time.UnixMilli(1714557601000).UTC().Format(time.RFC3339Nano), cart.Properties[suiteTimestampAttribute])
+	require.Len(t, cart.Tests, 2)
+	require.Equal(t, "testRemove", cart.Tests[1].Name)
+	require.Equal(t, junit.StatusFailed, cart.Tests[1].Status)
+	require.Equal(t, "//shop:cart_test", cart.Tests[1].Properties[BazelTargetLabel])
+	require.Equal(t, "linux-sandbox", cart.Tests[1].Properties[BazelTestStrategy])
+
+	// the last attempt of the flaky test, as its report is in the remote cache
+	flaky := report.suites[1]
+	require.Equal(t, "FLAKY", flaky.Properties[BazelTestStatus])
+	require.Equal(t, "true", flaky.Properties[BazelTestCached])
+	require.Len(t, flaky.Tests, 1)
+	require.Equal(t, "//shop:flaky_test", flaky.Tests[0].Name)
+	require.Equal(t, junit.StatusPassed, flaky.Tests[0].Status)
+	require.Equal(t, 1200*time.Millisecond, flaky.Tests[0].Duration)
+	require.Equal(t, "1", flaky.Tests[0].Properties[TestRetries])
+	require.Equal(t, "true", flaky.Tests[0].Properties[TestFlaky])
+	require.Equal(t, "true", flaky.Tests[0].Properties[BazelTestCached])
+
+	timeout := report.suites[2]
+	require.Equal(t, "2", timeout.Properties[BazelTestShards])
+	require.Equal(t, junit.StatusFailed, timeout.Tests[0].Status)
+	require.Equal(t, "timed out after 60s", timeout.Tests[0].Message)
+	require.Equal(t, "2", timeout.Tests[0].Properties[BazelTestShard])
+}
+
+func TestBazelTargetLabel(t *testing.T) {
+	tests := []struct {
+		path     string
+		label    string
+		shard    string
+		run      string
+		notFound bool
+	}{
+		{path: "bazel-testlogs/shop/cart/cart_test/test.xml", label: "//shop/cart:cart_test"},
+		{path: "/ws/bazel-testlogs/cart_test/test.xml", label: "//:cart_test"},
+		{path: "bazel-out/k8-fastbuild/testlogs/shop/cart_test/shard_2_of_4/test.xml", label: "//shop:cart_test", shard: "2"},
+		{path: "bazel-testlogs/shop/cart_test/run_3_of_5/test_attempts/attempt_1.xml", label: "//shop:cart_test", run: "3"},
+		{path: "build/test-results/TEST-cart.xml", notFound: true},
+		{path: "bazel-testlogs/test.xml", notFound: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			label, properties := bazelTargetLabel(tt.path)
+			if tt.notFound {
+				require.Empty(t, label)
+				return
+			}
+
+			require.Equal(t, tt.label, label)
+			require.Equal(t, tt.label, properties[BazelTargetLabel])
+			require.Equal(t, tt.shard, properties[BazelTestShard])
+			require.Equal(t, tt.run, properties[BazelTestRun])
+		})
+	}
+
+	t.Run("Test logs", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "bazel-testlogs", "shop", "cart_test")
+		require.NoError(t, os.MkdirAll(dir, 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "test.xml"), []byte(`<testsuites><testsuite name="shop/cart_test"><testcase name="shop/cart_test" status="run" time="1.5"/></testsuite></testsuites>`), 0o644))
+
+		report, err := ingestInputs([]string{filepath.Dir(filepath.Dir(dir))})
+		require.NoError(t, err)
+		require.Len(t, report.suites, 1)
+		require.Equal(t, "//shop:cart_test", report.suites[0].Properties[BazelTargetLabel])
+		require.Equal(t, "//shop:cart_test", report.suites[0].Tests[0].Properties[BazelTargetLabel])
+	})
+}
+
+func TestBazelInvalidEvents(t *testing.T) {
+	_, err := parseBazelEvents("", []byte(`{"id":{"started":{}},"children":[]}`+"\n"+`{"id":`))
+	require.Error(t, err)
+	require.Contains(t, fmt.Sprint(err), "Bazel")
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// benchmarkSizes the number of test cases of the reports of the benchmarks, the largest one being the one of
+// the performance budget
+var benchmarkSizes = []struct {
+	name  string
+	cases int
+}{
+	{"10k", 10_000},
+	{"100k", 100_000},
+	{"1M", 1_000_000},
+}
+
+// syntheticReport returns a jUnit report with the given number of test cases, in suites of 100 test cases, where
+// one in ten test cases fails with a stack trace, and one in ten is skipped, as in the reports of a large monorepo
+func syntheticReport(cases int) []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n<testsuites>\n")
+
+	for suite := 0; suite*100 < cases; suite++ {
+		fmt.Fprintf(buf, `  <testsuite name="com.example.module%d.ServiceTest" tests="100" timestamp="2024-01-01T12:00:00" hostname="runner-%d" time="12.5">`+"\n", suite, suite%8)
+		buf.WriteString(`    <properties><property name="java.version" value="21.0.1"/></properties>` + "\n")
+
+		for test := 0; test < 100 && suite*100+test < cases; test++ {
+			fmt.Fprintf(buf, `    <testcase name="testOperation%d" classname="com.example.module%d.ServiceTest" time="0.125">`, test, suite)
+			switch test % 10 {
+			case 3:
+				fmt.Fprintf(buf, `<failure message="expected:&lt;%d&gt; but was:&lt;0&gt;" type="java.lang.AssertionError">java.lang.AssertionError: expected:&lt;%d&gt; but was:&lt;0&gt;
+	at org.junit.Assert.fail(Assert.java:89)
+	at com.example.module%d.ServiceTest.testOperation%d(ServiceTest.java:%d)</failure>`, test, test, suite, test, 40+test)
+			case 7:
+				buf.WriteString(`<skipped message="disabled"/>`)
+			}
+			buf.WriteString("</testcase>\n")
+		}
+
+		buf.WriteString("  </testsuite>\n")
+	}
+
+	buf.WriteString("</testsuites>\n")
+	return buf.Bytes()
+}
+
+// reportMemory reports the memory obtained from the OS by the benchmark, an approximation of its peak RSS
+func reportMemory(b *testing.B) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	b.ReportMetric(float64(stats.Sys)/(1<<20), "MB-sys")
+}
+
+func BenchmarkParseReport(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		b.Run(size.name, func(b *testing.B) {
+			data := syntheticReport(size.cases)
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				_, err := parseReport(reportFormatJUnit, "", data)
+				require.NoError(b, err)
+			}
+
+			reportMemory(b)
+		})
+	}
+}
+
+// BenchmarkConvert converts the reports into spans and metrics, from the parsing of the report to the batches of
+// the exporters, which discard them
+func BenchmarkConvert(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		b.Run(size.name, func(b *testing.B) {
+			benchmarkConvert(b, size.cases)
+		})
+	}
+}
+
+// BenchmarkConvert10k converts the smallest report, for the budget of the allocations
+func BenchmarkConvert10k(b *testing.B) {
+	benchmarkConvert(b, benchmarkSizes[0].cases)
+}
+
+func benchmarkConvert(b *testing.B, cases int) {
+	defer func(cfg *config) { appConfig = cfg }(appConfig)
+	appConfig = &config{}
+
+	data := syntheticReport(cases)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		ctx := context.Background()
+
+		// the providers are configured as the ones of the tool
+		tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithIDGenerator(assignedIDGenerator{}), sdktrace.WithBatcher(tracetest.NewNoopExporter()))
+		reader := sdkmetric.NewManualReader()
+		meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithView(durationHistogramView(defaultHistogramBuckets)), sdkmetric.WithReader(reader))
+
+		report, err := parseReport(reportFormatJUnit, "", data)
+		require.NoError(b, err)
+		require.NoError(b, createTracesAndSpans(ctx, "benchmark", "", tracerProvider, meterProvider, nil, report))
+
+		require.NoError(b, tracerProvider.Shutdown(ctx))
+		require.NoError(b, reader.Collect(ctx, &metricdata.ResourceMetrics{}))
+		require.NoError(b, meterProvider.Shutdown(ctx))
+	}
+
+	reportMemory(b)
+}
+
+// TestConversionBudget guards the allocations of the conversion of a test case, which drive both the time and the
+// memory of the conversion of the large reports
+func TestConversionBudget(t *testing.T) {
+	const cases = 10_000
+
+	result := testing.Benchmark(BenchmarkConvert10k)
+	require.Less(t, result.AllocedBytesPerOp()/cases, int64(12<<10), "bytes allocated per test case")
+	require.Less(t, result.AllocsPerOp()/cases, int64(64), "allocations per test case")
+}
+
+// the budget of the conversion of the largest report of the benchmarks
+const (
+	budgetTime   = 10 * time.Second
+	budgetMemory = 500
+)
+
+// TestConversionBudget1M checks the budget of the conversion of a report of 1M test cases, in time and in memory
+// obtained from the OS. It takes about a minute, and the memory of the other tests would count, so it only runs
+// alone with make budget
+func TestConversionBudget1M(t *testing.T) {
+	if os.Getenv("JUNIT2OTLP_BUDGET") == "" {
+		t.Skip("the budget of the conversion is checked with make budget")
+	}
+
+	result := testing.Benchmark(func(b *testing.B) {
+		benchmarkConvert(b, benchmarkSizes[2].cases)
+	})
+
+	t.Logf("converted 1M test cases in %s with %.0fMB", time.Duration(result.NsPerOp()), result.Extra["MB-sys"])
+	require.Less(t, time.Duration(result.NsPerOp()), budgetTime, "time of the conversion")
+	require.Less(t, result.Extra["MB-sys"], float64(budgetMemory), "MB obtained from the OS")
+}
+
+// TestReportMemory guards the memory kept by the parsed report for each test case, which is held until the spans
+// of the run are created, and drives the peak RSS of the conversion of the large reports
+func TestReportMemory(t *testing.T) {
+	const cases = 10_000
+
+	data := syntheticReport(cases)
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	report, err := parseReport(reportFormatJUnit, "", data)
+	require.NoError(t, err)
+
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	runtime.KeepAlive(report)
+
+	require.Less(t, int64(after.HeapAlloc-before.HeapAlloc)/cases, int64(1<<10), "bytes kept per test case")
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// the CI systems where the tool annotates the build with the summary of the run
+const (
+	annotateNone      = "none"
+	annotateAuto      = "auto"
+	annotateBuildkite = "buildkite"
+)
+
+// buildkiteAgent the command of the Buildkite agent, which creates the annotations with the token of the job
+const buildkiteAgent = "buildkite-agent"
+
+// runCommand runs the command with the input, returning its combined output on failure. It is replaced in tests
+var runCommand = func(ctx context.Context, stdin io.Reader, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = stdin
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %w: %s", name, err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+func parseAnnotate(annotate string) (string, error) {
+	switch a := strings.ToLower(strings.TrimSpace(annotate)); a {
+	case "":
+		return annotateNone, nil
+	case annotateAuto:
+		// the agent sets this environment variable in the jobs
+		if os.Getenv("BUILDKITE") == "true" {
+			return annotateBuildkite, nil
+		}
+		return annotateNone, nil
+	case annotateNone, annotateBuildkite:
+		return a, nil
+	default:
+		return "", fmt.Errorf("invalid annotate value %q: valid values are %s, %s and %s", annotate, annotateNone, annotateAuto, annotateBuildkite)
+	}
+}
+
+// buildkiteAnnotationWriter annotates the Buildkite build with the Markdown summary of the run, the same as the job
+// summary of GitHub Actions, styled as an error if there are failures
+type buildkiteAnnotationWriter struct {
+	htmlReportWriter
+}
+
+func newBuildkiteAnnotationWriter(traceURL string) *buildkiteAnnotationWriter {
+	return &buildkiteAnnotationWriter{htmlReportWriter{traceURL: traceURL}}
+}
+
+// close creates the annotation, in a context of the service, so that the invocations of the same build for
+// different services create their own annotations, and a retried job replaces the one of its previous attempt
+func (w *buildkiteAnnotationWriter) close(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.rows) == 0 {
+		return nil
+	}
+
+	slices.SortStableFunc(w.rows, func(a, b map[string]any) int {
+		return compareRowValues(a[rowTimestamp], b[rowTimestamp])
+	})
+
+	report := w.report()
+
+	body := &bytes.Buffer{}
+	if err := stepSummaryTemplate.Execute(body, report); err != nil {
+		return fmt.Errorf("failed to render the Buildkite annotation: %w", err)
+	}
+
+	style := "success"
+	if report.Summary.Failed > 0 || report.Summary.Errors > 0 {
+		style = "error"
+	}
+
+	if err := runCommand(ctx, body, buildkiteAgent, "annotate", "--style", style, "--context", annotationContext(report.Title)); err != nil {
+		return fmt.Errorf("failed to annotate the Buildkite build: %w", err)
+	}
+
+	return nil
+}
+
+var annotationContextReplacer = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// annotationContext returns the context of the annotation of the service, i.e. junit2otlp-checkout
+func annotationContext(service string) string {
+	name := strings.Trim(annotationContextReplacer.ReplaceAllString(service, "-"), "-")
+	if name == "" || name == Junit2otlp {
+		return Junit2otlp
+	}
+
+	return Junit2otlp + "-" + name
+}
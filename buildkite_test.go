@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildkiteAnnotationWriter(t *testing.T) {
+	type command struct {
+		name  string
+		args  []string
+		stdin string
+	}
+
+	mockCommand := func(t *testing.T, err error) *[]command {
+		commands := []command{}
+		original := runCommand
+		t.Cleanup(func() { runCommand = original })
+
+		runCommand = func(ctx context.Context, stdin io.Reader, name string, args ...string) error {
+			data, readErr := io.ReadAll(stdin)
+			require.NoError(t, readErr)
+			commands = append(commands, command{name: name, args: args, stdin: string(data)})
+			return err
+		}
+
+		return &commands
+	}
+
+	rows := []map[string]any{
+		{
+			rowTimestamp: "2024-01-01T12:00:00Z", rowTraceID: "0af7651916cd43dd8448eb211c80319c", rowSpanID: "b7ad6b7169203331",
+			rowName: "TestLogin", rowDurationMs: int64(1500), serviceNameColumn: "checkout api",
+			flattenKey(TestsSuiteName): "auth", flattenKey(TestStatus): "passed",
+		},
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		commands := mockCommand(t, nil)
+
+		writer := newBuildkiteAnnotationWriter("https://jaeger.example.com/trace/{trace_id}")
+		require.NoError(t, writer.writeRows(context.Background(), rows))
+		require.NoError(t, writer.close(context.Background()))
+
+		require.Len(t, *commands, 1)
+		require.Equal(t, buildkiteAgent, (*commands)[0].name)
+		require.Equal(t, []string{"annotate", "--style", "success", "--context", "junit2otlp-checkout-api"}, (*commands)[0].args)
+		require.Contains(t, (*commands)[0].stdin, "### ✅ checkout api test results")
+		require.Contains(t, (*commands)[0].stdin, "[View the trace](https://jaeger.example.com/trace/0af7651916cd43dd8448eb211c80319c)")
+	})
+
+	t.Run("Failures", func(t *testing.T) {
+		commands := mockCommand(t, nil)
+
+		failed := map[string]any{rowName: "TestPayment", flattenKey(TestStatus): "failed", flattenKey(TestMessage): "expected 200"}
+		writer := newBuildkiteAnnotationWriter("")
+		require.NoError(t, writer.writeRows(context.Background(), append(rows, failed)))
+		require.NoError(t, writer.close(context.Background()))
+
+		require.Len(t, *commands, 1)
+		require.Equal(t, "error", (*commands)[0].args[2])
+		require.Contains(t, (*commands)[0].stdin, "| failed |  | TestPayment | expected 200 |")
+	})
+
+	t.Run("Without test cases", func(t *testing.T) {
+		commands := mockCommand(t, nil)
+
+		require.NoError(t, newBuildkiteAnnotationWriter("").close(context.Background()))
+		require.Empty(t, *commands)
+	})
+
+	t.Run("Agent failure", func(t *testing.T) {
+		mockCommand(t, errors.New("no access token"))
+
+		writer := newBuildkiteAnnotationWriter("")
+		require.NoError(t, writer.writeRows(context.Background(), rows))
+		require.ErrorContains(t, writer.close(context.Background()), "failed to annotate the Buildkite build: no access token")
+	})
+}
+
+func TestParseAnnotate(t *testing.T) {
+	t.Setenv("BUILDKITE", "")
+
+	annotate, err := parseAnnotate("auto")
+	require.NoError(t, err)
+	require.Equal(t, annotateNone, annotate)
+
+	t.Setenv("BUILDKITE", "true")
+	annotate, err = parseAnnotate("AUTO")
+	require.NoError(t, err)
+	require.Equal(t, annotateBuildkite, annotate)
+
+	annotate, err = parseAnnotate("")
+	require.NoError(t, err)
+	require.Equal(t, annotateNone, annotate)
+
+	_, err = parseAnnotate("gitlab")
+	require.Error(t, err)
+
+	require.Equal(t, "junit2otlp", annotationContext(Junit2otlp))
+	require.Equal(t, "junit2otlp", annotationContext("/"))
+}
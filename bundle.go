@@ -0,0 +1,631 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"aead.dev/minisign"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	packCommand = "pack"
+	pushCommand = "push"
+)
+
+// the entries of a bundle: the manifest with the checksums of the other entries, its minisign signature, the
+// payloads of OTLP, as spooled by the exporters, and the reports they were computed from
+const (
+	bundleManifestName  = "manifest.json"
+	bundleSignatureName = bundleManifestName + ".minisig"
+	bundlePayloadsDir   = "payloads"
+	bundleReportsDir    = "reports"
+)
+
+// bundleVersion the version of the layout of the bundles, refused by the older versions of the tool
+const bundleVersion = 1
+
+// bundlePushedExtension the extension of the record of the payloads of a bundle delivered by the push command, next
+// to the bundle, so pushing it again after a failure only sends the payloads that were not delivered
+const bundlePushedExtension = ".pushed"
+
+// maxBundleEntrySize the maximum size of an entry of a bundle, which is read in memory to verify its checksum
+const maxBundleEntrySize = 256 << 20
+
+// signingKeyPasswordEnvVar the environment variable with the password of the minisign private key. It has no flag, so
+// the password is not visible in the arguments of the processes
+const signingKeyPasswordEnvVar = "JUNIT2OTLP_SIGNING_KEY_PASSWORD"
+
+// bundleManifest the manifest of a bundle, listing its entries with their checksums
+type bundleManifest struct {
+	Version     int          `json:"version"`
+	Created     time.Time    `json:"created"`
+	ToolVersion string       `json:"tool_version"`
+	Service     string       `json:"service"`
+	Files       []bundleFile `json:"files"`
+}
+
+type bundleFile struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+	// Source the path of a report on the packing host, empty for the standard input
+	Source string `json:"source,omitempty"`
+}
+
+// bundleEntry an entry of a bundle, with its content
+type bundleEntry struct {
+	name   string
+	source string
+	data   []byte
+}
+
+// bundle a bundle read and verified by the push command
+type bundle struct {
+	manifest bundleManifest
+	entries  map[string][]byte
+	signed   bool
+}
+
+// bundlePushRecord the payloads of a bundle delivered by the push command, by their checksums, so a bundle packed
+// again at the same path does not match the record of the previous one
+type bundlePushRecord struct {
+	Payloads []string `json:"payloads"`
+}
+
+// recordingReader keeps the report read from the standard input, to pack it with its payloads
+type recordingReader struct {
+	InputReader
+	data []byte
+}
+
+func (r *recordingReader) Read() ([]byte, error) {
+	data, err := r.InputReader.Read()
+	r.data = data
+
+	return data, err
+}
+
+func runPack(args []string) {
+	if err := resolveFlags(flag.CommandLine, args); err != nil {
+		exit(err)
+	}
+
+	if err := packReport(context.Background(), &PipeReader{}, bundleFlag, signingKeyFlag); err != nil {
+		exit(err)
+	}
+}
+
+func runPush(args []string) {
+	if err := resolveFlags(flag.CommandLine, args); err != nil {
+		exit(err)
+	}
+
+	if err := pushBundle(context.Background(), bundleFlag, verifyKeyFlag); err != nil {
+		exit(err)
+	}
+}
+
+// packReport exports the telemetry of the report as the export does, with the attributes of the CI and the SCM of
+// the packing host, but writes the payloads of OTLP to a bundle with the report files instead of sending them. The
+// bundle is written even if the checks of the run fail, as the telemetry of a failing run is exported too
+func packReport(ctx context.Context, reader InputReader, bundlePath string, signingKeyPath string) error {
+	if bundlePath == "" {
+		return errors.New("the path of the bundle is required: set it with --bundle")
+	}
+
+	if !otlpFlag {
+		return errors.New("the pack command bundles the payloads of OTLP: it can't be used with --otlp=false")
+	}
+
+	// loaded before the export, so a wrong password does not waste it
+	signingKey, err := loadSigningKey(signingKeyPath)
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "junit2otlp-pack")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	spool := &exportSpool{dir: dir}
+	recorder := &recordingReader{InputReader: reader}
+
+	var sources []*reportSource
+	exportErr := exportReport(ctx, func() (*junitReport, error) {
+		report, err := readReport(recorder)
+		if err != nil {
+			return nil, err
+		}
+
+		sources = report.sources
+		return report, nil
+	}, withOtlpBundle(spool))
+
+	payloads, err := bundlePayloads(spool)
+	if err != nil {
+		return errors.Join(exportErr, err)
+	}
+
+	if len(payloads) == 0 {
+		if exportErr != nil {
+			return exportErr
+		}
+
+		return errors.New("there are no payloads to pack")
+	}
+
+	reports, err := bundleReports(sources, recorder.data)
+	if err != nil {
+		return errors.Join(exportErr, err)
+	}
+
+	if err := writeBundle(bundlePath, append(payloads, reports...), signingKey); err != nil {
+		return errors.Join(exportErr, err)
+	}
+
+	log.Printf("packed %d payloads and %d reports in %s", len(payloads), len(reports), bundlePath)
+
+	return exportErr
+}
+
+// bundlePayloads returns the payloads written to the spool, traces first, in the order they were written
+func bundlePayloads(spool *exportSpool) ([]bundleEntry, error) {
+	entries := []bundleEntry{}
+	for _, extension := range []string{spoolTracesExtension, spoolMetricsExtension} {
+		files, err := spool.files(extension)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+
+			entries = append(entries, bundleEntry{name: path.Join(bundlePayloadsDir, filepath.Base(file)), data: data})
+		}
+	}
+
+	return entries, nil
+}
+
+// bundleReports returns the reports of the exported suites, read again from their files, which must not have changed
+// since they were exported, or the one read from the standard input
+func bundleReports(sources []*reportSource, stdin []byte) ([]bundleEntry, error) {
+	entries := []bundleEntry{}
+	packed := map[[2]string]bool{}
+
+	for _, source := range sources {
+		if source == nil || packed[[2]string{source.file, source.sha256}] {
+			continue
+		}
+		packed[[2]string{source.file, source.sha256}] = true
+
+		name, data := "stdin", stdin
+		if source.file != "" {
+			var err error
+			if data, err = os.ReadFile(source.file); err != nil {
+				return nil, fmt.Errorf("failed to pack the report %s: %w", source.file, err)
+			}
+			name = filepath.Base(source.file)
+		}
+
+		if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != source.sha256 {
+			return nil, fmt.Errorf("failed to pack the report %s: it changed since it was exported", source.file)
+		}
+
+		// numbered, as the reports of different directories can have the same name
+		name = path.Join(bundleReportsDir, fmt.Sprintf("%03d-%s", len(entries)+1, name))
+		entries = append(entries, bundleEntry{name: name, source: source.file, data: data})
+	}
+
+	return entries, nil
+}
+
+// writeBundle writes the entries to a gzipped tarball, with their manifest, signed if there is a signing key. It's
+// renamed once written, so a partial bundle is never pushed
+func writeBundle(bundlePath string, entries []bundleEntry, signingKey *minisign.PrivateKey) error {
+	manifest := bundleManifest{
+		Version:     bundleVersion,
+		Created:     time.Now().UTC(),
+		ToolVersion: version,
+		Service:     getOtlpServiceName(),
+		Files:       []bundleFile{},
+	}
+
+	for _, entry := range entries {
+		sum := sha256.Sum256(entry.data)
+		manifest.Files = append(manifest.Files, bundleFile{
+			Name:   entry.name,
+			Size:   int64(len(entry.data)),
+			SHA256: hex.EncodeToString(sum[:]),
+			Source: entry.source,
+		})
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	head := []bundleEntry{{name: bundleManifestName, data: data}}
+	if signingKey != nil {
+		head = append(head, bundleEntry{name: bundleSignatureName, data: minisign.Sign(*signingKey, data)})
+	}
+	entries = append(head, entries...)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	for _, entry := range entries {
+		header := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     entry.name,
+			Mode:     0o600,
+			Size:     int64(len(entry.data)),
+			ModTime:  manifest.Created,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(entry.data); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(bundlePath+".tmp", buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to write the bundle: %w", err)
+	}
+
+	return os.Rename(bundlePath+".tmp", bundlePath)
+}
+
+// pushBundle exports the payloads of the bundle, once its checksums and its signature are verified, with the
+// exporters of the pushing host. Its attributes are the ones of the packing host
+func pushBundle(ctx context.Context, bundlePath string, verifyKeyPath string) error {
+	if bundlePath == "" {
+		return errors.New("the path of the bundle is required: set it with --bundle")
+	}
+
+	cfg, err := loadConfig(configFlag)
+	if err != nil {
+		return err
+	}
+	appConfig = cfg
+
+	var publicKey *minisign.PublicKey
+	if verifyKeyPath != "" {
+		key, err := minisign.PublicKeyFromFile(verifyKeyPath)
+		if err != nil {
+			return fmt.Errorf("failed to read the public key: %w", err)
+		}
+		publicKey = &key
+	}
+
+	b, err := readBundle(bundlePath, publicKey)
+	if err != nil {
+		return err
+	}
+
+	if b.signed && publicKey == nil {
+		log.Printf("the signature of the bundle %s is not verified: set the public key with --verify-key", bundlePath)
+	}
+
+	dir, err := os.MkdirTemp("", "junit2otlp-push")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	recordPath := bundlePath + bundlePushedExtension
+	delivered, err := readBundlePushRecord(recordPath)
+	if err != nil {
+		return err
+	}
+
+	// the checksums of the payloads to push, by their file in the spool
+	pending := map[string]string{}
+	for _, file := range b.manifest.Files {
+		if path.Dir(file.Name) != bundlePayloadsDir {
+			continue
+		}
+
+		if !strings.HasSuffix(file.Name, spoolTracesExtension) && !strings.HasSuffix(file.Name, spoolMetricsExtension) {
+			return fmt.Errorf("unknown payload %s in the bundle", file.Name)
+		}
+
+		if delivered[file.SHA256] {
+			continue
+		}
+
+		target := filepath.Join(dir, path.Base(file.Name))
+		if err := os.WriteFile(target, b.entries[file.Name], 0o600); err != nil {
+			return err
+		}
+		pending[target] = file.SHA256
+	}
+
+	if len(pending) == 0 {
+		log.Printf("the payloads of the bundle %s were already pushed, as recorded in %s", bundlePath, recordPath)
+		return nil
+	}
+
+	auditLog, err := openAuditLog(auditLogFlag)
+	if err != nil {
+		return err
+	}
+	defer auditLog.close()
+
+	spool := &exportSpool{dir: dir, audit: auditLog}
+	flushErr := spool.flush(ctx)
+
+	// the payloads sent are removed from the spool, and the invalid ones are set aside
+	remaining := 0
+	for target, sum := range pending {
+		if _, err := os.Stat(target); err == nil {
+			remaining++
+			continue
+		}
+		if _, err := os.Stat(target + invalidSpoolExtension); err == nil {
+			remaining++
+			continue
+		}
+		delivered[sum] = true
+	}
+
+	if err := writeBundlePushRecord(recordPath, delivered); err != nil {
+		return errors.Join(flushErr, err)
+	}
+
+	if flushErr != nil {
+		return fmt.Errorf("failed to push the bundle %s, pushing it again sends the %d payloads not delivered: %w", bundlePath, remaining, flushErr)
+	}
+
+	log.Printf("pushed the bundle %s of %s, packed at %s", bundlePath, b.manifest.Service, b.manifest.Created.Format(time.RFC3339))
+
+	return nil
+}
+
+// readBundlePushRecord returns the checksums of the payloads of the bundle already delivered, if any
+func readBundlePushRecord(recordPath string) (map[string]bool, error) {
+	delivered := map[string]bool{}
+
+	data, err := os.ReadFile(recordPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return delivered, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the payloads already pushed: %w", err)
+	}
+
+	record := bundlePushRecord{}
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("invalid record of the payloads already pushed %s: %w", recordPath, err)
+	}
+
+	for _, sum := range record.Payloads {
+		delivered[sum] = true
+	}
+
+	return delivered, nil
+}
+
+// writeBundlePushRecord writes the checksums of the payloads of the bundle delivered, sorted, with a temporary name
+// so a partial record is never read
+func writeBundlePushRecord(recordPath string, delivered map[string]bool) error {
+	if len(delivered) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(bundlePushRecord{Payloads: slices.Sorted(maps.Keys(delivered))})
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(recordPath+".tmp", data, 0o600); err != nil {
+		return fmt.Errorf("failed to record the payloads pushed: %w", err)
+	}
+
+	return os.Rename(recordPath+".tmp", recordPath)
+}
+
+// bundledExport checks if the options of the exporters write the payloads to a bundle
+func bundledExport(options []otlpOption) bool {
+	settings := otlpSettings{}
+	for _, option := range options {
+		option(&settings)
+	}
+
+	return settings.bundle != nil
+}
+
+// readBundle reads the entries of the bundle, verifying the signature of its manifest with the public key, if any,
+// and the checksums of all of its entries
+func readBundle(bundlePath string, publicKey *minisign.PublicKey) (*bundle, error) {
+	f, err := os.Open(bundlePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the bundle: %w", err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundle %s: %w", bundlePath, err)
+	}
+
+	entries := map[string][]byte{}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid bundle %s: %w", bundlePath, err)
+		}
+
+		switch {
+		case header.Typeflag != tar.TypeReg || !fs.ValidPath(header.Name):
+			return nil, fmt.Errorf("invalid entry %s of the bundle", header.Name)
+		case header.Size > maxBundleEntrySize:
+			return nil, fmt.Errorf("the entry %s of the bundle exceeds %d bytes", header.Name, maxBundleEntrySize)
+		case entries[header.Name] != nil:
+			return nil, fmt.Errorf("duplicated entry %s of the bundle", header.Name)
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bundle %s: %w", bundlePath, err)
+		}
+		entries[header.Name] = data
+	}
+
+	manifestData, ok := entries[bundleManifestName]
+	if !ok {
+		return nil, fmt.Errorf("the bundle %s has no manifest", bundlePath)
+	}
+
+	signature, signed := entries[bundleSignatureName]
+	if publicKey != nil {
+		if !signed {
+			return nil, fmt.Errorf("the bundle %s is not signed", bundlePath)
+		}
+
+		if !minisign.Verify(*publicKey, manifestData, signature) {
+			return nil, fmt.Errorf("invalid signature of the bundle %s", bundlePath)
+		}
+	}
+
+	b := &bundle{entries: map[string][]byte{}, signed: signed}
+	if err := json.Unmarshal(manifestData, &b.manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest of the bundle %s: %w", bundlePath, err)
+	}
+
+	if b.manifest.Version > bundleVersion {
+		return nil, fmt.Errorf("the bundle %s has version %d, which requires a newer version of the tool", bundlePath, b.manifest.Version)
+	}
+
+	for _, file := range b.manifest.Files {
+		data, ok := entries[file.Name]
+		if !ok {
+			return nil, fmt.Errorf("the entry %s of the bundle %s is missing", file.Name, bundlePath)
+		}
+
+		if sum := sha256.Sum256(data); int64(len(data)) != file.Size || hex.EncodeToString(sum[:]) != file.SHA256 {
+			return nil, fmt.Errorf("the checksum of the entry %s of the bundle %s does not match", file.Name, bundlePath)
+		}
+
+		b.entries[file.Name] = data
+	}
+
+	// the entries out of the manifest are neither checksummed nor signed
+	for name := range entries {
+		if _, ok := b.entries[name]; !ok && name != bundleManifestName && name != bundleSignatureName {
+			return nil, fmt.Errorf("the entry %s of the bundle %s is not in its manifest", name, bundlePath)
+		}
+	}
+
+	return b, nil
+}
+
+// loadSigningKey reads the minisign private key, decrypted with the password of its environment variable, if set
+func loadSigningKey(keyPath string) (*minisign.PrivateKey, error) {
+	if keyPath == "" {
+		return nil, nil
+	}
+
+	key, err := minisign.PrivateKeyFromFile(os.Getenv(signingKeyPasswordEnvVar), keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the signing key: %w", err)
+	}
+
+	return &key, nil
+}
+
+// bundleTraceClient writes the requests of the traces to the spool of a bundle, instead of sending them
+type bundleTraceClient struct {
+	spool *exportSpool
+}
+
+func (c *bundleTraceClient) Start(ctx context.Context) error {
+	return nil
+}
+
+func (c *bundleTraceClient) Stop(ctx context.Context) error {
+	return nil
+}
+
+func (c *bundleTraceClient) UploadTraces(ctx context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	data, err := proto.Marshal(&coltracepb.ExportTraceServiceRequest{ResourceSpans: protoSpans})
+	if err != nil {
+		return err
+	}
+
+	return c.spool.write(spoolTracesExtension, data)
+}
+
+// bundleMetricExporter writes the metrics to the spool of a bundle, instead of sending them, with the temporality
+// of the exporter, which is kept when they are pushed
+type bundleMetricExporter struct {
+	spool    *exportSpool
+	selector sdkmetric.TemporalitySelector
+}
+
+func (e *bundleMetricExporter) Temporality(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+	return e.selector(kind)
+}
+
+func (e *bundleMetricExporter) Aggregation(kind sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	return sdkmetric.DefaultAggregationSelector(kind)
+}
+
+func (e *bundleMetricExporter) Export(ctx context.Context, metrics *metricdata.ResourceMetrics) error {
+	data, err := json.Marshal(spoolResourceMetrics(metrics))
+	if err != nil {
+		return err
+	}
+
+	return e.spool.write(spoolMetricsExtension, data)
+}
+
+func (e *bundleMetricExporter) ForceFlush(ctx context.Context) error {
+	return nil
+}
+
+func (e *bundleMetricExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
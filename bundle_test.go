@@ -0,0 +1,299 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"aead.dev/minisign"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// rewriteBundle rewrites the entries of the bundle with the given function, leaving out the ones it returns nil for
+func rewriteBundle(t *testing.T, path string, rewrite func(name string, data []byte) []byte) {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	out := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(out)
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+
+		data = rewrite(header.Name, data)
+		if data == nil {
+			continue
+		}
+
+		header.Size = int64(len(data))
+		require.NoError(t, tw.WriteHeader(header))
+		_, err = tw.Write(data)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tw.Close())
+	require.NoError(t, out.Close())
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+}
+
+func TestBundle(t *testing.T) {
+	publicKey, privateKey, err := minisign.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	otherKey, _, err := minisign.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	entries := []bundleEntry{
+		{name: "payloads/1.traces.otlp", data: []byte("traces")},
+		{name: "payloads/2.metrics.json", data: []byte("{}")},
+		{name: "reports/001-TEST-sample.xml", source: "TEST-sample.xml", data: []byte("<testsuites/>")},
+	}
+
+	writeSigned := func(t *testing.T) string {
+		path := filepath.Join(t.TempDir(), "bundle.tar.gz")
+		require.NoError(t, writeBundle(path, entries, &privateKey))
+
+		return path
+	}
+
+	t.Run("Verified", func(t *testing.T) {
+		b, err := readBundle(writeSigned(t), &publicKey)
+		require.NoError(t, err)
+
+		require.True(t, b.signed)
+		require.Equal(t, bundleVersion, b.manifest.Version)
+		require.Len(t, b.manifest.Files, 3)
+		require.Equal(t, "TEST-sample.xml", b.manifest.Files[2].Source)
+		require.Equal(t, []byte("traces"), b.entries["payloads/1.traces.otlp"])
+	})
+
+	t.Run("Another key", func(t *testing.T) {
+		_, err := readBundle(writeSigned(t), &otherKey)
+		require.ErrorContains(t, err, "invalid signature")
+	})
+
+	t.Run("Unsigned", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "bundle.tar.gz")
+		require.NoError(t, writeBundle(path, entries, nil))
+
+		b, err := readBundle(path, nil)
+		require.NoError(t, err)
+		require.False(t, b.signed)
+
+		_, err = readBundle(path, &publicKey)
+		require.ErrorContains(t, err, "is not signed")
+	})
+
+	t.Run("Tampered entry", func(t *testing.T) {
+		path := writeSigned(t)
+		rewriteBundle(t, path, func(name string, data []byte) []byte {
+			if name == "payloads/1.traces.otlp" {
+				return []byte("forged")
+			}
+			return data
+		})
+
+		_, err := readBundle(path, &publicKey)
+		require.ErrorContains(t, err, "checksum of the entry payloads/1.traces.otlp")
+	})
+
+	t.Run("Tampered manifest", func(t *testing.T) {
+		path := writeSigned(t)
+		rewriteBundle(t, path, func(name string, data []byte) []byte {
+			if name == bundleManifestName {
+				return bytes.Replace(data, []byte("TEST-sample.xml"), []byte("TEST-forged.xml"), 1)
+			}
+			return data
+		})
+
+		_, err := readBundle(path, &publicKey)
+		require.ErrorContains(t, err, "invalid signature")
+	})
+
+	t.Run("Missing entry", func(t *testing.T) {
+		path := writeSigned(t)
+		rewriteBundle(t, path, func(name string, data []byte) []byte {
+			if name == "payloads/2.metrics.json" {
+				return nil
+			}
+			return data
+		})
+
+		_, err := readBundle(path, &publicKey)
+		require.ErrorContains(t, err, "is missing")
+	})
+
+	t.Run("Entry out of the manifest", func(t *testing.T) {
+		b, err := readBundle(writeSigned(t), nil)
+		require.NoError(t, err)
+		manifest, err := json.Marshal(b.manifest)
+		require.NoError(t, err)
+
+		// the manifest of the bundle without the extra entry
+		path := filepath.Join(t.TempDir(), "bundle.tar.gz")
+		require.NoError(t, writeBundle(path, append(entries, bundleEntry{name: "payloads/3.traces.otlp", data: []byte("extra")}), nil))
+		rewriteBundle(t, path, func(name string, data []byte) []byte {
+			if name == bundleManifestName {
+				return manifest
+			}
+			return data
+		})
+
+		_, err = readBundle(path, nil)
+		require.ErrorContains(t, err, "the entry payloads/3.traces.otlp of the bundle")
+	})
+
+	t.Run("Invalid entry name", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "bundle.tar.gz")
+		f, err := os.Create(path)
+		require.NoError(t, err)
+		gz := gzip.NewWriter(f)
+		tw := tar.NewWriter(gz)
+		require.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: "../manifest.json", Size: 2, Mode: 0o600}))
+		_, err = tw.Write([]byte("{}"))
+		require.NoError(t, err)
+		require.NoError(t, tw.Close())
+		require.NoError(t, gz.Close())
+		require.NoError(t, f.Close())
+
+		_, err = readBundle(path, nil)
+		require.ErrorContains(t, err, "invalid entry ../manifest.json")
+	})
+
+	t.Run("Missing signing key", func(t *testing.T) {
+		_, err := loadSigningKey(filepath.Join(t.TempDir(), "missing.key"))
+		require.ErrorContains(t, err, "failed to read the signing key")
+
+		key, err := loadSigningKey("")
+		require.NoError(t, err)
+		require.Nil(t, key)
+	})
+}
+
+func TestPackAndPush(t *testing.T) {
+	defer func(cfg *config, repository string, attrs []attribute.KeyValue, drops *dropLedger, endpoint string, protocol string, spoolDir string) {
+		appConfig, repositoryPathFlag, runtimeAttributes, droppedData, otlpEndpointFlag, otlpProtocolFlag, spoolDirFlag = cfg, repository, attrs, drops, endpoint, protocol, spoolDir
+	}(appConfig, repositoryPathFlag, runtimeAttributes, droppedData, otlpEndpointFlag, otlpProtocolFlag, spoolDirFlag)
+	repositoryPathFlag, droppedData = t.TempDir(), &dropLedger{}
+
+	bundlePath := filepath.Join(t.TempDir(), "bundle.tar.gz")
+
+	// a collector recording the paths of the requests, failing the metrics if told to
+	collector := func(t *testing.T, failMetrics bool) chan string {
+		socket := unixSocket(t)
+		listener, err := net.Listen("unix", socket)
+		require.NoError(t, err)
+
+		paths := make(chan string, 16)
+		server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.Copy(io.Discard, r.Body)
+			paths <- r.URL.Path
+			if failMetrics && r.URL.Path == "/v1/metrics" {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/x-protobuf")
+		})}
+		go server.Serve(listener)
+		t.Cleanup(func() { server.Close() })
+
+		otlpEndpointFlag, otlpProtocolFlag = "unix://"+socket, otlpProtocolHTTP
+		return paths
+	}
+
+	t.Run("Pack", func(t *testing.T) {
+		// the packing host has no collector
+		otlpEndpointFlag, otlpProtocolFlag = "unix://"+filepath.Join(t.TempDir(), "missing.sock"), otlpProtocolHTTP
+		spoolDirFlag = ""
+
+		require.ErrorContains(t, packReport(context.Background(), &TestReader{testFile: "TEST-sample.xml"}, "", ""), "--bundle")
+		require.NoError(t, packReport(context.Background(), &TestReader{testFile: "TEST-sample.xml"}, bundlePath, ""))
+
+		b, err := readBundle(bundlePath, nil)
+		require.NoError(t, err)
+
+		report, err := os.ReadFile("TEST-sample.xml")
+		require.NoError(t, err)
+		require.Equal(t, report, b.entries["reports/001-stdin"])
+
+		traces, metrics := 0, 0
+		for name := range b.entries {
+			switch {
+			case strings.HasSuffix(name, spoolTracesExtension):
+				traces++
+			case strings.HasSuffix(name, spoolMetricsExtension):
+				metrics++
+			}
+		}
+		require.Positive(t, traces)
+		require.Positive(t, metrics)
+	})
+
+	t.Run("Pack with a spool", func(t *testing.T) {
+		// the payloads spooled on the packing host are neither sent nor packed
+		paths := collector(t, false)
+		spoolDirFlag = t.TempDir()
+		spooled := filepath.Join(spoolDirFlag, "00000000000000000001-0000000001"+spoolTracesExtension)
+		require.NoError(t, os.WriteFile(spooled, []byte{}, 0o600))
+
+		path := filepath.Join(t.TempDir(), "bundle.tar.gz")
+		require.NoError(t, packReport(context.Background(), &TestReader{testFile: "TEST-sample.xml"}, path, ""))
+		require.FileExists(t, spooled)
+		require.Empty(t, paths)
+
+		entries, err := os.ReadDir(spoolDirFlag)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		spoolDirFlag = ""
+	})
+
+	t.Run("Push", func(t *testing.T) {
+		// the metrics are not delivered by the first push
+		paths := collector(t, true)
+		require.ErrorContains(t, pushBundle(context.Background(), bundlePath, ""), "pushing it again sends the")
+		require.FileExists(t, bundlePath+bundlePushedExtension)
+
+		sent := []string{}
+		for len(paths) > 0 {
+			sent = append(sent, <-paths)
+		}
+		require.Contains(t, sent, "/v1/traces")
+		require.Equal(t, "/v1/metrics", sent[len(sent)-1])
+
+		// pushing it again only sends the metrics
+		paths = collector(t, false)
+		require.NoError(t, pushBundle(context.Background(), bundlePath, ""))
+		require.Equal(t, "/v1/metrics", <-paths)
+		for len(paths) > 0 {
+			require.Equal(t, "/v1/metrics", <-paths)
+		}
+
+		// and once all of them are delivered, nothing
+		require.NoError(t, pushBundle(context.Background(), bundlePath, ""))
+		require.Empty(t, paths)
+	})
+}
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/exemplar"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// classification the sensitivity of an attribute: the public ones can be shared outside of the organization, the
+// internal ones describe its code, hosts and pipelines, and the sensitive ones can carry personal data or secrets,
+// i.e. the output of the tests or the authors of the changes
+type classification int
+
+const (
+	classificationPublic classification = iota
+	classificationInternal
+	classificationSensitive
+)
+
+// the names of the classifications, as set by --max-classification and the classification section of the config file
+const (
+	classificationPublicName    = "public"
+	classificationInternalName  = "internal"
+	classificationSensitiveName = "sensitive"
+)
+
+// defaultClassification the classification of the attributes with none, i.e. the properties of the reports
+const defaultClassification = classificationInternal
+
+func (c classification) String() string {
+	switch c {
+	case classificationPublic:
+		return classificationPublicName
+	case classificationInternal:
+		return classificationInternalName
+	default:
+		return classificationSensitiveName
+	}
+}
+
+func parseClassification(value string) (classification, error) {
+	switch strings.ToLower(value) {
+	case classificationPublicName:
+		return classificationPublic, nil
+	case classificationInternalName:
+		return classificationInternal, nil
+	case classificationSensitiveName:
+		return classificationSensitive, nil
+	default:
+		return 0, fmt.Errorf("invalid classification %q: must be public, internal or sensitive", value)
+	}
+}
+
+// attributeClassifications the classification of the attributes contributed by the tool, and of the standard ones
+// of the resources and the events. The keys ending with a dot classify the attributes of their namespace, unless
+// a longer key classifies them
+var attributeClassifications = map[string]classification{
+	// the tool, the service and the runtime
+	Junit2otlp + ".":   classificationPublic,
+	ToolVersion:        classificationPublic,
+	"service.":         classificationPublic,
+	"telemetry.":       classificationPublic,
+	"deployment.":      classificationPublic,
+	"os.":              classificationPublic,
+	"device.":          classificationPublic,
+	"host.":            classificationInternal,
+	"host.arch":        classificationPublic,
+	"container.":       classificationInternal,
+	"process.":         classificationInternal,
+	"process.runtime.": classificationPublic,
+	// the user running the tool, and its command line with the flags, which can have credentials
+	"process.owner":        classificationSensitive,
+	"process.command":      classificationSensitive,
+	"process.command_args": classificationSensitive,
+	"process.command_line": classificationSensitive,
+	QueueStorage:           classificationPublic,
+	ServerOutcome:          classificationPublic,
+	ServerTenant:           classificationInternal,
+
+	// the reports
+	"report.":            classificationInternal,
+	ReportFormat:         classificationPublic,
+	ReportInconsistent:   classificationPublic,
+	ReportSourceFormat:   classificationPublic,
+	ReportSourceModified: classificationPublic,
+	ReportSourceSHA256:   classificationPublic,
+	TestFramework:        classificationPublic,
+	TestFrameworkVersion: classificationPublic,
+
+	// the changes and the pipelines
+	"scm.":           classificationInternal,
+	ScmProvider:      classificationPublic,
+	ScmType:          classificationPublic,
+	ScmAuthors:       classificationSensitive,
+	ScmCommitters:    classificationSensitive,
+	"scm.git.":       classificationPublic,
+	GitFileStats:     classificationInternal,
+	GitModifiedFiles: classificationInternal,
+	GitRenamedFiles:  classificationInternal,
+	"cicd.":          classificationInternal,
+	LinkKind:         classificationPublic,
+
+	// the runs and the suites, whose counts and durations are public, unlike their names
+	"tests.":                   classificationPublic,
+	TestsRunName:               classificationInternal,
+	TestsCompareBase:           classificationInternal,
+	TestsCompareFixedTests:     classificationInternal,
+	TestsCompareRegressedTests: classificationInternal,
+	TestsSuiteName:             classificationInternal,
+	TestsSystemOut:             classificationSensitive,
+	TestsSystemErr:             classificationSensitive,
+	FailureCategory:            classificationPublic,
+
+	// the test cases, whose names and code are internal, and whose output and failures are sensitive
+	"tests.case.":         classificationInternal,
+	TestStatus:            classificationPublic,
+	TestDuration:          classificationPublic,
+	TestFlaky:             classificationPublic,
+	TestRetries:           classificationPublic,
+	TestAssertions:        classificationPublic,
+	TestMessage:           classificationSensitive,
+	TestError:             classificationSensitive,
+	TestSystemOut:         classificationSensitive,
+	TestSystemErr:         classificationSensitive,
+	TestReportEntries:     classificationSensitive,
+	"test.":               classificationInternal,
+	TestResult:            classificationPublic,
+	TestTimeout:           classificationPublic,
+	TestCompareResult:     classificationPublic,
+	TestCompareBaseStatus: classificationPublic,
+	TestCaseParameters:    classificationSensitive,
+	"code.":               classificationInternal,
+	"exception.":          classificationSensitive,
+	"exception.type":      classificationInternal,
+	SessionID:             classificationInternal,
+	WebDriverSessionID:    classificationInternal,
+
+	// the dialects of the reports
+	"bazel.":   classificationInternal,
+	"http.":    classificationInternal,
+	HTTPURL:    classificationSensitive,
+	"newman.":  classificationInternal,
+	"rust.":    classificationInternal,
+	"trx.":     classificationInternal,
+	TrxRunUser: classificationSensitive,
+}
+
+// lookupClassification returns the classification of the key, or of its longest namespace, in the classifications
+func lookupClassification(classifications map[string]classification, key string) (classification, bool) {
+	if c, ok := classifications[key]; ok {
+		return c, true
+	}
+
+	for i := len(key) - 1; i > 0; i-- {
+		if key[i] != '.' {
+			continue
+		}
+
+		if c, ok := classifications[key[:i+1]]; ok {
+			return c, true
+		}
+	}
+
+	return 0, false
+}
+
+// classificationConfig the classifications of the attributes set by the config file, i.e. for the properties of the
+// reports, overriding the ones of the tool, and the classification of the attributes without any
+type classificationConfig struct {
+	Default string            `yaml:"default"`
+	Keys    map[string]string `yaml:"keys"`
+
+	defaultClassification classification
+	classifications       map[string]classification
+}
+
+func (c *classificationConfig) compile() error {
+	if c.Default != "" {
+		parsed, err := parseClassification(c.Default)
+		if err != nil {
+			return fmt.Errorf("invalid default: %w", err)
+		}
+		c.defaultClassification = parsed
+	}
+
+	c.classifications = map[string]classification{}
+	for key, value := range c.Keys {
+		parsed, err := parseClassification(value)
+		if err != nil {
+			return fmt.Errorf("invalid classification of %s: %w", key, err)
+		}
+		c.classifications[key] = parsed
+	}
+
+	return nil
+}
+
+// classify returns the classification of the key: the one of the config file, the one of the tool, or the default one
+func (c *classificationConfig) classify(key string) classification {
+	if found, ok := lookupClassification(c.classifications, key); ok {
+		return found
+	}
+
+	if found, ok := lookupClassification(attributeClassifications, key); ok {
+		return found
+	}
+
+	if c.Default == "" {
+		return defaultClassification
+	}
+
+	return c.defaultClassification
+}
+
+// classificationPolicy drops the attributes classified above the maximum classification from the spans, their
+// events and links, the metrics and the resources, before they are exported
+type classificationPolicy struct {
+	max    classification
+	config *classificationConfig
+}
+
+// newClassificationPolicy returns the policy of the maximum classification, or nil if it drops nothing
+func newClassificationPolicy(maxClassification string, cfg *classificationConfig) (*classificationPolicy, error) {
+	parsed, err := parseClassification(maxClassification)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --max-classification: %w", err)
+	}
+
+	if parsed == classificationSensitive {
+		return nil, nil
+	}
+
+	return &classificationPolicy{max: parsed, config: cfg}, nil
+}
+
+// getClassificationPolicy returns the policy of --max-classification, with the classifications of the config file
+func getClassificationPolicy() (*classificationPolicy, error) {
+	return newClassificationPolicy(maxClassificationFlag, &appConfig.Classification)
+}
+
+func (p *classificationPolicy) allows(key attribute.Key) bool {
+	return p.config.classify(string(key)) <= p.max
+}
+
+func (p *classificationPolicy) filter(attrs []attribute.KeyValue) []attribute.KeyValue {
+	filtered := make([]attribute.KeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		if p.allows(attr.Key) {
+			filtered = append(filtered, attr)
+		}
+	}
+
+	return filtered
+}
+
+// resource returns the resource without the attributes above the maximum classification
+func (p *classificationPolicy) resource(res *resource.Resource) *resource.Resource {
+	if p == nil || res == nil {
+		return res
+	}
+
+	return resource.NewWithAttributes(res.SchemaURL(), p.filter(res.Attributes())...)
+}
+
+// spanExporter returns the exporter of the spans without the attributes above the maximum classification, if any
+func (p *classificationPolicy) spanExporter(exporter sdktrace.SpanExporter) sdktrace.SpanExporter {
+	if p == nil {
+		return exporter
+	}
+
+	return &classifiedSpanExporter{SpanExporter: exporter, policy: p}
+}
+
+// view returns the view of the metrics adding the filter of the attributes to the streams of the given view, and to
+// the ones of the instruments it does not match, as an instrument matched by several views has a stream for each one
+func (p *classificationPolicy) view(view sdkmetric.View) sdkmetric.View {
+	if p == nil {
+		return view
+	}
+
+	return func(instrument sdkmetric.Instrument) (sdkmetric.Stream, bool) {
+		stream, ok := view(instrument)
+		if !ok {
+			stream = sdkmetric.Stream{Name: instrument.Name, Description: instrument.Description, Unit: instrument.Unit}
+		}
+
+		filter := stream.AttributeFilter
+		stream.AttributeFilter = func(attr attribute.KeyValue) bool {
+			return (filter == nil || filter(attr)) && p.allows(attr.Key)
+		}
+
+		// the attributes dropped by the filter are kept in the exemplars, so the ones above the maximum
+		// classification are dropped from them too
+		selector := stream.ExemplarReservoirProviderSelector
+		if selector == nil {
+			selector = sdkmetric.DefaultExemplarReservoirProviderSelector
+		}
+		stream.ExemplarReservoirProviderSelector = func(aggregation sdkmetric.Aggregation) exemplar.ReservoirProvider {
+			provider := selector(aggregation)
+			return func(attrs attribute.Set) exemplar.Reservoir {
+				return &classifiedReservoir{Reservoir: provider(attrs), policy: p}
+			}
+		}
+
+		return stream, true
+	}
+}
+
+// classifiedReservoir keeps the exemplars without the attributes above the maximum classification
+type classifiedReservoir struct {
+	exemplar.Reservoir
+	policy *classificationPolicy
+}
+
+func (r *classifiedReservoir) Offer(ctx context.Context, t time.Time, value exemplar.Value, attrs []attribute.KeyValue) {
+	r.Reservoir.Offer(ctx, t, value, r.policy.filter(attrs))
+}
+
+// classifiedSpanExporter exports the spans without the attributes above the maximum classification
+type classifiedSpanExporter struct {
+	sdktrace.SpanExporter
+	policy *classificationPolicy
+}
+
+func (e *classifiedSpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	classified := make([]sdktrace.ReadOnlySpan, 0, len(spans))
+	for _, span := range spans {
+		classified = append(classified, classifiedSpan{ReadOnlySpan: span, policy: e.policy})
+	}
+
+	return e.SpanExporter.ExportSpans(ctx, classified)
+}
+
+// classifiedSpan a span without the attributes above the maximum classification, in its attributes, events, links
+// and resource, nor the data of the attributes kept out of them: the failure message in the description of its
+// status, and the names of the tests and the suites in its name
+type classifiedSpan struct {
+	sdktrace.ReadOnlySpan
+	policy *classificationPolicy
+}
+
+// Name returns a hash of the name of the span when the names of the tests and the suites are not allowed, stable
+// across the runs so the spans of a test are still grouped together
+func (s classifiedSpan) Name() string {
+	name := s.ReadOnlySpan.Name()
+	if s.policy.allows(TestCaseName) && s.policy.allows(TestsSuiteName) {
+		return name
+	}
+
+	return hashName(name, nameHashLength)
+}
+
+// Status returns the status of the span without its description, the failure message of the test, when the
+// failure messages are not allowed
+func (s classifiedSpan) Status() sdktrace.Status {
+	status := s.ReadOnlySpan.Status()
+	if !s.policy.allows(TestMessage) {
+		status.Description = ""
+	}
+
+	return status
+}
+
+func (s classifiedSpan) Attributes() []attribute.KeyValue {
+	return s.policy.filter(s.ReadOnlySpan.Attributes())
+}
+
+func (s classifiedSpan) Events() []sdktrace.Event {
+	events := s.ReadOnlySpan.Events()
+	classified := make([]sdktrace.Event, 0, len(events))
+	for _, event := range events {
+		event.Attributes = s.policy.filter(event.Attributes)
+		classified = append(classified, event)
+	}
+
+	return classified
+}
+
+func (s classifiedSpan) Links() []sdktrace.Link {
+	links := s.ReadOnlySpan.Links()
+	classified := make([]sdktrace.Link, 0, len(links))
+	for _, link := range links {
+		link.Attributes = s.policy.filter(link.Attributes)
+		classified = append(classified, link)
+	}
+
+	return classified
+}
+
+func (s classifiedSpan) Resource() *resource.Resource {
+	return s.policy.resource(s.ReadOnlySpan.Resource())
+}
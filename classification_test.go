@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/exemplar"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestAttributeClassifications(t *testing.T) {
+	t.Run("Every contributed key", func(t *testing.T) {
+		file, err := parser.ParseFile(token.NewFileSet(), "semconv.go", nil, 0)
+		require.NoError(t, err)
+
+		keys := 0
+		ast.Inspect(file, func(node ast.Node) bool {
+			spec, ok := node.(*ast.ValueSpec)
+			if !ok || spec.Names[0].Name == "Junit2otlp" {
+				return true
+			}
+
+			key, err := strconv.Unquote(spec.Values[0].(*ast.BasicLit).Value)
+			require.NoError(t, err)
+
+			_, ok = lookupClassification(attributeClassifications, key)
+			require.True(t, ok, "the key %s is not classified", key)
+			keys++
+
+			return true
+		})
+		require.Positive(t, keys)
+	})
+
+	t.Run("Namespaces", func(t *testing.T) {
+		cfg := &classificationConfig{}
+		require.NoError(t, cfg.compile())
+
+		require.Equal(t, classificationPublic, cfg.classify("service.name"))
+		require.Equal(t, classificationPublic, cfg.classify(TestStatus))
+		require.Equal(t, classificationInternal, cfg.classify(TestClassName))
+		require.Equal(t, classificationInternal, cfg.classify(TestArtifactPrefix+"screenshot"))
+		require.Equal(t, classificationSensitive, cfg.classify(TestSystemOut))
+		require.Equal(t, classificationSensitive, cfg.classify("exception.stacktrace"))
+		require.Equal(t, classificationInternal, cfg.classify("exception.type"))
+		require.Equal(t, classificationPublic, cfg.classify("process.runtime.name"))
+		require.Equal(t, classificationSensitive, cfg.classify("process.owner"))
+		require.Equal(t, classificationInternal, cfg.classify("java.version"))
+	})
+
+	t.Run("Config", func(t *testing.T) {
+		cfg := &classificationConfig{Default: "sensitive", Keys: map[string]string{"java.": "public", TestOwner: "public"}}
+		require.NoError(t, cfg.compile())
+
+		require.Equal(t, classificationPublic, cfg.classify("java.version"))
+		require.Equal(t, classificationPublic, cfg.classify(TestOwner))
+		require.Equal(t, classificationInternal, cfg.classify(TestClassName))
+		require.Equal(t, classificationSensitive, cfg.classify("custom.key"))
+
+		cfg = &classificationConfig{Keys: map[string]string{"java.": "secret"}}
+		require.ErrorContains(t, cfg.compile(), "invalid classification of java.")
+
+		cfg = &classificationConfig{Default: "private"}
+		require.ErrorContains(t, cfg.compile(), "invalid default")
+	})
+}
+
+func TestClassificationPolicy(t *testing.T) {
+	cfg := &classificationConfig{}
+	require.NoError(t, cfg.compile())
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := newClassificationPolicy("confidential", cfg)
+		require.ErrorContains(t, err, "invalid --max-classification")
+	})
+
+	t.Run("Sensitive", func(t *testing.T) {
+		policy, err := newClassificationPolicy(classificationSensitiveName, cfg)
+		require.NoError(t, err)
+		require.Nil(t, policy)
+
+		exporter := tracetest.NewInMemoryExporter()
+		require.Same(t, exporter, policy.spanExporter(exporter))
+	})
+
+	t.Run("Spans", func(t *testing.T) {
+		policy, err := newClassificationPolicy(classificationInternalName, cfg)
+		require.NoError(t, err)
+
+		span := tracetest.SpanStub{
+			Name: "test",
+			Attributes: []attribute.KeyValue{
+				attribute.String(TestClassName, "com.example.CheckoutTest"),
+				attribute.String(TestStatus, "failed"),
+				attribute.String(TestSystemOut, "token=secret"),
+			},
+			Events: []sdktrace.Event{{Name: "exception", Attributes: []attribute.KeyValue{
+				attribute.String("exception.type", "AssertionError"),
+				attribute.String("exception.message", "expected the password"),
+			}}},
+			Links: []sdktrace.Link{{Attributes: []attribute.KeyValue{
+				attribute.String(LinkKind, "deployment"),
+				attribute.String(ScmAuthors, "jane@example.com"),
+			}}},
+			Resource: resource.NewSchemaless(
+				attribute.String("service.name", "checkout"),
+				attribute.String("process.owner", "jane"),
+			),
+		}
+
+		exporter := tracetest.NewInMemoryExporter()
+		require.NoError(t, policy.spanExporter(exporter).ExportSpans(context.Background(), tracetest.SpanStubs{span}.Snapshots()))
+
+		exported := exporter.GetSpans()
+		require.Len(t, exported, 1)
+		require.ElementsMatch(t, []attribute.KeyValue{
+			attribute.String(TestClassName, "com.example.CheckoutTest"),
+			attribute.String(TestStatus, "failed"),
+		}, exported[0].Attributes)
+		require.Equal(t, []attribute.KeyValue{attribute.String("exception.type", "AssertionError")}, exported[0].Events[0].Attributes)
+		require.Equal(t, []attribute.KeyValue{attribute.String(LinkKind, "deployment")}, exported[0].Links[0].Attributes)
+		require.Equal(t, []attribute.KeyValue{attribute.String("service.name", "checkout")}, exported[0].Resource.Attributes())
+	})
+
+	t.Run("Failing test", func(t *testing.T) {
+		suite := junit.Suite{
+			Name: "checkout",
+			Tests: []junit.Test{
+				{Name: "pays with a card", Duration: time.Second, Status: junit.StatusFailed, Message: "expected the password hunter2", Error: junit.Error{Type: "AssertionError", Message: "expected the password hunter2"}},
+			},
+		}
+
+		export := func(t *testing.T, maxClassification string) []tracetest.SpanStub {
+			policy, err := newClassificationPolicy(maxClassification, cfg)
+			require.NoError(t, err)
+
+			exporter := tracetest.NewInMemoryExporter()
+			tracer := sdktrace.NewTracerProvider(sdktrace.WithSyncer(policy.spanExporter(exporter))).Tracer("test")
+			createSuiteSpans(context.Background(), tracer, newTestCaseMetrics(sdkmetric.NewMeterProvider().Meter("test"), 0), suite, nil, nil, nil, time.Time{})
+
+			spans := exporter.GetSpans()
+			require.Len(t, spans, 2)
+
+			return spans
+		}
+
+		spans := export(t, classificationSensitiveName)
+		require.Equal(t, "pays with a card", spans[0].Name)
+		require.Equal(t, "checkout", spans[1].Name)
+		require.Equal(t, sdktrace.Status{Code: codes.Error, Description: "expected the password hunter2"}, spans[0].Status)
+
+		spans = export(t, classificationInternalName)
+		require.Equal(t, "pays with a card", spans[0].Name)
+		require.Equal(t, "checkout", spans[1].Name)
+		require.Equal(t, sdktrace.Status{Code: codes.Error}, spans[0].Status)
+
+		spans = export(t, classificationPublicName)
+		require.Equal(t, hashName("pays with a card", nameHashLength), spans[0].Name)
+		require.Equal(t, hashName("checkout", nameHashLength), spans[1].Name)
+		require.Equal(t, sdktrace.Status{Code: codes.Error}, spans[0].Status)
+	})
+
+	t.Run("Metrics", func(t *testing.T) {
+		policy, err := newClassificationPolicy(classificationPublicName, cfg)
+		require.NoError(t, err)
+
+		reader := sdkmetric.NewManualReader()
+		provider := sdkmetric.NewMeterProvider(
+			sdkmetric.WithReader(reader),
+			sdkmetric.WithView(policy.view(durationHistogramView(defaultHistogramBuckets))),
+			sdkmetric.WithExemplarFilter(exemplar.AlwaysOnFilter),
+		)
+		meter := provider.Meter("test")
+
+		counter, err := meter.Int64Counter(TestsFailuresCount)
+		require.NoError(t, err)
+		counter.Add(context.Background(), 1, metric.WithAttributes(attribute.String(TestStatus, "failed"), attribute.String(TestClassName, "com.example.CheckoutTest")))
+
+		histogram, err := meter.Float64Histogram(TestCaseDurationHistogram)
+		require.NoError(t, err)
+		histogram.Record(context.Background(), 10, metric.WithAttributes(
+			attribute.String(TestStatus, "passed"),
+			attribute.String(TestsSuiteName, "checkout"),
+			attribute.String(TestMessage, "expected the password"),
+			attribute.Bool(TestFlaky, true),
+		))
+
+		rm := metricdata.ResourceMetrics{}
+		require.NoError(t, reader.Collect(context.Background(), &rm))
+		require.Len(t, rm.ScopeMetrics[0].Metrics, 2)
+
+		for _, m := range rm.ScopeMetrics[0].Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				require.Len(t, data.DataPoints, 1)
+				require.Equal(t, attribute.NewSet(attribute.String(TestStatus, "failed")), data.DataPoints[0].Attributes)
+			case metricdata.Histogram[float64]:
+				require.Len(t, data.DataPoints, 1)
+				require.Equal(t, defaultHistogramBuckets, data.DataPoints[0].Bounds)
+				require.Equal(t, attribute.NewSet(attribute.String(TestStatus, "passed")), data.DataPoints[0].Attributes)
+				// the exemplars keep the attributes dropped by the view, but not the ones above the classification
+				require.Len(t, data.DataPoints[0].Exemplars, 1)
+				require.Equal(t, []attribute.KeyValue{attribute.Bool(TestFlaky, true)}, data.DataPoints[0].Exemplars[0].FilteredAttributes)
+			default:
+				require.Failf(t, "unexpected metric", "%s", m.Name)
+			}
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+)
+
+const manCommand = "man"
+
+// flagValues the values of the flags with a fixed set of them, completed by the shells
+var flagValues = map[string]func() []string{
+	"format": supportedReportFormats,
+	"shape":  func() []string { return []string{shapeSpans, shapeEvents} },
+	"anchor": func() []string { return []string{anchorEnd, anchorStart, anchorReportTimestamp} },
+	"merge-policy": func() []string {
+		return []string{mergePolicyKeepSeparate, mergePolicyBySuiteName}
+	},
+	"backend": func() []string { return []string{dashboardBackendGrafana, dashboardBackendSigNoz} },
+	"system-log": func() []string {
+		return []string{systemLogNone, systemLogAuto, systemLogJournald, systemLogEventLog}
+	},
+	"max-classification": func() []string {
+		return []string{classificationPublicName, classificationInternalName, classificationSensitiveName}
+	},
+}
+
+// newRootCommand returns the commands of the tool. The flags are the ones of the standard library, shared by all the
+// commands, so the commands parse their own arguments with them, and their environment variables, as before: cobra
+// only routes the commands, and knows the flags to complete them and to document them in the help and the man pages
+func newRootCommand() *cobra.Command {
+	root := flagCommand(&cobra.Command{
+		Use:   "junit2otlp",
+		Short: "Exports the results of the tests in JUnit reports as OpenTelemetry traces and metrics",
+		Long: "Exports the results of the tests in the JUnit report read from the standard input, or from the --input files, " +
+			"as OpenTelemetry traces and metrics, with a span per run, suite and test case.",
+	}, func(args []string) {
+		if err := resolveFlags(flag.CommandLine, args); err != nil {
+			exit(err)
+		}
+
+		if err := Main(context.Background(), &PipeReader{}); err != nil {
+			exit(err)
+		}
+	})
+	// the positional arguments of the export are ignored, as the flags of the standard library do
+	root.Args = cobra.ArbitraryArgs
+	root.DisableAutoGenTag = true
+	root.PersistentFlags().AddGoFlagSet(flag.CommandLine)
+
+	root.AddCommand(
+		flagCommand(&cobra.Command{Use: pingCommand, Short: "Checks the connectivity with the OTLP endpoints, sending a test span and metric"}, runPing),
+		flagCommand(&cobra.Command{Use: liveCommand, Short: "Exports the span of each test case as soon as its result is received, while the tests run"}, runLive),
+		flagCommand(&cobra.Command{Use: teamcityCommand, Short: "Converts the TeamCity service messages of the build read from the standard input"}, runTeamCity),
+		flagCommand(&cobra.Command{Use: serveCommand, Short: "Receives the reports over HTTP, exporting them one at a time"}, runServe),
+		flagCommand(&cobra.Command{Use: coverageCommand + " [reports...]", Short: "Ingests the per-test coverage reports into the state"}, runCoverage),
+		flagCommand(&cobra.Command{Use: trendsCommand, Short: "Prints the trends of the duration and the pass rate of a test recorded in the state"}, runTrends),
+		flagCommand(&cobra.Command{Use: compareCommand, Short: "Prints the tests whose results differ between the runs of two branches recorded in the state"}, runCompare),
+		flagCommand(&cobra.Command{Use: validateCommand + " [reports...]", Short: "Validates the reports against the JUnit schemas, listing the fields dropped by the tool"}, runValidate),
+		flagCommand(&cobra.Command{Use: dashboardsCommand, Short: "Prints the dashboards of the backend for the telemetry of the tool"}, runDashboards),
+		flagCommand(&cobra.Command{Use: collectorConfigCommand, Short: "Prints the recommended configuration of the OpenTelemetry Collector"}, runCollectorConfig),
+		flagCommand(&cobra.Command{Use: tuiCommand + " reports...", Short: "Explores the reports in the terminal, exporting the selected suites and test cases"}, runTui),
+		flagCommand(&cobra.Command{Use: packCommand, Short: "Writes the OTLP payloads of the report, with the report files, to a signed bundle, for the hosts without egress"}, runPack),
+		flagCommand(&cobra.Command{Use: pushCommand, Short: "Exports the OTLP payloads of a bundle written by the pack command, once its checksums and signature are verified"}, runPush),
+		newManCommand(root),
+	)
+
+	return root
+}
+
+// flagCommand sets the command to parse its arguments with the flags of the standard library, showing its help
+// when they are invalid or -help is given
+func flagCommand(cmd *cobra.Command, run func(args []string)) *cobra.Command {
+	cmd.DisableFlagParsing = true
+	cmd.ValidArgsFunction = completeFlagValues
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		flag.CommandLine.Usage = func() {
+			_ = cmd.Help()
+		}
+
+		run(args)
+	}
+
+	return cmd
+}
+
+// completeFlagValues completes the values of the flags with a fixed set of them, which cobra does not complete for the
+// commands parsing their own flags, and the files otherwise
+func completeFlagValues(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	name, value, inline := strings.Cut(toComplete, "=")
+	if !inline || !strings.HasPrefix(name, "-") {
+		if len(args) == 0 {
+			return nil, cobra.ShellCompDirectiveDefault
+		}
+		name, value = args[len(args)-1], toComplete
+	}
+
+	values, ok := flagValues[strings.TrimLeft(name, "-")]
+	if !ok || !strings.HasPrefix(name, "-") {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+
+	completions := []string{}
+	for _, candidate := range values() {
+		if !strings.HasPrefix(candidate, value) {
+			continue
+		}
+
+		if inline {
+			candidate = name + "=" + candidate
+		}
+		completions = append(completions, candidate)
+	}
+
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// newManCommand returns the command writing the man pages of the commands to a directory
+func newManCommand(root *cobra.Command) *cobra.Command {
+	return &cobra.Command{
+		Use:   manCommand + " directory",
+		Short: "Writes the man pages of the commands to the directory",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := os.MkdirAll(args[0], 0o755); err != nil {
+				return err
+			}
+
+			header := &doc.GenManHeader{Title: "JUNIT2OTLP", Section: "1", Source: "junit2otlp " + version, Manual: "junit2otlp manual"}
+			if err := doc.GenManTree(root, header, args[0]); err != nil {
+				return fmt.Errorf("failed to write the man pages: %w", err)
+			}
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRootCommand(t *testing.T) {
+	execute := func(t *testing.T, args ...string) string {
+		t.Helper()
+		root := newRootCommand()
+		var out bytes.Buffer
+		root.SetOut(&out)
+		root.SetErr(&out)
+		root.SetArgs(args)
+		require.NoError(t, root.Execute())
+		return out.String()
+	}
+
+	t.Run("Routes the commands", func(t *testing.T) {
+		root := newRootCommand()
+		for _, name := range []string{pingCommand, liveCommand, teamcityCommand, serveCommand, coverageCommand, trendsCommand, compareCommand, validateCommand, dashboardsCommand, collectorConfigCommand, tuiCommand, manCommand} {
+			cmd, _, err := root.Find([]string{name, "--otlp=false"})
+			require.NoError(t, err)
+			require.Equal(t, name, cmd.Name())
+		}
+
+		// the flags of the export are not commands
+		cmd, args, err := root.Find([]string{"--otlp=false", "--format", "junit"})
+		require.NoError(t, err)
+		require.Equal(t, root, cmd)
+		require.Equal(t, []string{"--otlp=false", "--format", "junit"}, args)
+	})
+
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		t.Run("Writes the completion script of "+shell, func(t *testing.T) {
+			require.Contains(t, execute(t, "completion", shell), "junit2otlp")
+		})
+	}
+
+	t.Run("Completes the commands and the flags", func(t *testing.T) {
+		out := execute(t, cobra.ShellCompRequestCmd, "val")
+		require.Contains(t, out, "validate\t")
+
+		out = execute(t, cobra.ShellCompRequestCmd, "validate", "--merge-p")
+		require.Contains(t, out, "--merge-policy\t")
+	})
+
+	t.Run("Completes the values of the flags", func(t *testing.T) {
+		out := execute(t, cobra.ShellCompRequestCmd, "--format", "p")
+		require.Equal(t, []string{"playwright", ":4"}, strings.Fields(strings.Split(out, "Completion ended")[0]))
+
+		out = execute(t, cobra.ShellCompRequestCmd, "tui", "--shape=e")
+		require.Contains(t, out, "--shape=events\n")
+
+		// the reports are files
+		out = execute(t, cobra.ShellCompRequestCmd, "tui", "--otlp=false", "")
+		require.Contains(t, out, ":0\n")
+	})
+
+	t.Run("Writes the man pages", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "man")
+		execute(t, manCommand, dir)
+
+		page, err := os.ReadFile(filepath.Join(dir, "junit2otlp-validate.1"))
+		require.NoError(t, err)
+		require.Contains(t, string(page), "junit2otlp-validate - Validates the reports")
+		require.Contains(t, string(page), `\fB--strict\fP`)
+
+		_, err = os.Stat(filepath.Join(dir, "junit2otlp.1"))
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+const collectorConfigCommand = "collector-config"
+
+// collectorConfig the configuration of the OpenTelemetry Collector, with the components by their IDs
+type collectorConfig struct {
+	Receivers  map[string]any   `yaml:"receivers"`
+	Processors map[string]any   `yaml:"processors"`
+	Exporters  map[string]any   `yaml:"exporters"`
+	Service    collectorService `yaml:"service"`
+}
+
+type collectorService struct {
+	Pipelines map[string]collectorPipeline `yaml:"pipelines"`
+}
+
+type collectorPipeline struct {
+	Receivers  []string `yaml:"receivers"`
+	Processors []string `yaml:"processors"`
+	Exporters  []string `yaml:"exporters"`
+}
+
+// collectorAction an action of the attributes processor
+type collectorAction struct {
+	Key    string `yaml:"key"`
+	Action string `yaml:"action"`
+}
+
+// collectorAttribute an attribute matched by the attributes processor, with any value
+type collectorAttribute struct {
+	Key string `yaml:"key"`
+}
+
+// suiteOutputKeys the outputs of the suites, copied to the spans of their test cases
+var suiteOutputKeys = []string{TestsSystemOut, TestsSystemErr}
+
+// suiteMetricKeys the attributes of the suites with a value for each run, which give a series per run to the metrics
+// of the suites, so they are removed from them
+var suiteMetricKeys = []string{TestsSystemOut, TestsSystemErr, TestsDuration, SuiteDeclaredTime, TestsSuiteIdleBefore}
+
+func deleteActions(keys []string) []collectorAction {
+	actions := []collectorAction{}
+	for _, key := range keys {
+		actions = append(actions, collectorAction{Key: key, Action: "delete"})
+	}
+
+	return actions
+}
+
+// newCollectorConfig returns the recommended pipelines of the collector for the output of the tool: the OTLP receiver
+// of its protocol, the attributes processors removing the outputs of the suites from the test spans, which keep
+// their own ones, and the attributes of the suites with a value per run from the metrics, and the exporters of the
+// backend of the dashboards. The delta metrics are converted to cumulative ones for Prometheus
+func newCollectorConfig(protocol string, shape string, temporality string, backend string) (*collectorConfig, error) {
+	cfg := &collectorConfig{
+		Receivers: map[string]any{},
+		Processors: map[string]any{
+			"memory_limiter": map[string]any{"check_interval": "1s", "limit_percentage": 80, "spike_limit_percentage": 20},
+			"batch":          map[string]any{},
+			"attributes/metrics": map[string]any{
+				"actions": deleteActions(suiteMetricKeys),
+			},
+		},
+		Exporters: map[string]any{},
+		Service:   collectorService{Pipelines: map[string]collectorPipeline{}},
+	}
+
+	switch protocol {
+	case otlpProtocolGRPC:
+		cfg.Receivers["otlp"] = map[string]any{"protocols": map[string]any{"grpc": map[string]any{"endpoint": "0.0.0.0:4317"}}}
+	case otlpProtocolHTTP:
+		cfg.Receivers["otlp"] = map[string]any{"protocols": map[string]any{"http": map[string]any{"endpoint": "0.0.0.0:4318"}}}
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol %q: valid values are %s and %s", protocol, otlpProtocolGRPC, otlpProtocolHTTP)
+	}
+
+	traces := collectorPipeline{Receivers: []string{"otlp"}, Processors: []string{"memory_limiter"}}
+	metrics := collectorPipeline{Receivers: []string{"otlp"}, Processors: []string{"memory_limiter", "attributes/metrics"}}
+
+	shape, err := parseShape(shape)
+	if err != nil {
+		return nil, err
+	}
+
+	// the wide events have no suite span keeping the outputs of the suite
+	if shape == shapeSpans {
+		cfg.Processors["attributes/tests"] = map[string]any{
+			"include": map[string]any{"match_type": "strict", "attributes": []collectorAttribute{{Key: TestStatus}}},
+			"actions": deleteActions(suiteOutputKeys),
+		}
+		traces.Processors = append(traces.Processors, "attributes/tests")
+	}
+
+	switch backend {
+	case dashboardBackendGrafana:
+		cfg.Exporters["otlp/tempo"] = map[string]any{"endpoint": "${env:TEMPO_ENDPOINT}"}
+		cfg.Exporters["prometheus"] = map[string]any{"endpoint": "0.0.0.0:8889"}
+		traces.Exporters = []string{"otlp/tempo"}
+		metrics.Exporters = []string{"prometheus"}
+
+		if temporality == temporalityDelta || temporality == temporalityLowMemory {
+			cfg.Processors["deltatocumulative"] = map[string]any{}
+			metrics.Processors = append(metrics.Processors, "deltatocumulative")
+		}
+	case dashboardBackendSigNoz:
+		cfg.Exporters["otlp/signoz"] = map[string]any{
+			"endpoint": "${env:SIGNOZ_ENDPOINT}",
+			"headers":  map[string]any{"signoz-ingestion-key": "${env:SIGNOZ_INGESTION_KEY}"},
+		}
+		traces.Exporters = []string{"otlp/signoz"}
+		metrics.Exporters = []string{"otlp/signoz"}
+	default:
+		return nil, fmt.Errorf("invalid backend %q: valid values are %s and %s", backend, dashboardBackendGrafana, dashboardBackendSigNoz)
+	}
+
+	// the batch processor is the last one, as recommended by the collector
+	traces.Processors = append(traces.Processors, "batch")
+	metrics.Processors = append(metrics.Processors, "batch")
+	cfg.Service.Pipelines["traces"] = traces
+	cfg.Service.Pipelines["metrics"] = metrics
+
+	return cfg, nil
+}
+
+// writeCollectorConfig writes the YAML of the recommended configuration of the collector
+func writeCollectorConfig(w io.Writer, protocol string, shape string, temporality string, backend string) error {
+	cfg, err := newCollectorConfig(protocol, shape, temporality, backend)
+	if err != nil {
+		return err
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(cfg); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+// runCollectorConfig parses the flags of the collector-config command, and prints the configuration of the collector
+// matching them
+func runCollectorConfig(args []string) {
+	if err := resolveFlags(flag.CommandLine, args); err != nil {
+		log.Fatal(err)
+	}
+
+	protocol, err := getOtlpProtocol("TRACES")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := writeCollectorConfig(os.Stdout, protocol, shapeFlag, getMetricsTemporality(), dashboardBackendFlag); err != nil {
+		log.Fatal(err)
+	}
+}
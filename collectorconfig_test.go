@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestCollectorConfig(t *testing.T) {
+	t.Run("Grafana with the spans shape", func(t *testing.T) {
+		cfg, err := newCollectorConfig(otlpProtocolGRPC, shapeSpans, temporalityCumulative, dashboardBackendGrafana)
+		require.NoError(t, err)
+
+		require.Contains(t, cfg.Receivers["otlp"], "protocols")
+		require.Equal(t, collectorPipeline{
+			Receivers:  []string{"otlp"},
+			Processors: []string{"memory_limiter", "attributes/tests", "batch"},
+			Exporters:  []string{"otlp/tempo"},
+		}, cfg.Service.Pipelines["traces"])
+		require.Equal(t, collectorPipeline{
+			Receivers:  []string{"otlp"},
+			Processors: []string{"memory_limiter", "attributes/metrics", "batch"},
+			Exporters:  []string{"prometheus"},
+		}, cfg.Service.Pipelines["metrics"])
+	})
+
+	t.Run("Delta metrics are converted for Prometheus", func(t *testing.T) {
+		cfg, err := newCollectorConfig(otlpProtocolGRPC, shapeSpans, temporalityDelta, dashboardBackendGrafana)
+		require.NoError(t, err)
+		require.Contains(t, cfg.Processors, "deltatocumulative")
+		require.Equal(t, []string{"memory_limiter", "attributes/metrics", "deltatocumulative", "batch"}, cfg.Service.Pipelines["metrics"].Processors)
+	})
+
+	t.Run("SigNoz with the events shape", func(t *testing.T) {
+		cfg, err := newCollectorConfig(otlpProtocolHTTP, shapeEvents, temporalityDelta, dashboardBackendSigNoz)
+		require.NoError(t, err)
+		require.NotContains(t, cfg.Processors, "attributes/tests")
+		require.NotContains(t, cfg.Processors, "deltatocumulative")
+		require.Equal(t, []string{"memory_limiter", "batch"}, cfg.Service.Pipelines["traces"].Processors)
+		require.Equal(t, []string{"otlp/signoz"}, cfg.Service.Pipelines["metrics"].Exporters)
+	})
+
+	t.Run("Invalid settings", func(t *testing.T) {
+		_, err := newCollectorConfig("udp", shapeSpans, temporalityCumulative, dashboardBackendGrafana)
+		require.ErrorContains(t, err, `unsupported OTLP protocol "udp"`)
+
+		_, err = newCollectorConfig(otlpProtocolGRPC, "tree", temporalityCumulative, dashboardBackendGrafana)
+		require.ErrorContains(t, err, `invalid shape "tree"`)
+
+		_, err = newCollectorConfig(otlpProtocolGRPC, shapeSpans, temporalityCumulative, "kibana")
+		require.ErrorContains(t, err, `invalid backend "kibana"`)
+	})
+}
+
+func TestWriteCollectorConfig(t *testing.T) {
+	out := &bytes.Buffer{}
+	require.NoError(t, writeCollectorConfig(out, otlpProtocolGRPC, shapeSpans, temporalityCumulative, dashboardBackendGrafana))
+
+	require.Equal(t, []any{"receivers", "processors", "exporters", "service"}, topLevelKeys(t, out.Bytes()))
+	require.Contains(t, out.String(), `
+  attributes/tests:
+    actions:
+      - key: tests.suite.systemout
+        action: delete
+      - key: tests.suite.systemerr
+        action: delete
+    include:
+      attributes:
+        - key: tests.case.status
+      match_type: strict
+`)
+}
+
+// topLevelKeys returns the keys of the YAML document in their order
+func topLevelKeys(t *testing.T, data []byte) []any {
+	node := yaml.Node{}
+	require.NoError(t, yaml.Unmarshal(data, &node))
+
+	keys := []any{}
+	for i := 0; i < len(node.Content[0].Content); i += 2 {
+		keys = append(keys, node.Content[0].Content[i].Value)
+	}
+
+	return keys
+}
@@ -0,0 +1,301 @@
+// Package collectortest starts an OpenTelemetry Collector, and optionally a Jaeger backend, in containers for the
+// end-to-end tests of the export path: the collector writes the spans, the metrics and the logs it receives to a
+// file per signal, with the headers of the requests as resource attributes, so the tests can assert what was
+// actually exported, with the transports, the headers and the TLS settings of the exporters.
+package collectortest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/network"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// the images of the containers, pinned so the format of the exported files does not change under the tests
+const (
+	CollectorImage = "otel/opentelemetry-collector-contrib:0.104.0"
+	JaegerImage    = "jaegertracing/all-in-one:1.58.0"
+)
+
+// the ports of the OTLP receivers of the collector
+const (
+	GRPCPort = "4317/tcp"
+	HTTPPort = "4318/tcp"
+)
+
+// the transports of OTLP, as named in the OTEL_EXPORTER_OTLP_PROTOCOL environment variable
+const (
+	ProtocolGRPC = "grpc"
+	ProtocolHTTP = "http/protobuf"
+)
+
+// the collector flushes its batches every 200ms, and the files are polled until they contain the expected data
+const (
+	reportTimeout = 30 * time.Second
+	pollInterval  = 500 * time.Millisecond
+)
+
+const (
+	healthCheckPort = "13133/tcp"
+	jaegerUIPort    = "16686/tcp"
+	jaegerAlias     = "jaeger"
+	configPath      = "/etc/otelcol-contrib/config.yaml"
+	certPath        = "/etc/otelcol-contrib/cert.pem"
+	keyPath         = "/etc/otelcol-contrib/key.pem"
+)
+
+type options struct {
+	headers []string
+	tls     bool
+	jaeger  bool
+}
+
+// Option configures the containers started by Run
+type Option func(*options)
+
+// WithHeaders records the values of the headers of the requests as the collectortest.header.<name> resource
+// attributes, with the names in lower case
+func WithHeaders(names ...string) Option {
+	return func(o *options) { o.headers = append(o.headers, names...) }
+}
+
+// WithTLS serves the receivers with a self-signed certificate for localhost, written to CertFile
+func WithTLS() Option {
+	return func(o *options) { o.tls = true }
+}
+
+// WithJaeger exports the traces to a Jaeger backend too, queried with JaegerTraces
+func WithJaeger() Option {
+	return func(o *options) { o.jaeger = true }
+}
+
+// Collector a running collector, terminated at the end of the test
+type Collector struct {
+	container testcontainers.Container
+	jaeger    testcontainers.Container
+	// CertFile the path of the certificate of the receivers, if they are served with TLS
+	CertFile string
+}
+
+// Run starts the collector, and the Jaeger backend if requested, skipping the test if Docker is not available
+func Run(t *testing.T, opts ...Option) *Collector {
+	t.Helper()
+	skipWithoutDocker(t)
+
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	nw, err := network.New(ctx)
+	testcontainers.CleanupNetwork(t, nw)
+	require.NoError(t, err)
+
+	c := &Collector{}
+	if o.jaeger {
+		c.jaeger, err = testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+			ContainerRequest: testcontainers.ContainerRequest{
+				Image:          JaegerImage,
+				ExposedPorts:   []string{jaegerUIPort},
+				Env:            map[string]string{"COLLECTOR_OTLP_ENABLED": "true"},
+				Networks:       []string{nw.Name},
+				NetworkAliases: map[string][]string{nw.Name: {jaegerAlias}},
+				WaitingFor:     wait.ForHTTP("/").WithPort(jaegerUIPort),
+			},
+			Started: true,
+		})
+		testcontainers.CleanupContainer(t, c.jaeger)
+		require.NoError(t, err)
+	}
+
+	config, err := Config(o.headers, o.tls, o.jaeger)
+	require.NoError(t, err)
+
+	configFile := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, config, 0o644))
+
+	files := []testcontainers.ContainerFile{{HostFilePath: configFile, ContainerFilePath: configPath, FileMode: 0o644}}
+	// the exported files are created upfront, writable by the user of the collector
+	for _, signal := range signals {
+		files = append(files, testcontainers.ContainerFile{Reader: strings.NewReader(""), ContainerFilePath: exportPath(signal), FileMode: 0o666})
+	}
+	if o.tls {
+		c.CertFile = filepath.Join(dir, "cert.pem")
+		keyFile := filepath.Join(dir, "key.pem")
+		require.NoError(t, WriteCertificate(c.CertFile, keyFile))
+
+		files = append(files,
+			testcontainers.ContainerFile{HostFilePath: c.CertFile, ContainerFilePath: certPath, FileMode: 0o644},
+			testcontainers.ContainerFile{HostFilePath: keyFile, ContainerFilePath: keyPath, FileMode: 0o644},
+		)
+	}
+
+	c.container, err = testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        CollectorImage,
+			ExposedPorts: []string{GRPCPort, HTTPPort, healthCheckPort},
+			Files:        files,
+			Networks:     []string{nw.Name},
+			WaitingFor:   wait.ForHTTP("/").WithPort(healthCheckPort),
+		},
+		Started: true,
+	})
+	testcontainers.CleanupContainer(t, c.container)
+	require.NoError(t, err)
+
+	return c
+}
+
+// skipWithoutDocker skips the test if Docker is not running, or not installed at all, in which case testcontainers
+// panics resolving its host
+func skipWithoutDocker(t *testing.T) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r != nil {
+			t.Skipf("Docker is not available: %v", r)
+		}
+	}()
+
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+}
+
+// Endpoint returns the URL of the receiver of the protocol, with the https scheme if it is served with TLS
+func (c *Collector) Endpoint(t *testing.T, protocol string) string {
+	t.Helper()
+
+	port := GRPCPort
+	if protocol == ProtocolHTTP {
+		port = HTTPPort
+	}
+
+	ctx := context.Background()
+	host, err := c.container.Host(ctx)
+	require.NoError(t, err)
+
+	mapped, err := c.container.MappedPort(ctx, nat.Port(port))
+	require.NoError(t, err)
+
+	scheme := "http"
+	if c.CertFile != "" {
+		scheme = "https"
+	}
+
+	return fmt.Sprintf("%s://%s:%s", scheme, host, mapped.Port())
+}
+
+// SetEnv points the OTLP exporters of the environment to the receiver of the protocol, trusting its certificate
+// if it is served with TLS, for the duration of the test
+func (c *Collector) SetEnv(t *testing.T, protocol string) {
+	t.Helper()
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", c.Endpoint(t, protocol))
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", protocol)
+	t.Setenv("OTEL_EXPORTER_OTLP_CERTIFICATE", c.CertFile)
+	for _, key := range []string{"OTEL_EXPORTER_OTLP_INSECURE", "OTEL_EXPORTER_OTLP_SPAN_INSECURE", "OTEL_EXPORTER_OTLP_METRIC_INSECURE"} {
+		t.Setenv(key, fmt.Sprint(c.CertFile == ""))
+	}
+	for _, key := range []string{"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_PROTOCOL", "OTEL_EXPORTER_OTLP_METRICS_PROTOCOL"} {
+		t.Setenv(key, "")
+	}
+}
+
+// Report reads the spans, the metrics and the logs written by the collector so far
+func (c *Collector) Report(ctx context.Context) (*Report, error) {
+	files := map[string][]byte{}
+	for _, signal := range signals {
+		data, err := c.readFile(ctx, exportPath(signal))
+		if err != nil {
+			return nil, err
+		}
+
+		files[signal] = data
+	}
+
+	return ParseReport(files[signalTraces], files[signalMetrics], files[signalLogs])
+}
+
+// WaitForReport polls the files written by the collector until the condition holds for them, failing the test
+// if it does not within the timeout of the flushes of the collector
+func (c *Collector) WaitForReport(t *testing.T, condition func(*Report) bool) *Report {
+	t.Helper()
+
+	var report *Report
+	require.Eventually(t, func() bool {
+		var err error
+		report, err = c.Report(context.Background())
+
+		return err == nil && condition(report)
+	}, reportTimeout, pollInterval, "the collector did not write the expected report")
+
+	return report
+}
+
+// readFile reads a file of the container, which is empty until the collector exports its first batch
+func (c *Collector) readFile(ctx context.Context, path string) ([]byte, error) {
+	r, err := c.container.CopyFileFromContainer(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+// JaegerURL returns the URL of the UI and the API of the Jaeger backend
+func (c *Collector) JaegerURL(ctx context.Context) (string, error) {
+	if c.jaeger == nil {
+		return "", fmt.Errorf("the collector was not started with Jaeger")
+	}
+
+	host, err := c.jaeger.Host(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	mapped, err := c.jaeger.MappedPort(ctx, jaegerUIPort)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("http://%s:%s", host, mapped.Port()), nil
+}
+
+// JaegerTraces returns the traces of the service stored by the Jaeger backend
+func (c *Collector) JaegerTraces(ctx context.Context, service string) ([]JaegerTrace, error) {
+	jaegerURL, err := c.JaegerURL(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jaegerURL+"/api/traces?service="+url.QueryEscape(service), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status of the Jaeger API: %s", resp.Status)
+	}
+
+	return parseJaegerTraces(resp.Body)
+}
@@ -0,0 +1,130 @@
+package collectortest
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfig(t *testing.T) {
+	t.Run("Plain receivers with a file per signal", func(t *testing.T) {
+		data, err := Config(nil, false, false)
+		require.NoError(t, err)
+
+		config := map[string]any{}
+		require.NoError(t, yaml.Unmarshal(data, &config))
+
+		exporters := config["exporters"].(map[string]any)
+		require.Equal(t, map[string]any{"path": "/tmp/traces.json"}, exporters["file/traces"])
+		require.Equal(t, map[string]any{"path": "/tmp/metrics.json"}, exporters["file/metrics"])
+		require.Equal(t, map[string]any{"path": "/tmp/logs.json"}, exporters["file/logs"])
+		require.NotContains(t, string(data), "tls")
+		require.NotContains(t, string(data), "resource/headers")
+	})
+
+	t.Run("Headers, TLS and Jaeger", func(t *testing.T) {
+		data, err := Config([]string{"X-Team"}, true, true)
+		require.NoError(t, err)
+
+		config := struct {
+			Receivers struct {
+				OTLP struct {
+					Protocols map[string]struct {
+						TLS map[string]string `yaml:"tls"`
+					} `yaml:"protocols"`
+				} `yaml:"otlp"`
+			} `yaml:"receivers"`
+			Processors map[string]struct {
+				Attributes []map[string]string `yaml:"attributes"`
+			} `yaml:"processors"`
+			Service struct {
+				Pipelines map[string]struct {
+					Processors []string `yaml:"processors"`
+					Exporters  []string `yaml:"exporters"`
+				} `yaml:"pipelines"`
+			} `yaml:"service"`
+		}{}
+		require.NoError(t, yaml.Unmarshal(data, &config))
+
+		for _, protocol := range []string{"grpc", "http"} {
+			require.Equal(t, map[string]string{"cert_file": certPath, "key_file": keyPath}, config.Receivers.OTLP.Protocols[protocol].TLS)
+		}
+		require.Equal(t, []map[string]string{
+			{"key": "collectortest.header.x-team", "from_context": "metadata.x-team", "action": "upsert"},
+		}, config.Processors["resource/headers"].Attributes)
+		require.Equal(t, []string{"resource/headers", "batch"}, config.Service.Pipelines["metrics"].Processors)
+		require.Equal(t, []string{"file/traces", "otlp/jaeger"}, config.Service.Pipelines["traces"].Exporters)
+		require.Equal(t, []string{"file/logs"}, config.Service.Pipelines["logs"].Exporters)
+	})
+}
+
+func TestParseReport(t *testing.T) {
+	traces := []byte(`{"resourceSpans":[{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"junit2otlp"}}]},"scopeSpans":[{"scope":{"name":"junit2otlp"},"spans":[{"traceId":"5b8efff798038103d269b633813fc60c","spanId":"eee19b7ec3c1b174","parentSpanId":"eee19b7ec3c1b173","name":"TestA","kind":1,"startTimeUnixNano":"1544712660000000000","endTimeUnixNano":"1544712661000000000","attributes":[{"key":"tests.case.duration","value":{"intValue":"1000"}}]}]}]}]}
+
+{"resourceSpans":[{"scopeSpans":[{"spans":[{"traceId":"5b8efff798038103d269b633813fc60c","spanId":"eee19b7ec3c1b173","name":"suite"}]}]}]}
+`)
+	metrics := []byte(`{"resourceMetrics":[{"scopeMetrics":[{"metrics":[{"name":"tests.suite.total","sum":{"dataPoints":[{"asInt":"11"}],"aggregationTemporality":2,"isMonotonic":true}}]}]}]}
+`)
+	logs := []byte(`{"resourceLogs":[{"scopeLogs":[{"logRecords":[{"body":{"stringValue":"export failed"}}]}]}]}
+`)
+
+	report, err := ParseReport(traces, metrics, logs)
+	require.NoError(t, err)
+
+	require.Len(t, report.Spans(), 2)
+	span := report.Span("TestA")
+	require.NotNil(t, span)
+	require.Equal(t, "5b8efff798038103d269b633813fc60c", span.TraceIDHex())
+	require.Equal(t, "eee19b7ec3c1b173", span.ParentSpanIDHex())
+
+	value, ok := Attribute(span.GetAttributes(), "tests.case.duration")
+	require.True(t, ok)
+	require.Equal(t, "1000", value)
+
+	value, ok = Attribute(span.Resource, "service.name")
+	require.True(t, ok)
+	require.Equal(t, "junit2otlp", value)
+
+	_, ok = Attribute(span.GetAttributes(), "missing")
+	require.False(t, ok)
+
+	require.Nil(t, report.Span("missing"))
+	require.Equal(t, int64(11), report.Metric("tests.suite.total").GetSum().GetDataPoints()[0].GetAsInt())
+	require.Equal(t, "export failed", report.LogRecords()[0].GetBody().GetStringValue())
+
+	report, err = ParseReport(nil, nil, nil)
+	require.NoError(t, err)
+	require.Empty(t, report.Spans())
+
+	_, err = ParseReport([]byte(`{"resourceSpans":[{"scopeSpans":[{"spans":[{"traceId":"xyz"}]}]}]}`), nil, nil)
+	require.ErrorContains(t, err, `invalid traces: invalid traceId "xyz"`)
+}
+
+func TestWriteCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	require.NoError(t, WriteCertificate(certFile, keyFile))
+
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	require.NoError(t, err)
+	require.NoError(t, cert.VerifyHostname("localhost"))
+	require.NoError(t, cert.VerifyHostname("127.0.0.1"))
+
+	pem, err := os.ReadFile(certFile)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	require.True(t, pool.AppendCertsFromPEM(pem))
+	_, err = cert.Verify(x509.VerifyOptions{Roots: pool, DNSName: "localhost"})
+	require.NoError(t, err)
+}
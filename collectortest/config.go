@@ -0,0 +1,77 @@
+package collectortest
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// the signals of OTLP, each one exported to its own file
+const (
+	signalTraces  = "traces"
+	signalMetrics = "metrics"
+	signalLogs    = "logs"
+)
+
+var signals = []string{signalTraces, signalMetrics, signalLogs}
+
+// HeaderAttributePrefix the prefix of the resource attributes with the values of the headers of the requests
+const HeaderAttributePrefix = "collectortest.header."
+
+// exportPath returns the path of the file of the signal in the container
+func exportPath(signal string) string {
+	return "/tmp/" + signal + ".json"
+}
+
+// Config returns the configuration of the collector: the OTLP receivers, with TLS if requested, a file exporter
+// per signal, and the Jaeger exporter of the traces if requested. The headers are copied from the metadata of the
+// requests to the resource attributes before the batch processor, which drops the metadata
+func Config(headers []string, tls bool, jaeger bool) ([]byte, error) {
+	grpc := map[string]any{"endpoint": "0.0.0.0:4317", "include_metadata": true}
+	http := map[string]any{"endpoint": "0.0.0.0:4318", "include_metadata": true}
+	if tls {
+		grpc["tls"] = map[string]any{"cert_file": certPath, "key_file": keyPath}
+		http["tls"] = map[string]any{"cert_file": certPath, "key_file": keyPath}
+	}
+
+	processors := map[string]any{"batch": map[string]any{}}
+	pipelineProcessors := []string{}
+	if len(headers) > 0 {
+		actions := []map[string]any{}
+		for _, header := range headers {
+			name := strings.ToLower(header)
+			actions = append(actions, map[string]any{"key": HeaderAttributePrefix + name, "from_context": "metadata." + name, "action": "upsert"})
+		}
+
+		processors["resource/headers"] = map[string]any{"attributes": actions}
+		pipelineProcessors = append(pipelineProcessors, "resource/headers")
+	}
+	pipelineProcessors = append(pipelineProcessors, "batch")
+
+	exporters := map[string]any{}
+	pipelines := map[string]any{}
+	for _, signal := range signals {
+		exporters["file/"+signal] = map[string]any{"path": exportPath(signal)}
+		pipelines[signal] = map[string]any{
+			"receivers":  []string{"otlp"},
+			"processors": pipelineProcessors,
+			"exporters":  []string{"file/" + signal},
+		}
+	}
+
+	if jaeger {
+		exporters["otlp/jaeger"] = map[string]any{"endpoint": jaegerAlias + ":4317", "tls": map[string]any{"insecure": true}}
+		pipelines[signalTraces].(map[string]any)["exporters"] = []string{"file/" + signalTraces, "otlp/jaeger"}
+	}
+
+	return yaml.Marshal(map[string]any{
+		"receivers":  map[string]any{"otlp": map[string]any{"protocols": map[string]any{"grpc": grpc, "http": http}}},
+		"processors": processors,
+		"exporters":  exporters,
+		"extensions": map[string]any{"health_check": map[string]any{"endpoint": "0.0.0.0:13133"}},
+		"service": map[string]any{
+			"extensions": []string{"health_check"},
+			"pipelines":  pipelines,
+		},
+	})
+}
@@ -0,0 +1,264 @@
+package collectortest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Report the data written by the collector: a message per batch of each signal
+type Report struct {
+	TracesData  []*tracepb.TracesData
+	MetricsData []*metricspb.MetricsData
+	LogsData    []*logspb.LogsData
+}
+
+// idKeys the keys of the IDs, encoded in hex by OTLP JSON instead of the base64 of protojson
+var idKeys = map[string]bool{"traceId": true, "spanId": true, "parentSpanId": true}
+
+// ParseReport parses the files of the signals written by the file exporters of the collector, in OTLP JSON with a
+// message per line
+func ParseReport(traces []byte, metrics []byte, logs []byte) (*Report, error) {
+	report := &Report{}
+
+	err := parseLines(traces, func() proto.Message {
+		data := &tracepb.TracesData{}
+		report.TracesData = append(report.TracesData, data)
+		return data
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid traces: %w", err)
+	}
+
+	err = parseLines(metrics, func() proto.Message {
+		data := &metricspb.MetricsData{}
+		report.MetricsData = append(report.MetricsData, data)
+		return data
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid metrics: %w", err)
+	}
+
+	err = parseLines(logs, func() proto.Message {
+		data := &logspb.LogsData{}
+		report.LogsData = append(report.LogsData, data)
+		return data
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid logs: %w", err)
+	}
+
+	return report, nil
+}
+
+// parseLines unmarshals each line of the file into a new message
+func parseLines(data []byte, newMessage func() proto.Message) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		line, err := base64IDs(line)
+		if err != nil {
+			return err
+		}
+
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(line, newMessage()); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// base64IDs re-encodes the hex IDs of the line in base64, as expected by protojson
+func base64IDs(line []byte) ([]byte, error) {
+	var document any
+	if err := json.Unmarshal(line, &document); err != nil {
+		return nil, err
+	}
+
+	if err := replaceIDs(document); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(document)
+}
+
+func replaceIDs(value any) error {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if id, ok := child.(string); ok && idKeys[key] {
+				decoded, err := hex.DecodeString(id)
+				if err != nil {
+					return fmt.Errorf("invalid %s %q: %w", key, id, err)
+				}
+
+				v[key] = base64.StdEncoding.EncodeToString(decoded)
+				continue
+			}
+
+			if err := replaceIDs(child); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, child := range v {
+			if err := replaceIDs(child); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Span a span of the report, with the attributes of its resource
+type Span struct {
+	*tracepb.Span
+	Resource []*commonpb.KeyValue
+}
+
+// Spans returns all the spans of the report
+func (r *Report) Spans() []Span {
+	spans := []Span{}
+	for _, data := range r.TracesData {
+		for _, resourceSpans := range data.GetResourceSpans() {
+			for _, scopeSpans := range resourceSpans.GetScopeSpans() {
+				for _, span := range scopeSpans.GetSpans() {
+					spans = append(spans, Span{Span: span, Resource: resourceSpans.GetResource().GetAttributes()})
+				}
+			}
+		}
+	}
+
+	return spans
+}
+
+// TraceIDHex returns the ID of the trace of the span, in hex
+func (s Span) TraceIDHex() string {
+	return hex.EncodeToString(s.GetTraceId())
+}
+
+// ParentSpanIDHex returns the ID of the parent of the span, in hex, or empty for a root span
+func (s Span) ParentSpanIDHex() string {
+	return hex.EncodeToString(s.GetParentSpanId())
+}
+
+// Span returns the first span with the name, or nil
+func (r *Report) Span(name string) *Span {
+	for _, span := range r.Spans() {
+		if span.GetName() == name {
+			return &span
+		}
+	}
+
+	return nil
+}
+
+// Metric a metric of the report, with the attributes of its resource
+type Metric struct {
+	*metricspb.Metric
+	Resource []*commonpb.KeyValue
+}
+
+// Metrics returns all the metrics of the report
+func (r *Report) Metrics() []Metric {
+	metrics := []Metric{}
+	for _, data := range r.MetricsData {
+		for _, resourceMetrics := range data.GetResourceMetrics() {
+			for _, scopeMetrics := range resourceMetrics.GetScopeMetrics() {
+				for _, metric := range scopeMetrics.GetMetrics() {
+					metrics = append(metrics, Metric{Metric: metric, Resource: resourceMetrics.GetResource().GetAttributes()})
+				}
+			}
+		}
+	}
+
+	return metrics
+}
+
+// Metric returns the first metric with the name, or nil
+func (r *Report) Metric(name string) *Metric {
+	for _, metric := range r.Metrics() {
+		if metric.GetName() == name {
+			return &metric
+		}
+	}
+
+	return nil
+}
+
+// LogRecords returns all the log records of the report
+func (r *Report) LogRecords() []*logspb.LogRecord {
+	records := []*logspb.LogRecord{}
+	for _, data := range r.LogsData {
+		for _, resourceLogs := range data.GetResourceLogs() {
+			for _, scopeLogs := range resourceLogs.GetScopeLogs() {
+				records = append(records, scopeLogs.GetLogRecords()...)
+			}
+		}
+	}
+
+	return records
+}
+
+// Attribute returns the string representation of the value of the attribute with the key, and whether it is present
+func Attribute(attributes []*commonpb.KeyValue, key string) (string, bool) {
+	for _, kv := range attributes {
+		if kv.GetKey() != key {
+			continue
+		}
+
+		switch v := kv.GetValue().GetValue().(type) {
+		case *commonpb.AnyValue_StringValue:
+			return v.StringValue, true
+		case *commonpb.AnyValue_IntValue:
+			return fmt.Sprint(v.IntValue), true
+		case *commonpb.AnyValue_DoubleValue:
+			return fmt.Sprint(v.DoubleValue), true
+		case *commonpb.AnyValue_BoolValue:
+			return fmt.Sprint(v.BoolValue), true
+		default:
+			return kv.GetValue().String(), true
+		}
+	}
+
+	return "", false
+}
+
+// JaegerTrace a trace returned by the API of Jaeger
+type JaegerTrace struct {
+	TraceID string `json:"traceID"`
+	Spans   []struct {
+		SpanID        string `json:"spanID"`
+		OperationName string `json:"operationName"`
+	} `json:"spans"`
+}
+
+func parseJaegerTraces(r io.Reader) ([]JaegerTrace, error) {
+	response := struct {
+		Data []JaegerTrace `json:"data"`
+	}{}
+	if err := json.NewDecoder(r).Decode(&response); err != nil {
+		return nil, err
+	}
+
+	return response.Data, nil
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/joshdk/go-junit"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const compareCommand = "compare"
+
+// the results of a test in the head branch that changed from the base branch
+const (
+	comparisonRegression = "regression"
+	comparisonFixed      = "fixed"
+)
+
+// runComparison the comparison of the current run with the base branch, attached to its spans
+var runComparison *branchComparison
+
+// branchComparison the tests failing on the head branch but passing on the base branch, the regressions, and the
+// ones passing on the head branch but failing on the base branch, the fixes
+type branchComparison struct {
+	Base        string
+	Head        string
+	Regressions []string
+	Fixes       []string
+	// baseResults the latest result of each test on the base branch, by its selector
+	baseResults map[string]*stateResult
+}
+
+// runCompare prints the tests whose results differ between the runs of two branches recorded in the state
+func runCompare(args []string) {
+	if err := resolveFlags(flag.CommandLine, args); err != nil {
+		log.Fatal(err)
+	}
+
+	head := headFlag
+	if head == "" {
+		if scmContext := checkGitContext(); scmContext != nil {
+			head = scmContext.Branch
+		}
+	}
+
+	if err := compare(stateFlag, baseFlag, head, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func compare(location string, base string, head string, w io.Writer) error {
+	if location == "" {
+		return errors.New("the state is required for the comparison: set it with --state")
+	}
+
+	if base == "" || head == "" {
+		return errors.New("the branches are required for the comparison: set them with --base and --head")
+	}
+
+	state, err := readState(context.Background(), location)
+	if err != nil {
+		return err
+	}
+
+	baseResults := branchResults(state, base)
+	if len(baseResults) == 0 {
+		return fmt.Errorf("there are no runs of the branch %s in the state %s", base, location)
+	}
+
+	headResults := branchResults(state, head)
+	if len(headResults) == 0 {
+		return fmt.Errorf("there are no runs of the branch %s in the state %s", head, location)
+	}
+
+	return compareResults(base, head, baseResults, headResults).write(w)
+}
+
+// branchResults returns the latest result of each test in the runs of the branch, skipping the runs in which the
+// test was skipped, so that the result is the one of its last execution
+func branchResults(state *toolState, branch string) map[string]*stateResult {
+	results := map[string]*stateResult{}
+	for _, run := range state.Runs {
+		if run.Branch != branch {
+			continue
+		}
+
+		for selector, result := range run.Tests {
+			if result.Status != string(junit.StatusSkipped) {
+				results[selector] = result
+			}
+		}
+	}
+
+	return results
+}
+
+// compareResults compares the results of the tests on the head branch with the ones on the base branch. The
+// tests without a result on any of the branches are not compared
+func compareResults(base string, head string, baseResults map[string]*stateResult, headResults map[string]*stateResult) *branchComparison {
+	comparison := &branchComparison{Base: base, Head: head, Regressions: []string{}, Fixes: []string{}, baseResults: baseResults}
+
+	for _, selector := range slices.Sorted(maps.Keys(headResults)) {
+		baseResult, ok := baseResults[selector]
+		if !ok {
+			continue
+		}
+
+		switch comparisonResult(baseResult.Status, headResults[selector].Status) {
+		case comparisonRegression:
+			comparison.Regressions = append(comparison.Regressions, selector)
+		case comparisonFixed:
+			comparison.Fixes = append(comparison.Fixes, selector)
+		}
+	}
+
+	return comparison
+}
+
+// comparisonResult returns if the test regressed or was fixed in the head branch, or nothing if its result did
+// not change from passing to failing, or the other way around
+func comparisonResult(baseStatus string, headStatus string) string {
+	switch {
+	case baseStatus == string(junit.StatusPassed) && isFailingStatus(headStatus):
+		return comparisonRegression
+	case isFailingStatus(baseStatus) && headStatus == string(junit.StatusPassed):
+		return comparisonFixed
+	default:
+		return ""
+	}
+}
+
+func isFailingStatus(status string) bool {
+	return status == string(junit.StatusFailed) || status == string(junit.StatusError)
+}
+
+func (c *branchComparison) write(w io.Writer) error {
+	fmt.Fprintf(w, "%s compared with %s: regressions %d, fixes %d\n", c.Head, c.Base, len(c.Regressions), len(c.Fixes))
+
+	if len(c.Regressions) > 0 {
+		fmt.Fprintf(w, "\nFailing on %s, passing on %s:\n", c.Head, c.Base)
+		for _, selector := range c.Regressions {
+			fmt.Fprintf(w, "  %s\n", selector)
+		}
+	}
+
+	if len(c.Fixes) > 0 {
+		fmt.Fprintf(w, "\nPassing on %s, failing on %s:\n", c.Head, c.Base)
+		for _, selector := range c.Fixes {
+			fmt.Fprintf(w, "  %s\n", selector)
+		}
+	}
+
+	return nil
+}
+
+// attributes returns the attributes of the comparison for the root span of the run
+func (c *branchComparison) attributes() []attribute.KeyValue {
+	if c == nil {
+		return nil
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.Key(TestsCompareBase).String(c.Base),
+		attribute.Key(TestsCompareRegressions).Int(len(c.Regressions)),
+		attribute.Key(TestsCompareFixes).Int(len(c.Fixes)),
+	}
+	if len(c.Regressions) > 0 {
+		attrs = append(attrs, attribute.Key(TestsCompareRegressedTests).StringSlice(c.Regressions))
+	}
+	if len(c.Fixes) > 0 {
+		attrs = append(attrs, attribute.Key(TestsCompareFixedTests).StringSlice(c.Fixes))
+	}
+
+	return attrs
+}
+
+// testAttributes returns the status of the test on the base branch, and if it regressed or was fixed
+func (c *branchComparison) testAttributes(suite junit.Suite, test junit.Test) []attribute.KeyValue {
+	if c == nil {
+		return nil
+	}
+
+	baseResult, ok := c.baseResults[newImpactedTest(suite, test).Selector]
+	if !ok {
+		return nil
+	}
+
+	attrs := []attribute.KeyValue{attribute.Key(TestCompareBaseStatus).String(baseResult.Status)}
+	if result := comparisonResult(baseResult.Status, string(test.Status)); result != "" {
+		attrs = append(attrs, attribute.Key(TestCompareResult).String(result))
+	}
+
+	return attrs
+}
+
+// compareRun returns the comparison of the current run with the latest results of the base branch in the state,
+// or nothing if the branch has no runs yet
+func compareRun(state *toolState, base string, run *stateRun) *branchComparison {
+	baseResults := branchResults(state, base)
+	if len(baseResults) == 0 {
+		log.Printf("there are no runs of the base branch %s in the state to compare with", base)
+		return nil
+	}
+
+	return compareResults(base, run.Branch, baseResults, run.Tests)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestCompare(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	state := &toolState{}
+	state.addRun(&stateRun{Time: start, Branch: "main", Tests: map[string]*stateResult{
+		"com.acme.CartTest#testTotal":  {Status: "failed"},
+		"com.acme.CartTest#testEmpty":  {Status: "passed"},
+		"com.acme.CartTest#testAdd":    {Status: "error"},
+		"com.acme.CartTest#testRemove": {Status: "passed"},
+	}}, defaultStateHistory)
+	// the latest executed result of each test is the one compared
+	state.addRun(&stateRun{Time: start.Add(time.Hour), Branch: "main", Tests: map[string]*stateResult{
+		"com.acme.CartTest#testTotal":  {Status: "passed"},
+		"com.acme.CartTest#testRemove": {Status: "skipped"},
+	}}, defaultStateHistory)
+	state.addRun(&stateRun{Time: start.Add(2 * time.Hour), Branch: "feature-x", Tests: map[string]*stateResult{
+		"com.acme.CartTest#testTotal":  {Status: "failed"},
+		"com.acme.CartTest#testEmpty":  {Status: "error"},
+		"com.acme.CartTest#testAdd":    {Status: "passed"},
+		"com.acme.CartTest#testRemove": {Status: "passed"},
+		"com.acme.CartTest#testNew":    {Status: "failed"},
+	}}, defaultStateHistory)
+	require.NoError(t, updateState(context.Background(), path, func(current *toolState) error {
+		*current = *state
+		return nil
+	}))
+
+	t.Run("Report", func(t *testing.T) {
+		output := &bytes.Buffer{}
+		require.NoError(t, compare(path, "main", "feature-x", output))
+
+		require.Equal(t, `feature-x compared with main: regressions 2, fixes 1
+
+Failing on feature-x, passing on main:
+  com.acme.CartTest#testEmpty
+  com.acme.CartTest#testTotal
+
+Passing on feature-x, failing on main:
+  com.acme.CartTest#testAdd
+`, output.String())
+	})
+
+	t.Run("No differences", func(t *testing.T) {
+		output := &bytes.Buffer{}
+		require.NoError(t, compare(path, "main", "main", output))
+
+		require.Equal(t, "main compared with main: regressions 0, fixes 0\n", output.String())
+	})
+
+	t.Run("Branch without runs", func(t *testing.T) {
+		require.ErrorContains(t, compare(path, "develop", "feature-x", &bytes.Buffer{}), "there are no runs of the branch develop")
+	})
+
+	t.Run("Missing flags", func(t *testing.T) {
+		require.ErrorContains(t, compare("", "main", "feature-x", &bytes.Buffer{}), "--state")
+		require.ErrorContains(t, compare(path, "", "feature-x", &bytes.Buffer{}), "--base")
+	})
+}
+
+func TestComparisonAttributes(t *testing.T) {
+	state := &toolState{}
+	state.addRun(&stateRun{Branch: "main", Tests: map[string]*stateResult{
+		"com.acme.CartTest#testTotal": {Status: "passed"},
+		"com.acme.CartTest#testEmpty": {Status: "failed"},
+	}}, defaultStateHistory)
+
+	run := &stateRun{Branch: "feature-x", Tests: map[string]*stateResult{
+		"com.acme.CartTest#testTotal": {Status: "failed"},
+		"com.acme.CartTest#testEmpty": {Status: "failed"},
+	}}
+	comparison := compareRun(state, "main", run)
+	require.NotNil(t, comparison)
+
+	require.Equal(t, []attribute.KeyValue{
+		attribute.Key(TestsCompareBase).String("main"),
+		attribute.Key(TestsCompareRegressions).Int(1),
+		attribute.Key(TestsCompareFixes).Int(0),
+		attribute.Key(TestsCompareRegressedTests).StringSlice([]string{"com.acme.CartTest#testTotal"}),
+	}, comparison.attributes())
+
+	suite := junit.Suite{Name: "cart"}
+	require.Equal(t, []attribute.KeyValue{
+		attribute.Key(TestCompareBaseStatus).String("passed"),
+		attribute.Key(TestCompareResult).String(comparisonRegression),
+	}, comparison.testAttributes(suite, junit.Test{Name: "testTotal", Classname: "com.acme.CartTest", Status: junit.StatusFailed}))
+	require.Equal(t, []attribute.KeyValue{
+		attribute.Key(TestCompareBaseStatus).String("failed"),
+	}, comparison.testAttributes(suite, junit.Test{Name: "testEmpty", Classname: "com.acme.CartTest", Status: junit.StatusFailed}))
+	require.Empty(t, comparison.testAttributes(suite, junit.Test{Name: "testNew", Classname: "com.acme.CartTest", Status: junit.StatusFailed}))
+
+	// without runs of the base branch, there is nothing to compare with
+	require.Nil(t, compareRun(state, "develop", run))
+	require.Empty(t, (*branchComparison)(nil).attributes())
+}
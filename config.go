@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// config the configuration file of the tool, for the settings that do not fit in a flag
+type config struct {
+	Flags          map[string]string    `yaml:"flags"`
+	FailureRules   []failureRule        `yaml:"failure_rules"`
+	SpanHooks      []spanHook           `yaml:"span_hooks"`
+	Derived        derivedAttributes    `yaml:"derived_attributes"`
+	WebDriver      webDriverRules       `yaml:"webdriver"`
+	Tenancy        tenancyConfig        `yaml:"tenancy"`
+	Auth           authConfig           `yaml:"auth"`
+	StatusMapping  statusMapping        `yaml:"status_mapping"`
+	Exporters      exportersConfig      `yaml:"exporters"`
+	Mappings       reportMappings       `yaml:"mappings"`
+	Scrubbing      scrubbingConfig      `yaml:"scrubbing"`
+	Ownership      ownershipRules       `yaml:"ownership"`
+	Costs          costConfig           `yaml:"costs"`
+	ExpectedSuites expectedSuitesConfig `yaml:"expected_suites"`
+	Classification classificationConfig `yaml:"classification"`
+}
+
+// loadConfig reads the configuration file, returning an empty configuration if the path is empty
+func loadConfig(path string) (*config, error) {
+	cfg := &config{}
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the config file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse the config file %s: %w", path, err)
+	}
+
+	for i := range cfg.FailureRules {
+		if err := cfg.FailureRules[i].compile(); err != nil {
+			return nil, fmt.Errorf("invalid failure rule %d in the config file %s: %w", i, path, err)
+		}
+	}
+
+	for i := range cfg.SpanHooks {
+		if err := cfg.SpanHooks[i].compile(); err != nil {
+			return nil, fmt.Errorf("invalid span hook %d in the config file %s: %w", i, path, err)
+		}
+	}
+
+	if err := cfg.Derived.compile(); err != nil {
+		return nil, fmt.Errorf("invalid derived attributes in the config file %s: %w", path, err)
+	}
+
+	if err := cfg.WebDriver.compile(); err != nil {
+		return nil, fmt.Errorf("invalid webdriver session pattern in the config file %s: %w", path, err)
+	}
+
+	if err := cfg.Tenancy.validate(); err != nil {
+		return nil, fmt.Errorf("invalid tenancy in the config file %s: %w", path, err)
+	}
+
+	if err := cfg.Auth.validate(&cfg.Tenancy); err != nil {
+		return nil, fmt.Errorf("invalid auth in the config file %s: %w", path, err)
+	}
+
+	if err := cfg.StatusMapping.validate(); err != nil {
+		return nil, fmt.Errorf("invalid status mapping in the config file %s: %w", path, err)
+	}
+
+	if err := cfg.Exporters.validate(); err != nil {
+		return nil, fmt.Errorf("invalid exporters in the config file %s: %w", path, err)
+	}
+
+	if err := cfg.Mappings.validate(); err != nil {
+		return nil, fmt.Errorf("invalid mappings in the config file %s: %w", path, err)
+	}
+
+	if err := cfg.Scrubbing.compile(); err != nil {
+		return nil, fmt.Errorf("invalid scrubbing in the config file %s: %w", path, err)
+	}
+
+	if err := cfg.Ownership.compile(); err != nil {
+		return nil, fmt.Errorf("invalid ownership in the config file %s: %w", path, err)
+	}
+
+	if err := cfg.Costs.validate(); err != nil {
+		return nil, fmt.Errorf("invalid costs in the config file %s: %w", path, err)
+	}
+
+	if err := cfg.ExpectedSuites.validate(); err != nil {
+		return nil, fmt.Errorf("invalid expected suites in the config file %s: %w", path, err)
+	}
+
+	if err := cfg.Classification.compile(); err != nil {
+		return nil, fmt.Errorf("invalid classification in the config file %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,334 @@
+// Package convert converts the jUnit reports into OpenTelemetry traces and metrics within an application, with its
+// own tracer and meter providers, or its own span processors, i.e. to sample the spans, to enrich them, or to
+// capture them in memory in its tests. It creates the spans and the metrics of the suites and the test cases with
+// the same names and attributes as the junit2otlp command, without its flags, configuration file and exporters.
+package convert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/joshdk/go-junit"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName the name of the instrumentation scope of the spans and the metrics
+const ScopeName = "github.com/mdelapenya/junit2otlp/convert"
+
+// DefaultTraceName the name of the root span of the reports, the one of the command
+const DefaultTraceName = "junit2otlp"
+
+// the attributes and the metrics, the ones of the command
+const (
+	testsRunTotal   = "tests.run.total"
+	testsRunPassed  = "tests.run.passed"
+	testsRunFailed  = "tests.run.failed"
+	testsRunError   = "tests.run.error"
+	testsRunSkipped = "tests.run.skipped"
+
+	testsSuiteName      = "tests.suite.suitename"
+	testsSuiteDuration  = "tests.suite.duration"
+	testsSuiteSystemErr = "tests.suite.systemerr"
+	testsSuiteSystemOut = "tests.suite.systemout"
+	testsSuiteTotal     = "tests.suite.total"
+	testsSuitePassed    = "tests.suite.passed"
+	testsSuiteFailed    = "tests.suite.failed"
+	testsSuiteError     = "tests.suite.error"
+	testsSuiteSkipped   = "tests.suite.skipped"
+
+	testCaseDuration          = "tests.case.duration"
+	testCaseDurationHistogram = "tests.case.duration.histogram"
+	testCaseClassName         = "tests.case.classname"
+	testCaseMessage           = "tests.case.message"
+	testCaseStatus            = "tests.case.status"
+	testCaseSystemErr         = "tests.case.systemerr"
+	testCaseSystemOut         = "tests.case.systemout"
+	testCaseError             = "tests.case.error"
+)
+
+// Converter converts the jUnit reports with its providers. It's safe for concurrent use
+type Converter struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	processors     []sdktrace.SpanProcessor
+	traceName      string
+	hooks          []SpanHook
+	// sdkProvider the tracer provider created for the span processors, shut down with the converter
+	sdkProvider *sdktrace.TracerProvider
+
+	tracer    trace.Tracer
+	histogram metric.Int64Histogram
+	counters  map[string]metric.Int64Counter
+}
+
+// Option configures a Converter
+type Option func(*Converter)
+
+// WithTracerProvider sets the tracer provider of the spans, the global one by default
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *Converter) {
+		c.tracerProvider = provider
+	}
+}
+
+// WithMeterProvider sets the meter provider of the metrics, the global one by default
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(c *Converter) {
+		c.meterProvider = provider
+	}
+}
+
+// WithSpanProcessor registers the span processor, i.e. to enrich the spans or to capture them in memory, in the
+// tracer provider of the SDK set with WithTracerProvider, or in a new one if there's none
+func WithSpanProcessor(processor sdktrace.SpanProcessor) Option {
+	return func(c *Converter) {
+		c.processors = append(c.processors, processor)
+	}
+}
+
+// WithTraceName sets the name of the root span of the reports, DefaultTraceName by default
+func WithTraceName(name string) Option {
+	return func(c *Converter) {
+		c.traceName = name
+	}
+}
+
+// WithSpanHook adds the hook mutating the spans of the test cases before they are created. The hooks are applied
+// in the order they are added, each one seeing the changes of the previous ones
+func WithSpanHook(hook SpanHook) Option {
+	return func(c *Converter) {
+		c.hooks = append(c.hooks, hook)
+	}
+}
+
+// New returns a converter with the options. The span processors can only be registered in a tracer provider of
+// the SDK
+func New(options ...Option) (*Converter, error) {
+	c := &Converter{traceName: DefaultTraceName}
+	for _, option := range options {
+		option(c)
+	}
+
+	if len(c.processors) > 0 {
+		switch provider := c.tracerProvider.(type) {
+		case nil:
+			c.sdkProvider = sdktrace.NewTracerProvider()
+			c.tracerProvider = c.sdkProvider
+			for _, processor := range c.processors {
+				c.sdkProvider.RegisterSpanProcessor(processor)
+			}
+		case *sdktrace.TracerProvider:
+			for _, processor := range c.processors {
+				provider.RegisterSpanProcessor(processor)
+			}
+		default:
+			return nil, fmt.Errorf("the span processors cannot be registered in the tracer provider %T: it must be the one of the SDK", provider)
+		}
+	}
+
+	if c.tracerProvider == nil {
+		c.tracerProvider = otel.GetTracerProvider()
+	}
+	if c.meterProvider == nil {
+		c.meterProvider = otel.GetMeterProvider()
+	}
+
+	c.tracer = c.tracerProvider.Tracer(ScopeName)
+	meter := c.meterProvider.Meter(ScopeName)
+
+	histogram, err := meter.Int64Histogram(testCaseDurationHistogram, metric.WithDescription("Duration of the test cases"), metric.WithUnit("ms"))
+	if err != nil {
+		return nil, err
+	}
+	c.histogram = histogram
+
+	c.counters = map[string]metric.Int64Counter{}
+	for name, description := range map[string]string{
+		testsSuiteDuration: "Duration of the tests",
+		testsSuiteTotal:    "Total number of executed tests",
+		testsSuitePassed:   "Total number of passed tests",
+		testsSuiteFailed:   "Total number of failed tests",
+		testsSuiteError:    "Total number of tests with errors",
+		testsSuiteSkipped:  "Total number of skipped tests",
+	} {
+		counter, err := meter.Int64Counter(name, metric.WithDescription(description))
+		if err != nil {
+			return nil, err
+		}
+		c.counters[name] = counter
+	}
+
+	return c, nil
+}
+
+// Shutdown shuts down the tracer provider created for the span processors, if any, flushing their spans. The
+// providers of the application are shut down by it
+func (c *Converter) Shutdown(ctx context.Context) error {
+	if c.sdkProvider == nil {
+		return nil
+	}
+
+	return c.sdkProvider.Shutdown(ctx)
+}
+
+// Convert reads the jUnit report, and creates a trace with a span for the run, a child span for each suite, and a
+// span for each of its test cases, and records their metrics. The suites are laid out one after the other, ending
+// at the time of the conversion
+func (c *Converter) Convert(ctx context.Context, report io.Reader) error {
+	suites, err := junit.IngestReader(report)
+	if err != nil {
+		return fmt.Errorf("failed to parse the jUnit report: %w", err)
+	}
+
+	if len(suites) == 0 {
+		return errors.New("the jUnit report has no suites")
+	}
+
+	var totals junit.Totals
+	for _, suite := range suites {
+		totals.Tests += suite.Totals.Tests
+		totals.Passed += suite.Totals.Passed
+		totals.Failed += suite.Totals.Failed
+		totals.Error += suite.Totals.Error
+		totals.Skipped += suite.Totals.Skipped
+		totals.Duration += suite.Totals.Duration
+	}
+
+	end := time.Now()
+	cursor := end.Add(-totals.Duration)
+
+	ctx, runSpan := c.tracer.Start(ctx, c.traceName, trace.WithTimestamp(cursor), trace.WithAttributes(
+		attribute.Int(testsRunTotal, totals.Tests),
+		attribute.Int(testsRunPassed, totals.Passed),
+		attribute.Int(testsRunFailed, totals.Failed),
+		attribute.Int(testsRunError, totals.Error),
+		attribute.Int(testsRunSkipped, totals.Skipped),
+	))
+
+	for _, suite := range suites {
+		cursor = c.convertSuite(ctx, suite, cursor)
+	}
+
+	// the test cases can last longer than their suites declare
+	if cursor.After(end) {
+		end = cursor
+	}
+	runSpan.End(trace.WithTimestamp(end))
+
+	return nil
+}
+
+// convertSuite creates the span of the suite from the start time, and the spans of its test cases and its nested
+// suites one after the other, returning its end time
+func (c *Converter) convertSuite(ctx context.Context, suite junit.Suite, start time.Time) time.Time {
+	suiteAttributes := []attribute.KeyValue{
+		semconv.CodeNamespaceKey.String(suite.Package),
+		attribute.String(testsSuiteName, suite.Name),
+		attribute.String(testsSuiteSystemErr, suite.SystemErr),
+		attribute.String(testsSuiteSystemOut, suite.SystemOut),
+		attribute.Int64(testsSuiteDuration, suite.Totals.Duration.Milliseconds()),
+	}
+	suiteAttributes = appendProperties(suiteAttributes, suite.Properties)
+
+	metricAttributes := metric.WithAttributes(suiteAttributes...)
+	c.counters[testsSuiteDuration].Add(ctx, suite.Totals.Duration.Milliseconds(), metricAttributes)
+	c.counters[testsSuiteTotal].Add(ctx, int64(suite.Totals.Tests), metricAttributes)
+	c.counters[testsSuitePassed].Add(ctx, int64(suite.Totals.Passed), metricAttributes)
+	c.counters[testsSuiteFailed].Add(ctx, int64(suite.Totals.Failed), metricAttributes)
+	c.counters[testsSuiteError].Add(ctx, int64(suite.Totals.Error), metricAttributes)
+	c.counters[testsSuiteSkipped].Add(ctx, int64(suite.Totals.Skipped), metricAttributes)
+
+	suiteCtx, suiteSpan := c.tracer.Start(ctx, suite.Name, trace.WithTimestamp(start), trace.WithAttributes(suiteAttributes...))
+
+	cursor := start
+	for _, test := range suite.Tests {
+		c.convertTest(suiteCtx, suite, suiteAttributes, test, cursor)
+		cursor = cursor.Add(test.Duration)
+	}
+
+	for _, nested := range suite.Suites {
+		cursor = c.convertSuite(suiteCtx, nested, cursor)
+	}
+
+	end := start.Add(suite.Totals.Duration)
+	if cursor.After(end) {
+		end = cursor
+	}
+	suiteSpan.End(trace.WithTimestamp(end))
+
+	return end
+}
+
+// convertTest creates the span of the test case from the start time, mutated by the span hooks, and records its
+// duration within it, so the exemplars point to it
+func (c *Converter) convertTest(ctx context.Context, suite junit.Suite, suiteAttributes []attribute.KeyValue, test junit.Test, start time.Time) {
+	testAttributes := []attribute.KeyValue{
+		semconv.CodeFunctionKey.String(test.Name),
+		attribute.Int64(testCaseDuration, test.Duration.Milliseconds()),
+		attribute.String(testCaseClassName, test.Classname),
+		attribute.String(testCaseMessage, test.Message),
+		attribute.String(testCaseStatus, string(test.Status)),
+		attribute.String(testCaseSystemErr, test.SystemErr),
+		attribute.String(testCaseSystemOut, test.SystemOut),
+	}
+	testAttributes = appendProperties(testAttributes, test.Properties)
+	testAttributes = append(testAttributes, suiteAttributes...)
+	if test.Error != nil {
+		testAttributes = append(testAttributes, attribute.String(testCaseError, test.Error.Error()))
+	}
+
+	span := &SpanBuilder{name: test.Name, attributes: testAttributes}
+	if test.Status == junit.StatusFailed || test.Status == junit.StatusError {
+		span.SetStatus(codes.Error, test.Message)
+	}
+
+	if len(c.hooks) > 0 {
+		testCase := TestCase{
+			Suite:      suite.Name,
+			Name:       test.Name,
+			Classname:  test.Classname,
+			Status:     string(test.Status),
+			Message:    test.Message,
+			Duration:   test.Duration,
+			Properties: maps.Clone(test.Properties),
+			SystemOut:  test.SystemOut,
+			SystemErr:  test.SystemErr,
+		}
+		for _, hook := range c.hooks {
+			hook(testCase, span)
+		}
+	}
+
+	testCtx, testSpan := c.tracer.Start(ctx, span.name, trace.WithTimestamp(start), trace.WithAttributes(span.attributes...))
+	testSpan.SetStatus(span.status, span.description)
+
+	c.histogram.Record(testCtx, test.Duration.Milliseconds(), metric.WithAttributes(
+		semconv.CodeNamespaceKey.String(suite.Package),
+		semconv.CodeFunctionKey.String(span.name),
+		attribute.String(testsSuiteName, suite.Name),
+		attribute.String(testCaseClassName, test.Classname),
+		attribute.String(testCaseStatus, span.attribute(testCaseStatus, string(test.Status))),
+	))
+
+	testSpan.End(trace.WithTimestamp(start.Add(test.Duration)))
+}
+
+// appendProperties appends the properties as attributes named after them, sorted by name as the command does
+func appendProperties(attributes []attribute.KeyValue, properties map[string]string) []attribute.KeyValue {
+	for _, name := range slices.Sorted(maps.Keys(properties)) {
+		attributes = append(attributes, attribute.String(name, properties[name]))
+	}
+
+	return attributes
+}
@@ -0,0 +1,127 @@
+package convert
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const report = `<testsuites>
+	<testsuite name="api" time="3">
+		<properties><property name="build" value="42"/></properties>
+		<testcase name="Get" classname="api.Handlers" time="1"/>
+		<testcase name="Post" classname="api.Handlers" time="2"><failure message="expected 201"/></testcase>
+	</testsuite>
+</testsuites>`
+
+// spanAttributes returns the attributes of the span by key
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attributes := map[attribute.Key]attribute.Value{}
+	for _, attr := range span.Attributes() {
+		attributes[attr.Key] = attr.Value
+	}
+
+	return attributes
+}
+
+func TestConverter(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Span processor", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+		reader := sdkmetric.NewManualReader()
+
+		converter, err := New(WithSpanProcessor(recorder), WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))), WithTraceName("checkout"))
+		require.NoError(t, err)
+		require.NoError(t, converter.Convert(ctx, strings.NewReader(report)))
+		require.NoError(t, converter.Shutdown(ctx))
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 4)
+
+		names := []string{}
+		for _, span := range spans {
+			names = append(names, span.Name())
+		}
+		require.ElementsMatch(t, []string{"checkout", "api", "Get", "Post"}, names)
+
+		for _, span := range spans {
+			attributes := spanAttributes(span)
+			switch span.Name() {
+			case "checkout":
+				require.False(t, span.Parent().IsValid())
+				require.Equal(t, int64(2), attributes[testsRunTotal].AsInt64())
+				require.Equal(t, int64(1), attributes[testsRunFailed].AsInt64())
+			case "Post":
+				require.Equal(t, codes.Error, span.Status().Code)
+				require.Equal(t, "expected 201", span.Status().Description)
+				require.Equal(t, "failed", attributes[testCaseStatus].AsString())
+				require.Equal(t, "api", attributes[testsSuiteName].AsString())
+				require.Equal(t, "42", attributes["build"].AsString())
+				require.Equal(t, span.EndTime().Sub(span.StartTime()).Milliseconds(), attributes[testCaseDuration].AsInt64())
+			}
+		}
+
+		metrics := metricdata.ResourceMetrics{}
+		require.NoError(t, reader.Collect(ctx, &metrics))
+		require.Len(t, metrics.ScopeMetrics, 1)
+		require.Equal(t, ScopeName, metrics.ScopeMetrics[0].Scope.Name)
+
+		values := map[string]int64{}
+		for _, m := range metrics.ScopeMetrics[0].Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				values[m.Name] = data.DataPoints[0].Value
+			case metricdata.Histogram[int64]:
+				for _, point := range data.DataPoints {
+					values[m.Name] += int64(point.Count)
+				}
+			}
+		}
+		require.Equal(t, map[string]int64{
+			testsSuiteDuration:        3000,
+			testsSuiteTotal:           2,
+			testsSuitePassed:          1,
+			testsSuiteFailed:          1,
+			testsSuiteError:           0,
+			testsSuiteSkipped:         0,
+			testCaseDurationHistogram: 2,
+		}, values)
+	})
+
+	t.Run("Tracer provider of the application", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+		converter, err := New(WithTracerProvider(provider))
+		require.NoError(t, err)
+		require.NoError(t, converter.Convert(ctx, strings.NewReader(report)))
+
+		// the provider of the application is not shut down by the converter
+		require.NoError(t, converter.Shutdown(ctx))
+		require.Len(t, recorder.Ended(), 4)
+		require.Equal(t, DefaultTraceName, recorder.Ended()[3].Name())
+	})
+
+	t.Run("Span processor of another provider", func(t *testing.T) {
+		_, err := New(WithTracerProvider(noop.NewTracerProvider()), WithSpanProcessor(tracetest.NewSpanRecorder()))
+		require.ErrorContains(t, err, "the span processors cannot be registered")
+	})
+
+	t.Run("Invalid report", func(t *testing.T) {
+		converter, err := New(WithTracerProvider(noop.NewTracerProvider()))
+		require.NoError(t, err)
+
+		require.ErrorContains(t, converter.Convert(ctx, strings.NewReader("<testsuite")), "failed to parse the jUnit report")
+		require.ErrorContains(t, converter.Convert(ctx, strings.NewReader("<report/>")), "the jUnit report has no suites")
+	})
+}
@@ -0,0 +1,88 @@
+package convert
+
+import (
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// TestCase a test case of the report, as seen by the span hooks
+type TestCase struct {
+	Suite      string
+	Name       string
+	Classname  string
+	Status     string
+	Message    string
+	Duration   time.Duration
+	Properties map[string]string
+	SystemOut  string
+	SystemErr  string
+}
+
+// SpanHook mutates the span of a test case before it's created, i.e. to rename it, to change its attributes or its
+// status
+type SpanHook func(test TestCase, span *SpanBuilder)
+
+// SpanBuilder the name, the attributes and the status of the span of a test case, before it's created
+type SpanBuilder struct {
+	name        string
+	attributes  []attribute.KeyValue
+	status      codes.Code
+	description string
+}
+
+// Name returns the name of the span, the name of the test case by default
+func (b *SpanBuilder) Name() string {
+	return b.name
+}
+
+// SetName renames the span. The duration of the test case is recorded with the new name too
+func (b *SpanBuilder) SetName(name string) {
+	b.name = name
+}
+
+// Attributes returns a copy of the attributes of the span
+func (b *SpanBuilder) Attributes() []attribute.KeyValue {
+	return slices.Clone(b.attributes)
+}
+
+// SetAttributes sets the attributes, replacing the ones with the same keys. The duration of the test case is
+// recorded with the new tests.case.status too
+func (b *SpanBuilder) SetAttributes(attributes ...attribute.KeyValue) {
+	for _, attr := range attributes {
+		index := slices.IndexFunc(b.attributes, func(existing attribute.KeyValue) bool { return existing.Key == attr.Key })
+		if index < 0 {
+			b.attributes = append(b.attributes, attr)
+			continue
+		}
+		b.attributes[index] = attr
+	}
+}
+
+// RemoveAttributes removes the attributes with the keys
+func (b *SpanBuilder) RemoveAttributes(keys ...attribute.Key) {
+	b.attributes = slices.DeleteFunc(b.attributes, func(attr attribute.KeyValue) bool { return slices.Contains(keys, attr.Key) })
+}
+
+// Status returns the status of the span and its description, an error for the failed test cases
+func (b *SpanBuilder) Status() (codes.Code, string) {
+	return b.status, b.description
+}
+
+// SetStatus sets the status of the span, whose description is only kept for the error status
+func (b *SpanBuilder) SetStatus(code codes.Code, description string) {
+	b.status, b.description = code, description
+}
+
+// attribute returns the value of the attribute as a string, or the fallback if the span doesn't have it
+func (b *SpanBuilder) attribute(key attribute.Key, fallback string) string {
+	for _, attr := range b.attributes {
+		if attr.Key == key {
+			return attr.Value.Emit()
+		}
+	}
+
+	return fallback
+}
@@ -0,0 +1,90 @@
+package convert
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSpanHooks(t *testing.T) {
+	ctx := context.Background()
+	recorder := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+
+	tests := []TestCase{}
+	converter, err := New(
+		WithSpanProcessor(recorder),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+		WithSpanHook(func(test TestCase, span *SpanBuilder) {
+			tests = append(tests, test)
+
+			if test.Status == "failed" && strings.Contains(test.Message, "201") {
+				span.SetName(test.Classname + "/" + test.Name)
+				span.SetStatus(codes.Unset, "")
+				span.SetAttributes(attribute.String(testCaseStatus, "quarantined"), attribute.Bool("tests.case.quarantined", true))
+			}
+			span.RemoveAttributes(testCaseSystemOut, testCaseSystemErr)
+		}),
+		// the hooks see the changes of the previous ones
+		WithSpanHook(func(test TestCase, span *SpanBuilder) {
+			if code, _ := span.Status(); code == codes.Unset {
+				span.SetAttributes(attribute.String("tests.case.owner", span.Name()))
+			}
+		}),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, converter.Convert(ctx, strings.NewReader(report)))
+	require.NoError(t, converter.Shutdown(ctx))
+
+	require.Len(t, tests, 2)
+	// the properties of the test cases are their attributes, as the command reads them
+	require.Equal(t, TestCase{
+		Suite:      "api",
+		Name:       "Post",
+		Classname:  "api.Handlers",
+		Status:     "failed",
+		Message:    "expected 201",
+		Duration:   2 * time.Second,
+		Properties: map[string]string{"name": "Post", "classname": "api.Handlers", "time": "2"},
+	}, tests[1])
+
+	spans := map[string]map[attribute.Key]attribute.Value{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = spanAttributes(span)
+		if span.Name() == "api.Handlers/Post" {
+			require.Equal(t, codes.Unset, span.Status().Code)
+		}
+	}
+
+	quarantined, ok := spans["api.Handlers/Post"]
+	require.True(t, ok)
+	require.Equal(t, "quarantined", quarantined[testCaseStatus].AsString())
+	require.True(t, quarantined["tests.case.quarantined"].AsBool())
+	require.Equal(t, "api.Handlers/Post", quarantined["tests.case.owner"].AsString())
+	require.NotContains(t, quarantined, attribute.Key(testCaseSystemOut))
+	require.Equal(t, "Get", spans["Get"]["tests.case.owner"].AsString())
+
+	// the duration is recorded with the new name and status
+	metrics := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(ctx, &metrics))
+	statuses := map[string]string{}
+	for _, m := range metrics.ScopeMetrics[0].Metrics {
+		if histogram, ok := m.Data.(metricdata.Histogram[int64]); ok {
+			for _, point := range histogram.DataPoints {
+				name, _ := point.Attributes.Value("code.function")
+				status, _ := point.Attributes.Value(testCaseStatus)
+				statuses[name.AsString()] = status.AsString()
+			}
+		}
+	}
+	require.Equal(t, map[string]string{"Get": "passed", "api.Handlers/Post": "quarantined"}, statuses)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/joshdk/go-junit"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// defaultCostCurrency the currency of the rates of the runners, if not set in the config file
+const defaultCostCurrency = "USD"
+
+// costConfig the rates per minute of the types of runners of the CI system, i.e. the ones of the hosted runners of
+// GitHub Actions, so the cost of the runs is estimated from the type of their runner
+type costConfig struct {
+	Currency    string             `yaml:"currency"`
+	RunnerRates map[string]float64 `yaml:"runner_rates"`
+}
+
+func (c costConfig) validate() error {
+	for runnerType, rate := range c.RunnerRates {
+		if rate < 0 || math.IsNaN(rate) || math.IsInf(rate, 0) {
+			return fmt.Errorf("invalid rate %v of the runner type %q: it must be a positive number", rate, runnerType)
+		}
+	}
+
+	return nil
+}
+
+// runCost the estimated cost of the machine time of the run, attached to its spans, or nil if it's not estimated
+var runCost *costEstimate
+
+// costEstimate estimates the cost of the suites from their duration, with the rate per minute of the runner
+type costEstimate struct {
+	ratePerMinute float64
+	currency      string
+}
+
+// newCostEstimate returns the estimate with the rate of the runner type in the config file, or the rate per minute
+// otherwise, or nil if there is no rate
+func newCostEstimate(cfg costConfig, perMinute float64, runnerType string) (*costEstimate, error) {
+	if perMinute < 0 {
+		return nil, fmt.Errorf("invalid --cost-per-minute %v: it can't be negative", perMinute)
+	}
+
+	currency := cfg.Currency
+	if currency == "" {
+		currency = defaultCostCurrency
+	}
+
+	if runnerType != "" {
+		if rate, ok := cfg.RunnerRates[runnerType]; ok {
+			return &costEstimate{ratePerMinute: rate, currency: currency}, nil
+		}
+
+		if perMinute == 0 {
+			runnerTypes := make([]string, 0, len(cfg.RunnerRates))
+			for name := range cfg.RunnerRates {
+				runnerTypes = append(runnerTypes, name)
+			}
+			sort.Strings(runnerTypes)
+
+			return nil, fmt.Errorf("unknown runner type %q: the runner_rates of the costs section of the config file are %s", runnerType, strings.Join(runnerTypes, ", "))
+		}
+	}
+
+	if perMinute == 0 {
+		return nil, nil
+	}
+
+	return &costEstimate{ratePerMinute: perMinute, currency: currency}, nil
+}
+
+// cost returns the estimated cost of the machine time
+func (c *costEstimate) cost(duration time.Duration) float64 {
+	return duration.Minutes() * c.ratePerMinute
+}
+
+// runAttributes returns the attributes of the estimated cost of the run, from the durations of its suites
+func (c *costEstimate) runAttributes(report *junitReport) []attribute.KeyValue {
+	if c == nil {
+		return nil
+	}
+
+	var duration time.Duration
+	for _, suite := range report.suites {
+		duration += suite.Totals.Duration
+	}
+
+	return []attribute.KeyValue{
+		attribute.Key(TestsRunCost).Float64(c.cost(duration)),
+		attribute.Key(TestsCostCurrency).String(c.currency),
+	}
+}
+
+// suiteAttributes returns the attributes of the estimated cost of the suite
+func (c *costEstimate) suiteAttributes(suite junit.Suite) []attribute.KeyValue {
+	if c == nil {
+		return nil
+	}
+
+	return []attribute.KeyValue{attribute.Key(TestsSuiteCost).Float64(c.cost(suite.Totals.Duration))}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestNewCostEstimate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+costs:
+  currency: EUR
+  runner_rates:
+    ubuntu-latest: 0.008
+    macos-latest: 0.08
+`), 0o644))
+
+	cfg, err := loadConfig(path)
+	require.NoError(t, err)
+
+	estimate, err := newCostEstimate(cfg.Costs, 0, "")
+	require.NoError(t, err)
+	require.Nil(t, estimate)
+
+	// the rate of the runner type takes precedence over the rate per minute
+	estimate, err = newCostEstimate(cfg.Costs, 0.5, "macos-latest")
+	require.NoError(t, err)
+	require.Equal(t, &costEstimate{ratePerMinute: 0.08, currency: "EUR"}, estimate)
+
+	estimate, err = newCostEstimate(cfg.Costs, 0.5, "windows-latest")
+	require.NoError(t, err)
+	require.Equal(t, &costEstimate{ratePerMinute: 0.5, currency: "EUR"}, estimate)
+
+	estimate, err = newCostEstimate(costConfig{}, 0.5, "")
+	require.NoError(t, err)
+	require.Equal(t, defaultCostCurrency, estimate.currency)
+
+	_, err = newCostEstimate(cfg.Costs, 0, "windows-latest")
+	require.ErrorContains(t, err, "macos-latest, ubuntu-latest")
+
+	_, err = newCostEstimate(cfg.Costs, -1, "")
+	require.Error(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte("costs:\n  runner_rates:\n    ubuntu-latest: -1\n"), 0o644))
+	_, err = loadConfig(path)
+	require.ErrorContains(t, err, "invalid costs")
+}
+
+func TestCostAttributes(t *testing.T) {
+	defer func() { runCost = nil }()
+	runCost = &costEstimate{ratePerMinute: 0.5, currency: "USD"}
+
+	suite := junit.Suite{
+		Name:   "checkout",
+		Tests:  []junit.Test{{Name: "pays", Duration: 3 * time.Minute, Status: junit.StatusPassed}},
+		Totals: junit.Totals{Tests: 1, Passed: 1, Duration: 3 * time.Minute},
+	}
+
+	require.Equal(t, []attribute.KeyValue{
+		attribute.Float64(TestsRunCost, 1.5),
+		attribute.String(TestsCostCurrency, "USD"),
+	}, runCost.runAttributes(&junitReport{suites: []junit.Suite{suite}}))
+
+	exporter := tracetest.NewInMemoryExporter()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)).Tracer("test")
+	meter := sdkmetric.NewMeterProvider().Meter("test")
+
+	createSuiteSpans(context.Background(), tracer, newTestCaseMetrics(meter, 0), suite, nil, nil, nil, time.Now())
+
+	// the cost is only an attribute of the suite span
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	require.NotContains(t, spans[0].Attributes, attribute.Float64(TestsSuiteCost, 1.5))
+	require.Contains(t, spans[1].Attributes, attribute.Float64(TestsSuiteCost, 1.5))
+
+	var nilCost *costEstimate
+	require.Nil(t, nilCost.runAttributes(&junitReport{}))
+	require.Nil(t, nilCost.suiteAttributes(suite))
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/joshdk/go-junit"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// declaredSuiteCounts the numeric attributes of the suite elements, as declared by the test framework, and the
+// attributes of the suite spans for them
+var declaredSuiteCounts = []struct {
+	name string
+	key  string
+}{
+	{"tests", SuiteDeclaredTests},
+	{"failures", SuiteDeclaredFailures},
+	{"errors", SuiteDeclaredErrors},
+	{"skipped", SuiteDeclaredSkipped},
+}
+
+// suiteCountAttributes returns the numeric attributes declared in the suite element: the number of assertions
+// (i.e. PHPUnit or Rust nextest), the counts of tests and the time, in seconds. The missing or unparseable
+// attributes are skipped. The raw XML element can be nil, i.e. for the nested suites
+func suiteCountAttributes(rawSuite *xmlElement) []attribute.KeyValue {
+	if rawSuite == nil {
+		return nil
+	}
+
+	attributes := []attribute.KeyValue{}
+	if assertions, ok := suiteAssertions(rawSuite); ok {
+		attributes = append(attributes, attribute.Key(AssertionsCount).Int64(assertions))
+	}
+
+	for _, count := range declaredSuiteCounts {
+		if value, ok := parseCount(rawSuite.Attrs[count.name]); ok {
+			attributes = append(attributes, attribute.Key(count.key).Int64(value))
+		}
+	}
+
+	if value, err := strconv.ParseFloat(strings.TrimSpace(rawSuite.Attrs["time"]), 64); err == nil {
+		attributes = append(attributes, attribute.Key(SuiteDeclaredTime).Float64(value))
+	}
+
+	return attributes
+}
+
+// suiteAssertions returns the number of assertions declared in the suite element, and if it's present
+func suiteAssertions(rawSuite *xmlElement) (int64, bool) {
+	if rawSuite == nil {
+		return 0, false
+	}
+
+	return parseCount(rawSuite.Attrs["assertions"])
+}
+
+// testCountAttributes returns the number of assertions of the test case, if it's declared
+func testCountAttributes(test junit.Test) []attribute.KeyValue {
+	if assertions, ok := parseCount(test.Properties["assertions"]); ok {
+		return []attribute.KeyValue{attribute.Key(TestAssertions).Int64(assertions)}
+	}
+
+	return nil
+}
+
+// parseCount parses a non-negative count, allowing the thousands separators of some frameworks, i.e. "1,024"
+func parseCount(value string) (int64, bool) {
+	value = strings.ReplaceAll(strings.TrimSpace(value), ",", "")
+	count, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || count < 0 {
+		return 0, false
+	}
+
+	return count, true
+}
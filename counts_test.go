@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestSuiteCountAttributes(t *testing.T) {
+	t.Run("PHPUnit suite", func(t *testing.T) {
+		rawSuite := &xmlElement{Attrs: map[string]string{
+			"name":       "UserTest",
+			"tests":      "3",
+			"assertions": "1,024",
+			"errors":     "0",
+			"failures":   "1",
+			"skipped":    "0",
+			"time":       "0.012345",
+		}}
+
+		require.Equal(t, []attribute.KeyValue{
+			attribute.Int64(AssertionsCount, 1024),
+			attribute.Int64(SuiteDeclaredTests, 3),
+			attribute.Int64(SuiteDeclaredFailures, 1),
+			attribute.Int64(SuiteDeclaredErrors, 0),
+			attribute.Int64(SuiteDeclaredSkipped, 0),
+			attribute.Float64(SuiteDeclaredTime, 0.012345),
+		}, suiteCountAttributes(rawSuite))
+	})
+
+	t.Run("Missing and invalid attributes", func(t *testing.T) {
+		rawSuite := &xmlElement{Attrs: map[string]string{"tests": "many", "assertions": "-1", "time": ""}}
+
+		require.Empty(t, suiteCountAttributes(rawSuite))
+		require.Nil(t, suiteCountAttributes(nil))
+
+		_, ok := suiteAssertions(nil)
+		require.False(t, ok)
+	})
+
+	t.Run("Test case assertions", func(t *testing.T) {
+		test := junit.Test{Name: "testLogin", Properties: map[string]string{"assertions": "7"}}
+		require.Equal(t, []attribute.KeyValue{attribute.Int64(TestAssertions, 7)}, testCountAttributes(test))
+
+		require.Empty(t, testCountAttributes(junit.Test{Name: "testLogout"}))
+	})
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/joshdk/go-junit"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const coverageCommand = "coverage"
+
+// runCoverage ingests the per-test coverage reports into the state, so that the impacted tests are the ones
+// covering the changed files, and the test spans have the files they cover
+func runCoverage(args []string) {
+	if err := resolveFlags(flag.CommandLine, args); err != nil {
+		log.Fatal(err)
+	}
+
+	if stateFlag == "" {
+		log.Fatal("the state is required to ingest the coverage: set it with --state")
+	}
+
+	coverage := map[string][]string{}
+	for _, file := range flag.Args() {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			log.Fatalf("failed to read the coverage report: %v", err)
+		}
+
+		if err := parseCoverage(data, coverage); err != nil {
+			log.Fatalf("failed to parse the coverage report %s: %v", file, err)
+		}
+	}
+
+	err := updateState(context.Background(), stateFlag, func(state *toolState) error {
+		mergeCoverage(state, coverage)
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("ingested the coverage of %d tests into %s\n", len(coverage), stateFlag)
+}
+
+// parseCoverage adds the source files covered by each test in the report to the coverage: a JaCoCo XML report,
+// or the JSON report of coverage.py with the contexts of the tests
+func parseCoverage(data []byte, coverage map[string][]string) error {
+	data = bytes.TrimSpace(data)
+
+	switch {
+	case bytes.HasPrefix(data, []byte("<")):
+		return parseJaCoCoCoverage(data, coverage)
+	case bytes.HasPrefix(data, []byte("{")):
+		return parseCoveragePyCoverage(data, coverage)
+	default:
+		return errors.New("unknown format: the supported ones are the XML report of JaCoCo and the JSON report of coverage.py")
+	}
+}
+
+// jacocoReport the elements of a JaCoCo XML report with the covered source files, in packages that may be
+// grouped, i.e. by module
+type jacocoReport struct {
+	Sessions []struct {
+		ID string `xml:"id,attr"`
+	} `xml:"sessioninfo"`
+	jacocoGroup
+}
+
+type jacocoGroup struct {
+	Groups   []jacocoGroup   `xml:"group"`
+	Packages []jacocoPackage `xml:"package"`
+}
+
+type jacocoPackage struct {
+	Name        string             `xml:"name,attr"`
+	SourceFiles []jacocoSourceFile `xml:"sourcefile"`
+}
+
+type jacocoSourceFile struct {
+	Name     string          `xml:"name,attr"`
+	Counters []jacocoCounter `xml:"counter"`
+}
+
+type jacocoCounter struct {
+	Type    string `xml:"type,attr"`
+	Covered int    `xml:"covered,attr"`
+}
+
+// covered checks if any line of the source file is covered
+func (f jacocoSourceFile) covered() bool {
+	return slices.ContainsFunc(f.Counters, func(counter jacocoCounter) bool {
+		return counter.Type == "LINE" && counter.Covered > 0
+	})
+}
+
+// parseJaCoCoCoverage reads a JaCoCo report per test: the ID of its session is the selector of the test, i.e.
+// com.acme.CartTest#testTotal, and the source files with covered lines are the ones covered by the test. The
+// coverage of a report with several sessions is merged, so their tests cover all its source files
+func parseJaCoCoCoverage(data []byte, coverage map[string][]string) error {
+	report := jacocoReport{}
+	if err := xml.Unmarshal(data, &report); err != nil {
+		return err
+	}
+
+	files := []string{}
+	var collect func(group jacocoGroup)
+	collect = func(group jacocoGroup) {
+		for _, pkg := range group.Packages {
+			for _, sourceFile := range pkg.SourceFiles {
+				if sourceFile.covered() {
+					files = append(files, path.Join(pkg.Name, sourceFile.Name))
+				}
+			}
+		}
+
+		for _, child := range group.Groups {
+			collect(child)
+		}
+	}
+	collect(report.jacocoGroup)
+
+	for _, session := range report.Sessions {
+		if session.ID != "" {
+			addCoverage(coverage, session.ID, files...)
+		}
+	}
+
+	return nil
+}
+
+// coveragePyReport the JSON report of coverage.py, created with coverage json --show-contexts
+type coveragePyReport struct {
+	Files map[string]struct {
+		Contexts map[string][]string `json:"contexts"`
+	} `json:"files"`
+}
+
+// parseCoveragePyCoverage reads the contexts of the lines of each file, which pytest-cov sets to the ID of the
+// test running them with --cov-context=test, i.e. tests/test_auth.py::TestAuth::test_login|run
+func parseCoveragePyCoverage(data []byte, coverage map[string][]string) error {
+	report := coveragePyReport{}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return err
+	}
+
+	contexts := 0
+	for file, fileReport := range report.Files {
+		for _, lineContexts := range fileReport.Contexts {
+			for _, context := range lineContexts {
+				contexts++
+				if selector := pytestSelector(context); selector != "" {
+					addCoverage(coverage, selector, file)
+				}
+			}
+		}
+	}
+
+	if contexts == 0 && len(report.Files) > 0 {
+		return errors.New("the report has no contexts: create it with coverage json --show-contexts, and run pytest with --cov-context=test")
+	}
+
+	return nil
+}
+
+// pytestSelector returns the selector of the test of the pytest node ID, with the class name of the JUnit report
+// of pytest, i.e. tests.test_auth.TestAuth#test_login, or empty if the context is not a test
+func pytestSelector(context string) string {
+	nodeID, _, _ := strings.Cut(context, "|")
+	parts := strings.Split(nodeID, "::")
+	if len(parts) < 2 {
+		return ""
+	}
+
+	module := strings.ReplaceAll(strings.TrimSuffix(parts[0], ".py"), "/", ".")
+	classname := strings.Join(append([]string{module}, parts[1:len(parts)-1]...), ".")
+
+	return testSelector(classname, parts[len(parts)-1])
+}
+
+func addCoverage(coverage map[string][]string, selector string, files ...string) {
+	for _, file := range files {
+		if !slices.Contains(coverage[selector], file) {
+			coverage[selector] = append(coverage[selector], file)
+		}
+	}
+
+	if _, ok := coverage[selector]; !ok {
+		coverage[selector] = []string{}
+	}
+}
+
+// mergeCoverage replaces the coverage of the ingested tests in the state, keeping the one of the other tests
+func mergeCoverage(state *toolState, coverage map[string][]string) {
+	if state.Coverage == nil {
+		state.Coverage = map[string][]string{}
+	}
+
+	for selector, files := range coverage {
+		files = slices.Clone(files)
+		slices.Sort(files)
+		state.Coverage[selector] = files
+	}
+}
+
+// coverageAttributes returns the test.covers.files attribute of the test, with the source files it covers in
+// the coverage of the state
+func coverageAttributes(coverage impactMap, suite junit.Suite, test junit.Test) []attribute.KeyValue {
+	if len(coverage) == 0 {
+		return nil
+	}
+
+	files, ok := coverage.sources(newImpactedTest(suite, test))
+	if !ok || len(files) == 0 {
+		return nil
+	}
+
+	return []attribute.KeyValue{attribute.Key(TestCoversFiles).StringSlice(files)}
+}
+
+// stateCoverage returns the coverage of the tests in the state, with the entries of the impact map, which take
+// precedence
+func stateCoverage(state *toolState, mapping impactMap) impactMap {
+	coverage := impactMap{}
+	if state != nil {
+		maps.Copy(coverage, state.Coverage)
+	}
+	maps.Copy(coverage, mapping)
+
+	return coverage
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestParseCoverage(t *testing.T) {
+	t.Run("JaCoCo", func(t *testing.T) {
+		report := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<!DOCTYPE report PUBLIC "-//JACOCO//DTD Report 1.1//EN" "report.dtd">
+<report name="shop">
+  <sessioninfo id="com.acme.CartTest#testTotal" start="1704110400000" dump="1704110401000"/>
+  <group name="cart">
+    <package name="com/acme">
+      <sourcefile name="Cart.java">
+        <counter type="INSTRUCTION" missed="2" covered="10"/>
+        <counter type="LINE" missed="1" covered="4"/>
+      </sourcefile>
+      <sourcefile name="Checkout.java">
+        <counter type="LINE" missed="8" covered="0"/>
+      </sourcefile>
+    </package>
+  </group>
+  <package name="com/acme/pricing">
+    <sourcefile name="Discount.java">
+      <counter type="LINE" missed="0" covered="2"/>
+    </sourcefile>
+  </package>
+</report>`
+
+		coverage := map[string][]string{}
+		require.NoError(t, parseCoverage([]byte(report), coverage))
+		require.Equal(t, map[string][]string{
+			"com.acme.CartTest#testTotal": {"com/acme/pricing/Discount.java", "com/acme/Cart.java"},
+		}, coverage)
+	})
+
+	t.Run("coverage.py", func(t *testing.T) {
+		report := `{
+  "meta": {"version": "7.4.0", "show_contexts": true},
+  "files": {
+    "app/auth.py": {
+      "executed_lines": [1, 2, 3],
+      "contexts": {
+        "1": [""],
+        "2": ["tests/test_auth.py::TestAuth::test_login|run", "tests/test_auth.py::test_logout|run"],
+        "3": ["tests/test_auth.py::TestAuth::test_login|run"]
+      }
+    },
+    "app/session.py": {
+      "contexts": {"7": ["tests/test_auth.py::test_logout|setup"]}
+    }
+  }
+}`
+
+		coverage := map[string][]string{}
+		require.NoError(t, parseCoverage([]byte(report), coverage))
+		require.Len(t, coverage, 2)
+		require.Equal(t, []string{"app/auth.py"}, coverage["tests.test_auth.TestAuth#test_login"])
+		require.ElementsMatch(t, []string{"app/auth.py", "app/session.py"}, coverage["tests.test_auth#test_logout"])
+	})
+
+	t.Run("coverage.py without contexts", func(t *testing.T) {
+		err := parseCoverage([]byte(`{"files": {"app/auth.py": {"executed_lines": [1]}}}`), map[string][]string{})
+		require.ErrorContains(t, err, "--show-contexts")
+	})
+
+	t.Run("Unknown format", func(t *testing.T) {
+		require.Error(t, parseCoverage([]byte("SF:app/auth.py"), map[string][]string{}))
+	})
+}
+
+func TestMergeCoverage(t *testing.T) {
+	state := &toolState{Coverage: map[string][]string{
+		"com.acme.CartTest#testTotal": {"com/acme/Cart.java"},
+		"com.acme.CartTest#testEmpty": {"com/acme/Cart.java"},
+	}}
+
+	mergeCoverage(state, map[string][]string{"com.acme.CartTest#testTotal": {"com/acme/Price.java", "com/acme/Cart.java"}})
+
+	require.Equal(t, map[string][]string{
+		"com.acme.CartTest#testTotal": {"com/acme/Cart.java", "com/acme/Price.java"},
+		"com.acme.CartTest#testEmpty": {"com/acme/Cart.java"},
+	}, state.Coverage)
+}
+
+func TestCoverageAttributes(t *testing.T) {
+	coverage := impactMap{"com.acme.CartTest#testTotal": {"com/acme/Cart.java"}}
+	suite := junit.Suite{Name: "cart"}
+
+	require.Equal(t,
+		[]attribute.KeyValue{attribute.Key(TestCoversFiles).StringSlice([]string{"com/acme/Cart.java"})},
+		coverageAttributes(coverage, suite, junit.Test{Name: "testTotal", Classname: "com.acme.CartTest"}),
+	)
+	require.Empty(t, coverageAttributes(coverage, suite, junit.Test{Name: "testEmpty", Classname: "com.acme.CartTest"}))
+	require.Empty(t, coverageAttributes(nil, suite, junit.Test{Name: "testTotal", Classname: "com.acme.CartTest"}))
+
+	// the impact map takes precedence over the coverage of the state
+	merged := stateCoverage(&toolState{Coverage: coverage}, impactMap{"com.acme.CartTest#testTotal": {"src/**"}})
+	require.Equal(t, impactMap{"com.acme.CartTest#testTotal": {"src/**"}}, merged)
+}
@@ -0,0 +1,423 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/template"
+)
+
+const dashboardsCommand = "dashboards"
+
+// the backends of the dashboards: Grafana, with the Prometheus data source fed by the collector, and SigNoz
+const (
+	dashboardBackendGrafana = "grafana"
+	dashboardBackendSigNoz  = "signoz"
+)
+
+// dashboardsSchemaVersion the version of the names of the metrics and their attributes the dashboards are wired to,
+// bumped when they change, so the dashboards of an older version of the tool can be told apart
+const dashboardsSchemaVersion = 1
+
+// dashboardPanel a panel of the dashboards, whose queries are PromQL templates with the counter and histogram
+// functions returning the name of the metric in the backend, and the interval function the one of its rates
+type dashboardPanel struct {
+	title       string
+	description string
+	// stat if the panel shows a single value, instead of a time series
+	stat    bool
+	unit    string
+	queries []dashboardQuery
+}
+
+type dashboardQuery struct {
+	expr   string
+	legend string
+}
+
+// dashboardPanels the panels of the dashboards, wired to the metrics of the suites and the test cases
+var dashboardPanels = []dashboardPanel{
+	{
+		title:       "Pass rate",
+		description: "Ratio of the passed test cases over the executed ones",
+		stat:        true,
+		unit:        "percentunit",
+		queries: []dashboardQuery{
+			{expr: `sum(increase({{counter "` + PassedTestsCount + `"}}[{{interval}}])) / sum(increase({{counter "` + TotalTestsCount + `"}}[{{interval}}]))`},
+		},
+	},
+	{
+		title:       "Test cases by status",
+		description: "Number of test cases passed, failed, errored and skipped",
+		queries: []dashboardQuery{
+			{expr: `sum(increase({{counter "` + PassedTestsCount + `"}}[{{interval}}]))`, legend: "passed"},
+			{expr: `sum(increase({{counter "` + FailedTestsCount + `"}}[{{interval}}]))`, legend: "failed"},
+			{expr: `sum(increase({{counter "` + ErrorTestsCount + `"}}[{{interval}}]))`, legend: "errors"},
+			{expr: `sum(increase({{counter "` + SkippedTestsCount + `"}}[{{interval}}]))`, legend: "skipped"},
+		},
+	},
+	{
+		title:       "Failed test cases by suite",
+		description: "Number of failed and errored test cases of each suite",
+		queries: []dashboardQuery{
+			{expr: `sum by ({{label "` + TestsSuiteName + `"}}) (increase({{counter "` + FailedTestsCount + `"}}[{{interval}}]) + increase({{counter "` + ErrorTestsCount + `"}}[{{interval}}]))`, legend: "{{legend \"" + TestsSuiteName + "\"}}"},
+		},
+	},
+	{
+		title:       "Passed on retry",
+		description: "Number of test cases passed on a retry, the flaky ones",
+		queries: []dashboardQuery{
+			{expr: `sum by ({{label "` + TestsSuiteName + `"}}) (increase({{counter "` + PassedOnRetryTestsCount + `"}}[{{interval}}]))`, legend: "{{legend \"" + TestsSuiteName + "\"}}"},
+		},
+	},
+	{
+		title:       "Duration of the suites",
+		description: "Duration of the test cases of each suite, in milliseconds",
+		unit:        "ms",
+		queries: []dashboardQuery{
+			{expr: `sum by ({{label "` + TestsSuiteName + `"}}) (increase({{counter "` + TestsDuration + `"}}[{{interval}}]))`, legend: "{{legend \"" + TestsSuiteName + "\"}}"},
+		},
+	},
+	{
+		title:       "Duration of the test cases, p95",
+		description: "95th percentile of the duration of the test cases, in milliseconds",
+		unit:        "ms",
+		queries: []dashboardQuery{
+			{expr: `histogram_quantile(0.95, sum by (le) (rate({{histogram "` + TestCaseDurationHistogram + `"}}_bucket[{{interval}}])))`, legend: "p95"},
+		},
+	},
+	{
+		title:       "Duration by owner",
+		description: "Duration of the test cases of each owner, in milliseconds, from the ownership mapping",
+		unit:        "ms",
+		queries: []dashboardQuery{
+			{expr: `sum by ({{label "` + TestOwner + `"}}) (increase({{counter "` + TestsDurationByOwner + `"}}[{{interval}}]))`, legend: "{{legend \"" + TestOwner + "\"}}"},
+		},
+	},
+	{
+		title:       "Timeouts",
+		description: "Number of test cases failed by a timeout",
+		queries: []dashboardQuery{
+			{expr: `sum by ({{label "` + TestsSuiteName + `"}}) (increase({{counter "` + TimeoutTestsCount + `"}}[{{interval}}]))`, legend: "{{legend \"" + TestsSuiteName + "\"}}"},
+		},
+	},
+	{
+		title:       "Hanging suites",
+		description: "Number of expected suites whose report did not arrive in time, in server mode",
+		queries: []dashboardQuery{
+			{expr: `sum by ({{label "` + TestsSuiteName + `"}}) (increase({{counter "` + TestsSuitesHanging + `"}}[{{interval}}]))`, legend: "{{legend \"" + TestsSuiteName + "\"}}"},
+		},
+	},
+	{
+		title:       "Idle time of the sessions",
+		description: "Idle time before the suites of the sessions, in milliseconds, the waste of the orchestration of the pipelines",
+		unit:        "ms",
+		queries: []dashboardQuery{
+			{expr: `sum(increase({{counter "` + TestsSessionIdle + `"}}[{{interval}}]))`, legend: "idle"},
+		},
+	},
+}
+
+// dashboardNaming the names of the metrics of a backend, and the interval of the rates
+type dashboardNaming struct {
+	counter   func(name string) string
+	histogram func(name string) string
+	interval  string
+}
+
+func newDashboardNaming(backend string) (*dashboardNaming, error) {
+	switch backend {
+	case dashboardBackendGrafana:
+		// the names of the Prometheus exporter of the collector, with the suffixes of the type and the unit
+		return &dashboardNaming{
+			counter:   func(name string) string { return strings.TrimSuffix(prometheusName(name), "_total") + "_total" },
+			histogram: func(name string) string { return prometheusName(name) + "_milliseconds" },
+			interval:  "$__rate_interval",
+		}, nil
+	case dashboardBackendSigNoz:
+		return &dashboardNaming{
+			counter:   prometheusName,
+			histogram: prometheusName,
+			interval:  "5m",
+		}, nil
+	default:
+		return nil, fmt.Errorf("invalid backend %q: valid values are %s and %s", backend, dashboardBackendGrafana, dashboardBackendSigNoz)
+	}
+}
+
+// render returns the PromQL query, or the legend, of the template for the backend. The legends reference the labels
+// of the series with the {{label}} syntax of both backends
+func (n *dashboardNaming) render(text string) (string, error) {
+	tmpl, err := template.New("query").Funcs(template.FuncMap{
+		"counter":   n.counter,
+		"histogram": n.histogram,
+		"interval":  func() string { return n.interval },
+		"label":     prometheusName,
+		"legend":    func(label string) string { return "{{" + prometheusName(label) + "}}" },
+	}).Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	sb := strings.Builder{}
+	if err := tmpl.Execute(&sb, nil); err != nil {
+		return "", err
+	}
+
+	return sb.String(), nil
+}
+
+// dashboardTags the tags of the dashboards, with the version of the schema of their metrics
+func dashboardTags() []string {
+	return []string{Junit2otlp, "tests", fmt.Sprintf("schema-v%d", dashboardsSchemaVersion)}
+}
+
+func dashboardDescription() string {
+	return fmt.Sprintf("Results of the tests exported by %s %s, schema v%d", Junit2otlp, version, dashboardsSchemaVersion)
+}
+
+// the dashboard of Grafana, with the Prometheus data source selected by a variable
+type grafanaDashboard struct {
+	UID           string            `json:"uid"`
+	Title         string            `json:"title"`
+	Description   string            `json:"description"`
+	Tags          []string          `json:"tags"`
+	SchemaVersion int               `json:"schemaVersion"`
+	Version       int               `json:"version"`
+	Editable      bool              `json:"editable"`
+	Time          grafanaTime       `json:"time"`
+	Templating    grafanaTemplating `json:"templating"`
+	Panels        []grafanaPanel    `json:"panels"`
+}
+
+type grafanaTime struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type grafanaTemplating struct {
+	List []grafanaVariable `json:"list"`
+}
+
+type grafanaVariable struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+	Type  string `json:"type"`
+	Query string `json:"query"`
+}
+
+type grafanaPanel struct {
+	ID          int                `json:"id"`
+	Type        string             `json:"type"`
+	Title       string             `json:"title"`
+	Description string             `json:"description"`
+	Datasource  grafanaDatasource  `json:"datasource"`
+	GridPos     grafanaGridPos     `json:"gridPos"`
+	Targets     []grafanaTarget    `json:"targets"`
+	FieldConfig grafanaFieldConfig `json:"fieldConfig"`
+}
+
+type grafanaDatasource struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+}
+
+type grafanaGridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type grafanaTarget struct {
+	RefID        string            `json:"refId"`
+	Datasource   grafanaDatasource `json:"datasource"`
+	Expr         string            `json:"expr"`
+	LegendFormat string            `json:"legendFormat,omitempty"`
+}
+
+type grafanaFieldConfig struct {
+	Defaults grafanaFieldDefaults `json:"defaults"`
+}
+
+type grafanaFieldDefaults struct {
+	Unit string `json:"unit,omitempty"`
+}
+
+// the dashboard of SigNoz, with the PromQL queries of its panels
+type signozDashboard struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Tags        []string       `json:"tags"`
+	Version     string         `json:"version"`
+	Layout      []signozLayout `json:"layout"`
+	Widgets     []signozWidget `json:"widgets"`
+}
+
+type signozLayout struct {
+	I string `json:"i"`
+	X int    `json:"x"`
+	Y int    `json:"y"`
+	W int    `json:"w"`
+	H int    `json:"h"`
+}
+
+type signozWidget struct {
+	ID          string      `json:"id"`
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	PanelTypes  string      `json:"panelTypes"`
+	Query       signozQuery `json:"query"`
+}
+
+type signozQuery struct {
+	QueryType string         `json:"queryType"`
+	PromQL    []signozPromQL `json:"promql"`
+}
+
+type signozPromQL struct {
+	Name     string `json:"name"`
+	Query    string `json:"query"`
+	Legend   string `json:"legend"`
+	Disabled bool   `json:"disabled"`
+}
+
+// renderQueries returns the PromQL queries and the legends of the panel for the backend
+func renderQueries(naming *dashboardNaming, panel dashboardPanel) ([]dashboardQuery, error) {
+	queries := make([]dashboardQuery, 0, len(panel.queries))
+	for _, query := range panel.queries {
+		expr, err := naming.render(query.expr)
+		if err != nil {
+			return nil, fmt.Errorf("panel %q: %w", panel.title, err)
+		}
+
+		legend, err := naming.render(query.legend)
+		if err != nil {
+			return nil, fmt.Errorf("panel %q: %w", panel.title, err)
+		}
+
+		queries = append(queries, dashboardQuery{expr: expr, legend: legend})
+	}
+
+	return queries, nil
+}
+
+// newGrafanaDashboard returns the dashboard of Grafana, with the panels laid out in two columns
+func newGrafanaDashboard(naming *dashboardNaming) (*grafanaDashboard, error) {
+	dashboard := &grafanaDashboard{
+		UID:           Junit2otlp,
+		Title:         "Tests",
+		Description:   dashboardDescription(),
+		Tags:          dashboardTags(),
+		SchemaVersion: 39,
+		Version:       dashboardsSchemaVersion,
+		Editable:      true,
+		Time:          grafanaTime{From: "now-7d", To: "now"},
+		Templating:    grafanaTemplating{List: []grafanaVariable{{Name: "datasource", Label: "Data source", Type: "datasource", Query: "prometheus"}}},
+	}
+
+	datasource := grafanaDatasource{Type: "prometheus", UID: "${datasource}"}
+	for i, panel := range dashboardPanels {
+		queries, err := renderQueries(naming, panel)
+		if err != nil {
+			return nil, err
+		}
+
+		grafanaPanel := grafanaPanel{
+			ID:          i + 1,
+			Type:        "timeseries",
+			Title:       panel.title,
+			Description: panel.description,
+			Datasource:  datasource,
+			GridPos:     grafanaGridPos{H: 8, W: 12, X: (i % 2) * 12, Y: (i / 2) * 8},
+			FieldConfig: grafanaFieldConfig{Defaults: grafanaFieldDefaults{Unit: panel.unit}},
+		}
+		if panel.stat {
+			grafanaPanel.Type = "stat"
+		}
+
+		for j, query := range queries {
+			grafanaPanel.Targets = append(grafanaPanel.Targets, grafanaTarget{RefID: string(rune('A' + j)), Datasource: datasource, Expr: query.expr, LegendFormat: query.legend})
+		}
+
+		dashboard.Panels = append(dashboard.Panels, grafanaPanel)
+	}
+
+	return dashboard, nil
+}
+
+// newSigNozDashboard returns the dashboard of SigNoz, with the panels laid out in two columns
+func newSigNozDashboard(naming *dashboardNaming) (*signozDashboard, error) {
+	dashboard := &signozDashboard{
+		Title:       "Tests",
+		Description: dashboardDescription(),
+		Tags:        dashboardTags(),
+		Version:     "v4",
+	}
+
+	for i, panel := range dashboardPanels {
+		queries, err := renderQueries(naming, panel)
+		if err != nil {
+			return nil, err
+		}
+
+		widget := signozWidget{
+			ID:          fmt.Sprintf("%s-%d", Junit2otlp, i+1),
+			Title:       panel.title,
+			Description: panel.description,
+			PanelTypes:  "graph",
+			Query:       signozQuery{QueryType: "promql"},
+		}
+		if panel.stat {
+			widget.PanelTypes = "value"
+		}
+
+		for j, query := range queries {
+			widget.Query.PromQL = append(widget.Query.PromQL, signozPromQL{Name: string(rune('A' + j)), Query: query.expr, Legend: query.legend})
+		}
+
+		dashboard.Layout = append(dashboard.Layout, signozLayout{I: widget.ID, X: (i % 2) * 6, Y: (i / 2) * 8, W: 6, H: 8})
+		dashboard.Widgets = append(dashboard.Widgets, widget)
+	}
+
+	return dashboard, nil
+}
+
+// writeDashboard writes the JSON of the dashboard of the backend, to be imported into it
+func writeDashboard(w io.Writer, backend string) error {
+	naming, err := newDashboardNaming(backend)
+	if err != nil {
+		return err
+	}
+
+	var dashboard any
+	if backend == dashboardBackendSigNoz {
+		dashboard, err = newSigNozDashboard(naming)
+	} else {
+		dashboard, err = newGrafanaDashboard(naming)
+	}
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+
+	return encoder.Encode(dashboard)
+}
+
+// runDashboards parses the flags of the dashboards command, and prints the dashboard of the backend
+func runDashboards(args []string) {
+	if err := resolveFlags(flag.CommandLine, args); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := writeDashboard(os.Stdout, dashboardBackendFlag); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteDashboard(t *testing.T) {
+	t.Run("Grafana", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		require.NoError(t, writeDashboard(out, dashboardBackendGrafana))
+
+		dashboard := grafanaDashboard{}
+		require.NoError(t, json.Unmarshal(out.Bytes(), &dashboard))
+		require.Equal(t, dashboardsSchemaVersion, dashboard.Version)
+		require.Contains(t, dashboard.Tags, "schema-v1")
+		require.Len(t, dashboard.Panels, len(dashboardPanels))
+
+		passRate := dashboard.Panels[0]
+		require.Equal(t, "stat", passRate.Type)
+		require.Equal(t, "percentunit", passRate.FieldConfig.Defaults.Unit)
+		require.Equal(t, "sum(increase(tests_suite_passed_total[$__rate_interval])) / sum(increase(tests_suite_total[$__rate_interval]))", passRate.Targets[0].Expr)
+
+		bySuite := dashboard.Panels[2]
+		require.Equal(t, "timeseries", bySuite.Type)
+		require.Equal(t, "{{tests_suite_suitename}}", bySuite.Targets[0].LegendFormat)
+		require.Contains(t, bySuite.Targets[0].Expr, "sum by (tests_suite_suitename) (")
+
+		require.Equal(t, "histogram_quantile(0.95, sum by (le) (rate(tests_case_duration_histogram_milliseconds_bucket[$__rate_interval])))", dashboard.Panels[5].Targets[0].Expr)
+		require.Equal(t, grafanaGridPos{H: 8, W: 12, X: 12, Y: 8}, dashboard.Panels[3].GridPos)
+	})
+
+	t.Run("SigNoz", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		require.NoError(t, writeDashboard(out, dashboardBackendSigNoz))
+
+		dashboard := signozDashboard{}
+		require.NoError(t, json.Unmarshal(out.Bytes(), &dashboard))
+		require.Len(t, dashboard.Widgets, len(dashboardPanels))
+		require.Len(t, dashboard.Layout, len(dashboardPanels))
+		require.Equal(t, dashboard.Widgets[0].ID, dashboard.Layout[0].I)
+
+		require.Equal(t, "value", dashboard.Widgets[0].PanelTypes)
+		require.Equal(t, "promql", dashboard.Widgets[0].Query.QueryType)
+		require.Equal(t, "sum(increase(tests_suite_passed[5m])) / sum(increase(tests_suite_total[5m]))", dashboard.Widgets[0].Query.PromQL[0].Query)
+		require.Len(t, dashboard.Widgets[1].Query.PromQL, 4)
+		require.Equal(t, "D", dashboard.Widgets[1].Query.PromQL[3].Name)
+	})
+
+	t.Run("Unknown backend", func(t *testing.T) {
+		err := writeDashboard(&bytes.Buffer{}, "kibana")
+		require.ErrorContains(t, err, `invalid backend "kibana"`)
+	})
+}
+
+// the dashboards only reference the metrics of the tool
+func TestDashboardMetrics(t *testing.T) {
+	metrics := []string{PassedTestsCount, TotalTestsCount, FailedTestsCount, ErrorTestsCount, SkippedTestsCount, PassedOnRetryTestsCount, TestsDuration, TestCaseDurationHistogram, TestsDurationByOwner, TimeoutTestsCount, TestsSuitesHanging, TestsSessionIdle}
+
+	naming, err := newDashboardNaming(dashboardBackendSigNoz)
+	require.NoError(t, err)
+
+	for _, panel := range dashboardPanels {
+		queries, err := renderQueries(naming, panel)
+		require.NoError(t, err)
+
+		for _, query := range queries {
+			referenced := false
+			for _, name := range metrics {
+				referenced = referenced || strings.Contains(query.expr, prometheusName(name))
+			}
+			require.True(t, referenced, "the panel %q references no metric of the tool", panel.title)
+		}
+	}
+}
@@ -0,0 +1,458 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// defaultDatadogSite the site of Datadog, if the DD_SITE environment variable is not set
+const defaultDatadogSite = "datadoghq.com"
+
+// maxDatadogEvents the maximum number of events sent to the test intake in a request
+const maxDatadogEvents = 1000
+
+// the statuses of the tests in Datadog CI Visibility
+const (
+	datadogStatusPass = "pass"
+	datadogStatusFail = "fail"
+	datadogStatusSkip = "skip"
+)
+
+// getDatadogIntakeURL returns the URL of the test intake of Datadog CI Visibility, for the DD_SITE site, or the one
+// of the DD_CIVISIBILITY_AGENTLESS_URL environment variable, as the Datadog tracers do
+func getDatadogIntakeURL() string {
+	if url := os.Getenv("DD_CIVISIBILITY_AGENTLESS_URL"); url != "" {
+		return strings.TrimSuffix(url, "/") + "/api/v2/citestcycle"
+	}
+
+	site := os.Getenv("DD_SITE")
+	if site == "" {
+		site = defaultDatadogSite
+	}
+
+	return "https://citestcycle-intake." + site + "/api/v2/citestcycle"
+}
+
+// datadogExporter sends the test cases, the suites and the run to the test intake of Datadog CI Visibility, as the
+// test events of its tracers, so the tests have the first-class treatment of the Datadog UI instead of being
+// generic spans. The spans are buffered until the shutdown, as the events of the tests reference their suite and
+// session, whose spans end after them
+type datadogExporter struct {
+	url    string
+	apiKey string
+	env    string
+	client *http.Client
+
+	mu    sync.Mutex
+	spans []sdktrace.ReadOnlySpan
+}
+
+// newDatadogExporter creates the exporter, reading the API key from the DD_API_KEY environment variable, so it's not
+// exposed as a flag, and the environment of the tests from DD_ENV
+func newDatadogExporter(url string) (*datadogExporter, error) {
+	apiKey := os.Getenv("DD_API_KEY")
+	if apiKey == "" {
+		return nil, errors.New("the DD_API_KEY environment variable is required to send the tests to Datadog")
+	}
+
+	env := os.Getenv("DD_ENV")
+	if env == "" {
+		env = "ci"
+	}
+
+	return &datadogExporter{url: url, apiKey: apiKey, env: env, client: &http.Client{Timeout: 30 * time.Second}}, nil
+}
+
+func (e *datadogExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.spans = append(e.spans, spans...)
+
+	return nil
+}
+
+// Shutdown sends the events of the buffered spans, in batches
+func (e *datadogExporter) Shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	events := datadogEvents(e.spans)
+	e.spans = nil
+
+	for start := 0; start < len(events); start += maxDatadogEvents {
+		batch := events[start:min(start+maxDatadogEvents, len(events))]
+		if err := e.send(ctx, batch); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *datadogExporter) send(ctx context.Context, events []any) error {
+	payload := map[string]any{
+		"version": int64(1),
+		"metadata": map[string]any{
+			"*": map[string]any{
+				"env":             e.env,
+				"language":        Junit2otlp,
+				"library_version": version,
+			},
+		},
+		"events": events,
+	}
+
+	body := &bytes.Buffer{}
+	encodeMsgpack(body, payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/msgpack")
+	req.Header.Set("DD-API-KEY", e.apiKey)
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send the tests to Datadog: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("the test intake of Datadog responded with %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	return nil
+}
+
+// datadogEvents converts the spans into the events of the test intake: a test event per test case, a test suite
+// end event per suite, and the test module and session end events for the root span of the run. A run attached
+// to a parent context has no root span, so its suites belong to the session of the parent span
+func datadogEvents(spans []sdktrace.ReadOnlySpan) []any {
+	suites := map[trace.SpanID]sdktrace.ReadOnlySpan{}
+	for _, span := range spans {
+		if isDatadogSuiteSpan(span) {
+			suites[span.SpanContext().SpanID()] = span
+		}
+	}
+
+	// the session of a suite is the parent of its outermost suite, as the nested suites are children of their suite
+	sessionOf := func(parent trace.SpanContext) trace.SpanID {
+		for {
+			suite, ok := suites[parent.SpanID()]
+			if !ok {
+				return parent.SpanID()
+			}
+			parent = suite.Parent()
+		}
+	}
+
+	suiteStatuses := map[trace.SpanID][]string{}
+	sessionStatuses := map[trace.SpanID][]string{}
+	events := []any{}
+
+	for _, span := range spans {
+		if !isTestCaseSpan(span) {
+			continue
+		}
+
+		status := datadogTestStatus(span)
+		suiteID := span.Parent().SpanID()
+		sessionID := sessionOf(span.Parent())
+		suiteStatuses[suiteID] = append(suiteStatuses[suiteID], status)
+		sessionStatuses[sessionID] = append(sessionStatuses[sessionID], status)
+
+		traceID, spanID := span.SpanContext().TraceID(), span.SpanContext().SpanID()
+		content := datadogContent(span, "test", status)
+		content["trace_id"] = spanIDNumber(traceID[8:])
+		content["span_id"] = spanIDNumber(spanID[:])
+		content["parent_id"] = uint64(0)
+		content["test_suite_id"] = spanIDNumber(suiteID[:])
+		content["test_module_id"] = spanIDNumber(sessionID[:])
+		content["test_session_id"] = spanIDNumber(sessionID[:])
+
+		events = append(events, map[string]any{"type": "test", "version": int64(2), "content": content})
+	}
+
+	for _, span := range spans {
+		spanID := span.SpanContext().SpanID()
+
+		switch {
+		case isDatadogSuiteSpan(span):
+			sessionID := sessionOf(span.Parent())
+			content := datadogContent(span, "test_suite_end", aggregateDatadogStatus(suiteStatuses[spanID]))
+			content["test_suite_id"] = spanIDNumber(spanID[:])
+			content["test_module_id"] = spanIDNumber(sessionID[:])
+			content["test_session_id"] = spanIDNumber(sessionID[:])
+
+			events = append(events, map[string]any{"type": "test_suite_end", "version": int64(1), "content": content})
+		case isDatadogSessionSpan(span):
+			status := aggregateDatadogStatus(sessionStatuses[spanID])
+
+			// a single module per run, sharing the identifier of the session
+			module := datadogContent(span, "test_module_end", status)
+			module["test_module_id"] = spanIDNumber(spanID[:])
+			module["test_session_id"] = spanIDNumber(spanID[:])
+
+			session := datadogContent(span, "test_session_end", status)
+			session["test_session_id"] = spanIDNumber(spanID[:])
+
+			events = append(events,
+				map[string]any{"type": "test_module_end", "version": int64(1), "content": module},
+				map[string]any{"type": "test_session_end", "version": int64(1), "content": session},
+			)
+		}
+	}
+
+	return events
+}
+
+// isDatadogSuiteSpan checks if the span represents a suite: it has the name of the suite, which the test cases
+// have too, but not a status
+func isDatadogSuiteSpan(span sdktrace.ReadOnlySpan) bool {
+	_, ok := spanAttribute(span, TestsSuiteName)
+	return ok && !isTestCaseSpan(span)
+}
+
+// isDatadogSessionSpan checks if the span is the root span of the run, the only one with the totals of the run
+func isDatadogSessionSpan(span sdktrace.ReadOnlySpan) bool {
+	_, ok := spanAttribute(span, TestsRunTotal)
+	return ok
+}
+
+// datadogContent returns the content of the event of the span: its timing, and the attributes of the span and its
+// resource as tags, the strings in the meta and the numbers in the metrics, with the tags of Datadog CI Visibility
+func datadogContent(span sdktrace.ReadOnlySpan, eventType string, status string) map[string]any {
+	meta := map[string]any{}
+	metrics := map[string]any{}
+	add := func(attrs []attribute.KeyValue) {
+		for _, attr := range attrs {
+			switch attr.Value.Type() {
+			case attribute.INT64:
+				metrics[string(attr.Key)] = float64(attr.Value.AsInt64())
+			case attribute.FLOAT64:
+				metrics[string(attr.Key)] = attr.Value.AsFloat64()
+			default:
+				meta[string(attr.Key)] = attr.Value.Emit()
+			}
+		}
+	}
+
+	service := ""
+	if res := span.Resource(); res != nil {
+		add(res.Attributes())
+		if name, ok := res.Set().Value(semconv.ServiceNameKey); ok {
+			service = name.AsString()
+		}
+	}
+	add(span.Attributes())
+
+	framework := "junit"
+	if value, ok := spanAttribute(span, TestFramework); ok && value.AsString() != "" {
+		framework = strings.ToLower(value.AsString())
+	}
+
+	meta["_dd.origin"] = "ciapp-test"
+	meta["span.kind"] = "test"
+	meta["test.type"] = "test"
+	meta["test.framework"] = framework
+	meta["test.status"] = status
+	copyDatadogTag(meta, ScmBranch, "git.branch")
+	copyDatadogTag(meta, ScmRepository, "git.repository_url")
+	copyDatadogTag(meta, string(semconv.CodeFilepathKey), "test.source.file")
+	copyDatadogTag(meta, TestsSuiteName, "test.suite")
+
+	name, resource := framework+"."+eventType, span.Name()
+	if eventType == "test" {
+		meta["test.name"] = span.Name()
+		if suite, ok := meta["test.suite"].(string); ok && suite != "" {
+			resource = suite + "." + span.Name()
+		}
+		addDatadogError(span, meta)
+	}
+
+	errorFlag := int64(0)
+	if status == datadogStatusFail {
+		errorFlag = 1
+	}
+
+	return map[string]any{
+		"name":     name,
+		"resource": resource,
+		"service":  service,
+		"type":     eventType,
+		"start":    span.StartTime().UnixNano(),
+		"duration": span.EndTime().Sub(span.StartTime()).Nanoseconds(),
+		"error":    errorFlag,
+		"meta":     meta,
+		"metrics":  metrics,
+	}
+}
+
+// addDatadogError adds the tags of the failure of the test, from its exception event
+func addDatadogError(span sdktrace.ReadOnlySpan, meta map[string]any) {
+	for _, event := range span.Events() {
+		if event.Name != semconv.ExceptionEventName {
+			continue
+		}
+
+		for _, attr := range event.Attributes {
+			switch attr.Key {
+			case semconv.ExceptionTypeKey:
+				meta["error.type"] = attr.Value.AsString()
+			case semconv.ExceptionMessageKey:
+				meta["error.message"] = attr.Value.AsString()
+			case semconv.ExceptionStacktraceKey:
+				meta["error.stack"] = attr.Value.AsString()
+			}
+		}
+	}
+}
+
+// copyDatadogTag copies the value of the attribute of the span or its resource to the tag of Datadog
+func copyDatadogTag(meta map[string]any, key string, tag string) {
+	if value, ok := meta[key]; ok && value != "" {
+		meta[tag] = value
+	}
+}
+
+// datadogTestStatus returns the status of the test in Datadog: the tests passed on a retry pass, and the statuses
+// of the failure rules are the ones of their span status
+func datadogTestStatus(span sdktrace.ReadOnlySpan) string {
+	status, _ := spanAttribute(span, TestStatus)
+
+	switch status.AsString() {
+	case "passed", passedOnRetryStatus:
+		return datadogStatusPass
+	case "skipped":
+		return datadogStatusSkip
+	case "failed", "error":
+		return datadogStatusFail
+	}
+
+	if span.Status().Code == codes.Error {
+		return datadogStatusFail
+	}
+
+	return datadogStatusPass
+}
+
+// aggregateDatadogStatus returns the status of a suite or a session: failed if any of its tests failed, skipped if
+// all of them were skipped, and passed otherwise
+func aggregateDatadogStatus(statuses []string) string {
+	if slices.Contains(statuses, datadogStatusFail) {
+		return datadogStatusFail
+	}
+
+	if len(statuses) > 0 && !slices.ContainsFunc(statuses, func(status string) bool { return status != datadogStatusSkip }) {
+		return datadogStatusSkip
+	}
+
+	return datadogStatusPass
+}
+
+func spanAttribute(span sdktrace.ReadOnlySpan, key string) (attribute.Value, bool) {
+	for _, attr := range span.Attributes() {
+		if string(attr.Key) == key {
+			return attr.Value, true
+		}
+	}
+
+	return attribute.Value{}, false
+}
+
+// spanIDNumber the identifiers of Datadog are 64-bit numbers, so the trace ID is truncated to its lower half
+func spanIDNumber(id []byte) uint64 {
+	return binary.BigEndian.Uint64(id)
+}
+
+// encodeMsgpack encodes the value with MessagePack, the format of the test intake, supporting the types of the
+// events: nil, booleans, integers, floats, strings, slices and maps with string keys, sorted for a stable output
+func encodeMsgpack(buf *bytes.Buffer, value any) {
+	switch v := value.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if v {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case int:
+		encodeMsgpack(buf, int64(v))
+	case int64:
+		switch {
+		case v >= 0 && v < 128:
+			buf.WriteByte(byte(v))
+		case v < 0 && v >= -32:
+			buf.WriteByte(byte(int8(v)))
+		default:
+			buf.WriteByte(0xd3)
+			buf.Write(binary.BigEndian.AppendUint64(nil, uint64(v)))
+		}
+	case uint64:
+		if v < 128 {
+			buf.WriteByte(byte(v))
+		} else {
+			buf.WriteByte(0xcf)
+			buf.Write(binary.BigEndian.AppendUint64(nil, v))
+		}
+	case float64:
+		buf.WriteByte(0xcb)
+		buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(v)))
+	case string:
+		writeMsgpackHeader(buf, len(v), 0xa0, 32, 0xd9, 0xda, 0xdb)
+		buf.WriteString(v)
+	case []any:
+		writeMsgpackHeader(buf, len(v), 0x90, 16, 0, 0xdc, 0xdd)
+		for _, item := range v {
+			encodeMsgpack(buf, item)
+		}
+	case map[string]any:
+		writeMsgpackHeader(buf, len(v), 0x80, 16, 0, 0xde, 0xdf)
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			encodeMsgpack(buf, key)
+			encodeMsgpack(buf, v[key])
+		}
+	default:
+		encodeMsgpack(buf, fmt.Sprint(v))
+	}
+}
+
+// writeMsgpackHeader writes the header of a string, an array or a map with its length: in the fixed format for the
+// short ones, and in the 8-bit (only for the strings), 16-bit or 32-bit ones otherwise
+func writeMsgpackHeader(buf *bytes.Buffer, length int, fixed byte, fixedLimit int, format8 byte, format16 byte, format32 byte) {
+	switch {
+	case length < fixedLimit:
+		buf.WriteByte(fixed | byte(length))
+	case format8 != 0 && length < 256:
+		buf.WriteByte(format8)
+		buf.WriteByte(byte(length))
+	case length < 65536:
+		buf.WriteByte(format16)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(length)))
+	default:
+		buf.WriteByte(format32)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(length)))
+	}
+}
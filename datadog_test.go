@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func datadogSpans() []sdktrace.ReadOnlySpan {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	res := resource.NewSchemaless(semconv.ServiceNameKey.String("e2e"), attribute.Key(ScmBranch).String("main"))
+
+	traceID := trace.TraceID{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 9}
+	spanContext := func(id byte) trace.SpanContext {
+		return trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: trace.SpanID{0, 0, 0, 0, 0, 0, 0, id}})
+	}
+
+	return tracetest.SpanStubs{
+		{
+			Name:        "TestLogin",
+			SpanContext: spanContext(3),
+			Parent:      spanContext(2),
+			StartTime:   start,
+			EndTime:     start.Add(1500 * time.Millisecond),
+			Attributes: []attribute.KeyValue{
+				attribute.Key(TestStatus).String("failed"),
+				attribute.Key(TestDuration).Int64(1500),
+				attribute.Key(TestsSuiteName).String("checkout"),
+				attribute.Key(TestFramework).String("JUnit"),
+			},
+			Events: []sdktrace.Event{{Name: semconv.ExceptionEventName, Attributes: []attribute.KeyValue{
+				semconv.ExceptionTypeKey.String("java.lang.AssertionError"),
+				semconv.ExceptionMessageKey.String("expected 200"),
+				semconv.ExceptionStacktraceKey.String("at LoginTest.java:12"),
+			}}},
+			Resource: res,
+		},
+		{
+			Name:        "TestLogout",
+			SpanContext: spanContext(4),
+			Parent:      spanContext(2),
+			StartTime:   start,
+			EndTime:     start.Add(time.Second),
+			Attributes: []attribute.KeyValue{
+				attribute.Key(TestStatus).String(passedOnRetryStatus),
+				attribute.Key(TestsSuiteName).String("checkout"),
+			},
+			Resource: res,
+		},
+		{
+			Name:        "checkout",
+			SpanContext: spanContext(2),
+			Parent:      spanContext(1),
+			StartTime:   start,
+			EndTime:     start.Add(3 * time.Second),
+			Attributes:  []attribute.KeyValue{attribute.Key(TestsSuiteName).String("checkout")},
+			Resource:    res,
+		},
+		{
+			Name:        "junit2otlp",
+			SpanContext: spanContext(1),
+			StartTime:   start,
+			EndTime:     start.Add(3 * time.Second),
+			Attributes:  []attribute.KeyValue{attribute.Key(TestsRunTotal).Int(2)},
+			Resource:    res,
+		},
+	}.Snapshots()
+}
+
+func TestDatadogEvents(t *testing.T) {
+	events := datadogEvents(datadogSpans())
+	require.Len(t, events, 5)
+
+	types := []string{}
+	for _, event := range events {
+		types = append(types, event.(map[string]any)["type"].(string))
+	}
+	require.Equal(t, []string{"test", "test", "test_suite_end", "test_module_end", "test_session_end"}, types)
+
+	content := func(i int) map[string]any {
+		return events[i].(map[string]any)["content"].(map[string]any)
+	}
+
+	login := content(0)
+	require.Equal(t, "junit.test", login["name"])
+	require.Equal(t, "checkout.TestLogin", login["resource"])
+	require.Equal(t, "e2e", login["service"])
+	require.Equal(t, uint64(9), login["trace_id"])
+	require.Equal(t, uint64(3), login["span_id"])
+	require.Equal(t, uint64(2), login["test_suite_id"])
+	require.Equal(t, uint64(1), login["test_session_id"])
+	require.Equal(t, uint64(1), login["test_module_id"])
+	require.Equal(t, int64(1500*time.Millisecond), login["duration"])
+	require.Equal(t, int64(1), login["error"])
+
+	meta := login["meta"].(map[string]any)
+	require.Equal(t, "fail", meta["test.status"])
+	require.Equal(t, "TestLogin", meta["test.name"])
+	require.Equal(t, "checkout", meta["test.suite"])
+	require.Equal(t, "junit", meta["test.framework"])
+	require.Equal(t, "main", meta["git.branch"])
+	require.Equal(t, "java.lang.AssertionError", meta["error.type"])
+	require.Equal(t, "expected 200", meta["error.message"])
+	require.Equal(t, "at LoginTest.java:12", meta["error.stack"])
+	require.Equal(t, float64(1500), login["metrics"].(map[string]any)[TestDuration])
+
+	// the tests passed on a retry pass
+	require.Equal(t, "pass", content(1)["meta"].(map[string]any)["test.status"])
+	require.Equal(t, int64(0), content(1)["error"])
+
+	suite := content(2)
+	require.Equal(t, "junit.test_suite_end", suite["name"])
+	require.Equal(t, "fail", suite["meta"].(map[string]any)["test.status"])
+	require.Equal(t, uint64(2), suite["test_suite_id"])
+	require.Equal(t, uint64(1), suite["test_session_id"])
+
+	session := content(4)
+	require.Equal(t, "fail", session["meta"].(map[string]any)["test.status"])
+	require.Equal(t, uint64(1), session["test_session_id"])
+}
+
+func TestAggregateDatadogStatus(t *testing.T) {
+	require.Equal(t, "pass", aggregateDatadogStatus(nil))
+	require.Equal(t, "pass", aggregateDatadogStatus([]string{"pass", "skip"}))
+	require.Equal(t, "skip", aggregateDatadogStatus([]string{"skip", "skip"}))
+	require.Equal(t, "fail", aggregateDatadogStatus([]string{"pass", "fail", "skip"}))
+}
+
+func TestEncodeMsgpack(t *testing.T) {
+	tests := []struct {
+		value    any
+		expected []byte
+	}{
+		{nil, []byte{0xc0}},
+		{true, []byte{0xc3}},
+		{int64(1), []byte{0x01}},
+		{int64(-1), []byte{0xff}},
+		{int64(200), []byte{0xd3, 0, 0, 0, 0, 0, 0, 0, 200}},
+		{uint64(300), []byte{0xcf, 0, 0, 0, 0, 0, 0, 1, 44}},
+		{1.5, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{"abc", []byte{0xa3, 'a', 'b', 'c'}},
+		{[]any{int64(1), "a"}, []byte{0x92, 0x01, 0xa1, 'a'}},
+		{map[string]any{"b": int64(2), "a": int64(1)}, []byte{0x82, 0xa1, 'a', 0x01, 0xa1, 'b', 0x02}},
+	}
+
+	for _, tt := range tests {
+		buf := &bytes.Buffer{}
+		encodeMsgpack(buf, tt.value)
+		require.Equal(t, tt.expected, buf.Bytes(), "%v", tt.value)
+	}
+
+	long := &bytes.Buffer{}
+	encodeMsgpack(long, strings.Repeat("a", 40))
+	require.Equal(t, []byte{0xd9, 40}, long.Bytes()[:2])
+
+	longer := &bytes.Buffer{}
+	encodeMsgpack(longer, strings.Repeat("a", 300))
+	require.Equal(t, []byte{0xda, 1, 44}, longer.Bytes()[:3])
+}
+
+func TestDatadogExporter(t *testing.T) {
+	t.Run("Sends the events", func(t *testing.T) {
+		t.Setenv("DD_API_KEY", "secret")
+
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/api/v2/citestcycle", r.URL.Path)
+			require.Equal(t, "application/msgpack", r.Header.Get("Content-Type"))
+			require.Equal(t, "secret", r.Header.Get("DD-API-KEY"))
+
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			// a map with the events, the metadata and the version
+			require.Equal(t, byte(0x83), body[0])
+			require.Contains(t, string(body), "test_session_end")
+
+			requests++
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer server.Close()
+
+		t.Setenv("DD_CIVISIBILITY_AGENTLESS_URL", server.URL)
+		exporter, err := newDatadogExporter(getDatadogIntakeURL())
+		require.NoError(t, err)
+
+		require.NoError(t, exporter.ExportSpans(context.Background(), datadogSpans()))
+		require.Zero(t, requests)
+
+		require.NoError(t, exporter.Shutdown(context.Background()))
+		require.Equal(t, 1, requests)
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		t.Setenv("DD_API_KEY", "secret")
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "invalid API key", http.StatusForbidden)
+		}))
+		defer server.Close()
+
+		exporter, err := newDatadogExporter(server.URL)
+		require.NoError(t, err)
+		require.NoError(t, exporter.ExportSpans(context.Background(), datadogSpans()))
+		require.ErrorContains(t, exporter.Shutdown(context.Background()), "403 Forbidden: invalid API key")
+	})
+
+	t.Run("Intake URL", func(t *testing.T) {
+		t.Setenv("DD_CIVISIBILITY_AGENTLESS_URL", "")
+		t.Setenv("DD_SITE", "")
+		require.Equal(t, "https://citestcycle-intake.datadoghq.com/api/v2/citestcycle", getDatadogIntakeURL())
+
+		t.Setenv("DD_SITE", "datadoghq.eu")
+		require.Equal(t, "https://citestcycle-intake.datadoghq.eu/api/v2/citestcycle", getDatadogIntakeURL())
+	})
+
+	t.Run("Without API key", func(t *testing.T) {
+		t.Setenv("DD_API_KEY", "")
+
+		_, err := newDatadogExporter(getDatadogIntakeURL())
+		require.ErrorContains(t, err, "DD_API_KEY")
+	})
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// deploymentLinkKind the kind of the links of the root span to the traces of the deployments
+const deploymentLinkKind = "deployment"
+
+// parseDeployTraces returns the links to the traces of the previous deployments, i.e. the deployment under test, from
+// their comma-separated W3C traceparents, or from their trace IDs when the backend only exposes them
+func parseDeployTraces(value string) ([]trace.Link, error) {
+	links := []trace.Link{}
+	for _, deployTrace := range strings.Split(value, ",") {
+		deployTrace = strings.TrimSpace(deployTrace)
+		if deployTrace == "" {
+			continue
+		}
+
+		attributes := []attribute.KeyValue{attribute.Key(LinkKind).String(deploymentLinkKind)}
+
+		// a trace ID without the span of the deployment, kept by its attributes, as the span context is not valid
+		if traceID, err := trace.TraceIDFromHex(deployTrace); err == nil {
+			links = append(links, trace.Link{SpanContext: trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, Remote: true}), Attributes: attributes})
+			continue
+		}
+
+		carrier := propagation.MapCarrier{traceparentHeader: deployTrace}
+		spanContext := trace.SpanContextFromContext(propagation.TraceContext{}.Extract(context.Background(), carrier))
+		if !spanContext.IsValid() {
+			return nil, fmt.Errorf("invalid deploy trace %q: it must be a traceparent or a trace ID", deployTrace)
+		}
+
+		links = append(links, trace.Link{SpanContext: spanContext, Attributes: attributes})
+	}
+
+	return links, nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestParseDeployTraces(t *testing.T) {
+	links, err := parseDeployTraces("")
+	require.NoError(t, err)
+	require.Empty(t, links)
+
+	links, err = parseDeployTraces("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01, 0af7651916cd43dd8448eb211c80319c")
+	require.NoError(t, err)
+	require.Len(t, links, 2)
+
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", links[0].SpanContext.TraceID().String())
+	require.Equal(t, "00f067aa0ba902b7", links[0].SpanContext.SpanID().String())
+	require.Equal(t, []attribute.KeyValue{attribute.String(LinkKind, deploymentLinkKind)}, links[0].Attributes)
+
+	// the trace ID, without the span of the deployment
+	require.Equal(t, "0af7651916cd43dd8448eb211c80319c", links[1].SpanContext.TraceID().String())
+	require.False(t, links[1].SpanContext.SpanID().IsValid())
+	require.NotEmpty(t, links[1].Attributes)
+
+	_, err = parseDeployTraces("deploy-42")
+	require.ErrorContains(t, err, "invalid deploy trace")
+}
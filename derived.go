@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/joshdk/go-junit"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// derivedAttribute a user-defined attribute of the spans of the test cases, computed by a CEL expression over their
+// fields, i.e. the tier of a test from the name of its suite
+type derivedAttribute struct {
+	Key        string `yaml:"key"`
+	Expression string `yaml:"expression"`
+
+	program cel.Program
+}
+
+type derivedAttributes []derivedAttribute
+
+func (d derivedAttributes) compile() error {
+	keys := map[string]bool{}
+	for i := range d {
+		if d[i].Key == "" {
+			return fmt.Errorf("the key of the derived attribute %d is mandatory", i)
+		}
+
+		if keys[d[i].Key] {
+			return fmt.Errorf("the derived attribute %s is duplicated", d[i].Key)
+		}
+		keys[d[i].Key] = true
+
+		program, err := compileExpression(d[i].Expression, nil)
+		if err != nil {
+			return fmt.Errorf("derived attribute %s: %w", d[i].Key, err)
+		}
+		d[i].program = program
+	}
+
+	return nil
+}
+
+// evaluate returns the derived attributes of the test case, from its fields and the attributes of its span. The
+// expressions are independent, seeing none of the derived attributes, and the ones failing, i.e. reading a missing
+// property, are dropped
+func (d derivedAttributes) evaluate(suite junit.Suite, test junit.Test, name string, status string, attributes []attribute.KeyValue) []attribute.KeyValue {
+	if len(d) == 0 {
+		return nil
+	}
+
+	activation := testActivation(suite, test, name, status, attributes)
+	derived := make([]attribute.KeyValue, 0, len(d))
+	for _, attr := range d {
+		val, _, err := attr.program.Eval(activation)
+		if err != nil {
+			droppedData.drop(droppedDerived, 1, fmt.Sprintf("%s of the test %s: %v", attr.Key, name, err))
+			continue
+		}
+
+		kv, err := expressionAttribute(attr.Key, val)
+		if err != nil {
+			droppedData.drop(droppedDerived, 1, fmt.Sprintf("%s of the test %s: %v", attr.Key, name, err))
+			continue
+		}
+		derived = append(derived, kv)
+	}
+
+	return derived
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestDerivedAttributes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "junit2otlp.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+derived_attributes:
+  - key: test.tier
+    expression: 'suite.startsWith("e2e") ? "e2e" : "unit"'
+  - key: test.slow
+    expression: 'duration > duration("1m")'
+  - key: test.component
+    expression: 'properties.component'
+`), 0o644))
+
+	cfg, err := loadConfig(path)
+	require.NoError(t, err)
+	require.Len(t, cfg.Derived, 3)
+
+	t.Run("Evaluated for each test case", func(t *testing.T) {
+		test := junit.Test{Duration: 2 * time.Minute, Properties: map[string]string{"component": "checkout"}}
+
+		require.Equal(t, []attribute.KeyValue{
+			attribute.Key("test.tier").String("e2e"),
+			attribute.Key("test.slow").Bool(true),
+			attribute.Key("test.component").String("checkout"),
+		}, cfg.Derived.evaluate(junit.Suite{Name: "e2e-checkout"}, test, "TestA", "passed", nil))
+	})
+
+	t.Run("The failing expressions are dropped", func(t *testing.T) {
+		droppedData = &dropLedger{}
+		t.Cleanup(func() { droppedData = &dropLedger{} })
+
+		require.Equal(t, []attribute.KeyValue{
+			attribute.Key("test.tier").String("unit"),
+			attribute.Key("test.slow").Bool(false),
+		}, cfg.Derived.evaluate(junit.Suite{Name: "api"}, junit.Test{}, "TestB", "passed", nil))
+		require.Equal(t, map[dropKind]int64{droppedDerived: 1}, droppedData.counts)
+	})
+
+	t.Run("None without expressions", func(t *testing.T) {
+		require.Nil(t, derivedAttributes{}.evaluate(junit.Suite{}, junit.Test{}, "TestC", "passed", nil))
+	})
+}
+
+func TestDerivedAttributesValidation(t *testing.T) {
+	for name, tc := range map[string]struct {
+		derived string
+		err     string
+	}{
+		"Without a key": {
+			derived: "derived_attributes:\n  - expression: 'name'\n",
+			err:     "the key of the derived attribute 0 is mandatory",
+		},
+		"Duplicated": {
+			derived: "derived_attributes:\n  - key: a\n    expression: 'name'\n  - key: a\n    expression: 'suite'\n",
+			err:     "the derived attribute a is duplicated",
+		},
+		"Invalid expression": {
+			derived: "derived_attributes:\n  - key: a\n    expression: 'name =='\n",
+			err:     `invalid derived attributes in the config file`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "junit2otlp.yml")
+			require.NoError(t, os.WriteFile(path, []byte(tc.derived), 0o644))
+
+			_, err := loadConfig(path)
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestDerivedAttributesOnTestSpans(t *testing.T) {
+	defer func(cfg *config) { appConfig = cfg }(appConfig)
+	path := filepath.Join(t.TempDir(), "junit2otlp.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+derived_attributes:
+  - key: test.tier
+    expression: 'suite.startsWith("e2e") ? "e2e" : "unit"'
+span_hooks:
+  - when: 'attributes["test.tier"] == "e2e"'
+    name: '"e2e: " + name'
+`), 0o644))
+
+	var err error
+	appConfig, err = loadConfig(path)
+	require.NoError(t, err)
+
+	exporter := tracetest.NewInMemoryExporter()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)).Tracer("test")
+	meter := sdkmetric.NewMeterProvider().Meter("test")
+
+	createTestSpan(context.Background(), tracer, newTestCaseMetrics(meter, 0), junit.Suite{Name: "e2e-login"}, nil, junit.Test{Name: "TestLogin", Status: junit.StatusPassed}, 0, time.Time{})
+
+	// the hooks see the derived attributes
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	require.Equal(t, "e2e: TestLogin", spans[0].Name)
+	require.Contains(t, spans[0].Attributes, attribute.Key("test.tier").String("e2e"))
+}
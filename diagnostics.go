@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const rejectedSpansKind = "spans"
+const rejectedDataPointsKind = "metric data points"
+
+// partialSuccessRegex matches the errors the OTLP exporters send to the OpenTelemetry error handler when the
+// collector partially accepts an export, as their type is internal to the exporters
+var partialSuccessRegex = regexp.MustCompile(`OTLP partial success: (.*) \((\d+) (spans|metric data points) rejected\)`)
+
+// exportDiagnostics collects the outcome of the exports, as the exporters report the failures and the
+// partial successes to the OpenTelemetry error handler instead of returning them
+type exportDiagnostics struct {
+	mu                 sync.Mutex
+	exportedSpans      atomic.Int64
+	rejectedSpans      int64
+	rejectedDataPoints int64
+	messages           []string
+	errors             []error
+}
+
+// Handle implements the OpenTelemetry error handler
+func (d *exportDiagnostics) Handle(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	matches := partialSuccessRegex.FindStringSubmatch(err.Error())
+	if matches == nil {
+		log.Printf("failed to export: %v", err)
+		d.errors = append(d.errors, err)
+		return
+	}
+
+	log.Printf("the collector partially accepted the export: %s", matches[0])
+
+	rejected, _ := strconv.ParseInt(matches[2], 10, 64)
+	switch matches[3] {
+	case rejectedSpansKind:
+		d.rejectedSpans += rejected
+	case rejectedDataPointsKind:
+		d.rejectedDataPoints += rejected
+	}
+
+	if matches[1] != "" && matches[1] != "empty message" {
+		d.messages = append(d.messages, matches[1])
+	}
+}
+
+// report logs the summary of the exports when verbose
+func (d *exportDiagnostics) report() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	log.Printf(
+		"exported %d spans: %d spans and %d metric data points rejected by the collector, %d export errors",
+		d.exportedSpans.Load(), d.rejectedSpans, d.rejectedDataPoints, len(d.errors),
+	)
+	for _, msg := range d.messages {
+		log.Printf("collector message: %s", msg)
+	}
+}
+
+// err returns an error if any export failed, or if the collector rejected part of the telemetry
+func (d *exportDiagnostics) err() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	errs := []error{}
+	if d.rejectedSpans > 0 || d.rejectedDataPoints > 0 {
+		err := fmt.Errorf("the collector rejected %d spans and %d metric data points", d.rejectedSpans, d.rejectedDataPoints)
+		if len(d.messages) > 0 {
+			err = fmt.Errorf("%w: %s", err, strings.Join(d.messages, "; "))
+		}
+		errs = append(errs, err)
+	}
+	errs = append(errs, d.errors...)
+
+	return errors.Join(errs...)
+}
+
+// countingSpanExporter counts the spans successfully sent by the wrapped exporter
+type countingSpanExporter struct {
+	sdktrace.SpanExporter
+	diagnostics *exportDiagnostics
+}
+
+func (e *countingSpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	if err := e.SpanExporter.ExportSpans(ctx, spans); err != nil {
+		return err
+	}
+
+	e.diagnostics.exportedSpans.Add(int64(len(spans)))
+	return nil
+}
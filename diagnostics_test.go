@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportDiagnostics(t *testing.T) {
+	t.Run("No errors", func(t *testing.T) {
+		d := &exportDiagnostics{}
+		require.NoError(t, d.err())
+	})
+
+	t.Run("Partial success", func(t *testing.T) {
+		d := &exportDiagnostics{}
+		d.Handle(errors.New("OTLP partial success: attribute too long (3 spans rejected)"))
+		d.Handle(errors.New("OTLP partial success: empty message (2 metric data points rejected)"))
+
+		require.Equal(t, int64(3), d.rejectedSpans)
+		require.Equal(t, int64(2), d.rejectedDataPoints)
+		require.Equal(t, []string{"attribute too long"}, d.messages)
+		require.EqualError(t, d.err(), "the collector rejected 3 spans and 2 metric data points: attribute too long")
+	})
+
+	t.Run("Export errors", func(t *testing.T) {
+		exportErr := errors.New("traces export: context deadline exceeded")
+
+		d := &exportDiagnostics{}
+		d.Handle(exportErr)
+
+		require.Zero(t, d.rejectedSpans)
+		require.ErrorIs(t, d.err(), exportErr)
+	})
+}
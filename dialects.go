@@ -0,0 +1,173 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/joshdk/go-junit"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+// rspecLocationRegex matches the location that RSpec formatters append to the name of the examples,
+// i.e. "User is valid (./spec/models/user_spec.rb:12)"
+var rspecLocationRegex = regexp.MustCompile(`^(.*\S)\s+\(([^()\s]+):(\d+)\)$`)
+
+// rspecIDRegex matches the ID of the RSpec examples, i.e. "./spec/models/user_spec.rb[1:2:1]"
+var rspecIDRegex = regexp.MustCompile(`^([^\[\]]+)\[[\d:]+\]$`)
+
+// junitPlatformDisplayNameRegex matches the display name written by the JUnit Platform in the output of the test
+// cases of its legacy XML reports, after their unique ID, i.e. "display-name: 1 + 1 = 2"
+var junitPlatformDisplayNameRegex = regexp.MustCompile(`(?m)^display-name: (.*\S)\s*$`)
+
+// goSubtestRegex matches the subtests of Go, after the name of their test function, i.e. "TestCart/empty_cart"
+var goSubtestRegex = regexp.MustCompile(`^((?:Test|Benchmark|Example|Fuzz)[^/]*)/(.+)$`)
+
+// testParametersRegex matches the parameters of the parameterized tests, after the name of their method, i.e. the
+// "test_total[3-4]" of pytest or the "adds(int, int)[1]" of JUnit 5
+var testParametersRegex = regexp.MustCompile(`^([^\[]*?)(?:\([^()]*\))?\[(.*)\]$`)
+
+// suiteDialectAttributes returns the attributes of the suite specific to the dialect of the report,
+// i.e. PHPUnit adds the file of the test class to the nested suites
+func suiteDialectAttributes(suite junit.Suite) []attribute.KeyValue {
+	if file := suite.Properties["file"]; file != "" {
+		return []attribute.KeyValue{semconv.CodeFilepathKey.String(file)}
+	}
+
+	return nil
+}
+
+// testDialect returns the name of the test, without the location embedded by some dialects, and the attributes
+// for its file, line and ID: PHPUnit adds the file and line attributes to the test cases, and RSpec appends the
+// location to the name and adds the ID of the example
+func testDialect(test junit.Test) (string, []attribute.KeyValue) {
+	name := test.Name
+	file := test.Properties["file"]
+	line := test.Properties["line"]
+	id := test.Properties["id"]
+
+	// the regular expressions are only matched if they can match, as the dialect is read for every test case
+	if strings.HasSuffix(name, ")") {
+		if matches := rspecLocationRegex.FindStringSubmatch(name); matches != nil {
+			name = matches[1]
+			if file == "" {
+				file = matches[2]
+			}
+			if line == "" {
+				line = matches[3]
+			}
+		}
+	}
+
+	if file == "" && strings.HasSuffix(id, "]") {
+		if matches := rspecIDRegex.FindStringSubmatch(id); matches != nil {
+			file = matches[1]
+		}
+	}
+
+	if file == "" && line == "" && id == "" {
+		return name, nil
+	}
+
+	attributes := []attribute.KeyValue{}
+	if file != "" {
+		attributes = append(attributes, semconv.CodeFilepathKey.String(file))
+	}
+	if lineno, err := strconv.Atoi(strings.TrimSpace(line)); err == nil {
+		attributes = append(attributes, semconv.CodeLineNumberKey.Int(lineno))
+	}
+	if id != "" {
+		attributes = append(attributes, attribute.Key(TestID).String(id))
+	}
+
+	return name, attributes
+}
+
+// displayNameAttributes returns the attributes for the name of the test and its display name, the human-readable one
+// given by the framework (i.e. the @DisplayName of JUnit 5), which is its name if it has none (i.e. the descriptions
+// of RSpec)
+func displayNameAttributes(test junit.Test, name string) []attribute.KeyValue {
+	displayName := test.Properties[TestDisplayName]
+
+	// the output is only matched if it can match, as the display name is read for every test case
+	if displayName == "" && strings.Contains(test.SystemOut, "display-name: ") {
+		if matches := junitPlatformDisplayNameRegex.FindStringSubmatch(test.SystemOut); matches != nil {
+			displayName = matches[1]
+		}
+	}
+
+	if displayName == "" {
+		displayName = name
+	}
+
+	return []attribute.KeyValue{
+		attribute.Key(TestCaseName).String(name),
+		attribute.Key(TestDisplayName).String(displayName),
+	}
+}
+
+// demangledNameAttributes returns the attributes for the parts of the mangled names of the test cases: the package and
+// the test of the Go subtests, i.e. TestCart/empty_cart, the package and the nested classes of JUnit, i.e.
+// com.acme.CartTest$Totals, and the module, the classes and the parameters of the pytest node IDs, i.e.
+// tests/test_cart.py::TestCart::test_total[3-4]. The names of the other test cases are not demangled
+func demangledNameAttributes(classname string, name string) []attribute.KeyValue {
+	var pkg, class, method, subtest, parameters string
+
+	// the parameters can contain any character
+	unparameterized, _, _ := strings.Cut(name, "[")
+
+	switch {
+	case strings.Contains(unparameterized, "::"):
+		nodeID := name
+		if strings.HasSuffix(nodeID, "]") {
+			if matches := testParametersRegex.FindStringSubmatch(nodeID); matches != nil {
+				nodeID, parameters = matches[1], matches[2]
+			}
+		}
+
+		parts := strings.Split(nodeID, "::")
+		pkg = strings.ReplaceAll(strings.TrimSuffix(parts[0], ".py"), "/", ".")
+		class = strings.Join(parts[1:len(parts)-1], ".")
+		method = parts[len(parts)-1]
+	case strings.Contains(name, "/") && goSubtestRegex.MatchString(name):
+		matches := goSubtestRegex.FindStringSubmatch(name)
+		pkg, method, subtest = classname, matches[1], matches[2]
+	case strings.Contains(classname, "$"):
+		pkg, class = "", classname
+		if i := strings.LastIndex(classname, "."); i >= 0 {
+			pkg, class = classname[:i], classname[i+1:]
+		}
+		class = strings.ReplaceAll(class, "$", ".")
+
+		method = strings.TrimSuffix(name, "()")
+		if strings.HasSuffix(method, "]") {
+			if matches := testParametersRegex.FindStringSubmatch(method); matches != nil {
+				method, parameters = matches[1], matches[2]
+			}
+		}
+		if i := strings.Index(method, "("); i > 0 {
+			method = method[:i]
+		}
+	default:
+		return nil
+	}
+
+	attributes := []attribute.KeyValue{}
+	for _, part := range []struct {
+		key   string
+		value string
+	}{
+		{TestCasePackage, pkg},
+		{TestCaseClass, class},
+		{TestCaseMethod, method},
+		{TestCaseSubtest, subtest},
+		{TestCaseParameters, parameters},
+	} {
+		if part.value != "" {
+			attributes = append(attributes, attribute.Key(part.key).String(part.value))
+		}
+	}
+
+	return attributes
+}
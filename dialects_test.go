@@ -0,0 +1,137 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+func TestTestDialect(t *testing.T) {
+	t.Run("PHPUnit", func(t *testing.T) {
+		report, err := ingestReport([]byte(`<testsuites><testsuite name="Unit" tests="1">
+			<testsuite name="AppTest" file="/app/tests/AppTest.php" tests="1">
+				<testcase name="testWorks" class="AppTest" classname="AppTest" file="/app/tests/AppTest.php" line="10" assertions="1" time="0.01"/>
+			</testsuite>
+		</testsuite></testsuites>`))
+		require.NoError(t, err)
+
+		nested := report.suites[0].Suites[0]
+		require.Equal(t, []attribute.KeyValue{semconv.CodeFilepathKey.String("/app/tests/AppTest.php")}, suiteDialectAttributes(nested))
+
+		name, attrs := testDialect(nested.Tests[0])
+		require.Equal(t, "testWorks", name)
+		require.Equal(t, []attribute.KeyValue{
+			semconv.CodeFilepathKey.String("/app/tests/AppTest.php"),
+			semconv.CodeLineNumberKey.Int(10),
+		}, attrs)
+	})
+
+	t.Run("RSpec", func(t *testing.T) {
+		name, attrs := testDialect(junit.Test{
+			Name:       "User is valid (./spec/models/user_spec.rb:12)",
+			Properties: map[string]string{"id": "./spec/models/user_spec.rb[1:2:1]"},
+		})
+		require.Equal(t, "User is valid", name)
+		require.Equal(t, []attribute.KeyValue{
+			semconv.CodeFilepathKey.String("./spec/models/user_spec.rb"),
+			semconv.CodeLineNumberKey.Int(12),
+			attribute.Key(TestID).String("./spec/models/user_spec.rb[1:2:1]"),
+		}, attrs)
+	})
+
+	t.Run("RSpec ID without location", func(t *testing.T) {
+		name, attrs := testDialect(junit.Test{
+			Name:       "User is valid",
+			Properties: map[string]string{"id": "./spec/models/user_spec.rb[1:2:1]"},
+		})
+		require.Equal(t, "User is valid", name)
+		require.Equal(t, []attribute.KeyValue{
+			semconv.CodeFilepathKey.String("./spec/models/user_spec.rb"),
+			attribute.Key(TestID).String("./spec/models/user_spec.rb[1:2:1]"),
+		}, attrs)
+	})
+
+	t.Run("Parentheses in the name", func(t *testing.T) {
+		name, attrs := testDialect(junit.Test{Name: "For_RPM_(amd64)"})
+		require.Equal(t, "For_RPM_(amd64)", name)
+		require.Empty(t, attrs)
+	})
+}
+
+func TestDisplayNameAttributes(t *testing.T) {
+	t.Run("Without display name", func(t *testing.T) {
+		require.Equal(t, []attribute.KeyValue{
+			attribute.Key(TestCaseName).String("User is valid"),
+			attribute.Key(TestDisplayName).String("User is valid"),
+		}, displayNameAttributes(junit.Test{Name: "User is valid (./spec/models/user_spec.rb:12)"}, "User is valid"))
+	})
+
+	t.Run("JUnit Platform legacy report", func(t *testing.T) {
+		test := junit.Test{
+			Name:      "addsTwoNumbers()",
+			SystemOut: "\nunique-id: [engine:junit-jupiter]/[class:com.example.CalculatorTests]/[method:addsTwoNumbers()]\ndisplay-name: 1 + 1 = 2\n",
+		}
+
+		require.Equal(t, []attribute.KeyValue{
+			attribute.Key(TestCaseName).String("addsTwoNumbers()"),
+			attribute.Key(TestDisplayName).String("1 + 1 = 2"),
+		}, displayNameAttributes(test, test.Name))
+	})
+
+	t.Run("Display name property", func(t *testing.T) {
+		test := junit.Test{Name: "addsTwoNumbers()", Properties: map[string]string{TestDisplayName: "adds two numbers"}}
+
+		require.Equal(t, []attribute.KeyValue{
+			attribute.Key(TestCaseName).String("addsTwoNumbers()"),
+			attribute.Key(TestDisplayName).String("adds two numbers"),
+		}, displayNameAttributes(test, test.Name))
+	})
+}
+
+func TestDemangledNameAttributes(t *testing.T) {
+	t.Run("Go subtests", func(t *testing.T) {
+		require.Equal(t, []attribute.KeyValue{
+			attribute.Key(TestCasePackage).String("github.com/acme/shop/cart"),
+			attribute.Key(TestCaseMethod).String("TestCart"),
+			attribute.Key(TestCaseSubtest).String("empty_cart/without_items"),
+		}, demangledNameAttributes("github.com/acme/shop/cart", "TestCart/empty_cart/without_items"))
+	})
+
+	t.Run("JUnit nested classes", func(t *testing.T) {
+		require.Equal(t, []attribute.KeyValue{
+			attribute.Key(TestCasePackage).String("com.acme"),
+			attribute.Key(TestCaseClass).String("CartTest.Totals"),
+			attribute.Key(TestCaseMethod).String("sums"),
+		}, demangledNameAttributes("com.acme.CartTest$Totals", "sums()"))
+
+		require.Equal(t, []attribute.KeyValue{
+			attribute.Key(TestCasePackage).String("com.acme"),
+			attribute.Key(TestCaseClass).String("CartTest.Totals"),
+			attribute.Key(TestCaseMethod).String("multiplies"),
+			attribute.Key(TestCaseParameters).String("2"),
+		}, demangledNameAttributes("com.acme.CartTest$Totals", "multiplies(int, int)[2]"))
+	})
+
+	t.Run("pytest node IDs", func(t *testing.T) {
+		require.Equal(t, []attribute.KeyValue{
+			attribute.Key(TestCasePackage).String("tests.unit.test_cart"),
+			attribute.Key(TestCaseClass).String("TestCart.TestTotals"),
+			attribute.Key(TestCaseMethod).String("test_total"),
+			attribute.Key(TestCaseParameters).String("3-4::[x]"),
+		}, demangledNameAttributes("", "tests/unit/test_cart.py::TestCart::TestTotals::test_total[3-4::[x]]"))
+
+		require.Equal(t, []attribute.KeyValue{
+			attribute.Key(TestCasePackage).String("tests.test_api"),
+			attribute.Key(TestCaseMethod).String("test_health"),
+		}, demangledNameAttributes("", "tests/test_api.py::test_health"))
+	})
+
+	t.Run("Plain names", func(t *testing.T) {
+		require.Empty(t, demangledNameAttributes("com.acme.CartTest", "sums"))
+		require.Empty(t, demangledNameAttributes("", "checks out a/b"))
+		require.Empty(t, demangledNameAttributes("", "test[a::b]"))
+	})
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// the reasons of the data dropped by the tool
+const (
+	dropReasonTruncation    = "truncation"
+	dropReasonFiltering     = "filtering"
+	dropReasonCardinality   = "cardinality"
+	dropReasonParseRecovery = "parse_recovery"
+	dropReasonEvaluation    = "evaluation"
+)
+
+// the logging of the dropped data: none, a summary once the report is exported, or every drop as it happens
+const (
+	logDroppedNone    = "none"
+	logDroppedSummary = "summary"
+	logDroppedAll     = "all"
+)
+
+// dropKind a kind of data dropped by the tool, with its description in the logs, and the hint to keep it, if any
+type dropKind struct {
+	name        string
+	reason      string
+	description string
+	hint        string
+}
+
+var (
+	droppedSpanAttributes = dropKind{"span_attributes", dropReasonTruncation, "span attributes over the limit of the SDK", "increase OTEL_SPAN_ATTRIBUTE_COUNT_LIMIT"}
+	droppedSpanEvents     = dropKind{"span_events", dropReasonTruncation, "span events over the limit of the SDK", "increase OTEL_SPAN_EVENT_COUNT_LIMIT"}
+	droppedSpanLinks      = dropKind{"span_links", dropReasonTruncation, "span links over the limit of the SDK", "increase OTEL_SPAN_LINK_COUNT_LIMIT"}
+	droppedNames          = dropKind{"names", dropReasonTruncation, "names of tests and suites shortened", "increase --name-limit"}
+	droppedProperties     = dropKind{"properties", dropReasonFiltering, "properties not allowed", "add them to --properties-allowed"}
+	droppedPerTestSeries  = dropKind{"per_test_executions", dropReasonCardinality, "test executions without per-test metrics", "increase --per-test-metrics-limit"}
+	droppedCharacters     = dropKind{"characters", dropReasonParseRecovery, "characters not allowed in XML removed", ""}
+	droppedUTF8Sequences  = dropKind{"utf8_sequences", dropReasonParseRecovery, "invalid UTF-8 sequences replaced", ""}
+	droppedCharReferences = dropKind{"character_references", dropReasonParseRecovery, "character references not allowed in XML removed", ""}
+	droppedDurations      = dropKind{"durations", dropReasonParseRecovery, "invalid durations of test cases read as 0", ""}
+	droppedHookChanges    = dropKind{"span_hooks", dropReasonEvaluation, "changes of span hooks whose expressions failed", "fix the expressions of the span_hooks in the config file"}
+	droppedDerived        = dropKind{"derived_attributes", dropReasonEvaluation, "derived attributes whose expressions failed", "fix the expressions of the derived_attributes in the config file"}
+)
+
+// droppedData the ledger of the data dropped while converting the reports, shared by all of them, as the drops are
+// found from the parsing of the reports to the end of their spans
+var droppedData = &dropLedger{}
+
+// dropLedger counts the data dropped by the tool by kind, so their absence is visible: they are logged, and recorded
+// as the junit2otlp.dropped metric once each report is converted. It's safe for concurrent use
+type dropLedger struct {
+	mu     sync.Mutex
+	counts map[dropKind]int64
+}
+
+// drop records the number of data of the kind dropped, logging the detail if every drop is logged
+func (l *dropLedger) drop(kind dropKind, count int64, detail string) {
+	if l == nil || count <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.counts == nil {
+		l.counts = map[dropKind]int64{}
+	}
+	l.counts[kind] += count
+
+	if logDroppedMode() == logDroppedAll {
+		log.Printf("dropped %d %s (%s): %s", count, kind.description, kind.reason, detail)
+	}
+}
+
+// flush records the data dropped since the last flush in the counter of the meter, and logs their summary
+func (l *dropLedger) flush(ctx context.Context, meter metric.Meter) {
+	l.mu.Lock()
+	counts := l.counts
+	l.counts = nil
+	l.mu.Unlock()
+
+	if len(counts) == 0 {
+		return
+	}
+
+	counter := createIntCounter(meter, DroppedDataCount, "Total number of data dropped by the tool, by reason and kind")
+	for _, kind := range sortedDropKinds(counts) {
+		counter.Add(ctx, counts[kind], metric.WithAttributes(
+			attribute.Key(DroppedReason).String(kind.reason),
+			attribute.Key(DroppedKind).String(kind.name),
+		))
+
+		if logDroppedMode() == logDroppedNone {
+			continue
+		}
+
+		message := fmt.Sprintf("dropped %d %s (%s)", counts[kind], kind.description, kind.reason)
+		if kind.hint != "" {
+			message += ": " + kind.hint
+		}
+		log.Print(message)
+	}
+}
+
+func sortedDropKinds(counts map[dropKind]int64) []dropKind {
+	kinds := make([]dropKind, 0, len(counts))
+	for kind := range counts {
+		kinds = append(kinds, kind)
+	}
+	slices.SortFunc(kinds, func(a, b dropKind) int {
+		return strings.Compare(a.reason+"/"+a.name, b.reason+"/"+b.name)
+	})
+
+	return kinds
+}
+
+// logDroppedMode returns the logging of the dropped data, defaulting to the summary for unknown values, as the
+// flag is validated when the run starts
+func logDroppedMode() string {
+	switch mode := strings.ToLower(strings.TrimSpace(logDroppedFlag)); mode {
+	case logDroppedNone, logDroppedAll:
+		return mode
+	default:
+		return logDroppedSummary
+	}
+}
+
+// parseLogDropped validates the logging of the dropped data
+func parseLogDropped(mode string) error {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case logDroppedNone, logDroppedSummary, logDroppedAll:
+		return nil
+	default:
+		return fmt.Errorf("invalid logging of the dropped data %q: valid values are %s, %s and %s", mode, logDroppedNone, logDroppedSummary, logDroppedAll)
+	}
+}
+
+// droppedLimitsProcessor records the attributes, events and links of the spans dropped by the limits of the SDK,
+// which are only known once the spans end
+type droppedLimitsProcessor struct {
+	ledger *dropLedger
+}
+
+func (p droppedLimitsProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {}
+
+func (p droppedLimitsProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	p.ledger.drop(droppedSpanAttributes, int64(s.DroppedAttributes()), s.Name())
+	p.ledger.drop(droppedSpanEvents, int64(s.DroppedEvents()), s.Name())
+	p.ledger.drop(droppedSpanLinks, int64(s.DroppedLinks()), s.Name())
+}
+
+func (p droppedLimitsProcessor) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+func (p droppedLimitsProcessor) ForceFlush(ctx context.Context) error {
+	return nil
+}
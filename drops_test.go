@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// captureLog returns the buffer where the log is written until the end of the test
+func captureLog(t *testing.T) *bytes.Buffer {
+	writer := log.Writer()
+	t.Cleanup(func() { log.SetOutput(writer) })
+
+	buf := &bytes.Buffer{}
+	log.SetOutput(buf)
+
+	return buf
+}
+
+// droppedCounts returns the values of the junit2otlp.dropped counter collected by the reader, by kind
+func droppedCounts(t *testing.T, reader *sdkmetric.ManualReader) map[string]int64 {
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	counts := map[string]int64{}
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if m.Name != DroppedDataCount {
+				continue
+			}
+
+			for _, point := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				kind, _ := point.Attributes.Value(attribute.Key(DroppedKind))
+				counts[kind.AsString()] = point.Value
+			}
+		}
+	}
+
+	return counts
+}
+
+func TestDropLedger(t *testing.T) {
+	defer func(mode string) { logDroppedFlag = mode }(logDroppedFlag)
+
+	t.Run("Records and logs the summary", func(t *testing.T) {
+		logDroppedFlag = logDroppedSummary
+		output := captureLog(t)
+
+		ledger := &dropLedger{}
+		wg := sync.WaitGroup{}
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ledger.drop(droppedProperties, 1, "hostname")
+				ledger.drop(droppedCharacters, 3, "ANSI escape codes")
+			}()
+		}
+		wg.Wait()
+		ledger.drop(droppedNames, 0, "no drop")
+
+		reader := sdkmetric.NewManualReader()
+		ledger.flush(context.Background(), sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test"))
+
+		require.Equal(t, map[string]int64{"properties": 10, "characters": 30}, droppedCounts(t, reader))
+		require.Contains(t, output.String(), "dropped 10 properties not allowed (filtering): add them to --properties-allowed")
+		require.Contains(t, output.String(), "dropped 30 characters not allowed in XML removed (parse_recovery)")
+		require.NotContains(t, output.String(), "hostname")
+
+		// the drops are recorded once
+		output.Reset()
+		ledger.flush(context.Background(), sdkmetric.NewMeterProvider().Meter("test"))
+		require.Empty(t, output.String())
+	})
+
+	t.Run("Logs every drop", func(t *testing.T) {
+		logDroppedFlag = logDroppedAll
+		output := captureLog(t)
+
+		ledger := &dropLedger{}
+		ledger.drop(droppedProperties, 1, "hostname")
+		require.Contains(t, output.String(), "dropped 1 properties not allowed (filtering): hostname")
+	})
+
+	t.Run("Logs nothing", func(t *testing.T) {
+		logDroppedFlag = logDroppedNone
+		output := captureLog(t)
+
+		ledger := &dropLedger{}
+		ledger.drop(droppedProperties, 1, "hostname")
+
+		reader := sdkmetric.NewManualReader()
+		ledger.flush(context.Background(), sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test"))
+		require.Empty(t, output.String())
+		require.Equal(t, map[string]int64{"properties": 1}, droppedCounts(t, reader))
+	})
+
+	t.Run("Invalid logging", func(t *testing.T) {
+		require.NoError(t, parseLogDropped(logDroppedAll))
+		require.Error(t, parseLogDropped("verbose"))
+	})
+}
+
+func TestDroppedData(t *testing.T) {
+	defer func(drops *dropLedger) { droppedData = drops }(droppedData)
+
+	t.Run("Parse recovery", func(t *testing.T) {
+		ledger := &dropLedger{}
+		sanitizeXMLReport([]byte("<testsuite name=\"\x1b[31mred\x1b[0m \xff\xfe ok \xff\">&#27;&#x1b;&#65;</testsuite>"), ledger)
+		require.Equal(t, map[dropKind]int64{droppedCharacters: 2, droppedUTF8Sequences: 2, droppedCharReferences: 2}, ledger.counts)
+
+		droppedData = &dropLedger{}
+		_, err := ingestReport([]byte(`<testsuite><testcase name="a" time="fast"/><testcase name="b" time="1.5"/><testcase name="c"/></testsuite>`))
+		require.NoError(t, err)
+		require.Equal(t, map[dropKind]int64{droppedDurations: 1}, droppedData.counts)
+	})
+
+	t.Run("Filtered properties", func(t *testing.T) {
+		defer func(allowed string) { propertiesAllowedString = allowed }(propertiesAllowedString)
+		propertiesAllowedString = "browser"
+
+		droppedData = &dropLedger{}
+		attributes := appendPropsLabels(nil, map[string]string{"browser": "chrome", "hostname": "ci-1", "user": "root"})
+		require.Len(t, attributes, 1)
+		require.Equal(t, map[dropKind]int64{droppedProperties: 2}, droppedData.counts)
+	})
+
+	t.Run("Span limits", func(t *testing.T) {
+		ledger := &dropLedger{}
+		limits := sdktrace.NewSpanLimits()
+		limits.AttributeCountLimit = 2
+		provider := sdktrace.NewTracerProvider(sdktrace.WithRawSpanLimits(limits), sdktrace.WithSpanProcessor(droppedLimitsProcessor{ledger: ledger}))
+
+		_, span := provider.Tracer("test").Start(context.Background(), "test")
+		span.SetAttributes(attribute.String("a", "1"), attribute.String("b", "2"), attribute.String("c", "3"))
+		span.End()
+
+		require.Equal(t, map[dropKind]int64{droppedSpanAttributes: 1}, ledger.counts)
+	})
+}
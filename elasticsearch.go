@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+const defaultElasticsearchIndex = "junit2otlp-tests"
+
+// elasticsearchExporter indexes a document per test case in Elasticsearch or OpenSearch with the bulk API,
+// for the teams without an OpenTelemetry collector. The documents include all the attributes of the test span
+// and its resource as labels, flattened as the columns of the rows of the test cases
+type elasticsearchExporter struct {
+	url      string
+	index    string
+	apiKey   string
+	username string
+	password string
+	client   *http.Client
+}
+
+// getElasticsearchURL the precedence order is: flag > ELASTICSEARCH_URL > disabled
+func getElasticsearchURL() string {
+	return strings.TrimSuffix(getOtlpEnvVar(elasticsearchURLFlag, "ELASTICSEARCH_URL", ""), "/")
+}
+
+// newElasticsearchExporter creates the exporter, reading the credentials from the ELASTICSEARCH_API_KEY, or the
+// ELASTICSEARCH_USERNAME and ELASTICSEARCH_PASSWORD environment variables, so they are not exposed as flags
+func newElasticsearchExporter(url string, index string) *elasticsearchExporter {
+	return &elasticsearchExporter{
+		url:      url,
+		index:    index,
+		apiKey:   os.Getenv("ELASTICSEARCH_API_KEY"),
+		username: os.Getenv("ELASTICSEARCH_USERNAME"),
+		password: os.Getenv("ELASTICSEARCH_PASSWORD"),
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// bulkResponse the subset of the response of the bulk API needed to detect the rejected documents
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+		Error  struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// ExportSpans indexes the spans of the test cases, ignoring the spans of the run and the suites
+func (e *elasticsearchExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	body := &bytes.Buffer{}
+	encoder := json.NewEncoder(body)
+
+	documents := 0
+	for _, span := range spans {
+		if !isTestCaseSpan(span) {
+			continue
+		}
+
+		if err := encoder.Encode(map[string]any{"index": map[string]string{"_index": e.index}}); err != nil {
+			return err
+		}
+		if err := encoder.Encode(elasticsearchDocument(span)); err != nil {
+			return err
+		}
+		documents++
+	}
+
+	if documents == 0 {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url+"/_bulk", body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+e.apiKey)
+	} else if e.username != "" {
+		req.SetBasicAuth(e.username, e.password)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to index the test cases in elasticsearch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read the response of elasticsearch: %w", err)
+	}
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("elasticsearch responded with %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	result := bulkResponse{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("failed to parse the response of elasticsearch: %w", err)
+	}
+
+	if !result.Errors {
+		return nil
+	}
+
+	rejected := 0
+	reason := ""
+	for _, item := range result.Items {
+		for _, action := range item {
+			if action.Status/100 == 2 {
+				continue
+			}
+
+			rejected++
+			if reason == "" {
+				reason = action.Error.Type + ": " + action.Error.Reason
+			}
+		}
+	}
+
+	return fmt.Errorf("elasticsearch rejected %d of %d test case documents: %s", rejected, documents, reason)
+}
+
+// Shutdown implements the span exporter, there is nothing to release
+func (e *elasticsearchExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// elasticsearchDocument the document of a test case, following the Elastic Common Schema for the trace fields
+func elasticsearchDocument(span sdktrace.ReadOnlySpan) map[string]any {
+	service := map[string]any{}
+	if res := span.Resource(); res != nil {
+		if name, ok := res.Set().Value(semconv.ServiceNameKey); ok {
+			service["name"] = name.AsString()
+		}
+		if version, ok := res.Set().Value(semconv.ServiceVersionKey); ok {
+			service["version"] = version.AsString()
+		}
+	}
+
+	document := map[string]any{
+		"@timestamp": span.StartTime().UTC().Format(time.RFC3339Nano),
+		"name":       span.Name(),
+		"trace":      map[string]string{"id": span.SpanContext().TraceID().String()},
+		"span": map[string]any{
+			"id":       span.SpanContext().SpanID().String(),
+			"duration": map[string]int64{"us": span.EndTime().Sub(span.StartTime()).Microseconds()},
+		},
+		"labels": flattenAttributes(span),
+	}
+
+	if span.Parent().IsValid() {
+		document["parent"] = map[string]string{"id": span.Parent().SpanID().String()}
+	}
+
+	if len(service) > 0 {
+		document["service"] = service
+	}
+
+	return document
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+func TestElasticsearchExporter(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	res := resource.NewSchemaless(semconv.ServiceNameKey.String("e2e"), attribute.Key(ScmBranch).String("main"))
+
+	spans := tracetest.SpanStubs{
+		{
+			Name:       "suite",
+			StartTime:  start,
+			EndTime:    start.Add(time.Second),
+			Attributes: []attribute.KeyValue{attribute.Key(TestsSuiteName).String("suite")},
+			Resource:   res,
+		},
+		{
+			Name:      "TestLogin",
+			StartTime: start,
+			EndTime:   start.Add(1500 * time.Millisecond),
+			Attributes: []attribute.KeyValue{
+				attribute.Key(TestStatus).String("failed"),
+				attribute.Key(TestDuration).Int64(1500),
+			},
+			Resource: res,
+		},
+	}.Snapshots()
+
+	t.Run("Indexes the test cases", func(t *testing.T) {
+		t.Setenv("ELASTICSEARCH_API_KEY", "secret")
+
+		lines := []map[string]any{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/_bulk", r.URL.Path)
+			require.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+			require.Equal(t, "ApiKey secret", r.Header.Get("Authorization"))
+
+			scanner := bufio.NewScanner(r.Body)
+			for scanner.Scan() {
+				line := map[string]any{}
+				require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+				lines = append(lines, line)
+			}
+
+			w.Write([]byte(`{"errors":false,"items":[{"index":{"status":201}}]}`))
+		}))
+		defer server.Close()
+
+		exporter := newElasticsearchExporter(server.URL, "tests")
+		require.NoError(t, exporter.ExportSpans(context.Background(), spans))
+
+		// the action and the document of the only test case
+		require.Len(t, lines, 2)
+		require.Equal(t, map[string]any{"_index": "tests"}, lines[0]["index"])
+
+		document := lines[1]
+		require.Equal(t, "TestLogin", document["name"])
+		require.Equal(t, "2024-01-01T12:00:00Z", document["@timestamp"])
+		require.Equal(t, map[string]any{"name": "e2e"}, document["service"])
+		require.Equal(t, float64(1500000), document["span"].(map[string]any)["duration"].(map[string]any)["us"])
+
+		labels := document["labels"].(map[string]any)
+		require.Equal(t, "failed", labels["tests_case_status"])
+		require.Equal(t, float64(1500), labels["tests_case_duration"])
+		require.Equal(t, "main", labels["scm_branch"])
+	})
+
+	t.Run("Rejected documents", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"errors":true,"items":[{"index":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"failed to parse"}}}]}`))
+		}))
+		defer server.Close()
+
+		err := newElasticsearchExporter(server.URL, "tests").ExportSpans(context.Background(), spans)
+		require.EqualError(t, err, "elasticsearch rejected 1 of 1 test case documents: mapper_parsing_exception: failed to parse")
+	})
+
+	t.Run("Error response", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		err := newElasticsearchExporter(server.URL, "tests").ExportSpans(context.Background(), spans)
+		require.ErrorContains(t, err, "401 Unauthorized: unauthorized")
+	})
+
+	t.Run("No test cases", func(t *testing.T) {
+		exporter := newElasticsearchExporter("http://localhost:0", "tests")
+		require.NoError(t, exporter.ExportSpans(context.Background(), []sdktrace.ReadOnlySpan{spans[0]}))
+	})
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/joshdk/go-junit"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+// hostnameProperties the properties and attributes of the suites with the name of the host where they ran:
+// the hostname attribute of Ant and Jenkins, and the environment variables recorded by Surefire
+var hostnameProperties = []string{"hostname", "host.name", "env.HOSTNAME", "env.COMPUTERNAME"}
+
+// executorProperties the system properties recorded by Surefire and Gradle, mapped to the runtime attributes
+var executorProperties = []struct {
+	key        attribute.Key
+	properties []string
+}{
+	{semconv.OSNameKey, []string{"os.name"}},
+	{semconv.OSVersionKey, []string{"os.version"}},
+	{semconv.ProcessRuntimeNameKey, []string{"java.runtime.name", "java.vm.name"}},
+	{semconv.ProcessRuntimeVersionKey, []string{"java.runtime.version", "java.version"}},
+}
+
+// javaArchitectures the values of the os.arch property of the JVM, mapped to the values of host.arch
+var javaArchitectures = map[string]attribute.KeyValue{
+	"amd64":   semconv.HostArchAMD64,
+	"x86_64":  semconv.HostArchAMD64,
+	"aarch64": semconv.HostArchARM64,
+	"arm64":   semconv.HostArchARM64,
+	"x86":     semconv.HostArchX86,
+	"i386":    semconv.HostArchX86,
+}
+
+// executorAttributes returns the attributes of the host and the runtime where the suite ran, which may not be the
+// host running the tool, i.e. in distributed test farms. They are read from the raw XML element of the suite, if
+// present, as go-junit replaces the attributes of the suites with their properties, and from its properties.
+// They come after the runtime attributes of the tool and the properties, overriding them
+func executorAttributes(suite junit.Suite, rawSuite *xmlElement) []attribute.KeyValue {
+	var properties map[string]string
+	if rawSuite != nil {
+		properties = rawSuite.Properties()
+	}
+
+	lookup := func(names ...string) string {
+		for _, name := range names {
+			if rawSuite != nil {
+				if value, ok := rawSuite.Attr(name); ok && strings.TrimSpace(value) != "" {
+					return strings.TrimSpace(value)
+				}
+			}
+
+			if value := strings.TrimSpace(suite.Properties[name]); value != "" {
+				return value
+			}
+
+			if value := strings.TrimSpace(properties[name]); value != "" {
+				return value
+			}
+		}
+
+		return ""
+	}
+
+	attributes := []attribute.KeyValue{}
+	if hostname := lookup(hostnameProperties...); hostname != "" {
+		attributes = append(attributes, semconv.HostNameKey.String(hostname))
+	}
+
+	if arch, ok := javaArchitectures[strings.ToLower(lookup("os.arch"))]; ok {
+		attributes = append(attributes, arch)
+	}
+
+	for _, executor := range executorProperties {
+		if value := lookup(executor.properties...); value != "" {
+			attributes = append(attributes, executor.key.String(value))
+		}
+	}
+
+	// the description of the JVM, i.e. "Eclipse Adoptium OpenJDK 64-Bit Server VM 17.0.8+7"
+	description := strings.Fields(strings.Join([]string{lookup("java.vm.vendor"), lookup("java.vm.name"), lookup("java.vm.version")}, " "))
+	if len(description) > 0 {
+		attributes = append(attributes, semconv.ProcessRuntimeDescriptionKey.String(strings.Join(description, " ")))
+	}
+
+	return attributes
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+func TestExecutorAttributes(t *testing.T) {
+	t.Run("Surefire properties", func(t *testing.T) {
+		report, err := ingestReport([]byte(`<testsuite name="checkout" hostname="agent-7" tests="1">
+  <properties>
+    <property name="os.name" value="Linux"/>
+    <property name="os.version" value="6.1.0"/>
+    <property name="os.arch" value="aarch64"/>
+    <property name="java.runtime.name" value="OpenJDK Runtime Environment"/>
+    <property name="java.runtime.version" value="17.0.8+7"/>
+    <property name="java.vm.vendor" value="Eclipse Adoptium"/>
+    <property name="java.vm.name" value="OpenJDK 64-Bit Server VM"/>
+    <property name="java.vm.version" value="17.0.8+7"/>
+  </properties>
+  <testcase name="TestLogin"/>
+</testsuite>`))
+		require.NoError(t, err)
+
+		// the hostname attribute is replaced by the properties in go-junit, so it's read from the raw suite
+		require.NotContains(t, report.suites[0].Properties, "hostname")
+
+		require.Equal(t, []attribute.KeyValue{
+			semconv.HostNameKey.String("agent-7"),
+			semconv.HostArchARM64,
+			semconv.OSNameKey.String("Linux"),
+			semconv.OSVersionKey.String("6.1.0"),
+			semconv.ProcessRuntimeNameKey.String("OpenJDK Runtime Environment"),
+			semconv.ProcessRuntimeVersionKey.String("17.0.8+7"),
+			semconv.ProcessRuntimeDescriptionKey.String("Eclipse Adoptium OpenJDK 64-Bit Server VM 17.0.8+7"),
+		}, executorAttributes(report.suites[0], report.rawSuite(0)))
+	})
+
+	t.Run("Nested suites without raw element", func(t *testing.T) {
+		suite := junit.Suite{Properties: map[string]string{"env.HOSTNAME": "runner-1", "os.arch": "sparc"}}
+
+		require.Equal(t, []attribute.KeyValue{semconv.HostNameKey.String("runner-1")}, executorAttributes(suite, nil))
+	})
+
+	t.Run("Overriding the runtime attributes", func(t *testing.T) {
+		suite := junit.Suite{Name: "a", Properties: map[string]string{"os.name": "Windows 11"}}
+
+		set := attribute.NewSet(suiteSpanAttributes(suite, nil, nil)...)
+		value, ok := set.Value(semconv.OSNameKey)
+		require.True(t, ok)
+		require.Equal(t, "Windows 11", value.AsString())
+	})
+}
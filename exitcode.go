@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// the policies of the exit code for the failed tests
+const (
+	failOnNone     = "none"
+	failOnErrors   = "errors"
+	failOnFailures = "failures"
+	failOnAny      = "any"
+)
+
+// the exit codes of the failed tests, so the pipelines can tell the broken tests from the broken code. The errors
+// take precedence over the failures, and any other error of the tool exits with 1
+const (
+	exitCodeFailures = 2
+	exitCodeErrors   = 3
+)
+
+// exitError an error with the exit code of the process
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+func parseFailOn(policy string) (string, error) {
+	switch p := strings.ToLower(strings.TrimSpace(policy)); p {
+	case "":
+		return failOnNone, nil
+	case failOnNone, failOnErrors, failOnFailures, failOnAny:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid fail-on policy %q: valid values are %s, %s, %s and %s", policy, failOnNone, failOnErrors, failOnFailures, failOnAny)
+	}
+}
+
+// checkFailOn returns an exit error if the run has errors (the unexpected exceptions) or failures (the failed
+// assertions) covered by the policy
+func checkFailOn(policy string, summary runSummary) error {
+	if summary.Errors > 0 && (policy == failOnErrors || policy == failOnAny) {
+		return &exitError{code: exitCodeErrors, err: fmt.Errorf("%d test cases with errors, and %d with failures", summary.Errors, summary.Failed)}
+	}
+
+	if summary.Failed > 0 && (policy == failOnFailures || policy == failOnAny) {
+		return &exitError{code: exitCodeFailures, err: fmt.Errorf("%d test cases with failures, and %d with errors", summary.Failed, summary.Errors)}
+	}
+
+	return nil
+}
+
+// exit logs the error and exits with its exit code, or 1 if it has none
+func exit(err error) {
+	log.Print(err)
+
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.code)
+	}
+
+	os.Exit(1)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckFailOn(t *testing.T) {
+	tests := []struct {
+		policy  string
+		summary runSummary
+		code    int
+	}{
+		{failOnNone, runSummary{Failed: 1, Errors: 1}, 0},
+		{failOnErrors, runSummary{Failed: 1}, 0},
+		{failOnErrors, runSummary{Errors: 1}, exitCodeErrors},
+		{failOnFailures, runSummary{Errors: 1}, 0},
+		{failOnFailures, runSummary{Failed: 1, Errors: 1}, exitCodeFailures},
+		{failOnAny, runSummary{Failed: 1}, exitCodeFailures},
+		{failOnAny, runSummary{Failed: 1, Errors: 1}, exitCodeErrors},
+		{failOnAny, runSummary{Passed: 2}, 0},
+	}
+
+	for _, test := range tests {
+		err := checkFailOn(test.policy, test.summary)
+		if test.code == 0 {
+			require.NoError(t, err, test.policy)
+			continue
+		}
+
+		exitErr := &exitError{}
+		require.True(t, errors.As(err, &exitErr), test.policy)
+		require.Equal(t, test.code, exitErr.code, test.policy)
+	}
+
+	policy, err := parseFailOn(" ANY ")
+	require.NoError(t, err)
+	require.Equal(t, failOnAny, policy)
+
+	policy, err = parseFailOn("")
+	require.NoError(t, err)
+	require.Equal(t, failOnNone, policy)
+
+	_, err = parseFailOn("always")
+	require.Error(t, err)
+}
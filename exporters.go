@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+)
+
+// the signals exported by the tool, as named in the OpenTelemetry environment variables
+const (
+	signalTraces  = "TRACES"
+	signalMetrics = "METRICS"
+)
+
+// exportersConfig the exporters of each signal, for the vendors with a different ingest endpoint or key for the
+// traces and the metrics
+type exportersConfig struct {
+	Traces  signalExporterConfig `yaml:"traces"`
+	Metrics signalExporterConfig `yaml:"metrics"`
+}
+
+// signalExporterConfig the endpoint and the headers of the exporter of a signal. The environment variables of the
+// signal, i.e. OTEL_EXPORTER_OTLP_TRACES_ENDPOINT, or the generic ones, i.e. OTEL_EXPORTER_OTLP_ENDPOINT, take
+// precedence
+type signalExporterConfig struct {
+	Endpoint string            `yaml:"endpoint"`
+	Headers  map[string]string `yaml:"headers"`
+}
+
+func (c *exportersConfig) validate() error {
+	for signal, exporter := range map[string]signalExporterConfig{"traces": c.Traces, "metrics": c.Metrics} {
+		if exporter.Endpoint == "" {
+			continue
+		}
+
+		if !validOtlpEndpoint(exporter.Endpoint) {
+			return fmt.Errorf("invalid endpoint %q of the %s exporter: it must be an URL, i.e. https://collector:4317, or a unix socket, i.e. unix:///var/run/otelcol.sock", exporter.Endpoint, signal)
+		}
+	}
+
+	return nil
+}
+
+// endpoint returns the endpoint of the signal, unless it's set in the environment
+func (c signalExporterConfig) endpoint(signal string) string {
+	if firstEnv("OTEL_EXPORTER_OTLP_"+signal+"_ENDPOINT", "OTEL_EXPORTER_OTLP_ENDPOINT") != "" {
+		return ""
+	}
+
+	return c.Endpoint
+}
+
+// headers returns the headers of the signal, unless they are set in the environment, as they replace the ones
+// of the environment instead of being merged with them
+func (c signalExporterConfig) headers(signal string) map[string]string {
+	if firstEnv("OTEL_EXPORTER_OTLP_"+signal+"_HEADERS", "OTEL_EXPORTER_OTLP_HEADERS") != "" {
+		return nil
+	}
+
+	return c.Headers
+}
+
+func (c *exportersConfig) traceOptions() []otlpOption {
+	return otlpEndpointOptions(c.Traces.endpoint(signalTraces), c.Traces.headers(signalTraces))
+}
+
+func (c *exportersConfig) metricOptions() []otlpOption {
+	return otlpEndpointOptions(c.Metrics.endpoint(signalMetrics), c.Metrics.headers(signalMetrics))
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportersConfig(t *testing.T) {
+	load := func(t *testing.T, content string) (*config, error) {
+		path := filepath.Join(t.TempDir(), "config.yml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return loadConfig(path)
+	}
+
+	unsetEnv := func(t *testing.T) {
+		for _, name := range []string{"OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_HEADERS", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_HEADERS", "OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", "OTEL_EXPORTER_OTLP_METRICS_HEADERS"} {
+			t.Setenv(name, "")
+		}
+	}
+
+	content := `
+exporters:
+  traces:
+    endpoint: https://trace-intake.example.com:443
+    headers:
+      x-api-key: traces-key
+  metrics:
+    endpoint: https://metric-intake.example.com:443
+    headers:
+      x-api-key: metrics-key
+`
+
+	t.Run("Per signal", func(t *testing.T) {
+		unsetEnv(t)
+
+		cfg, err := load(t, content)
+		require.NoError(t, err)
+
+		require.Equal(t, "https://trace-intake.example.com:443", cfg.Exporters.Traces.endpoint(signalTraces))
+		require.Equal(t, map[string]string{"x-api-key": "traces-key"}, cfg.Exporters.Traces.headers(signalTraces))
+		require.Equal(t, "https://metric-intake.example.com:443", cfg.Exporters.Metrics.endpoint(signalMetrics))
+		require.Equal(t, map[string]string{"x-api-key": "metrics-key"}, cfg.Exporters.Metrics.headers(signalMetrics))
+		require.Len(t, cfg.Exporters.traceOptions(), 2)
+		require.Len(t, cfg.Exporters.metricOptions(), 2)
+	})
+
+	t.Run("Environment takes precedence", func(t *testing.T) {
+		unsetEnv(t)
+		t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "https://traces.example.com:4317")
+		t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "x-api-key=shared")
+
+		cfg, err := load(t, content)
+		require.NoError(t, err)
+
+		require.Empty(t, cfg.Exporters.Traces.endpoint(signalTraces))
+		require.Empty(t, cfg.Exporters.Traces.headers(signalTraces))
+		require.Equal(t, "https://metric-intake.example.com:443", cfg.Exporters.Metrics.endpoint(signalMetrics))
+		require.Empty(t, cfg.Exporters.Metrics.headers(signalMetrics))
+		require.Empty(t, cfg.Exporters.traceOptions())
+		require.Len(t, cfg.Exporters.metricOptions(), 1)
+	})
+
+	t.Run("Ping endpoint", func(t *testing.T) {
+		unsetEnv(t)
+
+		cfg, err := load(t, content)
+		require.NoError(t, err)
+
+		previous := appConfig
+		t.Cleanup(func() { appConfig = previous })
+		appConfig = cfg
+
+		require.Equal(t, "https://trace-intake.example.com:443", otlpTracesEndpoint())
+	})
+
+	t.Run("Without exporters", func(t *testing.T) {
+		cfg, err := load(t, `failure_rules: []`)
+		require.NoError(t, err)
+
+		require.Empty(t, cfg.Exporters.traceOptions())
+		require.Empty(t, cfg.Exporters.metricOptions())
+	})
+
+	t.Run("Invalid endpoint", func(t *testing.T) {
+		_, err := load(t, `
+exporters:
+  metrics:
+    endpoint: metric-intake.example.com
+`)
+		require.ErrorContains(t, err, `invalid endpoint "metric-intake.example.com" of the metrics exporter`)
+	})
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/ext"
+	"github.com/joshdk/go-junit"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// expressionEnv the environment of the CEL expressions of the configuration file, evaluated for each test case
+// with its name, the ones of its class and its suite, its status, message and duration, its properties, and the
+// attributes of its span
+var expressionEnv = sync.OnceValues(func() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable("name", cel.StringType),
+		cel.Variable("classname", cel.StringType),
+		cel.Variable("suite", cel.StringType),
+		cel.Variable("status", cel.StringType),
+		cel.Variable("message", cel.StringType),
+		cel.Variable("duration", cel.DurationType),
+		cel.Variable("properties", cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable("attributes", cel.MapType(cel.StringType, cel.DynType)),
+		ext.Strings(),
+	)
+})
+
+var stringSliceType = reflect.TypeOf([]string{})
+
+// compileExpression compiles the CEL expression, checking it evaluates to the type if it's not nil
+func compileExpression(source string, want *cel.Type) (cel.Program, error) {
+	env, err := expressionEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	ast, issues := env.Compile(source)
+	if issues.Err() != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", source, issues.Err())
+	}
+
+	if want != nil && !ast.OutputType().IsExactType(want) {
+		return nil, fmt.Errorf("the expression %q is a %s instead of a %s", source, ast.OutputType(), want)
+	}
+
+	return env.Program(ast)
+}
+
+// testActivation returns the variables of the expressions for the test case, with its current name, status and
+// attributes
+func testActivation(suite junit.Suite, test junit.Test, name string, status string, attributes []attribute.KeyValue) map[string]any {
+	values := make(map[string]any, len(attributes))
+	for _, kv := range attributes {
+		values[string(kv.Key)] = kv.Value.AsInterface()
+	}
+
+	properties := test.Properties
+	if properties == nil {
+		properties = map[string]string{}
+	}
+
+	return map[string]any{
+		"name":       name,
+		"classname":  test.Classname,
+		"suite":      suite.Name,
+		"status":     status,
+		"message":    test.Message,
+		"duration":   test.Duration,
+		"properties": properties,
+		"attributes": values,
+	}
+}
+
+// expressionAttribute converts the result of an expression into the value of an attribute: a string, a number,
+// a boolean or a list of strings
+func expressionAttribute(key string, val ref.Val) (attribute.KeyValue, error) {
+	switch v := val.Value().(type) {
+	case string:
+		return attribute.Key(key).String(v), nil
+	case int64:
+		return attribute.Key(key).Int64(v), nil
+	case uint64:
+		return attribute.Key(key).Int64(int64(v)), nil
+	case float64:
+		return attribute.Key(key).Float64(v), nil
+	case bool:
+		return attribute.Key(key).Bool(v), nil
+	}
+
+	if list, err := val.ConvertToNative(stringSliceType); err == nil {
+		return attribute.Key(key).StringSlice(list.([]string)), nil
+	}
+
+	return attribute.KeyValue{}, fmt.Errorf("the value of the attribute %s is a %s, not a string, a number, a boolean or a list of strings", key, val.Type().TypeName())
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/cel-go/common/types"
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestCompileExpression(t *testing.T) {
+	_, err := compileExpression(`suite.startsWith("e2e") ? "e2e" : "unit"`, nil)
+	require.NoError(t, err)
+
+	_, err = compileExpression(`duration > duration("1m")`, types.BoolType)
+	require.NoError(t, err)
+
+	_, err = compileExpression(`name +`, nil)
+	require.ErrorContains(t, err, `invalid expression "name +"`)
+
+	_, err = compileExpression(`duration`, types.StringType)
+	require.ErrorContains(t, err, "instead of a string")
+}
+
+func TestTestActivation(t *testing.T) {
+	program, err := compileExpression(`[name, classname, suite, status, message, string(duration), properties.tier, string(attributes["tests.case.duration"])]`, nil)
+	require.NoError(t, err)
+
+	test := junit.Test{Classname: "pkg", Message: "boom", Duration: 1500 * time.Millisecond, Properties: map[string]string{"tier": "gold"}}
+	activation := testActivation(junit.Suite{Name: "a"}, test, "TestA", "failed", []attribute.KeyValue{attribute.Key(TestDuration).Int64(1500)})
+
+	val, _, err := program.Eval(activation)
+	require.NoError(t, err)
+
+	kv, err := expressionAttribute("values", val)
+	require.NoError(t, err)
+	require.Equal(t, []string{"TestA", "pkg", "a", "failed", "boom", "1.5s", "gold", "1500"}, kv.Value.AsStringSlice())
+
+	// the properties are never missing
+	program, err = compileExpression(`size(properties)`, nil)
+	require.NoError(t, err)
+	val, _, err = program.Eval(testActivation(junit.Suite{}, junit.Test{}, "", "", nil))
+	require.NoError(t, err)
+	require.Equal(t, int64(0), val.Value())
+}
+
+func TestExpressionAttribute(t *testing.T) {
+	for _, tc := range []struct {
+		val      any
+		expected attribute.KeyValue
+	}{
+		{"e2e", attribute.Key("k").String("e2e")},
+		{int64(3), attribute.Key("k").Int64(3)},
+		{uint64(4), attribute.Key("k").Int64(4)},
+		{0.5, attribute.Key("k").Float64(0.5)},
+		{true, attribute.Key("k").Bool(true)},
+		{[]string{"a", "b"}, attribute.Key("k").StringSlice([]string{"a", "b"})},
+	} {
+		kv, err := expressionAttribute("k", types.DefaultTypeAdapter.NativeToValue(tc.val))
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, kv)
+	}
+
+	_, err := expressionAttribute("k", types.DefaultTypeAdapter.NativeToValue(map[string]string{"a": "b"}))
+	require.ErrorContains(t, err, "the value of the attribute k is a map")
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+const (
+	outputFormatCSV     = "csv"
+	outputFormatParquet = "parquet"
+)
+
+// fileRowWriter writes the rows of the test cases to a local file, for ad-hoc analysis in spreadsheets or DuckDB.
+// The rows are kept until the writer is closed, as the columns are not known until all of them are received
+type fileRowWriter struct {
+	mu     sync.Mutex
+	format string
+	path   string
+	rows   []map[string]any
+}
+
+// newFileRowWriter creates the writer of the file in the given format, named after it if the path is empty
+func newFileRowWriter(format string, path string) (*fileRowWriter, error) {
+	if format != outputFormatCSV && format != outputFormatParquet {
+		return nil, fmt.Errorf("invalid output format: %s, the valid formats are %s and %s", format, outputFormatCSV, outputFormatParquet)
+	}
+
+	if path == "" {
+		path = Junit2otlp + "-tests." + format
+	}
+
+	return &fileRowWriter{format: format, path: path}, nil
+}
+
+func (w *fileRowWriter) writeRows(ctx context.Context, rows []map[string]any) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.rows = append(w.rows, rows...)
+	return nil
+}
+
+// close writes the file, sorting the rows by their timestamp, as the spans are exported as they end
+func (w *fileRowWriter) close(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	slices.SortStableFunc(w.rows, func(a, b map[string]any) int {
+		return compareRowValues(a[rowTimestamp], b[rowTimestamp])
+	})
+
+	file, err := os.Create(w.path)
+	if err != nil {
+		return fmt.Errorf("failed to create the output file: %w", err)
+	}
+	defer file.Close()
+
+	columns := rowColumns(w.rows)
+	if w.format == outputFormatParquet {
+		err = writeParquet(file, columns, w.rows)
+	} else {
+		err = writeCSV(file, columns, w.rows)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write the output file %s: %w", w.path, err)
+	}
+
+	return file.Close()
+}
+
+func writeCSV(file *os.File, columns []string, rows []map[string]any) error {
+	writer := csv.NewWriter(file)
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		record := make([]string, len(columns))
+		for i, column := range columns {
+			if value, ok := row[column]; ok {
+				record[i] = formatRowValue(value)
+			}
+		}
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// rowColumns the columns of the rows: the identifiers and timing of the test cases, then the attributes sorted
+func rowColumns(rows []map[string]any) []string {
+	columns := []string{rowTimestamp, rowTraceID, rowSpanID, rowParentSpanID, rowName, rowDurationMs}
+
+	attributes := []string{}
+	for _, row := range rows {
+		for column := range row {
+			if !slices.Contains(columns, column) && !slices.Contains(attributes, column) {
+				attributes = append(attributes, column)
+			}
+		}
+	}
+	slices.Sort(attributes)
+
+	return append(columns, attributes...)
+}
+
+// formatRowValue formats the value of a column as text, with the arrays encoded as JSON
+func formatRowValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
+
+func compareRowValues(a any, b any) int {
+	textA, textB := formatRowValue(a), formatRowValue(b)
+	switch {
+	case textA < textB:
+		return -1
+	case textA > textB:
+		return 1
+	default:
+		return 0
+	}
+}
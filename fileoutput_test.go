@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileRowWriter(t *testing.T) {
+	rows := []map[string]any{
+		{rowTimestamp: "2024-01-01T12:00:01Z", rowName: "TestCheckout", rowDurationMs: int64(250), "tests_case_status": "failed", "tests_case_flaky": true},
+		{rowTimestamp: "2024-01-01T12:00:00Z", rowName: "TestLogin", rowDurationMs: int64(100), "tests_case_status": "passed", "scm_authors": []string{"a", "b"}},
+	}
+
+	write := func(t *testing.T, format string) string {
+		path := filepath.Join(t.TempDir(), "tests."+format)
+
+		writer, err := newFileRowWriter(format, path)
+		require.NoError(t, err)
+		require.NoError(t, writer.writeRows(context.Background(), rows))
+		require.NoError(t, writer.close(context.Background()))
+
+		return path
+	}
+
+	t.Run("CSV", func(t *testing.T) {
+		file, err := os.Open(write(t, outputFormatCSV))
+		require.NoError(t, err)
+		defer file.Close()
+
+		records, err := csv.NewReader(file).ReadAll()
+		require.NoError(t, err)
+
+		require.Equal(t, []string{rowTimestamp, rowTraceID, rowSpanID, rowParentSpanID, rowName, rowDurationMs, "scm_authors", "tests_case_flaky", "tests_case_status"}, records[0])
+		// sorted by timestamp
+		require.Equal(t, []string{"2024-01-01T12:00:00Z", "", "", "", "TestLogin", "100", `["a","b"]`, "", "passed"}, records[1])
+		require.Equal(t, []string{"2024-01-01T12:00:01Z", "", "", "", "TestCheckout", "250", "", "true", "failed"}, records[2])
+	})
+
+	t.Run("Parquet", func(t *testing.T) {
+		data, err := os.ReadFile(write(t, outputFormatParquet))
+		require.NoError(t, err)
+
+		require.Equal(t, parquetMagic, string(data[:4]))
+		require.Equal(t, parquetMagic, string(data[len(data)-4:]))
+
+		size := binary.LittleEndian.Uint32(data[len(data)-8:])
+		metadata := (&thriftReader{buf: bytes.NewReader(data[len(data)-8-int(size) : len(data)-8])}).readStruct()
+
+		require.Equal(t, int64(2), metadata[3])
+
+		schema := metadata[2].([]any)
+		require.Len(t, schema, 10)
+		durationColumn := 6
+		require.Equal(t, []byte(rowDurationMs), schema[durationColumn].(map[int16]any)[4])
+		require.Equal(t, int64(parquetInt64), schema[durationColumn].(map[int16]any)[1])
+		require.Equal(t, int64(parquetBoolean), schema[8].(map[int16]any)[1])
+		require.Equal(t, int64(parquetByteArray), schema[9].(map[int16]any)[1])
+
+		// the values of the duration column, after the page header and the definition levels
+		chunks := metadata[4].([]any)[0].(map[int16]any)[1].([]any)
+		chunk := chunks[durationColumn-1].(map[int16]any)[3].(map[int16]any)
+		page := bytes.NewReader(data[chunk[9].(int64):])
+		header := (&thriftReader{buf: page}).readStruct()
+		require.Equal(t, int64(2), header[5].(map[int16]any)[1])
+
+		var levelsSize uint32
+		require.NoError(t, binary.Read(page, binary.LittleEndian, &levelsSize))
+		levels := make([]byte, levelsSize)
+		_, err = page.Read(levels)
+		require.NoError(t, err)
+		require.Equal(t, []byte{0x03, 0x03}, levels)
+
+		values := make([]int64, 2)
+		require.NoError(t, binary.Read(page, binary.LittleEndian, values))
+		require.Equal(t, []int64{100, 250}, values)
+	})
+
+	t.Run("Invalid format", func(t *testing.T) {
+		_, err := newFileRowWriter("xlsx", "")
+		require.Error(t, err)
+	})
+}
+
+// thriftReader decodes the Thrift compact protocol into generic values, to check the Parquet metadata
+type thriftReader struct {
+	buf *bytes.Reader
+}
+
+func (r *thriftReader) readStruct() map[int16]any {
+	fields := map[int16]any{}
+	last := int16(0)
+	for {
+		header, _ := r.buf.ReadByte()
+		if header == 0 {
+			return fields
+		}
+
+		id := last + int16(header>>4)
+		if header>>4 == 0 {
+			value, _ := binary.ReadUvarint(r.buf)
+			id = int16(unzigzag(value))
+		}
+		last = id
+
+		fields[id] = r.readValue(header & 0x0f)
+	}
+}
+
+func (r *thriftReader) readValue(kind byte) any {
+	switch kind {
+	case thriftI32, thriftI64:
+		value, _ := binary.ReadUvarint(r.buf)
+		return unzigzag(value)
+	case thriftBinary:
+		size, _ := binary.ReadUvarint(r.buf)
+		value := make([]byte, size)
+		_, _ = r.buf.Read(value)
+		return value
+	case thriftList:
+		header, _ := r.buf.ReadByte()
+		size := uint64(header >> 4)
+		if size == 15 {
+			size, _ = binary.ReadUvarint(r.buf)
+		}
+
+		values := []any{}
+		for i := uint64(0); i < size; i++ {
+			values = append(values, r.readValue(header&0x0f))
+		}
+		return values
+	case thriftStruct:
+		return r.readStruct()
+	default:
+		panic("unsupported thrift type")
+	}
+}
+
+func unzigzag(value uint64) int64 {
+	return int64(value>>1) ^ -int64(value&1)
+}
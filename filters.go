@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/joshdk/go-junit"
+)
+
+// reportFilter selects the suites and the test cases of the report to export, i.e. only the e2e suites of a giant
+// report, by glob patterns of their names, or only the failing test cases, before their spans are created
+type reportFilter struct {
+	includeSuites []*regexp.Regexp
+	excludeSuites []*regexp.Regexp
+	includeTests  []*regexp.Regexp
+	// onlyFailures keeps the failing test cases, with the given number of test cases around each of them in their
+	// suite, so the effects of the order of the tests can be understood
+	onlyFailures bool
+	context      int
+}
+
+// newReportFilter compiles the comma-separated glob patterns of the filters, returning nil if there are none
+func newReportFilter(includeSuites string, excludeSuites string, includeTests string, onlyFailures bool, context int) (*reportFilter, error) {
+	switch {
+	case context < 0:
+		return nil, fmt.Errorf("invalid --with-context %d: it can't be negative", context)
+	case context > 0 && !onlyFailures:
+		return nil, fmt.Errorf("invalid --with-context %d: it requires --only-failures", context)
+	}
+
+	filter := &reportFilter{onlyFailures: onlyFailures, context: context}
+
+	var err error
+	if filter.includeSuites, err = compileGlobs(includeSuites); err != nil {
+		return nil, fmt.Errorf("invalid --include-suite: %w", err)
+	}
+	if filter.excludeSuites, err = compileGlobs(excludeSuites); err != nil {
+		return nil, fmt.Errorf("invalid --exclude-suite: %w", err)
+	}
+	if filter.includeTests, err = compileGlobs(includeTests); err != nil {
+		return nil, fmt.Errorf("invalid --include-test: %w", err)
+	}
+
+	if len(filter.includeSuites) == 0 && len(filter.excludeSuites) == 0 && len(filter.includeTests) == 0 && !onlyFailures {
+		return nil, nil
+	}
+
+	return filter, nil
+}
+
+// compileGlobs compiles the comma-separated glob patterns, where * matches any sequence of characters, including
+// the separators of the packages, and ? matches a single character
+func compileGlobs(patterns string) ([]*regexp.Regexp, error) {
+	globs := []*regexp.Regexp{}
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+
+		var expr strings.Builder
+		expr.WriteString("^")
+		for _, r := range pattern {
+			switch r {
+			case '*':
+				expr.WriteString(".*")
+			case '?':
+				expr.WriteString(".")
+			default:
+				expr.WriteString(regexp.QuoteMeta(string(r)))
+			}
+		}
+		expr.WriteString("$")
+
+		glob, err := regexp.Compile(expr.String())
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+
+		globs = append(globs, glob)
+	}
+
+	return globs, nil
+}
+
+func matchesAnyGlob(globs []*regexp.Regexp, values ...string) bool {
+	for _, glob := range globs {
+		for _, value := range values {
+			if glob.MatchString(value) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// apply keeps the suites of the report selected by the filter, with their selected test cases, leaving out the
+// suites without any of them. The suites of the report are kept with their raw elements, their devices and their
+// provenance, and the report before the filter is kept for the results of the run
+func (f *reportFilter) apply(report *junitReport) {
+	if f == nil {
+		return
+	}
+
+	unfiltered := *report
+	filtered := &junitReport{rawRoot: report.rawRoot, format: report.format, framework: report.framework, attributes: report.attributes, unfiltered: unfiltered.results()}
+	for i, suite := range report.suites {
+		kept, ok := f.filterSuite(suite, false)
+		if !ok {
+			continue
+		}
+
+		filtered.suites = append(filtered.suites, kept)
+		filtered.rawSuites = append(filtered.rawSuites, report.rawSuite(i))
+		filtered.devices = append(filtered.devices, report.device(i))
+		filtered.sources = append(filtered.sources, report.source(i))
+	}
+
+	*report = *filtered
+}
+
+// filterSuite returns the suite with its selected test cases and nested suites, and if it's kept. The nested suites
+// of an included suite are included too, and an excluded suite is left out with its nested suites
+func (f *reportFilter) filterSuite(suite junit.Suite, included bool) (junit.Suite, bool) {
+	if matchesAnyGlob(f.excludeSuites, suite.Name) {
+		return suite, false
+	}
+
+	included = included || len(f.includeSuites) == 0 || matchesAnyGlob(f.includeSuites, suite.Name)
+
+	tests := []junit.Test{}
+	if included {
+		for _, test := range suite.Tests {
+			if len(f.includeTests) == 0 || matchesAnyGlob(f.includeTests, test.Name, test.Classname+"#"+test.Name) {
+				tests = append(tests, test)
+			}
+		}
+
+		if f.onlyFailures {
+			tests = failuresWithContext(tests, f.context)
+		}
+	}
+
+	nested := []junit.Suite{}
+	for _, child := range suite.Suites {
+		if kept, ok := f.filterSuite(child, included); ok {
+			nested = append(nested, kept)
+		}
+	}
+
+	if len(tests) == 0 && len(nested) == 0 {
+		return suite, false
+	}
+
+	// the totals are the ones of the kept test cases, unless all of them are kept
+	if len(tests) != len(suite.Tests) || len(nested) != len(suite.Suites) {
+		suite.Tests = tests
+		suite.Suites = nested
+		suite.Aggregate()
+	}
+
+	return suite, true
+}
+
+// failuresWithContext returns the failing test cases, with up to the given number of test cases before and after
+// each of them, in their order
+func failuresWithContext(tests []junit.Test, context int) []junit.Test {
+	kept := make([]bool, len(tests))
+	for i, test := range tests {
+		if test.Status != junit.StatusFailed && test.Status != junit.StatusError {
+			continue
+		}
+
+		for j := max(0, i-context); j <= min(len(tests)-1, i+context); j++ {
+			kept[j] = true
+		}
+	}
+
+	failures := []junit.Test{}
+	for i, test := range tests {
+		if kept[i] {
+			failures = append(failures, test)
+		}
+	}
+
+	return failures
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestReportFilter(t *testing.T) {
+	data := []byte(`<testsuites>
+		<testsuite name="unit/cart">
+			<testcase name="adds" classname="com.acme.Cart" time="0.1"/>
+		</testsuite>
+		<testsuite name="e2e/checkout">
+			<testcase name="pays" classname="com.acme.Checkout" time="0.1"/>
+			<testcase name="ships" classname="com.acme.Shipping" time="0.1"><failure message="late"/></testcase>
+			<testsuite name="e2e/checkout/flaky">
+				<testcase name="retries" classname="com.acme.Checkout" time="0.1"/>
+			</testsuite>
+		</testsuite>
+		<testsuite name="e2e/search">
+			<testcase name="finds" classname="com.acme.Search" time="0.1"/>
+		</testsuite>
+	</testsuites>`)
+
+	names := func(report *junitReport) []string {
+		names := []string{}
+		for _, suite := range report.suites {
+			names = append(names, suite.Name)
+		}
+		return names
+	}
+
+	t.Run("no filters", func(t *testing.T) {
+		filter, err := newReportFilter("", " ", "", false, 0)
+		require.NoError(t, err)
+		require.Nil(t, filter)
+
+		report, err := parseReport(reportFormatAuto, "", data)
+		require.NoError(t, err)
+
+		filter.apply(report)
+		require.Equal(t, []string{"unit/cart", "e2e/checkout", "e2e/search"}, names(report))
+	})
+
+	t.Run("included and excluded suites", func(t *testing.T) {
+		filter, err := newReportFilter("e2e/*", "*/flaky, e2e/sea?ch", "", false, 0)
+		require.NoError(t, err)
+
+		report, err := parseReport(reportFormatAuto, "", data)
+		require.NoError(t, err)
+		require.Len(t, report.rawSuites, 3)
+
+		filter.apply(report)
+		require.Equal(t, []string{"e2e/checkout"}, names(report))
+		require.Len(t, report.rawSuites, 1)
+		name, _ := report.rawSuite(0).Attr("name")
+		require.Equal(t, "e2e/checkout", name)
+
+		checkout := report.suites[0]
+		require.Len(t, checkout.Tests, 2)
+		require.Empty(t, checkout.Suites)
+	})
+
+	t.Run("included tests", func(t *testing.T) {
+		filter, err := newReportFilter("", "", "com.acme.Checkout#*,finds", false, 0)
+		require.NoError(t, err)
+
+		report, err := parseReport(reportFormatAuto, "", data)
+		require.NoError(t, err)
+
+		filter.apply(report)
+		require.Equal(t, []string{"e2e/checkout", "e2e/search"}, names(report))
+
+		// the totals are the ones of the exported test cases, with the ones of the nested suites
+		checkout := report.suites[0]
+		require.Len(t, checkout.Tests, 1)
+		require.Equal(t, "pays", checkout.Tests[0].Name)
+		require.Equal(t, 2, checkout.Totals.Tests)
+		require.Equal(t, 0, checkout.Totals.Failed)
+		require.Len(t, checkout.Suites, 1)
+		require.Equal(t, "retries", checkout.Suites[0].Tests[0].Name)
+	})
+
+	t.Run("nested suites", func(t *testing.T) {
+		filter, err := newReportFilter("*/flaky", "", "", false, 0)
+		require.NoError(t, err)
+
+		report, err := parseReport(reportFormatAuto, "", data)
+		require.NoError(t, err)
+
+		// the parent suite is kept for its included nested suite, without its own test cases
+		filter.apply(report)
+		require.Equal(t, []string{"e2e/checkout"}, names(report))
+		require.Empty(t, report.suites[0].Tests)
+		require.Equal(t, "e2e/checkout/flaky", report.suites[0].Suites[0].Name)
+	})
+	t.Run("only failures", func(t *testing.T) {
+		filter, err := newReportFilter("", "", "", true, 0)
+		require.NoError(t, err)
+
+		report, err := parseReport(reportFormatAuto, "", data)
+		require.NoError(t, err)
+
+		filter.apply(report)
+		require.Equal(t, []string{"e2e/checkout"}, names(report))
+		require.Len(t, report.suites[0].Tests, 1)
+		require.Equal(t, "ships", report.suites[0].Tests[0].Name)
+		require.Empty(t, report.suites[0].Suites)
+	})
+
+	t.Run("invalid context", func(t *testing.T) {
+		_, err := newReportFilter("", "", "", false, 1)
+		require.ErrorContains(t, err, "requires --only-failures")
+
+		_, err = newReportFilter("", "", "", true, -1)
+		require.ErrorContains(t, err, "can't be negative")
+	})
+}
+
+func TestFailuresWithContext(t *testing.T) {
+	tests := []junit.Test{}
+	for i, status := range []junit.Status{junit.StatusPassed, junit.StatusPassed, junit.StatusPassed, junit.StatusFailed, junit.StatusPassed, junit.StatusSkipped, junit.StatusPassed, junit.StatusError} {
+		tests = append(tests, junit.Test{Name: fmt.Sprint(i), Status: status})
+	}
+
+	names := func(tests []junit.Test) []string {
+		names := []string{}
+		for _, test := range tests {
+			names = append(names, test.Name)
+		}
+		return names
+	}
+
+	require.Equal(t, []string{"3", "7"}, names(failuresWithContext(tests, 0)))
+	require.Equal(t, []string{"2", "3", "4", "6", "7"}, names(failuresWithContext(tests, 1)))
+	require.Equal(t, []string{"1", "2", "3", "4", "5", "6", "7"}, names(failuresWithContext(tests, 2)))
+	require.Empty(t, failuresWithContext(tests[:3], 5))
+}
+
+// TestReportFilter_Results checks the filters only select the spans to create: the summary of the run, its state,
+// the assertions and the failures are the ones of all the test cases
+func TestReportFilter_Results(t *testing.T) {
+	defer func(input string, onlyFailures bool, otlp bool, snapshot string, assert string, state string) {
+		inputFlag, onlyFailuresFlag, otlpFlag, snapshotFlag, assertFlag, stateFlag = input, onlyFailures, otlp, snapshot, assert, state
+	}(inputFlag, onlyFailuresFlag, otlpFlag, snapshotFlag, assertFlag, stateFlag)
+	defer func(attrs []attribute.KeyValue) { runtimeAttributes = attrs }(runtimeAttributes)
+
+	dir := t.TempDir()
+	inputFlag = filepath.Join(dir, "TEST-checkout.xml")
+	require.NoError(t, os.WriteFile(inputFlag, []byte(`<testsuite name="checkout" tests="5" failures="1">
+		<testcase name="adds" classname="com.acme.Cart" time="0.1"/>
+		<testcase name="removes" classname="com.acme.Cart" time="0.1"/>
+		<testcase name="pays" classname="com.acme.Checkout" time="0.1"/>
+		<testcase name="ships" classname="com.acme.Shipping" time="0.1"><failure message="late"/></testcase>
+		<testcase name="tracks" classname="com.acme.Shipping" time="0.1"/>
+	</testsuite>`), 0o644))
+
+	onlyFailuresFlag, otlpFlag = true, false
+	snapshotFlag = filepath.Join(dir, "snapshot.json")
+	stateFlag = filepath.Join(dir, "state.json")
+	assertFlag = "total == 5 && failed == 1"
+
+	require.NoError(t, Main(context.Background(), &PipeReader{}))
+
+	data, err := os.ReadFile(snapshotFlag)
+	require.NoError(t, err)
+	document := map[string]any{}
+	require.NoError(t, json.Unmarshal(data, &document))
+
+	spans := []map[string]any{}
+	for _, resource := range jsonObjects(document["resourceSpans"]) {
+		for _, scope := range jsonObjects(resource["scopeSpans"]) {
+			spans = append(spans, jsonObjects(scope["spans"])...)
+		}
+	}
+
+	// the root span, the suite and the failing test case
+	require.Len(t, spans, 3)
+	root := spans[len(spans)-1]
+	attributes := map[string]any{}
+	for _, attr := range jsonObjects(root["attributes"]) {
+		attributes[attr["key"].(string)] = attr["value"]
+	}
+	require.Equal(t, map[string]any{"intValue": "5"}, attributes[TestsRunTotal])
+	require.Equal(t, map[string]any{"boolValue": false}, attributes[ReportInconsistent])
+
+	state, err := readState(context.Background(), stateFlag)
+	require.NoError(t, err)
+	require.Len(t, state.Runs, 1)
+	require.Len(t, state.Runs[0].Tests, 5)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	reportFormatAuto        = "auto"
+	reportFormatJUnit       = "junit"
+	reportFormatLive        = "live"
+	reportFormatMochawesome = "mochawesome"
+	reportFormatPlaywright  = "playwright"
+)
+
+// reportParser parses a report in a given format, read from the given path, which is empty for the standard input
+type reportParser func(path string, data []byte) (*junitReport, error)
+
+// reportParsers the parsers of the supported report formats, converting them to the suites and test cases of jUnit
+var reportParsers = map[string]reportParser{
+	reportFormatBazel:       parseBazelEvents,
+	reportFormatJUnit:       ingestReportFrom,
+	reportFormatLibtest:     parseLibtestEvents,
+	reportFormatMochawesome: parseMochawesomeReport,
+	reportFormatNewman:      parseNewmanReport,
+	reportFormatOpenTest:    parseOpenTestReport,
+	reportFormatPlaywright:  parsePlaywrightReport,
+	reportFormatTrx:         parseTrxReport,
+}
+
+// supportedReportFormats returns the names of the supported report formats, sorted
+func supportedReportFormats() []string {
+	formats := []string{reportFormatAuto}
+	for format := range reportParsers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats[1:])
+
+	return formats
+}
+
+// detectReportFormat detects the format of the report from its content: XML reports are detected from their root
+// element, jUnit ones by default, and JSON reports are detected from their top-level keys
+func detectReportFormat(data []byte) (string, error) {
+	trimmed := bytes.TrimSpace(data)
+	if !bytes.HasPrefix(trimmed, []byte("{")) {
+		switch xmlRootName(trimmed) {
+		case trxRootElement:
+			return reportFormatTrx, nil
+		case openTestEventsElement, openTestExecutionElement:
+			return reportFormatOpenTest, nil
+		default:
+			return reportFormatJUnit, nil
+		}
+	}
+
+	// the events of the Build Event Protocol and of libtest are streams of JSON objects
+	if isBazelEventStream(data) {
+		return reportFormatBazel, nil
+	}
+	if isLibtestStream(data) {
+		return reportFormatLibtest, nil
+	}
+
+	keys := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return "", jsonParseError("JSON", data, err)
+	}
+
+	_, hasConfig := keys["config"]
+	_, hasSuites := keys["suites"]
+	_, hasStats := keys["stats"]
+	_, hasResults := keys["results"]
+
+	switch {
+	case hasConfig && hasSuites:
+		return reportFormatPlaywright, nil
+	case hasStats && hasResults:
+		return reportFormatMochawesome, nil
+	case isNewmanReport(keys):
+		return reportFormatNewman, nil
+	default:
+		return "", fmt.Errorf("unknown format of the JSON report")
+	}
+}
+
+// xmlRootName returns the local name of the root element of the XML report, or an empty name if it has none
+func xmlRootName(data []byte) string {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local
+		}
+	}
+}
+
+// parseReport parses the report with the parser of the given format, detecting it if it's auto
+func parseReport(format string, path string, data []byte) (*junitReport, error) {
+	normalizer, err := newNameNormalizer(nameLimitFlag, longNamesFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	// the provenance hashes the file as it was read, with its byte order mark
+	raw := data
+	data = decodeBOM(data)
+
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" || format == reportFormatAuto {
+		detected, err := detectReportFormat(data)
+		if err != nil {
+			return nil, err
+		}
+
+		format = detected
+	}
+
+	parser, ok := reportParsers[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown report format %q: valid values are %s", format, strings.Join(supportedReportFormats(), ", "))
+	}
+
+	if format == reportFormatJUnit {
+		if err := checkStrictness(data); err != nil {
+			return nil, err
+		}
+	}
+
+	report, err := parser(path, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == reportFormatJUnit {
+		addBazelTargetLabel(report, path)
+		addNextestBinaries(report)
+		addRestAssuredRequests(report)
+	}
+
+	// the secrets are masked before the mappings, which can copy the output to the properties
+	scrubReport(report, appConfig.Scrubbing.secretPatterns(scrubSecretsFlag))
+	appConfig.Mappings.apply(format, report)
+	normalizer.normalizeReport(report)
+
+	report.format = format
+	source := newReportSource(path, format, raw)
+	report.sources = make([]*reportSource, len(report.suites))
+	for i := range report.sources {
+		report.sources[i] = source
+	}
+
+	return report, nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectReportFormat(t *testing.T) {
+	for file, expected := range map[string]string{
+		"TEST-sample.xml":                  reportFormatJUnit,
+		"testdata/playwright-report.json":  reportFormatPlaywright,
+		"testdata/mochawesome-report.json": reportFormatMochawesome,
+		"testdata/sample.trx":              reportFormatTrx,
+	} {
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+
+		format, err := detectReportFormat(data)
+		require.NoError(t, err)
+		require.Equal(t, expected, format, file)
+	}
+
+	_, err := detectReportFormat([]byte(`{"foo": "bar"}`))
+	require.Error(t, err)
+
+	_, err = parseReport("nunit2", "", []byte(`<test-results/>`))
+	require.ErrorContains(t, err, "unknown report format")
+}
+
+func TestParsePlaywrightReport(t *testing.T) {
+	data, err := os.ReadFile("testdata/playwright-report.json")
+	require.NoError(t, err)
+
+	report, err := parseReport(reportFormatAuto, "", data)
+	require.NoError(t, err)
+	require.Equal(t, reportFormatPlaywright, report.format)
+	require.Equal(t, &testFramework{Name: reportFormatPlaywright, Version: "1.40.1"}, detectFramework(report))
+
+	require.Len(t, report.suites, 1)
+	suite := report.suites[0]
+	require.Equal(t, "example.spec.ts", suite.Name)
+	require.Equal(t, junit.Totals{Tests: 3, Passed: 2, Failed: 1, Duration: 3500 * time.Millisecond}, suite.Totals)
+
+	chromium := suite.Tests[0]
+	require.Equal(t, "has title", chromium.Name)
+	require.Equal(t, "loading\n", chromium.SystemOut)
+	require.Equal(t, "chromium", chromium.Properties[TestProject])
+	require.Equal(t, "0", chromium.Properties[TestRetries])
+
+	flaky := suite.Tests[1]
+	require.Equal(t, junit.StatusPassed, flaky.Status)
+	require.Equal(t, "1", flaky.Properties[TestRetries])
+	require.Equal(t, "true", flaky.Properties[TestFlaky])
+	require.Equal(t, "test-results/example-has-title-firefox-retry1/trace.zip", flaky.Properties[TestArtifactPrefix+"trace"])
+
+	failed := suite.Tests[2]
+	require.Equal(t, "navigation › get started link", failed.Name)
+	require.Equal(t, junit.StatusFailed, failed.Status)
+	require.Equal(t, "expected visible", failed.Message)
+	require.Equal(t, "test-results/example-get-started-chromium/video.webm", failed.Properties[TestArtifactPrefix+"video"])
+	require.Equal(t, "10", failed.Properties["line"])
+}
+
+func TestParseMochawesomeReport(t *testing.T) {
+	data, err := os.ReadFile("testdata/mochawesome-report.json")
+	require.NoError(t, err)
+
+	report, err := parseReport(reportFormatAuto, "", data)
+	require.NoError(t, err)
+	require.Equal(t, reportFormatMochawesome, report.format)
+
+	require.Len(t, report.suites, 1)
+	suite := report.suites[0]
+	require.Equal(t, "cypress/e2e/login.cy.js", suite.Name)
+	require.Equal(t, junit.Totals{Tests: 3, Passed: 1, Failed: 1, Skipped: 1, Duration: 4420 * time.Millisecond}, suite.Totals)
+
+	failed := suite.Tests[1]
+	require.Equal(t, "login logs in", failed.Name)
+	require.Equal(t, "AssertionError: expected 401 to equal 200", failed.Message)
+	require.Equal(t, "cypress/screenshots/login.cy.js/login -- logs in (failed).png", failed.Properties[TestArtifactPrefix+"screenshot"])
+	require.Equal(t, "cypress/videos/login.cy.js.mp4", failed.Properties[TestArtifactPrefix+"video"])
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	frameworkGotestsum = "gotestsum"
+	frameworkJest      = "jest"
+	frameworkPHPUnit   = "phpunit"
+	frameworkPytest    = "pytest"
+	frameworkSurefire  = "surefire"
+)
+
+// testFramework the test framework producing the report, and its version if the report includes it
+type testFramework struct {
+	Name    string
+	Version string
+}
+
+// detectFramework infers the test framework producing the report from its structure and properties,
+// returning nil if it cannot be inferred
+func detectFramework(report *junitReport) *testFramework {
+	// the parsers of the other formats know the framework producing the report
+	if report.framework != nil {
+		return report.framework
+	}
+
+	if isNextestReport(report) {
+		return &testFramework{Name: frameworkNextest}
+	}
+
+	if report.rawRoot != nil {
+		for _, root := range report.rawRoot.Children {
+			// jest-junit names the root element after the tool, i.e. "jest tests"
+			if name, _ := root.Attr("name"); root.Name == "testsuites" && strings.Contains(strings.ToLower(name), frameworkJest) {
+				return &testFramework{Name: frameworkJest}
+			}
+		}
+	}
+
+	for _, suite := range report.rawSuites {
+		properties := suite.Properties()
+
+		if schema, _ := suite.Attr("noNamespaceSchemaLocation"); strings.Contains(schema, frameworkSurefire) || hasPropertyPrefix(properties, "surefire.") {
+			version, _ := suite.Attr("version")
+			return &testFramework{Name: frameworkSurefire, Version: version}
+		}
+
+		if name, _ := suite.Attr("name"); name == frameworkPytest {
+			return &testFramework{Name: frameworkPytest}
+		}
+
+		// gotestsum and go-junit-report add the version of Go as a property
+		if goVersion, ok := properties["go.version"]; ok {
+			return &testFramework{Name: frameworkGotestsum, Version: goVersion}
+		}
+
+		if isPHPUnitSuite(suite) {
+			return &testFramework{Name: frameworkPHPUnit}
+		}
+	}
+
+	return nil
+}
+
+// attributes returns the attributes of the framework, if any
+func (f *testFramework) attributes() []attribute.KeyValue {
+	if f == nil {
+		return nil
+	}
+
+	attributes := []attribute.KeyValue{attribute.Key(TestFramework).String(f.Name)}
+	if f.Version != "" {
+		attributes = append(attributes, attribute.Key(TestFrameworkVersion).String(f.Version))
+	}
+
+	return attributes
+}
+
+func hasPropertyPrefix(properties map[string]string, prefix string) bool {
+	for name := range properties {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isPHPUnitSuite checks if any test case in the suite, or in its nested suites, has the file and the number
+// of assertions, as PHPUnit adds them to the test cases
+func isPHPUnitSuite(suite *xmlElement) bool {
+	for _, child := range suite.Children {
+		if child.Name == "testcase" {
+			_, hasFile := child.Attr("file")
+			_, hasAssertions := child.Attr("assertions")
+			if hasFile && hasAssertions {
+				return true
+			}
+		}
+
+		if child.Name == "testsuite" && isPHPUnitSuite(child) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectFramework(t *testing.T) {
+	tests := []struct {
+		name     string
+		xml      string
+		expected *testFramework
+	}{
+		{
+			name: "surefire",
+			xml: `<testsuite xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:noNamespaceSchemaLocation="https://maven.apache.org/surefire/maven-surefire-plugin/xsd/surefire-test-report.xsd" version="3.0.2" name="com.example.AppTest" tests="1">
+				<testcase name="works" classname="com.example.AppTest" time="0.01"/>
+			</testsuite>`,
+			expected: &testFramework{Name: frameworkSurefire, Version: "3.0.2"},
+		},
+		{
+			name: "pytest",
+			xml: `<testsuites><testsuite name="pytest" tests="1" hostname="runner">
+				<testcase classname="tests.test_app" name="test_works" time="0.01"/>
+			</testsuite></testsuites>`,
+			expected: &testFramework{Name: frameworkPytest},
+		},
+		{
+			name: "jest",
+			xml: `<testsuites name="jest tests" tests="1"><testsuite name="app" tests="1">
+				<testcase classname="app works" name="app works" time="0.01"/>
+			</testsuite></testsuites>`,
+			expected: &testFramework{Name: frameworkJest},
+		},
+		{
+			name: "phpunit",
+			xml: `<testsuites><testsuite name="Unit" tests="1"><testsuite name="AppTest" file="tests/AppTest.php" tests="1">
+				<testcase name="testWorks" class="AppTest" file="tests/AppTest.php" line="10" assertions="1" time="0.01"/>
+			</testsuite></testsuite></testsuites>`,
+			expected: &testFramework{Name: frameworkPHPUnit},
+		},
+		{
+			name: "unknown",
+			xml: `<testsuites><testsuite name="suite" tests="1">
+				<testcase classname="suite" name="works" time="0.01"/>
+			</testsuite></testsuites>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := ingestReport([]byte(tt.xml))
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, detectFramework(report))
+		})
+	}
+
+	t.Run("gotestsum", func(t *testing.T) {
+		data, err := os.ReadFile("TEST-sample.xml")
+		require.NoError(t, err)
+
+		report, err := ingestReport(data)
+		require.NoError(t, err)
+		require.Equal(t, &testFramework{Name: frameworkGotestsum, Version: "go1.16.3 linux/amd64"}, detectFramework(report))
+	})
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// previousSuiteEnd the end of the latest suite of the previous invocations of the session, read from the context
+// file, or zero if it's the first one
+var previousSuiteEnd time.Time
+
+// suiteGaps returns the idle time before each suite of the report: the time between the end of the suites before it,
+// including the ones of the previous invocations of the session, and its start, and the idle time of the whole run.
+// The suites are taken in the order they started, and the overlapping ones have no idle time, so the idle time is
+// the waste of the orchestration of the pipeline rather than the slowness of the tests
+func suiteGaps(report *junitReport, startTimes []time.Time, previousEnd time.Time) ([]time.Duration, time.Duration) {
+	order := make([]int, len(report.suites))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return startTimes[a].Compare(startTimes[b])
+	})
+
+	gaps := make([]time.Duration, len(report.suites))
+	total := time.Duration(0)
+	end := previousEnd
+	for _, i := range order {
+		if !end.IsZero() && startTimes[i].After(end) {
+			gaps[i] = startTimes[i].Sub(end)
+			total += gaps[i]
+		}
+
+		if suiteEnd := startTimes[i].Add(suiteSpanDuration(report, i)); suiteEnd.After(end) {
+			end = suiteEnd
+		}
+	}
+
+	return gaps, total
+}
+
+// suiteGapAttributes returns the attribute of the idle time before the suite, if there is any
+func suiteGapAttributes(gap time.Duration) []attribute.KeyValue {
+	if gap <= 0 {
+		return nil
+	}
+
+	return []attribute.KeyValue{attribute.Key(TestsSuiteIdleBefore).Int64(gap.Milliseconds())}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestSuiteGaps(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	report := &junitReport{
+		suites: []junit.Suite{
+			{Name: "unit", Totals: junit.Totals{Duration: time.Minute}},
+			{Name: "e2e", Totals: junit.Totals{Duration: time.Minute}},
+			{Name: "integration", Totals: junit.Totals{Duration: 2 * time.Minute}},
+			{Name: "lint", Totals: junit.Totals{Duration: time.Minute}},
+		},
+	}
+	startTimes := []time.Time{
+		start,
+		start.Add(5 * time.Minute),
+		start.Add(2 * time.Minute),
+		start.Add(3 * time.Minute), // overlapping the integration suite
+	}
+
+	t.Run("The first invocation of the session", func(t *testing.T) {
+		gaps, idle := suiteGaps(report, startTimes, time.Time{})
+		require.Equal(t, []time.Duration{0, time.Minute, time.Minute, 0}, gaps)
+		require.Equal(t, 2*time.Minute, idle)
+	})
+
+	t.Run("After a previous invocation of the session", func(t *testing.T) {
+		gaps, idle := suiteGaps(report, startTimes, start.Add(-30*time.Second))
+		require.Equal(t, []time.Duration{30 * time.Second, time.Minute, time.Minute, 0}, gaps)
+		require.Equal(t, 150*time.Second, idle)
+	})
+
+	t.Run("Overlapping a previous invocation of the session", func(t *testing.T) {
+		gaps, _ := suiteGaps(report, startTimes, start.Add(90*time.Second))
+		require.Equal(t, []time.Duration{0, time.Minute, 30 * time.Second, 0}, gaps)
+	})
+}
+
+func TestSuiteGapAttributes(t *testing.T) {
+	require.Nil(t, suiteGapAttributes(0))
+	require.Equal(t, []attribute.KeyValue{attribute.Key(TestsSuiteIdleBefore).Int64(1500)}, suiteGapAttributes(1500*time.Millisecond))
+}
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/url"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	git "github.com/go-git/go-git/v5"
@@ -16,9 +20,13 @@ import (
 type GitScm struct {
 	baseRef        string
 	branchName     string
+	changeNumber   string
 	headSha        string
+	patchset       string
 	changeRequest  bool // if the tool is evaluating a change request or a branch
+	fileStats      bool // if the per-file stats of the changeset must be contributed
 	provider       string
+	pullRequestURL string
 	repository     *git.Repository
 	repositoryPath string
 }
@@ -26,6 +34,7 @@ type GitScm struct {
 // NewGitScm retrieves a Git SCM repository, using the repository filesystem path to read it
 func NewGitScm(repositoryPath string) *GitScm {
 	scm := &GitScm{
+		fileStats:      scmFileStatsFlag,
 		repositoryPath: repositoryPath,
 	}
 
@@ -46,6 +55,9 @@ func NewGitScm(repositoryPath string) *GitScm {
 	scm.baseRef = gitCtx.GetTargetBranch()
 	scm.changeRequest = gitCtx.ChangeRequest
 	scm.provider = gitCtx.Provider
+	scm.changeNumber = gitCtx.ChangeNumber
+	scm.patchset = gitCtx.Patchset
+	scm.pullRequestURL = gitCtx.PullRequestURL
 
 	return scm
 }
@@ -92,6 +104,11 @@ func (scm *GitScm) calculateCommits() (*object.Commit, *object.Commit, error) {
 // contributeAttributes this method never fails, returning the current state of the contributed attributes
 // at the moment of the failure
 func (scm *GitScm) contributeAttributes() []attribute.KeyValue {
+	// there is no git context, i.e. a local run without the BRANCH variable
+	if scm == nil {
+		return nil
+	}
+
 	// from now on, this is a Git repository
 	gitAttributes := []attribute.KeyValue{
 		attribute.Key(ScmType).String("git"),
@@ -101,6 +118,18 @@ func (scm *GitScm) contributeAttributes() []attribute.KeyValue {
 		gitAttributes = append(gitAttributes, attribute.Key(ScmProvider).String(scm.provider))
 	}
 
+	if scm.changeNumber != "" {
+		gitAttributes = append(gitAttributes, attribute.Key(ScmChangeNumber).String(scm.changeNumber))
+	}
+
+	if scm.patchset != "" {
+		gitAttributes = append(gitAttributes, attribute.Key(ScmChangePatchset).String(scm.patchset))
+	}
+
+	if scm.changeRequest && scm.pullRequestURL != "" {
+		gitAttributes = append(gitAttributes, attribute.Key(ScmPullRequestURL).String(scm.pullRequestURL))
+	}
+
 	shallow, err := scm.repository.Storer.Shallow()
 	if err != nil {
 		return gitAttributes
@@ -139,6 +168,7 @@ func (scm *GitScm) contributeAttributes() []attribute.KeyValue {
 
 	contributions := []func(*object.Commit, *object.Commit) ([]attribute.KeyValue, error){
 		scm.contributeCommitters,
+		scm.contributeCommitTypes,
 	}
 
 	if scm.changeRequest {
@@ -170,23 +200,9 @@ func (scm *GitScm) contributeAttributes() []attribute.KeyValue {
 func (scm *GitScm) contributeCommitters(headCommit *object.Commit, targetCommit *object.Commit) (attributes []attribute.KeyValue, outError error) {
 	attributes = []attribute.KeyValue{}
 
-	commits, err := headCommit.MergeBase(targetCommit)
+	commitsIterator, err := scm.changesetCommits(headCommit, targetCommit)
 	if err != nil {
-		outError = errors.Wrapf(err, "not able to find a common ancestor between HEAD and TARGET_BRANCH: %v", err)
-		return
-	}
-
-	if len(commits) == 0 {
-		outError = errors.Wrapf(err, "not able to find a common ancestor between HEAD and TARGET_BRANCH: %v", err)
-		return
-	}
-
-	ancestor := commits[0]
-
-	when := ancestor.Author.When.Add(time.Millisecond * 1) // adding one millisecond to avoid including the ancestor in the log
-	commitsIterator, err := scm.repository.Log(&git.LogOptions{From: headCommit.Hash, Since: &when})
-	if err != nil {
-		outError = errors.Wrapf(err, "not able to retrieve commits between HEAD and TARGET_BRANCH: %v", err)
+		outError = err
 		return
 	}
 
@@ -210,9 +226,121 @@ func (scm *GitScm) contributeCommitters(headCommit *object.Commit, targetCommit
 	return
 }
 
-// contributeFilesAndLines this algorithm will look for the first ancestor between HEAD and the TARGET_BRANCH, and will iterate through
-// the list of commits, storing the modified files for each commit; for each modified file it will get the added and deleted lines.
+// contributeCommitTypes this algorithm will look for the first ancestor between HEAD and the TARGET_BRANCH, and will iterate through
+// the list of commits, classifying each commit as a merge, squash or revert commit, and by its conventional-commit type.
+// It will contribute an Integer attribute for each class, including the total number of commits in the changeset.
+// This method will return the current state of the contributed attributes at the moment of an eventual failure.
+func (scm *GitScm) contributeCommitTypes(headCommit *object.Commit, targetCommit *object.Commit) (attributes []attribute.KeyValue, outError error) {
+	attributes = []attribute.KeyValue{}
+
+	commitsIterator, err := scm.changesetCommits(headCommit, targetCommit)
+	if err != nil {
+		outError = err
+		return
+	}
+
+	total := 0
+	counts := map[string]int{}
+
+	commitsIterator.ForEach(func(c *object.Commit) error {
+		total++
+		for _, class := range classifyCommit(c.Message, c.NumParents()) {
+			counts[class]++
+		}
+		return nil
+	})
+
+	if total == 0 {
+		return
+	}
+
+	attributes = append(attributes, attribute.Key(GitCommits).Int(total))
+	for _, class := range []string{GitCommitsMerge, GitCommitsSquash, GitCommitsRevert, GitCommitsBreaking} {
+		attributes = append(attributes, attribute.Key(class).Int(counts[class]))
+	}
+
+	for _, commitType := range conventionalCommitTypes {
+		key := GitCommitsTypePrefix + commitType
+		if counts[key] > 0 {
+			attributes = append(attributes, attribute.Key(key).Int(counts[key]))
+		}
+	}
+
+	return
+}
+
+// conventionalCommitTypes the types defined by the conventional commits specification, plus the ones
+// from the Angular convention. Any other type is not classified, to keep the number of attributes bounded.
+var conventionalCommitTypes = []string{"build", "chore", "ci", "docs", "feat", "fix", "perf", "refactor", "revert", "style", "test"}
+
+var conventionalCommitRegex = regexp.MustCompile(`^(\w+)(\([^)]*\))?(!)?: `)
+
+// squashedCommitRegex matches the subject of the squashed commits created by Github, i.e. "Fix something (#123)"
+var squashedCommitRegex = regexp.MustCompile(`\(#\d+\)$`)
+
+// classifyCommit returns the keys of the classes a commit belongs to, based on its message and its number of parents
+func classifyCommit(message string, parents int) []string {
+	classes := []string{}
+
+	subject, body, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	subject = strings.TrimSpace(subject)
+
+	if parents > 1 {
+		classes = append(classes, GitCommitsMerge)
+	} else if squashedCommitRegex.MatchString(subject) || strings.Contains(body, "Squashed commit of the following") {
+		classes = append(classes, GitCommitsSquash)
+	}
+
+	if strings.HasPrefix(subject, "Revert ") || strings.Contains(body, "This reverts commit") {
+		classes = append(classes, GitCommitsRevert)
+	}
+
+	matches := conventionalCommitRegex.FindStringSubmatch(subject)
+	if matches != nil {
+		commitType := strings.ToLower(matches[1])
+		if slices.Contains(conventionalCommitTypes, commitType) {
+			classes = append(classes, GitCommitsTypePrefix+commitType)
+		}
+
+		if matches[3] == "!" {
+			classes = append(classes, GitCommitsBreaking)
+		}
+	}
+
+	if !slices.Contains(classes, GitCommitsBreaking) && strings.Contains(body, "BREAKING CHANGE") {
+		classes = append(classes, GitCommitsBreaking)
+	}
+
+	return classes
+}
+
+// changesetCommits returns an iterator for the commits between the first ancestor between HEAD and the TARGET_BRANCH,
+// excluding the ancestor, and HEAD
+func (scm *GitScm) changesetCommits(headCommit *object.Commit, targetCommit *object.Commit) (object.CommitIter, error) {
+	commits, err := headCommit.MergeBase(targetCommit)
+	if err != nil {
+		return nil, errors.Wrapf(err, "not able to find a common ancestor between HEAD and TARGET_BRANCH: %v", err)
+	}
+
+	if len(commits) == 0 {
+		return nil, errors.Wrapf(err, "not able to find a common ancestor between HEAD and TARGET_BRANCH: %v", err)
+	}
+
+	ancestor := commits[0]
+
+	when := ancestor.Author.When.Add(time.Millisecond * 1) // adding one millisecond to avoid including the ancestor in the log
+	commitsIterator, err := scm.repository.Log(&git.LogOptions{From: headCommit.Hash, Since: &when})
+	if err != nil {
+		return nil, errors.Wrapf(err, "not able to retrieve commits between HEAD and TARGET_BRANCH: %v", err)
+	}
+
+	return commitsIterator, nil
+}
+
+// contributeFilesAndLines this algorithm will look for the first ancestor between HEAD and the TARGET_BRANCH, and will compute the
+// changeset from that ancestor to HEAD, detecting renamed files so that a moved file is not counted as a full deletion plus a full addition.
 // It will contribute an Integer attribute including number of modified files, including added and deleted lines in the changeset.
+// If the file stats are enabled, it will also contribute the per-file stats of the changeset.
 // This method will return the current state of the contributed attributes at the moment of an eventual failure.
 func (scm *GitScm) contributeFilesAndLines(headCommit *object.Commit, targetCommit *object.Commit) (attributes []attribute.KeyValue, outError error) {
 	attributes = []attribute.KeyValue{}
@@ -223,19 +351,34 @@ func (scm *GitScm) contributeFilesAndLines(headCommit *object.Commit, targetComm
 		return
 	}
 
-	targetTree, err := targetCommit.Tree()
+	baseTree, err := changesetBase(headCommit, targetCommit).Tree()
 	if err != nil {
 		outError = errors.Wrapf(err, "not able to find a TARGET_BRANCH tree: %v", err)
 		return
 	}
 
-	patch, err := targetTree.Patch(headTree)
+	// the changeset goes from the base to HEAD: copies are not detected by go-git, so they count as additions
+	changes, err := object.DiffTreeWithOptions(context.Background(), baseTree, headTree, object.DefaultDiffTreeOptions)
 	if err != nil {
-		outError = errors.Wrapf(err, "not able to find the pathc between HEAD and TARGET_BRANCH trees: %v", err)
+		outError = errors.Wrapf(err, "not able to find the changes between HEAD and TARGET_BRANCH trees: %v", err)
 		return
 	}
 
+	patch, err := changes.Patch()
+	if err != nil {
+		outError = errors.Wrapf(err, "not able to find the patch between HEAD and TARGET_BRANCH trees: %v", err)
+		return
+	}
+
+	var renamedFiles int = 0
+	for _, change := range changes {
+		if change.From.Name != "" && change.To.Name != "" && change.From.Name != change.To.Name {
+			renamedFiles++
+		}
+	}
+
 	var changedFiles []string
+	var fileStats []string
 	var additions int = 0
 	var deletions int = 0
 	for _, fileStat := range patch.Stats() {
@@ -243,20 +386,76 @@ func (scm *GitScm) contributeFilesAndLines(headCommit *object.Commit, targetComm
 		deletions += fileStat.Deletion
 
 		changedFiles = append(changedFiles, fileStat.Name)
+		fileStats = append(fileStats, fmt.Sprintf("%s +%d -%d", fileStat.Name, fileStat.Addition, fileStat.Deletion))
 	}
 
 	attributes = append(attributes, attribute.Key(GitAdditions).Int(additions))
 	attributes = append(attributes, attribute.Key(GitDeletions).Int(deletions))
 	attributes = append(attributes, attribute.Key(GitModifiedFiles).Int(len(changedFiles)))
+	attributes = append(attributes, attribute.Key(GitRenamedFiles).Int(renamedFiles))
+
+	if scm.fileStats && len(fileStats) > 0 {
+		attributes = append(attributes, attribute.Key(GitFileStats).StringSlice(fileStats))
+	}
 
 	return
 }
 
+// changedFiles returns the paths of the files modified in the changeset, from the first common ancestor between
+// HEAD and the TARGET_BRANCH to HEAD, sorted. The renamed files are included with their old and new paths
+func (scm *GitScm) changedFiles() ([]string, error) {
+	headCommit, targetCommit, err := scm.calculateCommits()
+	if err != nil {
+		return nil, err
+	}
+
+	headTree, err := headCommit.Tree()
+	if err != nil {
+		return nil, errors.Wrapf(err, "not able to find a HEAD tree: %v", err)
+	}
+
+	baseTree, err := changesetBase(headCommit, targetCommit).Tree()
+	if err != nil {
+		return nil, errors.Wrapf(err, "not able to find a TARGET_BRANCH tree: %v", err)
+	}
+
+	changes, err := object.DiffTreeWithOptions(context.Background(), baseTree, headTree, object.DefaultDiffTreeOptions)
+	if err != nil {
+		return nil, errors.Wrapf(err, "not able to find the changes between HEAD and TARGET_BRANCH trees: %v", err)
+	}
+
+	files := []string{}
+	for _, change := range changes {
+		for _, name := range []string{change.From.Name, change.To.Name} {
+			if name != "" && !slices.Contains(files, name) {
+				files = append(files, name)
+			}
+		}
+	}
+	slices.Sort(files)
+
+	return files, nil
+}
+
+// changesetBase returns the first common ancestor between HEAD and the TARGET_BRANCH, falling back to the
+// TARGET_BRANCH commit if there is no common ancestor, so that commits landed in the target branch after
+// branching off are not included in the changeset.
+func changesetBase(headCommit *object.Commit, targetCommit *object.Commit) *object.Commit {
+	commits, err := headCommit.MergeBase(targetCommit)
+	if err != nil || len(commits) == 0 {
+		return targetCommit
+	}
+
+	return commits[0]
+}
+
+// mapToArray returns the keys of the map, sorted so the array is the same in every run
 func mapToArray(m map[string]bool) []string {
 	array := []string{}
 	for k := range m {
 		array = append(array, k)
 	}
+	slices.Sort(array)
 
 	return array
 }
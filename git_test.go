@@ -508,11 +508,20 @@ func TestGit_ContributeFilesAndLines(t *testing.T) {
 				t.Error()
 			}
 
-			require.Equal(t, 3, len(atts))
+			require.Equal(t, 4, len(atts))
 			// we are adding 1 file with 202 lines, and we are deleting 1 file with 1 line
 			require.Condition(t, func() bool { return keyExistsWithIntValue(t, atts, GitAdditions, 202) }, "Additions should be set as scm.git.additions. Attributes: %v", atts)
 			require.Condition(t, func() bool { return keyExistsWithIntValue(t, atts, GitDeletions, 1) }, "Deletions should be set as scm.git.deletions. Attributes: %v", atts)
 			require.Condition(t, func() bool { return keyExistsWithIntValue(t, atts, GitModifiedFiles, 2) }, "Modified files should be set as scm.git.modified.files. Attributes: %v", atts)
+			require.Condition(t, func() bool { return keyExistsWithIntValue(t, atts, GitRenamedFiles, 0) }, "Renamed files should be set as scm.git.files.renamed. Attributes: %v", atts)
+
+			scm.fileStats = true
+			atts, err = scm.contributeFilesAndLines(headCommit, targetCommit)
+			if err != nil {
+				t.Error()
+			}
+
+			require.Condition(t, func() bool { return keyExistsWithValue(t, atts, GitFileStats, "TEST-sample2.xml +202 -0") }, "File stats should be set as scm.git.files.stats. Attributes: %v", atts)
 		})
 	}
 
@@ -628,6 +637,37 @@ func TestGit_CalculateCommitsForBranches(t *testing.T) {
 	}
 }
 
+func TestClassifyCommit(t *testing.T) {
+	tests := []struct {
+		message  string
+		parents  int
+		expected []string
+	}{
+		{message: "Update README", parents: 1, expected: []string{}},
+		{message: "Merge branch 'main' into feature", parents: 2, expected: []string{GitCommitsMerge}},
+		{message: "fix: do not panic on empty reports (#42)", parents: 1, expected: []string{GitCommitsSquash, GitCommitsTypePrefix + "fix"}},
+		{message: "Revert \"feat: add flag\"\n\nThis reverts commit 0123456.", parents: 1, expected: []string{GitCommitsRevert}},
+		{message: "feat(cli)!: rename flags", parents: 1, expected: []string{GitCommitsTypePrefix + "feat", GitCommitsBreaking}},
+		{message: "chore: bump deps\n\nBREAKING CHANGE: requires Go 1.23", parents: 1, expected: []string{GitCommitsTypePrefix + "chore", GitCommitsBreaking}},
+		{message: "wip: not a conventional type", parents: 1, expected: []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.message, func(t *testing.T) {
+			require.Equal(t, test.expected, classifyCommit(test.message, test.parents))
+		})
+	}
+}
+
+func TestMapToArray(t *testing.T) {
+	m := map[string]bool{"zoe@example.com": true, "ann@example.com": true, "mia@example.com": true}
+
+	for i := 0; i < 10; i++ {
+		require.Equal(t, []string{"ann@example.com", "mia@example.com", "zoe@example.com"}, mapToArray(m))
+	}
+	require.Equal(t, []string{}, mapToArray(map[string]bool{}))
+}
+
 func TestGit_RedactedRepositoryURL(t *testing.T) {
 	t.Setenv("GITHUB_SHA", "")
 	tp, err := internal_test.NewTestProxy("https://github.com/octocat/hello-world.git")
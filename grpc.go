@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"maps"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mdelapenya/junit2otlp/ingest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ingestServer the gRPC service of the server mode, queueing the reports in the same queue as the HTTP endpoint
+type ingestServer struct {
+	ingest.UnimplementedIngestServiceServer
+	server *reportServer
+}
+
+func newGRPCServer(server *reportServer) *grpc.Server {
+	grpcServer := grpc.NewServer(grpc.MaxRecvMsgSize(maxReportSize + 1<<20))
+	ingest.RegisterIngestServiceServer(grpcServer, &ingestServer{server: server})
+
+	return grpcServer
+}
+
+// SubmitTestReport queues the report, assigning the ID of its trace before it's exported. The requests are
+// authenticated with the same tokens as the HTTP endpoint, in the authorization metadata
+func (s *ingestServer) SubmitTestReport(ctx context.Context, req *ingest.SubmitTestReportRequest) (*ingest.SubmitTestReportResponse, error) {
+	report := &queuedReport{}
+	code := codes.OK
+	defer func() {
+		outcome := outcomeAccepted
+		if code != codes.OK {
+			outcome = outcomeRejected
+		}
+		s.server.metrics.received.Add(ctx, 1, metric.WithAttributes(attribute.Key(ServerOutcome).String(outcome), attribute.Key(ServerTenant).String(report.metadata.Tenant)))
+	}()
+
+	tenant, err := appConfig.Tenancy.tenant(req.GetTenant())
+	if err != nil {
+		code = codes.InvalidArgument
+		return nil, status.Error(code, err.Error())
+	}
+	report.metadata.Tenant = tenant
+
+	if len(req.GetReport()) > maxReportSize {
+		code = codes.ResourceExhausted
+		return nil, status.Errorf(code, "the report is larger than %d bytes", maxReportSize)
+	}
+
+	report.metadata.Attributes = maps.Clone(req.GetAttributes())
+	if appConfig.Auth.enabled() {
+		token, err := appConfig.Auth.authenticate(metadataHeader(ctx), tenant, req.GetAttributes(), req.GetReport(), time.Now())
+		if err != nil {
+			code = codes.Unauthenticated
+			if errors.Is(err, errForbidden) {
+				code = codes.PermissionDenied
+			}
+			return nil, status.Error(code, err.Error())
+		}
+
+		// the attributes of the token take precedence over the ones of the request
+		if report.metadata.Attributes == nil && len(token.Attributes) > 0 {
+			report.metadata.Attributes = map[string]string{}
+		}
+		maps.Copy(report.metadata.Attributes, token.Attributes)
+	}
+
+	if len(bytes.TrimSpace(req.GetReport())) == 0 {
+		code = codes.InvalidArgument
+		return nil, status.Error(code, "the report is empty")
+	}
+
+	traceID := s.server.idGenerator.newTraceID()
+	report.metadata.TraceID = traceID.String()
+	report.data = req.GetReport()
+
+	if err := s.server.queue.push(ctx, report); err != nil {
+		code = codes.Unavailable
+		if !errors.Is(err, errQueueClosed) && !errors.Is(err, errSpoolFull) {
+			code = codes.Internal
+		}
+		return nil, status.Errorf(code, "failed to queue the report: %v", err)
+	}
+
+	return &ingest.SubmitTestReportResponse{TraceId: traceID.String()}, nil
+}
+
+// metadataHeader returns the metadata of the request as the headers of an HTTP request
+func metadataHeader(ctx context.Context) http.Header {
+	header := http.Header{}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for key, values := range md {
+		if strings.HasPrefix(key, ":") {
+			continue
+		}
+
+		for _, value := range values {
+			header.Add(key, value)
+		}
+	}
+
+	return header
+}
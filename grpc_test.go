@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mdelapenya/junit2otlp/ingest"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestIngestServer(t *testing.T) {
+	defer func(cfg *config) { appConfig = cfg }(appConfig)
+	appConfig = &config{
+		Tenancy: tenancyConfig{Header: "X-Team", Tenants: map[string]*tenantConfig{"checkout": {}, "payments": {}}},
+		Auth: authConfig{Tokens: []*apiToken{
+			{Name: "checkout", Token: "abc123", Tenants: []string{"checkout"}, Attributes: map[string]string{"team": "checkout"}},
+		}},
+	}
+
+	queue, err := newReportQueue(10, defaultQueueMaxMemory, t.TempDir(), 0)
+	require.NoError(t, err)
+
+	server, err := newReportServer(queue, func(ctx context.Context, report *queuedReport) error { return nil })
+	require.NoError(t, err)
+
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := newGRPCServer(server)
+	go grpcServer.Serve(listener)
+	defer grpcServer.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	client := ingest.NewIngestServiceClient(conn)
+	authorized := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer abc123")
+
+	t.Run("Submitted report", func(t *testing.T) {
+		resp, err := client.SubmitTestReport(authorized, &ingest.SubmitTestReportRequest{
+			Report:     []byte(`<testsuite name="a"/>`),
+			Tenant:     "checkout",
+			Attributes: map[string]string{"team": "platform", "project": "web"},
+		})
+		require.NoError(t, err)
+
+		traceID, err := trace.TraceIDFromHex(resp.GetTraceId())
+		require.NoError(t, err)
+
+		report, err := queue.tryPop()
+		require.NoError(t, err)
+		require.Equal(t, &queuedReport{
+			data: []byte(`<testsuite name="a"/>`),
+			metadata: reportMetadata{
+				Tenant:     "checkout",
+				Attributes: map[string]string{"team": "checkout", "project": "web"},
+				TraceID:    traceID.String(),
+			},
+		}, report)
+	})
+
+	t.Run("Rejected reports", func(t *testing.T) {
+		_, err := client.SubmitTestReport(context.Background(), &ingest.SubmitTestReportRequest{Report: []byte(`<testsuite name="a"/>`)})
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		_, err = client.SubmitTestReport(authorized, &ingest.SubmitTestReportRequest{Report: []byte(`<testsuite name="a"/>`), Tenant: "marketing"})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		_, err = client.SubmitTestReport(authorized, &ingest.SubmitTestReportRequest{Report: []byte(`<testsuite name="a"/>`), Tenant: "payments"})
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+		_, err = client.SubmitTestReport(authorized, &ingest.SubmitTestReportRequest{Report: []byte("  ")})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		report, err := queue.tryPop()
+		require.NoError(t, err)
+		require.Nil(t, report)
+	})
+}
+
+func TestAssignedIDGenerator(t *testing.T) {
+	provider := sdktrace.NewTracerProvider(sdktrace.WithIDGenerator(assignedIDGenerator{}))
+	tracer := provider.Tracer("test")
+
+	traceID := newTraceID()
+	ctx, root := tracer.Start(withAssignedTraceID(context.Background(), traceID), "root")
+	_, child := tracer.Start(ctx, "child")
+
+	require.Equal(t, traceID, root.SpanContext().TraceID())
+	require.Equal(t, traceID, child.SpanContext().TraceID())
+	require.NotEqual(t, root.SpanContext().SpanID(), child.SpanContext().SpanID())
+
+	_, other := tracer.Start(context.Background(), "other")
+	require.True(t, other.SpanContext().TraceID().IsValid())
+	require.NotEqual(t, traceID, other.SpanContext().TraceID())
+}
+
+func TestXRayIDGenerator(t *testing.T) {
+	generator, err := parseIDGenerator("XRay")
+	require.NoError(t, err)
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithIDGenerator(generator))
+	tracer := provider.Tracer("test")
+
+	before := time.Now().Unix()
+	_, span := tracer.Start(context.Background(), "root")
+	traceID := span.SpanContext().TraceID()
+	require.True(t, traceID.IsValid())
+	require.InDelta(t, before, binary.BigEndian.Uint32(traceID[:4]), 1)
+
+	// the trace ID assigned to a report is kept
+	assigned := generator.newTraceID()
+	_, root := tracer.Start(withAssignedTraceID(context.Background(), assigned), "root")
+	require.Equal(t, assigned, root.SpanContext().TraceID())
+
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	require.Equal(t, "663212a0", newXRayTraceID(now).String()[:8])
+
+	generator, err = parseIDGenerator("")
+	require.NoError(t, err)
+	require.False(t, generator.xray)
+
+	_, err = parseIDGenerator("uuid")
+	require.ErrorContains(t, err, `invalid ID generator "uuid"`)
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// defaultExpectedSuitesTimeout the time the server waits for the reports of the expected suites, if not set in the
+// config file
+const defaultExpectedSuitesTimeout = 30 * time.Minute
+
+// expectedSuitesConfig the suites whose reports the server expects in each run, declared in the config file or
+// inferred from the suites of the previous run, so the jobs hung before uploading their reports become visible
+type expectedSuitesConfig struct {
+	Suites  []string      `yaml:"suites"`
+	Infer   bool          `yaml:"infer"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+func (c expectedSuitesConfig) enabled() bool {
+	return len(c.Suites) > 0 || c.Infer
+}
+
+func (c expectedSuitesConfig) validate() error {
+	if c.Timeout < 0 {
+		return fmt.Errorf("invalid timeout %s: it can't be negative", c.Timeout)
+	}
+
+	for i, suite := range c.Suites {
+		if suite == "" {
+			return fmt.Errorf("suite %d: the name is mandatory", i)
+		}
+	}
+
+	return nil
+}
+
+func (c expectedSuitesConfig) timeout() time.Duration {
+	if c.Timeout == 0 {
+		return defaultExpectedSuitesTimeout
+	}
+
+	return c.Timeout
+}
+
+// suiteWatcher tracks the suites of the reports exported by the server. A run starts with the first report after the
+// previous run, and ends once the reports of all the expected suites arrived, or after the timeout, when the suites
+// without a report are reported as hanging
+type suiteWatcher struct {
+	cfg     expectedSuitesConfig
+	hanging func(suites []string, start time.Time, end time.Time)
+
+	mu       sync.Mutex
+	previous []string
+	seen     map[string]bool
+	start    time.Time
+	timer    *time.Timer
+}
+
+func newSuiteWatcher(cfg expectedSuitesConfig, hanging func(suites []string, start time.Time, end time.Time)) *suiteWatcher {
+	return &suiteWatcher{cfg: cfg, hanging: hanging}
+}
+
+// expected returns the suites expected in the run: the declared ones, and the ones of the previous run if inferred
+func (w *suiteWatcher) expected() []string {
+	expected := slices.Clone(w.cfg.Suites)
+	if w.cfg.Infer {
+		expected = append(expected, w.previous...)
+	}
+	slices.Sort(expected)
+
+	return slices.Compact(expected)
+}
+
+// observe records the suites of an exported report, starting a run if there is none
+func (w *suiteWatcher) observe(report *junitReport) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.seen == nil {
+		w.seen = map[string]bool{}
+		w.start = time.Now()
+		w.timer = time.AfterFunc(w.cfg.timeout(), w.expire)
+	}
+
+	for _, suite := range report.suites {
+		w.seen[suite.Name] = true
+	}
+
+	expected := w.expected()
+	if len(expected) == 0 {
+		return
+	}
+	for _, suite := range expected {
+		if !w.seen[suite] {
+			return
+		}
+	}
+
+	// all the expected suites arrived, so the run is complete before the timeout
+	if w.timer.Stop() {
+		w.end()
+	}
+}
+
+// expire ends the run once the timeout is over, reporting the expected suites without a report
+func (w *suiteWatcher) expire() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.seen == nil {
+		return
+	}
+
+	missing := []string{}
+	for _, suite := range w.expected() {
+		if !w.seen[suite] {
+			missing = append(missing, suite)
+		}
+	}
+
+	start := w.start
+	w.end()
+
+	if len(missing) > 0 {
+		w.hanging(missing, start, start.Add(w.cfg.timeout()))
+	}
+}
+
+// end ends the run, keeping its suites for the inference of the next one
+func (w *suiteWatcher) end() {
+	w.previous = w.previous[:0]
+	for suite := range w.seen {
+		w.previous = append(w.previous, suite)
+	}
+	w.seen = nil
+	w.timer = nil
+}
+
+// stop stops the timeout of the current run, without reporting its suites
+func (w *suiteWatcher) stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+}
+
+// hangingSuitesReporter emits a synthetic errored span for each suite without a report, as the root of its own trace,
+// and counts them in the hanging suites metric
+type hangingSuitesReporter struct {
+	tracer  trace.Tracer
+	counter metric.Int64Counter
+}
+
+func newHangingSuitesReporter(tracerProvider trace.TracerProvider, meter metric.Meter) (*hangingSuitesReporter, error) {
+	counter, err := meter.Int64Counter(TestsSuitesHanging, metric.WithDescription("Number of expected suites whose report did not arrive in time"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &hangingSuitesReporter{tracer: tracerProvider.Tracer(Junit2otlp), counter: counter}, nil
+}
+
+func (r *hangingSuitesReporter) report(suites []string, start time.Time, end time.Time) {
+	ctx := context.Background()
+	timeout := end.Sub(start)
+
+	for _, suite := range suites {
+		_, span := r.tracer.Start(ctx, suite, trace.WithTimestamp(start), trace.WithAttributes(
+			attribute.Key(TestsSuiteName).String(suite),
+			attribute.Key(TestsSuiteHanging).Bool(true),
+		))
+		span.SetStatus(codes.Error, fmt.Sprintf("the report of the suite did not arrive within %s", timeout))
+		span.End(trace.WithTimestamp(end))
+
+		r.counter.Add(ctx, 1, metric.WithAttributes(attribute.Key(TestsSuiteName).String(suite)))
+	}
+}
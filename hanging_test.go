@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func suitesReport(names ...string) *junitReport {
+	report := &junitReport{}
+	for _, name := range names {
+		report.suites = append(report.suites, junit.Suite{Name: name})
+	}
+
+	return report
+}
+
+func TestSuiteWatcher(t *testing.T) {
+	watch := func(cfg expectedSuitesConfig) (*suiteWatcher, func() [][]string) {
+		mu := sync.Mutex{}
+		hanging := [][]string{}
+		watcher := newSuiteWatcher(cfg, func(suites []string, start time.Time, end time.Time) {
+			mu.Lock()
+			defer mu.Unlock()
+			require.Equal(t, cfg.Timeout, end.Sub(start))
+			hanging = append(hanging, suites)
+		})
+		t.Cleanup(watcher.stop)
+
+		return watcher, func() [][]string {
+			mu.Lock()
+			defer mu.Unlock()
+			return hanging
+		}
+	}
+
+	t.Run("the declared suites without a report are hanging", func(t *testing.T) {
+		watcher, hanging := watch(expectedSuitesConfig{Suites: []string{"unit", "integration", "e2e"}, Timeout: 20 * time.Millisecond})
+
+		watcher.observe(suitesReport("unit"))
+		watcher.observe(suitesReport("e2e", "lint"))
+
+		require.Eventually(t, func() bool { return len(hanging()) == 1 }, time.Second, 5*time.Millisecond)
+		require.Equal(t, [][]string{{"integration"}}, hanging())
+	})
+
+	t.Run("the run ends once all the suites arrived", func(t *testing.T) {
+		watcher, hanging := watch(expectedSuitesConfig{Suites: []string{"unit", "integration"}, Timeout: 20 * time.Millisecond})
+
+		watcher.observe(suitesReport("unit"))
+		watcher.observe(suitesReport("integration"))
+
+		time.Sleep(50 * time.Millisecond)
+		require.Empty(t, hanging())
+	})
+
+	t.Run("the suites of the previous run are expected", func(t *testing.T) {
+		watcher, hanging := watch(expectedSuitesConfig{Infer: true, Timeout: 20 * time.Millisecond})
+
+		watcher.observe(suitesReport("unit", "integration"))
+		time.Sleep(50 * time.Millisecond)
+		require.Empty(t, hanging())
+
+		watcher.observe(suitesReport("unit"))
+		require.Eventually(t, func() bool { return len(hanging()) == 1 }, time.Second, 5*time.Millisecond)
+		require.Equal(t, [][]string{{"integration"}}, hanging())
+
+		// the hanging suite is not expected anymore, as it's not in the last run
+		watcher.observe(suitesReport("unit"))
+		time.Sleep(50 * time.Millisecond)
+		require.Len(t, hanging(), 1)
+	})
+}
+
+func TestHangingSuitesReporter(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	reader := sdkmetric.NewManualReader()
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	reporter, err := newHangingSuitesReporter(tracerProvider, meterProvider.Meter(Junit2otlp))
+	require.NoError(t, err)
+
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	reporter.report([]string{"integration", "e2e"}, start, start.Add(30*time.Minute))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	require.Equal(t, "integration", spans[0].Name())
+	require.Equal(t, start, spans[0].StartTime())
+	require.Equal(t, start.Add(30*time.Minute), spans[0].EndTime())
+	require.Equal(t, codes.Error, spans[0].Status().Code)
+	require.Equal(t, "the report of the suite did not arrive within 30m0s", spans[0].Status().Description)
+	require.Contains(t, spans[0].Attributes(), attribute.Key(TestsSuiteHanging).Bool(true))
+	require.NotEqual(t, spans[0].SpanContext().TraceID(), spans[1].SpanContext().TraceID())
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	require.Equal(t, TestsSuitesHanging, rm.ScopeMetrics[0].Metrics[0].Name)
+	require.Len(t, rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64]).DataPoints, 2)
+}
+
+func TestExpectedSuitesConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+expected_suites:
+  suites: [unit, integration]
+  infer: true
+  timeout: 45m
+`), 0o644))
+
+	cfg, err := loadConfig(path)
+	require.NoError(t, err)
+	require.Equal(t, expectedSuitesConfig{Suites: []string{"unit", "integration"}, Infer: true, Timeout: 45 * time.Minute}, cfg.ExpectedSuites)
+
+	require.False(t, expectedSuitesConfig{}.enabled())
+	require.True(t, expectedSuitesConfig{Infer: true}.enabled())
+	require.Equal(t, defaultExpectedSuitesTimeout, expectedSuitesConfig{}.timeout())
+
+	require.NoError(t, expectedSuitesConfig{Suites: []string{"unit"}, Timeout: time.Hour}.validate())
+	require.ErrorContains(t, expectedSuitesConfig{Timeout: -time.Second}.validate(), "it can't be negative")
+	require.ErrorContains(t, expectedSuitesConfig{Suites: []string{""}}.validate(), "suite 0: the name is mandatory")
+}
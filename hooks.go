@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/google/cel-go/cel"
+	"github.com/joshdk/go-junit"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// spanHook a user-defined hook mutating the spans of the test cases before they are exported: the spans matching
+// its CEL condition are renamed, get its status, and get or lose its attributes. The name and the attributes are
+// CEL expressions too, with the same variables as the condition
+type spanHook struct {
+	When       string            `yaml:"when"`
+	Name       string            `yaml:"name"`
+	Status     string            `yaml:"status"`
+	Attributes map[string]string `yaml:"attributes"`
+	Remove     []string          `yaml:"remove"`
+
+	when       cel.Program
+	name       cel.Program
+	keys       []string
+	attributes map[string]cel.Program
+}
+
+func (h *spanHook) compile() error {
+	if h.When == "" {
+		return fmt.Errorf("the condition is mandatory")
+	}
+
+	if h.Name == "" && h.Status == "" && len(h.Attributes) == 0 && len(h.Remove) == 0 {
+		return fmt.Errorf("the hook changes nothing: set its name, status, attributes or the attributes to remove")
+	}
+
+	var err error
+	if h.when, err = compileExpression(h.When, cel.BoolType); err != nil {
+		return err
+	}
+
+	if h.Name != "" {
+		if h.name, err = compileExpression(h.Name, cel.StringType); err != nil {
+			return err
+		}
+	}
+
+	h.keys = slices.Sorted(maps.Keys(h.Attributes))
+	h.attributes = map[string]cel.Program{}
+	for _, key := range h.keys {
+		if h.attributes[key], err = compileExpression(h.Attributes[key], nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applySpanHooks applies the hooks matching the test case, in the order of the configuration file, each one seeing
+// the changes of the previous ones, and returns its name, status and attributes. The changes of a hook whose
+// expressions fail are dropped as a whole
+func applySpanHooks(hooks []spanHook, suite junit.Suite, test junit.Test, name string, status string, attributes []attribute.KeyValue) (string, string, []attribute.KeyValue) {
+	for i := range hooks {
+		hookName, hookStatus, hookAttributes, err := hooks[i].apply(suite, test, name, status, attributes)
+		if err != nil {
+			droppedData.drop(droppedHookChanges, 1, fmt.Sprintf("span hook %d of the test %s: %v", i, name, err))
+			continue
+		}
+
+		name, status, attributes = hookName, hookStatus, hookAttributes
+	}
+
+	return name, status, attributes
+}
+
+func (h *spanHook) apply(suite junit.Suite, test junit.Test, name string, status string, attributes []attribute.KeyValue) (string, string, []attribute.KeyValue, error) {
+	activation := testActivation(suite, test, name, status, attributes)
+
+	matched, _, err := h.when.Eval(activation)
+	if err != nil {
+		return "", "", nil, err
+	}
+	if matched.Value() != true {
+		return name, status, attributes, nil
+	}
+
+	if h.name != nil {
+		val, _, err := h.name.Eval(activation)
+		if err != nil {
+			return "", "", nil, err
+		}
+
+		name = val.Value().(string)
+	}
+
+	if h.Status != "" {
+		status = h.Status
+	}
+
+	changes := make([]attribute.KeyValue, 0, len(h.keys))
+	for _, key := range h.keys {
+		val, _, err := h.attributes[key].Eval(activation)
+		if err != nil {
+			return "", "", nil, err
+		}
+
+		kv, err := expressionAttribute(key, val)
+		if err != nil {
+			return "", "", nil, err
+		}
+		changes = append(changes, kv)
+	}
+
+	attributes = slices.DeleteFunc(attributes, func(kv attribute.KeyValue) bool {
+		return slices.Contains(h.Remove, string(kv.Key))
+	})
+
+	return name, status, setAttributes(attributes, changes...), nil
+}
+
+// setAttributes sets the attributes, replacing the ones with the same key
+func setAttributes(attributes []attribute.KeyValue, kvs ...attribute.KeyValue) []attribute.KeyValue {
+	for _, kv := range kvs {
+		i := slices.IndexFunc(attributes, func(existing attribute.KeyValue) bool { return existing.Key == kv.Key })
+		if i < 0 {
+			attributes = append(attributes, kv)
+			continue
+		}
+
+		attributes[i] = kv
+	}
+
+	return attributes
+}
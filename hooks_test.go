@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func loadSpanHooks(t *testing.T, hooks string) *config {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "junit2otlp.yml")
+	require.NoError(t, os.WriteFile(path, []byte(hooks), 0o644))
+
+	cfg, err := loadConfig(path)
+	require.NoError(t, err)
+
+	return cfg
+}
+
+func TestApplySpanHooks(t *testing.T) {
+	cfg := loadSpanHooks(t, `
+span_hooks:
+  - when: 'suite.startsWith("e2e") && status == "failed" && message.contains("connection refused")'
+    status: infrastructure
+    attributes:
+      tests.case.quarantined: 'true'
+  - when: 'duration > duration("5s")'
+    name: 'classname + "." + name'
+    attributes:
+      tests.case.slow: 'duration.getSeconds()'
+      tests.case.tier: 'properties.tier'
+    remove: [tests.case.systemout]
+  - when: '"owner.team" in attributes && attributes["owner.team"] == "platform"'
+    attributes:
+      tests.case.labels: '["platform", status]'
+`)
+	require.Len(t, cfg.SpanHooks, 3)
+
+	base := func() []attribute.KeyValue {
+		return []attribute.KeyValue{
+			attribute.Key(TestSystemOut).String("out"),
+			attribute.Key("owner.team").String("platform"),
+		}
+	}
+
+	t.Run("The hooks see the changes of the previous ones", func(t *testing.T) {
+		test := junit.Test{Classname: "pkg", Status: junit.StatusFailed, Message: "dial: connection refused", Duration: 10 * time.Second, Properties: map[string]string{"tier": "gold"}}
+		name, status, attrs := applySpanHooks(cfg.SpanHooks, junit.Suite{Name: "e2e-api"}, test, "TestA", string(junit.StatusFailed), base())
+
+		require.Equal(t, "pkg.TestA", name)
+		require.Equal(t, "infrastructure", status)
+		require.Equal(t, []attribute.KeyValue{
+			attribute.Key("owner.team").String("platform"),
+			attribute.Key("tests.case.quarantined").Bool(true),
+			attribute.Key("tests.case.slow").Int64(10),
+			attribute.Key("tests.case.tier").String("gold"),
+			attribute.Key("tests.case.labels").StringSlice([]string{"platform", "infrastructure"}),
+		}, attrs)
+	})
+
+	t.Run("Unmatched hooks change nothing", func(t *testing.T) {
+		name, status, attrs := applySpanHooks(cfg.SpanHooks[:2], junit.Suite{Name: "unit"}, junit.Test{Duration: time.Second}, "TestB", string(junit.StatusPassed), base())
+
+		require.Equal(t, "TestB", name)
+		require.Equal(t, string(junit.StatusPassed), status)
+		require.Equal(t, base(), attrs)
+	})
+
+	t.Run("The changes of a failing hook are dropped", func(t *testing.T) {
+		droppedData = &dropLedger{}
+		t.Cleanup(func() { droppedData = &dropLedger{} })
+
+		// the tier property is missing
+		name, status, attrs := applySpanHooks(cfg.SpanHooks[1:2], junit.Suite{Name: "unit"}, junit.Test{Classname: "pkg", Duration: time.Minute}, "TestC", string(junit.StatusPassed), base())
+
+		require.Equal(t, "TestC", name)
+		require.Equal(t, string(junit.StatusPassed), status)
+		require.Equal(t, base(), attrs)
+		require.Equal(t, map[dropKind]int64{droppedHookChanges: 1}, droppedData.counts)
+	})
+}
+
+func TestSpanHookValidation(t *testing.T) {
+	for name, tc := range map[string]struct {
+		hooks string
+		err   string
+	}{
+		"Without a condition": {
+			hooks: "span_hooks:\n  - status: skipped\n",
+			err:   "invalid span hook 0 in the config file",
+		},
+		"Without changes": {
+			hooks: "span_hooks:\n  - when: 'true'\n",
+			err:   "the hook changes nothing",
+		},
+		"A condition which is not a boolean": {
+			hooks: "span_hooks:\n  - when: 'name'\n    status: skipped\n",
+			err:   `the expression "name" is a string instead of a bool`,
+		},
+		"A name which is not a string": {
+			hooks: "span_hooks:\n  - when: 'true'\n    name: 'duration'\n",
+			err:   `the expression "duration" is a google.protobuf.Duration instead of a string`,
+		},
+		"An unknown variable": {
+			hooks: "span_hooks:\n  - when: 'team == \"a\"'\n    status: skipped\n",
+			err:   "undeclared reference to 'team'",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "junit2otlp.yml")
+			require.NoError(t, os.WriteFile(path, []byte(tc.hooks), 0o644))
+
+			_, err := loadConfig(path)
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestSpanHooksOnTestSpans(t *testing.T) {
+	defer func(cfg *config) { appConfig = cfg }(appConfig)
+	appConfig = loadSpanHooks(t, `
+span_hooks:
+  - when: 'status == "failed" && name.startsWith("TestFlaky")'
+    name: 'name.lowerAscii()'
+    status: quarantined
+`)
+
+	exporter := tracetest.NewInMemoryExporter()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)).Tracer("test")
+	meter := sdkmetric.NewMeterProvider().Meter("test")
+
+	createTestSpan(context.Background(), tracer, newTestCaseMetrics(meter, 0), junit.Suite{Name: "a"}, nil, junit.Test{Name: "TestFlakyLogin", Status: junit.StatusFailed, Message: "boom"}, 0, time.Time{})
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	require.Equal(t, "testflakylogin", spans[0].Name)
+	require.Equal(t, codes.Unset, spans[0].Status.Code)
+	require.Contains(t, spans[0].Attributes, attribute.Key(TestStatus).String("quarantined"))
+	require.Contains(t, spans[0].Attributes, attribute.Key(TestResult).String("quarantined"))
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joshdk/go-junit"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+// htmlReportWriter writes a standalone HTML summary of the run from the rows of the test cases, with the
+// details of the failures and deep links to the exported traces, as a CI artifact for the people without
+// access to the backend
+type htmlReportWriter struct {
+	mu       sync.Mutex
+	path     string
+	traceURL string
+	rows     []map[string]any
+}
+
+// getTraceURL the precedence order is: flag > JUNIT2OTLP_TRACE_URL > no links
+func getTraceURL() string {
+	return getOtlpEnvVar(traceURLFlag, "JUNIT2OTLP_TRACE_URL", "")
+}
+
+func newHTMLReportWriter(path string, traceURL string) *htmlReportWriter {
+	return &htmlReportWriter{path: path, traceURL: traceURL}
+}
+
+func (w *htmlReportWriter) writeRows(ctx context.Context, rows []map[string]any) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.rows = append(w.rows, rows...)
+	return nil
+}
+
+// htmlReport the data of the HTML report
+type htmlReport struct {
+	Title     string
+	Generated string
+	TraceURLs []string
+	Summary   runSummary
+	Suites    []*htmlSuite
+	Failures  []htmlTest
+}
+
+type htmlSuite struct {
+	Name     string
+	Total    int
+	Failed   int
+	Skipped  int
+	Duration time.Duration
+}
+
+type htmlTest struct {
+	Suite     string
+	Name      string
+	Classname string
+	Status    string
+	Duration  time.Duration
+	Message   string
+	Error     string
+	SystemOut string
+	SystemErr string
+	URL       string
+}
+
+// close writes the HTML report, with the suites and the failures in the order of their execution
+func (w *htmlReportWriter) close(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	slices.SortStableFunc(w.rows, func(a, b map[string]any) int {
+		return compareRowValues(a[rowTimestamp], b[rowTimestamp])
+	})
+
+	report := w.report()
+
+	file, err := os.Create(w.path)
+	if err != nil {
+		return fmt.Errorf("failed to create the HTML report: %w", err)
+	}
+	defer file.Close()
+
+	if err := htmlReportTemplate.Execute(file, report); err != nil {
+		return fmt.Errorf("failed to write the HTML report %s: %w", w.path, err)
+	}
+
+	return file.Close()
+}
+
+func (w *htmlReportWriter) report() htmlReport {
+	report := htmlReport{Title: Junit2otlp, Generated: time.Now().UTC().Format(time.RFC1123)}
+	suites := map[string]*htmlSuite{}
+
+	for _, row := range w.rows {
+		text := func(column string) string { return formatRowValue(row[column]) }
+
+		if name := text(serviceNameColumn); name != "" {
+			report.Title = name
+		}
+
+		if url := w.link(text(rowTraceID), ""); url != "" && !slices.Contains(report.TraceURLs, url) {
+			report.TraceURLs = append(report.TraceURLs, url)
+		}
+
+		suiteName := text(flattenKey(TestsSuiteName))
+		suite, ok := suites[suiteName]
+		if !ok {
+			suite = &htmlSuite{Name: suiteName}
+			suites[suiteName] = suite
+			report.Suites = append(report.Suites, suite)
+			report.Summary.Suites++
+		}
+
+		duration, _ := row[rowDurationMs].(int64)
+		test := htmlTest{
+			Suite:     suiteName,
+			Name:      text(rowName),
+			Classname: text(flattenKey(TestClassName)),
+			Status:    text(flattenKey(TestStatus)),
+			Duration:  time.Duration(duration) * time.Millisecond,
+			Message:   text(flattenKey(TestMessage)),
+			Error:     text(flattenKey(TestError)),
+			SystemOut: text(flattenKey(TestSystemOut)),
+			SystemErr: text(flattenKey(TestSystemErr)),
+			URL:       w.link(text(rowTraceID), text(rowSpanID)),
+		}
+
+		suite.Total++
+		suite.Duration += test.Duration
+		report.Summary.Total++
+		report.Summary.Duration += test.Duration
+
+		switch junit.Status(test.Status) {
+		case junit.StatusPassed:
+			report.Summary.Passed++
+		case junit.StatusSkipped:
+			suite.Skipped++
+			report.Summary.Skipped++
+		case junit.StatusError:
+			suite.Failed++
+			report.Summary.Errors++
+			report.Failures = append(report.Failures, test)
+		default:
+			// the statuses set by the failure rules are failures too
+			suite.Failed++
+			report.Summary.Failed++
+			report.Failures = append(report.Failures, test)
+		}
+	}
+
+	return report
+}
+
+// link returns the URL of the trace, or of the span if the template includes it, or empty if there is no template
+func (w *htmlReportWriter) link(traceID string, spanID string) string {
+	if w.traceURL == "" || traceID == "" {
+		return ""
+	}
+
+	return strings.NewReplacer("{trace_id}", traceID, "{span_id}", spanID).Replace(w.traceURL)
+}
+
+// serviceNameColumn the column with the service name of the resource
+var serviceNameColumn = flattenKey(semconv.ServiceNameKey)
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"percent": func(ratio float64) string { return fmt.Sprintf("%.1f%%", ratio*100) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}} test report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #24292f; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #d0d7de; padding: 0.4em 0.8em; text-align: left; }
+th { background: #f6f8fa; }
+.passed { color: #1a7f37; }
+.failed { color: #cf222e; }
+.skipped { color: #9a6700; }
+details { border: 1px solid #d0d7de; border-radius: 6px; padding: 0.5em 1em; margin-bottom: 0.5em; }
+summary { cursor: pointer; font-weight: 600; }
+pre { background: #f6f8fa; padding: 1em; overflow-x: auto; white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>{{.Title}} test report</h1>
+<p>Generated at {{.Generated}}{{range .TraceURLs}} · <a href="{{.}}">View the trace</a>{{end}}</p>
+
+<h2>Summary</h2>
+<table>
+<tr><th>Tests</th><th>Passed</th><th>Failed</th><th>Errors</th><th>Skipped</th><th>Pass rate</th><th>Duration</th></tr>
+<tr>
+<td>{{.Summary.Total}}</td>
+<td class="passed">{{.Summary.Passed}}</td>
+<td class="failed">{{.Summary.Failed}}</td>
+<td class="failed">{{.Summary.Errors}}</td>
+<td class="skipped">{{.Summary.Skipped}}</td>
+<td>{{percent .Summary.PassRate}}</td>
+<td>{{.Summary.Duration}}</td>
+</tr>
+</table>
+
+<h2>Suites</h2>
+<table>
+<tr><th>Suite</th><th>Tests</th><th>Failed</th><th>Skipped</th><th>Duration</th></tr>
+{{range .Suites}}<tr>
+<td>{{.Name}}</td>
+<td>{{.Total}}</td>
+<td{{if .Failed}} class="failed"{{end}}>{{.Failed}}</td>
+<td>{{.Skipped}}</td>
+<td>{{.Duration}}</td>
+</tr>
+{{end}}</table>
+
+<h2>Failures</h2>
+{{range .Failures}}<details>
+<summary><span class="failed">{{.Status}}</span> {{.Suite}} › {{if .Classname}}{{.Classname}} › {{end}}{{.Name}} ({{.Duration}})</summary>
+{{if .URL}}<p><a href="{{.URL}}">View the test span</a></p>{{end}}
+{{if .Message}}<p>{{.Message}}</p>{{end}}
+{{if .Error}}<pre>{{.Error}}</pre>{{end}}
+{{if .SystemOut}}<h4>Standard output</h4><pre>{{.SystemOut}}</pre>{{end}}
+{{if .SystemErr}}<h4>Standard error</h4><pre>{{.SystemErr}}</pre>{{end}}
+</details>
+{{else}}<p class="passed">No failures.</p>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTMLReportWriter(t *testing.T) {
+	rows := []map[string]any{
+		{
+			rowTimestamp: "2024-01-01T12:00:00Z", rowTraceID: "0af7651916cd43dd8448eb211c80319c", rowSpanID: "b7ad6b7169203331",
+			rowName: "TestLogin", rowDurationMs: int64(1500), serviceNameColumn: "checkout",
+			flattenKey(TestsSuiteName): "auth", flattenKey(TestStatus): "passed",
+		},
+		{
+			rowTimestamp: "2024-01-01T12:00:01Z", rowTraceID: "0af7651916cd43dd8448eb211c80319c", rowSpanID: "00f067aa0ba902b7",
+			rowName: "TestPayment", rowDurationMs: int64(2000), serviceNameColumn: "checkout",
+			flattenKey(TestsSuiteName): "cart", flattenKey(TestStatus): "failed", flattenKey(TestClassName): "CartTest",
+			flattenKey(TestMessage): "expected <200>", flattenKey(TestError): "at CartTest.java:42",
+		},
+		{
+			rowTimestamp: "2024-01-01T12:00:02Z", rowName: "TestRefund", serviceNameColumn: "checkout",
+			flattenKey(TestsSuiteName): "cart", flattenKey(TestStatus): "skipped",
+		},
+	}
+
+	write := func(t *testing.T, traceURL string) string {
+		path := filepath.Join(t.TempDir(), "report.html")
+
+		writer := newHTMLReportWriter(path, traceURL)
+		require.NoError(t, writer.writeRows(context.Background(), rows))
+		require.NoError(t, writer.close(context.Background()))
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		return string(data)
+	}
+
+	t.Run("Summary and failures", func(t *testing.T) {
+		html := write(t, "")
+
+		require.Contains(t, html, "<title>checkout test report</title>")
+		require.Contains(t, html, "<td>3</td>\n<td class=\"passed\">1</td>\n<td class=\"failed\">1</td>")
+		require.Contains(t, html, "<td>50.0%</td>")
+		require.Contains(t, html, "cart › CartTest › TestPayment (2s)")
+		// the output of the tests is escaped
+		require.Contains(t, html, "<p>expected &lt;200&gt;</p>")
+		require.Contains(t, html, "<pre>at CartTest.java:42</pre>")
+		require.NotContains(t, html, "TestRefund")
+		require.NotContains(t, html, "<a href")
+	})
+
+	t.Run("Links to the traces", func(t *testing.T) {
+		html := write(t, "https://jaeger.example.com/trace/{trace_id}?uiFind={span_id}")
+
+		require.Contains(t, html, `<a href="https://jaeger.example.com/trace/0af7651916cd43dd8448eb211c80319c?uiFind=">View the trace</a>`)
+		require.Contains(t, html, `<a href="https://jaeger.example.com/trace/0af7651916cd43dd8448eb211c80319c?uiFind=00f067aa0ba902b7">View the test span</a>`)
+	})
+
+	t.Run("No failures", func(t *testing.T) {
+		writer := newHTMLReportWriter(filepath.Join(t.TempDir(), "report.html"), "")
+		require.NoError(t, writer.writeRows(context.Background(), rows[:1]))
+
+		report := writer.report()
+		require.Empty(t, report.Failures)
+		require.Equal(t, 1.0, report.Summary.PassRate())
+	})
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/joshdk/go-junit"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"gopkg.in/yaml.v3"
+)
+
+// the reasons for a test to be impacted by the changeset
+const (
+	impactReasonChangeset = "unknown-changeset"
+	impactReasonFile      = "file"
+	impactReasonMap       = "impact-map"
+	impactReasonName      = "name"
+	impactReasonPackage   = "package"
+)
+
+// impactMap the source files exercised by each test, i.e. from its per-test coverage. The keys are the selectors
+// of the tests, their class names, their names, or their files, and the values are the paths of the source files,
+// glob patterns, or directories ending with a slash
+type impactMap map[string][]string
+
+// impactedTest a test of the report impacted by the changeset, with the reasons of its impact
+type impactedTest struct {
+	Selector  string   `json:"selector"`
+	Suite     string   `json:"suite"`
+	Classname string   `json:"classname,omitempty"`
+	Name      string   `json:"name"`
+	File      string   `json:"file,omitempty"`
+	Reasons   []string `json:"reasons"`
+}
+
+func loadImpactMap(path string) (impactMap, error) {
+	mapping := impactMap{}
+	if path == "" {
+		return mapping, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the impact map: %w", err)
+	}
+
+	// the YAML decoder reads the JSON files too
+	if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&mapping); err != nil {
+		return nil, fmt.Errorf("failed to parse the impact map %s: %w", path, err)
+	}
+
+	return mapping, nil
+}
+
+// testSelector returns the selector of the test, as the test runners select a method of a class, i.e.
+// com.acme.CartTest#testTotal, or its name if it has no class name
+func testSelector(classname string, name string) string {
+	if classname == "" {
+		return name
+	}
+
+	return classname + "#" + name
+}
+
+// writeImpactedTestsOutput writes the tests of the report impacted by the changes of the repository. Without a
+// changeset, i.e. outside a change request, the impact cannot be determined, and all the tests are impacted
+func writeImpactedTestsOutput(outputPath string, report *junitReport, scm Scm, mapping impactMap) error {
+	var changed []string
+	if gitScm, ok := scm.(*GitScm); ok && gitScm != nil && gitScm.changeRequest {
+		files, err := gitScm.changedFiles()
+		if err != nil {
+			log.Printf("all the tests are impacted, as the changeset is unknown: %v", err)
+		}
+		changed = files
+	}
+
+	return writeImpactedTests(outputPath, impactedTests(report, changed, mapping))
+}
+
+// impactedTests returns the tests impacted by the changed files, once each, in the order of the report. A test is
+// impacted if its file changed, or if one of its source files changed: the ones of the impact map, or, if it's not
+// in the map, the ones named after the test, i.e. cart.go for cart_test.go or CartTest, or in its package. If the
+// changed files are unknown, all the tests are impacted
+func impactedTests(report *junitReport, changed []string, mapping impactMap) []impactedTest {
+	tests := []impactedTest{}
+	seen := map[string]bool{}
+
+	for _, suite := range report.suites {
+		for _, test := range suite.Tests {
+			impacted := newImpactedTest(suite, test)
+			if seen[impacted.Selector] {
+				continue
+			}
+
+			if changed == nil {
+				impacted.Reasons = []string{impactReasonChangeset}
+			} else {
+				impacted.Reasons = impactReasons(impacted, changed, mapping)
+			}
+
+			if len(impacted.Reasons) > 0 {
+				seen[impacted.Selector] = true
+				tests = append(tests, impacted)
+			}
+		}
+	}
+
+	return tests
+}
+
+func newImpactedTest(suite junit.Suite, test junit.Test) impactedTest {
+	name, attributes := testDialect(test)
+
+	file := suite.Properties["file"]
+	for _, attr := range attributes {
+		if attr.Key == semconv.CodeFilepathKey {
+			file = attr.Value.AsString()
+		}
+	}
+
+	return impactedTest{
+		Selector:  testSelector(test.Classname, name),
+		Suite:     suite.Name,
+		Classname: test.Classname,
+		Name:      name,
+		File:      file,
+	}
+}
+
+func impactReasons(test impactedTest, changed []string, mapping impactMap) []string {
+	reasons := []string{}
+
+	if test.File != "" && slices.ContainsFunc(changed, func(file string) bool { return samePath(test.File, file) }) {
+		reasons = append(reasons, impactReasonFile)
+	}
+
+	patterns, mapped := mapping.sources(test)
+	if mapped {
+		if slices.ContainsFunc(changed, func(file string) bool { return matchesAnySource(patterns, file) }) {
+			reasons = append(reasons, impactReasonMap)
+		}
+
+		return reasons
+	}
+
+	stems := []string{}
+	if test.File != "" {
+		stems = append(stems, sourceStem(path.Base(test.File)))
+	}
+	if test.Classname != "" {
+		stems = append(stems, sourceStem(classSimpleName(test.Classname)))
+	}
+	if slices.ContainsFunc(changed, func(file string) bool { return slices.Contains(stems, sourceStem(path.Base(file))) }) {
+		reasons = append(reasons, impactReasonName)
+	}
+
+	if pkg := classPackage(test.Classname); pkg != "" && slices.ContainsFunc(changed, func(file string) bool { return inPackage(path.Dir(file), pkg) }) {
+		reasons = append(reasons, impactReasonPackage)
+	}
+
+	return reasons
+}
+
+// sources returns the source files of the test in the map, by its selector, its class name, its name, or its file
+func (m impactMap) sources(test impactedTest) ([]string, bool) {
+	for _, key := range []string{test.Selector, test.Classname, test.Name, test.File} {
+		if patterns, ok := m[key]; ok && key != "" {
+			return patterns, true
+		}
+	}
+
+	if test.File != "" {
+		for key, patterns := range m {
+			if samePath(test.File, key) {
+				return patterns, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// matchesAnySource checks if the file matches any of the source files: the same path, or a suffix of it, a glob
+// pattern, or a directory ending with a slash, or with /**
+func matchesAnySource(patterns []string, file string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.TrimPrefix(pattern, "./")
+
+		if dir, ok := strings.CutSuffix(pattern, "**"); ok && (dir == "" || strings.HasSuffix(dir, "/")) {
+			pattern = dir
+		}
+
+		if strings.HasSuffix(pattern, "/") {
+			if strings.HasPrefix(file, pattern) {
+				return true
+			}
+			continue
+		}
+
+		// the source files of the coverage reports may be relative to the source directories, i.e. com/acme/Cart.java
+		if matched, err := path.Match(pattern, file); (err == nil && matched) || samePath(pattern, file) || samePath(file, pattern) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// samePath checks if the path in the report, which may be absolute or relative to another directory, i.e.
+// /app/tests/AppTest.php, is the path relative to the root of the repository, i.e. tests/AppTest.php
+func samePath(reportPath string, repositoryPath string) bool {
+	reportPath = path.Clean(strings.TrimPrefix(reportPath, "./"))
+	repositoryPath = path.Clean(strings.TrimPrefix(repositoryPath, "./"))
+
+	return reportPath == repositoryPath || strings.HasSuffix(reportPath, "/"+repositoryPath)
+}
+
+// testFileSuffixes the suffixes of the names of the test files and classes, removed from them to get the name of
+// the source file they test, in order
+var testFileSuffixes = []string{"_test", ".test", "-test", "_spec", ".spec", "-spec", "Tests", "Test", "Spec", "IT"}
+
+// sourceStem returns the name of the source file exercised by a test file or class, without its extension, in
+// lower case, i.e. cart for cart_test.go, cart.spec.ts, CartTest.java, test_cart.py, or cart.go itself
+func sourceStem(name string) string {
+	stem := strings.TrimSuffix(name, path.Ext(name))
+
+	for _, suffix := range testFileSuffixes {
+		if trimmed, ok := strings.CutSuffix(stem, suffix); ok && trimmed != "" {
+			stem = trimmed
+			break
+		}
+	}
+
+	if trimmed, ok := strings.CutPrefix(stem, "test_"); ok && trimmed != "" {
+		stem = trimmed
+	} else if trimmed, ok := strings.CutPrefix(stem, "Test"); ok && trimmed != "" && strings.ToUpper(trimmed[:1]) == trimmed[:1] {
+		stem = trimmed
+	}
+
+	return strings.ToLower(stem)
+}
+
+// classSimpleName returns the last segment of the class name, i.e. CartTest for com.acme.CartTest
+func classSimpleName(classname string) string {
+	if strings.Contains(classname, "/") {
+		return path.Base(classname)
+	}
+
+	return classname[strings.LastIndex(classname, ".")+1:]
+}
+
+// classPackage returns the package of the class name as a path: the class name itself if it's a path, as the
+// import paths of Go, or its dotted segments without the class, i.e. com/acme for com.acme.CartTest
+func classPackage(classname string) string {
+	if strings.Contains(classname, "/") {
+		return strings.Trim(classname, "/")
+	}
+
+	index := strings.LastIndex(classname, ".")
+	if index < 0 {
+		return ""
+	}
+
+	return strings.ReplaceAll(classname[:index], ".", "/")
+}
+
+// inPackage checks if the directory of a changed file is the package, i.e. src/main/java/com/acme for com/acme,
+// or cart for the github.com/acme/shop/cart import path
+func inPackage(dir string, pkg string) bool {
+	if dir == "." || dir == "" {
+		return false
+	}
+
+	return dir == pkg || strings.HasSuffix(dir, "/"+pkg) || strings.HasSuffix(pkg, "/"+dir)
+}
+
+// writeImpactedTests writes the impacted tests to the file: a JSON array with their details if its extension is
+// .json, or their selectors, one per line
+func writeImpactedTests(outputPath string, tests []impactedTest) error {
+	buffer := &bytes.Buffer{}
+
+	if strings.EqualFold(path.Ext(outputPath), ".json") {
+		encoder := json.NewEncoder(buffer)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(tests); err != nil {
+			return fmt.Errorf("failed to encode the impacted tests: %w", err)
+		}
+	} else {
+		for _, test := range tests {
+			fmt.Fprintln(buffer, test.Selector)
+		}
+	}
+
+	if err := os.WriteFile(outputPath, buffer.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write the impacted tests: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImpactedTests(t *testing.T) {
+	report := &junitReport{suites: []junit.Suite{
+		{
+			Name: "com.acme.CartTest",
+			Tests: []junit.Test{
+				{Name: "testTotal", Classname: "com.acme.CartTest"},
+				{Name: "testTotal", Classname: "com.acme.CartTest"},
+			},
+		},
+		{
+			Name: "github.com/acme/shop/payments",
+			Tests: []junit.Test{
+				{Name: "TestRefund", Classname: "github.com/acme/shop/payments"},
+			},
+		},
+		{
+			Name: "rspec",
+			Tests: []junit.Test{
+				{Name: "User is valid (./spec/models/user_spec.rb:12)", Classname: "spec.models.user_spec"},
+			},
+		},
+		{
+			Name: "pytest",
+			Tests: []junit.Test{
+				{Name: "test_login", Classname: "tests.test_auth"},
+			},
+		},
+	}}
+
+	selectors := func(tests []impactedTest) []string {
+		result := []string{}
+		for _, test := range tests {
+			result = append(result, test.Selector)
+		}
+		return result
+	}
+
+	t.Run("Unknown changeset", func(t *testing.T) {
+		tests := impactedTests(report, nil, impactMap{})
+
+		require.Equal(t, []string{"com.acme.CartTest#testTotal", "github.com/acme/shop/payments#TestRefund", "spec.models.user_spec#User is valid", "tests.test_auth#test_login"}, selectors(tests))
+		require.Equal(t, []string{impactReasonChangeset}, tests[0].Reasons)
+	})
+
+	t.Run("Empty changeset", func(t *testing.T) {
+		require.Empty(t, impactedTests(report, []string{}, impactMap{}))
+	})
+
+	t.Run("Heuristics", func(t *testing.T) {
+		tests := impactedTests(report, []string{"src/main/java/com/acme/Cart.java", "payments/refund.go", "spec/models/user_spec.rb", "README.md"}, impactMap{})
+
+		require.Equal(t, []string{"com.acme.CartTest#testTotal", "github.com/acme/shop/payments#TestRefund", "spec.models.user_spec#User is valid"}, selectors(tests))
+		require.Equal(t, []string{impactReasonName, impactReasonPackage}, tests[0].Reasons)
+		require.Equal(t, []string{impactReasonPackage}, tests[1].Reasons)
+		require.Equal(t, []string{impactReasonFile, impactReasonName, impactReasonPackage}, tests[2].Reasons)
+		require.Equal(t, "./spec/models/user_spec.rb", tests[2].File)
+	})
+
+	t.Run("Impact map", func(t *testing.T) {
+		mapping := impactMap{
+			"tests.test_auth#test_login": {"app/auth/**"},
+			"com.acme.CartTest":          {"src/main/java/com/acme/Cart.java"},
+		}
+
+		tests := impactedTests(report, []string{"app/auth/session.py", "src/main/java/com/acme/Checkout.java"}, mapping)
+
+		// the mapped tests are not guessed from their packages
+		require.Equal(t, []string{"tests.test_auth#test_login"}, selectors(tests))
+		require.Equal(t, []string{impactReasonMap}, tests[0].Reasons)
+	})
+}
+
+func TestSourceStem(t *testing.T) {
+	for name, expected := range map[string]string{
+		"cart.go":       "cart",
+		"cart_test.go":  "cart",
+		"cart.spec.ts":  "cart",
+		"CartTest.java": "cart",
+		"CartIT.java":   "cart",
+		"test_cart.py":  "cart",
+		"TestCart":      "cart",
+		"Testing.java":  "testing",
+		"Test.java":     "test",
+	} {
+		require.Equal(t, expected, sourceStem(name), name)
+	}
+}
+
+func TestMatchesAnySource(t *testing.T) {
+	require.True(t, matchesAnySource([]string{"./src/cart.go"}, "src/cart.go"))
+	require.True(t, matchesAnySource([]string{"src/*.go"}, "src/cart.go"))
+	require.True(t, matchesAnySource([]string{"src/"}, "src/cart/cart.go"))
+	require.True(t, matchesAnySource([]string{"src/**"}, "src/cart/cart.go"))
+	require.True(t, matchesAnySource([]string{"com/acme/Cart.java"}, "src/main/java/com/acme/Cart.java"))
+	require.False(t, matchesAnySource([]string{"src/*.go"}, "src/cart/cart.go"))
+	require.False(t, matchesAnySource([]string{"lib/"}, "src/cart.go"))
+}
+
+func TestWriteImpactedTests(t *testing.T) {
+	tests := []impactedTest{
+		{Selector: "com.acme.CartTest#testTotal", Suite: "cart", Classname: "com.acme.CartTest", Name: "testTotal", Reasons: []string{impactReasonName}},
+		{Selector: "TestLogin", Suite: "auth", Name: "TestLogin", File: "auth_test.go", Reasons: []string{impactReasonFile}},
+	}
+
+	t.Run("Text", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "tests.txt")
+		require.NoError(t, writeImpactedTests(path, tests))
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "com.acme.CartTest#testTotal\nTestLogin\n", string(data))
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "tests.json")
+		require.NoError(t, writeImpactedTests(path, tests))
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.JSONEq(t, `[
+			{"selector": "com.acme.CartTest#testTotal", "suite": "cart", "classname": "com.acme.CartTest", "name": "testTotal", "reasons": ["name"]},
+			{"selector": "TestLogin", "suite": "auth", "name": "TestLogin", "file": "auth_test.go", "reasons": ["file"]}
+		]`, string(data))
+	})
+
+	t.Run("Without impacted tests", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "tests.txt")
+		require.NoError(t, writeImpactedTests(path, nil))
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Empty(t, data)
+	})
+}
+
+func TestLoadImpactMap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "impact.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"com.acme.CartTest": ["src/main/java/com/acme/Cart.java"]}`), 0o644))
+
+	mapping, err := loadImpactMap(path)
+	require.NoError(t, err)
+	require.Equal(t, impactMap{"com.acme.CartTest": {"src/main/java/com/acme/Cart.java"}}, mapping)
+
+	_, err = loadImpactMap(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.Error(t, err)
+}
@@ -0,0 +1,5 @@
+// Package ingest defines the gRPC service of junit2otlp in server mode, so the build tools can submit their test
+// reports programmatically, getting the ID of the trace of each report.
+package ingest
+
+//go:generate protoc --proto_path=.. --go_out=.. --go_opt=paths=source_relative --go-grpc_out=.. --go-grpc_opt=paths=source_relative ingest/ingest.proto
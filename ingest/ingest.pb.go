@@ -0,0 +1,223 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.3
+// 	protoc        (unknown)
+// source: ingest/ingest.proto
+
+package ingest
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// SubmitTestReportRequest a report, with its metadata.
+type SubmitTestReportRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The raw bytes of the report, in any of the formats supported by junit2otlp.
+	Report []byte `protobuf:"bytes,1,opt,name=report,proto3" json:"report,omitempty"`
+	// The tenant of the report, if the server is multi-tenant.
+	Tenant string `protobuf:"bytes,2,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	// The attributes added to the spans of the report.
+	Attributes    map[string]string `protobuf:"bytes,3,rep,name=attributes,proto3" json:"attributes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitTestReportRequest) Reset() {
+	*x = SubmitTestReportRequest{}
+	mi := &file_ingest_ingest_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitTestReportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitTestReportRequest) ProtoMessage() {}
+
+func (x *SubmitTestReportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ingest_ingest_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitTestReportRequest.ProtoReflect.Descriptor instead.
+func (*SubmitTestReportRequest) Descriptor() ([]byte, []int) {
+	return file_ingest_ingest_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SubmitTestReportRequest) GetReport() []byte {
+	if x != nil {
+		return x.Report
+	}
+	return nil
+}
+
+func (x *SubmitTestReportRequest) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+func (x *SubmitTestReportRequest) GetAttributes() map[string]string {
+	if x != nil {
+		return x.Attributes
+	}
+	return nil
+}
+
+// SubmitTestReportResponse the result of queueing a report.
+type SubmitTestReportResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The hex encoded ID of the trace of the report.
+	TraceId       string `protobuf:"bytes,1,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitTestReportResponse) Reset() {
+	*x = SubmitTestReportResponse{}
+	mi := &file_ingest_ingest_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitTestReportResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitTestReportResponse) ProtoMessage() {}
+
+func (x *SubmitTestReportResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ingest_ingest_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitTestReportResponse.ProtoReflect.Descriptor instead.
+func (*SubmitTestReportResponse) Descriptor() ([]byte, []int) {
+	return file_ingest_ingest_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SubmitTestReportResponse) GetTraceId() string {
+	if x != nil {
+		return x.TraceId
+	}
+	return ""
+}
+
+var File_ingest_ingest_proto protoreflect.FileDescriptor
+
+var file_ingest_ingest_proto_rawDesc = []byte{
+	0x0a, 0x13, 0x69, 0x6e, 0x67, 0x65, 0x73, 0x74, 0x2f, 0x69, 0x6e, 0x67, 0x65, 0x73, 0x74, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x14, 0x6a, 0x75, 0x6e, 0x69, 0x74, 0x32, 0x6f, 0x74, 0x6c,
+	0x70, 0x2e, 0x69, 0x6e, 0x67, 0x65, 0x73, 0x74, 0x2e, 0x76, 0x31, 0x22, 0xe7, 0x01, 0x0a, 0x17,
+	0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x54, 0x65, 0x73, 0x74, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x70, 0x6f, 0x72,
+	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x72, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x12,
+	0x16, 0x0a, 0x06, 0x74, 0x65, 0x6e, 0x61, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x06, 0x74, 0x65, 0x6e, 0x61, 0x6e, 0x74, 0x12, 0x5d, 0x0a, 0x0a, 0x61, 0x74, 0x74, 0x72, 0x69,
+	0x62, 0x75, 0x74, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x3d, 0x2e, 0x6a, 0x75,
+	0x6e, 0x69, 0x74, 0x32, 0x6f, 0x74, 0x6c, 0x70, 0x2e, 0x69, 0x6e, 0x67, 0x65, 0x73, 0x74, 0x2e,
+	0x76, 0x31, 0x2e, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x54, 0x65, 0x73, 0x74, 0x52, 0x65, 0x70,
+	0x6f, 0x72, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x41, 0x74, 0x74, 0x72, 0x69,
+	0x62, 0x75, 0x74, 0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0a, 0x61, 0x74, 0x74, 0x72,
+	0x69, 0x62, 0x75, 0x74, 0x65, 0x73, 0x1a, 0x3d, 0x0a, 0x0f, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62,
+	0x75, 0x74, 0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x35, 0x0a, 0x18, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x54,
+	0x65, 0x73, 0x74, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x19, 0x0a, 0x08, 0x74, 0x72, 0x61, 0x63, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x07, 0x74, 0x72, 0x61, 0x63, 0x65, 0x49, 0x64, 0x32, 0x82, 0x01, 0x0a,
+	0x0d, 0x49, 0x6e, 0x67, 0x65, 0x73, 0x74, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x71,
+	0x0a, 0x10, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x54, 0x65, 0x73, 0x74, 0x52, 0x65, 0x70, 0x6f,
+	0x72, 0x74, 0x12, 0x2d, 0x2e, 0x6a, 0x75, 0x6e, 0x69, 0x74, 0x32, 0x6f, 0x74, 0x6c, 0x70, 0x2e,
+	0x69, 0x6e, 0x67, 0x65, 0x73, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74,
+	0x54, 0x65, 0x73, 0x74, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x2e, 0x2e, 0x6a, 0x75, 0x6e, 0x69, 0x74, 0x32, 0x6f, 0x74, 0x6c, 0x70, 0x2e, 0x69,
+	0x6e, 0x67, 0x65, 0x73, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x54,
+	0x65, 0x73, 0x74, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x42, 0x29, 0x5a, 0x27, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
+	0x6d, 0x64, 0x65, 0x6c, 0x61, 0x70, 0x65, 0x6e, 0x79, 0x61, 0x2f, 0x6a, 0x75, 0x6e, 0x69, 0x74,
+	0x32, 0x6f, 0x74, 0x6c, 0x70, 0x2f, 0x69, 0x6e, 0x67, 0x65, 0x73, 0x74, 0x62, 0x06, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_ingest_ingest_proto_rawDescOnce sync.Once
+	file_ingest_ingest_proto_rawDescData = file_ingest_ingest_proto_rawDesc
+)
+
+func file_ingest_ingest_proto_rawDescGZIP() []byte {
+	file_ingest_ingest_proto_rawDescOnce.Do(func() {
+		file_ingest_ingest_proto_rawDescData = protoimpl.X.CompressGZIP(file_ingest_ingest_proto_rawDescData)
+	})
+	return file_ingest_ingest_proto_rawDescData
+}
+
+var file_ingest_ingest_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_ingest_ingest_proto_goTypes = []any{
+	(*SubmitTestReportRequest)(nil),  // 0: junit2otlp.ingest.v1.SubmitTestReportRequest
+	(*SubmitTestReportResponse)(nil), // 1: junit2otlp.ingest.v1.SubmitTestReportResponse
+	nil,                              // 2: junit2otlp.ingest.v1.SubmitTestReportRequest.AttributesEntry
+}
+var file_ingest_ingest_proto_depIdxs = []int32{
+	2, // 0: junit2otlp.ingest.v1.SubmitTestReportRequest.attributes:type_name -> junit2otlp.ingest.v1.SubmitTestReportRequest.AttributesEntry
+	0, // 1: junit2otlp.ingest.v1.IngestService.SubmitTestReport:input_type -> junit2otlp.ingest.v1.SubmitTestReportRequest
+	1, // 2: junit2otlp.ingest.v1.IngestService.SubmitTestReport:output_type -> junit2otlp.ingest.v1.SubmitTestReportResponse
+	2, // [2:3] is the sub-list for method output_type
+	1, // [1:2] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_ingest_ingest_proto_init() }
+func file_ingest_ingest_proto_init() {
+	if File_ingest_ingest_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_ingest_ingest_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_ingest_ingest_proto_goTypes,
+		DependencyIndexes: file_ingest_ingest_proto_depIdxs,
+		MessageInfos:      file_ingest_ingest_proto_msgTypes,
+	}.Build()
+	File_ingest_ingest_proto = out.File
+	file_ingest_ingest_proto_rawDesc = nil
+	file_ingest_ingest_proto_goTypes = nil
+	file_ingest_ingest_proto_depIdxs = nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ingestInputs ingests the reports in the given files or directories, merging them into one report.
+// The directories are walked looking for XML, JSON and TRX files, i.e. the Firebase Test Lab result bundles
+func ingestInputs(paths []string) (*junitReport, error) {
+	reports := []*junitReport{}
+
+	for _, path := range paths {
+		files, walked, err := inputFiles(strings.TrimSpace(path))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+
+			// the directories can contain other JSON files, i.e. the metadata of the result bundles
+			if walked && !isReportContent(data) {
+				continue
+			}
+
+			report, err := parseReport(formatFlag, file, data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to ingest %s: %w", file, err)
+			}
+
+			reports = append(reports, report)
+		}
+	}
+
+	return mergeReports(reports), nil
+}
+
+// isReportContent checks if the content of a file is a report in a supported format
+func isReportContent(data []byte) bool {
+	if formatFlag != "" && formatFlag != reportFormatAuto {
+		return true
+	}
+
+	_, err := detectReportFormat(data)
+	return err == nil
+}
+
+// inputFiles returns the report files in the path, in lexical order, and true as the path is a directory, or the path
+// itself if it's a file. See scanDirectory
+func inputFiles(path string) ([]string, bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !info.IsDir() {
+		return []string{path}, false, nil
+	}
+
+	files, err := scanDirectory(path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return files, true, nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mdelapenya/junit2otlp/collectortest"
+	"github.com/stretchr/testify/require"
+)
+
+// sampleSpans the spans of TEST-sample.xml: the run, its 3 suites and their 11 test cases
+const sampleSpans = 15
+
+// exportSample converts TEST-sample.xml with the exporters of the environment, waiting for the collector to write
+// its spans and metrics
+func exportSample(t *testing.T, collector *collectortest.Collector) *collectortest.Report {
+	t.Helper()
+	t.Setenv("BRANCH", "main")
+	t.Setenv("OTEL_SERVICE_NAME", "junit2otlp-integration")
+
+	require.NoError(t, Main(context.Background(), &TestReader{testFile: "TEST-sample.xml"}))
+
+	return collector.WaitForReport(t, func(r *collectortest.Report) bool {
+		return len(r.Spans()) == sampleSpans && r.Metric(TotalTestsCount) != nil
+	})
+}
+
+func Test_Integration_Export(t *testing.T) {
+	for _, protocol := range []string{collectortest.ProtocolGRPC, collectortest.ProtocolHTTP} {
+		t.Run(protocol, func(t *testing.T) {
+			collector := collectortest.Run(t, collectortest.WithHeaders("x-team"))
+			collector.SetEnv(t, protocol)
+			t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "x-team=platform")
+
+			report := exportSample(t, collector)
+
+			testCase := report.Span("TestCheckConfigDirsCreatesWorkspaceAtHome")
+			require.NotNil(t, testCase)
+			value, ok := collectortest.Attribute(testCase.GetAttributes(), TestClassName)
+			require.True(t, ok)
+			require.Equal(t, "github.com/elastic/e2e-testing/cli/config", value)
+
+			value, ok = collectortest.Attribute(testCase.Resource, "service.name")
+			require.True(t, ok)
+			require.Equal(t, "junit2otlp-integration", value)
+
+			// all the spans belong to the same trace
+			for _, span := range report.Spans() {
+				require.Equal(t, testCase.TraceIDHex(), span.TraceIDHex())
+			}
+
+			// the headers reach the collector with both signals
+			value, ok = collectortest.Attribute(testCase.Resource, collectortest.HeaderAttributePrefix+"x-team")
+			require.True(t, ok)
+			require.Equal(t, "platform", value)
+
+			metric := report.Metric(TotalTestsCount)
+			value, ok = collectortest.Attribute(metric.Resource, collectortest.HeaderAttributePrefix+"x-team")
+			require.True(t, ok)
+			require.Equal(t, "platform", value)
+
+			// the tool exports no logs
+			require.Empty(t, report.LogRecords())
+		})
+	}
+}
+
+func Test_Integration_TLS(t *testing.T) {
+	for _, protocol := range []string{collectortest.ProtocolGRPC, collectortest.ProtocolHTTP} {
+		t.Run(protocol, func(t *testing.T) {
+			collector := collectortest.Run(t, collectortest.WithTLS())
+			collector.SetEnv(t, protocol)
+
+			report := exportSample(t, collector)
+			require.Len(t, report.Spans(), sampleSpans)
+		})
+	}
+}
+
+func Test_Integration_Jaeger(t *testing.T) {
+	collector := collectortest.Run(t, collectortest.WithJaeger())
+	collector.SetEnv(t, collectortest.ProtocolGRPC)
+
+	report := exportSample(t, collector)
+
+	// Jaeger trims the leading zeros of the IDs
+	traceID := strings.TrimLeft(report.Spans()[0].TraceIDHex(), "0")
+	require.Eventually(t, func() bool {
+		traces, err := collector.JaegerTraces(context.Background(), "junit2otlp-integration")
+		return err == nil && len(traces) == 1 && len(traces[0].Spans) == sampleSpans && strings.TrimLeft(traces[0].TraceID, "0") == traceID
+	}, 30*time.Second, time.Second, "the trace was not stored by Jaeger")
+}
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/joshdk/go-junit"
+	"github.com/mdelapenya/junit2otlp/live"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const liveCommand = "live"
+const defaultLiveAddress = "tcp://localhost:4320"
+
+// liveSuite a suite of the live results, with its span open until the suite or the run ends
+type liveSuite struct {
+	suite      junit.Suite
+	attributes []attribute.KeyValue
+	ctx        context.Context
+	span       trace.Span
+}
+
+// liveServer creates the spans of the test cases as their results are streamed, under the root span of the run
+type liveServer struct {
+	mu          sync.Mutex
+	ctx         context.Context
+	tracer      trace.Tracer
+	testMetrics *testCaseMetrics
+	suites      map[string]*liveSuite
+	all         []*liveSuite
+	done        chan struct{}
+	endOnce     sync.Once
+}
+
+func newLiveServer(ctx context.Context, tracer trace.Tracer, testMetrics *testCaseMetrics) *liveServer {
+	return &liveServer{
+		ctx:         ctx,
+		tracer:      tracer,
+		testMetrics: testMetrics,
+		suites:      map[string]*liveSuite{},
+		done:        make(chan struct{}),
+	}
+}
+
+// handle processes an event of the stream
+func (s *liveServer) handle(event live.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch event.Type {
+	case live.EventTest:
+		return s.handleTest(event)
+	case live.EventSuiteEnd:
+		s.endSuite(event.Suite)
+		return nil
+	case live.EventEnd:
+		s.endOnce.Do(func() { close(s.done) })
+		return nil
+	default:
+		return fmt.Errorf("unknown event type %q", event.Type)
+	}
+}
+
+func (s *liveServer) handleTest(event live.Event) error {
+	status := junit.Status(event.Status)
+	switch status {
+	case junit.StatusPassed, junit.StatusFailed, junit.StatusError, junit.StatusSkipped:
+	default:
+		return fmt.Errorf("unknown status %q of the test %s", event.Status, event.Name)
+	}
+
+	test := junit.Test{
+		Name:       event.Name,
+		Classname:  event.Classname,
+		Duration:   time.Duration(event.Duration * float64(time.Second)),
+		Status:     status,
+		Message:    event.Message,
+		SystemOut:  event.SystemOut,
+		SystemErr:  event.SystemErr,
+		Properties: event.Props,
+	}
+	if status == junit.StatusFailed || status == junit.StatusError {
+		test.Error = junit.Error{Message: event.Message, Body: event.Error}
+	}
+
+	s.addTest(event.Suite, test, time.Now())
+
+	return nil
+}
+
+// startSuite returns the suite, starting its span at the start time if it's not started yet
+func (s *liveServer) startSuite(name string, startTime time.Time) *liveSuite {
+	ls, ok := s.suites[name]
+	if !ok {
+		suite := junit.Suite{Name: name}
+		ls = &liveSuite{suite: suite, attributes: suiteSpanAttributes(suite, nil, nil)}
+		ls.ctx, ls.span = s.tracer.Start(s.ctx, suite.Name, trace.WithAttributes(ls.attributes...), trace.WithTimestamp(startTime))
+
+		s.suites[name] = ls
+		s.all = append(s.all, ls)
+	}
+
+	return ls
+}
+
+// addTest creates the span of the completed test case in its suite, ending at the end time
+func (s *liveServer) addTest(suiteName string, test junit.Test, endTime time.Time) {
+	scrubTest(&test, appConfig.Scrubbing.secretPatterns(scrubSecretsFlag))
+
+	ls := s.startSuite(suiteName, endTime.Add(-test.Duration))
+	ls.suite.Tests = append(ls.suite.Tests, test)
+	createTestSpan(ls.ctx, s.tracer, s.testMetrics, ls.suite, ls.attributes, test, suiteTimeout(ls.suite), endTime)
+}
+
+// endSuite ends the span of the suite, if it's started
+func (s *liveServer) endSuite(name string, options ...trace.SpanEndOption) {
+	if ls, ok := s.suites[name]; ok {
+		endLiveSuite(ls, options...)
+		delete(s.suites, name)
+	}
+}
+
+// endLiveSuite ends the span of the suite, with the totals of its test cases
+func endLiveSuite(ls *liveSuite, options ...trace.SpanEndOption) {
+	ls.suite.Aggregate()
+	ls.span.SetAttributes(
+		attribute.Key(TestsDuration).Int64(ls.suite.Totals.Duration.Milliseconds()),
+		attribute.Key(ErrorTestsCount).Int(ls.suite.Totals.Error),
+		attribute.Key(FailedTestsCount).Int(ls.suite.Totals.Failed),
+		attribute.Key(PassedTestsCount).Int(ls.suite.Totals.Passed),
+		attribute.Key(SkippedTestsCount).Int(ls.suite.Totals.Skipped),
+		attribute.Key(TotalTestsCount).Int(ls.suite.Totals.Tests),
+	)
+	ls.span.End(options...)
+}
+
+// consume reads the events of the stream, one JSON object per line, skipping the invalid ones
+func (s *liveServer) consume(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		event := live.Event{}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			log.Printf("skipping invalid live event: %v", err)
+			continue
+		}
+
+		if err := s.handle(event); err != nil {
+			log.Printf("skipping live event: %v", err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		log.Printf("failed to read the live events: %v", err)
+	}
+}
+
+// close ends the spans of the suites still open, returning the report with all the test cases received
+func (s *liveServer) close() *junitReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	open := make([]string, 0, len(s.suites))
+	for name := range s.suites {
+		open = append(open, name)
+	}
+	sort.Strings(open)
+
+	for _, name := range open {
+		endLiveSuite(s.suites[name])
+	}
+
+	report := &junitReport{format: reportFormatLive}
+	for _, ls := range s.all {
+		report.suites = append(report.suites, ls.suite)
+	}
+	s.suites = map[string]*liveSuite{}
+
+	return report
+}
+
+// listen accepts the streams of events at the address: connections to a TCP or Unix socket, or the writers
+// of a named pipe, which is reopened after each writer closes it
+func (s *liveServer) listen(address string) error {
+	network, addr, err := live.ParseAddress(address)
+	if err != nil {
+		return err
+	}
+
+	if network == live.NetworkPipe {
+		info, err := os.Stat(addr)
+		if err != nil {
+			return fmt.Errorf("the named pipe does not exist, create it with mkfifo: %w", err)
+		}
+		if info.Mode()&os.ModeNamedPipe == 0 {
+			return fmt.Errorf("%s is not a named pipe", addr)
+		}
+
+		go func() {
+			for {
+				// opening a named pipe blocks until a writer opens it
+				file, err := os.Open(addr)
+				if err != nil {
+					log.Printf("failed to open the named pipe: %v", err)
+					return
+				}
+
+				s.consume(file)
+				file.Close()
+			}
+		}()
+
+		return nil
+	}
+
+	listener, err := net.Listen(network, addr)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		<-s.done
+		listener.Close()
+	}()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			if err != nil {
+				log.Printf("failed to accept a live connection: %v", err)
+				continue
+			}
+
+			go func() {
+				defer conn.Close()
+				s.consume(conn)
+			}()
+		}
+	}()
+
+	return nil
+}
+
+// runLive parses the flags of the live mode and runs it until the end event is received, or the process is
+// interrupted, exporting the span of each test case as soon as its result is received
+func runLive(args []string) {
+	if err := resolveFlags(flag.CommandLine, args); err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := serveLive(ctx); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func serveLive(ctx context.Context) error {
+	return runLiveServer(ctx, func(server *liveServer) error {
+		if err := server.listen(liveListenFlag); err != nil {
+			return err
+		}
+		log.Printf("listening for live results at %s", liveListenFlag)
+
+		select {
+		case <-server.done:
+		case <-ctx.Done():
+			log.Printf("interrupted, ending the live run")
+		}
+
+		return nil
+	})
+}
+
+// runLiveServer creates the root span of the run, and runs the live server until the run function returns,
+// ending the spans of the suites still open and the root span with the summary of the run
+func runLiveServer(ctx context.Context, run func(server *liveServer) error) error {
+	otlpSrvName := getOtlpServiceName()
+
+	cfg, err := loadConfig(configFlag)
+	if err != nil {
+		return err
+	}
+	appConfig = cfg
+
+	ctx = initOtelContext(ctx)
+
+	if sessionID := getSessionID(nil); sessionID != "" {
+		runtimeAttributes = append(runtimeAttributes, attribute.Key(SessionID).String(sessionID))
+	}
+
+	if err := addAdditionalAttributes(); err != nil {
+		return err
+	}
+
+	if scm := GetScm(repositoryPathFlag); scm != nil {
+		runtimeAttributes = append(runtimeAttributes, scm.contributeAttributes()...)
+	}
+
+	res, err := newResource(ctx, otlpSrvName, getOtlpServiceVersion())
+	if err != nil {
+		return err
+	}
+
+	diagnostics := &exportDiagnostics{}
+	otel.SetErrorHandler(diagnostics)
+
+	outputs, err := newSpanOutputs()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := outputs.shutdown(context.Background()); err != nil {
+			otel.Handle(err)
+		}
+	}()
+
+	tracesProvides, err := initTracerProvider(ctx, res, diagnostics, outputs)
+	if err != nil {
+		return err
+	}
+	defer tracesProvides.Shutdown(context.Background())
+
+	meterProvider, err := initMetricsProvider(ctx, res, outputs)
+	if err != nil {
+		return fmt.Errorf("failed to initialise pusher: %v", err)
+	}
+	defer meterProvider.Shutdown(context.Background())
+
+	scopeName := scopeNameFlag
+	if scopeName == "" {
+		scopeName = otlpSrvName
+	}
+
+	tracer := tracesProvides.Tracer(scopeName, trace.WithInstrumentationVersion(scopeVersionFlag))
+	meter := meterProvider.Meter(scopeName, metric.WithInstrumentationVersion(scopeVersionFlag))
+	testMetrics := newTestCaseMetrics(meter, perTestMetricsLimit())
+	defer droppedData.flush(ctx, meter)
+
+	// the root span is not bound to the signals, so it's exported when the process is interrupted
+	ciRun := detectCIRun()
+	traceName := runName(traceNameFlag, otlpSrvName, ciRun)
+	rootAttributes := append(append([]attribute.KeyValue{}, runtimeAttributes...), runNameAttributes(traceName, ciRun)...)
+	rootCtx, rootSpan := tracer.Start(context.WithoutCancel(ctx), traceName, trace.WithAttributes(rootAttributes...))
+
+	server := newLiveServer(rootCtx, tracer, testMetrics)
+	if err := run(server); err != nil {
+		return err
+	}
+
+	report := server.close()
+	rootSpan.SetAttributes(summarize(report).attributes()...)
+	rootSpan.End()
+
+	return nil
+}
@@ -0,0 +1,165 @@
+// Package live streams the results of long-running integration tests (i.e. Terratest) to junit2otlp running
+// in live mode, as each test case completes, so that their spans are exported in real time instead of in one
+// post-hoc export of the report.
+package live
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// the types of the events
+const (
+	EventTest     = "test"
+	EventSuiteEnd = "suite_end"
+	EventEnd      = "end"
+)
+
+// the statuses of the test cases, the same as in jUnit reports
+const (
+	StatusPassed  = "passed"
+	StatusFailed  = "failed"
+	StatusError   = "error"
+	StatusSkipped = "skipped"
+)
+
+// the networks of the addresses
+const (
+	NetworkTCP  = "tcp"
+	NetworkUnix = "unix"
+	NetworkPipe = "pipe"
+)
+
+// Event an event of the stream, encoded as a JSON line: a completed test case, the end of a suite,
+// or the end of the run
+type Event struct {
+	Type      string            `json:"type"`
+	Suite     string            `json:"suite,omitempty"`
+	Name      string            `json:"name,omitempty"`
+	Classname string            `json:"classname,omitempty"`
+	Status    string            `json:"status,omitempty"`
+	Duration  float64           `json:"duration,omitempty"`
+	Message   string            `json:"message,omitempty"`
+	Error     string            `json:"error,omitempty"`
+	SystemOut string            `json:"systemout,omitempty"`
+	SystemErr string            `json:"systemerr,omitempty"`
+	Props     map[string]string `json:"properties,omitempty"`
+}
+
+// ParseAddress parses the address of the live mode: "tcp://host:port", "unix:///path/to/socket",
+// or the path of a named pipe, returning its network and address
+func ParseAddress(address string) (string, string, error) {
+	switch {
+	case strings.HasPrefix(address, NetworkTCP+"://"):
+		return NetworkTCP, strings.TrimPrefix(address, NetworkTCP+"://"), nil
+	case strings.HasPrefix(address, NetworkUnix+"://"):
+		return NetworkUnix, strings.TrimPrefix(address, NetworkUnix+"://"), nil
+	case strings.Contains(address, "://"):
+		return "", "", fmt.Errorf("unsupported address %q: valid schemes are tcp and unix", address)
+	case address == "":
+		return "", "", fmt.Errorf("the address is empty")
+	default:
+		return NetworkPipe, address, nil
+	}
+}
+
+// TB the subset of testing.TB used to track the test cases
+type TB interface {
+	Name() string
+	Failed() bool
+	Skipped() bool
+	Cleanup(func())
+}
+
+// Reporter sends the events to junit2otlp running in live mode. It's safe for concurrent use,
+// i.e. from parallel tests
+type Reporter struct {
+	mu      sync.Mutex
+	closer  func() error
+	encoder *json.Encoder
+}
+
+// Dial connects to junit2otlp running in live mode at the given address
+func Dial(address string) (*Reporter, error) {
+	network, addr, err := ParseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	if network == NetworkPipe {
+		file, err := os.OpenFile(addr, os.O_WRONLY, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open the named pipe %s: %w", addr, err)
+		}
+
+		return newReporter(file, file.Close), nil
+	}
+
+	conn, err := net.Dial(network, addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", address, err)
+	}
+
+	return newReporter(conn, conn.Close), nil
+}
+
+func newReporter(writer io.Writer, closer func() error) *Reporter {
+	return &Reporter{closer: closer, encoder: json.NewEncoder(writer)}
+}
+
+// Send sends an event
+func (r *Reporter) Send(event Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.encoder.Encode(event)
+}
+
+// Test sends a completed test case of the suite
+func (r *Reporter) Test(suite string, event Event) error {
+	event.Type = EventTest
+	event.Suite = suite
+
+	return r.Send(event)
+}
+
+// Track sends the result of the test when it completes, with its duration, from a cleanup function
+func (r *Reporter) Track(t TB, suite string) {
+	start := time.Now()
+
+	t.Cleanup(func() {
+		status := StatusPassed
+		if t.Failed() {
+			status = StatusFailed
+		} else if t.Skipped() {
+			status = StatusSkipped
+		}
+
+		_ = r.Test(suite, Event{Name: t.Name(), Status: status, Duration: time.Since(start).Seconds()})
+	})
+}
+
+// EndSuite sends the end of the suite, ending its span
+func (r *Reporter) EndSuite(suite string) error {
+	return r.Send(Event{Type: EventSuiteEnd, Suite: suite})
+}
+
+// End sends the end of the run, ending the root span, and closes the connection
+func (r *Reporter) End() error {
+	if err := r.Send(Event{Type: EventEnd}); err != nil {
+		return err
+	}
+
+	return r.Close()
+}
+
+// Close closes the connection, without ending the run, so other processes can keep reporting to it
+func (r *Reporter) Close() error {
+	return r.closer()
+}
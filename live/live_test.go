@@ -0,0 +1,85 @@
+package live
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		network string
+		addr    string
+	}{
+		{"tcp://localhost:4320", NetworkTCP, "localhost:4320"},
+		{"unix:///tmp/junit2otlp.sock", NetworkUnix, "/tmp/junit2otlp.sock"},
+		{"/tmp/junit2otlp.fifo", NetworkPipe, "/tmp/junit2otlp.fifo"},
+	}
+
+	for _, tt := range tests {
+		network, addr, err := ParseAddress(tt.address)
+		require.NoError(t, err)
+		require.Equal(t, tt.network, network)
+		require.Equal(t, tt.addr, addr)
+	}
+
+	_, _, err := ParseAddress("http://localhost:4320")
+	require.Error(t, err)
+}
+
+type fakeTB struct {
+	name     string
+	failed   bool
+	cleanups []func()
+}
+
+func (f *fakeTB) Name() string      { return f.name }
+func (f *fakeTB) Failed() bool      { return f.failed }
+func (f *fakeTB) Skipped() bool     { return false }
+func (f *fakeTB) Cleanup(fn func()) { f.cleanups = append(f.cleanups, fn) }
+
+func TestReporter(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	events := make(chan Event)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			event := Event{}
+			if json.Unmarshal(scanner.Bytes(), &event) == nil {
+				events <- event
+			}
+		}
+		close(events)
+	}()
+
+	reporter, err := Dial("tcp://" + listener.Addr().String())
+	require.NoError(t, err)
+
+	tb := &fakeTB{name: "TestCluster", failed: true}
+	reporter.Track(tb, "terraform")
+	tb.cleanups[0]()
+	require.NoError(t, reporter.EndSuite("terraform"))
+	require.NoError(t, reporter.End())
+
+	test := <-events
+	require.Equal(t, EventTest, test.Type)
+	require.Equal(t, "terraform", test.Suite)
+	require.Equal(t, "TestCluster", test.Name)
+	require.Equal(t, StatusFailed, test.Status)
+
+	require.Equal(t, Event{Type: EventSuiteEnd, Suite: "terraform"}, <-events)
+	require.Equal(t, Event{Type: EventEnd}, <-events)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestLiveServer(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	meter := sdkmetric.NewMeterProvider().Meter("test")
+
+	tracer := tracerProvider.Tracer("test")
+	ctx, root := tracer.Start(context.Background(), "root")
+
+	server := newLiveServer(ctx, tracer, newTestCaseMetrics(meter, 0))
+	server.consume(strings.NewReader(strings.Join([]string{
+		`{"type":"test","suite":"terraform","name":"TestVpc","status":"passed","duration":12.5}`,
+		`not json`,
+		`{"type":"test","suite":"terraform","name":"TestCluster","status":"failed","duration":300,"message":"timed out"}`,
+		`{"type":"suite_end","suite":"terraform"}`,
+		`{"type":"test","suite":"smoke","name":"TestHealth","status":"unknown"}`,
+		`{"type":"test","suite":"smoke","name":"TestHealth","status":"skipped"}`,
+		`{"type":"end"}`,
+	}, "\n")))
+
+	select {
+	case <-server.done:
+	default:
+		t.Fatal("the end event should end the run")
+	}
+
+	// the spans of the test cases and the ended suites are exported as they complete
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 4)
+	require.Equal(t, "TestVpc", spans[0].Name)
+	require.Equal(t, int64(12500), spans[0].EndTime.Sub(spans[0].StartTime).Milliseconds())
+	require.Equal(t, "TestCluster", spans[1].Name)
+	require.Contains(t, spans[1].Attributes, attribute.Key(TestTimeout).Bool(true))
+	require.Equal(t, "terraform", spans[2].Name)
+	require.Contains(t, spans[2].Attributes, attribute.Key(FailedTestsCount).Int(1))
+	require.Equal(t, "TestHealth", spans[3].Name)
+
+	report := server.close()
+	root.End()
+
+	summary := summarize(report)
+	require.Equal(t, 3, summary.Total)
+	require.Equal(t, 2, summary.Suites)
+	require.Len(t, exporter.GetSpans(), 6)
+}
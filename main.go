@@ -1,12 +1,14 @@
 package main
 
 import (
-	"bufio"
+	"bytes"
 	"context"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"maps"
 	"os"
 	"runtime"
 	"slices"
@@ -16,8 +18,6 @@ import (
 	"github.com/joshdk/go-junit"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	"go.opentelemetry.io/otel/metric"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
@@ -32,37 +32,208 @@ var batchSizeFlag int
 var repositoryPathFlag string
 var serviceNameFlag string
 var serviceVersionFlag string
+var environmentFlag string
+var deploymentRingFlag string
 var traceNameFlag string
 var propertiesAllowedString string
 var additionalAttributes string
+var scmFileStatsFlag bool
+var spanKindFlag string
+var scopeNameFlag string
+var scopeVersionFlag string
+var detectResourcesFlag bool
+var metricsTemporalityFlag string
+var histogramBucketsFlag string
+var exemplarsFlag string
+var sessionIDFlag string
+var contextFileFlag string
+var emitContextFlag string
+var parentContextFlag string
+var verboseFlag bool
+var failOnExportErrorsFlag bool
+var configFlag string
+var testTimeoutFlag time.Duration
+var inputFlag string
+var formatFlag string
+var webDriverLinksFlag bool
+var liveListenFlag string
+var perTestMetricsFlag bool
+var perTestMetricsLimitFlag int
+var assertFlag string
+var otlpFlag bool
+var otlpEndpointFlag string
+var otlpProtocolFlag string
+var elasticsearchURLFlag string
+var elasticsearchIndexFlag string
+var zipkinURLFlag string
+var datadogFlag bool
+var clickHouseURLFlag string
+var clickHouseTableFlag string
+var bigQueryTableFlag string
+var outputFlag string
+var outputFileFlag string
+var htmlReportFlag string
+var traceURLFlag string
+var assumeTimezoneFlag string
+var mergePolicyFlag string
+var serverAddressFlag string
+var queueSizeFlag int
+var queueMaxMemoryFlag int64
+var spoolDirFlag string
+var spoolMaxSizeFlag int64
+var grpcAddressFlag string
+var failOnFlag string
+var anchorFlag string
+var shapeFlag string
+var traceLinkFlag string
+var stepSummaryFlag bool
+var annotateFlag string
+var impactedTestsOutputFlag string
+var impactMapFlag string
+var stateFlag string
+var stateHistoryFlag int
+var testFlag string
+var trendsFormatFlag string
+var baseFlag string
+var headFlag string
+var strictnessFlag string
+var schemaFlag string
+var strictFlag bool
+var snapshotFlag string
+var nameLimitFlag int
+var longNamesFlag string
+var logDroppedFlag string
+var scrubSecretsFlag bool
+var includeSuiteFlag string
+var excludeSuiteFlag string
+var includeTestFlag string
+var onlyFailuresFlag bool
+var withContextFlag int
+var deployTracesFlag string
+var costPerMinuteFlag float64
+var runnerTypeFlag string
+var overheadThresholdFlag time.Duration
+var idGeneratorFlag string
+var dashboardBackendFlag string
+var systemLogFlag string
+var bundleFlag string
+var signingKeyFlag string
+var verifyKeyFlag string
+var payloadDigestFlag bool
+var attestationFlag string
+var auditLogFlag string
+var maxClassificationFlag string
 
 const propertiesAllowAll = "all"
 
 var runtimeAttributes []attribute.KeyValue
-var propsAllowed []string
+var appConfig = &config{}
+
+// testCoverage the source files covered by each test, from the state
+var testCoverage impactMap
 
 func init() {
 	flag.IntVar(&batchSizeFlag, "batch-size", defaultMaxBatchSize, "Maximum export batch size allowed when creating a BatchSpanProcessor")
 	flag.StringVar(&repositoryPathFlag, "repository-path", getDefaultwd(), "Path to the SCM repository to be read")
 	flag.StringVar(&serviceNameFlag, "service-name", "", "OpenTelemetry Service Name to be used when sending traces and metrics for the jUnit report")
 	flag.StringVar(&serviceVersionFlag, "service-version", "", "OpenTelemetry Service Version to be used when sending traces and metrics for the jUnit report")
-	flag.StringVar(&traceNameFlag, "trace-name", Junit2otlp, "OpenTelemetry Trace Name to be used when sending traces and metrics for the jUnit report")
+	flag.StringVar(&environmentFlag, "environment", "", "Environment of the tests, i.e. staging or production, set as the deployment.environment resource attribute so the runs of each environment are separated in the dashboards")
+	flag.StringVar(&deploymentRingFlag, "deployment-ring", "", "Deployment ring, or release train, of the tests, i.e. canary, set as the deployment.ring resource attribute")
+	flag.StringVar(&traceNameFlag, "trace-name", traceNameAuto, "OpenTelemetry Trace Name to be used when sending traces and metrics for the jUnit report: a name, a template with the {repository}, {branch}, {run_number}, {pipeline} and {service} placeholders of the run in the CI system, or auto, which names it repository@branch #run_number in the CI systems, and junit2otlp outside them")
 	flag.StringVar(&propertiesAllowedString, "properties-allowed", propertiesAllowAll, "Comma separated list of properties to be allowed in the jUnit report")
 	flag.StringVar(&additionalAttributes, "additional-attributes", "", "Comma separated list of attributes to be added to the jUnit report")
+	flag.BoolVar(&scmFileStatsFlag, "scm-file-stats", false, "Add the per-file stats of the changeset (added and deleted lines for each file) for change requests")
+	flag.StringVar(&spanKindFlag, "span-kind", "server", "OpenTelemetry Span Kind to be used for the root span of the jUnit report: internal, server, client, producer or consumer")
+	flag.StringVar(&scopeNameFlag, "scope-name", "", "OpenTelemetry Instrumentation Scope Name to be used when sending traces and metrics for the jUnit report. Defaults to the service name")
+	flag.BoolVar(&detectResourcesFlag, "detect-resources", false, "Detect the host, OS, process and container resource attributes, including the ones in the OTEL_RESOURCE_ATTRIBUTES environment variable")
+	flag.StringVar(&metricsTemporalityFlag, "metrics-temporality", "", "Aggregation temporality of the metrics: cumulative, delta or lowmemory. Defaults to the OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE environment variable, or cumulative")
+	flag.StringVar(&histogramBucketsFlag, "histogram-buckets", "", "Comma separated list of explicit bucket boundaries, in milliseconds, for the test duration histogram")
+	flag.StringVar(&exemplarsFlag, "exemplars", "", "Exemplar filter for the test duration histogram, linking each datapoint to a test span: trace_based, always_on or always_off. Defaults to the OTEL_METRICS_EXEMPLAR_FILTER environment variable, or trace_based")
+	flag.StringVar(&sessionIDFlag, "session-id", "", "Session shared by multiple invocations within one pipeline. Defaults to an ID derived from the CI run")
+	flag.StringVar(&contextFileFlag, "context-file", "", "File persisting the trace context of the session: the first invocation creates it, and the next ones use it as parent")
+	flag.StringVar(&emitContextFlag, "emit-context", "", "File where the trace context of the root span is written, so later steps can attach their suites under it with --parent-context")
+	flag.StringVar(&parentContextFlag, "parent-context", "", "File with the trace context written by --emit-context: the suites are attached under that root span, instead of creating a new one")
+	flag.BoolVar(&verboseFlag, "verbose", false, "Log the diagnostics of the export: the exported spans, and the items rejected by the collector")
+	flag.BoolVar(&failOnExportErrorsFlag, "fail-on-export-errors", false, "Exit with an error if an export failed, or if the collector rejected part of the spans or metric data points")
+	flag.StringVar(&configFlag, "config", "", "YAML configuration file, i.e. with the failure rules categorizing the failure messages")
+	flag.DurationVar(&testTimeoutFlag, "test-timeout", 0, "Time limit of the tests: the failed tests reaching it are considered timeouts. The timeout property of a suite takes precedence")
+	flag.StringVar(&inputFlag, "input", "", "Comma separated list of report files or directories, i.e. Firebase Test Lab result bundles, to be read instead of the standard input")
+	flag.StringVar(&formatFlag, "format", reportFormatAuto, "Format of the reports: "+strings.Join(supportedReportFormats(), ", ")+". By default it's detected from the content of the report")
+	flag.BoolVar(&webDriverLinksFlag, "webdriver-links", false, "Link the test spans to the traces of their browser sessions, when the output of the tests includes their trace context")
+	flag.StringVar(&liveListenFlag, "listen", defaultLiveAddress, "Address where the live mode listens for the results of the tests: tcp://host:port, unix:///path/to/socket, or the path of a named pipe")
+	flag.BoolVar(&perTestMetricsFlag, "per-test-metrics", false, "Emit the tests.case.failed gauge and the tests.case.executions counter with one series per test case, so that backends can alert on the failure of a specific test")
+	flag.IntVar(&perTestMetricsLimitFlag, "per-test-metrics-limit", defaultPerTestMetricsLimit, "Maximum number of distinct test cases with their own series in the per-test metrics")
+	flag.StringVar(&assertFlag, "assert", "", "Expression over the results of the run, i.e. 'failed == 0 && duration_p95 < 60s': the tool exits with an error if it's not satisfied, once the results are exported")
+	flag.StringVar(&failOnFlag, "fail-on", failOnNone, "Exit with an error for the failed tests: none, errors (exit code 3), failures (exit code 2) or any. The errors are the unexpected exceptions, and the failures are the failed assertions")
+	flag.BoolVar(&otlpFlag, "otlp", true, "Export the traces and metrics with OTLP. It can be disabled when the test cases are only indexed in Elasticsearch")
+	flag.StringVar(&otlpEndpointFlag, "otlp-endpoint", "", "Endpoint of the OTLP exporters of both signals, as an URL, i.e. https://collector:4317, or a unix socket, i.e. unix:///var/run/otelcol.sock. If not set, the OTEL_EXPORTER_OTLP_ENDPOINT environment variables are used")
+	flag.StringVar(&otlpProtocolFlag, "otlp-protocol", "", "Transport of the OTLP exporters: grpc or http/protobuf. If not set, the OTEL_EXPORTER_OTLP_PROTOCOL environment variables are used, or grpc")
+	flag.StringVar(&elasticsearchURLFlag, "elasticsearch-url", "", "URL of Elasticsearch or OpenSearch, where a document per test case is indexed with the bulk API. If not set, the ELASTICSEARCH_URL environment variable is used")
+	flag.StringVar(&elasticsearchIndexFlag, "elasticsearch-index", defaultElasticsearchIndex, "Index, or data stream, of the test case documents in Elasticsearch")
+	flag.StringVar(&zipkinURLFlag, "zipkin-url", "", "URL of the spans API of Zipkin, i.e. http://localhost:9411/api/v2/spans, where the spans are sent with its JSON v2 format. If not set, the OTEL_EXPORTER_ZIPKIN_ENDPOINT environment variable is used")
+	flag.BoolVar(&datadogFlag, "datadog", false, "Send the test cases, the suites and the run to the test intake of Datadog CI Visibility, with the API key of the DD_API_KEY environment variable, in the DD_SITE site")
+	flag.StringVar(&clickHouseURLFlag, "clickhouse-url", "", "URL of the HTTP interface of ClickHouse, where a row per test case is inserted. If not set, the CLICKHOUSE_URL environment variable is used")
+	flag.StringVar(&clickHouseTableFlag, "clickhouse-table", defaultClickHouseTable, "Table of the test case rows in ClickHouse")
+	flag.StringVar(&bigQueryTableFlag, "bigquery-table", "", "BigQuery table, as project.dataset.table, where a row per test case is inserted. If not set, the BIGQUERY_TABLE environment variable is used")
+	flag.StringVar(&outputFlag, "output", "", "Format of the local file with a flattened row per test case: csv or parquet")
+	flag.StringVar(&outputFileFlag, "output-file", "", "Path of the local file with the rows of the test cases. By default it's junit2otlp-tests, with the extension of the format")
+	flag.StringVar(&htmlReportFlag, "html-report", "", "Path of a standalone HTML report summarizing the run, with the details of the failures")
+	flag.BoolVar(&stepSummaryFlag, "step-summary", true, "Append a Markdown summary of the run to the job summary of GitHub Actions, when the GITHUB_STEP_SUMMARY environment variable is set")
+	flag.StringVar(&annotateFlag, "annotate", annotateNone, "Annotate the build with a Markdown summary of the run: none, auto or buildkite. Auto detects Buildkite, where the annotation is created with the buildkite-agent command")
+	flag.StringVar(&traceURLFlag, "trace-url", "", "URL of the traces in the backend, with the {trace_id} and {span_id} placeholders, for the links of the HTML report. If not set, the JUNIT2OTLP_TRACE_URL environment variable is used")
+	flag.StringVar(&traceLinkFlag, "trace-link", traceLinkNone, "Format of the line with the link to the trace, written to the standard output for the UIs of the CI systems: none, auto, github, teamcity or json. Auto detects the CI system")
+	flag.StringVar(&stateFlag, "state", "", "Location of the state of the tool across the runs: the coverage of the tests ingested with the coverage command, and the results of the last runs. A path of a SQLite database, or of a JSON file with the .json extension, or a s3://, gs://, redis:// or rediss:// URL")
+	flag.IntVar(&stateHistoryFlag, "state-history", defaultStateHistory, "Number of runs kept in the state, with the results of their tests, for the trends command")
+	flag.StringVar(&testFlag, "test", "", "Selector of the test of the trends command, as classname#name, i.e. com.acme.CartTest#testTotal")
+	flag.StringVar(&trendsFormatFlag, "trends-format", trendsFormatSparkline, "Format of the trends of the test: sparkline or csv")
+	flag.StringVar(&baseFlag, "base", "", "Branch to compare the results of the tests with, from its runs in the state: the tests failing in the run but passing on it, and the other way around, are attached to the spans")
+	flag.StringVar(&headFlag, "head", "", "Branch compared with the base branch by the compare command. Defaults to the current branch")
+	flag.StringVar(&impactedTestsOutputFlag, "impacted-tests-output", "", "Path of the file with the tests of the report impacted by the changeset of the change request, one per line, or a JSON array if its extension is .json")
+	flag.StringVar(&impactMapFlag, "impact-map", "", "Path of a YAML or JSON file with the source files exercised by each test, i.e. from the per-test coverage, for the impacted tests. Without it, the impact is guessed from the names and the packages of the tests")
+	flag.StringVar(&assumeTimezoneFlag, "assume-timezone", "Local", "Time zone of the timestamps of the reports without one, as an IANA name (i.e. Europe/Madrid), UTC or Local")
+	flag.StringVar(&shapeFlag, "shape", shapeSpans, "Shape of the telemetry of the report: spans creates a span per run, suite and test case, and events a wide event per test case, with the attributes of its suite and the run, which suits the columnar backends, i.e. Honeycomb")
+	flag.StringVar(&anchorFlag, "anchor", anchorEnd, "Anchoring of the spans of the suites, laid out one after the other: end lays them out backwards from now, start forwards from the start of the invocation, and report-timestamp forwards from the timestamp of each suite")
+	flag.StringVar(&mergePolicyFlag, "merge-policy", mergePolicyKeepSeparate, "Policy for the suites with the same name, i.e. across the input files: keep-separate creates a suite span for each of them, merge-by-suite-name merges them into one")
+	flag.StringVar(&serverAddressFlag, "server-address", defaultServerAddress, "Address where the server mode listens for the reports, as host:port")
+	flag.IntVar(&queueSizeFlag, "queue-size", defaultQueueSize, "Maximum number of reports kept in memory by the server mode before spilling them to disk")
+	flag.Int64Var(&queueMaxMemoryFlag, "queue-max-memory", defaultQueueMaxMemory, "Maximum size, in bytes, of the reports kept in memory by the server mode before spilling them to disk")
+	flag.StringVar(&spoolDirFlag, "spool-dir", "", "Directory where the server mode spills the reports when its queue is full, defaulting to a junit2otlp-spool directory in the temporary directory, and where the OTLP exports failed after their retries, i.e. when the collector is down, are spooled until the next invocation sends them")
+	flag.Int64Var(&spoolMaxSizeFlag, "spool-max-size", defaultSpoolMaxSize, "Maximum size, in bytes, of the reports spilled to disk by the server mode, once reached the new reports are refused with a 503 status until the queue is drained, so a sustained burst does not fill the disk. 0 means no limit")
+	flag.StringVar(&grpcAddressFlag, "grpc-address", "", "Address where the server mode listens for the reports with the gRPC ingestion service, as host:port. If not set, the gRPC service is disabled")
+	flag.StringVar(&strictnessFlag, "strictness", strictnessLenient, "Strictness of the validation of the jUnit reports against the schema: lenient ingests any report, strict refuses the reports with deviations from the schema")
+	flag.StringVar(&schemaFlag, "schema", schemaAuto, "JUnit schema to validate the reports against: auto, ant, jenkins or surefire. Auto picks the closest one")
+	flag.BoolVar(&strictFlag, "strict", false, "Refuses the jUnit reports with fields the tool would silently drop, i.e. the elements it does not read or the properties not allowed, reporting all of them, and makes them errors of the validate command")
+	flag.StringVar(&snapshotFlag, "snapshot", "", "Path of a file where the traces and metrics are written as OTLP JSON, normalized to be diffed across versions of the tool: the IDs are numbered, the spans start at zero, and the metrics are sorted without timestamps")
+	flag.IntVar(&nameLimitFlag, "name-limit", 0, "Maximum length, in bytes, of the names of the tests and the suites, i.e. the limit of the span names of the backend. The longer names are handled as set by --long-names. 0 means no limit")
+	flag.StringVar(&longNamesFlag, "long-names", longNamesHash, "Handling of the names exceeding --name-limit: hash keeps their prefix with a hash of the whole name, and transliterate writes their Latin letters in ASCII without diacritics nor emoji, hashing them if they still exceed it")
+	flag.StringVar(&logDroppedFlag, "log-dropped", logDroppedSummary, "Logging of the data dropped by the tool, i.e. the properties not allowed or the characters removed from a malformed report, which are also recorded as the junit2otlp.dropped metric: none, summary or all")
+	flag.BoolVar(&scrubSecretsFlag, "scrub-secrets", true, "Mask the common secrets, i.e. AWS keys, bearer tokens and the credentials of URLs, in the output and the failure messages of the tests before they are exported, besides the patterns of the scrubbing section of the config file")
+	flag.StringVar(&includeSuiteFlag, "include-suite", "", "Comma-separated glob patterns of the names of the suites to export, i.e. *e2e*, with their nested suites. * matches any characters and ? a single one. If not set, all the suites are exported")
+	flag.StringVar(&excludeSuiteFlag, "exclude-suite", "", "Comma-separated glob patterns of the names of the suites not to export, with their nested suites, even if they are included by --include-suite")
+	flag.StringVar(&includeTestFlag, "include-test", "", "Comma-separated glob patterns of the test cases to export, matching their name or their classname#name. If not set, all the test cases of the exported suites are exported")
+	flag.BoolVar(&onlyFailuresFlag, "only-failures", false, "Export only the failed and errored test cases, with their parent suites. The suites without failures are not exported")
+	flag.IntVar(&withContextFlag, "with-context", 0, "Number of test cases exported before and after each failure of a suite with --only-failures, in their order in the suite, to understand the effects of the order of the tests")
+	flag.StringVar(&deployTracesFlag, "deploy-traces", "", "Comma-separated traceparents, or trace IDs, of the traces of the previous deployments, i.e. the one of the environment under test, linked from the root span of the run")
+	flag.Float64Var(&costPerMinuteFlag, "cost-per-minute", 0, "Cost per minute of the machine time of the runner, to estimate the cost of the run and of its suites from their durations. 0 means no estimation")
+	flag.StringVar(&runnerTypeFlag, "runner-type", "", "Type of the runner of the tests, i.e. ubuntu-latest, whose rate per minute is read from the runner_rates of the costs section of the config file, instead of --cost-per-minute")
+	flag.DurationVar(&overheadThresholdFlag, "overhead-threshold", defaultOverheadThreshold, "Minimum time declared by a suite and not spent in its test cases, i.e. in the setup and the teardown of its fixtures, to be reported in an overhead span. 0 disables it")
+	flag.StringVar(&idGeneratorFlag, "id-generator", idGeneratorW3C, "Generator of the trace IDs: w3c, random ones, or xray, with the epoch time of AWS X-Ray in their first bytes, so the traces exported through the ADOT collector land in X-Ray")
+	flag.StringVar(&dashboardBackendFlag, "backend", dashboardBackendGrafana, "Backend of the dashboards printed by the dashboards command, and of the exporters printed by the collector-config command: grafana or signoz")
+	flag.StringVar(&systemLogFlag, "system-log", systemLogNone, "System logger where the failures of the exports are logged, so the monitoring of the nodes catches a broken telemetry pipeline: none, auto, journald or eventlog. Auto picks the Event Log on Windows, and the journal of systemd if it's running")
+	flag.StringVar(&bundleFlag, "bundle", "", "Path of the bundle written by the pack command, with the OTLP payloads of the report and the report files, and read by the push command to export them from a host with access to the collector")
+	flag.StringVar(&signingKeyFlag, "signing-key", "", "Path of the minisign private key signing the bundles written by the pack command, the payloads of the traces with --payload-digest, and the attestations. Its password is read from the JUNIT2OTLP_SIGNING_KEY_PASSWORD environment variable")
+	flag.StringVar(&verifyKeyFlag, "verify-key", "", "Path of the minisign public key verifying the signature of the bundles read by the push command. If set, the unsigned bundles are refused")
+	flag.BoolVar(&payloadDigestFlag, "payload-digest", false, "Attach the SHA-256 digest of each payload of the traces exported with OTLP to its resource, as the junit2otlp.payload.digest attribute, and its minisign signature as junit2otlp.payload.signature if --signing-key is set, so the telemetry can be proven unaltered when it's kept as an audit record")
+	flag.StringVar(&attestationFlag, "attestation", "", "Path of a file where an in-toto attestation of the run is written, with the hashes of the reports as its subjects, and the summary, the CI and SCM attributes of the run and the reports as its predicate. It's signed with a minisign signature next to it if --signing-key is set")
+	flag.StringVar(&auditLogFlag, "audit-log", "", "Path of an append-only audit log, where a JSON line records each payload of OTLP exported, or written to a bundle: when, by which service, to which endpoint, with its outcome, its trace IDs and its SHA-256 hash")
+	flag.StringVar(&maxClassificationFlag, "max-classification", classificationSensitiveName, "Maximum classification of the attributes exported: public, internal or sensitive. The attributes classified above it, i.e. the output of the tests and the authors of the changes with internal, are dropped from the traces, the metrics and their resources. The classifications of the attributes are overridden by the classification section of the config file")
+	flag.StringVar(&scopeVersionFlag, "scope-version", version, "OpenTelemetry Instrumentation Scope Version to be used when sending traces and metrics for the jUnit report")
 
 	// initialize runtime keys
 	runtimeAttributes = []attribute.KeyValue{
 		semconv.HostArchKey.String(runtime.GOARCH),
 		semconv.OSNameKey.String(runtime.GOOS),
 	}
-
-	propsAllowed = []string{}
-	if propertiesAllowedString != "" {
-		allowed := strings.Split(propertiesAllowedString, ",")
-		for _, prop := range allowed {
-			propsAllowed = append(propsAllowed, strings.TrimSpace(prop))
-		}
-	}
 }
 
 func createIntCounter(meter metric.Meter, name string, description string) metric.Int64Counter {
@@ -72,9 +243,69 @@ func createIntCounter(meter metric.Meter, name string, description string) metri
 	return counter
 }
 
-func createTracesAndSpans(ctx context.Context, srvName string, tracesProvides *sdktrace.TracerProvider, suites []junit.Suite) error {
-	tracer := tracesProvides.Tracer(srvName)
-	meter := otel.Meter(srvName)
+func createIntHistogram(meter metric.Meter, name string, description string) metric.Int64Histogram {
+	histogram, _ := meter.Int64Histogram(name, metric.WithDescription(description), metric.WithUnit("ms"))
+	// Accumulators always return nil errors
+	return histogram
+}
+
+func createTracesAndSpans(ctx context.Context, srvName string, sessionID string, tracesProvides *sdktrace.TracerProvider, meterProvider metric.MeterProvider, deviceProviders map[string]*sdktrace.TracerProvider, report *junitReport) error {
+	spanKind, err := parseSpanKind(spanKindFlag)
+	if err != nil {
+		return err
+	}
+
+	location, err := getAssumedTimezone()
+	if err != nil {
+		return err
+	}
+
+	anchor, err := parseAnchor(anchorFlag)
+	if err != nil {
+		return err
+	}
+
+	shape, err := parseShape(shapeFlag)
+	if err != nil {
+		return err
+	}
+
+	if shape == shapeEvents && emitContextFlag != "" {
+		return fmt.Errorf("the %s shape has no root span to emit its context: use the %s shape", shapeEvents, shapeSpans)
+	}
+
+	traceLinkFormat, err := parseTraceLinkFormat(traceLinkFlag)
+	if err != nil {
+		return err
+	}
+
+	deployLinks, err := parseDeployTraces(deployTracesFlag)
+	if err != nil {
+		return err
+	}
+
+	if err := parseLogDropped(logDroppedFlag); err != nil {
+		return err
+	}
+
+	scopeName := scopeNameFlag
+	if scopeName == "" {
+		scopeName = srvName
+	}
+
+	reportFormat := report.format
+	if reportFormat == "" {
+		reportFormat = reportFormatJUnit
+	}
+
+	scopeAttributes := attribute.NewSet(
+		attribute.Key(ReportFormat).String(reportFormat),
+		attribute.Key(ToolVersion).String(version),
+	)
+
+	tracerOptions := []trace.TracerOption{trace.WithInstrumentationVersion(scopeVersionFlag), trace.WithInstrumentationAttributes(scopeAttributes.ToSlice()...)}
+	tracer := tracesProvides.Tracer(scopeName, tracerOptions...)
+	meter := meterProvider.Meter(scopeName, metric.WithInstrumentationVersion(scopeVersionFlag), metric.WithInstrumentationAttributes(scopeAttributes.ToSlice()...))
 
 	scm := GetScm(repositoryPathFlag)
 	if scm != nil {
@@ -83,66 +314,335 @@ func createTracesAndSpans(ctx context.Context, srvName string, tracesProvides *s
 	}
 
 	durationCounter := createIntCounter(meter, TestsDuration, "Duration of the tests")
-	errorCounter := createIntCounter(meter, ErrorTestsCount, "Total number of failed tests")
+	errorCounter := createIntCounter(meter, ErrorTestsCount, "Total number of tests with errors")
 	failedCounter := createIntCounter(meter, FailedTestsCount, "Total number of failed tests")
 	passedCounter := createIntCounter(meter, PassedTestsCount, "Total number of passed tests")
+	passedOnRetryCounter := createIntCounter(meter, PassedOnRetryTestsCount, "Total number of tests passed on a retry, included in the passed tests")
 	skippedCounter := createIntCounter(meter, SkippedTestsCount, "Total number of skipped tests")
 	testsCounter := createIntCounter(meter, TotalTestsCount, "Total number of executed tests")
+	timeoutCounter := createIntCounter(meter, TimeoutTestsCount, "Total number of tests failed by a timeout")
+	assertionsCounter := createIntCounter(meter, AssertionsCount, "Total number of assertions declared by the suites")
+	ownerDurationCounter := createIntCounter(meter, TestsDurationByOwner, "Duration of the test cases, by owner, module and package")
+	idleCounter := createIntCounter(meter, TestsSessionIdle, "Idle time before the suites of a session, between the end of the previous suites and their start")
+	var costCounter metric.Float64Counter
+	if runCost != nil {
+		// Accumulators always return nil errors
+		costCounter, _ = meter.Float64Counter(TestsSuiteCost, metric.WithDescription("Estimated cost of the machine time of the suites"), metric.WithUnit(runCost.currency))
+	}
+	testMetrics := newTestCaseMetrics(meter, perTestMetricsLimit())
+
+	// the data dropped while parsing the report and creating its spans, once the root span ends
+	defer droppedData.flush(ctx, meter)
+
+	summary := summarize(report.results())
+	frameworkAttributes := detectFramework(report).attributes()
+
+	outerAttributes := append([]attribute.KeyValue{}, runtimeAttributes...)
+	outerAttributes = append(outerAttributes, frameworkAttributes...)
+	outerAttributes = append(outerAttributes, report.attributes...)
+	outerAttributes = append(outerAttributes, summary.attributes()...)
+	outerAttributes = append(outerAttributes, runComparison.attributes()...)
+	outerAttributes = append(outerAttributes, runCost.runAttributes(report)...)
+
+	ciRun := detectCIRun()
+	traceName := runName(traceNameFlag, srvName, ciRun)
+	outerAttributes = append(outerAttributes, runNameAttributes(traceName, ciRun)...)
+
+	// the wide events have the attributes of the run, besides the runtime ones of their suite
+	runAttributes := append(summary.attributes(), runComparison.attributes()...)
+
+	// the root span starts with the earliest suite, and ends with the latest one
+	now := time.Now()
+	startTimes := suiteStartTimes(report, anchor, location, now)
+	earliest, latest := now, now
+	for i := range report.suites {
+		if startTimes[i].Before(earliest) {
+			earliest = startTimes[i]
+		}
+		if end := startTimes[i].Add(suiteSpanDuration(report, i)); end.After(latest) {
+			latest = end
+		}
+	}
 
-	ctx, outerSpan := tracer.Start(ctx, traceNameFlag, trace.WithAttributes(runtimeAttributes...), trace.WithSpanKind(trace.SpanKindServer))
-	defer outerSpan.End()
+	// in a session, the idle time between the suites is the waste of the orchestration of the pipeline
+	var gaps []time.Duration
+	if sessionID != "" {
+		var idle time.Duration
+		gaps, idle = suiteGaps(report, startTimes, previousSuiteEnd)
+		outerAttributes = append(outerAttributes, attribute.Key(TestsRunIdle).Int64(idle.Milliseconds()))
+	}
+	outerOptions := []trace.SpanStartOption{trace.WithAttributes(outerAttributes...), trace.WithSpanKind(spanKind), trace.WithTimestamp(earliest), trace.WithLinks(deployLinks...)}
+
+	// when attaching to a parent context, the root span was already created by a previous step, and the wide events
+	// have no root span
+	if parentContextFlag == "" && shape == shapeSpans {
+		var outerSpan trace.Span
+		ctx, outerSpan = tracer.Start(ctx, traceName, outerOptions...)
+		defer outerSpan.End(trace.WithTimestamp(latest))
+	}
 
-	for _, suite := range suites {
-		totals := suite.Totals
+	if err := writeTraceLink(os.Stdout, traceLinkFormat, getTraceURL(), trace.SpanContextFromContext(ctx)); err != nil {
+		return err
+	}
 
-		suiteAttributes := []attribute.KeyValue{
-			semconv.CodeNamespaceKey.String(suite.Package),
-			attribute.Key(TestsSuiteName).String(suite.Name),
-			attribute.Key(TestsSystemErr).String(suite.SystemErr),
-			attribute.Key(TestsSystemOut).String(suite.SystemOut),
-			attribute.Key(TestsDuration).Int64(suite.Totals.Duration.Milliseconds()),
+	if contextFileFlag != "" && shape == shapeSpans {
+		if err := writeContextFile(ctx, contextFileFlag, sessionID, true); err != nil {
+			return err
 		}
+	}
 
-		suiteAttributes = append(suiteAttributes, runtimeAttributes...)
-		suiteAttributes = append(suiteAttributes, propsToLabels(suite.Properties)...)
+	if emitContextFlag != "" {
+		if err := writeContextFile(ctx, emitContextFlag, sessionID, false); err != nil {
+			return err
+		}
+	}
+
+	if contextFileFlag != "" && sessionID != "" {
+		if err := writeLastSuiteEnd(contextFileFlag, latest); err != nil {
+			return err
+		}
+	}
+
+	for i, suite := range report.suites {
+		totals := suite.Totals
+
+		suiteAttributes := suiteSpanAttributes(suite, report.rawSuite(i), frameworkAttributes)
+		metricSuiteAttributes := suiteAttributes
+
+		// the suites of each device are reported with its resource, and the metrics include the device attributes
+		suiteTracer := tracer
+		if d := report.device(i); d != nil {
+			suiteTracer = deviceProviders[d.key()].Tracer(scopeName, tracerOptions...)
+			metricSuiteAttributes = append(append([]attribute.KeyValue{}, suiteAttributes...), d.attributes()...)
+		}
 
-		attributeSet := attribute.NewSet(suiteAttributes...)
+		attributeSet := attribute.NewSet(metricSuiteAttributes...)
 		metricAttributes := metric.WithAttributeSet(attributeSet)
 
 		durationCounter.Add(ctx, totals.Duration.Milliseconds(), metricAttributes)
 		errorCounter.Add(ctx, int64(totals.Error), metricAttributes)
 		failedCounter.Add(ctx, int64(totals.Failed), metricAttributes)
 		passedCounter.Add(ctx, int64(totals.Passed), metricAttributes)
+		passedOnRetryCounter.Add(ctx, int64(countPassedOnRetry(suite)), metricAttributes)
 		skippedCounter.Add(ctx, int64(totals.Skipped), metricAttributes)
 		testsCounter.Add(ctx, int64(totals.Tests), metricAttributes)
+		if assertions, ok := suiteAssertions(report.rawSuite(i)); ok {
+			assertionsCounter.Add(ctx, assertions, metricAttributes)
+		}
+		if costCounter != nil {
+			costCounter.Add(ctx, runCost.cost(totals.Duration), metricAttributes)
+		}
+		if gaps != nil && gaps[i] > 0 {
+			idleCounter.Add(ctx, gaps[i].Milliseconds(), metricAttributes)
+			suiteAttributes = append(slices.Clip(suiteAttributes), suiteGapAttributes(gaps[i])...)
+		}
 
-		ctx, suiteSpan := tracer.Start(ctx, suite.Name, trace.WithAttributes(suiteAttributes...))
-		for _, test := range suite.Tests {
-			testAttributes := []attribute.KeyValue{
-				semconv.CodeFunctionKey.String(test.Name),
-				attribute.Key(TestDuration).Int64(test.Duration.Milliseconds()),
-				attribute.Key(TestClassName).String(test.Classname),
-				attribute.Key(TestMessage).String(test.Message),
-				attribute.Key(TestStatus).String(string(test.Status)),
-				attribute.Key(TestSystemErr).String(test.SystemErr),
-				attribute.Key(TestSystemOut).String(test.SystemOut),
-			}
+		// the provenance has a value for each run, as the gaps
+		suiteAttributes = append(slices.Clip(suiteAttributes), report.source(i).attributes()...)
+
+		var timeouts int64
+		if shape == shapeEvents {
+			timeouts = createSuiteEvents(ctx, suiteTracer, testMetrics, suite, suiteAttributes, runAttributes, frameworkAttributes, startTimes[i])
+		} else {
+			timeouts = createSuiteSpans(ctx, suiteTracer, testMetrics, suite, report.rawSuite(i), suiteAttributes, frameworkAttributes, startTimes[i])
+		}
+		timeoutCounter.Add(ctx, timeouts, metricAttributes)
+	}
 
-			testAttributes = append(testAttributes, propsToLabels(test.Properties)...)
-			testAttributes = append(testAttributes, suiteAttributes...)
+	recordOwnerDurations(ctx, ownerDurationCounter, appConfig.Ownership, report)
 
-			if test.Error != nil {
-				testAttributes = append(testAttributes, attribute.Key(TestError).String(test.Error.Error()))
-			}
+	return nil
+}
+
+// suiteSpanAttributes returns the attributes of the suite, including the runtime and the framework ones, and the
+// ones of the host where it ran. The raw XML element of the suite can be nil, i.e. for the nested suites
+func suiteSpanAttributes(suite junit.Suite, rawSuite *xmlElement, frameworkAttributes []attribute.KeyValue) []attribute.KeyValue {
+	suiteAttributes := []attribute.KeyValue{
+		semconv.CodeNamespaceKey.String(suite.Package),
+		attribute.Key(TestsSuiteName).String(suite.Name),
+		attribute.Key(TestsSystemErr).String(suite.SystemErr),
+		attribute.Key(TestsSystemOut).String(suite.SystemOut),
+		attribute.Key(TestsDuration).Int64(suite.Totals.Duration.Milliseconds()),
+	}
+
+	suiteAttributes = append(suiteAttributes, runtimeAttributes...)
+	suiteAttributes = append(suiteAttributes, frameworkAttributes...)
+	suiteAttributes = appendPropsLabels(suiteAttributes, suite.Properties)
+	suiteAttributes = append(suiteAttributes, suiteDialectAttributes(suite)...)
+	suiteAttributes = append(suiteAttributes, suiteCountAttributes(rawSuite)...)
+	suiteAttributes = append(suiteAttributes, executorAttributes(suite, rawSuite)...)
+
+	return suiteAttributes
+}
+
+// createSuiteSpans creates the span of the suite, with a child span for each test case, and the spans of the nested
+// suites (i.e. PHPUnit groups the test cases of each file in a nested suite) as children. It returns the number of
+// tests failed by a timeout, including the nested suites. If the start time is not zero, the test cases and the
+// nested suites are laid out one after the other from it, otherwise the spans are created at the time of the export.
+// The time declared by the suite and not spent in its test cases is laid out after them, in the overhead span
+func createSuiteSpans(ctx context.Context, tracer trace.Tracer, testMetrics *testCaseMetrics, suite junit.Suite, rawSuite *xmlElement, suiteAttributes []attribute.KeyValue, frameworkAttributes []attribute.KeyValue, startTime time.Time) int64 {
+	timeoutLimit := suiteTimeout(suite)
+	timeouts := int64(0)
+
+	startOptions := []trace.SpanStartOption{trace.WithAttributes(suiteAttributes...)}
+	if !startTime.IsZero() {
+		startOptions = append(startOptions, trace.WithTimestamp(startTime))
+	}
+
+	ctx, suiteSpan := tracer.Start(ctx, suite.Name, startOptions...)
+	if costAttributes := runCost.suiteAttributes(suite); costAttributes != nil {
+		suiteSpan.SetAttributes(costAttributes...)
+	}
+
+	overhead := suiteOverhead(suite, rawSuite, overheadThresholdFlag)
+	if overhead > 0 {
+		suiteSpan.SetAttributes(attribute.Key(SuiteOverheadDuration).Int64(overhead.Milliseconds()))
+	}
+
+	cursor := startTime
+	for _, test := range suite.Tests {
+		endTime := time.Time{}
+		if !cursor.IsZero() {
+			cursor = cursor.Add(test.Duration)
+			endTime = cursor
+		}
 
-			_, testSpan := tracer.Start(ctx, test.Name, trace.WithAttributes(testAttributes...))
-			testSpan.End()
+		if createTestSpan(ctx, tracer, testMetrics, suite, suiteAttributes, test, timeoutLimit, endTime) {
+			timeouts++
 		}
+	}
 
+	for _, nested := range suite.Suites {
+		timeouts += createSuiteSpans(ctx, tracer, testMetrics, nested, nil, suiteSpanAttributes(nested, nil, frameworkAttributes), frameworkAttributes, cursor)
+		if !cursor.IsZero() {
+			cursor = cursor.Add(nested.Totals.Duration)
+		}
+	}
+
+	if startTime.IsZero() {
 		suiteSpan.End()
+		return timeouts
 	}
 
-	return nil
+	if overhead > 0 {
+		_, overheadSpan := tracer.Start(ctx, overheadSpanName, trace.WithTimestamp(cursor), trace.WithAttributes(attribute.Key(SuiteOverheadDuration).Int64(overhead.Milliseconds())))
+		cursor = cursor.Add(overhead)
+		overheadSpan.End(trace.WithTimestamp(cursor))
+	}
+
+	endTime := startTime.Add(suite.Totals.Duration)
+	if cursor.After(endTime) {
+		endTime = cursor
+	}
+	suiteSpan.End(trace.WithTimestamp(endTime))
+
+	return timeouts
+}
+
+// createTestSpan creates the span of the test case, recording its duration, and returns if it failed by a timeout.
+// If the end time is not zero, the span starts the duration of the test before it, i.e. for the live results
+func createTestSpan(ctx context.Context, tracer trace.Tracer, testMetrics *testCaseMetrics, suite junit.Suite, suiteAttributes []attribute.KeyValue, test junit.Test, timeoutLimit time.Duration, endTime time.Time) bool {
+	status, ruleAttributes := applyFailureRules(appConfig.FailureRules, test)
+	testName, dialectAttributes := testDialect(test)
+	demangledAttributes := demangledNameAttributes(test.Classname, testName)
+	ownerAttributes := appConfig.Ownership.ownerAttributes(suite, test)
+	webDriverAttributes, links := webDriverSession(&appConfig.WebDriver, test, webDriverLinksFlag)
+	spanStatus, result := appConfig.StatusMapping.resolve(status)
+
+	// the attributes are allocated once, as a span is created for each test case of the largest reports
+	testAttributes := make([]attribute.KeyValue, 0, 14+len(test.Properties)+len(dialectAttributes)+len(demangledAttributes)+len(ownerAttributes)+len(ruleAttributes)+len(webDriverAttributes)+len(suiteAttributes))
+	testAttributes = append(testAttributes,
+		semconv.CodeFunctionKey.String(testName),
+		attribute.Key(TestDuration).Int64(test.Duration.Milliseconds()),
+		attribute.Key(TestClassName).String(test.Classname),
+		attribute.Key(TestMessage).String(test.Message),
+		attribute.Key(TestStatus).String(status),
+		attribute.Key(TestResult).String(result),
+		attribute.Key(TestSystemErr).String(test.SystemErr),
+		attribute.Key(TestSystemOut).String(test.SystemOut),
+	)
+
+	testAttributes = appendPropsLabels(testAttributes, test.Properties)
+	testAttributes = append(testAttributes, displayNameAttributes(test, testName)...)
+	testAttributes = append(testAttributes, demangledAttributes...)
+	testAttributes = append(testAttributes, ownerAttributes...)
+	testAttributes = append(testAttributes, testCountAttributes(test)...)
+	testAttributes = append(testAttributes, coverageAttributes(testCoverage, suite, test)...)
+	testAttributes = append(testAttributes, runComparison.testAttributes(suite, test)...)
+	testAttributes = append(testAttributes, seedAttributes(suite, test)...)
+	testAttributes = append(testAttributes, dialectAttributes...)
+	testAttributes = append(testAttributes, ruleAttributes...)
+	testAttributes = append(testAttributes, webDriverAttributes...)
+
+	timeout := isTimeout(test, timeoutLimit)
+	if timeout {
+		testAttributes = append(testAttributes, attribute.Key(TestTimeout).Bool(true))
+	}
+
+	testAttributes = append(testAttributes, suiteAttributes...)
+
+	if test.Error != nil {
+		testAttributes = append(testAttributes, attribute.Key(TestError).String(test.Error.Error()))
+	}
+
+	if errType := exceptionType(test); errType != "" {
+		testAttributes = append(testAttributes, semconv.ExceptionTypeKey.String(errType))
+	}
+
+	testAttributes = append(testAttributes, appConfig.Derived.evaluate(suite, test, testName, status, testAttributes)...)
+
+	if len(appConfig.SpanHooks) > 0 {
+		testName, status, testAttributes = applySpanHooks(appConfig.SpanHooks, suite, test, testName, status, testAttributes)
+		spanStatus, result = appConfig.StatusMapping.resolve(status)
+		testAttributes = setAttributes(testAttributes, attribute.Key(TestStatus).String(status), attribute.Key(TestResult).String(result))
+	}
+
+	// recording the duration within the test span, so the exemplars point to it
+	startOptions := []trace.SpanStartOption{trace.WithAttributes(testAttributes...), trace.WithLinks(links...)}
+	endOptions := []trace.SpanEndOption{}
+	if !endTime.IsZero() {
+		startOptions = append(startOptions, trace.WithTimestamp(endTime.Add(-test.Duration)))
+		endOptions = append(endOptions, trace.WithTimestamp(endTime))
+	}
+
+	testCtx, testSpan := tracer.Start(ctx, testName, startOptions...)
+	// the description is only kept for the error status
+	testSpan.SetStatus(spanStatus, test.Message)
+	addReportEntryEvents(testSpan, test, endTime)
+	if junitErr, ok := test.Error.(junit.Error); ok {
+		// the failure is recorded at the end of the test case
+		eventOptions := []trace.EventOption{trace.WithAttributes(
+			semconv.ExceptionTypeKey.String(junitErr.Type),
+			semconv.ExceptionMessageKey.String(junitErr.Message),
+			semconv.ExceptionStacktraceKey.String(junitErr.Body),
+		)}
+		if !endTime.IsZero() {
+			eventOptions = append(eventOptions, trace.WithTimestamp(endTime))
+		}
+		testSpan.AddEvent(semconv.ExceptionEventName, eventOptions...)
+	}
+	testMetrics.record(testCtx, suite, test, testName, status)
+	testSpan.End(endOptions...)
+
+	return timeout
+}
+
+// parseSpanKind converts the name of a span kind into an OpenTelemetry span kind
+func parseSpanKind(kind string) (trace.SpanKind, error) {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "internal":
+		return trace.SpanKindInternal, nil
+	case "server":
+		return trace.SpanKindServer, nil
+	case "client":
+		return trace.SpanKindClient, nil
+	case "producer":
+		return trace.SpanKindProducer, nil
+	case "consumer":
+		return trace.SpanKindConsumer, nil
+	default:
+		return trace.SpanKindUnspecified, fmt.Errorf("invalid span kind: %s", kind)
+	}
 }
 
 // getDefaultwd retrieves the current working dir, using '.' in the case an error occurs
@@ -179,51 +679,212 @@ func getOtlpServiceVersion() string {
 	return getOtlpEnvVar(serviceVersionFlag, "OTEL_SERVICE_VERSION", "")
 }
 
-func initMetricsProvider(ctx context.Context, res *resource.Resource) (*sdkmetric.MeterProvider, error) {
-	exporter, err := otlpmetricgrpc.New(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create the collector exporter: %v", err)
+// resourceDetectors returns the resource detectors to be used, which are the standard OpenTelemetry
+// ones for the host, OS, process and container when the detection of resources is enabled
+func resourceDetectors() []resource.Option {
+	if !detectResourcesFlag {
+		return []resource.Option{resource.WithProcess()}
 	}
 
-	reader := sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(2*time.Second))
-	meterProvider := sdkmetric.NewMeterProvider(
-		sdkmetric.WithReader(reader),
-		sdkmetric.WithResource(res),
-	)
+	return []resource.Option{
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+		resource.WithHostID(),
+		resource.WithOS(),
+		resource.WithProcess(),
+		resource.WithContainer(),
+	}
+}
+
+// addAdditionalAttributes adds the additional attributes, if provided, to the runtime attributes
+func addAdditionalAttributes() error {
+	if additionalAttributes == "" {
+		return nil
+	}
+
+	additionalAttrsErrors := []error{}
+
+	addAttrs := strings.Split(additionalAttributes, ",")
+	for _, attr := range addAttrs {
+		kv := strings.Split(attr, "=")
+		if len(kv) == 2 {
+			runtimeAttributes = append(runtimeAttributes, attribute.Key(kv[0]).String(kv[1]))
+		} else {
+			additionalAttrsErrors = append(additionalAttrsErrors,
+				fmt.Errorf("invalid attribute: %s", attr))
+		}
+	}
+
+	if err := errors.Join(additionalAttrsErrors...); err != nil {
+		return fmt.Errorf("failed to add additional attributes: %w", err)
+	}
+
+	return nil
+}
+
+// newResource creates the resource of the telemetry, with the service name that will show up in tracing UIs
+func newResource(ctx context.Context, srvName string, srvVersion string) (*resource.Resource, error) {
+	attributes := []attribute.KeyValue{
+		semconv.ServiceNameKey.String(srvName),
+		semconv.ServiceVersionKey.String(srvVersion),
+	}
+
+	// the environment and the ring of the deployment, overriding the ones of OTEL_RESOURCE_ATTRIBUTES
+	if environmentFlag != "" {
+		attributes = append(attributes, semconv.DeploymentEnvironmentKey.String(environmentFlag))
+	}
+	if deploymentRingFlag != "" {
+		attributes = append(attributes, attribute.Key(DeploymentRing).String(deploymentRingFlag))
+	}
+
+	res, err := resource.New(ctx, append(resourceDetectors(), resource.WithAttributes(attributes...))...)
+	if errors.Is(err, resource.ErrPartialResource) {
+		// some detectors are not able to work in every environment (i.e. the host ID in containers)
+		log.Printf("not all the resource attributes could be detected: %v", err)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to create OpenTelemetry service name resource: %s", err)
+	}
+
+	return res, nil
+}
+
+func initMetricsProvider(ctx context.Context, res *resource.Resource, outputs *spanOutputs, exporterOptions ...otlpOption) (*sdkmetric.MeterProvider, error) {
+	meterProvider, err := newMeterProvider(ctx, res, outputs, exporterOptions...)
+	if err != nil {
+		return nil, err
+	}
 
 	otel.SetMeterProvider(meterProvider)
 
 	return meterProvider, nil
 }
 
-func initTracerProvider(ctx context.Context, res *resource.Resource) (*sdktrace.TracerProvider, error) {
-	traceExporter, err := otlptracegrpc.New(ctx)
+// newMeterProvider creates a meter provider exporting the metrics with the given resource, with OTLP and to the outputs
+func newMeterProvider(ctx context.Context, res *resource.Resource, outputs *spanOutputs, exporterOptions ...otlpOption) (*sdkmetric.MeterProvider, error) {
+	selector, err := temporalitySelector(getMetricsTemporality())
+	if err != nil {
+		return nil, err
+	}
+
+	buckets, err := getHistogramBuckets()
+	if err != nil {
+		return nil, err
+	}
+
+	exemplarFilter, err := getExemplarFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := getClassificationPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	options := []sdkmetric.Option{
+		sdkmetric.WithResource(policy.resource(res)),
+		sdkmetric.WithView(policy.view(durationHistogramView(buckets))),
+		sdkmetric.WithExemplarFilter(exemplarFilter),
+	}
+
+	// without OTLP the metrics are recorded, but not exported
+	if otlpFlag {
+		exporter, err := newOtlpMetricExporter(ctx, selector, exporterOptions...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the collector exporter: %v", err)
+		}
+
+		options = append(options, sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(2*time.Second))))
+	}
+
+	options = append(options, outputs.meterProviderOptions()...)
+
+	return sdkmetric.NewMeterProvider(options...), nil
+}
+
+func initTracerProvider(ctx context.Context, res *resource.Resource, diagnostics *exportDiagnostics, outputs *spanOutputs, exporterOptions ...otlpOption) (*sdktrace.TracerProvider, error) {
+	tracerProvider, err := newTracerProvider(ctx, res, diagnostics, outputs, exporterOptions...)
 	if err != nil {
 		return nil, err
 	}
 
-	tracerProvider := sdktrace.NewTracerProvider(
+	otel.SetTracerProvider(tracerProvider)
+
+	return tracerProvider, nil
+}
+
+// newTracerProvider creates a tracer provider exporting the spans with the given resource, with OTLP and to the outputs.
+// The options of the OTLP exporter override the ones of the environment variables and of the exporters of the
+// configuration file, i.e. for the tenants of the server
+func newTracerProvider(ctx context.Context, res *resource.Resource, diagnostics *exportDiagnostics, outputs *spanOutputs, exporterOptions ...otlpOption) (*sdktrace.TracerProvider, error) {
+	idGenerator, err := parseIDGenerator(idGeneratorFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := getClassificationPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	options := []sdktrace.TracerProviderOption{
 		sdktrace.WithResource(res),
-		sdktrace.WithSpanProcessor(
+		sdktrace.WithIDGenerator(idGenerator),
+		sdktrace.WithSpanProcessor(droppedLimitsProcessor{ledger: droppedData}),
+	}
+
+	if otlpFlag {
+		traceExporter, err := newOtlpTraceExporter(ctx, exporterOptions...)
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, sdktrace.WithSpanProcessor(
 			sdktrace.NewBatchSpanProcessor(
-				traceExporter,
+				&countingSpanExporter{SpanExporter: policy.spanExporter(traceExporter), diagnostics: diagnostics},
 				sdktrace.WithMaxExportBatchSize(batchSizeFlag),
 			),
-		),
-	)
+		))
+	}
 
-	otel.SetTracerProvider(tracerProvider)
+	options = append(options, outputs.tracerProviderOptions(policy)...)
 
-	return tracerProvider, nil
+	return sdktrace.NewTracerProvider(options...), nil
+}
+
+// propertyAllowed returns if the property is allowed by --properties-allowed: all of them with "all" (default) or
+// an empty list. The flag is read on each call, as it's resolved from the command line, the environment and the
+// config file after the flags are registered
+func propertyAllowed(name string) bool {
+	if propertiesAllowedString == propertiesAllowAll || strings.TrimSpace(propertiesAllowedString) == "" {
+		return true
+	}
+
+	for rest := propertiesAllowedString; rest != ""; {
+		var prop string
+		prop, rest, _ = strings.Cut(rest, ",")
+		if strings.TrimSpace(prop) == name {
+			return true
+		}
+	}
+
+	return false
 }
 
-func propsToLabels(props map[string]string) []attribute.KeyValue {
-	attributes := []attribute.KeyValue{}
-	for k, v := range props {
-		// if propertiesAllowedString is not "all" (default) and the key is not in the
-		// allowed list, skip it
-		if propertiesAllowedString != propertiesAllowAll &&
-			len(propsAllowed) > 0 && !slices.Contains(propsAllowed, k) {
+// appendPropsLabels appends the allowed properties to the attributes, without allocating the intermediate ones.
+// They are appended sorted by name, so the attributes are emitted in the same order by every run
+func appendPropsLabels(attributes []attribute.KeyValue, props map[string]string) []attribute.KeyValue {
+	for _, k := range slices.Sorted(maps.Keys(props)) {
+		v := props[k]
+		// the entries published by the tests are recorded as the events of their spans, and their display names
+		// are always recorded
+		if k == TestReportEntries || k == TestDisplayName {
+			continue
+		}
+
+		if !propertyAllowed(k) {
+			droppedData.drop(droppedProperties, 1, k)
 			continue
 		}
 
@@ -245,71 +906,187 @@ func (pr *PipeReader) Read() ([]byte, error) {
 		return nil, err
 	}
 
+	// the report is read as is, with its newlines, so the errors are located in it, and the UTF-16 reports keep
+	// the alignment of their code units
 	if (stat.Mode() & os.ModeCharDevice) == 0 {
-		var buf []byte
-		scanner := bufio.NewScanner(os.Stdin)
-
-		// 64KB initial buffer, 1MB max buffer size
-		// was seeing large failure messages causing parsing to fail
-		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
-
-		for scanner.Scan() {
-			buf = append(buf, scanner.Bytes()...)
-		}
-
-		if err := scanner.Err(); err != nil {
-			return nil, err
-		}
-
-		return buf, nil
+		return io.ReadAll(os.Stdin)
 	}
 
 	return nil, fmt.Errorf("there is no data in the pipe")
 }
 
 func Main(ctx context.Context, reader InputReader) error {
+	return exportReport(ctx, func() (*junitReport, error) {
+		return readReport(reader)
+	})
+}
+
+// exportReport exports the telemetry of the report returned by the read function, once the providers are ready, with
+// the given options of the OTLP exporters
+func exportReport(ctx context.Context, read func() (*junitReport, error), options ...otlpOption) (err error) {
 	otlpSrvName := getOtlpServiceName()
 	otlpSrvVersion := getOtlpServiceVersion()
 
+	cfg, err := loadConfig(configFlag)
+	if err != nil {
+		return err
+	}
+	appConfig = cfg
+
+	// parsed before exporting anything, so that an invalid assertion does not go unnoticed
+	gate, err := parseAssertion(assertFlag)
+	if err != nil {
+		return err
+	}
+
+	failOn, err := parseFailOn(failOnFlag)
+	if err != nil {
+		return err
+	}
+
+	systemLog, err := newSystemLogger(systemLogFlag)
+	if err != nil {
+		return err
+	}
+
+	impactMap, err := loadImpactMap(impactMapFlag)
+	if err != nil {
+		return err
+	}
+
+	var state *toolState
+	if stateFlag != "" {
+		state, err = readState(ctx, stateFlag)
+		if err != nil {
+			return err
+		}
+
+		testCoverage = state.Coverage
+		impactMap = stateCoverage(state, impactMap)
+	}
+
+	if baseFlag != "" && state == nil {
+		return errors.New("the state is required to compare the run with the base branch: set it with --state")
+	}
+
 	ctx = initOtelContext(ctx)
 
-	// add additional attributes if provided to the runtime attributes
-	if additionalAttributes != "" {
-		additionalAttrsErrors := []error{}
-
-		addAttrs := strings.Split(additionalAttributes, ",")
-		for _, attr := range addAttrs {
-			kv := strings.Split(attr, "=")
-			if len(kv) == 2 {
-				runtimeAttributes = append(runtimeAttributes, attribute.Key(kv[0]).String(kv[1]))
-			} else {
-				additionalAttrsErrors = append(additionalAttrsErrors,
-					fmt.Errorf("invalid attribute: %s", attr))
+	var sessionContext *persistedContext
+	if contextFileFlag != "" {
+		pc, err := readContextFile(contextFileFlag)
+		if err != nil {
+			return err
+		}
+
+		if pc != nil {
+			// the context file takes precedence over the TRACEPARENT environment variable
+			ctx = pc.extract(ctx)
+			sessionContext = pc
+			if pc.LastSuiteEnd != nil {
+				previousSuiteEnd = *pc.LastSuiteEnd
 			}
 		}
+	}
+
+	if parentContextFlag != "" {
+		pc, err := readContextFile(parentContextFlag)
+		if err != nil {
+			return err
+		}
 
-		if err := errors.Join(additionalAttrsErrors...); err != nil {
-			return fmt.Errorf("failed to add additional attributes: %w", err)
+		if pc == nil {
+			return fmt.Errorf("the parent context file does not exist: %s", parentContextFlag)
 		}
+
+		// the parent context takes precedence over the context file and the TRACEPARENT environment variable
+		ctx = pc.extract(ctx)
+		sessionContext = pc
 	}
 
-	// set the service name that will show up in tracing UIs
-	resAttrs := resource.WithAttributes(
-		semconv.ServiceNameKey.String(otlpSrvName),
-		semconv.ServiceVersionKey.String(otlpSrvVersion),
-	)
-	res, err := resource.New(ctx, resource.WithProcess(), resAttrs)
+	sessionID := getSessionID(sessionContext)
+	if sessionID != "" {
+		runtimeAttributes = append(runtimeAttributes, attribute.Key(SessionID).String(sessionID))
+	}
+
+	if err := addAdditionalAttributes(); err != nil {
+		return err
+	}
+
+	res, err := newResource(ctx, otlpSrvName, otlpSrvVersion)
 	if err != nil {
-		return fmt.Errorf("failed to create OpenTelemetry service name resource: %s", err)
+		return err
 	}
 
-	tracesProvides, err := initTracerProvider(ctx, res)
+	// the exporters report the failed and partially successful exports to the error handler
+	diagnostics := &exportDiagnostics{}
+	otel.SetErrorHandler(diagnostics)
+	// registered before shutting down the providers, so it runs after the last exports
+	defer func() {
+		if verboseFlag {
+			diagnostics.report()
+		}
+
+		signalExportFailure(systemLog, otlpSrvName, diagnostics.err())
+
+		if err == nil && failOnExportErrorsFlag {
+			err = diagnostics.err()
+		}
+	}()
+
+	outputs, err := newSpanOutputs()
+	if err != nil {
+		return err
+	}
+	// registered before the tracer providers, so it runs once all of them exported their spans
+	defer func() {
+		if err := outputs.shutdown(ctx); err != nil {
+			otel.Handle(err)
+		}
+	}()
+
+	// registered before the providers, so it's closed once they exported their last payloads
+	auditLog, err := openAuditLog(auditLogFlag)
+	if err != nil {
+		return err
+	}
+	defer auditLog.close()
+
+	// the exports written to a bundle never reach the collector, so they neither spool their payloads nor flush the
+	// ones spooled on the host
+	var spool *exportSpool
+	if !bundledExport(options) {
+		spool, err = getExportSpool()
+		if err != nil {
+			return err
+		}
+	}
+
+	// the exports failed by an unavailable collector are spooled for the next invocation
+	exporterOptions := slices.Clip(options)
+	if auditLog != nil {
+		exporterOptions = append(exporterOptions, withOtlpAuditLog(auditLog))
+	}
+	if spool != nil {
+		spool.audit = auditLog
+		exporterOptions = append(exporterOptions, withOtlpSpool(spool))
+	}
+
+	if payloadDigestFlag {
+		signingKey, err := loadSigningKey(signingKeyFlag)
+		if err != nil {
+			return err
+		}
+
+		exporterOptions = append(exporterOptions, withOtlpPayloadDigest(signingKey))
+	}
+
+	tracesProvides, err := initTracerProvider(ctx, res, diagnostics, outputs, exporterOptions...)
 	if err != nil {
 		return err
 	}
 	defer tracesProvides.Shutdown(ctx)
 
-	provider, err := initMetricsProvider(ctx, res)
+	provider, err := initMetricsProvider(ctx, res, outputs, exporterOptions...)
 	if err != nil {
 		return fmt.Errorf("failed to initialise pusher: %v", err)
 	}
@@ -322,23 +1099,119 @@ func Main(ctx context.Context, reader InputReader) error {
 		}
 	}()
 
+	report, err := read()
+	if err != nil {
+		return err
+	}
+
+	if spool != nil && otlpFlag {
+		if err := spool.flush(ctx); err != nil {
+			log.Printf("the spooled payloads could not be sent: %v", err)
+		}
+	}
+
+	deviceProviders, err := initDeviceTracerProviders(ctx, res, diagnostics, outputs, report, exporterOptions...)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, provider := range deviceProviders {
+			provider.Shutdown(ctx)
+		}
+	}()
+
+	runCost, err = newCostEstimate(appConfig.Costs, costPerMinuteFlag, runnerTypeFlag)
+	if err != nil {
+		return err
+	}
+
+	// the run is compared with the base branch before creating the spans, and then recorded in the state
+	var run *stateRun
+	if state != nil {
+		run = newStateRun(report.results(), otlpSrvName, checkGitContext(), time.Now())
+		if baseFlag != "" {
+			runComparison = compareRun(state, baseFlag, run)
+		}
+	}
+
+	if err := createTracesAndSpans(ctx, otlpSrvName, sessionID, tracesProvides, provider, deviceProviders, report); err != nil {
+		return err
+	}
+
+	if run != nil {
+		err := updateState(ctx, stateFlag, func(state *toolState) error {
+			state.addRun(run, stateHistoryFlag)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if impactedTestsOutputFlag != "" {
+		if err := writeImpactedTestsOutput(impactedTestsOutputFlag, report, GetScm(repositoryPathFlag), impactMap); err != nil {
+			return err
+		}
+	}
+
+	// the filters only select the spans to create, the results of the run are the ones of all its test cases
+	if gate != nil {
+		if err := gate.check(report.results()); err != nil {
+			return err
+		}
+	}
+
+	return checkFailOn(failOn, summarize(report.results()))
+}
+
+// readReport reads the report from the input files, or from the reader if there are none
+func readReport(reader InputReader) (*junitReport, error) {
+	if inputFlag != "" {
+		report, err := ingestInputs(strings.Split(inputFlag, ","))
+		if err != nil {
+			return nil, fmt.Errorf("failed to ingest the input files: %v", err)
+		}
+
+		return selectReport(report)
+	}
+
 	xmlBuffer, err := reader.Read()
+	if err != nil && emitContextFlag == "" {
+		return nil, fmt.Errorf("failed to read from pipe: %v", err)
+	}
+
+	// a step emitting the context can create the root span of the run without any report
+	if err != nil || len(bytes.TrimSpace(xmlBuffer)) == 0 {
+		return &junitReport{}, nil
+	}
+
+	report, err := parseReport(formatFlag, "", xmlBuffer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ingest the report: %v", err)
+	}
+
+	return selectReport(report)
+}
+
+// selectReport merges the suites of the report by the merge policy, and keeps the suites and the test cases selected
+// by the filters, before their spans are created
+func selectReport(report *junitReport) (*junitReport, error) {
+	report, err := applyMergePolicy(report, mergePolicyFlag)
 	if err != nil {
-		return fmt.Errorf("failed to read from pipe: %v", err)
+		return nil, err
 	}
 
-	suites, err := junit.Ingest(xmlBuffer)
+	filter, err := newReportFilter(includeSuiteFlag, excludeSuiteFlag, includeTestFlag, onlyFailuresFlag, withContextFlag)
 	if err != nil {
-		return fmt.Errorf("failed to ingest JUnit xml: %v", err)
+		return nil, err
 	}
 
-	return createTracesAndSpans(ctx, otlpSrvName, tracesProvides, suites)
+	filter.apply(report)
+	return report, nil
 }
 
 func main() {
-	flag.Parse()
-
-	if err := Main(context.Background(), &PipeReader{}); err != nil {
-		log.Fatal(err)
+	if err := newRootCommand().Execute(); err != nil {
+		os.Exit(1)
 	}
 }
@@ -12,10 +12,16 @@ import (
 	"testing"
 	"time"
 
+	"github.com/mdelapenya/junit2otlp/convert"
 	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/network"
 	"github.com/testcontainers/testcontainers-go/wait"
+	"go.opentelemetry.io/otel/attribute"
+	noopmetric "go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const exporterEndpointKey = "OTEL_EXPORTER_OTLP_ENDPOINT"
@@ -371,3 +377,197 @@ func Test_GetServiceVariable(t *testing.T) {
 		})
 	}
 }
+
+func Test_ParseSpanKind(t *testing.T) {
+	for kind, expected := range map[string]trace.SpanKind{
+		"internal": trace.SpanKindInternal,
+		"server":   trace.SpanKindServer,
+		"Client":   trace.SpanKindClient,
+		"producer": trace.SpanKindProducer,
+		"consumer": trace.SpanKindConsumer,
+	} {
+		t.Run(kind, func(t *testing.T) {
+			spanKind, err := parseSpanKind(kind)
+			require.NoError(t, err)
+			require.Equal(t, expected, spanKind)
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := parseSpanKind("foo")
+		require.Error(t, err)
+	})
+}
+
+func Test_ResourceDetectors(t *testing.T) {
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "team=checkout")
+	defer func(detect bool) { detectResourcesFlag = detect }(detectResourcesFlag)
+
+	// the process attributes are always detected, and the other ones only with --detect-resources
+	t.Run("disabled", func(t *testing.T) {
+		detectResourcesFlag = false
+
+		res, err := newResource(context.Background(), "svc", "1.0.0")
+		require.NoError(t, err)
+
+		_, ok := res.Set().Value(semconv.ProcessPIDKey)
+		require.True(t, ok)
+
+		for _, key := range []attribute.Key{semconv.HostNameKey, semconv.OSTypeKey, semconv.TelemetrySDKNameKey, "team"} {
+			_, ok := res.Set().Value(key)
+			require.False(t, ok, key)
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		detectResourcesFlag = true
+
+		res, err := newResource(context.Background(), "svc", "1.0.0")
+		require.NoError(t, err)
+
+		for _, key := range []attribute.Key{semconv.ProcessPIDKey, semconv.HostNameKey, semconv.OSTypeKey, semconv.TelemetrySDKNameKey} {
+			_, ok := res.Set().Value(key)
+			require.True(t, ok, key)
+		}
+
+		team, ok := res.Set().Value("team")
+		require.True(t, ok)
+		require.Equal(t, "checkout", team.AsString())
+
+		service, ok := res.Set().Value(semconv.ServiceNameKey)
+		require.True(t, ok)
+		require.Equal(t, "svc", service.AsString())
+	})
+}
+
+func Test_NewResource(t *testing.T) {
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "deployment.environment=dev")
+	detectResourcesFlag = true
+	defer func() { detectResourcesFlag = false }()
+
+	t.Run("without environment", func(t *testing.T) {
+		environmentFlag, deploymentRingFlag = "", ""
+
+		res, err := newResource(context.Background(), "svc", "1.0.0")
+		require.NoError(t, err)
+
+		environment, ok := res.Set().Value(semconv.DeploymentEnvironmentKey)
+		require.True(t, ok)
+		require.Equal(t, "dev", environment.AsString())
+
+		_, ok = res.Set().Value(DeploymentRing)
+		require.False(t, ok)
+	})
+
+	// the flags take precedence over OTEL_RESOURCE_ATTRIBUTES
+	t.Run("with environment and ring", func(t *testing.T) {
+		environmentFlag, deploymentRingFlag = "staging", "canary"
+		defer func() { environmentFlag, deploymentRingFlag = "", "" }()
+
+		res, err := newResource(context.Background(), "svc", "1.0.0")
+		require.NoError(t, err)
+
+		environment, ok := res.Set().Value(semconv.DeploymentEnvironmentKey)
+		require.True(t, ok)
+		require.Equal(t, "staging", environment.AsString())
+
+		ring, ok := res.Set().Value(DeploymentRing)
+		require.True(t, ok)
+		require.Equal(t, "canary", ring.AsString())
+	})
+}
+
+// withStdin replaces the standard input with the file, for the duration of the test
+func withStdin(t *testing.T, path string) {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+
+	stdin := os.Stdin
+	os.Stdin = file
+	t.Cleanup(func() {
+		os.Stdin = stdin
+		file.Close()
+	})
+}
+
+func Test_PipeReader(t *testing.T) {
+	t.Run("Error positions", func(t *testing.T) {
+		defer func(input string) { inputFlag = input }(inputFlag)
+		path := filepath.Join("testdata", "TEST-malformed.xml")
+
+		inputFlag = path
+		_, err := readReport(&PipeReader{})
+		require.ErrorContains(t, err, "failed to parse the jUnit report at line 4, column 35")
+
+		// the report piped to the standard input is located at the same line and column as the file
+		inputFlag = ""
+		withStdin(t, path)
+		_, err = readReport(&PipeReader{})
+		require.ErrorContains(t, err, "failed to parse the jUnit report at line 4, column 35")
+	})
+
+	t.Run("UTF-16", func(t *testing.T) {
+		withStdin(t, filepath.Join("testdata", "TEST-utf16.xml"))
+
+		report, err := readReport(&PipeReader{})
+		require.NoError(t, err)
+		require.Len(t, report.suites, 1)
+		require.Len(t, report.suites[0].Tests, 2)
+		require.Equal(t, "Get-Café", report.suites[0].Tests[0].Name)
+		require.Equal(t, "Get-Menu", report.suites[0].Tests[1].Name)
+	})
+}
+
+// Test_ConvertLibrary checks that the spans of the library have the names and the attributes of the ones of the
+// command, so both are queried alike
+func Test_ConvertLibrary(t *testing.T) {
+	defer func(input string, otlp bool, snapshot string) {
+		inputFlag, otlpFlag, snapshotFlag = input, otlp, snapshot
+	}(inputFlag, otlpFlag, snapshotFlag)
+	defer func(attrs []attribute.KeyValue) { runtimeAttributes = attrs }(runtimeAttributes)
+
+	report := []byte(`<testsuite name="checkout" package="com.acme">
+		<properties><property name="build" value="42"/></properties>
+		<testcase name="adds" classname="com.acme.Cart" time="0.1"/>
+		<testcase name="ships" classname="com.acme.Shipping" time="0.2"><failure message="late">at Shipping.java:12</failure></testcase>
+	</testsuite>`)
+
+	dir := t.TempDir()
+	inputFlag = filepath.Join(dir, "TEST-checkout.xml")
+	require.NoError(t, os.WriteFile(inputFlag, report, 0o644))
+	otlpFlag, snapshotFlag = false, filepath.Join(dir, "snapshot.json")
+	require.NoError(t, Main(context.Background(), &PipeReader{}))
+
+	data, err := os.ReadFile(snapshotFlag)
+	require.NoError(t, err)
+	document := map[string]any{}
+	require.NoError(t, json.Unmarshal(data, &document))
+
+	commandKeys := map[string]map[string]bool{}
+	for _, resource := range jsonObjects(document["resourceSpans"]) {
+		for _, scope := range jsonObjects(resource["scopeSpans"]) {
+			for _, span := range jsonObjects(scope["spans"]) {
+				keys := map[string]bool{}
+				for _, attr := range jsonObjects(span["attributes"]) {
+					keys[attr["key"].(string)] = true
+				}
+				commandKeys[span["name"].(string)] = keys
+			}
+		}
+	}
+
+	recorder := tracetest.NewSpanRecorder()
+	converter, err := convert.New(convert.WithSpanProcessor(recorder), convert.WithMeterProvider(noopmetric.NewMeterProvider()))
+	require.NoError(t, err)
+	require.NoError(t, converter.Convert(context.Background(), bytes.NewReader(report)))
+
+	require.Len(t, recorder.Ended(), len(commandKeys))
+	for _, span := range recorder.Ended() {
+		keys, ok := commandKeys[span.Name()]
+		require.True(t, ok, span.Name())
+
+		for _, attr := range span.Attributes() {
+			require.True(t, keys[string(attr.Key)], "the attribute %s of the span %s", attr.Key, span.Name())
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/joshdk/go-junit"
+	"gopkg.in/yaml.v3"
+)
+
+// mappingsAllFormats the key of the mappings applied to the reports of every format, before the ones of their format
+const mappingsAllFormats = "all"
+
+// mappingPropertyPrefix the prefix of the fields of the properties of the suites and the test cases
+const mappingPropertyPrefix = "properties."
+
+// the fields of the suites and the test cases that can be mapped, besides their properties
+var (
+	suiteMappingFields = []string{"name", "package", "systemout", "systemerr"}
+	testMappingFields  = []string{"name", "classname", "message", "systemout", "systemerr"}
+)
+
+// reportMappings the user-defined mappings of the fields of the suites and the test cases of each report format, so
+// the unusual in-house reporters are accommodated without code changes. The properties of the suites and the test
+// cases are the attributes of their spans, and their names are the names of their spans
+type reportMappings map[string]*formatMapping
+
+type formatMapping struct {
+	Suites []fieldMapping `yaml:"suites"`
+	Tests  []fieldMapping `yaml:"tests"`
+}
+
+// fieldMapping renames a field, combines several fields in one, joined by the separator, or drops them. The source
+// fields are removed, unless they are kept
+type fieldMapping struct {
+	From      mappingFields `yaml:"from"`
+	To        string        `yaml:"to"`
+	Drop      bool          `yaml:"drop"`
+	Separator string        `yaml:"separator"`
+	Keep      bool          `yaml:"keep"`
+}
+
+// mappingFields the source fields of a mapping, as a field or a list of fields
+type mappingFields []string
+
+func (f *mappingFields) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*f = mappingFields{node.Value}
+		return nil
+	}
+
+	fields := []string{}
+	if err := node.Decode(&fields); err != nil {
+		return err
+	}
+
+	*f = fields
+	return nil
+}
+
+func (m reportMappings) validate() error {
+	for format, mapping := range m {
+		if format != mappingsAllFormats {
+			if _, ok := reportParsers[format]; !ok {
+				return fmt.Errorf("unknown report format %q: valid values are %s and %s", format, mappingsAllFormats, strings.Join(supportedReportFormats()[1:], ", "))
+			}
+		}
+		if mapping == nil {
+			return fmt.Errorf("the format %q has no mappings", format)
+		}
+
+		for i, field := range mapping.Suites {
+			if err := field.validate(suiteMappingFields); err != nil {
+				return fmt.Errorf("invalid mapping %d of the suites of the format %q: %w", i, format, err)
+			}
+		}
+		for i, field := range mapping.Tests {
+			if err := field.validate(testMappingFields); err != nil {
+				return fmt.Errorf("invalid mapping %d of the test cases of the format %q: %w", i, format, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (f fieldMapping) validate(fields []string) error {
+	if len(f.From) == 0 {
+		return fmt.Errorf("the source field is mandatory")
+	}
+
+	for _, from := range f.From {
+		if !isMappingField(from, fields) {
+			return fmt.Errorf("unknown field %q: valid values are %s, or %s<name>", from, strings.Join(fields, ", "), mappingPropertyPrefix)
+		}
+	}
+
+	switch {
+	case f.Drop && f.To != "":
+		return fmt.Errorf("a dropped field can't be mapped to %q", f.To)
+	case !f.Drop && f.To == "":
+		return fmt.Errorf("the target field is mandatory, unless the field is dropped")
+	case f.To != "" && !isMappingField(f.To, fields):
+		return fmt.Errorf("unknown field %q: valid values are %s, or %s<name>", f.To, strings.Join(fields, ", "), mappingPropertyPrefix)
+	}
+
+	return nil
+}
+
+func isMappingField(field string, fields []string) bool {
+	if name, found := strings.CutPrefix(field, mappingPropertyPrefix); found {
+		return name != ""
+	}
+
+	return slices.Contains(fields, field)
+}
+
+// apply maps the fields of the suites of the report, and their test cases, with the mappings of all the formats
+// and then the ones of the format of the report
+func (m reportMappings) apply(format string, report *junitReport) {
+	for _, key := range []string{mappingsAllFormats, format} {
+		mapping, ok := m[key]
+		if !ok || mapping == nil {
+			continue
+		}
+
+		for i := range report.suites {
+			mapping.applySuite(&report.suites[i])
+		}
+	}
+}
+
+func (m *formatMapping) applySuite(suite *junit.Suite) {
+	for _, field := range m.Suites {
+		field.apply(suiteFields{suite})
+	}
+
+	for i := range suite.Tests {
+		for _, field := range m.Tests {
+			field.apply(testFields{&suite.Tests[i]})
+		}
+	}
+
+	for i := range suite.Suites {
+		m.applySuite(&suite.Suites[i])
+	}
+}
+
+// mappedFields gets and sets the fields of a suite or a test case by name, deleting the properties set to empty
+type mappedFields interface {
+	get(field string) string
+	set(field string, value string)
+}
+
+func (f fieldMapping) apply(fields mappedFields) {
+	values := []string{}
+	for _, from := range f.From {
+		if value := fields.get(from); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	// the fields are only combined if they are present, so the target keeps its value otherwise
+	if !f.Drop && len(values) == 0 {
+		return
+	}
+
+	if !f.Keep || f.Drop {
+		for _, from := range f.From {
+			fields.set(from, "")
+		}
+	}
+
+	if !f.Drop {
+		fields.set(f.To, strings.Join(values, f.Separator))
+	}
+}
+
+type suiteFields struct {
+	suite *junit.Suite
+}
+
+func (f suiteFields) get(field string) string {
+	switch field {
+	case "name":
+		return f.suite.Name
+	case "package":
+		return f.suite.Package
+	case "systemout":
+		return f.suite.SystemOut
+	case "systemerr":
+		return f.suite.SystemErr
+	default:
+		return f.suite.Properties[strings.TrimPrefix(field, mappingPropertyPrefix)]
+	}
+}
+
+func (f suiteFields) set(field string, value string) {
+	switch field {
+	case "name":
+		f.suite.Name = value
+	case "package":
+		f.suite.Package = value
+	case "systemout":
+		f.suite.SystemOut = value
+	case "systemerr":
+		f.suite.SystemErr = value
+	default:
+		f.suite.Properties = setMappedProperty(f.suite.Properties, strings.TrimPrefix(field, mappingPropertyPrefix), value)
+	}
+}
+
+type testFields struct {
+	test *junit.Test
+}
+
+func (f testFields) get(field string) string {
+	switch field {
+	case "name":
+		return f.test.Name
+	case "classname":
+		return f.test.Classname
+	case "message":
+		return f.test.Message
+	case "systemout":
+		return f.test.SystemOut
+	case "systemerr":
+		return f.test.SystemErr
+	default:
+		return f.test.Properties[strings.TrimPrefix(field, mappingPropertyPrefix)]
+	}
+}
+
+func (f testFields) set(field string, value string) {
+	switch field {
+	case "name":
+		f.test.Name = value
+	case "classname":
+		f.test.Classname = value
+	case "message":
+		f.test.Message = value
+	case "systemout":
+		f.test.SystemOut = value
+	case "systemerr":
+		f.test.SystemErr = value
+	default:
+		f.test.Properties = setMappedProperty(f.test.Properties, strings.TrimPrefix(field, mappingPropertyPrefix), value)
+	}
+}
+
+// setMappedProperty sets the property, or deletes it if the value is empty, copying the properties, as they can be
+// shared by the test cases of a suite
+func setMappedProperty(properties map[string]string, name string, value string) map[string]string {
+	if value == "" {
+		if _, ok := properties[name]; !ok {
+			return properties
+		}
+
+		properties = mergeProperties(properties, nil)
+		delete(properties, name)
+		return properties
+	}
+
+	return mergeProperties(properties, map[string]string{name: value})
+}
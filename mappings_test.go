@@ -0,0 +1,92 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportMappings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+mappings:
+  all:
+    tests:
+      - from: properties.internal
+        drop: true
+  junit:
+    suites:
+      - from: properties.build_id
+        to: properties.ci.build.id
+    tests:
+      - from: [properties.module, name]
+        to: name
+        separator: " / "
+      - from: systemout
+        to: properties.log
+        keep: true
+  newman:
+    tests:
+      - from: properties.missing
+        to: classname
+`), 0o644))
+
+	cfg, err := loadConfig(path)
+	require.NoError(t, err)
+
+	original := appConfig
+	appConfig = cfg
+	defer func() { appConfig = original }()
+
+	data := []byte(`<testsuites><testsuite name="checkout">
+		<properties><property name="build_id" value="1234"/></properties>
+		<testcase name="pays" classname="Checkout" time="0.1" module="payments" internal="x">
+			<system-out>paid</system-out>
+		</testcase>
+		<testcase name="ships" classname="Checkout" time="0.1"/>
+	</testsuite></testsuites>`)
+
+	report, err := parseReport(reportFormatAuto, "", data)
+	require.NoError(t, err)
+
+	suite := report.suites[0]
+	require.Equal(t, "1234", suite.Properties["ci.build.id"])
+	require.NotContains(t, suite.Properties, "build_id")
+
+	// the fields are combined, the dropped ones are removed, and the kept ones are left in place
+	pays := suite.Tests[0]
+	require.Equal(t, "payments / pays", pays.Name)
+	require.NotContains(t, pays.Properties, "module")
+	require.NotContains(t, pays.Properties, "internal")
+	require.Equal(t, "paid", pays.SystemOut)
+	require.Equal(t, "paid", pays.Properties["log"])
+
+	// the target keeps its value without the source fields
+	ships := suite.Tests[1]
+	require.Equal(t, "ships", ships.Name)
+	require.NotContains(t, ships.Properties, "log")
+}
+
+func TestReportMappingsValidation(t *testing.T) {
+	tests := map[string]string{
+		"unknown format":  "mappings:\n  xunit:\n    tests:\n      - from: name\n        to: classname\n",
+		"unknown field":   "mappings:\n  junit:\n    tests:\n      - from: package\n        to: name\n",
+		"missing target":  "mappings:\n  junit:\n    suites:\n      - from: name\n",
+		"dropped target":  "mappings:\n  junit:\n    suites:\n      - from: name\n        to: package\n        drop: true\n",
+		"missing source":  "mappings:\n  junit:\n    suites:\n      - to: package\n",
+		"empty property":  "mappings:\n  junit:\n    suites:\n      - from: properties.\n        drop: true\n",
+		"missing mapping": "mappings:\n  junit:\n",
+	}
+
+	for name, config := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yml")
+			require.NoError(t, os.WriteFile(path, []byte(config), 0o644))
+
+			_, err := loadConfig(path)
+			require.ErrorContains(t, err, "invalid mappings")
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joshdk/go-junit"
+)
+
+// the policies for the suites with the same name, i.e. the ones Gradle splits in a file per test class
+const (
+	mergePolicyKeepSeparate = "keep-separate"
+	mergePolicyBySuiteName  = "merge-by-suite-name"
+)
+
+// mergedCountAttributes the attributes of the raw suites with counts, summed when merging them
+var mergedCountAttributes = []string{"tests", "failures", "errors", "skipped"}
+
+// applyMergePolicy applies the merge policy to the suites of the report: keeping the suites with the same name as
+// separate suite spans, or merging them into one. The suites that ran in different devices are never merged
+func applyMergePolicy(report *junitReport, policy string) (*junitReport, error) {
+	switch strings.ToLower(strings.TrimSpace(policy)) {
+	case "", mergePolicyKeepSeparate:
+		return report, nil
+	case mergePolicyBySuiteName:
+	default:
+		return nil, fmt.Errorf("invalid merge policy %q: valid values are %s and %s", policy, mergePolicyKeepSeparate, mergePolicyBySuiteName)
+	}
+
+	merged := &junitReport{rawRoot: report.rawRoot, format: report.format, framework: report.framework}
+	indexes := map[string]int{}
+
+	for i, suite := range report.suites {
+		key := suite.Name
+		if d := report.device(i); d != nil {
+			key += "\x00" + d.key()
+		}
+
+		index, ok := indexes[key]
+		if !ok {
+			indexes[key] = len(merged.suites)
+			merged.suites = append(merged.suites, suite)
+			merged.rawSuites = append(merged.rawSuites, report.rawSuite(i))
+			merged.devices = append(merged.devices, report.device(i))
+			merged.sources = append(merged.sources, report.source(i))
+			continue
+		}
+
+		merged.suites[index] = mergeSuites(merged.suites[index], suite)
+		merged.rawSuites[index] = mergeRawSuites(merged.rawSuites[index], report.rawSuite(i))
+	}
+
+	return merged, nil
+}
+
+// mergeSuites merges the test cases and the nested suites of the second suite into the first one, in that order.
+// The properties of the first suite take precedence, and the outputs are concatenated
+func mergeSuites(first junit.Suite, second junit.Suite) junit.Suite {
+	merged := first
+	merged.Tests = append(append([]junit.Test{}, first.Tests...), second.Tests...)
+	merged.Suites = append(append([]junit.Suite{}, first.Suites...), second.Suites...)
+	merged.SystemOut = joinOutputs(first.SystemOut, second.SystemOut)
+	merged.SystemErr = joinOutputs(first.SystemErr, second.SystemErr)
+
+	merged.Properties = maps.Clone(second.Properties)
+	if merged.Properties == nil {
+		merged.Properties = map[string]string{}
+	}
+	maps.Copy(merged.Properties, first.Properties)
+
+	if merged.Package == "" {
+		merged.Package = second.Package
+	}
+
+	merged.Aggregate()
+
+	return merged
+}
+
+// mergeRawSuites merges the raw XML elements of the suites, keeping the attributes of the first one, except the
+// timestamp, which is the earliest one, and summing the declared counts, which are dropped if any of them is
+// missing or not a number
+func mergeRawSuites(first *xmlElement, second *xmlElement) *xmlElement {
+	merged := &xmlElement{
+		Name:     first.Name,
+		Attrs:    maps.Clone(first.Attrs),
+		Children: append(append([]*xmlElement{}, first.Children...), second.Children...),
+	}
+	if merged.Attrs == nil {
+		merged.Attrs = map[string]string{}
+	}
+
+	for _, name := range mergedCountAttributes {
+		a, errA := strconv.Atoi(first.Attrs[name])
+		b, errB := strconv.Atoi(second.Attrs[name])
+		if errA != nil || errB != nil {
+			delete(merged.Attrs, name)
+			continue
+		}
+
+		merged.Attrs[name] = strconv.Itoa(a + b)
+	}
+
+	// both timestamps are parsed in the same time zone, so the ones without a time zone can be compared
+	if timestamp, ok := second.Attr(suiteTimestampAttribute); ok {
+		current, err := parseTimestamp(merged.Attrs[suiteTimestampAttribute], time.UTC)
+		other, otherErr := parseTimestamp(timestamp, time.UTC)
+		if otherErr == nil && (err != nil || other.Before(current)) {
+			merged.Attrs[suiteTimestampAttribute] = timestamp
+		}
+	}
+
+	return merged
+}
+
+func joinOutputs(first string, second string) string {
+	if first == "" || second == "" {
+		return first + second
+	}
+
+	return first + "\n" + second
+}
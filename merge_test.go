@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyMergePolicy(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"TEST-LoginTest.xml": `<testsuite name="integration" tests="2" failures="1" timestamp="2024-01-01T12:00:00Z">
+  <properties><property name="browser" value="chrome"/></properties>
+  <testcase name="TestLogin" time="1"/>
+  <testcase name="TestLogout" time="1"><failure message="boom"/></testcase>
+  <system-out>login</system-out>
+</testsuite>`,
+		"TEST-CartTest.xml": `<testsuite name="integration" tests="1" failures="0" timestamp="2024-01-01T12:00:02Z">
+  <properties><property name="browser" value="firefox"/><property name="cart" value="true"/></properties>
+  <testcase name="TestCheckout" time="2"/>
+  <system-out>cart</system-out>
+</testsuite>`,
+		"TEST-UnitTest.xml": `<testsuite name="unit" tests="1"><testcase name="TestSum" time="0.1"/></testsuite>`,
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	report, err := ingestInputs([]string{dir})
+	require.NoError(t, err)
+	require.Len(t, report.suites, 3)
+
+	t.Run("Keep separate", func(t *testing.T) {
+		kept, err := applyMergePolicy(report, mergePolicyKeepSeparate)
+		require.NoError(t, err)
+		require.Same(t, report, kept)
+	})
+
+	t.Run("Merge by suite name", func(t *testing.T) {
+		merged, err := applyMergePolicy(report, mergePolicyBySuiteName)
+		require.NoError(t, err)
+		require.Len(t, merged.suites, 2)
+		require.Len(t, merged.rawSuites, 2)
+		require.Len(t, merged.devices, 2)
+
+		// the files are read in lexical order
+		suite := merged.suites[0]
+		require.Equal(t, "integration", suite.Name)
+		require.Equal(t, []string{"TestCheckout", "TestLogin", "TestLogout"}, []string{suite.Tests[0].Name, suite.Tests[1].Name, suite.Tests[2].Name})
+		require.Equal(t, 3, suite.Totals.Tests)
+		require.Equal(t, 1, suite.Totals.Failed)
+		require.Equal(t, "cart\nlogin", suite.SystemOut)
+		require.Equal(t, "firefox", suite.Properties["browser"])
+		require.Equal(t, "true", suite.Properties["cart"])
+
+		raw := merged.rawSuite(0)
+		require.Equal(t, "2024-01-01T12:00:00Z", raw.Attrs["timestamp"])
+		require.Equal(t, "3", raw.Attrs["tests"])
+		require.Equal(t, "1", raw.Attrs["failures"])
+		require.NotContains(t, raw.Attrs, "errors")
+		require.Len(t, raw.ChildrenNamed("testcase"), 3)
+		require.True(t, suiteIsConsistent(raw, suite.Totals.Tests, suite.Totals.Failed, suite.Totals.Error, suite.Totals.Skipped))
+
+		require.Equal(t, "unit", merged.suites[1].Name)
+	})
+
+	t.Run("Devices are not merged", func(t *testing.T) {
+		devices := &junitReport{
+			suites:  report.suites[:2],
+			devices: []*device{{Model: "Pixel2"}, {Model: "Pixel3"}},
+		}
+
+		merged, err := applyMergePolicy(devices, mergePolicyBySuiteName)
+		require.NoError(t, err)
+		require.Len(t, merged.suites, 2)
+	})
+
+	t.Run("Invalid policy", func(t *testing.T) {
+		_, err := applyMergePolicy(report, "merge-all")
+		require.Error(t, err)
+	})
+}
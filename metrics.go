@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/joshdk/go-junit"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/exemplar"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+const (
+	temporalityCumulative = "cumulative"
+	temporalityDelta      = "delta"
+	temporalityLowMemory  = "lowmemory"
+)
+
+const (
+	exemplarsAlwaysOn   = "always_on"
+	exemplarsAlwaysOff  = "always_off"
+	exemplarsTraceBased = "trace_based"
+)
+
+// durationHistogramKeys the attributes identifying each series of the test duration histogram. Any other
+// attribute recorded in the histogram, such as the test name, is only kept in the exemplars
+var durationHistogramKeys = []attribute.Key{
+	semconv.CodeNamespaceKey,
+	attribute.Key(TestsSuiteName),
+	attribute.Key(TestStatus),
+}
+
+// defaultHistogramBuckets the bucket boundaries, in milliseconds, for the test duration histogram
+var defaultHistogramBuckets = []float64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000, 300000}
+
+// getMetricsTemporality the precedence order is: flag > OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE > cumulative
+func getMetricsTemporality() string {
+	return strings.ToLower(getOtlpEnvVar(metricsTemporalityFlag, "OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE", temporalityCumulative))
+}
+
+// temporalitySelector returns the temporality selector for the given preference, following the
+// OpenTelemetry specification for the OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE values
+func temporalitySelector(preference string) (sdkmetric.TemporalitySelector, error) {
+	switch preference {
+	case temporalityCumulative:
+		return sdkmetric.DefaultTemporalitySelector, nil
+	case temporalityDelta:
+		return func(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+			switch kind {
+			case sdkmetric.InstrumentKindUpDownCounter, sdkmetric.InstrumentKindObservableUpDownCounter:
+				return metricdata.CumulativeTemporality
+			default:
+				return metricdata.DeltaTemporality
+			}
+		}, nil
+	case temporalityLowMemory:
+		return func(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+			switch kind {
+			case sdkmetric.InstrumentKindCounter, sdkmetric.InstrumentKindHistogram:
+				return metricdata.DeltaTemporality
+			default:
+				return metricdata.CumulativeTemporality
+			}
+		}, nil
+	default:
+		return nil, fmt.Errorf("invalid metrics temporality: %s", preference)
+	}
+}
+
+// getExemplarFilter the precedence order is: flag > OTEL_METRICS_EXEMPLAR_FILTER > trace_based
+func getExemplarFilter() (exemplar.Filter, error) {
+	filter := strings.ToLower(getOtlpEnvVar(exemplarsFlag, "OTEL_METRICS_EXEMPLAR_FILTER", exemplarsTraceBased))
+
+	switch filter {
+	case exemplarsAlwaysOn:
+		return exemplar.AlwaysOnFilter, nil
+	case exemplarsAlwaysOff:
+		return exemplar.AlwaysOffFilter, nil
+	case exemplarsTraceBased:
+		return exemplar.TraceBasedFilter, nil
+	default:
+		return nil, fmt.Errorf("invalid exemplar filter: %s", filter)
+	}
+}
+
+// durationHistogramView configures the buckets of the test duration histogram, keeping the test identity out of
+// the series, so that it's only present in the exemplars pointing to the test spans
+func durationHistogramView(buckets []float64) sdkmetric.View {
+	return sdkmetric.NewView(
+		sdkmetric.Instrument{Name: TestCaseDurationHistogram},
+		sdkmetric.Stream{
+			Aggregation:     sdkmetric.AggregationExplicitBucketHistogram{Boundaries: buckets},
+			AttributeFilter: attribute.NewAllowKeysFilter(durationHistogramKeys...),
+		},
+	)
+}
+
+// getHistogramBuckets the precedence order is: flag > JUNIT2OTLP_HISTOGRAM_BUCKETS > default buckets
+func getHistogramBuckets() ([]float64, error) {
+	buckets := getOtlpEnvVar(histogramBucketsFlag, "JUNIT2OTLP_HISTOGRAM_BUCKETS", "")
+	if buckets == "" {
+		return defaultHistogramBuckets, nil
+	}
+
+	return parseHistogramBuckets(buckets)
+}
+
+// parseHistogramBuckets parses a comma separated list of increasing bucket boundaries
+func parseHistogramBuckets(buckets string) ([]float64, error) {
+	boundaries := []float64{}
+	for _, bucket := range strings.Split(buckets, ",") {
+		boundary, err := strconv.ParseFloat(strings.TrimSpace(bucket), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid histogram bucket %q: %w", bucket, err)
+		}
+
+		if len(boundaries) > 0 && boundary <= boundaries[len(boundaries)-1] {
+			return nil, fmt.Errorf("histogram buckets must be in increasing order: %s", buckets)
+		}
+
+		boundaries = append(boundaries, boundary)
+	}
+
+	return boundaries, nil
+}
+
+// defaultPerTestMetricsLimit the maximum number of distinct test cases with their own series in the per-test metrics
+const defaultPerTestMetricsLimit = 1000
+
+// testCaseMetrics the metrics recorded for each test case: the duration histogram, whose series do not include the
+// test identity, and the opt-in per-test metrics, with one series per test case up to the cardinality limit
+type testCaseMetrics struct {
+	durationHistogram metric.Int64Histogram
+	failuresCounter   metric.Int64Counter
+	errorsCounter     metric.Int64Counter
+	failedGauge       metric.Int64Gauge
+	executionsCounter metric.Int64Counter
+	limit             int
+	mu                sync.Mutex
+	ids               map[string]struct{}
+	dropped           int
+}
+
+// newTestCaseMetrics creates the instruments of the test case metrics, enabling the per-test metrics with
+// the given cardinality limit if it's greater than zero
+func newTestCaseMetrics(meter metric.Meter, perTestLimit int) *testCaseMetrics {
+	m := &testCaseMetrics{
+		durationHistogram: createIntHistogram(meter, TestCaseDurationHistogram, "Duration of the test cases"),
+		failuresCounter:   createIntCounter(meter, TestsFailuresCount, "Total number of test cases with a failure, by exception type"),
+		errorsCounter:     createIntCounter(meter, TestsErrorsCount, "Total number of test cases with an error, by exception type"),
+		limit:             perTestLimit,
+		ids:               map[string]struct{}{},
+	}
+
+	if perTestLimit > 0 {
+		// Accumulators always return nil errors
+		m.failedGauge, _ = meter.Int64Gauge(TestCaseFailedGauge, metric.WithDescription("If the last execution of the test case failed (1) or not (0)"))
+		m.executionsCounter = createIntCounter(meter, TestCaseExecutionsCount, "Total number of executions of the test case")
+	}
+
+	return m
+}
+
+// perTestMetricsLimit returns the cardinality limit of the per-test metrics, or zero if they are disabled
+func perTestMetricsLimit() int {
+	if !perTestMetricsFlag {
+		return 0
+	}
+
+	return perTestMetricsLimitFlag
+}
+
+// record records the duration of the test case within the context of its span, so the exemplars point to it,
+// and the per-test metrics if they are enabled and the test case is within the cardinality limit
+func (m *testCaseMetrics) record(ctx context.Context, suite junit.Suite, test junit.Test, testName string, status string) {
+	m.durationHistogram.Record(ctx, test.Duration.Milliseconds(), metric.WithAttributeSet(attribute.NewSet(
+		semconv.CodeNamespaceKey.String(suite.Package),
+		semconv.CodeFunctionKey.String(testName),
+		attribute.Key(TestsSuiteName).String(suite.Name),
+		attribute.Key(TestClassName).String(test.Classname),
+		attribute.Key(TestStatus).String(status),
+	)))
+
+	// the failures are the failed assertions, and the errors are the unexpected exceptions
+	outcomeAttributes := metric.WithAttributes(
+		attribute.Key(TestsSuiteName).String(suite.Name),
+		semconv.ExceptionTypeKey.String(exceptionType(test)),
+	)
+	switch test.Status {
+	case junit.StatusFailed:
+		m.failuresCounter.Add(ctx, 1, outcomeAttributes)
+	case junit.StatusError:
+		m.errorsCounter.Add(ctx, 1, outcomeAttributes)
+	}
+
+	if m.limit <= 0 {
+		return
+	}
+
+	id := testMetricID(suite, test, testName)
+	if !m.allow(id) {
+		return
+	}
+
+	idAttributes := []attribute.KeyValue{
+		attribute.Key(TestMetricID).String(id),
+		attribute.Key(TestsSuiteName).String(suite.Name),
+	}
+
+	failed := int64(0)
+	if status == string(junit.StatusFailed) || status == string(junit.StatusError) {
+		failed = 1
+	}
+
+	m.failedGauge.Record(ctx, failed, metric.WithAttributes(idAttributes...))
+	m.executionsCounter.Add(ctx, 1, metric.WithAttributes(append(idAttributes, attribute.Key(TestStatus).String(status))...))
+}
+
+// allow returns if the test case has its own series in the per-test metrics, counting the ones dropped once
+// the cardinality limit is reached
+func (m *testCaseMetrics) allow(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.ids[id]; ok {
+		return true
+	}
+
+	if len(m.ids) >= m.limit {
+		m.dropped++
+		droppedData.drop(droppedPerTestSeries, 1, id)
+		return false
+	}
+
+	m.ids[id] = struct{}{}
+	return true
+}
+
+// testMetricID returns the identifier of the test case in the per-test metrics: its class name, or its suite
+// name if there is no class name, followed by its name
+func testMetricID(suite junit.Suite, test junit.Test, testName string) string {
+	if test.Classname != "" {
+		return test.Classname + "." + testName
+	}
+
+	return suite.Name + "." + testName
+}
+
+// exceptionType returns the type of the failure or the error of the test case, i.e. java.lang.AssertionError
+func exceptionType(test junit.Test) string {
+	if junitErr, ok := test.Error.(junit.Error); ok {
+		return junitErr.Type
+	}
+
+	return ""
+}
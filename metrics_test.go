@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+func TestTemporalitySelector(t *testing.T) {
+	t.Run("cumulative", func(t *testing.T) {
+		selector, err := temporalitySelector(temporalityCumulative)
+		require.NoError(t, err)
+		require.Equal(t, metricdata.CumulativeTemporality, selector(sdkmetric.InstrumentKindCounter))
+		require.Equal(t, metricdata.CumulativeTemporality, selector(sdkmetric.InstrumentKindHistogram))
+	})
+
+	t.Run("delta", func(t *testing.T) {
+		selector, err := temporalitySelector(temporalityDelta)
+		require.NoError(t, err)
+		require.Equal(t, metricdata.DeltaTemporality, selector(sdkmetric.InstrumentKindCounter))
+		require.Equal(t, metricdata.DeltaTemporality, selector(sdkmetric.InstrumentKindHistogram))
+		require.Equal(t, metricdata.CumulativeTemporality, selector(sdkmetric.InstrumentKindUpDownCounter))
+	})
+
+	t.Run("lowmemory", func(t *testing.T) {
+		selector, err := temporalitySelector(temporalityLowMemory)
+		require.NoError(t, err)
+		require.Equal(t, metricdata.DeltaTemporality, selector(sdkmetric.InstrumentKindCounter))
+		require.Equal(t, metricdata.CumulativeTemporality, selector(sdkmetric.InstrumentKindObservableCounter))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := temporalitySelector("foo")
+		require.Error(t, err)
+	})
+
+	t.Run("env/no-flag", func(t *testing.T) {
+		t.Setenv("OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE", "Delta")
+		metricsTemporalityFlag = ""
+
+		require.Equal(t, temporalityDelta, getMetricsTemporality())
+	})
+}
+
+func TestParseHistogramBuckets(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		buckets, err := parseHistogramBuckets("10, 100,1000.5")
+		require.NoError(t, err)
+		require.Equal(t, []float64{10, 100, 1000.5}, buckets)
+	})
+
+	t.Run("not a number", func(t *testing.T) {
+		_, err := parseHistogramBuckets("10,foo")
+		require.Error(t, err)
+	})
+
+	t.Run("not increasing", func(t *testing.T) {
+		_, err := parseHistogramBuckets("100,10")
+		require.Error(t, err)
+	})
+}
+
+func TestGetExemplarFilter(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		t.Setenv("OTEL_METRICS_EXEMPLAR_FILTER", "")
+		exemplarsFlag = ""
+
+		filter, err := getExemplarFilter()
+		require.NoError(t, err)
+		require.NotNil(t, filter)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Setenv("OTEL_METRICS_EXEMPLAR_FILTER", "foo")
+		exemplarsFlag = ""
+
+		_, err := getExemplarFilter()
+		require.Error(t, err)
+	})
+}
+
+func TestTestCaseMetrics(t *testing.T) {
+	collect := func(t *testing.T, limit int, tests ...junit.Test) (map[string]metricdata.Aggregation, *testCaseMetrics) {
+		reader := sdkmetric.NewManualReader()
+		meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+
+		m := newTestCaseMetrics(meter, limit)
+		suite := junit.Suite{Name: "suite"}
+		for _, test := range tests {
+			m.record(context.Background(), suite, test, test.Name, string(test.Status))
+		}
+
+		rm := metricdata.ResourceMetrics{}
+		require.NoError(t, reader.Collect(context.Background(), &rm))
+
+		metrics := map[string]metricdata.Aggregation{}
+		for _, sm := range rm.ScopeMetrics {
+			for _, metric := range sm.Metrics {
+				metrics[metric.Name] = metric.Data
+			}
+		}
+
+		return metrics, m
+	}
+
+	passed := junit.Test{Name: "TestLogin", Classname: "auth", Status: junit.StatusPassed}
+	failed := junit.Test{Name: "TestCheckout", Classname: "cart", Status: junit.StatusFailed}
+	noClass := junit.Test{Name: "TestSearch", Status: junit.StatusError}
+
+	t.Run("disabled", func(t *testing.T) {
+		metrics, _ := collect(t, 0, passed, failed)
+
+		require.Contains(t, metrics, TestCaseDurationHistogram)
+		require.NotContains(t, metrics, TestCaseFailedGauge)
+		require.NotContains(t, metrics, TestCaseExecutionsCount)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		metrics, m := collect(t, 10, passed, failed, noClass)
+
+		gauge := metrics[TestCaseFailedGauge].(metricdata.Gauge[int64])
+		values := map[string]int64{}
+		for _, dp := range gauge.DataPoints {
+			id, _ := dp.Attributes.Value(attribute.Key(TestMetricID))
+			values[id.AsString()] = dp.Value
+		}
+		require.Equal(t, map[string]int64{"auth.TestLogin": 0, "cart.TestCheckout": 1, "suite.TestSearch": 1}, values)
+
+		counter := metrics[TestCaseExecutionsCount].(metricdata.Sum[int64])
+		require.Len(t, counter.DataPoints, 3)
+		require.Zero(t, m.dropped)
+	})
+
+	t.Run("failures and errors", func(t *testing.T) {
+		assertion := junit.Test{Name: "TestPay", Status: junit.StatusFailed, Error: junit.Error{Type: "java.lang.AssertionError"}}
+		exception := junit.Test{Name: "TestRefund", Status: junit.StatusError, Error: junit.Error{Type: "java.lang.NullPointerException"}}
+		metrics, _ := collect(t, 0, passed, failed, assertion, exception)
+
+		outcomes := func(name string) map[string]int64 {
+			values := map[string]int64{}
+			for _, dp := range metrics[name].(metricdata.Sum[int64]).DataPoints {
+				errType, _ := dp.Attributes.Value(semconv.ExceptionTypeKey)
+				values[errType.AsString()] = dp.Value
+			}
+			return values
+		}
+
+		require.Equal(t, map[string]int64{"": 1, "java.lang.AssertionError": 1}, outcomes(TestsFailuresCount))
+		require.Equal(t, map[string]int64{"java.lang.NullPointerException": 1}, outcomes(TestsErrorsCount))
+	})
+
+	t.Run("cardinality limit", func(t *testing.T) {
+		metrics, m := collect(t, 1, passed, failed, passed)
+
+		gauge := metrics[TestCaseFailedGauge].(metricdata.Gauge[int64])
+		require.Len(t, gauge.DataPoints, 1)
+		id, _ := gauge.DataPoints[0].Attributes.Value(attribute.Key(TestMetricID))
+		require.Equal(t, "auth.TestLogin", id.AsString())
+
+		counter := metrics[TestCaseExecutionsCount].(metricdata.Sum[int64])
+		require.Equal(t, int64(2), counter.DataPoints[0].Value)
+		require.Equal(t, 1, m.dropped)
+	})
+}
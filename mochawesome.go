@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/joshdk/go-junit"
+)
+
+// mochawesomeReport the JSON report of Mochawesome, i.e. produced by Cypress
+type mochawesomeReport struct {
+	Results []mochawesomeSuite `json:"results"`
+}
+
+type mochawesomeSuite struct {
+	Title  string             `json:"title"`
+	File   string             `json:"file"`
+	Tests  []mochawesomeTest  `json:"tests"`
+	Suites []mochawesomeSuite `json:"suites"`
+}
+
+type mochawesomeTest struct {
+	Title     string `json:"title"`
+	FullTitle string `json:"fullTitle"`
+	Duration  int64  `json:"duration"`
+	Pass      bool   `json:"pass"`
+	Fail      bool   `json:"fail"`
+	Pending   bool   `json:"pending"`
+	Skipped   bool   `json:"skipped"`
+	Context   string `json:"context"`
+	Err       struct {
+		Message string `json:"message"`
+		Estack  string `json:"estack"`
+	} `json:"err"`
+}
+
+// mochawesomeArtifacts the kinds of artifacts added to the context of the tests, by file extension
+var mochawesomeArtifacts = map[string]string{
+	".mp4":  "video",
+	".png":  "screenshot",
+	".jpg":  "screenshot",
+	".zip":  "trace",
+	".webm": "video",
+}
+
+// parseMochawesomeReport converts the JSON report of Mochawesome to jUnit suites, one per spec file, with the
+// paths of the videos and screenshots added to the context of the tests kept as properties
+func parseMochawesomeReport(_ string, data []byte) (*junitReport, error) {
+	mr := mochawesomeReport{}
+	if err := json.Unmarshal(data, &mr); err != nil {
+		return nil, jsonParseError("Mochawesome", data, err)
+	}
+
+	report := &junitReport{framework: &testFramework{Name: "cypress"}}
+	for _, result := range mr.Results {
+		suite := junit.Suite{
+			Name:       result.File,
+			Package:    result.File,
+			Properties: map[string]string{"file": result.File},
+		}
+		suite.Tests = mochawesomeTests(result, result.File)
+		suite.Aggregate()
+
+		report.suites = append(report.suites, suite)
+	}
+
+	return report, nil
+}
+
+// mochawesomeTests returns the test cases of the suite and its nested suites, named after their full title
+func mochawesomeTests(suite mochawesomeSuite, file string) []junit.Test {
+	tests := []junit.Test{}
+
+	for _, mt := range suite.Tests {
+		name := mt.FullTitle
+		if name == "" {
+			name = mt.Title
+		}
+
+		test := junit.Test{
+			Name:       name,
+			Classname:  file,
+			Duration:   time.Duration(mt.Duration) * time.Millisecond,
+			Status:     mochawesomeStatus(mt),
+			Properties: map[string]string{"file": file},
+		}
+
+		for kind, artifact := range mochawesomeContextArtifacts(mt.Context) {
+			test.Properties[TestArtifactPrefix+kind] = artifact
+		}
+
+		if test.Status == junit.StatusFailed {
+			test.Message = mt.Err.Message
+			test.Error = junit.Error{Message: mt.Err.Message, Body: mt.Err.Estack}
+		}
+
+		tests = append(tests, test)
+	}
+
+	for _, nested := range suite.Suites {
+		tests = append(tests, mochawesomeTests(nested, file)...)
+	}
+
+	return tests
+}
+
+func mochawesomeStatus(test mochawesomeTest) junit.Status {
+	switch {
+	case test.Fail:
+		return junit.StatusFailed
+	case test.Pending || test.Skipped:
+		return junit.StatusSkipped
+	default:
+		return junit.StatusPassed
+	}
+}
+
+// mochawesomeContextArtifacts finds the paths of the artifacts in the context of a test, which is a JSON
+// encoded string, object with title and value, or a list of them, keeping the last one of each kind
+func mochawesomeContextArtifacts(context string) map[string]string {
+	artifacts := map[string]string{}
+	if context == "" {
+		return artifacts
+	}
+
+	var value any
+	if err := json.Unmarshal([]byte(context), &value); err != nil {
+		return artifacts
+	}
+
+	var collect func(v any)
+	collect = func(v any) {
+		switch typed := v.(type) {
+		case string:
+			if kind, ok := mochawesomeArtifacts[strings.ToLower(path.Ext(typed))]; ok {
+				artifacts[kind] = typed
+			}
+		case map[string]any:
+			collect(typed["value"])
+		case []any:
+			for _, item := range typed {
+				collect(item)
+			}
+		}
+	}
+	collect(value)
+
+	return artifacts
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/joshdk/go-junit"
+	"golang.org/x/text/unicode/norm"
+)
+
+// the handling of the names of the tests and the suites exceeding the limit of the backend
+const (
+	longNamesHash          = "hash"
+	longNamesTransliterate = "transliterate"
+)
+
+// nameHashLength the length of the hash suffix of the long names, as ~ and 8 hex digits
+const nameHashLength = 9
+
+// invisibleNameRunes the characters with no glyph found in the names copied from the editors and the terminals,
+// i.e. the byte order mark of the files of the tests, which make the same name differ across the runs
+var invisibleNameRunes = []rune{'\uFEFF', '\u200B', '\u2060'}
+
+// transliterations the Latin letters without a decomposition into an ASCII letter and a diacritic
+var transliterations = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'Æ': "AE", 'œ': "oe", 'Œ': "OE", 'ø': "o", 'Ø': "O", 'đ': "d", 'Đ': "D",
+	'ł': "l", 'Ł': "L", 'þ': "th", 'Þ': "TH", 'ð': "d", 'Ð': "D", 'ı': "i",
+}
+
+// nameNormalizer normalizes the names of the tests and the suites of the reports, so the same test is grouped under
+// the same name across the runs, whatever the tool writing the report: the names are composed into the NFC form,
+// i.e. the macOS file systems decompose the accents of the names of the files, and the invisible characters are
+// removed. With a limit, the names exceeding it are transliterated or hashed
+type nameNormalizer struct {
+	limit     int
+	longNames string
+}
+
+// newNameNormalizer creates the normalizer of the names, with the limit of their length in bytes, if not 0
+func newNameNormalizer(limit int, longNames string) (*nameNormalizer, error) {
+	if limit < 0 {
+		return nil, fmt.Errorf("invalid name limit %d: it must be a positive number of bytes, or 0 for no limit", limit)
+	}
+
+	longNames = strings.ToLower(strings.TrimSpace(longNames))
+	switch longNames {
+	case "":
+		longNames = longNamesHash
+	case longNamesHash, longNamesTransliterate:
+	default:
+		return nil, fmt.Errorf("invalid handling of the long names %q: valid values are %s and %s", longNames, longNamesHash, longNamesTransliterate)
+	}
+
+	return &nameNormalizer{limit: limit, longNames: longNames}, nil
+}
+
+// normalizeReport normalizes the names of the suites of the report, and of their test cases, in place
+func (n *nameNormalizer) normalizeReport(report *junitReport) {
+	for i := range report.suites {
+		n.normalizeSuite(&report.suites[i])
+	}
+}
+
+func (n *nameNormalizer) normalizeSuite(suite *junit.Suite) {
+	suite.Name = n.normalize(suite.Name)
+	suite.Package = normalizeName(suite.Package)
+
+	for i := range suite.Tests {
+		suite.Tests[i].Name = n.normalize(suite.Tests[i].Name)
+		suite.Tests[i].Classname = normalizeName(suite.Tests[i].Classname)
+	}
+
+	for i := range suite.Suites {
+		n.normalizeSuite(&suite.Suites[i])
+	}
+}
+
+// normalize returns the normalized name, within the limit
+func (n *nameNormalizer) normalize(name string) string {
+	name = normalizeName(name)
+	if n.limit == 0 || len(name) <= n.limit {
+		return name
+	}
+
+	droppedData.drop(droppedNames, 1, name)
+
+	if n.longNames == longNamesTransliterate {
+		if transliterated := transliterateName(name); len(transliterated) <= n.limit {
+			return transliterated
+		}
+	}
+
+	return hashName(name, n.limit)
+}
+
+// normalizeName composes the name into the NFC form, without the invisible characters. The names are only
+// copied if they need to be normalized, as most of them are ASCII
+func normalizeName(name string) string {
+	if strings.ContainsFunc(name, isInvisibleNameRune) {
+		name = strings.Map(func(r rune) rune {
+			if isInvisibleNameRune(r) {
+				return -1
+			}
+
+			return r
+		}, name)
+	}
+
+	if norm.NFC.IsNormalString(name) {
+		return name
+	}
+
+	return norm.NFC.String(name)
+}
+
+func isInvisibleNameRune(r rune) bool {
+	for _, invisible := range invisibleNameRunes {
+		if r == invisible {
+			return true
+		}
+	}
+
+	return false
+}
+
+// transliterateName returns the name with the Latin letters in ASCII, without their diacritics, and without the
+// emoji and their modifiers. The scripts without a Latin transliteration, i.e. the CJK ones, are kept
+func transliterateName(name string) string {
+	builder := strings.Builder{}
+	for _, r := range norm.NFD.String(name) {
+		switch {
+		case r < utf8.RuneSelf:
+			builder.WriteRune(r)
+		case transliterations[r] != "":
+			builder.WriteString(transliterations[r])
+		case unicode.Is(unicode.Mn, r), unicode.Is(unicode.So, r), unicode.Is(unicode.Sk, r), r == '\u200D', unicode.In(r, unicode.Variation_Selector):
+			// the diacritics, the emoji and the joiners and selectors of their sequences are removed
+		default:
+			builder.WriteRune(r)
+		}
+	}
+
+	return strings.Join(strings.Fields(norm.NFC.String(builder.String())), " ")
+}
+
+// hashName returns the longest prefix of the name fitting in the limit with a hash of the whole name, so the
+// names sharing a long prefix are still distinct, i.e. the parameterized tests
+func hashName(name string, limit int) string {
+	sum := sha256.Sum256([]byte(name))
+	suffix := "~" + hex.EncodeToString(sum[:])[:nameHashLength-1]
+	if limit <= nameHashLength {
+		return suffix[1:min(limit+1, nameHashLength)]
+	}
+
+	prefix := name[:limit-nameHashLength]
+	for !utf8.ValidString(prefix) {
+		prefix = prefix[:len(prefix)-1]
+	}
+
+	return prefix + suffix
+}
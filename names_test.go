@@ -0,0 +1,106 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func TestNameNormalizer(t *testing.T) {
+	t.Run("Composes the names", func(t *testing.T) {
+		normalizer, err := newNameNormalizer(0, "")
+		require.NoError(t, err)
+
+		// the accent is decomposed in the name written by macOS, and an emoji is kept as is
+		require.Equal(t, "café \U0001F600", normalizer.normalize("cafe\u0301 \U0001F600"))
+		require.Equal(t, "テスト", normalizer.normalize("\uFEFFテスト"))
+		require.Equal(t, "should render", normalizer.normalize("should\u200B render"))
+	})
+
+	t.Run("Hashes the long names", func(t *testing.T) {
+		normalizer, err := newNameNormalizer(20, longNamesHash)
+		require.NoError(t, err)
+
+		short := "shouldRender"
+		require.Equal(t, short, normalizer.normalize(short))
+
+		first := normalizer.normalize("shouldRenderTheCheckoutPage[1]")
+		second := normalizer.normalize("shouldRenderTheCheckoutPage[2]")
+		require.Len(t, first, 20)
+		require.True(t, strings.HasPrefix(first, "shouldRende~"))
+		require.NotEqual(t, first, second)
+		require.Equal(t, first, normalizer.normalize("shouldRenderTheCheckoutPage[1]"))
+
+		// the prefix is cut at the start of a character
+		cjk := normalizer.normalize("購入ページを表示するテスト")
+		require.True(t, utf8.ValidString(cjk))
+		require.LessOrEqual(t, len(cjk), 20)
+		require.True(t, strings.HasPrefix(cjk, "購入ペ~"))
+	})
+
+	t.Run("Transliterates the long names", func(t *testing.T) {
+		normalizer, err := newNameNormalizer(24, longNamesTransliterate)
+		require.NoError(t, err)
+
+		require.Equal(t, "Crème brûlée \U0001F36E", normalizer.normalize("Crème brûlée \U0001F36E"))
+		require.Equal(t, "Strasse zum Cafe naive", normalizer.normalize("Straße zum Café naïve \U0001F44D\U0001F3FD"))
+
+		// the names without a Latin transliteration are hashed
+		cjk := normalizer.normalize("購入ページを表示するテスト")
+		require.LessOrEqual(t, len(cjk), 24)
+		require.Contains(t, cjk, "~")
+	})
+
+	t.Run("Invalid settings", func(t *testing.T) {
+		_, err := newNameNormalizer(-1, longNamesHash)
+		require.Error(t, err)
+
+		_, err = newNameNormalizer(10, "truncate")
+		require.Error(t, err)
+	})
+}
+
+func TestParseReportNames(t *testing.T) {
+	defer func(limit int, longNames string) { nameLimitFlag, longNamesFlag = limit, longNames }(nameLimitFlag, longNamesFlag)
+
+	t.Run("UTF-16 report", func(t *testing.T) {
+		nameLimitFlag, longNamesFlag = 0, longNamesHash
+
+		data, err := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder().Bytes([]byte("<?xml version=\"1.0\" encoding=\"UTF-16\"?>\n<testsuite name=\"Pester\"><testcase name=\"Get-Cafe\u0301\"/></testsuite>"))
+		require.NoError(t, err)
+
+		report, err := parseReport(reportFormatAuto, "", data)
+		require.NoError(t, err)
+		require.Equal(t, "Pester", report.suites[0].Name)
+		require.Equal(t, "Get-Café", report.suites[0].Tests[0].Name)
+	})
+
+	t.Run("JSON report with a byte order mark", func(t *testing.T) {
+		nameLimitFlag, longNamesFlag = 16, longNamesHash
+
+		data, err := os.ReadFile("testdata/playwright-report.json")
+		require.NoError(t, err)
+
+		report, err := parseReport(reportFormatAuto, "", append([]byte("\xef\xbb\xbf"), data...))
+		require.NoError(t, err)
+		require.Equal(t, reportFormatPlaywright, report.format)
+
+		for _, suite := range report.suites {
+			require.LessOrEqual(t, len(suite.Name), 16)
+			for _, test := range suite.Tests {
+				require.LessOrEqual(t, len(test.Name), 16)
+			}
+		}
+	})
+
+	t.Run("Invalid handling of the long names", func(t *testing.T) {
+		nameLimitFlag, longNamesFlag = 16, "truncate"
+
+		_, err := parseReport(reportFormatJUnit, "", []byte("<testsuite/>"))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joshdk/go-junit"
+)
+
+const reportFormatNewman = "newman"
+
+// restAssuredRequestRegex matches the request logged by REST-assured, i.e. with log().all() or
+// log().ifValidationFails(), as "Request method:\tGET\nRequest URI:\thttp://localhost:8080/users"
+var restAssuredRequestRegex = regexp.MustCompile(`Request method:\s*([A-Z]+)\s*\n\s*Request URI:\s*(\S+)`)
+
+// restAssuredResponseRegex matches the status line of the response logged by REST-assured, i.e. HTTP/1.1 200 OK
+var restAssuredResponseRegex = regexp.MustCompile(`(?m)^HTTP/\d(?:\.\d)? (\d{3})\b`)
+
+// restAssuredStatusRegex matches the status code of the failed expectation of REST-assured, i.e. "Expected status
+// code <200> but was <404>."
+var restAssuredStatusRegex = regexp.MustCompile(`Expected status code <[^>]*> but was <(\d{3})>`)
+
+// newmanReport the JSON report of Newman, the runner of the Postman collections, written with --reporters json
+type newmanReport struct {
+	Collection struct {
+		Info struct {
+			Name string `json:"name"`
+		} `json:"info"`
+		Item []newmanItem `json:"item"`
+	} `json:"collection"`
+	Run struct {
+		Timings struct {
+			Started int64 `json:"started"`
+		} `json:"timings"`
+		Executions []newmanExecution `json:"executions"`
+	} `json:"run"`
+}
+
+// newmanItem an item of the collection: a folder, with its items, or a request
+type newmanItem struct {
+	ID   string       `json:"id"`
+	Name string       `json:"name"`
+	Item []newmanItem `json:"item"`
+}
+
+// newmanExecution the execution of a request of the collection, in an iteration of the run
+type newmanExecution struct {
+	Cursor struct {
+		Iteration int `json:"iteration"`
+	} `json:"cursor"`
+	Item struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"item"`
+	Request struct {
+		Method string    `json:"method"`
+		URL    newmanURL `json:"url"`
+	} `json:"request"`
+	Response *struct {
+		Code         int   `json:"code"`
+		ResponseTime int64 `json:"responseTime"`
+	} `json:"response"`
+	RequestError *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"requestError"`
+	Assertions []struct {
+		Assertion string `json:"assertion"`
+		Skipped   bool   `json:"skipped"`
+		Error     *struct {
+			Name    string `json:"name"`
+			Message string `json:"message"`
+			Stack   string `json:"stack"`
+		} `json:"error"`
+	} `json:"assertions"`
+}
+
+// newmanURL the URL of a request, which Newman writes as a string, or as an object with its parts
+type newmanURL string
+
+func (u *newmanURL) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		*u = newmanURL(raw)
+		return nil
+	}
+
+	parts := struct {
+		Raw      string   `json:"raw"`
+		Protocol string   `json:"protocol"`
+		Host     []string `json:"host"`
+		Port     string   `json:"port"`
+		Path     []string `json:"path"`
+		Query    []struct {
+			Key      string `json:"key"`
+			Value    string `json:"value"`
+			Disabled bool   `json:"disabled"`
+		} `json:"query"`
+	}{}
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return err
+	}
+
+	if parts.Raw != "" {
+		*u = newmanURL(parts.Raw)
+		return nil
+	}
+
+	url := strings.Join(parts.Host, ".")
+	if parts.Protocol != "" {
+		url = parts.Protocol + "://" + url
+	}
+	if parts.Port != "" {
+		url += ":" + parts.Port
+	}
+	if len(parts.Path) > 0 {
+		url += "/" + strings.Join(parts.Path, "/")
+	}
+
+	query := []string{}
+	for _, param := range parts.Query {
+		if !param.Disabled {
+			query = append(query, param.Key+"="+param.Value)
+		}
+	}
+	if len(query) > 0 {
+		url += "?" + strings.Join(query, "&")
+	}
+
+	*u = newmanURL(url)
+	return nil
+}
+
+// isNewmanReport checks if the top-level keys of the JSON report are the ones of the report of Newman
+func isNewmanReport(keys map[string]json.RawMessage) bool {
+	_, hasCollection := keys["collection"]
+	_, hasRun := keys["run"]
+
+	return hasCollection && hasRun
+}
+
+// parseNewmanReport converts the JSON report of Newman to a jUnit suite for the collection, with a nested suite for
+// each folder, and a test case for each execution of a request, with its method, URL, status code and response time
+// kept as properties. The failed assertions of the tests of a request fail its test case, and its request errors,
+// i.e. a refused connection, are its errors
+func parseNewmanReport(_ string, data []byte) (*junitReport, error) {
+	nr := newmanReport{}
+	if err := json.Unmarshal(data, &nr); err != nil {
+		return nil, jsonParseError("Newman", data, err)
+	}
+
+	executions := map[string][]newmanExecution{}
+	iterations := 0
+	for _, execution := range nr.Run.Executions {
+		executions[execution.Item.ID] = append(executions[execution.Item.ID], execution)
+		iterations = max(iterations, execution.Cursor.Iteration+1)
+	}
+
+	root := newmanItem{Name: nr.Collection.Info.Name, Item: nr.Collection.Item}
+	suite := newmanSuite(root, root.Name, executions, iterations > 1)
+	if nr.Run.Timings.Started > 0 {
+		suite.Properties = map[string]string{
+			suiteTimestampAttribute: time.UnixMilli(nr.Run.Timings.Started).UTC().Format(time.RFC3339Nano),
+		}
+	}
+	suite.Aggregate()
+
+	return &junitReport{suites: []junit.Suite{suite}, framework: &testFramework{Name: reportFormatNewman}}, nil
+}
+
+// newmanSuite returns the suite of the folder, with the test cases of the executions of its requests and the nested
+// suites of its folders, leaving out the folders without executions
+func newmanSuite(folder newmanItem, path string, executions map[string][]newmanExecution, iterations bool) junit.Suite {
+	suite := junit.Suite{Name: folder.Name, Package: path}
+
+	for _, item := range folder.Item {
+		if item.Item != nil {
+			nested := newmanSuite(item, path+"/"+item.Name, executions, iterations)
+			if len(nested.Tests) > 0 || len(nested.Suites) > 0 {
+				suite.Suites = append(suite.Suites, nested)
+			}
+			continue
+		}
+
+		for _, execution := range executions[item.ID] {
+			test := newmanTest(execution, path)
+			if iterations {
+				test.Properties[NewmanIteration] = strconv.Itoa(execution.Cursor.Iteration + 1)
+			}
+			suite.Tests = append(suite.Tests, test)
+		}
+	}
+
+	return suite
+}
+
+// newmanTest returns the test case of the execution of the request, failed by the first of its failed assertions
+func newmanTest(execution newmanExecution, path string) junit.Test {
+	test := junit.Test{
+		Name:      execution.Item.Name,
+		Classname: path,
+		Status:    junit.StatusPassed,
+		Properties: map[string]string{
+			HTTPMethod:   execution.Request.Method,
+			HTTPURL:      string(execution.Request.URL),
+			"assertions": strconv.Itoa(len(execution.Assertions)),
+		},
+	}
+
+	if execution.Response != nil {
+		test.Duration = time.Duration(execution.Response.ResponseTime) * time.Millisecond
+		test.Properties[HTTPStatusCode] = strconv.Itoa(execution.Response.Code)
+		test.Properties[HTTPResponseTime] = strconv.FormatInt(execution.Response.ResponseTime, 10)
+	}
+
+	if execution.RequestError != nil {
+		test.Status = junit.StatusError
+		test.Message = execution.RequestError.Message
+		test.Error = junit.Error{Message: execution.RequestError.Message, Type: execution.RequestError.Code}
+		return test
+	}
+
+	failures := []string{}
+	for _, assertion := range execution.Assertions {
+		if assertion.Skipped || assertion.Error == nil {
+			continue
+		}
+
+		if test.Status == junit.StatusPassed {
+			test.Status = junit.StatusFailed
+			test.Message = fmt.Sprintf("%s: %s", assertion.Assertion, assertion.Error.Message)
+			test.Error = junit.Error{Message: test.Message, Type: assertion.Error.Name, Body: assertion.Error.Stack}
+		}
+		failures = append(failures, fmt.Sprintf("%s: %s", assertion.Assertion, assertion.Error.Message))
+	}
+
+	// all the failed assertions are reported, as the tests of a request go on after a failure
+	if len(failures) > 1 {
+		junitErr := test.Error.(junit.Error)
+		junitErr.Body = strings.TrimSpace(strings.Join(failures, "\n") + "\n\n" + junitErr.Body)
+		test.Error = junitErr
+	}
+
+	return test
+}
+
+// addRestAssuredRequests adds the method, the URL and the status code of the requests logged by REST-assured in the
+// output of the test cases of the jUnit report, or in their failures, to their properties
+func addRestAssuredRequests(report *junitReport) {
+	for i := range report.suites {
+		addRestAssuredSuiteRequests(&report.suites[i])
+	}
+}
+
+func addRestAssuredSuiteRequests(suite *junit.Suite) {
+	for i := range suite.Tests {
+		addRestAssuredRequest(&suite.Tests[i])
+	}
+	for i := range suite.Suites {
+		addRestAssuredSuiteRequests(&suite.Suites[i])
+	}
+}
+
+// addRestAssuredRequest adds the last request logged by REST-assured for the test case to its properties
+func addRestAssuredRequest(test *junit.Test) {
+	output := test.SystemOut + "\n" + test.SystemErr
+	if junitErr, ok := test.Error.(junit.Error); ok {
+		output = output + "\n" + junitErr.Body
+	}
+
+	// the request logs are only matched if they can match, as they are searched for in every test case
+	if !strings.Contains(output, "Request method:") {
+		return
+	}
+
+	requests := restAssuredRequestRegex.FindAllStringSubmatchIndex(output, -1)
+	if requests == nil {
+		return
+	}
+
+	last := requests[len(requests)-1]
+	properties := map[string]string{
+		HTTPMethod: output[last[2]:last[3]],
+		HTTPURL:    output[last[4]:last[5]],
+	}
+
+	if match := restAssuredResponseRegex.FindStringSubmatch(output[last[1]:]); match != nil {
+		properties[HTTPStatusCode] = match[1]
+	} else if match := restAssuredStatusRegex.FindStringSubmatch(test.Message + "\n" + output); match != nil {
+		properties[HTTPStatusCode] = match[1]
+	}
+
+	test.Properties = mergeProperties(test.Properties, properties)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNewmanReport(t *testing.T) {
+	data, err := os.ReadFile("testdata/newman-report.json")
+	require.NoError(t, err)
+
+	format, err := detectReportFormat(data)
+	require.NoError(t, err)
+	require.Equal(t, reportFormatNewman, format)
+
+	report, err := parseReport(reportFormatAuto, "", data)
+	require.NoError(t, err)
+	require.Equal(t, &testFramework{Name: reportFormatNewman}, report.framework)
+	require.Len(t, report.suites, 1)
+
+	// the collection, with its folders as nested suites, leaving out the empty ones
+	collection := report.suites[0]
+	require.Equal(t, "Shop API", collection.Name)
+	require.Equal(t, "2024-05-01T10:00:00Z", collection.Properties[suiteTimestampAttribute])
+	require.Equal(t, 4, collection.Totals.Tests)
+	require.Equal(t, 1, collection.Totals.Failed)
+	require.Equal(t, 1, collection.Totals.Error)
+	require.Len(t, collection.Suites, 1)
+
+	users := collection.Suites[0]
+	require.Equal(t, "Users", users.Name)
+	require.Equal(t, "Shop API/Users", users.Package)
+	require.Len(t, users.Tests, 2)
+	require.Len(t, users.Suites, 1)
+	require.Equal(t, "Shop API/Users/Admin", users.Suites[0].Package)
+
+	list := users.Tests[0]
+	require.Equal(t, "List users", list.Name)
+	require.Equal(t, "Shop API/Users", list.Classname)
+	require.Equal(t, junit.StatusPassed, list.Status)
+	require.Equal(t, 120*time.Millisecond, list.Duration)
+	require.Equal(t, "GET", list.Properties[HTTPMethod])
+	require.Equal(t, "https://api.example.com/users?page=1", list.Properties[HTTPURL])
+	require.Equal(t, "200", list.Properties[HTTPStatusCode])
+	require.Equal(t, "120", list.Properties[HTTPResponseTime])
+	require.Equal(t, "2", list.Properties["assertions"])
+
+	// the first failed assertion is the message, and all of them are in the error
+	create := users.Tests[1]
+	require.Equal(t, junit.StatusFailed, create.Status)
+	require.Equal(t, "https://api.example.com:8443/users", create.Properties[HTTPURL])
+	require.Equal(t, "400", create.Properties[HTTPStatusCode])
+	require.Equal(t, "Status code is 201: expected response to have status code 201 but got 400", create.Message)
+	require.Equal(t, "AssertionError", create.Error.(junit.Error).Type)
+	require.Contains(t, create.Error.(junit.Error).Body, "Has an ID: expected undefined to be a string")
+
+	require.Equal(t, "https://api.example.com/users/1", users.Suites[0].Tests[0].Properties[HTTPURL])
+
+	// the request error is the error of the test case
+	health := collection.Tests[0]
+	require.Equal(t, junit.StatusError, health.Status)
+	require.Equal(t, "connect ECONNREFUSED 127.0.0.1:8080", health.Message)
+	require.Equal(t, "ECONNREFUSED", health.Error.(junit.Error).Type)
+	require.Equal(t, "http://localhost:8080/health", health.Properties[HTTPURL])
+	require.Empty(t, health.Properties[HTTPStatusCode])
+	require.Empty(t, health.Properties[NewmanIteration])
+}
+
+func TestNewmanIterations(t *testing.T) {
+	data := []byte(`{
+		"collection": {"info": {"name": "Smoke"}, "item": [{"id": "r1", "name": "Ping", "request": {"method": "GET", "url": "http://localhost/ping"}}]},
+		"run": {"executions": [
+			{"cursor": {"iteration": 0}, "item": {"id": "r1", "name": "Ping"}, "request": {"method": "GET", "url": "http://localhost/ping"}, "response": {"code": 200, "responseTime": 5}},
+			{"cursor": {"iteration": 1}, "item": {"id": "r1", "name": "Ping"}, "request": {"method": "GET", "url": "http://localhost/ping"}, "response": {"code": 503, "responseTime": 7}}
+		]}
+	}`)
+
+	report, err := parseReport(reportFormatNewman, "", data)
+	require.NoError(t, err)
+	require.Len(t, report.suites[0].Tests, 2)
+	require.Equal(t, "1", report.suites[0].Tests[0].Properties[NewmanIteration])
+	require.Equal(t, "2", report.suites[0].Tests[1].Properties[NewmanIteration])
+	require.Equal(t, "503", report.suites[0].Tests[1].Properties[HTTPStatusCode])
+
+	_, err = parseReport(reportFormatNewman, "", []byte(`{"collection": {"item": 1}, "run": {}}`))
+	require.Error(t, err)
+	require.Contains(t, fmt.Sprint(err), "Newman")
+}
+
+func TestRestAssuredRequests(t *testing.T) {
+	data := []byte(`<testsuites><testsuite name="UserApiTest">
+		<testcase name="createsUser" classname="com.example.UserApiTest" time="0.4">
+			<system-out>Request method:	POST
+Request URI:	http://localhost:8080/api/users
+Proxy:			&lt;none&gt;
+Body:
+{"name":"Ada"}
+HTTP/1.1 201 Created
+Content-Type: application/json</system-out>
+		</testcase>
+		<testcase name="getsMissingUser" classname="com.example.UserApiTest" time="0.1">
+			<failure message="1 expectation failed.&#10;Expected status code &lt;200&gt; but was &lt;404&gt;." type="java.lang.AssertionError">java.lang.AssertionError: 1 expectation failed.
+Expected status code &lt;200&gt; but was &lt;404&gt;.</failure>
+			<system-out>Request method:	GET
+Request URI:	http://localhost:8080/api/users/42</system-out>
+		</testcase>
+		<testcase name="plainTest" classname="com.example.UserApiTest" time="0.1"/>
+	</testsuite></testsuites>`)
+
+	report, err := parseReport(reportFormatAuto, "", data)
+	require.NoError(t, err)
+
+	tests := report.suites[0].Tests
+	require.Equal(t, "POST", tests[0].Properties[HTTPMethod])
+	require.Equal(t, "http://localhost:8080/api/users", tests[0].Properties[HTTPURL])
+	require.Equal(t, "201", tests[0].Properties[HTTPStatusCode])
+
+	require.Equal(t, "GET", tests[1].Properties[HTTPMethod])
+	require.Equal(t, "404", tests[1].Properties[HTTPStatusCode])
+
+	require.Empty(t, tests[2].Properties[HTTPMethod])
+}
@@ -0,0 +1,422 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/joshdk/go-junit"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const reportFormatOpenTest = "open-test-reporting"
+
+// the root elements of the reports of open-test-reporting, written by the JUnit Platform: the events of the
+// execution, as written while the tests run, and the hierarchy of the execution, as converted by its CLI
+const (
+	openTestEventsElement    = "events"
+	openTestExecutionElement = "execution"
+)
+
+// reportEntryEventName the name of the span events of the entries published by a test, i.e. with the TestReporter of
+// JUnit 5
+const reportEntryEventName = "report_entry"
+
+// openTestNode a test or a container of tests of the report, i.e. an engine, a class or a parameterized test
+type openTestNode struct {
+	name     string
+	start    time.Time
+	duration time.Duration
+	// elements the elements describing the node: the started and reported events, or the element of the hierarchy
+	elements []*xmlElement
+	result   *xmlElement
+	children []*openTestNode
+}
+
+// reportEntry the entries published by a test at once, with the time since the start of the test, kept in the
+// TestReportEntries property of the test case, so they are recorded as the events of its span
+type reportEntry struct {
+	Offset  time.Duration     `json:"offset"`
+	Entries map[string]string `json:"entries"`
+}
+
+// parseOpenTestReport converts the report of open-test-reporting, written by the JUnit Platform, to jUnit suites, one
+// per container of the test engines, i.e. a test class, with a nested suite for each of their containers, i.e. the
+// nested classes and the parameterized tests. The unique IDs, the tags, the outputs and the files of the tests are
+// kept, and the entries they publish are recorded as the events of their spans
+func parseOpenTestReport(_ string, data []byte) (*junitReport, error) {
+	root, err := parseXMLElements(sanitizeXMLReport(data, droppedData))
+	if err != nil {
+		var parseErr *reportParseError
+		if errors.As(err, &parseErr) {
+			parseErr.format = "open-test-reporting"
+		}
+		return nil, err
+	}
+
+	report := &junitReport{framework: &testFramework{Name: "junit5"}}
+
+	var roots []*openTestNode
+	var infrastructure *xmlElement
+	for _, element := range root.Children {
+		switch element.Name {
+		case openTestEventsElement:
+			roots = openTestEvents(element)
+		case openTestExecutionElement:
+			roots = openTestHierarchy(element.ChildrenNamed("root"))
+		default:
+			continue
+		}
+
+		infrastructure = firstChildNamed(element, "infrastructure")
+	}
+
+	host := ""
+	if infrastructure != nil {
+		host = strings.TrimSpace(childText(infrastructure, "hostName"))
+	}
+
+	for _, engine := range roots {
+		tests := &openTestNode{name: engine.name, start: engine.start, duration: engine.duration, elements: engine.elements, result: engine.result}
+		for _, child := range engine.children {
+			if child.isTest() {
+				tests.children = append(tests.children, child)
+				continue
+			}
+
+			report.suites = append(report.suites, openTestSuite(child, host))
+		}
+
+		// the tests of the engine without containers, or the failure of the engine
+		if len(tests.children) > 0 || tests.failed() {
+			report.suites = append(report.suites, openTestSuite(tests, host))
+		}
+	}
+
+	return report, nil
+}
+
+// openTestEvents returns the tree of the nodes of the events of the execution, from their started events
+func openTestEvents(events *xmlElement) []*openTestNode {
+	roots := []*openTestNode{}
+	nodes := map[string]*openTestNode{}
+
+	for _, event := range events.Children {
+		id := event.Attrs["id"]
+		eventTime, _ := time.Parse(time.RFC3339Nano, event.Attrs["time"])
+
+		switch event.Name {
+		case "started":
+			node := &openTestNode{name: event.Attrs["name"], start: eventTime, elements: []*xmlElement{event}}
+			nodes[id] = node
+
+			if parent, ok := nodes[event.Attrs["parentId"]]; ok {
+				parent.children = append(parent.children, node)
+			} else {
+				roots = append(roots, node)
+			}
+		case "reported":
+			if node, ok := nodes[id]; ok {
+				node.elements = append(node.elements, event)
+			}
+		case "finished":
+			if node, ok := nodes[id]; ok {
+				node.result = firstChildNamed(event, "result")
+				if !node.start.IsZero() && !eventTime.IsZero() {
+					node.duration = eventTime.Sub(node.start)
+				}
+			}
+		}
+	}
+
+	return roots
+}
+
+// openTestHierarchy returns the nodes of the elements of the hierarchy of the execution, with their children
+func openTestHierarchy(elements []*xmlElement) []*openTestNode {
+	nodes := []*openTestNode{}
+	for _, element := range elements {
+		node := &openTestNode{
+			name:     element.Attrs["name"],
+			duration: parseISODuration(element.Attrs["duration"]),
+			elements: []*xmlElement{element},
+			result:   firstChildNamed(element, "result"),
+			children: openTestHierarchy(element.ChildrenNamed("child")),
+		}
+		node.start, _ = time.Parse(time.RFC3339Nano, element.Attrs["start"])
+
+		nodes = append(nodes, node)
+	}
+
+	return nodes
+}
+
+// parseISODuration parses the ISO-8601 durations of the hierarchy, i.e. PT1M2.5S, returning 0 if it's invalid
+func parseISODuration(value string) time.Duration {
+	value, found := strings.CutPrefix(strings.TrimSpace(value), "PT")
+	if !found {
+		return 0
+	}
+
+	duration, err := time.ParseDuration(strings.ToLower(value))
+	if err != nil {
+		return 0
+	}
+
+	return duration
+}
+
+// metadata returns the text of the child of the metadata of the node, i.e. its uniqueId or its legacyReportingName
+func (n *openTestNode) metadata(name string) string {
+	for _, element := range n.elements {
+		if metadata := firstChildNamed(element, "metadata"); metadata != nil {
+			if value := strings.TrimSpace(childText(metadata, name)); value != "" {
+				return value
+			}
+		}
+	}
+
+	return ""
+}
+
+// tags returns the tags of the node, from its metadata
+func (n *openTestNode) tags() []string {
+	tags := []string{}
+	for _, element := range n.elements {
+		for _, metadata := range element.ChildrenNamed("metadata") {
+			for _, group := range metadata.ChildrenNamed("tags") {
+				for _, tag := range group.ChildrenNamed("tag") {
+					tags = append(tags, strings.TrimSpace(tag.Text))
+				}
+			}
+		}
+	}
+
+	return tags
+}
+
+// source returns the first source of the node with the name, i.e. its classSource or its fileSource
+func (n *openTestNode) source(name string) *xmlElement {
+	for _, element := range n.elements {
+		for _, sources := range element.ChildrenNamed("sources") {
+			if source := firstChildNamed(sources, name); source != nil {
+				return source
+			}
+		}
+	}
+
+	return nil
+}
+
+// attachments returns the attachments of the node, in the order they were reported
+func (n *openTestNode) attachments() []*xmlElement {
+	attachments := []*xmlElement{}
+	for _, element := range n.elements {
+		for _, group := range element.ChildrenNamed("attachments") {
+			attachments = append(attachments, group.Children...)
+		}
+	}
+
+	return attachments
+}
+
+// className returns the class of the node, from its method or class source
+func (n *openTestNode) className() string {
+	for _, name := range []string{"methodSource", "classSource"} {
+		if source := n.source(name); source != nil {
+			return source.Attrs["className"]
+		}
+	}
+
+	return ""
+}
+
+// isTest checks if the node is a test, or a container without children, i.e. a parameterized test without arguments
+func (n *openTestNode) isTest() bool {
+	switch n.metadata("type") {
+	case "TEST":
+		return true
+	case "CONTAINER_AND_TEST", "":
+		return len(n.children) == 0
+	default:
+		return false
+	}
+}
+
+// failed checks if the node failed or errored
+func (n *openTestNode) failed() bool {
+	if n.result == nil {
+		return false
+	}
+
+	status := n.result.Attrs["status"]
+	return status == "FAILED" || status == "ERRORED"
+}
+
+// openTestSuite returns the suite of the container, with the test cases of its tests and the nested suites of its
+// containers. The failure of the container, i.e. in a method running before all its tests, is a test case too
+func openTestSuite(container *openTestNode, host string) junit.Suite {
+	className := container.className()
+	if className == "" {
+		className = container.name
+	}
+
+	suite := junit.Suite{Name: container.name, Package: className, Properties: map[string]string{}}
+	if host != "" {
+		suite.Properties["hostname"] = host
+	}
+	if !container.start.IsZero() {
+		suite.Properties[suiteTimestampAttribute] = container.start.Format(time.RFC3339Nano)
+	}
+
+	for _, child := range container.children {
+		if child.isTest() {
+			suite.Tests = append(suite.Tests, openTestTest(child, className))
+			continue
+		}
+
+		suite.Suites = append(suite.Suites, openTestSuite(child, host))
+	}
+
+	if container.failed() {
+		test := openTestTest(container, className)
+		test.Duration = 0
+		suite.Tests = append(suite.Tests, test)
+	}
+
+	suite.Aggregate()
+	return suite
+}
+
+// openTestTest returns the test case of the node, named after its legacy reporting name, i.e. the name of its method,
+// or its display name, which is kept too
+func openTestTest(node *openTestNode, className string) junit.Test {
+	name := node.metadata("legacyReportingName")
+	if name == "" {
+		name = node.name
+	}
+	if source := node.className(); source != "" {
+		className = source
+	}
+
+	test := junit.Test{
+		Name:       name,
+		Classname:  className,
+		Duration:   node.duration,
+		Status:     junit.StatusPassed,
+		Properties: map[string]string{},
+	}
+
+	if id := node.metadata("uniqueId"); id != "" {
+		test.Properties["id"] = id
+	}
+	if node.name != "" {
+		test.Properties[TestDisplayName] = node.name
+	}
+	if tags := node.tags(); len(tags) > 0 {
+		test.Properties[TestTags] = strings.Join(tags, ",")
+	}
+	if source := node.source("fileSource"); source != nil {
+		test.Properties["file"] = source.Attrs["path"]
+		if position := firstChildNamed(source, "filePosition"); position != nil {
+			test.Properties["line"] = position.Attrs["line"]
+		}
+	}
+
+	entries := []reportEntry{}
+	for _, attachment := range node.attachments() {
+		switch attachment.Name {
+		case "data":
+			entry := reportEntry{Entries: map[string]string{}}
+			if entryTime, err := time.Parse(time.RFC3339Nano, attachment.Attrs["time"]); err == nil && !node.start.IsZero() {
+				entry.Offset = max(entryTime.Sub(node.start), 0)
+			}
+			for _, value := range attachment.ChildrenNamed("entry") {
+				entry.Entries[value.Attrs["key"]] = value.Text
+			}
+			entries = append(entries, entry)
+		case "output":
+			if attachment.Attrs["source"] == "STDERR" {
+				test.SystemErr += attachment.Text
+			} else {
+				test.SystemOut += attachment.Text
+			}
+		case "file":
+			filePath := attachment.Attrs["path"]
+			name := strings.TrimSuffix(path.Base(filePath), path.Ext(filePath))
+			test.Properties[TestArtifactPrefix+name] = filePath
+		}
+	}
+	if len(entries) > 0 {
+		if data, err := json.Marshal(entries); err == nil {
+			test.Properties[TestReportEntries] = string(data)
+		}
+	}
+
+	if node.result == nil {
+		return test
+	}
+
+	test.Message = strings.TrimSpace(childText(node.result, "reason"))
+	throwable := firstChildNamed(node.result, "throwable")
+
+	switch node.result.Attrs["status"] {
+	case "SKIPPED", "ABORTED":
+		test.Status = junit.StatusSkipped
+		return test
+	case "FAILED":
+		test.Status = junit.StatusFailed
+	case "ERRORED":
+		test.Status = junit.StatusError
+	default:
+		return test
+	}
+
+	junitErr := junit.Error{Message: test.Message}
+	if throwable != nil {
+		junitErr.Type = throwable.Attrs["type"]
+		junitErr.Body = strings.TrimSpace(throwable.Text)
+
+		// the first line of the stack trace is the type of the throwable, and its message
+		firstLine, _, _ := strings.Cut(junitErr.Body, "\n")
+		if message, found := strings.CutPrefix(firstLine, junitErr.Type+": "); found && junitErr.Message == "" {
+			junitErr.Message = strings.TrimSpace(message)
+		}
+	}
+
+	test.Message = junitErr.Message
+	test.Error = junitErr
+	return test
+}
+
+// addReportEntryEvents records the entries published by the test as the events of its span, after the start of the
+// test if the span is laid out in time
+func addReportEntryEvents(span trace.Span, test junit.Test, endTime time.Time) {
+	value, ok := test.Properties[TestReportEntries]
+	if !ok {
+		return
+	}
+
+	entries := []reportEntry{}
+	if err := json.Unmarshal([]byte(value), &entries); err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		attributes := make([]attribute.KeyValue, 0, len(entry.Entries))
+		for _, key := range slices.Sorted(maps.Keys(entry.Entries)) {
+			attributes = append(attributes, attribute.Key(key).String(entry.Entries[key]))
+		}
+
+		options := []trace.EventOption{trace.WithAttributes(attributes...)}
+		if !endTime.IsZero() {
+			options = append(options, trace.WithTimestamp(endTime.Add(-test.Duration).Add(min(entry.Offset, test.Duration))))
+		}
+		span.AddEvent(reportEntryEventName, options...)
+	}
+}
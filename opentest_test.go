@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestParseOpenTestEvents(t *testing.T) {
+	data, err := os.ReadFile("testdata/open-test-report.xml")
+	require.NoError(t, err)
+
+	format, err := detectReportFormat(data)
+	require.NoError(t, err)
+	require.Equal(t, reportFormatOpenTest, format)
+
+	report, err := parseReport(reportFormatAuto, "", data)
+	require.NoError(t, err)
+	require.Equal(t, &testFramework{Name: "junit5"}, report.framework)
+	require.Len(t, report.suites, 2)
+
+	// a suite per class, with a nested suite per parameterized test
+	calculator := report.suites[0]
+	require.Equal(t, "Calculator", calculator.Name)
+	require.Equal(t, "com.example.CalculatorTests", calculator.Package)
+	require.Equal(t, "build-07", calculator.Properties["hostname"])
+	require.Equal(t, "2024-05-01T10:00:00.1Z", calculator.Properties[suiteTimestampAttribute])
+	require.Equal(t, 4, calculator.Totals.Tests)
+	require.Equal(t, 1, calculator.Totals.Failed)
+	require.Equal(t, 1, calculator.Totals.Skipped)
+	require.Len(t, calculator.Tests, 3)
+	require.Len(t, calculator.Suites, 1)
+
+	add := calculator.Tests[0]
+	require.Equal(t, "addsTwoNumbers()", add.Name)
+	require.Equal(t, "1 + 1 = 2", add.Properties[TestDisplayName])
+	require.Equal(t, "com.example.CalculatorTests", add.Classname)
+	require.Equal(t, junit.StatusPassed, add.Status)
+	require.Equal(t, 100*time.Millisecond, add.Duration)
+	require.Equal(t, "[engine:junit-jupiter]/[class:com.example.CalculatorTests]/[method:addsTwoNumbers()]", add.Properties["id"])
+	require.Equal(t, "fast,math", add.Properties[TestTags])
+	require.Equal(t, "src/test/java/com/example/CalculatorTests.java", add.Properties["file"])
+	require.Equal(t, "21", add.Properties["line"])
+	require.Equal(t, "adding 1 and 1", add.SystemOut)
+	require.Equal(t, "build/reports/calculator/screenshot.png", add.Properties[TestArtifactPrefix+"screenshot"])
+	require.JSONEq(t, `[{"offset":50000000,"entries":{"user":"ada","locale":"en-GB"}}]`, add.Properties[TestReportEntries])
+
+	divide := calculator.Tests[1]
+	require.Equal(t, junit.StatusFailed, divide.Status)
+	require.Equal(t, "expected: <0> but was: <Infinity>", divide.Message)
+	require.Equal(t, "org.opentest4j.AssertionFailedError", divide.Error.(junit.Error).Type)
+	require.Contains(t, divide.Error.(junit.Error).Body, "CalculatorTests.java:35")
+
+	rounds := calculator.Tests[2]
+	require.Equal(t, junit.StatusSkipped, rounds.Status)
+	require.Equal(t, "not implemented yet", rounds.Message)
+
+	multiplies := calculator.Suites[0]
+	require.Equal(t, "multiplies", multiplies.Name)
+	require.Equal(t, "multiplies(int, int)[1]", multiplies.Tests[0].Name)
+
+	// the failure of the class, without running its tests
+	database := report.suites[1]
+	require.Len(t, database.Tests, 1)
+	require.Equal(t, "com.example.DatabaseTests", database.Tests[0].Name)
+	require.Equal(t, junit.StatusFailed, database.Tests[0].Status)
+	require.Equal(t, "Connection refused", database.Tests[0].Message)
+}
+
+func TestParseOpenTestHierarchy(t *testing.T) {
+	data := []byte(`<?xml version="1.0" ?>
+<h:execution xmlns="https://schemas.opentest4j.org/reporting/core/0.2.0" xmlns:h="https://schemas.opentest4j.org/reporting/hierarchy/0.2.0" xmlns:junit="https://schemas.junit.org/open-test-reporting">
+  <infrastructure><hostName>build-08</hostName></infrastructure>
+  <h:root duration="PT1.5S" name="JUnit Jupiter" start="2024-05-01T10:00:00Z">
+    <metadata><junit:type>CONTAINER</junit:type></metadata>
+    <result status="SUCCESSFUL"/>
+    <h:child duration="PT1.2S" name="Cart" start="2024-05-01T10:00:00.1Z">
+      <metadata><junit:type>CONTAINER</junit:type></metadata>
+      <h:child duration="PT1M0.5S" name="checks out" start="2024-05-01T10:00:00.2Z">
+        <metadata><junit:legacyReportingName>checksOut()</junit:legacyReportingName><junit:type>TEST</junit:type></metadata>
+        <result status="ERRORED"><reason>boom</reason></result>
+      </h:child>
+    </h:child>
+  </h:root>
+</h:execution>`)
+
+	format, err := detectReportFormat(data)
+	require.NoError(t, err)
+	require.Equal(t, reportFormatOpenTest, format)
+
+	report, err := parseReport(reportFormatAuto, "", data)
+	require.NoError(t, err)
+	require.Len(t, report.suites, 1)
+
+	cart := report.suites[0]
+	require.Equal(t, "Cart", cart.Name)
+	require.Equal(t, "build-08", cart.Properties["hostname"])
+	require.Equal(t, "checksOut()", cart.Tests[0].Name)
+	require.Equal(t, "Cart", cart.Tests[0].Classname)
+	require.Equal(t, time.Minute+500*time.Millisecond, cart.Tests[0].Duration)
+	require.Equal(t, junit.StatusError, cart.Tests[0].Status)
+	require.Equal(t, "boom", cart.Tests[0].Message)
+
+	_, err = parseReport(reportFormatOpenTest, "", []byte(`<e:events xmlns:e="x"><e:started id="1">`))
+	require.Error(t, err)
+	require.Contains(t, fmt.Sprint(err), "open-test-reporting")
+}
+
+func TestReportEntryEvents(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)).Tracer("test")
+	meter := sdkmetric.NewMeterProvider().Meter("test")
+
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	suite := junit.Suite{
+		Name: "a",
+		Tests: []junit.Test{{
+			Name:     "publishes",
+			Duration: time.Second,
+			Status:   junit.StatusPassed,
+			Properties: map[string]string{
+				TestReportEntries: `[{"offset":250000000,"entries":{"user":"ada","locale":"en-GB"}},{"offset":5000000000,"entries":{"step":"done"}}]`,
+			},
+		}},
+		Totals: junit.Totals{Tests: 1, Passed: 1, Duration: time.Second},
+	}
+
+	createSuiteSpans(context.Background(), tracer, newTestCaseMetrics(meter, 0), suite, nil, nil, nil, start)
+
+	span := exporter.GetSpans()[0]
+	require.Len(t, span.Events, 2)
+	require.Equal(t, reportEntryEventName, span.Events[0].Name)
+	require.Equal(t, start.Add(250*time.Millisecond), span.Events[0].Time)
+	require.Equal(t, []attribute.KeyValue{attribute.String("locale", "en-GB"), attribute.String("user", "ada")}, span.Events[0].Attributes)
+
+	// the entries are within the span
+	require.Equal(t, start.Add(time.Second), span.Events[1].Time)
+
+	// the entries are not attributes of the span
+	for _, kv := range span.Attributes {
+		require.NotEqual(t, attribute.Key(TestReportEntries), kv.Key)
+	}
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"aead.dev/minisign"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// the transports of OTLP, as named in the OTEL_EXPORTER_OTLP_PROTOCOL environment variable
+const (
+	otlpProtocolGRPC = "grpc"
+	otlpProtocolHTTP = "http/protobuf"
+)
+
+// unixScheme the scheme of the endpoints in a unix domain socket, i.e. unix:///var/run/otelcol.sock, for the
+// collectors of the node in the hardened environments where the TCP loopback is restricted
+const unixScheme = "unix"
+
+// otlpSettings the settings of an OTLP exporter overriding the ones of the environment variables, for both transports
+type otlpSettings struct {
+	endpoint string
+	headers  map[string]string
+	noRetry  bool
+	spool    *exportSpool
+	bundle   *exportSpool
+	// digest attaches the digest of the payloads of the traces, signed with the signing key, if any
+	digest     bool
+	signingKey *minisign.PrivateKey
+	audit      *auditLog
+}
+
+type otlpOption func(*otlpSettings)
+
+func withOtlpEndpoint(endpoint string) otlpOption {
+	return func(s *otlpSettings) { s.endpoint = endpoint }
+}
+
+func withOtlpHeaders(headers map[string]string) otlpOption {
+	return func(s *otlpSettings) { s.headers = headers }
+}
+
+// withoutOtlpRetry disables the retries, so an unreachable endpoint is reported as soon as possible
+func withoutOtlpRetry() otlpOption {
+	return func(s *otlpSettings) { s.noRetry = true }
+}
+
+// withOtlpSpool spools the payloads the exporter fails to send, after its retries, for the next invocation
+func withOtlpSpool(spool *exportSpool) otlpOption {
+	return func(s *otlpSettings) { s.spool = spool }
+}
+
+// withOtlpBundle writes the payloads to the spool of a bundle instead of sending them, for the hosts without egress
+func withOtlpBundle(spool *exportSpool) otlpOption {
+	return func(s *otlpSettings) { s.bundle = spool }
+}
+
+// withOtlpPayloadDigest attaches the digest of each payload of the traces to its resource, with its signature if
+// there is a signing key
+func withOtlpPayloadDigest(signingKey *minisign.PrivateKey) otlpOption {
+	return func(s *otlpSettings) { s.digest, s.signingKey = true, signingKey }
+}
+
+// withOtlpAuditLog records the payloads sent by the exporter, or written to a bundle, in the audit log
+func withOtlpAuditLog(audit *auditLog) otlpOption {
+	return func(s *otlpSettings) { s.audit = audit }
+}
+
+// otlpEndpointOptions returns the options of the endpoint and the headers, if they are set
+func otlpEndpointOptions(endpoint string, headers map[string]string) []otlpOption {
+	options := []otlpOption{}
+	if endpoint != "" {
+		options = append(options, withOtlpEndpoint(endpoint))
+	}
+	if len(headers) > 0 {
+		options = append(options, withOtlpHeaders(headers))
+	}
+
+	return options
+}
+
+// validOtlpEndpoint checks the endpoint is an URL with a host, or the path of a unix domain socket
+func validOtlpEndpoint(endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return false
+	}
+
+	if u.Scheme == unixScheme {
+		return unixSocketPath(u) != ""
+	}
+
+	return u.Scheme != "" && u.Host != ""
+}
+
+// unixSocketPath returns the path of the socket of an unix endpoint: absolute in unix:///path, relative in unix:path
+func unixSocketPath(u *url.URL) string {
+	if u.Opaque != "" {
+		return u.Opaque
+	}
+
+	return u.Path
+}
+
+// getOtlpProtocol the precedence order is: flag > OTEL_EXPORTER_OTLP_<SIGNAL>_PROTOCOL > OTEL_EXPORTER_OTLP_PROTOCOL > grpc
+func getOtlpProtocol(signal string) (string, error) {
+	protocol := getOtlpEnvVar(otlpProtocolFlag, "OTEL_EXPORTER_OTLP_"+signal+"_PROTOCOL", "")
+	if protocol == "" {
+		protocol = getOtlpEnvVar("", "OTEL_EXPORTER_OTLP_PROTOCOL", otlpProtocolGRPC)
+	}
+
+	switch protocol {
+	case otlpProtocolGRPC, otlpProtocolHTTP:
+		return protocol, nil
+	default:
+		return "", fmt.Errorf("unsupported OTLP protocol %q: valid values are %s and %s", protocol, otlpProtocolGRPC, otlpProtocolHTTP)
+	}
+}
+
+// resolveOtlpSettings applies the options of the exporter of the signal: the ones of the exporters of the
+// configuration file, the --otlp-endpoint flag and the given ones, i.e. of a tenant, in that order. An unix
+// endpoint of the environment is resolved too, as the exporters only support the URLs with a host
+func resolveOtlpSettings(signal string, configOptions []otlpOption, options []otlpOption) otlpSettings {
+	settings := otlpSettings{}
+	if endpoint := firstEnv("OTEL_EXPORTER_OTLP_"+signal+"_ENDPOINT", "OTEL_EXPORTER_OTLP_ENDPOINT"); strings.HasPrefix(endpoint, unixScheme+":") {
+		settings.endpoint = endpoint
+	}
+
+	all := append([]otlpOption{}, configOptions...)
+	if otlpEndpointFlag != "" {
+		all = append(all, withOtlpEndpoint(otlpEndpointFlag))
+	}
+
+	for _, option := range append(all, options...) {
+		option(&settings)
+	}
+
+	return settings
+}
+
+// unixHTTPClient returns a client dialing the socket for all the requests, whatever their host
+func unixHTTPClient(path string) *http.Client {
+	dialer := &net.Dialer{}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, "unix", path)
+			},
+		},
+	}
+}
+
+// httpEndpointURL returns the URL of the signal in the endpoint, with the default path of the signal, i.e.
+// /v1/traces, if it has none, as the endpoint is shared by both signals
+func httpEndpointURL(u *url.URL, signalPath string) string {
+	if u.Path == "" || u.Path == "/" {
+		u.Path = signalPath
+	}
+
+	return u.String()
+}
+
+// newOtlpTraceExporter creates the OTLP exporter of the traces, with the transport and the settings of the
+// environment variables, overridden by the exporters of the configuration file, the flags and the given options
+func newOtlpTraceExporter(ctx context.Context, options ...otlpOption) (sdktrace.SpanExporter, error) {
+	settings := resolveOtlpSettings(signalTraces, appConfig.Exporters.traceOptions(), options)
+
+	var client otlptrace.Client
+	if settings.bundle != nil {
+		client = settings.audit.traceClient(&bundleTraceClient{spool: settings.bundle}, auditBundleDestination, "")
+	} else {
+		otlpClient, err := newOtlpTraceClient(settings)
+		if err != nil {
+			return nil, err
+		}
+
+		// the protocol was validated by the client
+		protocol, _ := getOtlpProtocol(signalTraces)
+		client = settings.audit.traceClient(otlpClient, otlpDestination(signalTraces, settings, protocol), protocol)
+		if settings.spool != nil {
+			client = &spoolingTraceClient{Client: client, spool: settings.spool}
+		}
+	}
+
+	// the payloads are digested before they are spooled, so the spooled ones keep their digest
+	if settings.digest {
+		client = &digestingTraceClient{Client: client, signingKey: settings.signingKey}
+	}
+
+	return otlptrace.New(ctx, client)
+}
+
+// newOtlpTraceClient creates the client of the exporter of the traces, with the transport of the environment
+func newOtlpTraceClient(settings otlpSettings) (otlptrace.Client, error) {
+	protocol, err := getOtlpProtocol(signalTraces)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint, err := url.Parse(settings.endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q: %w", settings.endpoint, err)
+	}
+
+	if protocol == otlpProtocolHTTP {
+		httpOptions := []otlptracehttp.Option{}
+		switch {
+		case endpoint.Scheme == unixScheme:
+			// the host is not resolved, but it's the one of the requests
+			httpOptions = append(httpOptions, otlptracehttp.WithHTTPClient(unixHTTPClient(unixSocketPath(endpoint))), otlptracehttp.WithEndpoint("localhost"), otlptracehttp.WithInsecure())
+		case settings.endpoint != "":
+			httpOptions = append(httpOptions, otlptracehttp.WithEndpointURL(httpEndpointURL(endpoint, "/v1/traces")))
+		}
+		if len(settings.headers) > 0 {
+			httpOptions = append(httpOptions, otlptracehttp.WithHeaders(settings.headers))
+		}
+		if settings.noRetry {
+			httpOptions = append(httpOptions, otlptracehttp.WithRetry(otlptracehttp.RetryConfig{Enabled: false}))
+		}
+
+		return otlptracehttp.NewClient(httpOptions...), nil
+	}
+
+	grpcOptions := []otlptracegrpc.Option{}
+	switch {
+	case endpoint.Scheme == unixScheme:
+		// gRPC resolves the unix targets itself, without TLS
+		grpcOptions = append(grpcOptions, otlptracegrpc.WithEndpoint(settings.endpoint), otlptracegrpc.WithInsecure())
+	case settings.endpoint != "":
+		grpcOptions = append(grpcOptions, otlptracegrpc.WithEndpointURL(settings.endpoint))
+	}
+	if len(settings.headers) > 0 {
+		grpcOptions = append(grpcOptions, otlptracegrpc.WithHeaders(settings.headers))
+	}
+	if settings.noRetry {
+		grpcOptions = append(grpcOptions, otlptracegrpc.WithRetry(otlptracegrpc.RetryConfig{Enabled: false}))
+	}
+
+	return otlptracegrpc.NewClient(grpcOptions...), nil
+}
+
+// newOtlpMetricExporter creates the OTLP exporter of the metrics, with the transport and the settings of the
+// environment variables, overridden by the exporters of the configuration file, the flags and the given options
+func newOtlpMetricExporter(ctx context.Context, selector sdkmetric.TemporalitySelector, options ...otlpOption) (sdkmetric.Exporter, error) {
+	protocol, err := getOtlpProtocol(signalMetrics)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := resolveOtlpSettings(signalMetrics, appConfig.Exporters.metricOptions(), options)
+	if settings.bundle != nil {
+		return settings.audit.metricExporter(&bundleMetricExporter{spool: settings.bundle, selector: selector}, auditBundleDestination, ""), nil
+	}
+
+	endpoint, err := url.Parse(settings.endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q: %w", settings.endpoint, err)
+	}
+
+	if protocol == otlpProtocolHTTP {
+		httpOptions := []otlpmetrichttp.Option{otlpmetrichttp.WithTemporalitySelector(selector)}
+		switch {
+		case endpoint.Scheme == unixScheme:
+			httpOptions = append(httpOptions, otlpmetrichttp.WithHTTPClient(unixHTTPClient(unixSocketPath(endpoint))), otlpmetrichttp.WithEndpoint("localhost"), otlpmetrichttp.WithInsecure())
+		case settings.endpoint != "":
+			httpOptions = append(httpOptions, otlpmetrichttp.WithEndpointURL(httpEndpointURL(endpoint, "/v1/metrics")))
+		}
+		if len(settings.headers) > 0 {
+			httpOptions = append(httpOptions, otlpmetrichttp.WithHeaders(settings.headers))
+		}
+		if settings.noRetry {
+			httpOptions = append(httpOptions, otlpmetrichttp.WithRetry(otlpmetrichttp.RetryConfig{Enabled: false}))
+		}
+
+		exporter, err := otlpmetrichttp.New(ctx, httpOptions...)
+		if err != nil {
+			return nil, err
+		}
+
+		return spoolMetrics(settings.audit.metricExporter(exporter, otlpDestination(signalMetrics, settings, protocol), protocol), settings.spool), nil
+	}
+
+	grpcOptions := []otlpmetricgrpc.Option{otlpmetricgrpc.WithTemporalitySelector(selector)}
+	switch {
+	case endpoint.Scheme == unixScheme:
+		grpcOptions = append(grpcOptions, otlpmetricgrpc.WithEndpoint(settings.endpoint), otlpmetricgrpc.WithInsecure())
+	case settings.endpoint != "":
+		grpcOptions = append(grpcOptions, otlpmetricgrpc.WithEndpointURL(settings.endpoint))
+	}
+	if len(settings.headers) > 0 {
+		grpcOptions = append(grpcOptions, otlpmetricgrpc.WithHeaders(settings.headers))
+	}
+	if settings.noRetry {
+		grpcOptions = append(grpcOptions, otlpmetricgrpc.WithRetry(otlpmetricgrpc.RetryConfig{Enabled: false}))
+	}
+
+	exporter, err := otlpmetricgrpc.New(ctx, grpcOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	return spoolMetrics(settings.audit.metricExporter(exporter, otlpDestination(signalMetrics, settings, protocol), protocol), settings.spool), nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// traceCollector a collector of the OTLP traces over gRPC, recording the headers of the requests
+type traceCollector struct {
+	coltracepb.UnimplementedTraceServiceServer
+	requests chan metadata.MD
+}
+
+func (c *traceCollector) Export(ctx context.Context, req *coltracepb.ExportTraceServiceRequest) (*coltracepb.ExportTraceServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	c.requests <- md
+
+	return &coltracepb.ExportTraceServiceResponse{}, nil
+}
+
+// unixSocket returns the path of a socket in a short temporary directory, as the paths of the sockets are limited
+func unixSocket(t *testing.T) string {
+	dir, err := os.MkdirTemp("", "otlp")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	return filepath.Join(dir, "otelcol.sock")
+}
+
+func exportTestSpan(t *testing.T, exporter sdktrace.SpanExporter) {
+	span := tracetest.SpanStub{
+		Name: "test",
+		SpanContext: trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    trace.TraceID{0x01},
+			SpanID:     trace.SpanID{0x01},
+			TraceFlags: trace.FlagsSampled,
+		}),
+		StartTime: time.Now(),
+		EndTime:   time.Now(),
+	}
+
+	require.NoError(t, exporter.ExportSpans(context.Background(), []sdktrace.ReadOnlySpan{span.Snapshot()}))
+	require.NoError(t, exporter.Shutdown(context.Background()))
+}
+
+func TestOtlpUnixSocket(t *testing.T) {
+	defer func(cfg *config, endpoint string, protocol string) {
+		appConfig, otlpEndpointFlag, otlpProtocolFlag = cfg, endpoint, protocol
+	}(appConfig, otlpEndpointFlag, otlpProtocolFlag)
+	appConfig = &config{}
+
+	t.Run("gRPC", func(t *testing.T) {
+		socket := unixSocket(t)
+		listener, err := net.Listen("unix", socket)
+		require.NoError(t, err)
+
+		collector := &traceCollector{requests: make(chan metadata.MD, 1)}
+		server := grpc.NewServer()
+		coltracepb.RegisterTraceServiceServer(server, collector)
+		go server.Serve(listener)
+		defer server.Stop()
+
+		otlpEndpointFlag, otlpProtocolFlag = "unix://"+socket, otlpProtocolGRPC
+
+		exporter, err := newOtlpTraceExporter(context.Background(), withOtlpHeaders(map[string]string{"x-team": "checkout"}))
+		require.NoError(t, err)
+		exportTestSpan(t, exporter)
+
+		require.Equal(t, []string{"checkout"}, (<-collector.requests).Get("x-team"))
+	})
+
+	t.Run("HTTP", func(t *testing.T) {
+		socket := unixSocket(t)
+		listener, err := net.Listen("unix", socket)
+		require.NoError(t, err)
+
+		requests := make(chan *http.Request, 1)
+		server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.Copy(io.Discard, r.Body)
+			requests <- r
+			w.Header().Set("Content-Type", "application/x-protobuf")
+		})}
+		go server.Serve(listener)
+		defer server.Close()
+
+		otlpEndpointFlag, otlpProtocolFlag = "unix://"+socket, otlpProtocolHTTP
+
+		exporter, err := newOtlpTraceExporter(context.Background(), withOtlpHeaders(map[string]string{"x-team": "checkout"}))
+		require.NoError(t, err)
+		exportTestSpan(t, exporter)
+
+		request := <-requests
+		require.Equal(t, "/v1/traces", request.URL.Path)
+		require.Equal(t, "checkout", request.Header.Get("x-team"))
+	})
+}
+
+func TestGetOtlpProtocol(t *testing.T) {
+	defer func(protocol string) { otlpProtocolFlag = protocol }(otlpProtocolFlag)
+	otlpProtocolFlag = ""
+
+	protocol, err := getOtlpProtocol(signalTraces)
+	require.NoError(t, err)
+	require.Equal(t, otlpProtocolGRPC, protocol)
+
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "http/protobuf")
+	t.Setenv("OTEL_EXPORTER_OTLP_METRICS_PROTOCOL", "grpc")
+
+	protocol, err = getOtlpProtocol(signalTraces)
+	require.NoError(t, err)
+	require.Equal(t, otlpProtocolHTTP, protocol)
+
+	protocol, err = getOtlpProtocol(signalMetrics)
+	require.NoError(t, err)
+	require.Equal(t, otlpProtocolGRPC, protocol)
+
+	otlpProtocolFlag = "http/json"
+	_, err = getOtlpProtocol(signalTraces)
+	require.Error(t, err)
+}
+
+func TestResolveOtlpSettings(t *testing.T) {
+	defer func(endpoint string) { otlpEndpointFlag = endpoint }(otlpEndpointFlag)
+
+	configOptions := otlpEndpointOptions("https://config:4317", map[string]string{"x-api-key": "config"})
+
+	t.Run("Configuration file", func(t *testing.T) {
+		otlpEndpointFlag = ""
+
+		settings := resolveOtlpSettings(signalTraces, configOptions, nil)
+		require.Equal(t, otlpSettings{endpoint: "https://config:4317", headers: map[string]string{"x-api-key": "config"}}, settings)
+	})
+
+	t.Run("Flag and tenant", func(t *testing.T) {
+		otlpEndpointFlag = "unix:///var/run/otelcol.sock"
+
+		settings := resolveOtlpSettings(signalTraces, configOptions, nil)
+		require.Equal(t, "unix:///var/run/otelcol.sock", settings.endpoint)
+
+		settings = resolveOtlpSettings(signalTraces, configOptions, otlpEndpointOptions("https://tenant:4317", nil))
+		require.Equal(t, "https://tenant:4317", settings.endpoint)
+		require.Equal(t, map[string]string{"x-api-key": "config"}, settings.headers)
+	})
+
+	t.Run("Unix socket of the environment", func(t *testing.T) {
+		otlpEndpointFlag = ""
+		t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "unix:///var/run/otelcol.sock")
+
+		settings := resolveOtlpSettings(signalMetrics, nil, nil)
+		require.Equal(t, "unix:///var/run/otelcol.sock", settings.endpoint)
+	})
+}
+
+func TestValidOtlpEndpoint(t *testing.T) {
+	require.True(t, validOtlpEndpoint("https://collector:4317"))
+	require.True(t, validOtlpEndpoint("unix:///var/run/otelcol.sock"))
+	require.True(t, validOtlpEndpoint("unix:otelcol.sock"))
+	require.False(t, validOtlpEndpoint("unix://"))
+	require.False(t, validOtlpEndpoint("collector:4317"))
+}
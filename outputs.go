@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// spanOutputs the exporters of the test cases to other backends than OTLP. They are shared by all the tracer
+// providers, i.e. the ones of the devices, so they are shut down once all of them are
+type spanOutputs struct {
+	exporters []sdktrace.SpanExporter
+
+	// snapshot the traces and the metrics of the run, written as normalized OTLP JSON
+	snapshot *otlpSnapshot
+}
+
+// newSpanOutputs creates the exporters of the configured outputs
+func newSpanOutputs() (*spanOutputs, error) {
+	outputs := &spanOutputs{}
+
+	if url := getElasticsearchURL(); url != "" {
+		outputs.exporters = append(outputs.exporters, newElasticsearchExporter(url, elasticsearchIndexFlag))
+	}
+
+	if url := getZipkinURL(); url != "" {
+		outputs.exporters = append(outputs.exporters, newZipkinExporter(url))
+	}
+
+	if datadogFlag {
+		exporter, err := newDatadogExporter(getDatadogIntakeURL())
+		if err != nil {
+			return nil, err
+		}
+
+		outputs.exporters = append(outputs.exporters, exporter)
+	}
+
+	if url := getClickHouseURL(); url != "" {
+		outputs.exporters = append(outputs.exporters, &rowExporter{writer: newClickHouseWriter(url, clickHouseTableFlag)})
+	}
+
+	if table := getBigQueryTable(); table != "" {
+		writer, err := newBigQueryWriter(table)
+		if err != nil {
+			return nil, err
+		}
+
+		outputs.exporters = append(outputs.exporters, &rowExporter{writer: writer})
+	}
+
+	if outputFlag != "" {
+		writer, err := newFileRowWriter(outputFlag, outputFileFlag)
+		if err != nil {
+			return nil, err
+		}
+
+		outputs.exporters = append(outputs.exporters, &rowExporter{writer: writer})
+	}
+
+	if htmlReportFlag != "" {
+		outputs.exporters = append(outputs.exporters, &rowExporter{writer: newHTMLReportWriter(htmlReportFlag, getTraceURL())})
+	}
+
+	if path := getStepSummaryPath(); path != "" {
+		outputs.exporters = append(outputs.exporters, &rowExporter{writer: newStepSummaryWriter(path, getTraceURL())})
+	}
+
+	if attestationFlag != "" {
+		exporter, err := newAttestationExporter(attestationFlag, signingKeyFlag)
+		if err != nil {
+			return nil, err
+		}
+
+		outputs.exporters = append(outputs.exporters, exporter)
+	}
+
+	if snapshotFlag != "" {
+		outputs.snapshot = newOtlpSnapshot(snapshotFlag)
+		exporter, err := outputs.snapshot.spanExporter(context.Background())
+		if err != nil {
+			return nil, err
+		}
+
+		outputs.exporters = append(outputs.exporters, exporter)
+	}
+
+	annotate, err := parseAnnotate(annotateFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	if annotate == annotateBuildkite {
+		outputs.exporters = append(outputs.exporters, &rowExporter{writer: newBuildkiteAnnotationWriter(getTraceURL())})
+	}
+
+	return outputs, nil
+}
+
+// tracerProviderOptions returns a span processor for each output, which does not shut it down, exporting the spans
+// without the attributes dropped by the classification policy
+func (o *spanOutputs) tracerProviderOptions(policy *classificationPolicy) []sdktrace.TracerProviderOption {
+	options := []sdktrace.TracerProviderOption{}
+	for _, exporter := range o.exporters {
+		options = append(options, sdktrace.WithSpanProcessor(sdktrace.NewBatchSpanProcessor(sharedSpanExporter{policy.spanExporter(exporter)})))
+	}
+
+	return options
+}
+
+// meterProviderOptions returns the readers of the metrics of the outputs. The outputs can be nil, i.e. for the
+// meter providers of the tenants, as a reader is registered with a single meter provider
+func (o *spanOutputs) meterProviderOptions() []sdkmetric.Option {
+	if o == nil || o.snapshot == nil {
+		return nil
+	}
+
+	return []sdkmetric.Option{sdkmetric.WithReader(o.snapshot.metricReader())}
+}
+
+// shutdown shuts down the exporters, once the tracer providers exported all their spans
+func (o *spanOutputs) shutdown(ctx context.Context) error {
+	errs := []error{}
+	for _, exporter := range o.exporters {
+		errs = append(errs, exporter.Shutdown(ctx))
+	}
+
+	return errors.Join(errs...)
+}
+
+// sharedSpanExporter an exporter shared by several tracer providers, which is not shut down with them
+type sharedSpanExporter struct {
+	sdktrace.SpanExporter
+}
+
+func (e sharedSpanExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joshdk/go-junit"
+)
+
+// overheadSpanName the name of the synthetic span of the time of the suite not spent in its test cases
+const overheadSpanName = "overhead"
+
+// defaultOverheadThreshold the minimum overhead of a suite to be reported
+const defaultOverheadThreshold = time.Second
+
+// overheadRatio the minimum ratio of the overhead over the declared time of the suite to be reported, so the rounding
+// of the times of the test cases is not reported as overhead
+const overheadRatio = 0.1
+
+// suiteOverhead returns the time declared by the suite element not spent in its test cases and its nested suites, i.e.
+// the setup and the teardown of its fixtures and the gaps of the scheduling, or zero if it's below the threshold or
+// the suite does not declare its time. The raw XML element can be nil, i.e. for the nested suites
+func suiteOverhead(suite junit.Suite, rawSuite *xmlElement, threshold time.Duration) time.Duration {
+	if rawSuite == nil || threshold <= 0 {
+		return 0
+	}
+
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(rawSuite.Attrs["time"]), 64)
+	if err != nil {
+		return 0
+	}
+
+	declared := time.Duration(seconds * float64(time.Second))
+	overhead := declared - suite.Totals.Duration
+	if overhead < threshold || float64(overhead) < overheadRatio*float64(declared) {
+		return 0
+	}
+
+	return overhead
+}
+
+// suiteSpanDuration returns the duration of the span of the i-th suite of the report, with its overhead, so the suites
+// are laid out one after the other
+func suiteSpanDuration(report *junitReport, i int) time.Duration {
+	suite := report.suites[i]
+	return suite.Totals.Duration + suiteOverhead(suite, report.rawSuite(i), overheadThresholdFlag)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSuiteOverhead(t *testing.T) {
+	suite := junit.Suite{Totals: junit.Totals{Duration: 10 * time.Second}}
+	declared := func(time string) *xmlElement {
+		return &xmlElement{Attrs: map[string]string{"time": time}}
+	}
+
+	require.Equal(t, 5*time.Second, suiteOverhead(suite, declared("15"), time.Second))
+	require.Equal(t, 2500*time.Millisecond, suiteOverhead(suite, declared(" 12.5 "), time.Second))
+
+	// below the threshold, or below the ratio of the declared time
+	require.Zero(t, suiteOverhead(suite, declared("10.5"), time.Second))
+	require.Zero(t, suiteOverhead(junit.Suite{Totals: junit.Totals{Duration: time.Hour}}, declared("3602"), time.Second))
+
+	// without the declared time, or disabled
+	require.Zero(t, suiteOverhead(suite, nil, time.Second))
+	require.Zero(t, suiteOverhead(suite, declared(""), time.Second))
+	require.Zero(t, suiteOverhead(suite, declared("8"), time.Second))
+	require.Zero(t, suiteOverhead(suite, declared("15"), 0))
+}
+
+func TestOverheadSpan(t *testing.T) {
+	defer func(threshold time.Duration) { overheadThresholdFlag = threshold }(overheadThresholdFlag)
+	overheadThresholdFlag = time.Second
+
+	exporter := tracetest.NewInMemoryExporter()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	tracer := tracerProvider.Tracer("test")
+	meter := sdkmetric.NewMeterProvider().Meter("test")
+
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	suite := junit.Suite{
+		Name:   "a",
+		Tests:  []junit.Test{{Name: "first", Duration: 3 * time.Second, Status: junit.StatusPassed}},
+		Totals: junit.Totals{Tests: 1, Passed: 1, Duration: 3 * time.Second},
+	}
+	rawSuite := &xmlElement{Attrs: map[string]string{"time": "10"}}
+
+	t.Run("Laid out after the test cases", func(t *testing.T) {
+		exporter.Reset()
+		createSuiteSpans(context.Background(), tracer, newTestCaseMetrics(meter, 0), suite, rawSuite, nil, nil, start)
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 3)
+		require.Equal(t, "first", spans[0].Name)
+		require.Equal(t, overheadSpanName, spans[1].Name)
+		require.Equal(t, start.Add(3*time.Second), spans[1].StartTime)
+		require.Equal(t, start.Add(10*time.Second), spans[1].EndTime)
+		require.Equal(t, spans[2].SpanContext.SpanID(), spans[1].Parent.SpanID())
+		require.Equal(t, "a", spans[2].Name)
+		require.Equal(t, start.Add(10*time.Second), spans[2].EndTime)
+		require.Contains(t, spans[2].Attributes, attribute.Key(SuiteOverheadDuration).Int64(7000))
+	})
+
+	t.Run("Only the attribute without the start of the suite", func(t *testing.T) {
+		exporter.Reset()
+		createSuiteSpans(context.Background(), tracer, newTestCaseMetrics(meter, 0), suite, rawSuite, nil, nil, time.Time{})
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 2)
+		require.Equal(t, "a", spans[1].Name)
+		require.Contains(t, spans[1].Attributes, attribute.Key(SuiteOverheadDuration).Int64(7000))
+	})
+}
+
+func TestSuiteSpanDuration(t *testing.T) {
+	defer func(threshold time.Duration) { overheadThresholdFlag = threshold }(overheadThresholdFlag)
+	overheadThresholdFlag = time.Second
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	report := &junitReport{
+		suites: []junit.Suite{
+			{Name: "a", Totals: junit.Totals{Duration: time.Minute}},
+			{Name: "b", Totals: junit.Totals{Duration: time.Minute}},
+		},
+		rawSuites: []*xmlElement{
+			{Attrs: map[string]string{"time": "90"}},
+			{Attrs: map[string]string{"time": "60.2"}},
+		},
+	}
+
+	require.Equal(t, 90*time.Second, suiteSpanDuration(report, 0))
+	require.Equal(t, time.Minute, suiteSpanDuration(report, 1))
+
+	// the next suite starts after the overhead of the previous one
+	require.Equal(t, []time.Time{now.Add(-150 * time.Second), now.Add(-time.Minute)}, suiteStartTimes(report, anchorEnd, time.UTC, now))
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/joshdk/go-junit"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+// ownershipRule assigns the test cases matching any of its glob patterns, by their package, their class name or
+// their file, to an owner, i.e. the team responsible for them, and optionally to a module of the codebase
+type ownershipRule struct {
+	Match  mappingFields `yaml:"match"`
+	Owner  string        `yaml:"owner"`
+	Module string        `yaml:"module"`
+
+	globs []*regexp.Regexp
+}
+
+// ownershipRules the ownership mapping of the test cases, where the first matching rule wins
+type ownershipRules []ownershipRule
+
+func (r ownershipRules) compile() error {
+	for i := range r {
+		rule := &r[i]
+		if rule.Owner == "" {
+			return fmt.Errorf("rule %d: the owner is mandatory", i)
+		}
+		if len(rule.Match) == 0 {
+			return fmt.Errorf("rule %d: the match patterns are mandatory", i)
+		}
+
+		globs, err := compileGlobs(strings.Join(rule.Match, ","))
+		if err != nil {
+			return fmt.Errorf("rule %d: %w", i, err)
+		}
+		rule.globs = globs
+	}
+
+	return nil
+}
+
+// ownerOf returns the owner and the module of the test case, by its package, its class name or its file, or empty
+// values if no rule matches it
+func (r ownershipRules) ownerOf(suite junit.Suite, test junit.Test) (string, string) {
+	for _, rule := range r {
+		if matchesAnyGlob(rule.globs, testPackage(suite, test), test.Classname, test.Properties["file"], suite.Properties["file"]) {
+			return rule.Owner, rule.Module
+		}
+	}
+
+	return "", ""
+}
+
+// ownerAttributes returns the attributes of the owner and the module of the test case, if it has an owner
+func (r ownershipRules) ownerAttributes(suite junit.Suite, test junit.Test) []attribute.KeyValue {
+	owner, module := r.ownerOf(suite, test)
+	if owner == "" {
+		return nil
+	}
+
+	attributes := []attribute.KeyValue{attribute.Key(TestOwner).String(owner)}
+	if module != "" {
+		attributes = append(attributes, attribute.Key(TestModule).String(module))
+	}
+
+	return attributes
+}
+
+// testPackage returns the package of the test case: the one of its suite, or its class name without the class
+func testPackage(suite junit.Suite, test junit.Test) string {
+	if suite.Package != "" {
+		return suite.Package
+	}
+
+	if i := strings.LastIndex(test.Classname, "."); i > 0 {
+		return test.Classname[:i]
+	}
+
+	return test.Classname
+}
+
+// ownerDuration the key of the durations aggregated by owner, module and package
+type ownerDuration struct {
+	owner  string
+	module string
+	pkg    string
+}
+
+// recordOwnerDurations records the durations of the test cases of the report, in milliseconds, aggregated by their
+// owner, their module and their package, so the time budget of the CI can be attributed to the teams
+func recordOwnerDurations(ctx context.Context, counter metric.Int64Counter, rules ownershipRules, report *junitReport) {
+	durations := map[ownerDuration]int64{}
+
+	var aggregate func(suite junit.Suite)
+	aggregate = func(suite junit.Suite) {
+		for _, test := range suite.Tests {
+			owner, module := rules.ownerOf(suite, test)
+			durations[ownerDuration{owner: owner, module: module, pkg: testPackage(suite, test)}] += test.Duration.Milliseconds()
+		}
+
+		for _, nested := range suite.Suites {
+			aggregate(nested)
+		}
+	}
+
+	for _, suite := range report.suites {
+		aggregate(suite)
+	}
+
+	// the series are recorded in order, so the exports are stable
+	keys := make([]ownerDuration, 0, len(durations))
+	for key := range durations {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b ownerDuration) int {
+		return strings.Compare(a.owner+"\x00"+a.module+"\x00"+a.pkg, b.owner+"\x00"+b.module+"\x00"+b.pkg)
+	})
+
+	for _, key := range keys {
+		attributes := []attribute.KeyValue{semconv.CodeNamespaceKey.String(key.pkg)}
+		if key.owner != "" {
+			attributes = append(attributes, attribute.Key(TestOwner).String(key.owner))
+		}
+		if key.module != "" {
+			attributes = append(attributes, attribute.Key(TestModule).String(key.module))
+		}
+
+		counter.Add(ctx, durations[key], metric.WithAttributes(attributes...))
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+func TestOwnershipRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+ownership:
+  - match: [com.acme.checkout*, "*/payments/*"]
+    owner: team-checkout
+    module: checkout
+  - match: com.acme.*
+    owner: team-platform
+`), 0o644))
+
+	cfg, err := loadConfig(path)
+	require.NoError(t, err)
+
+	suite := junit.Suite{Name: "checkout", Package: "com.acme.checkout.api"}
+	owner, module := cfg.Ownership.ownerOf(suite, junit.Test{Classname: "com.acme.checkout.api.CartTest"})
+	require.Equal(t, "team-checkout", owner)
+	require.Equal(t, "checkout", module)
+
+	// the first matching rule wins, by the package, the class name or the file
+	owner, module = cfg.Ownership.ownerOf(junit.Suite{}, junit.Test{Classname: "com.acme.search.SearchTest"})
+	require.Equal(t, "team-platform", owner)
+	require.Empty(t, module)
+
+	owner, _ = cfg.Ownership.ownerOf(junit.Suite{}, junit.Test{Properties: map[string]string{"file": "src/payments/cards_test.go"}})
+	require.Equal(t, "team-checkout", owner)
+
+	require.Empty(t, cfg.Ownership.ownerAttributes(junit.Suite{}, junit.Test{Classname: "org.other.Test"}))
+	require.Equal(t, []attribute.KeyValue{
+		attribute.String(TestOwner, "team-checkout"),
+		attribute.String(TestModule, "checkout"),
+	}, cfg.Ownership.ownerAttributes(suite, junit.Test{}))
+}
+
+func TestOwnershipRulesValidation(t *testing.T) {
+	for name, config := range map[string]string{
+		"missing owner": "ownership:\n  - match: com.acme.*\n",
+		"missing match": "ownership:\n  - owner: team\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yml")
+			require.NoError(t, os.WriteFile(path, []byte(config), 0o644))
+
+			_, err := loadConfig(path)
+			require.ErrorContains(t, err, "invalid ownership")
+		})
+	}
+}
+
+func TestRecordOwnerDurations(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+	counter := createIntCounter(meter, TestsDurationByOwner, "")
+
+	rules := ownershipRules{{Match: mappingFields{"com.acme.checkout*"}, Owner: "team-checkout"}}
+	require.NoError(t, rules.compile())
+
+	report := &junitReport{suites: []junit.Suite{{
+		Tests: []junit.Test{
+			{Classname: "com.acme.checkout.CartTest", Duration: 2 * time.Second},
+			{Classname: "com.acme.checkout.PayTest", Duration: time.Second},
+			{Classname: "com.acme.search.SearchTest", Duration: 500 * time.Millisecond},
+		},
+		Suites: []junit.Suite{{Package: "com.acme.checkout", Tests: []junit.Test{{Duration: 250 * time.Millisecond}}}},
+	}}}
+
+	recordOwnerDurations(context.Background(), counter, rules, report)
+
+	var metrics metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &metrics))
+
+	durations := map[string]int64{}
+	for _, point := range metrics.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64]).DataPoints {
+		owner, _ := point.Attributes.Value(attribute.Key(TestOwner))
+		pkg, _ := point.Attributes.Value(semconv.CodeNamespaceKey)
+		durations[owner.AsString()+" "+pkg.AsString()] = point.Value
+	}
+
+	require.Equal(t, map[string]int64{
+		"team-checkout com.acme.checkout": 3250,
+		" com.acme.search":                500,
+	}, durations)
+}
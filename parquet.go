@@ -0,0 +1,314 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// the subset of the Parquet format used to write the flattened rows: a single row group, with one uncompressed
+// data page per column, all of them optional and PLAIN encoded. See https://github.com/apache/parquet-format
+const parquetMagic = "PAR1"
+
+// the physical types of the columns
+const (
+	parquetBoolean   = 0
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+)
+
+const (
+	parquetOptional      = 1
+	parquetConvertedUTF8 = 0
+	parquetEncodingPlain = 0
+	parquetEncodingRLE   = 3
+	parquetDataPage      = 0
+	parquetUncompressed  = 0
+	parquetFormatVersion = 1
+	parquetStringColumn  = parquetByteArray
+)
+
+// parquetColumnType infers the physical type of the column from its values, falling back to strings
+func parquetColumnType(values []any) int32 {
+	kind := int32(-1)
+	for _, value := range values {
+		var valueKind int32
+		switch value.(type) {
+		case nil:
+			continue
+		case bool:
+			valueKind = parquetBoolean
+		case int64:
+			valueKind = parquetInt64
+		case float64:
+			valueKind = parquetDouble
+		default:
+			return parquetStringColumn
+		}
+
+		switch {
+		case kind == -1 || kind == valueKind:
+			kind = valueKind
+		case (kind == parquetInt64 && valueKind == parquetDouble) || (kind == parquetDouble && valueKind == parquetInt64):
+			kind = parquetDouble
+		default:
+			return parquetStringColumn
+		}
+	}
+
+	if kind == -1 {
+		return parquetStringColumn
+	}
+
+	return kind
+}
+
+// writeParquet writes the rows as a Parquet file, with the given columns
+func writeParquet(w io.Writer, columns []string, rows []map[string]any) error {
+	file := &bytes.Buffer{}
+	file.WriteString(parquetMagic)
+
+	chunks := &thriftWriter{}
+	schema := &thriftWriter{}
+
+	// the root of the schema, with the columns as its children
+	schema.beginStruct()
+	schema.fieldString(4, "schema")
+	schema.fieldI32(5, int32(len(columns)))
+	schema.endStruct()
+
+	for _, column := range columns {
+		values := make([]any, len(rows))
+		for i, row := range rows {
+			values[i] = row[column]
+		}
+
+		kind := parquetColumnType(values)
+
+		schema.beginStruct()
+		schema.fieldI32(1, kind)
+		schema.fieldI32(3, parquetOptional)
+		schema.fieldString(4, column)
+		if kind == parquetStringColumn {
+			schema.fieldI32(6, parquetConvertedUTF8)
+		}
+		schema.endStruct()
+
+		page := encodeParquetPage(kind, values)
+		header := &thriftWriter{}
+		header.beginStruct()
+		header.fieldI32(1, parquetDataPage)
+		header.fieldI32(2, int32(len(page)))
+		header.fieldI32(3, int32(len(page)))
+		header.fieldBeginStruct(5)
+		header.fieldI32(1, int32(len(values)))
+		header.fieldI32(2, parquetEncodingPlain)
+		header.fieldI32(3, parquetEncodingRLE)
+		header.fieldI32(4, parquetEncodingRLE)
+		header.endStruct()
+		header.endStruct()
+
+		offset := int64(file.Len())
+		size := int64(header.buf.Len() + len(page))
+		file.Write(header.buf.Bytes())
+		file.Write(page)
+
+		chunks.beginStruct()
+		chunks.fieldI64(2, offset)
+		chunks.fieldBeginStruct(3)
+		chunks.fieldI32(1, kind)
+		chunks.fieldListI32(2, []int32{parquetEncodingPlain, parquetEncodingRLE})
+		chunks.fieldListString(3, []string{column})
+		chunks.fieldI32(4, parquetUncompressed)
+		chunks.fieldI64(5, int64(len(values)))
+		chunks.fieldI64(6, size)
+		chunks.fieldI64(7, size)
+		chunks.fieldI64(9, offset)
+		chunks.endStruct()
+		chunks.endStruct()
+	}
+
+	dataSize := int64(file.Len() - len(parquetMagic))
+
+	metadata := &thriftWriter{}
+	metadata.beginStruct()
+	metadata.fieldI32(1, parquetFormatVersion)
+	metadata.fieldListStructs(2, len(columns)+1, schema.buf.Bytes())
+	metadata.fieldI64(3, int64(len(rows)))
+	metadata.fieldBeginList(4, thriftStruct, 1)
+	metadata.beginStruct()
+	metadata.fieldListStructs(1, len(columns), chunks.buf.Bytes())
+	metadata.fieldI64(2, dataSize)
+	metadata.fieldI64(3, int64(len(rows)))
+	metadata.endStruct()
+	metadata.fieldString(6, Junit2otlp+" version "+version)
+	metadata.endStruct()
+
+	file.Write(metadata.buf.Bytes())
+	_ = binary.Write(file, binary.LittleEndian, uint32(metadata.buf.Len()))
+	file.WriteString(parquetMagic)
+
+	_, err := w.Write(file.Bytes())
+	return err
+}
+
+// encodeParquetPage encodes the definition levels of the values, with the bit-packed hybrid encoding,
+// followed by the non-null values, PLAIN encoded
+func encodeParquetPage(kind int32, values []any) []byte {
+	defined := make([]bool, len(values))
+	for i, value := range values {
+		defined[i] = value != nil
+	}
+
+	levels := &bytes.Buffer{}
+	groups := (len(values) + 7) / 8
+	writeUvarint(levels, uint64(groups)<<1|1)
+	levels.Write(packBits(defined, groups))
+
+	page := &bytes.Buffer{}
+	_ = binary.Write(page, binary.LittleEndian, uint32(levels.Len()))
+	page.Write(levels.Bytes())
+
+	booleans := []bool{}
+	for _, value := range values {
+		if value == nil {
+			continue
+		}
+
+		switch kind {
+		case parquetBoolean:
+			booleans = append(booleans, value.(bool))
+		case parquetInt64:
+			_ = binary.Write(page, binary.LittleEndian, value.(int64))
+		case parquetDouble:
+			number, ok := value.(float64)
+			if !ok {
+				number = float64(value.(int64))
+			}
+			_ = binary.Write(page, binary.LittleEndian, math.Float64bits(number))
+		default:
+			text := formatRowValue(value)
+			_ = binary.Write(page, binary.LittleEndian, uint32(len(text)))
+			page.WriteString(text)
+		}
+	}
+
+	if kind == parquetBoolean {
+		page.Write(packBits(booleans, (len(booleans)+7)/8))
+	}
+
+	return page.Bytes()
+}
+
+// packBits packs the bits in the given number of bytes, from the least significant bit
+func packBits(bits []bool, size int) []byte {
+	packed := make([]byte, size)
+	for i, bit := range bits {
+		if bit {
+			packed[i/8] |= 1 << (i % 8)
+		}
+	}
+
+	return packed
+}
+
+func writeUvarint(buf *bytes.Buffer, value uint64) {
+	var encoded [binary.MaxVarintLen64]byte
+	buf.Write(encoded[:binary.PutUvarint(encoded[:], value)])
+}
+
+// the types of the Thrift compact protocol
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter writes the structs of the Parquet metadata with the Thrift compact protocol
+type thriftWriter struct {
+	buf     bytes.Buffer
+	lastIDs []int16
+}
+
+func (t *thriftWriter) beginStruct() {
+	t.lastIDs = append(t.lastIDs, 0)
+}
+
+func (t *thriftWriter) endStruct() {
+	t.buf.WriteByte(0)
+	t.lastIDs = t.lastIDs[:len(t.lastIDs)-1]
+}
+
+func (t *thriftWriter) fieldHeader(id int16, kind byte) {
+	last := t.lastIDs[len(t.lastIDs)-1]
+	if delta := id - last; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | kind)
+	} else {
+		t.buf.WriteByte(kind)
+		writeUvarint(&t.buf, zigzag(int64(id)))
+	}
+	t.lastIDs[len(t.lastIDs)-1] = id
+}
+
+func (t *thriftWriter) fieldI32(id int16, value int32) {
+	t.fieldHeader(id, thriftI32)
+	writeUvarint(&t.buf, zigzag(int64(value)))
+}
+
+func (t *thriftWriter) fieldI64(id int16, value int64) {
+	t.fieldHeader(id, thriftI64)
+	writeUvarint(&t.buf, zigzag(value))
+}
+
+func (t *thriftWriter) fieldString(id int16, value string) {
+	t.fieldHeader(id, thriftBinary)
+	t.writeString(value)
+}
+
+func (t *thriftWriter) writeString(value string) {
+	writeUvarint(&t.buf, uint64(len(value)))
+	t.buf.WriteString(value)
+}
+
+func (t *thriftWriter) fieldBeginStruct(id int16) {
+	t.fieldHeader(id, thriftStruct)
+	t.beginStruct()
+}
+
+func (t *thriftWriter) fieldBeginList(id int16, kind byte, size int) {
+	t.fieldHeader(id, thriftList)
+	if size < 15 {
+		t.buf.WriteByte(byte(size)<<4 | kind)
+	} else {
+		t.buf.WriteByte(0xf0 | kind)
+		writeUvarint(&t.buf, uint64(size))
+	}
+}
+
+func (t *thriftWriter) fieldListI32(id int16, values []int32) {
+	t.fieldBeginList(id, thriftI32, len(values))
+	for _, value := range values {
+		writeUvarint(&t.buf, zigzag(int64(value)))
+	}
+}
+
+func (t *thriftWriter) fieldListString(id int16, values []string) {
+	t.fieldBeginList(id, thriftBinary, len(values))
+	for _, value := range values {
+		t.writeString(value)
+	}
+}
+
+// fieldListStructs writes a list of structs already encoded
+func (t *thriftWriter) fieldListStructs(id int16, size int, encoded []byte) {
+	t.fieldBeginList(id, thriftStruct, size)
+	t.buf.Write(encoded)
+}
+
+func zigzag(value int64) uint64 {
+	return uint64((value << 1) ^ (value >> 63))
+}
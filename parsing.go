@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// reportParseError an error parsing a report, located at the line and the column of the report where it was
+// found, starting at 1. The line and the column are 0 if the location is unknown
+type reportParseError struct {
+	format string
+	line   int
+	column int
+	err    error
+}
+
+func (e *reportParseError) Error() string {
+	message := e.err.Error()
+
+	// the line is already in the message of the XML syntax errors
+	var syntaxErr *xml.SyntaxError
+	if errors.As(e.err, &syntaxErr) {
+		message = syntaxErr.Msg
+	}
+
+	if e.line == 0 {
+		return fmt.Sprintf("failed to parse the %s report: %s", e.format, message)
+	}
+
+	return fmt.Sprintf("failed to parse the %s report at line %d, column %d: %s", e.format, e.line, e.column, message)
+}
+
+func (e *reportParseError) Unwrap() error {
+	return e.err
+}
+
+// jsonParseError returns the error parsing a JSON report, located from the offset of the syntax and type errors
+func jsonParseError(format string, data []byte, err error) error {
+	parseErr := &reportParseError{format: format, err: err}
+
+	var offset int64
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	}
+
+	// the offset is the one after the byte where the error was found
+	if offset > 0 {
+		parseErr.line, parseErr.column = positionAt(data, offset-1)
+	}
+
+	return parseErr
+}
+
+// positionAt returns the line and the column of the byte at the offset of the data, starting at 1
+func positionAt(data []byte, offset int64) (int, int) {
+	before := data[:min(offset, int64(len(data)))]
+
+	return bytes.Count(before, []byte("\n")) + 1, len(before) - bytes.LastIndexByte(before, '\n')
+}
+
+// xmlEncodingRegex matches the encoding of the XML declaration, i.e. <?xml version="1.0" encoding="ISO-8859-1"?>
+var xmlEncodingRegex = regexp.MustCompile(`^(\s*<\?xml[^>]*?\sencoding\s*=\s*["'])([A-Za-z0-9._-]+)(["'])`)
+
+// xmlCharRefRegex matches the character references of the XML documents, i.e. &#27; or &#x1b;
+var xmlCharRefRegex = regexp.MustCompile(`&#(x[0-9a-fA-F]+|[0-9]+);`)
+
+// xmlSingleByteEncodings the single byte encodings declared by the reports of the older tools, i.e. Ant, which
+// are converted to UTF-8, as the XML decoder only supports UTF-8. ASCII is a subset of all of them
+var xmlSingleByteEncodings = map[string]*charmap.Charmap{
+	"ascii":        charmap.Windows1252,
+	"cp1252":       charmap.Windows1252,
+	"iso-8859-1":   charmap.ISO8859_1,
+	"iso-8859-15":  charmap.ISO8859_15,
+	"iso8859-1":    charmap.ISO8859_1,
+	"latin1":       charmap.ISO8859_1,
+	"us-ascii":     charmap.Windows1252,
+	"windows-1252": charmap.Windows1252,
+}
+
+// utf8BOM the byte order mark written by some tools at the start of the UTF-8 documents
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// utf16BOMs the byte order marks of the UTF-16 documents, i.e. the reports redirected to a file by PowerShell
+var utf16BOMs = [][]byte{[]byte("\xff\xfe"), []byte("\xfe\xff")}
+
+// decodeBOM returns the document without its byte order mark, converted to UTF-8 if it's an UTF-16 one, with
+// the encoding of its XML declaration, if any, rewritten as UTF-8
+func decodeBOM(data []byte) []byte {
+	if bytes.HasPrefix(data, utf8BOM) {
+		return data[len(utf8BOM):]
+	}
+
+	for _, bom := range utf16BOMs {
+		if !bytes.HasPrefix(data, bom) {
+			continue
+		}
+
+		// the endianness is the one of the byte order mark, which is removed
+		decoded, err := unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM).NewDecoder().Bytes(data)
+		if err != nil {
+			return data
+		}
+
+		return xmlEncodingRegex.ReplaceAll(decoded, []byte("${1}UTF-8${3}"))
+	}
+
+	return data
+}
+
+// sanitizeXMLReport fixes the deviations from the XML specification found in the reports of the real world, so
+// they are parsed leniently instead of failing the pipeline: the single byte encodings are converted to UTF-8,
+// the invalid UTF-8 sequences are replaced, and the characters not allowed in XML documents, i.e. the ANSI escape
+// codes of the colored outputs of the tests, are removed. The lines of the document are kept, so the errors of
+// the sanitized document are located in the original one. The fixes are recorded in the ledger, if not nil
+func sanitizeXMLReport(data []byte, drops *dropLedger) []byte {
+	data = decodeBOM(data)
+
+	if matches := xmlEncodingRegex.FindSubmatchIndex(data); matches != nil {
+		encoding := strings.ToLower(string(data[matches[4]:matches[5]]))
+		if cm, ok := xmlSingleByteEncodings[encoding]; ok {
+			decoded, err := cm.NewDecoder().Bytes(data)
+			if err == nil {
+				data = xmlEncodingRegex.ReplaceAll(decoded, []byte("${1}UTF-8${3}"))
+			}
+		}
+	}
+
+	// the reports are copied only if they need to be fixed, as they can be large
+	if !utf8.Valid(data) {
+		drops.drop(droppedUTF8Sequences, invalidUTF8Sequences(data), "the report is not valid UTF-8")
+		data = bytes.ToValidUTF8(data, []byte(string(utf8.RuneError)))
+	}
+
+	if bytes.IndexFunc(data, func(r rune) bool { return !isXMLChar(r) }) >= 0 {
+		removed := int64(0)
+		data = bytes.Map(func(r rune) rune {
+			if !isXMLChar(r) {
+				removed++
+				return -1
+			}
+
+			return r
+		}, data)
+		drops.drop(droppedCharacters, removed, "the report has control characters, i.e. ANSI escape codes")
+	}
+
+	if !bytes.Contains(data, []byte("&#")) {
+		return data
+	}
+
+	removed := int64(0)
+	data = xmlCharRefRegex.ReplaceAllFunc(data, func(ref []byte) []byte {
+		value := string(ref[2 : len(ref)-1])
+		base := 10
+		if strings.HasPrefix(value, "x") {
+			value, base = value[1:], 16
+		}
+
+		code, err := strconv.ParseUint(value, base, 32)
+		if err != nil || !isXMLChar(rune(code)) {
+			removed++
+			return nil
+		}
+
+		return ref
+	})
+	drops.drop(droppedCharReferences, removed, "the report has references to control characters, i.e. ANSI escape codes")
+
+	return data
+}
+
+// invalidUTF8Sequences returns the number of runs of invalid UTF-8 bytes of the data, as each one is replaced
+// by a single replacement character
+func invalidUTF8Sequences(data []byte) int64 {
+	count := int64(0)
+	invalid := false
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		if r == utf8.RuneError && size == 1 {
+			if !invalid {
+				count++
+			}
+			invalid = true
+		} else {
+			invalid = false
+		}
+		data = data[size:]
+	}
+
+	return count
+}
+
+// isXMLChar checks the character is allowed in XML documents, as defined by the XML specification
+func isXMLChar(r rune) bool {
+	return r == 0x09 || r == 0x0A || r == 0x0D ||
+		r >= 0x20 && r <= 0xD7FF ||
+		r >= 0xE000 && r <= 0xFFFD ||
+		r >= 0x10000 && r <= 0x10FFFF
+}
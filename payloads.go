@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"aead.dev/minisign"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// payloadDigestPrefix the algorithm of the digests of the payloads, prefixed as the digests of the OCI images
+const payloadDigestPrefix = "sha256:"
+
+// payloadEncoding the deterministic encoding of the payloads, so the verifiers encode them to the same bytes
+var payloadEncoding = proto.MarshalOptions{Deterministic: true}
+
+// digestingTraceClient attaches to the resource of the spans of each payload the digest of its serialized spans,
+// and their minisign signature if there is a signing key, so the telemetry of the tests can be proven to be the one
+// exported by the tool when it's kept as an audit record. The digest covers the payload without both attributes
+type digestingTraceClient struct {
+	otlptrace.Client
+	signingKey *minisign.PrivateKey
+}
+
+func (c *digestingTraceClient) UploadTraces(ctx context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	digested := make([]*tracepb.ResourceSpans, 0, len(protoSpans))
+	for _, rs := range protoSpans {
+		d, err := digestResourceSpans(rs, c.signingKey)
+		if err != nil {
+			return err
+		}
+
+		digested = append(digested, d)
+	}
+
+	return c.Client.UploadTraces(ctx, digested)
+}
+
+// digestResourceSpans returns the spans with the digest, and the signature, in the attributes of their resource,
+// without modifying the given ones
+func digestResourceSpans(rs *tracepb.ResourceSpans, signingKey *minisign.PrivateKey) (*tracepb.ResourceSpans, error) {
+	data, err := payloadEncoding.Marshal(rs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the payload to digest it: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	attributes := []attribute.KeyValue{attribute.String(PayloadDigest, payloadDigestPrefix+hex.EncodeToString(sum[:]))}
+	if signingKey != nil {
+		attributes = append(attributes, attribute.String(PayloadSignature, string(minisign.Sign(*signingKey, data))))
+	}
+
+	res := &resourcepb.Resource{}
+	if rs.GetResource() != nil {
+		res = proto.Clone(rs.GetResource()).(*resourcepb.Resource)
+	}
+	res.Attributes = append(res.Attributes, snapshotAttributes(attributes)...)
+
+	return &tracepb.ResourceSpans{Resource: res, ScopeSpans: rs.GetScopeSpans(), SchemaUrl: rs.GetSchemaUrl()}, nil
+}
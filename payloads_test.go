@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"aead.dev/minisign"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// recordingTraceClient records the spans uploaded to the collector
+type recordingTraceClient struct {
+	otlptrace.Client
+	uploaded []*tracepb.ResourceSpans
+}
+
+func (c *recordingTraceClient) UploadTraces(ctx context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	c.uploaded = append(c.uploaded, protoSpans...)
+	return nil
+}
+
+func testResourceSpans() *tracepb.ResourceSpans {
+	return &tracepb.ResourceSpans{
+		Resource: &resourcepb.Resource{Attributes: snapshotAttributes([]attribute.KeyValue{attribute.String("service.name", "payloads")})},
+		ScopeSpans: []*tracepb.ScopeSpans{{
+			Spans: []*tracepb.Span{{Name: "TestPayload", TraceId: make([]byte, 16), SpanId: make([]byte, 8)}},
+		}},
+		SchemaUrl: "https://opentelemetry.io/schemas/1.4.0",
+	}
+}
+
+func TestPayloadDigest(t *testing.T) {
+	publicKey, privateKey, err := minisign.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	otherKey, _, err := minisign.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	t.Run("Digest", func(t *testing.T) {
+		rs := testResourceSpans()
+		digested, err := digestResourceSpans(rs, nil)
+		require.NoError(t, err)
+
+		// the spans given to the client are not modified
+		require.Len(t, rs.Resource.Attributes, 1)
+		require.Len(t, digested.Resource.Attributes, 2)
+		require.Equal(t, PayloadDigest, digested.Resource.Attributes[1].Key)
+		require.True(t, strings.HasPrefix(digested.Resource.Attributes[1].Value.GetStringValue(), payloadDigestPrefix))
+
+		require.NoError(t, verifyResourceSpans(digested, nil))
+		require.ErrorContains(t, verifyResourceSpans(digested, &publicKey), "not signed")
+		require.ErrorContains(t, verifyResourceSpans(rs, nil), "no digest")
+	})
+
+	t.Run("Signature", func(t *testing.T) {
+		digested, err := digestResourceSpans(testResourceSpans(), &privateKey)
+		require.NoError(t, err)
+
+		require.Len(t, digested.Resource.Attributes, 3)
+		require.Equal(t, PayloadSignature, digested.Resource.Attributes[2].Key)
+
+		require.NoError(t, verifyResourceSpans(digested, &publicKey))
+		require.ErrorContains(t, verifyResourceSpans(digested, &otherKey), "invalid signature")
+	})
+
+	t.Run("Tampered spans", func(t *testing.T) {
+		digested, err := digestResourceSpans(testResourceSpans(), &privateKey)
+		require.NoError(t, err)
+
+		digested.ScopeSpans[0].Spans[0].Name = "TestForged"
+		require.ErrorContains(t, verifyResourceSpans(digested, &publicKey), "does not match")
+	})
+
+	t.Run("Client", func(t *testing.T) {
+		recorder := &recordingTraceClient{}
+		client := &digestingTraceClient{Client: recorder, signingKey: &privateKey}
+
+		require.NoError(t, client.UploadTraces(context.Background(), []*tracepb.ResourceSpans{testResourceSpans(), testResourceSpans()}))
+
+		require.Len(t, recorder.uploaded, 2)
+		for _, rs := range recorder.uploaded {
+			require.NoError(t, verifyResourceSpans(rs, &publicKey))
+		}
+	})
+}
+
+// verifyResourceSpans verifies the digest of the spans received from the tool, and their signature with the public
+// key, if any, as the verifiers of the audit records do: both attributes are removed from their resource, and the
+// rest is encoded deterministically
+func verifyResourceSpans(rs *tracepb.ResourceSpans, publicKey *minisign.PublicKey) error {
+	if rs.GetResource() == nil {
+		return errors.New("the payload has no digest")
+	}
+
+	res := proto.Clone(rs.GetResource()).(*resourcepb.Resource)
+
+	var digest, signature string
+	res.Attributes = slices.DeleteFunc(res.Attributes, func(kv *commonpb.KeyValue) bool {
+		switch kv.GetKey() {
+		case PayloadDigest:
+			digest = kv.GetValue().GetStringValue()
+		case PayloadSignature:
+			signature = kv.GetValue().GetStringValue()
+		default:
+			return false
+		}
+		return true
+	})
+
+	if digest == "" {
+		return errors.New("the payload has no digest")
+	}
+
+	data, err := payloadEncoding.Marshal(&tracepb.ResourceSpans{Resource: res, ScopeSpans: rs.GetScopeSpans(), SchemaUrl: rs.GetSchemaUrl()})
+	if err != nil {
+		return err
+	}
+
+	if sum := sha256.Sum256(data); payloadDigestPrefix+hex.EncodeToString(sum[:]) != digest {
+		return fmt.Errorf("the digest %s of the payload does not match", digest)
+	}
+
+	if publicKey == nil {
+		return nil
+	}
+
+	if signature == "" {
+		return errors.New("the payload is not signed")
+	}
+
+	if !minisign.Verify(*publicKey, data, []byte(signature)) {
+		return errors.New("invalid signature of the payload")
+	}
+
+	return nil
+}
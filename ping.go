@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const pingCommand = "ping"
+const pingSpanName = "junit2otlp ping"
+const pingTimeout = 10 * time.Second
+const defaultOtlpEndpoint = "localhost:4317"
+
+// otlpTracesEndpoint returns the endpoint where the traces are sent, as configured in the environment or in the
+// exporters of the configuration file
+func otlpTracesEndpoint() string {
+	if otlpEndpointFlag != "" {
+		return otlpEndpointFlag
+	}
+
+	if endpoint := firstEnv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "OTEL_EXPORTER_OTLP_ENDPOINT"); endpoint != "" {
+		return endpoint
+	}
+
+	if appConfig.Exporters.Traces.Endpoint != "" {
+		return appConfig.Exporters.Traces.Endpoint
+	}
+
+	return defaultOtlpEndpoint
+}
+
+// ping sends a tiny test span to the configured endpoint, checking the connectivity and the
+// authentication end to end, as a misconfigured endpoint otherwise appears as silent data loss
+func ping(ctx context.Context) error {
+	endpoint := otlpTracesEndpoint()
+
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	// no retries, so that an unreachable endpoint is reported as soon as possible
+	exporter, err := newOtlpTraceExporter(ctx, withoutOtlpRetry())
+	if err != nil {
+		return fmt.Errorf("failed to create the exporter for %s: %w", endpoint, err)
+	}
+	defer exporter.Shutdown(context.Background())
+
+	now := time.Now()
+	span := tracetest.SpanStub{
+		Name: pingSpanName,
+		SpanContext: trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    trace.TraceID{0x01},
+			SpanID:     trace.SpanID{0x01},
+			TraceFlags: trace.FlagsSampled,
+		}),
+		SpanKind:  trace.SpanKindInternal,
+		StartTime: now,
+		EndTime:   now,
+	}
+
+	if err := exporter.ExportSpans(ctx, []sdktrace.ReadOnlySpan{span.Snapshot()}); err != nil {
+		return describePingError(endpoint, err)
+	}
+
+	log.Printf("successfully sent a test span to %s", endpoint)
+	return nil
+}
+
+// describePingError converts the error of the export into an actionable message
+func describePingError(endpoint string, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s sending to %s: check that the endpoint is reachable from this network: %w", pingTimeout, endpoint, err)
+	}
+
+	var hint string
+	switch status.Code(err) {
+	case codes.Unavailable:
+		hint = "the endpoint is unreachable: check the OTEL_EXPORTER_OTLP_ENDPOINT environment variable, and OTEL_EXPORTER_OTLP_INSECURE if the collector does not use TLS"
+	case codes.Unauthenticated:
+		hint = "the request was not authenticated: check the credentials in the OTEL_EXPORTER_OTLP_HEADERS environment variable"
+	case codes.PermissionDenied:
+		hint = "the credentials are not allowed to send traces: check the credentials in the OTEL_EXPORTER_OTLP_HEADERS environment variable"
+	case codes.Unimplemented:
+		hint = "the endpoint does not accept OTLP traces over gRPC: check that it's the gRPC port (i.e. 4317) and not the HTTP one (i.e. 4318)"
+	case codes.DeadlineExceeded:
+		hint = "the endpoint did not answer in time: check that it's reachable from this network"
+	case codes.ResourceExhausted:
+		hint = "the endpoint is throttling the requests: check the quotas of the backend"
+	default:
+		hint = "unexpected error"
+	}
+
+	return fmt.Errorf("failed to send a test span to %s: %s: %w", endpoint, hint, err)
+}
+
+// runPing parses the flags of the ping command and runs it
+func runPing(args []string) {
+	if err := resolveFlags(flag.CommandLine, args); err != nil {
+		log.Fatal(err)
+	}
+
+	cfg, err := loadConfig(configFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	appConfig = cfg
+
+	if err := ping(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDescribePingError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{status.Error(codes.Unavailable, "connection refused"), "the endpoint is unreachable"},
+		{status.Error(codes.Unauthenticated, "missing token"), "OTEL_EXPORTER_OTLP_HEADERS"},
+		{status.Error(codes.PermissionDenied, "forbidden"), "not allowed to send traces"},
+		{fmt.Errorf("traces export: %w", status.Error(codes.Unimplemented, "unknown service")), "not the HTTP one"},
+		{fmt.Errorf("traces export: %w", context.DeadlineExceeded), "timed out"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			err := describePingError("localhost:4317", tt.err)
+			require.ErrorContains(t, err, tt.expected)
+			require.ErrorContains(t, err, "localhost:4317")
+			require.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestOtlpTracesEndpoint(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	require.Equal(t, defaultOtlpEndpoint, otlpTracesEndpoint())
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "collector:4317")
+	require.Equal(t, "collector:4317", otlpTracesEndpoint())
+
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "traces:4317")
+	require.Equal(t, "traces:4317", otlpTracesEndpoint())
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joshdk/go-junit"
+)
+
+// playwrightTitleSeparator separator of the titles of the describe blocks, as Playwright shows them
+const playwrightTitleSeparator = " › "
+
+// playwrightReport the JSON report of Playwright, produced by the json reporter
+type playwrightReport struct {
+	Config struct {
+		Version string `json:"version"`
+	} `json:"config"`
+	Suites []playwrightSuite `json:"suites"`
+}
+
+type playwrightSuite struct {
+	Title  string            `json:"title"`
+	File   string            `json:"file"`
+	Specs  []playwrightSpec  `json:"specs"`
+	Suites []playwrightSuite `json:"suites"`
+}
+
+type playwrightSpec struct {
+	Title string           `json:"title"`
+	File  string           `json:"file"`
+	Line  int              `json:"line"`
+	ID    string           `json:"id"`
+	Tests []playwrightTest `json:"tests"`
+}
+
+type playwrightTest struct {
+	ProjectName string             `json:"projectName"`
+	Status      string             `json:"status"`
+	Results     []playwrightResult `json:"results"`
+}
+
+type playwrightResult struct {
+	Status      string                 `json:"status"`
+	Duration    int64                  `json:"duration"`
+	Retry       int                    `json:"retry"`
+	Error       *playwrightError       `json:"error"`
+	Stdout      []playwrightOutput     `json:"stdout"`
+	Stderr      []playwrightOutput     `json:"stderr"`
+	Attachments []playwrightAttachment `json:"attachments"`
+}
+
+type playwrightError struct {
+	Message string `json:"message"`
+	Stack   string `json:"stack"`
+}
+
+type playwrightOutput struct {
+	Text string `json:"text"`
+}
+
+type playwrightAttachment struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// parsePlaywrightReport converts the JSON report of Playwright to jUnit suites, one per spec file, with a test
+// case for each test and project (i.e. browser). The last result is the one reported, and the retries,
+// the project and the paths of the artifacts (i.e. traces, videos and screenshots) are kept as properties
+func parsePlaywrightReport(path string, data []byte) (*junitReport, error) {
+	pw := playwrightReport{}
+	if err := json.Unmarshal(data, &pw); err != nil {
+		return nil, jsonParseError("Playwright", data, err)
+	}
+
+	report := &junitReport{framework: &testFramework{Name: reportFormatPlaywright, Version: pw.Config.Version}}
+	for _, fileSuite := range pw.Suites {
+		suite := junit.Suite{
+			Name:       fileSuite.Title,
+			Package:    fileSuite.File,
+			Properties: map[string]string{"file": fileSuite.File},
+		}
+		suite.Tests = playwrightTests(fileSuite, nil)
+		suite.Aggregate()
+
+		report.suites = append(report.suites, suite)
+	}
+
+	return report, nil
+}
+
+// playwrightTests returns the test cases of the suite and its nested describe blocks, named after their titles
+func playwrightTests(suite playwrightSuite, titles []string) []junit.Test {
+	tests := []junit.Test{}
+
+	for _, spec := range suite.Specs {
+		name := strings.Join(append(append([]string{}, titles...), spec.Title), playwrightTitleSeparator)
+
+		for _, pwTest := range spec.Tests {
+			if len(pwTest.Results) == 0 {
+				tests = append(tests, junit.Test{Name: name, Classname: spec.File, Status: junit.StatusSkipped})
+				continue
+			}
+
+			result := pwTest.Results[len(pwTest.Results)-1]
+			test := junit.Test{
+				Name:      name,
+				Classname: spec.File,
+				Duration:  time.Duration(result.Duration) * time.Millisecond,
+				Status:    playwrightStatus(pwTest.Status),
+				SystemOut: playwrightOutputText(result.Stdout),
+				SystemErr: playwrightOutputText(result.Stderr),
+				Properties: map[string]string{
+					"file":      spec.File,
+					"id":        spec.ID,
+					TestRetries: strconv.Itoa(result.Retry),
+				},
+			}
+
+			if spec.Line > 0 {
+				test.Properties["line"] = strconv.Itoa(spec.Line)
+			}
+			if pwTest.ProjectName != "" {
+				test.Properties[TestProject] = pwTest.ProjectName
+			}
+			if pwTest.Status == "flaky" {
+				test.Properties[TestFlaky] = "true"
+			}
+
+			for _, attachment := range result.Attachments {
+				if attachment.Path != "" {
+					test.Properties[TestArtifactPrefix+attachment.Name] = attachment.Path
+				}
+			}
+
+			if result.Error != nil && test.Status == junit.StatusFailed {
+				test.Message = result.Error.Message
+				test.Error = junit.Error{Message: result.Error.Message, Body: result.Error.Stack}
+			}
+
+			tests = append(tests, test)
+		}
+	}
+
+	for _, nested := range suite.Suites {
+		tests = append(tests, playwrightTests(nested, append(append([]string{}, titles...), nested.Title))...)
+	}
+
+	return tests
+}
+
+// playwrightStatus converts the outcome of a Playwright test to a jUnit status: flaky tests passed on a retry
+func playwrightStatus(status string) junit.Status {
+	switch status {
+	case "expected", "flaky":
+		return junit.StatusPassed
+	case "skipped":
+		return junit.StatusSkipped
+	default:
+		return junit.StatusFailed
+	}
+}
+
+func playwrightOutputText(output []playwrightOutput) string {
+	texts := make([]string, 0, len(output))
+	for _, o := range output {
+		texts = append(texts, o.Text)
+	}
+
+	return strings.Join(texts, "")
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// writePrometheus writes the metrics in the Prometheus text exposition format: the dots of the names are replaced
+// by underscores, the monotonic sums get the _total suffix, and the histograms are written as cumulative buckets.
+// See https://prometheus.io/docs/instrumenting/exposition_formats/
+func writePrometheus(w io.Writer, rm metricdata.ResourceMetrics) error {
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			name := prometheusName(m.Name)
+
+			var err error
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				err = writePrometheusSum(w, name, m.Description, data)
+			case metricdata.Sum[float64]:
+				err = writePrometheusSum(w, name, m.Description, data)
+			case metricdata.Gauge[int64]:
+				err = writePrometheusGauge(w, name, m.Description, data.DataPoints)
+			case metricdata.Gauge[float64]:
+				err = writePrometheusGauge(w, name, m.Description, data.DataPoints)
+			case metricdata.Histogram[int64]:
+				err = writePrometheusHistogram(w, name, m.Description, data.DataPoints)
+			case metricdata.Histogram[float64]:
+				err = writePrometheusHistogram(w, name, m.Description, data.DataPoints)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func writePrometheusSum[N int64 | float64](w io.Writer, name string, description string, data metricdata.Sum[N]) error {
+	kind := "gauge"
+	if data.IsMonotonic {
+		name += "_total"
+		kind = "counter"
+	}
+
+	if err := writePrometheusHeader(w, name, description, kind); err != nil {
+		return err
+	}
+
+	for _, point := range data.DataPoints {
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", name, prometheusLabels(point.Attributes), formatPrometheusValue(float64(point.Value))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writePrometheusGauge[N int64 | float64](w io.Writer, name string, description string, points []metricdata.DataPoint[N]) error {
+	if err := writePrometheusHeader(w, name, description, "gauge"); err != nil {
+		return err
+	}
+
+	for _, point := range points {
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", name, prometheusLabels(point.Attributes), formatPrometheusValue(float64(point.Value))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writePrometheusHistogram[N int64 | float64](w io.Writer, name string, description string, points []metricdata.HistogramDataPoint[N]) error {
+	if err := writePrometheusHeader(w, name, description, "histogram"); err != nil {
+		return err
+	}
+
+	for _, point := range points {
+		cumulative := uint64(0)
+		for i, count := range point.BucketCounts {
+			cumulative += count
+
+			bound := math.Inf(1)
+			if i < len(point.Bounds) {
+				bound = point.Bounds[i]
+			}
+
+			labels := prometheusLabels(point.Attributes, attribute.String("le", formatPrometheusValue(bound)))
+			if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", name, labels, cumulative); err != nil {
+				return err
+			}
+		}
+
+		labels := prometheusLabels(point.Attributes)
+		if _, err := fmt.Fprintf(w, "%s_sum%s %s\n%s_count%s %d\n", name, labels, formatPrometheusValue(float64(point.Sum)), name, labels, point.Count); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writePrometheusHeader(w io.Writer, name string, description string, kind string) error {
+	if description != "" {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n", name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(description)); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+	return err
+}
+
+// prometheusName replaces the characters not allowed in the names of the metrics and the labels by underscores
+func prometheusName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == ':' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// prometheusLabels returns the labels of the attributes, sorted by name, followed by the extra ones
+func prometheusLabels(set attribute.Set, extra ...attribute.KeyValue) string {
+	labels := []string{}
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+	for _, kv := range set.ToSlice() {
+		labels = append(labels, fmt.Sprintf(`%s="%s"`, prometheusName(string(kv.Key)), escape.Replace(kv.Value.Emit())))
+	}
+	slices.Sort(labels)
+
+	for _, kv := range extra {
+		labels = append(labels, fmt.Sprintf(`%s="%s"`, kv.Key, escape.Replace(kv.Value.Emit())))
+	}
+
+	if len(labels) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(labels, ",") + "}"
+}
+
+func formatPrometheusValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// reportSource the provenance of the suites of a report: the file they were read from, its hash and modification
+// time, and the format of the report, so a span can be traced back to the exact artifact producing it
+type reportSource struct {
+	file     string
+	sha256   string
+	modified time.Time
+	format   string
+}
+
+// newReportSource returns the provenance of the report read from the path, or from the standard input or a request
+// of the server mode if the path is empty, without a file nor a modification time then
+func newReportSource(path string, format string, data []byte) *reportSource {
+	sum := sha256.Sum256(data)
+	source := &reportSource{file: path, sha256: hex.EncodeToString(sum[:]), format: format}
+
+	if path != "" {
+		if info, err := os.Stat(path); err == nil {
+			source.modified = info.ModTime().UTC()
+		}
+	}
+
+	return source
+}
+
+// attributes returns the provenance attributes of the suites, which have a value for each run, so they are only
+// added to the spans
+func (s *reportSource) attributes() []attribute.KeyValue {
+	if s == nil {
+		return nil
+	}
+
+	attributes := []attribute.KeyValue{
+		attribute.Key(ReportSourceSHA256).String(s.sha256),
+		attribute.Key(ReportSourceFormat).String(s.format),
+	}
+	if s.file != "" {
+		attributes = append(attributes, attribute.Key(ReportSourceFile).String(s.file))
+	}
+	if !s.modified.IsZero() {
+		attributes = append(attributes, attribute.Key(ReportSourceModified).String(s.modified.Format(time.RFC3339)))
+	}
+
+	return attributes
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestReportSource(t *testing.T) {
+	data := []byte(`<testsuites><testsuite name="a" tests="1"><testcase name="t"/></testsuite><testsuite name="b" tests="0"/></testsuites>`)
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "TEST-provenance.xml")
+		require.NoError(t, os.WriteFile(path, data, 0o644))
+		modified := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+		require.NoError(t, os.Chtimes(path, modified, modified))
+
+		report, err := parseReport(reportFormatAuto, path, data)
+		require.NoError(t, err)
+		require.Len(t, report.sources, 2)
+		require.Same(t, report.source(0), report.source(1))
+
+		require.ElementsMatch(t, []attribute.KeyValue{
+			attribute.Key(ReportSourceFile).String(path),
+			attribute.Key(ReportSourceSHA256).String(hash),
+			attribute.Key(ReportSourceModified).String("2024-05-01T10:30:00Z"),
+			attribute.Key(ReportSourceFormat).String(reportFormatJUnit),
+		}, report.source(0).attributes())
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		report, err := parseReport(reportFormatAuto, "", data)
+		require.NoError(t, err)
+
+		require.ElementsMatch(t, []attribute.KeyValue{
+			attribute.Key(ReportSourceSHA256).String(hash),
+			attribute.Key(ReportSourceFormat).String(reportFormatJUnit),
+		}, report.source(0).attributes())
+	})
+
+	t.Run("byte-order-mark", func(t *testing.T) {
+		withBOM := append([]byte{0xEF, 0xBB, 0xBF}, data...)
+		report, err := parseReport(reportFormatAuto, "", withBOM)
+		require.NoError(t, err)
+
+		sum := sha256.Sum256(withBOM)
+		require.Contains(t, report.source(0).attributes(), attribute.Key(ReportSourceSHA256).String(hex.EncodeToString(sum[:])))
+	})
+
+	t.Run("merged", func(t *testing.T) {
+		first, err := parseReport(reportFormatAuto, "first.xml", data)
+		require.NoError(t, err)
+		second, err := parseReport(reportFormatAuto, "second.xml", data)
+		require.NoError(t, err)
+
+		merged := mergeReports([]*junitReport{first, second})
+		require.Len(t, merged.sources, 4)
+		require.Equal(t, "first.xml", merged.source(1).file)
+		require.Equal(t, "second.xml", merged.source(2).file)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		require.Nil(t, (&junitReport{}).source(0).attributes())
+	})
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const defaultQueueSize = 100
+
+// defaultQueueMaxMemory the maximum size, in bytes, of the reports kept in memory by the server
+const defaultQueueMaxMemory = 256 << 20
+
+// defaultSpoolMaxSize the maximum size, in bytes, of the reports spilled to disk by the server
+const defaultSpoolMaxSize = 1 << 30
+
+// spoolExtension the extension of the reports spilled to disk
+const spoolExtension = ".report"
+
+// the storages of the queued reports
+const (
+	queueStorageMemory = "memory"
+	queueStorageDisk   = "disk"
+)
+
+var errQueueClosed = errors.New("the queue is closed")
+
+// errSpoolFull the error of the reports refused once the spilled ones reach the maximum size of the spool, so a
+// sustained burst does not fill the disk of the server
+var errSpoolFull = errors.New("the spool of the queue is full")
+
+// queuedReport a report received by the server, with the metadata of the request
+type queuedReport struct {
+	metadata reportMetadata
+	data     []byte
+}
+
+// reportMetadata the metadata of a report, kept in the first line of the spilled files
+type reportMetadata struct {
+	Tenant     string            `json:"tenant,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	TraceID    string            `json:"trace_id,omitempty"`
+}
+
+// reportQueue a FIFO queue of the reports received by the server, bounded in memory by the number of reports and
+// their size: once it's full, the reports
+// are spilled to files in the spool directory, and all the new ones go to disk until it's drained, to keep the
+// order. The reports spilled by a previous process are recovered when the queue is created. Once the spilled
+// reports reach the maximum size of the spool, the new ones are refused, unless it's 0
+type reportQueue struct {
+	mu           sync.Mutex
+	limit        int
+	maxMemory    int64
+	memorySize   int64
+	spoolDir     string
+	maxSpoolSize int64
+	spoolSize    int64
+	memory       []*queuedReport
+	spooled      []string
+	sequence     int64
+	closed       bool
+	notify       chan struct{}
+	spills       metric.Int64Counter
+}
+
+// getSpoolDir the precedence order is: flag > a directory in the temporary directory
+func getSpoolDir() string {
+	if spoolDirFlag != "" {
+		return spoolDirFlag
+	}
+
+	return filepath.Join(os.TempDir(), Junit2otlp+"-spool")
+}
+
+func newReportQueue(limit int, maxMemory int64, spoolDir string, maxSpoolSize int64) (*reportQueue, error) {
+	if limit < 1 {
+		return nil, fmt.Errorf("invalid queue size %d: it must be greater than zero", limit)
+	}
+
+	if maxMemory < 1 {
+		return nil, fmt.Errorf("invalid queue memory %d: it must be greater than zero", maxMemory)
+	}
+
+	if maxSpoolSize < 0 {
+		return nil, fmt.Errorf("invalid spool size %d: it must not be negative", maxSpoolSize)
+	}
+
+	if err := os.MkdirAll(spoolDir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create the spool directory: %w", err)
+	}
+
+	entries, err := os.ReadDir(spoolDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the spool directory: %w", err)
+	}
+
+	q := &reportQueue{limit: limit, maxMemory: maxMemory, spoolDir: spoolDir, maxSpoolSize: maxSpoolSize, notify: make(chan struct{}, 1)}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !strings.HasSuffix(entry.Name(), spoolExtension) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the spool directory: %w", err)
+		}
+
+		q.spooled = append(q.spooled, filepath.Join(spoolDir, entry.Name()))
+		q.spoolSize += info.Size()
+	}
+
+	// the names of the files sort in the order they were spilled
+	slices.Sort(q.spooled)
+	if len(q.spooled) > 0 {
+		q.signal()
+	}
+
+	return q, nil
+}
+
+// instrument records the depth of the queue, by storage, and the reports spilled to disk
+func (q *reportQueue) instrument(meter metric.Meter) error {
+	spills, err := meter.Int64Counter(QueueSpilled, metric.WithDescription("Number of reports spilled to disk by the queue of the server"))
+	if err != nil {
+		return err
+	}
+	q.spills = spills
+
+	_, err = meter.Int64ObservableGauge(QueueDepth,
+		metric.WithDescription("Number of reports waiting to be exported in the queue of the server"),
+		metric.WithInt64Callback(func(ctx context.Context, o metric.Int64Observer) error {
+			memory, disk := q.depth()
+			o.Observe(int64(memory), metric.WithAttributes(attribute.Key(QueueStorage).String(queueStorageMemory)))
+			o.Observe(int64(disk), metric.WithAttributes(attribute.Key(QueueStorage).String(queueStorageDisk)))
+			return nil
+		}),
+	)
+
+	return err
+}
+
+// push adds the report to the queue, spilling it to disk if the memory is full
+func (q *reportQueue) push(ctx context.Context, report *queuedReport) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return errQueueClosed
+	}
+
+	if len(q.memory) < q.limit && q.memorySize+int64(len(report.data)) <= q.maxMemory && len(q.spooled) == 0 {
+		q.memory = append(q.memory, report)
+		q.memorySize += int64(len(report.data))
+		q.signal()
+		return nil
+	}
+
+	if q.maxSpoolSize > 0 && q.spoolSize+spilledSize(report) > q.maxSpoolSize {
+		return errSpoolFull
+	}
+
+	if err := q.spill(report); err != nil {
+		return err
+	}
+
+	if q.spills != nil {
+		q.spills.Add(ctx, 1)
+	}
+
+	q.signal()
+	return nil
+}
+
+// spilledSize returns the size of the file of the spilled report, with its metadata in the first line
+func spilledSize(report *queuedReport) int64 {
+	metadata, _ := json.Marshal(report.metadata)
+
+	return int64(len(metadata) + 1 + len(report.data))
+}
+
+// spill writes the report to a new file of the spool directory, named after the time and a sequence number,
+// with its metadata as JSON in the first line. The reports spilled when the queue is closed were already accepted,
+// so the maximum size of the spool is only checked by push
+func (q *reportQueue) spill(report *queuedReport) error {
+	q.sequence++
+	name := fmt.Sprintf("%020d-%010d%s", time.Now().UnixNano(), q.sequence, spoolExtension)
+	path := filepath.Join(q.spoolDir, name)
+
+	metadata, err := json.Marshal(report.metadata)
+	if err != nil {
+		return fmt.Errorf("failed to spill the report to disk: %w", err)
+	}
+
+	// written with a temporary name, so a partial file is never recovered
+	data := slices.Concat(metadata, []byte("\n"), report.data)
+	if err := os.WriteFile(path+".tmp", data, 0o600); err != nil {
+		return fmt.Errorf("failed to spill the report to disk: %w", err)
+	}
+
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to spill the report to disk: %w", err)
+	}
+
+	q.spooled = append(q.spooled, path)
+	q.spoolSize += int64(len(data))
+	return nil
+}
+
+// pop removes the oldest report from the queue, waiting until there is one, the context is done, or the queue
+// is closed
+func (q *reportQueue) pop(ctx context.Context) (*queuedReport, error) {
+	for {
+		report, err := q.tryPop()
+		if err != nil || report != nil {
+			return report, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-q.notify:
+		}
+	}
+}
+
+func (q *reportQueue) tryPop() (*queuedReport, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// the remaining reports are kept on disk for the next process
+	if q.closed {
+		return nil, errQueueClosed
+	}
+
+	if len(q.memory) > 0 {
+		report := q.memory[0]
+		q.memory[0] = nil
+		q.memory = q.memory[1:]
+		q.memorySize -= int64(len(report.data))
+		q.signalIfPending()
+		return report, nil
+	}
+
+	if len(q.spooled) > 0 {
+		path := q.spooled[0]
+		q.spooled = q.spooled[1:]
+		q.signalIfPending()
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the spilled report: %w", err)
+		}
+		q.spoolSize = max(q.spoolSize-int64(len(data)), 0)
+
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove the spilled report: %w", err)
+		}
+
+		report := &queuedReport{}
+		metadata, body, _ := bytes.Cut(data, []byte("\n"))
+		if err := json.Unmarshal(metadata, &report.metadata); err != nil {
+			return nil, fmt.Errorf("failed to read the metadata of the spilled report %s: %w", path, err)
+		}
+		report.data = body
+
+		return report, nil
+	}
+
+	return nil, nil
+}
+
+// close stops accepting reports, and spills the ones in memory to disk, so they are exported by the next process
+func (q *reportQueue) close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	q.signal()
+
+	errs := []error{}
+	for _, report := range q.memory {
+		errs = append(errs, q.spill(report))
+	}
+	q.memory = nil
+	q.memorySize = 0
+
+	return errors.Join(errs...)
+}
+
+// depth returns the number of reports in memory and on disk
+func (q *reportQueue) depth() (int, int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.memory), len(q.spooled)
+}
+
+// signal wakes up the consumer without blocking, as the channel keeps at most one pending notification
+func (q *reportQueue) signal() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (q *reportQueue) signalIfPending() {
+	if len(q.memory) > 0 || len(q.spooled) > 0 {
+		q.signal()
+	}
+}
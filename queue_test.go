@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestReportQueue(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Spill to disk in order", func(t *testing.T) {
+		dir := t.TempDir()
+		queue, err := newReportQueue(2, defaultQueueMaxMemory, dir, 0)
+		require.NoError(t, err)
+
+		reader := sdkmetric.NewManualReader()
+		require.NoError(t, queue.instrument(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")))
+
+		for _, report := range []string{"a", "b", "c", "d"} {
+			require.NoError(t, queue.push(ctx, &queuedReport{data: []byte(report)}))
+		}
+
+		memory, disk := queue.depth()
+		require.Equal(t, 2, memory)
+		require.Equal(t, 2, disk)
+
+		metrics := metricdata.ResourceMetrics{}
+		require.NoError(t, reader.Collect(ctx, &metrics))
+		values := map[string]int64{}
+		for _, m := range metrics.ScopeMetrics[0].Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				values[m.Name] = data.DataPoints[0].Value
+			case metricdata.Gauge[int64]:
+				for _, point := range data.DataPoints {
+					storage, _ := point.Attributes.Value(QueueStorage)
+					values[m.Name+"."+storage.AsString()] = point.Value
+				}
+			}
+		}
+		require.Equal(t, map[string]int64{QueueSpilled: 2, QueueDepth + ".memory": 2, QueueDepth + ".disk": 2}, values)
+
+		// once spilling, the new reports go to disk until it's drained
+		first, err := queue.pop(ctx)
+		require.NoError(t, err)
+		require.NoError(t, queue.push(ctx, &queuedReport{data: []byte("e")}))
+
+		popped := []string{string(first.data)}
+		for i := 0; i < 4; i++ {
+			report, err := queue.pop(ctx)
+			require.NoError(t, err)
+			popped = append(popped, string(report.data))
+		}
+		require.Equal(t, []string{"a", "b", "c", "d", "e"}, popped)
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+
+	t.Run("Close and recover", func(t *testing.T) {
+		dir := t.TempDir()
+		queue, err := newReportQueue(10, defaultQueueMaxMemory, dir, 0)
+		require.NoError(t, err)
+
+		require.NoError(t, queue.push(ctx, &queuedReport{data: []byte("a"), metadata: reportMetadata{Tenant: "checkout", Attributes: map[string]string{"team": "checkout"}}}))
+		require.NoError(t, queue.push(ctx, &queuedReport{data: []byte("b\nc")}))
+		require.NoError(t, queue.close())
+
+		_, err = queue.pop(ctx)
+		require.ErrorIs(t, err, errQueueClosed)
+		require.ErrorIs(t, queue.push(ctx, &queuedReport{data: []byte("c")}), errQueueClosed)
+
+		recovered, err := newReportQueue(10, defaultQueueMaxMemory, dir, 0)
+		require.NoError(t, err)
+
+		memory, disk := recovered.depth()
+		require.Equal(t, 0, memory)
+		require.Equal(t, 2, disk)
+
+		// the metadata is kept with the spilled reports
+		report, err := recovered.pop(ctx)
+		require.NoError(t, err)
+		require.Equal(t, &queuedReport{data: []byte("a"), metadata: reportMetadata{Tenant: "checkout", Attributes: map[string]string{"team": "checkout"}}}, report)
+
+		report, err = recovered.pop(ctx)
+		require.NoError(t, err)
+		require.Equal(t, &queuedReport{data: []byte("b\nc")}, report)
+	})
+
+	t.Run("Waiting for reports", func(t *testing.T) {
+		queue, err := newReportQueue(1, defaultQueueMaxMemory, t.TempDir(), 0)
+		require.NoError(t, err)
+
+		timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err = queue.pop(timeout)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			_ = queue.push(ctx, &queuedReport{data: []byte("a")})
+		}()
+
+		report, err := queue.pop(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "a", string(report.data))
+	})
+
+	t.Run("Spool full", func(t *testing.T) {
+		dir := t.TempDir()
+		// the size of the files of two reports of 3 bytes, with their empty metadata
+		queue, err := newReportQueue(1, defaultQueueMaxMemory, dir, 12)
+		require.NoError(t, err)
+
+		for _, report := range []string{"abc", "def", "ghi"} {
+			require.NoError(t, queue.push(ctx, &queuedReport{data: []byte(report)}))
+		}
+		require.ErrorIs(t, queue.push(ctx, &queuedReport{data: []byte("jkl")}), errSpoolFull)
+
+		// the spool is recovered with its size, with the report in memory spilled when the queue was closed
+		require.NoError(t, queue.close())
+		recovered, err := newReportQueue(1, defaultQueueMaxMemory, dir, 24)
+		require.NoError(t, err)
+		require.NoError(t, recovered.push(ctx, &queuedReport{data: []byte("jkl")}))
+		require.ErrorIs(t, recovered.push(ctx, &queuedReport{data: []byte("mno")}), errSpoolFull)
+
+		// the popped reports free the spool
+		report, err := recovered.pop(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "def", string(report.data))
+		require.NoError(t, recovered.push(ctx, &queuedReport{data: []byte("mno")}))
+
+		_, disk := recovered.depth()
+		require.Equal(t, 4, disk)
+	})
+
+	t.Run("Memory full", func(t *testing.T) {
+		// the memory keeps the first two reports of 3 bytes, the third one is spilled
+		queue, err := newReportQueue(10, 6, t.TempDir(), 0)
+		require.NoError(t, err)
+
+		for _, report := range []string{"abc", "def", "ghi"} {
+			require.NoError(t, queue.push(ctx, &queuedReport{data: []byte(report)}))
+		}
+
+		memory, disk := queue.depth()
+		require.Equal(t, 2, memory)
+		require.Equal(t, 1, disk)
+
+		// the popped reports free the memory, once the spilled ones are drained
+		for _, expected := range []string{"abc", "def", "ghi"} {
+			report, err := queue.pop(ctx)
+			require.NoError(t, err)
+			require.Equal(t, expected, string(report.data))
+		}
+		require.NoError(t, queue.push(ctx, &queuedReport{data: []byte("jkl")}))
+
+		memory, disk = queue.depth()
+		require.Equal(t, 1, memory)
+		require.Zero(t, disk)
+	})
+
+	t.Run("Invalid size", func(t *testing.T) {
+		_, err := newReportQueue(0, defaultQueueMaxMemory, t.TempDir(), 0)
+		require.Error(t, err)
+
+		_, err = newReportQueue(1, 0, t.TempDir(), 0)
+		require.Error(t, err)
+
+		_, err = newReportQueue(1, defaultQueueMaxMemory, t.TempDir(), -1)
+		require.Error(t, err)
+	})
+}
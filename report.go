@@ -0,0 +1,386 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unique"
+
+	"github.com/joshdk/go-junit"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// junitReport represents a parsed jUnit report: the suites ingested by go-junit, plus the raw XML elements
+// for the top-level suites, in the same order, so that the XML attributes not exposed by go-junit can be read.
+// The raw root element holds the root elements of the document as children, and the devices where the suites ran
+// are kept in the same order too. Reports in other formats are converted to jUnit suites, keeping their format, and
+// the framework producing them
+type junitReport struct {
+	suites    []junit.Suite
+	rawSuites []*xmlElement
+	rawRoot   *xmlElement
+	devices   []*device
+	sources   []*reportSource
+	format    string
+	framework *testFramework
+	// attributes the attributes of the run, i.e. the ones of the build producing the report
+	attributes []attribute.KeyValue
+	// unfiltered the report before its suites and test cases were filtered, whose results are the ones of the run
+	unfiltered *junitReport
+}
+
+// results returns the report with the results of the run: the one before the filters, which only select the spans
+// to create, so the summary, the state, the assertions and the failures are the ones of all the test cases
+func (r *junitReport) results() *junitReport {
+	if r.unfiltered != nil {
+		return r.unfiltered
+	}
+
+	return r
+}
+
+// rawSuite returns the raw XML element for the suite at the given index, or an empty element if it's not present
+func (r *junitReport) rawSuite(index int) *xmlElement {
+	if index < len(r.rawSuites) {
+		return r.rawSuites[index]
+	}
+
+	return &xmlElement{Attrs: map[string]string{}}
+}
+
+// device returns the device where the suite at the given index ran, or nil if it's unknown
+func (r *junitReport) device(index int) *device {
+	if index < len(r.devices) {
+		return r.devices[index]
+	}
+
+	return nil
+}
+
+// source returns the provenance of the suite at the given index, or nil if it's unknown
+func (r *junitReport) source(index int) *reportSource {
+	if index < len(r.sources) {
+		return r.sources[index]
+	}
+
+	return nil
+}
+
+// xmlElement a generic XML element, keeping its attributes, its children and its text, i.e. the stack trace of
+// a failure, with the character data of the CDATA sections
+type xmlElement struct {
+	Name     string
+	Attrs    map[string]string
+	Children []*xmlElement
+	Text     string
+}
+
+// openXMLElement an element being parsed, with its character data, converted to its text once it ends, so the
+// elements of the largest reports don't keep a buffer each
+type openXMLElement struct {
+	element *xmlElement
+	text    []byte
+}
+
+// Attr returns the value of an attribute of the element, and if it's present
+func (e *xmlElement) Attr(name string) (string, bool) {
+	value, ok := e.Attrs[name]
+	return value, ok
+}
+
+// ChildrenNamed returns the children of the element with the given name
+func (e *xmlElement) ChildrenNamed(name string) []*xmlElement {
+	children := []*xmlElement{}
+	for _, child := range e.Children {
+		if child.Name == name {
+			children = append(children, child)
+		}
+	}
+
+	return children
+}
+
+// Properties returns the properties of the element, declared in its properties child element
+func (e *xmlElement) Properties() map[string]string {
+	properties := map[string]string{}
+	for _, props := range e.ChildrenNamed("properties") {
+		for _, prop := range props.ChildrenNamed("property") {
+			name, _ := prop.Attr("name")
+			value, _ := prop.Attr("value")
+			properties[name] = value
+		}
+	}
+
+	return properties
+}
+
+// ingestReport ingests the jUnit report with go-junit, and reads the raw XML elements for its suites
+func ingestReport(data []byte) (*junitReport, error) {
+	return ingestReportFrom("", data)
+}
+
+// ingestReportFrom ingests the jUnit report read from the given path, which is used to detect the device
+// where the suites ran, i.e. in the Firebase Test Lab result bundles. The report is sanitized first, and it's
+// decoded once into raw elements, which are converted to the suites of go-junit, so the syntax errors are
+// located in the report, and the large reports are not decoded twice
+func ingestReportFrom(path string, data []byte) (*junitReport, error) {
+	root, err := parseXMLElements(sanitizeXMLReport(data, droppedData))
+	if err != nil {
+		return nil, err
+	}
+
+	report := &junitReport{rawSuites: findRawSuites(root.Children), rawRoot: root}
+	report.suites = make([]junit.Suite, 0, len(report.rawSuites))
+	report.devices = make([]*device, 0, len(report.rawSuites))
+	for i, rawSuite := range report.rawSuites {
+		report.suites = append(report.suites, ingestSuite(rawSuite))
+		report.devices = append(report.devices, suiteDevice(path, report.rawSuite(i)))
+	}
+
+	markRetries(report)
+
+	return report, nil
+}
+
+// mergeReports merges the reports into one, keeping the order of their suites
+func mergeReports(reports []*junitReport) *junitReport {
+	merged := &junitReport{rawRoot: &xmlElement{Attrs: map[string]string{}}}
+	formats := []string{}
+	for _, report := range reports {
+		if report.format != "" && !slices.Contains(formats, report.format) {
+			formats = append(formats, report.format)
+		}
+
+		if merged.framework == nil {
+			merged.framework = report.framework
+		}
+		merged.attributes = append(merged.attributes, report.attributes...)
+
+		for i, suite := range report.suites {
+			merged.suites = append(merged.suites, suite)
+			merged.rawSuites = append(merged.rawSuites, report.rawSuite(i))
+			merged.devices = append(merged.devices, report.device(i))
+			merged.sources = append(merged.sources, report.source(i))
+		}
+
+		if report.rawRoot != nil {
+			merged.rawRoot.Children = append(merged.rawRoot.Children, report.rawRoot.Children...)
+		}
+	}
+
+	slices.Sort(formats)
+	merged.format = strings.Join(formats, ",")
+
+	return merged
+}
+
+// findRawSuites finds the top-level testsuite elements, following the same algorithm as go-junit
+func findRawSuites(elements []*xmlElement) []*xmlElement {
+	suites := []*xmlElement{}
+	for _, element := range elements {
+		if element.Name == "testsuite" {
+			suites = append(suites, element)
+			continue
+		}
+
+		suites = append(suites, findRawSuites(element.Children)...)
+	}
+
+	return suites
+}
+
+// ingestSuite converts the raw element of a suite to a suite of go-junit, as go-junit ingests it: its properties
+// are the attributes of the suite, unless it has a properties element, and its totals are aggregated from its
+// test cases and nested suites
+func ingestSuite(element *xmlElement) junit.Suite {
+	suite := junit.Suite{
+		Name:       element.Attrs["name"],
+		Package:    element.Attrs["package"],
+		Properties: sharedAttrs(element.Attrs),
+	}
+
+	// the test cases are allocated at once, as the suites of the largest reports have thousands of them
+	if tests := countChildrenNamed(element, "testcase"); tests > 0 {
+		suite.Tests = make([]junit.Test, 0, tests)
+	}
+
+	for _, child := range element.Children {
+		switch child.Name {
+		case "testsuite":
+			suite.Suites = append(suite.Suites, ingestSuite(child))
+		case "testcase":
+			suite.Tests = append(suite.Tests, ingestTestCase(child))
+		case "properties":
+			suite.Properties = make(map[string]string, len(child.Children))
+			for _, prop := range child.ChildrenNamed("property") {
+				suite.Properties[prop.Attrs["name"]] = prop.Attrs["value"]
+			}
+		case "system-out":
+			suite.SystemOut = child.Text
+		case "system-err":
+			suite.SystemErr = child.Text
+		}
+	}
+
+	suite.Aggregate()
+	return suite
+}
+
+// countChildrenNamed returns the number of children of the element with the given name
+func countChildrenNamed(element *xmlElement, name string) int {
+	count := 0
+	for _, child := range element.Children {
+		if child.Name == name {
+			count++
+		}
+	}
+
+	return count
+}
+
+// ingestTestCase converts the raw element of a test case to a test of go-junit, as go-junit ingests it: its
+// properties are its attributes, and its status is the one of its last skipped, failure or error element
+func ingestTestCase(element *xmlElement) junit.Test {
+	duration, valid := testCaseDuration(element.Attrs["time"])
+	if !valid && element.Attrs["time"] != "" {
+		droppedData.drop(droppedDurations, 1, fmt.Sprintf("time %q of the test case %q", element.Attrs["time"], element.Attrs["name"]))
+	}
+
+	test := junit.Test{
+		Name:       element.Attrs["name"],
+		Classname:  element.Attrs["classname"],
+		Duration:   duration,
+		Status:     junit.StatusPassed,
+		Properties: sharedAttrs(element.Attrs),
+	}
+
+	for _, child := range element.Children {
+		switch child.Name {
+		case "skipped":
+			test.Status = junit.StatusSkipped
+			test.Message = child.Attrs["message"]
+		case "failure", "error":
+			test.Status = junit.StatusFailed
+			if child.Name == "error" {
+				test.Status = junit.StatusError
+			}
+			test.Message = child.Attrs["message"]
+			test.Error = junit.Error{Body: child.Text, Type: child.Attrs["type"], Message: child.Attrs["message"]}
+		case "system-out":
+			test.SystemOut = child.Text
+		case "system-err":
+			test.SystemErr = child.Text
+		}
+	}
+
+	return test
+}
+
+// testCaseDuration parses the time of a test case as go-junit does: in seconds, truncated to microseconds,
+// or as a Go duration. It returns if it's valid, or zero if it's not
+func testCaseDuration(value string) (time.Duration, bool) {
+	value = strings.ReplaceAll(value, ",", "")
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(seconds*1000000) * time.Microsecond, true
+	}
+
+	if d, err := time.ParseDuration(value); err == nil {
+		return d, true
+	}
+
+	return 0, false
+}
+
+// sharedAttrs returns the attributes of an element as the properties of a suite or a test case, or nil if it has
+// no attributes, as go-junit does. The map is shared with the raw element instead of copied, as the largest reports
+// have millions of test cases, so the properties must be copied before modifying them, i.e. with their retries
+func sharedAttrs(attrs map[string]string) map[string]string {
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	return attrs
+}
+
+// maxXMLDepth the maximum nesting of the elements of the reports, far above the one of the nested suites of any
+// test framework, as the trees of elements are walked recursively, and the server accepts untrusted reports
+const maxXMLDepth = 128
+
+// xmlEntityRegex matches the declarations of entities in the document type definition of a document
+var xmlEntityRegex = regexp.MustCompile(`<!ENTITY\s+(%\s+)?([^\s>]+)`)
+
+// parseXMLElements parses the XML document into a tree of generic elements, under a fake root element
+// so that documents with multiple root elements are supported, as go-junit does. The errors are located
+// at the line and the column of the document where they were found.
+// The reports can be untrusted, i.e. in the server mode, so the documents nesting their elements deeper than
+// maxXMLDepth, or declaring entities, are refused. The decoder never resolves the external entities, nor expands
+// any entity but the predefined ones of XML, but the declarations are refused with an explicit error
+func parseXMLElements(data []byte) (*xmlElement, error) {
+	root := &xmlElement{Attrs: map[string]string{}}
+	stack := []openXMLElement{{element: root}}
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err == nil {
+			err = checkXMLToken(token, len(stack))
+		}
+		if err != nil {
+			// the position of the decoder is located from its offset, as its line is not restored when it
+			// unreads a newline, i.e. after an ampersand ending a line
+			line, column := positionAt(data, decoder.InputOffset())
+			return nil, &reportParseError{format: "jUnit", line: line, column: column, err: err}
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			// the names are interned, as they are repeated in every test case of the largest reports
+			element := &xmlElement{Name: unique.Make(t.Name.Local).Value(), Attrs: make(map[string]string, len(t.Attr))}
+			for _, attr := range t.Attr {
+				element.Attrs[unique.Make(attr.Name.Local).Value()] = attr.Value
+			}
+
+			parent := stack[len(stack)-1].element
+			parent.Children = append(parent.Children, element)
+			stack = append(stack, openXMLElement{element: element})
+		case xml.CharData:
+			current := &stack[len(stack)-1]
+			current.text = append(current.text, t...)
+		case xml.EndElement:
+			// the decoder rejects the unbalanced end elements, but the fake root element is never closed
+			if len(stack) > 1 {
+				current := stack[len(stack)-1]
+				current.element.Text = string(current.text)
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+
+	return root, nil
+}
+
+// checkXMLToken checks the token of the document is within the limits of the untrusted reports, with the level of
+// nesting of the token, starting at 1 for the root elements of the document
+func checkXMLToken(token xml.Token, depth int) error {
+	switch t := token.(type) {
+	case xml.StartElement:
+		if depth > maxXMLDepth {
+			return fmt.Errorf("the elements are nested deeper than %d levels", maxXMLDepth)
+		}
+	case xml.Directive:
+		if matches := xmlEntityRegex.FindSubmatch(t); matches != nil {
+			return fmt.Errorf("the entity %s is declared, but the entities of the reports are not supported", matches[2])
+		}
+	}
+
+	return nil
+}
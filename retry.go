@@ -0,0 +1,133 @@
+package main
+
+import (
+	"maps"
+	"strconv"
+
+	"github.com/joshdk/go-junit"
+)
+
+// passedOnRetryStatus the status of the test cases that passed after failing in previous attempts, so they are
+// told apart from the clean passes, as the tests retried to green are a key indicator of the flakiness
+const passedOnRetryStatus = "passed_on_retry"
+
+// the elements of the Surefire test cases for the failed attempts of the test: the flaky ones for the tests that
+// passed on a rerun, and the rerun ones for the tests that failed in all the reruns
+var (
+	flakyElements = []string{"flakyFailure", "flakyError"}
+	rerunElements = []string{"rerunFailure", "rerunError"}
+)
+
+// markRetries records the retries of the test cases of the report in their properties, with the flaky ones, that
+// passed on a retry: the reruns of Surefire, and the test cases repeated in a suite, i.e. by the test-retry plugin
+// of Gradle, whose last attempt is the final outcome
+func markRetries(report *junitReport) {
+	for i := range report.suites {
+		markSuiteRetries(&report.suites[i], report.rawSuite(i))
+	}
+}
+
+// markSuiteRetries records the retries of the test cases of the suite and its nested suites. The raw XML element
+// can be nil, i.e. for the suites merged from other formats
+func markSuiteRetries(suite *junit.Suite, rawSuite *xmlElement) {
+	var rawTests, rawNested []*xmlElement
+	if rawSuite != nil {
+		rawTests = rawSuite.ChildrenNamed("testcase")
+		rawNested = rawSuite.ChildrenNamed("testsuite")
+	}
+
+	// go-junit keeps the test cases in the order of the elements
+	if len(rawTests) == len(suite.Tests) {
+		for i, rawTest := range rawTests {
+			flaky := countChildren(rawTest, flakyElements)
+			reruns := countChildren(rawTest, rerunElements)
+			if flaky+reruns > 0 {
+				setRetries(&suite.Tests[i], flaky+reruns, flaky > 0 && suite.Tests[i].Status == junit.StatusPassed)
+			}
+		}
+	}
+
+	attempts := map[string][]int{}
+	for i, test := range suite.Tests {
+		selector := newImpactedTest(*suite, test).Selector
+		attempts[selector] = append(attempts[selector], i)
+	}
+	for _, indexes := range attempts {
+		if len(indexes) < 2 {
+			continue
+		}
+
+		failedBefore := false
+		for _, index := range indexes[:len(indexes)-1] {
+			failedBefore = failedBefore || isFailingStatus(string(suite.Tests[index].Status))
+		}
+
+		last := &suite.Tests[indexes[len(indexes)-1]]
+		setRetries(last, len(indexes)-1, failedBefore && last.Status == junit.StatusPassed)
+	}
+
+	for i := range suite.Suites {
+		var rawChild *xmlElement
+		if len(rawNested) == len(suite.Suites) {
+			rawChild = rawNested[i]
+		}
+		markSuiteRetries(&suite.Suites[i], rawChild)
+	}
+}
+
+// setRetries sets the number of retries of the test, unless the format already declared them, and marks it as
+// flaky if it passed on a retry
+func setRetries(test *junit.Test, retries int, passedOnRetry bool) {
+	// the properties of the ingested test cases are shared with the attributes of their raw elements
+	test.Properties = maps.Clone(test.Properties)
+	if test.Properties == nil {
+		test.Properties = map[string]string{}
+	}
+
+	if _, ok := test.Properties[TestRetries]; !ok {
+		test.Properties[TestRetries] = strconv.Itoa(retries)
+	}
+
+	if passedOnRetry {
+		test.Properties[TestFlaky] = "true"
+	}
+}
+
+func countChildren(element *xmlElement, names []string) int {
+	count := 0
+	for _, name := range names {
+		count += len(element.ChildrenNamed(name))
+	}
+
+	return count
+}
+
+// isPassedOnRetry checks if the test passed after failing in previous attempts
+func isPassedOnRetry(test junit.Test) bool {
+	return test.Status == junit.StatusPassed && test.Properties[TestFlaky] == "true"
+}
+
+// testStatus returns the status of the test, distinguishing the tests that passed on a retry from the clean passes
+func testStatus(test junit.Test) string {
+	if isPassedOnRetry(test) {
+		return passedOnRetryStatus
+	}
+
+	return string(test.Status)
+}
+
+// countPassedOnRetry returns the number of tests of the suite, including its nested suites, that passed on a retry
+func countPassedOnRetry(suite junit.Suite) int {
+	count := 0
+	for _, test := range suite.Tests {
+		if isPassedOnRetry(test) {
+			count++
+		}
+	}
+
+	for _, nested := range suite.Suites {
+		count += countPassedOnRetry(nested)
+	}
+
+	return count
+}
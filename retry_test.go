@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestMarkRetries(t *testing.T) {
+	t.Run("Surefire reruns", func(t *testing.T) {
+		report, err := ingestReport([]byte(`<testsuite name="checkout" tests="3">
+  <testcase name="testLogin" classname="com.acme.LoginTest">
+    <flakyFailure message="timeout" type="java.lang.AssertionError"/>
+    <flakyError message="connection refused" type="java.io.IOException"/>
+  </testcase>
+  <testcase name="testLogout" classname="com.acme.LoginTest">
+    <failure message="expected true" type="java.lang.AssertionError"/>
+    <rerunFailure message="expected true" type="java.lang.AssertionError"/>
+  </testcase>
+  <testcase name="testSignup" classname="com.acme.LoginTest"/>
+</testsuite>`))
+		require.NoError(t, err)
+
+		tests := report.suites[0].Tests
+		require.Equal(t, passedOnRetryStatus, testStatus(tests[0]))
+		require.Equal(t, "2", tests[0].Properties[TestRetries])
+
+		require.Equal(t, "failed", testStatus(tests[1]))
+		require.Equal(t, "1", tests[1].Properties[TestRetries])
+		require.Empty(t, tests[1].Properties[TestFlaky])
+
+		require.Equal(t, "passed", testStatus(tests[2]))
+		require.NotContains(t, tests[2].Properties, TestRetries)
+
+		summary := summarize(report)
+		require.Equal(t, 2, summary.Passed)
+		require.Equal(t, 1, summary.PassedOnRetry)
+	})
+
+	t.Run("Repeated test cases", func(t *testing.T) {
+		report, err := ingestReport([]byte(`<testsuite name="checkout">
+  <testcase name="testLogin" classname="com.acme.LoginTest"><failure message="timeout"/></testcase>
+  <testcase name="testLogout" classname="com.acme.LoginTest"/>
+  <testcase name="testLogin" classname="com.acme.LoginTest"/>
+  <testsuite name="nested">
+    <testcase name="testSignup" classname="com.acme.SignupTest"/>
+    <testcase name="testSignup" classname="com.acme.SignupTest"/>
+  </testsuite>
+</testsuite>`))
+		require.NoError(t, err)
+
+		tests := report.suites[0].Tests
+		require.Equal(t, "failed", testStatus(tests[0]))
+		require.Equal(t, "passed", testStatus(tests[1]))
+		require.Equal(t, passedOnRetryStatus, testStatus(tests[2]))
+		require.Equal(t, "1", tests[2].Properties[TestRetries])
+
+		// a test passing in all the attempts is retried, but not flaky
+		nested := report.suites[0].Suites[0].Tests
+		require.Equal(t, "passed", testStatus(nested[1]))
+		require.Equal(t, "1", nested[1].Properties[TestRetries])
+
+		require.Equal(t, 1, countPassedOnRetry(report.suites[0]))
+	})
+}
+
+func TestPassedOnRetryStatus(t *testing.T) {
+	test := junit.Test{Name: "testLogin", Status: junit.StatusPassed, Properties: map[string]string{TestFlaky: "true"}}
+
+	status, _ := applyFailureRules(nil, test)
+	require.Equal(t, passedOnRetryStatus, status)
+
+	spanStatus, result := statusMapping{}.resolve(status)
+	require.Equal(t, codes.Unset, spanStatus)
+	require.Equal(t, "pass", result)
+
+	// a test that is not passed is never passed on a retry
+	test.Status = junit.StatusFailed
+	require.Equal(t, "failed", testStatus(test))
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// the columns of the rows of the test cases, besides the flattened attributes
+const (
+	rowTimestamp    = "timestamp"
+	rowTraceID      = "trace_id"
+	rowSpanID       = "span_id"
+	rowParentSpanID = "parent_span_id"
+	rowName         = "name"
+	rowDurationMs   = "duration_ms"
+)
+
+// rowWriter writes the rows of the test cases to an analytical backend or a file
+type rowWriter interface {
+	writeRows(ctx context.Context, rows []map[string]any) error
+	close(ctx context.Context) error
+}
+
+// rowExporter exports the spans of the test cases as flattened rows, one per test case, so that the tables
+// contain the same attributes as the traces: the ones of the span, including the suite and SCM attributes,
+// and the ones of the resource, such as the CI ones
+type rowExporter struct {
+	writer rowWriter
+}
+
+// ExportSpans writes the rows of the test cases, ignoring the spans of the run and the suites
+func (e *rowExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	rows := []map[string]any{}
+	for _, span := range spans {
+		if isTestCaseSpan(span) {
+			rows = append(rows, testCaseRow(span))
+		}
+	}
+
+	if len(rows) == 0 {
+		return nil
+	}
+
+	return e.writer.writeRows(ctx, rows)
+}
+
+// Shutdown closes the writer, i.e. flushing the rows to a file
+func (e *rowExporter) Shutdown(ctx context.Context) error {
+	return e.writer.close(ctx)
+}
+
+// isTestCaseSpan checks if the span represents a test case, which are the only ones with a status
+func isTestCaseSpan(span sdktrace.ReadOnlySpan) bool {
+	for _, attr := range span.Attributes() {
+		if attr.Key == TestStatus {
+			return true
+		}
+	}
+
+	return false
+}
+
+// testCaseRow the row of a test case: its trace identifiers and timing, and its flattened attributes
+func testCaseRow(span sdktrace.ReadOnlySpan) map[string]any {
+	row := flattenAttributes(span)
+
+	row[rowTimestamp] = span.StartTime().UTC().Format(time.RFC3339Nano)
+	row[rowTraceID] = span.SpanContext().TraceID().String()
+	row[rowSpanID] = span.SpanContext().SpanID().String()
+	row[rowName] = span.Name()
+	// the spans of the reports are created once the tests ran, so their duration is the one of the test case
+	row[rowDurationMs] = span.EndTime().Sub(span.StartTime()).Milliseconds()
+	if duration, ok := row[flattenKey(TestDuration)].(int64); ok {
+		row[rowDurationMs] = duration
+	}
+	if span.Parent().IsValid() {
+		row[rowParentSpanID] = span.Parent().SpanID().String()
+	}
+
+	return row
+}
+
+// flattenAttributes returns the attributes of the resource and the span, the latter taking precedence, with the
+// dots of their keys replaced by underscores, as most backends would otherwise expand them into nested fields,
+// making i.e. scm.git.commits and scm.git.commits.merge conflict, or do not allow them in column names
+func flattenAttributes(span sdktrace.ReadOnlySpan) map[string]any {
+	flattened := map[string]any{}
+	add := func(attrs []attribute.KeyValue) {
+		for _, attr := range attrs {
+			flattened[flattenKey(attr.Key)] = attr.Value.AsInterface()
+		}
+	}
+
+	if res := span.Resource(); res != nil {
+		add(res.Attributes())
+	}
+	add(span.Attributes())
+
+	return flattened
+}
+
+// flattenKey returns the name of the column of the attribute, i.e. tests_case_status
+func flattenKey(key attribute.Key) string {
+	return strings.ReplaceAll(string(key), ".", "_")
+}
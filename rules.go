@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+
+	"github.com/joshdk/go-junit"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// failureRule a user-defined rule matching the failure messages of the tests, to categorize the failures
+// (i.e. infrastructure, timeout or assertion) and optionally report them with a distinct status
+type failureRule struct {
+	Pattern    string            `yaml:"pattern"`
+	Category   string            `yaml:"category"`
+	Status     string            `yaml:"status"`
+	Attributes map[string]string `yaml:"attributes"`
+
+	regex *regexp.Regexp
+}
+
+func (r *failureRule) compile() error {
+	if r.Pattern == "" {
+		return fmt.Errorf("the pattern is mandatory")
+	}
+
+	regex, err := regexp.Compile(r.Pattern)
+	if err != nil {
+		return err
+	}
+
+	r.regex = regex
+	return nil
+}
+
+// matches checks the rule against the message, the error and the standard error of the failed test
+func (r *failureRule) matches(test junit.Test) bool {
+	if test.Status != junit.StatusFailed && test.Status != junit.StatusError {
+		return false
+	}
+
+	if r.regex.MatchString(test.Message) || r.regex.MatchString(test.SystemErr) {
+		return true
+	}
+
+	return test.Error != nil && r.regex.MatchString(test.Error.Error())
+}
+
+// applyFailureRules returns the status of the test and the attributes of the first rule matching it,
+// so the rules are evaluated in the order of the configuration file
+func applyFailureRules(rules []failureRule, test junit.Test) (string, []attribute.KeyValue) {
+	for _, rule := range rules {
+		if !rule.matches(test) {
+			continue
+		}
+
+		status := testStatus(test)
+		if rule.Status != "" {
+			status = rule.Status
+		}
+
+		attributes := []attribute.KeyValue{}
+		if rule.Category != "" {
+			attributes = append(attributes, attribute.Key(FailureCategory).String(rule.Category))
+		}
+		for _, k := range slices.Sorted(maps.Keys(rule.Attributes)) {
+			attributes = append(attributes, attribute.Key(k).String(rule.Attributes[k]))
+		}
+
+		return status, attributes
+	}
+
+	return testStatus(test), nil
+}
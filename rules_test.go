@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestApplyFailureRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "junit2otlp.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+failure_rules:
+  - pattern: "(?i)connection refused|no space left on device"
+    category: infrastructure
+    status: infrastructure
+    attributes:
+      team: platform
+  - pattern: "(?i)timed? ?out"
+    category: timeout
+  - pattern: "expected .* but was"
+    category: assertion
+`), 0o644))
+
+	cfg, err := loadConfig(path)
+	require.NoError(t, err)
+	require.Len(t, cfg.FailureRules, 3)
+
+	t.Run("Infrastructure failures are downgraded", func(t *testing.T) {
+		status, attrs := applyFailureRules(cfg.FailureRules, junit.Test{Status: junit.StatusError, Message: "dial tcp: connection refused"})
+		require.Equal(t, "infrastructure", status)
+		require.ElementsMatch(t, []attribute.KeyValue{
+			attribute.Key(FailureCategory).String("infrastructure"),
+			attribute.Key("team").String("platform"),
+		}, attrs)
+	})
+
+	t.Run("The error body is matched", func(t *testing.T) {
+		status, attrs := applyFailureRules(cfg.FailureRules, junit.Test{
+			Status: junit.StatusFailed,
+			Error:  junit.Error{Message: "test failed", Body: "context deadline exceeded: timed out"},
+		})
+		require.Equal(t, string(junit.StatusFailed), status)
+		require.Equal(t, []attribute.KeyValue{attribute.Key(FailureCategory).String("timeout")}, attrs)
+	})
+
+	t.Run("Passed tests are not matched", func(t *testing.T) {
+		status, attrs := applyFailureRules(cfg.FailureRules, junit.Test{Status: junit.StatusPassed, SystemOut: "connection refused"})
+		require.Equal(t, string(junit.StatusPassed), status)
+		require.Empty(t, attrs)
+	})
+
+	t.Run("Unmatched failures", func(t *testing.T) {
+		status, attrs := applyFailureRules(cfg.FailureRules, junit.Test{Status: junit.StatusFailed, Message: "panic"})
+		require.Equal(t, string(junit.StatusFailed), status)
+		require.Empty(t, attrs)
+	})
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Run("Empty path", func(t *testing.T) {
+		cfg, err := loadConfig("")
+		require.NoError(t, err)
+		require.Empty(t, cfg.FailureRules)
+	})
+
+	t.Run("Invalid pattern", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "junit2otlp.yml")
+		require.NoError(t, os.WriteFile(path, []byte("failure_rules:\n  - pattern: \"(\"\n"), 0o644))
+
+		_, err := loadConfig(path)
+		require.ErrorContains(t, err, "invalid failure rule 0")
+	})
+
+	t.Run("Unknown fields", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "junit2otlp.yml")
+		require.NoError(t, os.WriteFile(path, []byte("failure_rule: []\n"), 0o644))
+
+		_, err := loadConfig(path)
+		require.Error(t, err)
+	})
+}
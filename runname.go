@@ -0,0 +1,143 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// traceNameAuto names the root span of the run from the context of the CI system, i.e. "acme/shop@main #42"
+const traceNameAuto = "auto"
+
+// ciRun the context of the run of the tests in the CI system, read from the environment variables of its builds
+type ciRun struct {
+	Repository string
+	Branch     string
+	RunNumber  string
+	Pipeline   string
+	URL        string
+}
+
+// detectCIRun returns the context of the run in the CI system, or nil if it's not run by a supported one
+func detectCIRun() *ciRun {
+	switch {
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		run := &ciRun{
+			Repository: os.Getenv("GITHUB_REPOSITORY"),
+			Branch:     firstEnv("GITHUB_HEAD_REF", "GITHUB_REF_NAME"), // the head ref is only present for pull requests
+			RunNumber:  os.Getenv("GITHUB_RUN_NUMBER"),
+			Pipeline:   os.Getenv("GITHUB_WORKFLOW"),
+		}
+		if server, id := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_RUN_ID"); server != "" && id != "" {
+			run.URL = strings.TrimSuffix(server, "/") + "/" + run.Repository + "/actions/runs/" + id
+		}
+		return run
+	case os.Getenv("GITLAB_CI") == "true":
+		return &ciRun{
+			Repository: os.Getenv("CI_PROJECT_PATH"),
+			Branch:     os.Getenv("CI_COMMIT_REF_NAME"),
+			RunNumber:  os.Getenv("CI_PIPELINE_IID"),
+			Pipeline:   firstEnv("CI_PIPELINE_NAME", "CI_JOB_NAME"),
+			URL:        os.Getenv("CI_PIPELINE_URL"),
+		}
+	case os.Getenv("BUILDKITE") == "true":
+		return &ciRun{
+			Repository: repositoryName(os.Getenv("BUILDKITE_REPO")),
+			Branch:     os.Getenv("BUILDKITE_BRANCH"),
+			RunNumber:  os.Getenv("BUILDKITE_BUILD_NUMBER"),
+			Pipeline:   os.Getenv("BUILDKITE_PIPELINE_SLUG"),
+			URL:        os.Getenv("BUILDKITE_BUILD_URL"),
+		}
+	case os.Getenv("JENKINS_URL") != "":
+		return &ciRun{
+			Repository: repositoryName(os.Getenv("GIT_URL")),
+			Branch:     firstEnv("CHANGE_BRANCH", "BRANCH_NAME", "GIT_BRANCH"),
+			RunNumber:  os.Getenv("BUILD_NUMBER"),
+			Pipeline:   os.Getenv("JOB_NAME"),
+			URL:        os.Getenv("BUILD_URL"),
+		}
+	case os.Getenv("TEAMCITY_VERSION") != "":
+		return &ciRun{
+			RunNumber: os.Getenv("BUILD_NUMBER"),
+			Pipeline:  os.Getenv("TEAMCITY_BUILDCONF_NAME"),
+		}
+	default:
+		return nil
+	}
+}
+
+// repositoryName returns the owner and the name of the repository of a Git URL, i.e. acme/shop for
+// git@github.com:acme/shop.git or https://github.com/acme/shop
+func repositoryName(gitURL string) string {
+	name := strings.TrimSuffix(strings.TrimSuffix(gitURL, "/"), ".git")
+	if i := strings.LastIndexAny(name, ":/"); i >= 0 {
+		owner := name[:i]
+		name = name[i+1:]
+		if j := strings.LastIndexAny(owner, ":/"); j >= 0 && !strings.HasSuffix(owner[:j+1], "//") {
+			name = owner[j+1:] + "/" + name
+		}
+	}
+
+	return name
+}
+
+// runName returns the name of the root span of the run: the name given by the flag, the template of the flag with
+// the {repository}, {branch}, {run_number}, {pipeline} and {service} placeholders of the run, or, when it's auto, the
+// repository@branch #run_number name of the run in the CI system, falling back to the name of the tool outside them
+func runName(nameFlag string, srvName string, run *ciRun) string {
+	if run == nil {
+		run = &ciRun{}
+	}
+
+	if nameFlag == traceNameAuto {
+		if run.Repository == "" && run.Pipeline == "" {
+			return Junit2otlp
+		}
+
+		name := run.Repository
+		if name == "" {
+			name = run.Pipeline
+		}
+		if run.Branch != "" {
+			name += "@" + run.Branch
+		}
+		if run.RunNumber != "" {
+			name += " #" + run.RunNumber
+		}
+		return name
+	}
+
+	if !strings.Contains(nameFlag, "{") {
+		return nameFlag
+	}
+
+	return strings.NewReplacer(
+		"{repository}", run.Repository,
+		"{branch}", run.Branch,
+		"{run_number}", run.RunNumber,
+		"{pipeline}", run.Pipeline,
+		"{service}", srvName,
+	).Replace(nameFlag)
+}
+
+// runNameAttributes returns the display attributes of the root span of the run: its name, and the pipeline, the
+// number and the URL of the run in the CI system, so the runs are told apart at a glance in the trace search
+func runNameAttributes(name string, run *ciRun) []attribute.KeyValue {
+	attributes := []attribute.KeyValue{attribute.Key(TestsRunName).String(name)}
+	if run == nil {
+		return attributes
+	}
+
+	if run.Pipeline != "" {
+		attributes = append(attributes, attribute.Key(CICDPipelineName).String(run.Pipeline))
+	}
+	if run.RunNumber != "" {
+		attributes = append(attributes, attribute.Key(CICDPipelineRunID).String(run.RunNumber))
+	}
+	if run.URL != "" {
+		attributes = append(attributes, attribute.Key(CICDPipelineRunURL).String(run.URL))
+	}
+
+	return attributes
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestDetectCIRun(t *testing.T) {
+	for _, env := range []string{"GITHUB_ACTIONS", "GITLAB_CI", "BUILDKITE", "JENKINS_URL", "TEAMCITY_VERSION"} {
+		t.Setenv(env, "")
+	}
+
+	t.Run("Github Actions", func(t *testing.T) {
+		t.Setenv("GITHUB_ACTIONS", "true")
+		t.Setenv("GITHUB_REPOSITORY", "acme/shop")
+		t.Setenv("GITHUB_HEAD_REF", "")
+		t.Setenv("GITHUB_REF_NAME", "main")
+		t.Setenv("GITHUB_RUN_NUMBER", "42")
+		t.Setenv("GITHUB_RUN_ID", "1234567")
+		t.Setenv("GITHUB_WORKFLOW", "CI")
+		t.Setenv("GITHUB_SERVER_URL", "https://github.com")
+
+		require.Equal(t, &ciRun{
+			Repository: "acme/shop",
+			Branch:     "main",
+			RunNumber:  "42",
+			Pipeline:   "CI",
+			URL:        "https://github.com/acme/shop/actions/runs/1234567",
+		}, detectCIRun())
+	})
+
+	t.Run("Jenkins", func(t *testing.T) {
+		t.Setenv("JENKINS_URL", "http://jenkins.local")
+		t.Setenv("GIT_URL", "git@github.com:acme/shop.git")
+		t.Setenv("CHANGE_BRANCH", "")
+		t.Setenv("BRANCH_NAME", "feature/cart")
+		t.Setenv("BUILD_NUMBER", "7")
+		t.Setenv("JOB_NAME", "shop/feature%2Fcart")
+		t.Setenv("BUILD_URL", "http://jenkins.local/job/shop/7/")
+
+		run := detectCIRun()
+		require.Equal(t, "acme/shop", run.Repository)
+		require.Equal(t, "feature/cart", run.Branch)
+		require.Equal(t, "7", run.RunNumber)
+	})
+
+	t.Run("Outside CI", func(t *testing.T) {
+		require.Nil(t, detectCIRun())
+	})
+}
+
+func TestRepositoryName(t *testing.T) {
+	for url, expected := range map[string]string{
+		"git@github.com:acme/shop.git":      "acme/shop",
+		"https://github.com/acme/shop":      "acme/shop",
+		"https://gitlab.com/acme/shop.git/": "acme/shop",
+		"https://git.example.com/shop":      "shop",
+		"":                                  "",
+	} {
+		require.Equal(t, expected, repositoryName(url), url)
+	}
+}
+
+func TestRunName(t *testing.T) {
+	run := &ciRun{Repository: "acme/shop", Branch: "main", RunNumber: "42", Pipeline: "CI"}
+
+	require.Equal(t, "acme/shop@main #42", runName(traceNameAuto, "checkout", run))
+	require.Equal(t, "CI #42", runName(traceNameAuto, "checkout", &ciRun{Pipeline: "CI", RunNumber: "42"}))
+	require.Equal(t, Junit2otlp, runName(traceNameAuto, "checkout", nil))
+	require.Equal(t, "nightly", runName("nightly", "checkout", run))
+	require.Equal(t, "checkout: CI 42 (main)", runName("{service}: {pipeline} {run_number} ({branch})", "checkout", run))
+	require.Equal(t, "checkout on ", runName("{service} on {branch}", "checkout", nil))
+}
+
+func TestRunNameAttributes(t *testing.T) {
+	require.Equal(t, []attribute.KeyValue{attribute.String(TestsRunName, Junit2otlp)}, runNameAttributes(Junit2otlp, nil))
+
+	run := &ciRun{Repository: "acme/shop", RunNumber: "42", Pipeline: "CI", URL: "https://ci.example.com/42"}
+	require.Equal(t, []attribute.KeyValue{
+		attribute.String(TestsRunName, "acme/shop #42"),
+		attribute.String(CICDPipelineName, "CI"),
+		attribute.String(CICDPipelineRunID, "42"),
+		attribute.String(CICDPipelineRunURL, "https://ci.example.com/42"),
+	}, runNameAttributes("acme/shop #42", run))
+}
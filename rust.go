@@ -0,0 +1,280 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/joshdk/go-junit"
+)
+
+const reportFormatLibtest = "libtest"
+
+const (
+	frameworkLibtest = "libtest"
+	frameworkNextest = "nextest"
+)
+
+// nextestRunName the name of the root element of the jUnit reports of cargo-nextest
+const nextestRunName = "nextest-run"
+
+// the kinds of the Rust test binaries, the lib one being the unit tests of the library of the crate
+const (
+	rustBinaryKindLib  = "lib"
+	rustBinaryKindTest = "test"
+)
+
+// rustPanicRegex matches the panic of a failed Rust test in its output, with its location and message, i.e.
+// "thread 'tests::add' panicked at src/lib.rs:10:5:\nassertion failed", or the older
+// "thread 'tests::add' panicked at 'assertion failed', src/lib.rs:10:5"
+var rustPanicRegex = regexp.MustCompile(`panicked at (?:'((?s:.*?))', )?([^\s:']+):(\d+):\d+:?(?:\n([^\n]+))?`)
+
+// libtestEvent an event of the JSON output of libtest, written by cargo test with --format json, one JSON object
+// per line, or by cargo-nextest with --message-format libtest-json, which adds the binary to the suite events
+type libtestEvent struct {
+	Type     string   `json:"type"`
+	Event    string   `json:"event"`
+	Name     string   `json:"name"`
+	ExecTime *float64 `json:"exec_time"`
+	Stdout   string   `json:"stdout"`
+	Message  string   `json:"message"`
+	Median   float64  `json:"median"`
+	Nextest  *struct {
+		Crate      string `json:"crate"`
+		TestBinary string `json:"test_binary"`
+		Kind       string `json:"kind"`
+	} `json:"nextest"`
+}
+
+// rustBinary the test binary of a Rust crate, identified by nextest as crate::kind/name, i.e. my-crate::bin/cli,
+// or my-crate::integration for the integration tests, and my-crate for the unit tests of the library
+type rustBinary struct {
+	crate string
+	kind  string
+	name  string
+}
+
+// parseRustBinaryID parses the ID of the test binary of nextest
+func parseRustBinaryID(id string) rustBinary {
+	crate, binary, found := strings.Cut(id, "::")
+	if !found {
+		return rustBinary{crate: crate, kind: rustBinaryKindLib, name: crate}
+	}
+
+	if kind, name, found := strings.Cut(binary, "/"); found {
+		return rustBinary{crate: crate, kind: kind, name: name}
+	}
+
+	return rustBinary{crate: crate, kind: rustBinaryKindTest, name: binary}
+}
+
+// id returns the ID of the binary, as nextest names it
+func (b rustBinary) id() string {
+	switch {
+	case b.kind == rustBinaryKindLib || b.kind == "proc-macro":
+		return b.crate
+	case b.kind == rustBinaryKindTest:
+		return b.crate + "::" + b.name
+	default:
+		return b.crate + "::" + b.kind + "/" + b.name
+	}
+}
+
+// properties returns the properties of the suite of the binary
+func (b rustBinary) properties() map[string]string {
+	return map[string]string{RustCrate: b.crate, RustBinary: b.name, RustBinaryKind: b.kind}
+}
+
+// isNextestReport checks if the jUnit report was written by cargo-nextest, from the name of its root element
+func isNextestReport(report *junitReport) bool {
+	if report.rawRoot == nil {
+		return false
+	}
+
+	for _, root := range report.rawRoot.Children {
+		if name, _ := root.Attr("name"); root.Name == "testsuites" && name == nextestRunName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// addNextestBinaries adds the crate and the binary of the suites of the jUnit reports of cargo-nextest, named
+// after the ID of their binary, and the location of the panics of their failed test cases
+func addNextestBinaries(report *junitReport) {
+	if !isNextestReport(report) {
+		return
+	}
+
+	for i := range report.suites {
+		suite := &report.suites[i]
+		suite.Properties = mergeProperties(suite.Properties, parseRustBinaryID(suite.Name).properties())
+
+		for j := range suite.Tests {
+			addRustPanic(&suite.Tests[j])
+		}
+	}
+}
+
+// addRustPanic adds the location of the panic of the failed test case, found in its failure or its output, to its
+// properties, and uses the message of the panic as the message of the test case if it has none
+func addRustPanic(test *junit.Test) {
+	if test.Status != junit.StatusFailed && test.Status != junit.StatusError {
+		return
+	}
+
+	output := test.SystemOut + "\n" + test.SystemErr
+	if junitErr, ok := test.Error.(junit.Error); ok {
+		output = junitErr.Body + "\n" + output
+	}
+
+	match := rustPanicRegex.FindStringSubmatch(output)
+	if match == nil {
+		return
+	}
+
+	test.Properties = mergeProperties(test.Properties, map[string]string{"file": match[2], "line": match[3]})
+
+	// the older panics quote their message before the location, and the newer ones write it on the next line
+	message := strings.TrimSpace(match[1])
+	if message == "" {
+		message = strings.TrimSpace(match[4])
+	}
+	if message != "" && (test.Message == "" || strings.HasPrefix(test.Message, "code=")) {
+		test.Message = message
+	}
+}
+
+// isLibtestStream checks if the first JSON object of the data is an event of the JSON output of libtest
+func isLibtestStream(data []byte) bool {
+	event := libtestEvent{}
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(&event); err != nil {
+		return false
+	}
+
+	return (event.Type == "suite" || event.Type == "test") && event.Event != ""
+}
+
+// parseLibtestEvents converts the JSON output of libtest to jUnit suites, one per test binary, with a test case for
+// each test and benchmark. cargo test doesn't name the binaries in its JSON output, so their suites are named after
+// the report, unless nextest wrote it with their crate and binary. The test cases repeated in a suite, i.e. by the
+// retries of nextest, are the attempts of the test
+func parseLibtestEvents(path string, data []byte) (*junitReport, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+
+	report := &junitReport{framework: &testFramework{Name: frameworkLibtest}}
+	var suite *junit.Suite
+	endSuite := func() {
+		if suite == nil {
+			return
+		}
+
+		suite.Aggregate()
+		markSuiteRetries(suite, nil)
+		report.suites = append(report.suites, *suite)
+		suite = nil
+	}
+	startSuite := func(event libtestEvent) {
+		endSuite()
+
+		suite = &junit.Suite{Name: libtestSuiteName(path, len(report.suites))}
+		if event.Nextest != nil {
+			report.framework.Name = frameworkNextest
+
+			binary := rustBinary{crate: event.Nextest.Crate, kind: event.Nextest.Kind, name: event.Nextest.TestBinary}
+			suite.Name = binary.id()
+			suite.Properties = binary.properties()
+		}
+	}
+
+	for {
+		event := libtestEvent{}
+		err := decoder.Decode(&event)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, jsonParseError("libtest", data, err)
+		}
+
+		switch {
+		case event.Type == "suite" && event.Event == "started":
+			startSuite(event)
+		case event.Type == "suite":
+			endSuite()
+		case event.Type == "test" || event.Type == "bench":
+			test, ok := libtestTest(event)
+			if !ok {
+				continue
+			}
+
+			// the events of the binaries without the start of their suite, i.e. in a truncated output
+			if suite == nil {
+				startSuite(libtestEvent{})
+			}
+
+			test.Classname = suite.Name
+			suite.Tests = append(suite.Tests, test)
+		}
+	}
+	endSuite()
+
+	return report, nil
+}
+
+// libtestTest returns the test case of the event, if it's the outcome of a test: the starts of the tests and the
+// warnings of the slow ones are not
+func libtestTest(event libtestEvent) (junit.Test, bool) {
+	// nextest prefixes the names of the tests with the ID of their binary, i.e. my-crate::bin/cli$tests::parse
+	_, name, found := strings.Cut(event.Name, "$")
+	if !found {
+		name = event.Name
+	}
+
+	test := junit.Test{Name: name, SystemOut: event.Stdout, Message: event.Message}
+	if event.ExecTime != nil {
+		test.Duration = time.Duration(*event.ExecTime * float64(time.Second))
+	}
+
+	if event.Type == "bench" {
+		test.Status = junit.StatusPassed
+		test.Duration = time.Duration(event.Median)
+		return test, true
+	}
+
+	switch event.Event {
+	case "ok":
+		test.Status = junit.StatusPassed
+	case "ignored":
+		test.Status = junit.StatusSkipped
+	case "failed":
+		test.Status = junit.StatusFailed
+		test.Error = junit.Error{Message: event.Message, Body: event.Stdout}
+		addRustPanic(&test)
+	default:
+		return junit.Test{}, false
+	}
+
+	return test, true
+}
+
+// libtestSuiteName returns the name of the suite of the binary at the index, after the file of the report
+func libtestSuiteName(path string, index int) string {
+	name := frameworkLibtest
+	if path != "" {
+		name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	if index == 0 {
+		return name
+	}
+
+	return fmt.Sprintf("%s-%d", name, index+1)
+}
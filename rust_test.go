@@ -0,0 +1,130 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextestReport(t *testing.T) {
+	data, err := os.ReadFile("testdata/nextest-junit.xml")
+	require.NoError(t, err)
+
+	report, err := parseReport(reportFormatAuto, "testdata/nextest-junit.xml", data)
+	require.NoError(t, err)
+	require.Equal(t, &testFramework{Name: frameworkNextest}, detectFramework(report))
+	require.Len(t, report.suites, 3)
+
+	// the suites are named after the ID of their binary
+	for i, binary := range []string{"shop", "shop-cli", "checkout"} {
+		require.Equal(t, "shop", report.suites[i].Properties[RustCrate])
+		require.Equal(t, binary, report.suites[i].Properties[RustBinary])
+		require.Equal(t, []string{"lib", "bin", "test"}[i], report.suites[i].Properties[RustBinaryKind])
+	}
+
+	// the panic locates the failure, and the reruns are the retries
+	remove := report.suites[0].Tests[1]
+	require.Equal(t, junit.StatusFailed, remove.Status)
+	require.Equal(t, "assertion `left == right` failed", remove.Message)
+	require.Equal(t, "src/cart.rs", remove.Properties["file"])
+	require.Equal(t, "42", remove.Properties["line"])
+	require.Equal(t, "1", remove.Properties[TestRetries])
+
+	parse := report.suites[1].Tests[0]
+	require.Equal(t, passedOnRetryStatus, testStatus(parse))
+	require.Equal(t, "1", parse.Properties[TestRetries])
+}
+
+func TestParseRustBinaryID(t *testing.T) {
+	for id, expected := range map[string]rustBinary{
+		"shop":                  {crate: "shop", kind: "lib", name: "shop"},
+		"shop::bin/shop-cli":    {crate: "shop", kind: "bin", name: "shop-cli"},
+		"shop::checkout":        {crate: "shop", kind: "test", name: "checkout"},
+		"shop::bench/pricing":   {crate: "shop", kind: "bench", name: "pricing"},
+		"shop::example/minimal": {crate: "shop", kind: "example", name: "minimal"},
+	} {
+		binary := parseRustBinaryID(id)
+		require.Equal(t, expected, binary, id)
+		require.Equal(t, id, binary.id())
+	}
+}
+
+func TestParseLibtestEvents(t *testing.T) {
+	t.Run("cargo test", func(t *testing.T) {
+		events := strings.Join([]string{
+			`{ "type": "suite", "event": "started", "test_count": 3 }`,
+			`{ "type": "test", "event": "started", "name": "tests::add" }`,
+			`{ "type": "test", "name": "tests::add", "event": "ok", "exec_time": 0.25 }`,
+			`{ "type": "test", "event": "started", "name": "tests::remove" }`,
+			`{ "type": "test", "name": "tests::remove", "event": "timeout" }`,
+			`{ "type": "test", "name": "tests::remove", "event": "failed", "exec_time": 61.5, "stdout": "thread 'tests::remove' panicked at src/lib.rs:10:5:\nassertion failed: cart.is_empty()\nnote: run with RUST_BACKTRACE=1\n" }`,
+			`{ "type": "test", "name": "tests::ship", "event": "ignored", "message": "needs a carrier" }`,
+			`{ "type": "suite", "event": "failed", "passed": 1, "failed": 1, "ignored": 1, "measured": 0, "filtered_out": 0, "exec_time": 61.8 }`,
+			`{ "type": "suite", "event": "started", "test_count": 1 }`,
+			`{ "type": "bench", "name": "bench_total", "median": 1250, "deviation": 40 }`,
+			`{ "type": "suite", "event": "ok", "passed": 0, "failed": 0, "ignored": 0, "measured": 1, "filtered_out": 0, "exec_time": 0.5 }`,
+		}, "\n")
+
+		format, err := detectReportFormat([]byte(events))
+		require.NoError(t, err)
+		require.Equal(t, reportFormatLibtest, format)
+
+		report, err := parseReport(reportFormatAuto, "target/cargo-test.json", []byte(events))
+		require.NoError(t, err)
+		require.Equal(t, &testFramework{Name: frameworkLibtest}, report.framework)
+		require.Len(t, report.suites, 2)
+
+		suite := report.suites[0]
+		require.Equal(t, "cargo-test", suite.Name)
+		require.Equal(t, 3, suite.Totals.Tests)
+		require.Equal(t, 250*time.Millisecond, suite.Tests[0].Duration)
+
+		remove := suite.Tests[1]
+		require.Equal(t, junit.StatusFailed, remove.Status)
+		require.Equal(t, "assertion failed: cart.is_empty()", remove.Message)
+		require.Equal(t, "src/lib.rs", remove.Properties["file"])
+		require.Equal(t, "10", remove.Properties["line"])
+
+		require.Equal(t, junit.StatusSkipped, suite.Tests[2].Status)
+		require.Equal(t, "needs a carrier", suite.Tests[2].Message)
+
+		require.Equal(t, "cargo-test-2", report.suites[1].Name)
+		require.Equal(t, 1250*time.Nanosecond, report.suites[1].Tests[0].Duration)
+	})
+
+	t.Run("nextest", func(t *testing.T) {
+		events := strings.Join([]string{
+			`{"type":"suite","event":"started","test_count":1,"nextest":{"crate":"shop","test_binary":"shop-cli","kind":"bin"}}`,
+			`{"type":"test","event":"started","name":"shop::bin/shop-cli$tests::parse_args"}`,
+			`{"type":"test","event":"failed","name":"shop::bin/shop-cli$tests::parse_args","exec_time":0.03,"stdout":"thread 'tests::parse_args' panicked at 'connection refused', src/main.rs:7:5"}`,
+			`{"type":"test","event":"started","name":"shop::bin/shop-cli$tests::parse_args"}`,
+			`{"type":"test","event":"ok","name":"shop::bin/shop-cli$tests::parse_args","exec_time":0.11}`,
+			`{"type":"suite","event":"ok","passed":1,"failed":0,"ignored":0,"measured":0,"filtered_out":0,"exec_time":0.2,"nextest":{"crate":"shop","test_binary":"shop-cli","kind":"bin"}}`,
+		}, "\n")
+
+		report, err := parseReport(reportFormatLibtest, "", []byte(events))
+		require.NoError(t, err)
+		require.Equal(t, &testFramework{Name: frameworkNextest}, report.framework)
+		require.Len(t, report.suites, 1)
+
+		suite := report.suites[0]
+		require.Equal(t, "shop::bin/shop-cli", suite.Name)
+		require.Equal(t, "shop-cli", suite.Properties[RustBinary])
+		require.Len(t, suite.Tests, 2)
+		require.Equal(t, "tests::parse_args", suite.Tests[0].Name)
+		require.Equal(t, "connection refused", suite.Tests[0].Message)
+
+		// the retry passed
+		require.Equal(t, passedOnRetryStatus, testStatus(suite.Tests[1]))
+		require.Equal(t, "1", suite.Tests[1].Properties[TestRetries])
+	})
+
+	t.Run("Invalid events", func(t *testing.T) {
+		_, err := parseReport(reportFormatLibtest, "", []byte(`{"type":"suite","event":"started"}`+"\n"+`{"type":`))
+		require.Error(t, err)
+	})
+}
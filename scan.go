@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ignoreFileName the name of the files listing the paths skipped by the scan of the input directories, with the
+// syntax of the .gitignore files. They apply to the directory they are in, and to its subdirectories
+const ignoreFileName = ".junit2otlpignore"
+
+// ignoreRule a pattern of an ignore file, matched against the paths relative to its directory
+type ignoreRule struct {
+	segments []string
+	negated  bool
+	dirOnly  bool
+}
+
+// parseIgnoreRules parses the patterns of an ignore file: a line per pattern, without the empty lines and the
+// comments starting with #. The patterns starting with ! include again the paths excluded by the previous ones, the
+// ones ending with / only match directories, and the ones without any other / match the names at any depth. The **
+// segments match any number of directories
+func parseIgnoreRules(data []byte) []ignoreRule {
+	rules := []ignoreRule{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := ignoreRule{}
+		if strings.HasPrefix(line, "!") {
+			rule.negated = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		if line == "" {
+			continue
+		}
+
+		rule.segments = strings.Split(line, "/")
+		if !anchored {
+			rule.segments = append([]string{"**"}, rule.segments...)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules
+}
+
+// match checks if the rule matches the path, relative to the directory of its ignore file and separated by /
+func (r ignoreRule) match(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+
+	return matchSegments(r.segments, strings.Split(rel, "/"))
+}
+
+func matchSegments(pattern []string, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchSegments(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if len(name) == 0 {
+		return false
+	}
+
+	if matched, err := path.Match(pattern[0], name[0]); err != nil || !matched {
+		return false
+	}
+
+	return matchSegments(pattern[1:], name[1:])
+}
+
+// ignoreFile the rules of an ignore file, with the path of its directory relative to the scanned one
+type ignoreFile struct {
+	dir   string
+	rules []ignoreRule
+}
+
+// isIgnored checks if the path, relative to the scanned directory, is excluded by the ignore files of its parents.
+// As in git, the last matching rule wins, the ones of the nested ignore files overriding the ones of their parents
+func isIgnored(ignores []ignoreFile, rel string, isDir bool) bool {
+	ignored := false
+	for _, ignore := range ignores {
+		relative := rel
+		if ignore.dir != "" {
+			relative = strings.TrimPrefix(rel, ignore.dir+"/")
+		}
+
+		for _, rule := range ignore.rules {
+			if rule.match(relative, isDir) {
+				ignored = !rule.negated
+			}
+		}
+	}
+
+	return ignored
+}
+
+// isReportFile checks if the file has the extension of a supported report: XML, JSON or TRX
+func isReportFile(file string) bool {
+	ext := strings.ToLower(filepath.Ext(file))
+	return ext == ".xml" || ext == ".json" || ext == ".trx"
+}
+
+// scanDirectory returns the report files in the directory and its subdirectories, in lexical order. The symbolic
+// links, and the junctions of Windows, are followed, including the ones out of the directory, i.e. the
+// bazel-testlogs link of Bazel, but each directory is scanned once: the links to a directory already scanned, through
+// another link or being scanned, which would loop forever, are skipped, so its reports are not ingested twice.
+// The hidden directories, i.e. .git, and the paths excluded by the ignore files are skipped
+func scanDirectory(dir string) ([]string, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{}
+	visited := []os.FileInfo{info}
+	if err := scanDirectoryTree(dir, "", &visited, nil, &files); err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// scanDirectoryTree appends the report files of the directory to the files, with the directories visited by the
+// whole scan, which are compared with the targets of the links as their paths can't tell a loop through a junction
+func scanDirectoryTree(dir string, rel string, visited *[]os.FileInfo, ignores []ignoreFile, files *[]string) error {
+	data, err := os.ReadFile(filepath.Join(dir, ignoreFileName))
+	switch {
+	case err == nil:
+		ignores = append(slices.Clip(ignores), ignoreFile{dir: rel, rules: parseIgnoreRules(data)})
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		file := filepath.Join(dir, entry.Name())
+		entryRel := path.Join(rel, entry.Name())
+
+		info, err := os.Stat(file)
+		if err != nil {
+			if entry.Type()&fs.ModeSymlink != 0 {
+				log.Printf("the link %s is broken, it's skipped: %v", file, err)
+				continue
+			}
+
+			return err
+		}
+
+		if !info.IsDir() {
+			if info.Mode().IsRegular() && isReportFile(file) && !isIgnored(ignores, entryRel, false) {
+				*files = append(*files, file)
+			}
+
+			continue
+		}
+
+		if strings.HasPrefix(entry.Name(), ".") || isIgnored(ignores, entryRel, true) {
+			continue
+		}
+
+		if slices.ContainsFunc(*visited, func(scanned os.FileInfo) bool { return os.SameFile(scanned, info) }) {
+			log.Printf("the directory %s links to a directory already scanned, it's skipped", file)
+			continue
+		}
+		*visited = append(*visited, info)
+
+		if err := scanDirectoryTree(file, entryRel, visited, ignores, files); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanDirectory(t *testing.T) {
+	write := func(t *testing.T, file string, content string) {
+		t.Helper()
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0o755))
+		require.NoError(t, os.WriteFile(file, []byte(content), 0o644))
+	}
+
+	relative := func(t *testing.T, dir string, files []string) []string {
+		t.Helper()
+		paths := []string{}
+		for _, file := range files {
+			rel, err := filepath.Rel(dir, file)
+			require.NoError(t, err)
+			paths = append(paths, filepath.ToSlash(rel))
+		}
+		return paths
+	}
+
+	t.Run("Finds the reports in lexical order", func(t *testing.T) {
+		dir := t.TempDir()
+		write(t, filepath.Join(dir, "b", "TEST-b.xml"), "")
+		write(t, filepath.Join(dir, "a", "nested", "report.json"), "")
+		write(t, filepath.Join(dir, "a", "results.TRX"), "")
+		write(t, filepath.Join(dir, "a", "build.log"), "")
+
+		files, err := scanDirectory(dir)
+		require.NoError(t, err)
+		require.Equal(t, []string{"a/nested/report.json", "a/results.TRX", "b/TEST-b.xml"}, relative(t, dir, files))
+	})
+
+	t.Run("Skips the hidden directories", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), ".results")
+		write(t, filepath.Join(dir, "TEST-a.xml"), "")
+		write(t, filepath.Join(dir, ".git", "TEST-b.xml"), "")
+		write(t, filepath.Join(dir, "module", ".gradle", "TEST-c.xml"), "")
+
+		files, err := scanDirectory(dir)
+		require.NoError(t, err)
+		require.Equal(t, []string{"TEST-a.xml"}, relative(t, dir, files))
+	})
+
+	t.Run("Skips the paths of the ignore files", func(t *testing.T) {
+		dir := t.TempDir()
+		write(t, filepath.Join(dir, ignoreFileName), "# generated\n*.json\n!keep.json\n/fixtures/\nbuild/**/TEST-*.xml\n")
+		write(t, filepath.Join(dir, "report.json"), "")
+		write(t, filepath.Join(dir, "nested", "keep.json"), "")
+		write(t, filepath.Join(dir, "fixtures", "TEST-a.xml"), "")
+		write(t, filepath.Join(dir, "module", "fixtures", "TEST-b.xml"), "")
+		write(t, filepath.Join(dir, "build", "test", "TEST-c.xml"), "")
+		write(t, filepath.Join(dir, "build", "test", "results.xml"), "")
+		write(t, filepath.Join(dir, "module", ignoreFileName), "TEST-d.xml\n!report.json\n")
+		write(t, filepath.Join(dir, "module", "TEST-d.xml"), "")
+		write(t, filepath.Join(dir, "module", "report.json"), "")
+		write(t, filepath.Join(dir, "TEST-d.xml"), "")
+
+		files, err := scanDirectory(dir)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"TEST-d.xml",
+			"build/test/results.xml",
+			"module/fixtures/TEST-b.xml",
+			"module/report.json",
+			"nested/keep.json",
+		}, relative(t, dir, files))
+	})
+
+	t.Run("Follows the links without looping", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("the symbolic links need privileges on Windows")
+		}
+
+		dir := t.TempDir()
+		write(t, filepath.Join(dir, "results", "TEST-a.xml"), "")
+
+		other := t.TempDir()
+		write(t, filepath.Join(other, "TEST-b.xml"), "")
+		require.NoError(t, os.Symlink(other, filepath.Join(dir, "results", "linked")))
+		require.NoError(t, os.Symlink(dir, filepath.Join(dir, "results", "loop")))
+		require.NoError(t, os.Symlink(filepath.Join(dir, "results"), filepath.Join(other, "back")))
+		require.NoError(t, os.Symlink(filepath.Join(dir, "missing"), filepath.Join(dir, "broken.xml")))
+
+		files, err := scanDirectory(dir)
+		require.NoError(t, err)
+		require.Equal(t, []string{"results/TEST-a.xml", "results/linked/TEST-b.xml"}, relative(t, dir, files))
+	})
+
+	t.Run("Scans each directory once", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("the symbolic links need privileges on Windows")
+		}
+
+		dir := t.TempDir()
+		write(t, filepath.Join(dir, "results", "TEST-a.xml"), "")
+
+		other := t.TempDir()
+		write(t, filepath.Join(other, "TEST-b.xml"), "")
+		require.NoError(t, os.Symlink(other, filepath.Join(dir, "first")))
+		require.NoError(t, os.Symlink(other, filepath.Join(dir, "second")))
+		require.NoError(t, os.Symlink(filepath.Join(dir, "results"), filepath.Join(dir, "z-results")))
+
+		files, err := scanDirectory(dir)
+		require.NoError(t, err)
+		require.Equal(t, []string{"first/TEST-b.xml", "results/TEST-a.xml"}, relative(t, dir, files))
+	})
+
+	t.Run("Ingests the reports of a directory", func(t *testing.T) {
+		dir := t.TempDir()
+		data, err := os.ReadFile("TEST-sample.xml")
+		require.NoError(t, err)
+		write(t, filepath.Join(dir, "TEST-sample.xml"), string(data))
+		write(t, filepath.Join(dir, "ignored", "TEST-sample.xml"), string(data))
+		write(t, filepath.Join(dir, ignoreFileName), "ignored/\n")
+
+		report, err := ingestInputs([]string{dir})
+		require.NoError(t, err)
+
+		sample, err := parseReport(reportFormatAuto, "", data)
+		require.NoError(t, err)
+		require.Len(t, report.suites, len(sample.suites))
+	})
+}
+
+func TestParseIgnoreRules(t *testing.T) {
+	rules := parseIgnoreRules([]byte("\n# comment\n!/a/b/\n*.xml\n/\n"))
+	require.Equal(t, []ignoreRule{
+		{segments: []string{"a", "b"}, negated: true, dirOnly: true},
+		{segments: []string{"**", "*.xml"}},
+	}, rules)
+
+	require.True(t, rules[1].match("x/y/TEST-a.xml", false))
+	require.False(t, rules[0].match("a/b", false))
+	require.True(t, rules[0].match("a/b", true))
+	require.False(t, rules[0].match("c/a/b", true))
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"os"
 	"path"
+	"strings"
 )
 
 type Scm interface {
@@ -14,12 +15,21 @@ type ScmContext struct {
 	// Branch the name of the branch, which will be calculated
 	// reading the environment variables for each supported context
 	Branch string
+	// ChangeNumber the identifier of the change request in the review system, such as Gerrit's
+	// change number or Phabricator's revision. Only present for review systems without pull requests
+	ChangeNumber string
 	// ChangeRequest if the SCM context is for a change request
 	ChangeRequest bool
 	// Commit the commit hash for the SCM context
 	Commit string
+	// Patchset the revision of the change request in the review system, such as Gerrit's
+	// patchset or Phabricator's diff. Only present for review systems without pull requests
+	Patchset string
 	// Provider the provider of the SCM context: Github, Gitlab, Jenkins, Other, etc.
 	Provider string
+	// PullRequestURL the URL of the change request in the provider, i.e. the pull request in Github or the merge
+	// request in Gitlab. Only present for change requests, when the provider exposes it
+	PullRequestURL string
 	// TargetBranch the name of the branch in the case the SCM context represents a
 	// change request. In the case ChangeRequest is false, it won't be considered
 	TargetBranch string
@@ -41,6 +51,18 @@ func checkGitContext() *ScmContext {
 		return githubContext
 	}
 
+	// is Gerrit? It must be checked before Jenkins, as the Gerrit Trigger plugin runs on Jenkins
+	gerritContext := FromGerrit()
+	if gerritContext != nil {
+		return gerritContext
+	}
+
+	// is Phabricator? It must be checked before Jenkins, as Harbormaster usually triggers Jenkins builds
+	phabricatorContext := FromPhabricator()
+	if phabricatorContext != nil {
+		return phabricatorContext
+	}
+
 	// is Jenkins?
 	jenkinsContext := FromJenkins()
 	if jenkinsContext != nil {
@@ -80,15 +102,35 @@ func FromGithub() *ScmContext {
 
 	isChangeRequest := (baseRef != "" && headRef != "")
 
+	pullRequestURL := ""
+	if isChangeRequest {
+		pullRequestURL = githubPullRequestURL()
+	}
+
 	return &ScmContext{
-		ChangeRequest: isChangeRequest,
-		Commit:        sha,
-		Branch:        branchName,
-		Provider:      "Github",
-		TargetBranch:  baseRef,
+		ChangeRequest:  isChangeRequest,
+		Commit:         sha,
+		Branch:         branchName,
+		Provider:       "Github",
+		PullRequestURL: pullRequestURL,
+		TargetBranch:   baseRef,
 	}
 }
 
+// githubPullRequestURL returns the URL of the pull request from the refs/pull/NUMBER/merge ref of its workflows,
+// in the server and the repository of the workflow
+func githubPullRequestURL() string {
+	server := os.Getenv("GITHUB_SERVER_URL")
+	repository := os.Getenv("GITHUB_REPOSITORY")
+	number, found := strings.CutPrefix(os.Getenv("GITHUB_REF"), "refs/pull/")
+	if server == "" || repository == "" || !found {
+		return ""
+	}
+
+	number, _, _ = strings.Cut(number, "/")
+	return strings.TrimSuffix(server, "/") + "/" + repository + "/pull/" + number
+}
+
 // FromGitlab returns an SCM context for Gitlab, reading the right environment variables, as described
 // in their docs
 func FromGitlab() *ScmContext {
@@ -103,12 +145,20 @@ func FromGitlab() *ScmContext {
 
 	isChangeRequest := (commitBranch == "")
 
+	pullRequestURL := ""
+	projectURL := os.Getenv("CI_MERGE_REQUEST_PROJECT_URL") // only present on merge requests on Gitlab CI
+	mergeRequestID := os.Getenv("CI_MERGE_REQUEST_IID")     // only present on merge requests on Gitlab CI
+	if isChangeRequest && projectURL != "" && mergeRequestID != "" {
+		pullRequestURL = strings.TrimSuffix(projectURL, "/") + "/-/merge_requests/" + mergeRequestID
+	}
+
 	return &ScmContext{
-		ChangeRequest: isChangeRequest,
-		Commit:        sha,
-		Branch:        headRef,
-		Provider:      "Gitlab",
-		TargetBranch:  baseRef,
+		ChangeRequest:  isChangeRequest,
+		Commit:         sha,
+		Branch:         headRef,
+		Provider:       "Gitlab",
+		PullRequestURL: pullRequestURL,
+		TargetBranch:   baseRef,
 	}
 }
 
@@ -126,11 +176,12 @@ func FromJenkins() *ScmContext {
 
 	if isPR {
 		return &ScmContext{
-			ChangeRequest: isPR,
-			Commit:        sha,
-			Branch:        headRef,
-			Provider:      "Jenkins",
-			TargetBranch:  baseRef,
+			ChangeRequest:  isPR,
+			Commit:         sha,
+			Branch:         headRef,
+			Provider:       "Jenkins",
+			PullRequestURL: os.Getenv("CHANGE_URL"), // only present on multibranch pipelines on Jenkins
+			TargetBranch:   baseRef,
 		}
 	} else {
 		return &ScmContext{
@@ -143,6 +194,98 @@ func FromJenkins() *ScmContext {
 	}
 }
 
+// FromGerrit returns an SCM context for Gerrit, reading the environment variables populated by the
+// Gerrit Trigger plugin for Jenkins, or by Zuul. Gerrit reviews are always change requests, whose
+// number and patchset are read from the variables, or from the refs/changes/XX/CHANGE/PATCHSET ref
+func FromGerrit() *ScmContext {
+	if os.Getenv("GERRIT_CHANGE_NUMBER") == "" && os.Getenv("ZUUL_CHANGE") == "" {
+		return nil
+	}
+
+	changeNumber := firstEnv("GERRIT_CHANGE_NUMBER", "ZUUL_CHANGE")
+	patchset := firstEnv("GERRIT_PATCHSET_NUMBER", "ZUUL_PATCHSET")
+	sha := firstEnv("GERRIT_PATCHSET_REVISION", "ZUUL_COMMIT")
+	baseRef := firstEnv("GERRIT_BRANCH", "ZUUL_BRANCH")
+	ref := firstEnv("GERRIT_REFSPEC", "ZUUL_REF")
+
+	refChange, refPatchset, ok := parseGerritRef(ref)
+	if ok {
+		if changeNumber == "" {
+			changeNumber = refChange
+		}
+		if patchset == "" {
+			patchset = refPatchset
+		}
+	}
+
+	return &ScmContext{
+		Branch:         ref,
+		ChangeNumber:   changeNumber,
+		ChangeRequest:  true,
+		Commit:         sha,
+		Patchset:       patchset,
+		Provider:       "Gerrit",
+		PullRequestURL: os.Getenv("GERRIT_CHANGE_URL"), // only present on the Gerrit Trigger plugin
+		TargetBranch:   baseRef,
+	}
+}
+
+// FromPhabricator returns an SCM context for Phabricator, reading the environment variables that must be
+// exported from the Harbormaster build plan (i.e. PHABRICATOR_DIFF_ID=${buildable.diff}), as Harbormaster does not
+// populate any variable by default. The staging ref uploaded by Arcanist follows the phabricator/diff/DIFF layout
+func FromPhabricator() *ScmContext {
+	if os.Getenv("PHABRICATOR_DIFF_ID") == "" {
+		return nil
+	}
+
+	diffID := os.Getenv("PHABRICATOR_DIFF_ID")
+	revisionID := os.Getenv("PHABRICATOR_REVISION_ID") // only present for differential revisions
+	sha := os.Getenv("PHABRICATOR_COMMIT")             // only present for commits
+	baseRef := os.Getenv("PHABRICATOR_TARGET_BRANCH")  // only present for differential revisions
+	headRef := os.Getenv("PHABRICATOR_STAGING_REF")    // only present if the staging area is configured
+	if headRef == "" {
+		headRef = "phabricator/diff/" + diffID
+	}
+
+	// the revision is at the root of the Phabricator instance, i.e. https://phabricator.example.com/D123
+	pullRequestURL := ""
+	if instanceURL := os.Getenv("PHABRICATOR_URL"); instanceURL != "" && revisionID != "" {
+		pullRequestURL = strings.TrimSuffix(instanceURL, "/") + "/D" + strings.TrimPrefix(revisionID, "D")
+	}
+
+	return &ScmContext{
+		Branch:         headRef,
+		ChangeNumber:   strings.TrimPrefix(revisionID, "D"),
+		ChangeRequest:  revisionID != "",
+		Commit:         sha,
+		Patchset:       diffID,
+		Provider:       "Phabricator",
+		PullRequestURL: pullRequestURL,
+		TargetBranch:   baseRef,
+	}
+}
+
+// parseGerritRef returns the change number and the patchset from a Gerrit ref, such as refs/changes/45/12345/3
+func parseGerritRef(ref string) (string, string, bool) {
+	parts := strings.Split(strings.TrimPrefix(ref, "refs/changes/"), "/")
+	if len(parts) != 3 || !strings.HasPrefix(ref, "refs/changes/") {
+		return "", "", false
+	}
+
+	return parts[1], parts[2], true
+}
+
+// firstEnv returns the value of the first non-empty environment variable
+func firstEnv(keys ...string) string {
+	for _, key := range keys {
+		if value := os.Getenv(key); value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
+
 // FromLocal returns an SCM context for local, using TARGET_BRANCH and BRANCH as the variables controlling
 // if the SCM context represents a change request. BRANCH is mandatory, otherwise an empty context will be retrieved.
 // If TARGET_BRANCH is not empty, it will represent a change request
@@ -34,6 +34,9 @@ func TestCheckGitContext(t *testing.T) {
 			t.Setenv("GITHUB_REF_NAME", testHeadRef)
 			t.Setenv("GITHUB_BASE_REF", testBaseRef)
 			t.Setenv("GITHUB_HEAD_REF", testHeadRef)
+			t.Setenv("GITHUB_SERVER_URL", "https://github.com")
+			t.Setenv("GITHUB_REPOSITORY", "mdelapenya/junit2otlp")
+			t.Setenv("GITHUB_REF", "refs/pull/23/merge")
 
 			gitCtx := checkGitContext()
 			require.Equal(t, testSha, gitCtx.Commit)
@@ -41,6 +44,7 @@ func TestCheckGitContext(t *testing.T) {
 			require.Equal(t, testBaseRef, gitCtx.GetTargetBranch())
 			require.Equal(t, "Github", gitCtx.Provider)
 			require.True(t, gitCtx.ChangeRequest)
+			require.Equal(t, "https://github.com/mdelapenya/junit2otlp/pull/23", gitCtx.PullRequestURL)
 		})
 	})
 
@@ -72,6 +76,7 @@ func TestCheckGitContext(t *testing.T) {
 			t.Setenv("GIT_COMMIT", testSha)
 			t.Setenv("CHANGE_ID", "PR-123")
 			t.Setenv("CHANGE_TARGET", "main")
+			t.Setenv("CHANGE_URL", "https://github.com/acme/shop/pull/123")
 			t.Setenv("BRANCH_NAME", testBranch)
 
 			gitCtx := checkGitContext()
@@ -80,6 +85,88 @@ func TestCheckGitContext(t *testing.T) {
 			require.Equal(t, "main", gitCtx.GetTargetBranch())
 			require.Equal(t, "Jenkins", gitCtx.Provider)
 			require.True(t, gitCtx.ChangeRequest)
+			require.Equal(t, "https://github.com/acme/shop/pull/123", gitCtx.PullRequestURL)
+		})
+	})
+
+	t.Run("Gerrit", func(t *testing.T) {
+		// Disable Local and Github
+		t.Setenv("BRANCH", "")
+		t.Setenv("GITHUB_SHA", "")
+
+		t.Run("Running with the Gerrit Trigger plugin", func(t *testing.T) {
+			t.Setenv("JENKINS_URL", "http://jenkins.local")
+			t.Setenv("GERRIT_CHANGE_NUMBER", "12345")
+			t.Setenv("GERRIT_PATCHSET_NUMBER", "3")
+			t.Setenv("GERRIT_PATCHSET_REVISION", "0123456")
+			t.Setenv("GERRIT_BRANCH", "main")
+			t.Setenv("GERRIT_REFSPEC", "refs/changes/45/12345/3")
+			t.Setenv("GERRIT_CHANGE_URL", "https://review.example.com/12345")
+
+			gitCtx := checkGitContext()
+			require.Equal(t, "0123456", gitCtx.Commit)
+			require.Equal(t, "refs/changes/45/12345/3", gitCtx.Branch)
+			require.Equal(t, "main", gitCtx.GetTargetBranch())
+			require.Equal(t, "12345", gitCtx.ChangeNumber)
+			require.Equal(t, "3", gitCtx.Patchset)
+			require.Equal(t, "Gerrit", gitCtx.Provider)
+			require.True(t, gitCtx.ChangeRequest)
+			require.Equal(t, "https://review.example.com/12345", gitCtx.PullRequestURL)
+		})
+
+		t.Run("Running with Zuul", func(t *testing.T) {
+			t.Setenv("GERRIT_CHANGE_NUMBER", "")
+			t.Setenv("ZUUL_CHANGE", "678")
+			t.Setenv("ZUUL_PATCHSET", "")
+			t.Setenv("ZUUL_COMMIT", "0123456")
+			t.Setenv("ZUUL_BRANCH", "main")
+			t.Setenv("ZUUL_REF", "refs/changes/78/678/2")
+
+			gitCtx := checkGitContext()
+			require.Equal(t, "0123456", gitCtx.Commit)
+			require.Equal(t, "main", gitCtx.GetTargetBranch())
+			require.Equal(t, "678", gitCtx.ChangeNumber)
+			require.Equal(t, "2", gitCtx.Patchset)
+			require.Equal(t, "Gerrit", gitCtx.Provider)
+		})
+	})
+
+	t.Run("Phabricator", func(t *testing.T) {
+		// Disable Local, Github and Gerrit
+		t.Setenv("BRANCH", "")
+		t.Setenv("GITHUB_SHA", "")
+		t.Setenv("GERRIT_CHANGE_NUMBER", "")
+		t.Setenv("ZUUL_CHANGE", "")
+
+		t.Run("Running for Differential revisions", func(t *testing.T) {
+			t.Setenv("PHABRICATOR_DIFF_ID", "987")
+			t.Setenv("PHABRICATOR_REVISION_ID", "D123")
+			t.Setenv("PHABRICATOR_TARGET_BRANCH", "main")
+			t.Setenv("PHABRICATOR_STAGING_REF", "")
+			t.Setenv("PHABRICATOR_URL", "https://phabricator.example.com/")
+
+			gitCtx := checkGitContext()
+			require.Equal(t, "phabricator/diff/987", gitCtx.Branch)
+			require.Equal(t, "main", gitCtx.GetTargetBranch())
+			require.Equal(t, "123", gitCtx.ChangeNumber)
+			require.Equal(t, "987", gitCtx.Patchset)
+			require.Equal(t, "Phabricator", gitCtx.Provider)
+			require.True(t, gitCtx.ChangeRequest)
+			require.Equal(t, "https://phabricator.example.com/D123", gitCtx.PullRequestURL)
+		})
+
+		t.Run("Running for Commits", func(t *testing.T) {
+			t.Setenv("PHABRICATOR_DIFF_ID", "987")
+			t.Setenv("PHABRICATOR_REVISION_ID", "")
+			t.Setenv("PHABRICATOR_COMMIT", "0123456")
+			t.Setenv("PHABRICATOR_STAGING_REF", "refs/tags/phabricator/diff/987")
+
+			gitCtx := checkGitContext()
+			require.Equal(t, "0123456", gitCtx.Commit)
+			require.Equal(t, "refs/tags/phabricator/diff/987", gitCtx.Branch)
+			require.Equal(t, "Phabricator", gitCtx.Provider)
+			require.False(t, gitCtx.ChangeRequest)
+			require.Empty(t, gitCtx.PullRequestURL)
 		})
 	})
 
@@ -107,6 +194,8 @@ func TestCheckGitContext(t *testing.T) {
 			t.Setenv("CI_COMMIT_REF_NAME", "branch")
 			t.Setenv("CI_MERGE_REQUEST_SOURCE_BRANCH_SHA", "0123456")
 			t.Setenv("CI_MERGE_REQUEST_TARGET_BRANCH_NAME", "main")
+			t.Setenv("CI_MERGE_REQUEST_PROJECT_URL", "https://gitlab.com/acme/shop")
+			t.Setenv("CI_MERGE_REQUEST_IID", "7")
 
 			gitCtx := checkGitContext()
 			require.Equal(t, "0123456", gitCtx.Commit)
@@ -114,6 +203,7 @@ func TestCheckGitContext(t *testing.T) {
 			require.Equal(t, "main", gitCtx.GetTargetBranch())
 			require.Equal(t, "Gitlab", gitCtx.Provider)
 			require.True(t, gitCtx.ChangeRequest)
+			require.Equal(t, "https://gitlab.com/acme/shop/-/merge_requests/7", gitCtx.PullRequestURL)
 		})
 	})
 
@@ -143,9 +233,12 @@ func TestCheckGitContext(t *testing.T) {
 	})
 
 	t.Run("Empty SCM context", func(t *testing.T) {
-		// Disable Local, Github, Jenkins and Gitlab
+		// Disable Local, Github, Gerrit, Phabricator, Jenkins and Gitlab
 		t.Setenv("BRANCH", "")
 		t.Setenv("GITHUB_SHA", "")
+		t.Setenv("GERRIT_CHANGE_NUMBER", "")
+		t.Setenv("ZUUL_CHANGE", "")
+		t.Setenv("PHABRICATOR_DIFF_ID", "")
 		t.Setenv("JENKINS_URL", "")
 		t.Setenv("CI_COMMIT_BRANCH", "")
 
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"regexp"
+
+	"github.com/joshdk/go-junit"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// seedProperties the properties of the test cases and the suites holding the seed of the random order of the
+// tests: the configuration parameter of JUnit 5, and the property of the RSpec JUnit formatter
+var seedProperties = []string{"junit.jupiter.execution.order.random.seed", "seed"}
+
+// seedRegexes match the seeds that the test runners log in their output: RSpec and Minitest ("Randomized with
+// seed 1234", "--seed 1234"), pytest-randomly ("--randomly-seed=1234"), PHPUnit ("Random Seed: 1234") and Go
+// ("-test.shuffle 1234")
+var seedRegexes = []*regexp.Regexp{
+	regexp.MustCompile(`Randomized with seed (\d+)`),
+	regexp.MustCompile(`--seed[ =](\d+)`),
+	regexp.MustCompile(`--randomly-seed=(\d+)`),
+	regexp.MustCompile(`Random [Ss]eed:\s+(\d+)`),
+	regexp.MustCompile(`-test\.shuffle[ =](\d+)`),
+}
+
+// seedAttributes returns the test.seed attribute with the seed of the random order of the test, read from the
+// properties of the test or its suite, or parsed from their output, so that the order-dependent failures can be
+// reproduced from the trace
+func seedAttributes(suite junit.Suite, test junit.Test) []attribute.KeyValue {
+	seed := findSeed(
+		[]map[string]string{test.Properties, suite.Properties},
+		[]string{test.SystemOut, test.SystemErr, suite.SystemOut, suite.SystemErr},
+	)
+	if seed == "" {
+		return nil
+	}
+
+	return []attribute.KeyValue{attribute.Key(TestSeed).String(seed)}
+}
+
+// findSeed returns the first seed in the properties, or else in the outputs
+func findSeed(properties []map[string]string, outputs []string) string {
+	for _, props := range properties {
+		for _, property := range seedProperties {
+			if value := props[property]; value != "" {
+				return value
+			}
+		}
+	}
+
+	for _, output := range outputs {
+		for _, regex := range seedRegexes {
+			if matches := regex.FindStringSubmatch(output); matches != nil {
+				return matches[1]
+			}
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestSeedAttributes(t *testing.T) {
+	seed := func(value string) []attribute.KeyValue {
+		return []attribute.KeyValue{attribute.Key(TestSeed).String(value)}
+	}
+
+	t.Run("JUnit 5 property", func(t *testing.T) {
+		suite := junit.Suite{Properties: map[string]string{"junit.jupiter.execution.order.random.seed": "42"}}
+		require.Equal(t, seed("42"), seedAttributes(suite, junit.Test{Name: "testTotal"}))
+	})
+
+	t.Run("Test property takes precedence", func(t *testing.T) {
+		suite := junit.Suite{Properties: map[string]string{"seed": "1"}}
+		test := junit.Test{Name: "testTotal", Properties: map[string]string{"seed": "2"}}
+		require.Equal(t, seed("2"), seedAttributes(suite, test))
+	})
+
+	t.Run("Output", func(t *testing.T) {
+		outputs := map[string]string{
+			"rspec":           "Randomized with seed 12345\n",
+			"minitest":        "Run options: --seed 23456\n",
+			"pytest-randomly": "Using --randomly-seed=34567\n",
+			"phpunit":         "Random Seed:   45678\n",
+			"go":              "-test.shuffle 56789\n",
+		}
+		expected := map[string]string{"rspec": "12345", "minitest": "23456", "pytest-randomly": "34567", "phpunit": "45678", "go": "56789"}
+
+		for runner, output := range outputs {
+			require.Equal(t, seed(expected[runner]), seedAttributes(junit.Suite{SystemOut: output}, junit.Test{Name: "test"}), runner)
+		}
+
+		require.Equal(t, seed("7"), seedAttributes(junit.Suite{}, junit.Test{SystemErr: "Randomized with seed 7"}))
+	})
+
+	t.Run("No seed", func(t *testing.T) {
+		require.Empty(t, seedAttributes(junit.Suite{SystemOut: "all tests passed"}, junit.Test{Name: "test"}))
+	})
+}
@@ -3,39 +3,211 @@ package main
 const (
 	Junit2otlp = "junit2otlp"
 
+	// instrumentation scope keys
+	ReportFormat = "report.format"
+	ToolVersion  = "tool.version"
+
 	// git keys
-	GitAdditions     = "scm.git.additions"
-	GitCloneDepth    = "scm.git.clone.depth"
-	GitCloneShallow  = "scm.git.clone.shallow"
-	GitDeletions     = "scm.git.deletions"
-	GitModifiedFiles = "scm.git.files.modified"
+	GitAdditions         = "scm.git.additions"
+	GitCloneDepth        = "scm.git.clone.depth"
+	GitCloneShallow      = "scm.git.clone.shallow"
+	GitCommits           = "scm.git.commits"
+	GitCommitsBreaking   = "scm.git.commits.breaking"
+	GitCommitsMerge      = "scm.git.commits.merge"
+	GitCommitsRevert     = "scm.git.commits.revert"
+	GitCommitsSquash     = "scm.git.commits.squash"
+	GitCommitsTypePrefix = "scm.git.commits.type."
+	GitDeletions         = "scm.git.deletions"
+	GitFileStats         = "scm.git.files.stats"
+	GitModifiedFiles     = "scm.git.files.modified"
+	GitRenamedFiles      = "scm.git.files.renamed"
 
 	// scm keys
-	ScmAuthors    = "scm.authors"
-	ScmBaseRef    = "scm.baseRef"
-	ScmBranch     = "scm.branch"
-	ScmCommitters = "scm.committers"
-	ScmProvider   = "scm.provider"
-	ScmRepository = "scm.repository"
-	ScmType       = "scm.type"
+	ScmAuthors        = "scm.authors"
+	ScmBaseRef        = "scm.baseRef"
+	ScmBranch         = "scm.branch"
+	ScmChangeNumber   = "scm.change.number"
+	ScmChangePatchset = "scm.change.patchset"
+	ScmCommitters     = "scm.committers"
+	ScmProvider       = "scm.provider"
+	ScmPullRequestURL = "scm.pull_request.url"
+	ScmRepository     = "scm.repository"
+	ScmType           = "scm.type"
+
+	// ci keys
+	CICDPipelineName   = "cicd.pipeline.name"
+	CICDPipelineRunID  = "cicd.pipeline.run.id"
+	CICDPipelineRunURL = "cicd.pipeline.run.url.full"
+
+	// deployment keys
+	DeploymentRing = "deployment.ring"
+
+	// link keys
+	LinkKind = "link.kind"
+
+	// bazel keys
+	BazelActionsExecuted = "bazel.actions.executed"
+	BazelBuildDuration   = "bazel.build.duration"
+	BazelCommand         = "bazel.command"
+	BazelExitCode        = "bazel.exit_code"
+	BazelInvocationID    = "bazel.invocation.id"
+	BazelTargetLabel     = "bazel.target.label"
+	BazelTargetsBuilt    = "bazel.targets.built"
+	BazelTargetsFailed   = "bazel.targets.failed"
+	BazelTestCached      = "bazel.test.cached"
+	BazelTestRun         = "bazel.test.run"
+	BazelTestShard       = "bazel.test.shard"
+	BazelTestShards      = "bazel.test.shards"
+	BazelTestStatus      = "bazel.test.status"
+	BazelTestStrategy    = "bazel.test.strategy"
+
+	// http keys, the response time being in milliseconds
+	HTTPMethod       = "http.method"
+	HTTPResponseTime = "http.response.time"
+	HTTPStatusCode   = "http.status_code"
+	HTTPURL          = "http.url"
+
+	// newman keys
+	NewmanIteration = "newman.iteration"
+
+	// rust keys
+	RustBinary     = "rust.binary"
+	RustBinaryKind = "rust.binary.kind"
+	RustCrate      = "rust.crate"
+
+	// trx keys
+	TrxCategories = "trx.test.categories"
+	TrxOwners     = "trx.test.owners"
+	TrxRunName    = "trx.run.name"
+	TrxRunOutcome = "trx.run.outcome"
+	TrxRunUser    = "trx.run.user"
+
+	// report keys
+	ReportInconsistent   = "report.inconsistent"
+	ReportSourceFile     = "report.source.file"
+	ReportSourceFormat   = "report.source.format"
+	ReportSourceModified = "report.source.modified"
+	ReportSourceSHA256   = "report.source.sha256"
+
+	// payload keys
+	PayloadDigest    = "junit2otlp.payload.digest"
+	PayloadSignature = "junit2otlp.payload.signature"
+
+	// session keys
+	SessionID = "session.id"
+
+	// framework keys
+	TestFramework        = "test.framework"
+	TestFrameworkVersion = "test.framework.version"
+
+	// device keys
+	DeviceModelIdentifier = "device.model.identifier"
+	OSVersion             = "os.version"
+
+	// webdriver keys
+	WebDriverSessionID = "webdriver.session.id"
+
+	// failure keys
+	FailureCategory = "failure.category"
+
+	// run keys
+	TestsCompareBase           = "tests.compare.base"
+	TestsCompareFixedTests     = "tests.compare.fixed_tests"
+	TestsCompareFixes          = "tests.compare.fixes"
+	TestsCompareRegressedTests = "tests.compare.regressed_tests"
+	TestsCompareRegressions    = "tests.compare.regressions"
+	TestsCostCurrency          = "tests.cost.currency"
+	TestsRunCost               = "tests.run.cost"
+	TestsRunDuration           = "tests.run.duration"
+	TestsRunError              = "tests.run.error"
+	TestsRunFailed             = "tests.run.failed"
+	TestsRunIdle               = "tests.run.idle"
+	TestsRunName               = "tests.run.name"
+	TestsRunPassRate           = "tests.run.pass_rate"
+	TestsRunPassed             = "tests.run.passed"
+	TestsRunPassedOnRetry      = "tests.run.passed_on_retry"
+	TestsRunSkipped            = "tests.run.skipped"
+	TestsRunSuites             = "tests.run.suites"
+	TestsRunTotal              = "tests.run.total"
 
 	// suite keys
-	FailedTestsCount  = "tests.suite.failed"
-	ErrorTestsCount   = "tests.suite.error"
-	PassedTestsCount  = "tests.suite.passed"
-	SkippedTestsCount = "tests.suite.skipped"
-	TestsDuration     = "tests.suite.duration"
-	TestsSuiteName    = "tests.suite.suitename"
-	TestsSystemErr    = "tests.suite.systemerr"
-	TestsSystemOut    = "tests.suite.systemout"
-	TotalTestsCount   = "tests.suite.total"
+	AssertionsCount         = "tests.suite.assertions"
+	SuiteDeclaredErrors     = "tests.suite.declared.errors"
+	SuiteDeclaredFailures   = "tests.suite.declared.failures"
+	SuiteDeclaredSkipped    = "tests.suite.declared.skipped"
+	SuiteDeclaredTests      = "tests.suite.declared.tests"
+	SuiteDeclaredTime       = "tests.suite.declared.time"
+	FailedTestsCount        = "tests.suite.failed"
+	ErrorTestsCount         = "tests.suite.error"
+	PassedTestsCount        = "tests.suite.passed"
+	PassedOnRetryTestsCount = "tests.suite.passed_on_retry"
+	SkippedTestsCount       = "tests.suite.skipped"
+	TestsSuiteCost          = "tests.suite.cost"
+	TestsSuiteHanging       = "tests.suite.hanging"
+	TestsSuiteIdleBefore    = "tests.suite.idle_before"
+	TestsDuration           = "tests.suite.duration"
+	TestsDurationByOwner    = "tests.duration.by_owner"
+	TestsSuiteName          = "tests.suite.suitename"
+	SuiteOverheadDuration   = "tests.suite.overhead.duration"
+	TestsSystemErr          = "tests.suite.systemerr"
+	TestsSystemOut          = "tests.suite.systemout"
+	TotalTestsCount         = "tests.suite.total"
+
+	// test metrics keys
+	TestCaseDurationHistogram = "tests.case.duration.histogram"
+	TestCaseExecutionsCount   = "tests.case.executions"
+	TestCaseFailedGauge       = "tests.case.failed"
+	TestMetricID              = "tests.case.metric.id"
+	TimeoutTestsCount         = "tests.timeouts"
+	TestsErrorsCount          = "tests.errors"
+	TestsFailuresCount        = "tests.failures"
+	TestsSuitesHanging        = "tests.suites.hanging"
+	TestsSessionIdle          = "tests.session.idle"
+
+	// dropped data metrics
+	DroppedDataCount = "junit2otlp.dropped"
+	DroppedKind      = "junit2otlp.dropped.kind"
+	DroppedReason    = "junit2otlp.dropped.reason"
+
+	// server metrics
+	ExportDuration  = "junit2otlp.export.duration"
+	QueueDepth      = "junit2otlp.queue.depth"
+	QueueSpilled    = "junit2otlp.queue.spilled"
+	QueueStorage    = "queue.storage"
+	ReportsExported = "junit2otlp.reports.exported"
+	ReportsReceived = "junit2otlp.reports.received"
+	ServerOutcome   = "outcome"
+	ServerTenant    = "tenant"
 
 	// test keys
-	TestClassName = "tests.case.classname"
-	TestDuration  = "tests.case.duration"
-	TestError     = "tests.case.error"
-	TestMessage   = "tests.case.message"
-	TestStatus    = "tests.case.status"
-	TestSystemErr = "tests.case.systemerr"
-	TestSystemOut = "tests.case.systemout"
+	TestArtifactPrefix    = "tests.case.artifact."
+	TestAssertions        = "tests.case.assertions"
+	TestCaseClass         = "test.case.class"
+	TestCaseMethod        = "test.case.method"
+	TestCaseName          = "test.case.name"
+	TestCasePackage       = "test.case.package"
+	TestCaseParameters    = "test.case.parameters"
+	TestCaseSubtest       = "test.case.subtest"
+	TestClassName         = "tests.case.classname"
+	TestCompareBaseStatus = "test.compare.base_status"
+	TestCompareResult     = "test.compare.result"
+	TestCoversFiles       = "test.covers.files"
+	TestDisplayName       = "test.case.display_name"
+	TestDuration          = "tests.case.duration"
+	TestError             = "tests.case.error"
+	TestFlaky             = "tests.case.flaky"
+	TestID                = "tests.case.id"
+	TestMessage           = "tests.case.message"
+	TestModule            = "tests.case.module"
+	TestOwner             = "tests.case.owner"
+	TestProject           = "tests.case.project"
+	TestReportEntries     = "tests.case.report_entries"
+	TestResult            = "test.result"
+	TestRetries           = "tests.case.retries"
+	TestSeed              = "test.seed"
+	TestStatus            = "tests.case.status"
+	TestSystemErr         = "tests.case.systemerr"
+	TestSystemOut         = "tests.case.systemout"
+	TestTags              = "tests.case.tags"
+	TestTimeout           = "test.timeout"
 )
@@ -0,0 +1,518 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+const serveCommand = "serve"
+const defaultServerAddress = "localhost:4321"
+
+// reportsPath the path of the endpoint receiving the reports
+const reportsPath = "/v1/reports"
+
+// maxReportSize the maximum size of a report received by the server
+const maxReportSize = 64 << 20
+
+// the outcomes of the reports in the metrics of the server
+const (
+	outcomeAccepted = "accepted"
+	outcomeRejected = "rejected"
+	outcomeSuccess  = "success"
+	outcomeFailure  = "failure"
+)
+
+// exportDurationBuckets the boundaries of the histogram of the export durations, in seconds
+var exportDurationBuckets = []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// reportServer receives the reports over HTTP, queueing them to be exported one at a time, so that the bursts
+// of reports do not grow the memory of the process beyond the queue
+type reportServer struct {
+	queue   *reportQueue
+	export  func(ctx context.Context, report *queuedReport) error
+	metrics *serverMetrics
+	ready   atomic.Bool
+	// idGenerator generates the trace IDs assigned to the reports when they are submitted
+	idGenerator assignedIDGenerator
+}
+
+// serverMetrics the metrics of the operation of the server, exposed in the Prometheus format
+type serverMetrics struct {
+	reader   *sdkmetric.ManualReader
+	provider *sdkmetric.MeterProvider
+	received metric.Int64Counter
+	exported metric.Int64Counter
+	duration metric.Float64Histogram
+}
+
+func newServerMetrics() (*serverMetrics, error) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	meter := provider.Meter(Junit2otlp, metric.WithInstrumentationVersion(version))
+
+	received, err := meter.Int64Counter(ReportsReceived, metric.WithDescription("Number of reports received by the server, by outcome"))
+	if err != nil {
+		return nil, err
+	}
+
+	exported, err := meter.Int64Counter(ReportsExported, metric.WithDescription("Number of reports exported by the server, by outcome"))
+	if err != nil {
+		return nil, err
+	}
+
+	duration, err := meter.Float64Histogram(ExportDuration,
+		metric.WithDescription("Duration of the exports of the reports, in seconds"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(exportDurationBuckets...),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &serverMetrics{reader: reader, provider: provider, received: received, exported: exported, duration: duration}, nil
+}
+
+func (m *serverMetrics) meter() metric.Meter {
+	return m.provider.Meter(Junit2otlp, metric.WithInstrumentationVersion(version))
+}
+
+func (m *serverMetrics) handle(w http.ResponseWriter, r *http.Request) {
+	rm := metricdata.ResourceMetrics{}
+	if err := m.reader.Collect(r.Context(), &rm); err != nil {
+		http.Error(w, fmt.Sprintf("failed to collect the metrics: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := writePrometheus(w, rm); err != nil {
+		log.Printf("failed to write the metrics: %v", err)
+	}
+}
+
+func newReportServer(queue *reportQueue, export func(ctx context.Context, report *queuedReport) error) (*reportServer, error) {
+	metrics, err := newServerMetrics()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := queue.instrument(metrics.meter()); err != nil {
+		return nil, err
+	}
+
+	return &reportServer{queue: queue, export: export, metrics: metrics}, nil
+}
+
+func (s *reportServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+reportsPath, s.receive)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("GET /readyz", s.readiness)
+	mux.HandleFunc("GET /metrics", s.metrics.handle)
+
+	return mux
+}
+
+// readiness reports if the server is exporting the reports, which is not the case before the queue is
+// consumed and once the server is stopping
+func (s *reportServer) readiness(w http.ResponseWriter, r *http.Request) {
+	if !s.ready.Load() {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+
+	fmt.Fprintln(w, "ready")
+}
+
+// receive queues the report in the body of the request, accepting it before it's exported
+func (s *reportServer) receive(w http.ResponseWriter, r *http.Request) {
+	status := http.StatusAccepted
+	report := &queuedReport{}
+	defer func() {
+		outcome := outcomeAccepted
+		if status != http.StatusAccepted {
+			outcome = outcomeRejected
+		}
+		s.metrics.received.Add(r.Context(), 1, metric.WithAttributes(attribute.Key(ServerOutcome).String(outcome), attribute.Key(ServerTenant).String(report.metadata.Tenant)))
+	}()
+
+	tenant, err := appConfig.Tenancy.tenant(r.Header.Get(appConfig.Tenancy.Header))
+	if err != nil {
+		status = http.StatusBadRequest
+		http.Error(w, err.Error(), status)
+		return
+	}
+	report.metadata.Tenant = tenant
+
+	// the credentials are checked before the body is read, so the clients without them cannot make the server
+	// buffer their reports
+	var auth *requestAuth
+	if appConfig.Auth.enabled() {
+		auth, err = appConfig.Auth.authenticateHeader(r.Header, tenant, nil, time.Now())
+		if err != nil {
+			status = authErrorStatus(w, err)
+			return
+		}
+	}
+
+	var body io.Reader = http.MaxBytesReader(w, r.Body, maxReportSize)
+	if auth != nil {
+		body = io.TeeReader(body, auth)
+	}
+
+	data, err := io.ReadAll(body)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		status = http.StatusRequestEntityTooLarge
+		http.Error(w, fmt.Sprintf("the report is larger than %d bytes", maxBytesErr.Limit), status)
+		return
+	}
+	if err != nil {
+		status = http.StatusBadRequest
+		http.Error(w, fmt.Sprintf("failed to read the report: %v", err), status)
+		return
+	}
+
+	if auth != nil {
+		token, err := auth.verify()
+		if err != nil {
+			status = authErrorStatus(w, err)
+			return
+		}
+		report.metadata.Attributes = token.Attributes
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		status = http.StatusBadRequest
+		http.Error(w, "the report is empty", status)
+		return
+	}
+
+	report.data = data
+	if err := s.queue.push(r.Context(), report); err != nil {
+		status = http.StatusServiceUnavailable
+		http.Error(w, fmt.Sprintf("failed to queue the report: %v", err), status)
+		return
+	}
+
+	w.WriteHeader(status)
+}
+
+// authErrorStatus responds to the request whose authentication failed, returning its status
+func authErrorStatus(w http.ResponseWriter, err error) int {
+	if errors.Is(err, errForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return http.StatusForbidden
+	}
+
+	w.Header().Set("WWW-Authenticate", authSchemeBearer)
+	http.Error(w, err.Error(), http.StatusUnauthorized)
+	return http.StatusUnauthorized
+}
+
+// run exports the queued reports until the context is done or the queue is closed. The report being exported
+// when the context is done is exported completely
+func (s *reportServer) run(ctx context.Context) {
+	s.ready.Store(true)
+	defer s.ready.Store(false)
+
+	for {
+		report, err := s.queue.pop(ctx)
+		if err != nil {
+			if !errors.Is(err, errQueueClosed) && !errors.Is(err, context.Canceled) {
+				log.Printf("failed to read a report from the queue: %v", err)
+				continue
+			}
+			return
+		}
+
+		start := time.Now()
+		outcome := outcomeSuccess
+		if err := s.export(context.WithoutCancel(ctx), report); err != nil {
+			outcome = outcomeFailure
+			log.Printf("failed to export a report: %v", err)
+		}
+
+		attributes := metric.WithAttributes(attribute.Key(ServerOutcome).String(outcome), attribute.Key(ServerTenant).String(report.metadata.Tenant))
+		s.metrics.exported.Add(ctx, 1, attributes)
+		s.metrics.duration.Record(ctx, time.Since(start).Seconds(), attributes)
+	}
+}
+
+// reportExporter exports each report as its own trace, with the providers shared by all the reports of its tenant.
+// The reports without a tenant are exported with the default providers
+type reportExporter struct {
+	srvName     string
+	diagnostics *exportDiagnostics
+	outputs     *spanOutputs
+	defaults    *tenantProviders
+	tenants     map[string]*tenantProviders
+	watcher     *suiteWatcher
+}
+
+// providers returns the providers of the tenant, creating them with its first report
+func (e *reportExporter) providers(ctx context.Context, tenant string) (*tenantProviders, error) {
+	if tenant == "" {
+		return e.defaults, nil
+	}
+
+	if providers, ok := e.tenants[tenant]; ok {
+		return providers, nil
+	}
+
+	config, ok := appConfig.Tenancy.Tenants[tenant]
+	if !ok {
+		return nil, fmt.Errorf("unknown tenant %q", tenant)
+	}
+
+	providers, err := newTenantProviders(ctx, config, e.defaults.res, e.diagnostics, e.outputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the exporters of the tenant %q: %w", tenant, err)
+	}
+
+	if e.tenants == nil {
+		e.tenants = map[string]*tenantProviders{}
+	}
+	e.tenants[tenant] = providers
+
+	return providers, nil
+}
+
+// shutdown shuts down the providers of the tenants, the default ones are shut down by the server
+func (e *reportExporter) shutdown(ctx context.Context) error {
+	errs := []error{}
+	for _, providers := range e.tenants {
+		errs = append(errs, providers.shutdown(ctx))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (e *reportExporter) export(ctx context.Context, queued *queuedReport) error {
+	providers, err := e.providers(ctx, queued.metadata.Tenant)
+	if err != nil {
+		return err
+	}
+
+	// the trace ID returned to the client when the report was submitted
+	if traceID, err := trace.TraceIDFromHex(queued.metadata.TraceID); err == nil {
+		ctx = withAssignedTraceID(ctx, traceID)
+	}
+
+	report, err := parseReport(formatFlag, "", queued.data)
+	if err != nil {
+		return fmt.Errorf("failed to ingest the report: %w", err)
+	}
+
+	report, err = applyMergePolicy(report, mergePolicyFlag)
+	if err != nil {
+		return err
+	}
+
+	// the suites arrived, even if their export fails
+	if e.watcher != nil {
+		e.watcher.observe(report)
+	}
+
+	deviceProviders, err := initDeviceTracerProviders(ctx, providers.res, e.diagnostics, e.outputs, report, providers.traceOptions...)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, provider := range deviceProviders {
+			provider.Shutdown(ctx)
+		}
+	}()
+
+	// the attributes of the SCM and the ones of the token are added to the runtime ones for each report
+	defer func(attributes []attribute.KeyValue) { runtimeAttributes = attributes }(runtimeAttributes)
+	runtimeAttributes = append(slices.Clip(runtimeAttributes), tokenAttributes(queued.metadata.Attributes)...)
+
+	if err := createTracesAndSpans(ctx, e.srvName, "", providers.tracerProvider, providers.meterProvider, deviceProviders, report); err != nil {
+		return err
+	}
+
+	// the spans of the report are exported before the next one, so the memory is bounded by the queue
+	return providers.tracerProvider.ForceFlush(ctx)
+}
+
+// runServe parses the flags of the server mode, and runs it until the process is interrupted
+func runServe(args []string) {
+	if err := resolveFlags(flag.CommandLine, args); err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := serve(ctx); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func serve(ctx context.Context) error {
+	otlpSrvName := getOtlpServiceName()
+
+	cfg, err := loadConfig(configFlag)
+	if err != nil {
+		return err
+	}
+	appConfig = cfg
+
+	if sessionID := getSessionID(nil); sessionID != "" {
+		runtimeAttributes = append(runtimeAttributes, attribute.Key(SessionID).String(sessionID))
+	}
+
+	if err := addAdditionalAttributes(); err != nil {
+		return err
+	}
+
+	res, err := newResource(ctx, otlpSrvName, getOtlpServiceVersion())
+	if err != nil {
+		return err
+	}
+
+	diagnostics := &exportDiagnostics{}
+	otel.SetErrorHandler(diagnostics)
+	defer func() {
+		if verboseFlag {
+			diagnostics.report()
+		}
+	}()
+
+	outputs, err := newSpanOutputs()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := outputs.shutdown(context.Background()); err != nil {
+			otel.Handle(err)
+		}
+	}()
+
+	tracesProvides, err := initTracerProvider(ctx, res, diagnostics, outputs)
+	if err != nil {
+		return err
+	}
+	defer tracesProvides.Shutdown(context.Background())
+
+	meterProvider, err := initMetricsProvider(ctx, res, outputs)
+	if err != nil {
+		return fmt.Errorf("failed to initialise pusher: %v", err)
+	}
+	defer meterProvider.Shutdown(context.Background())
+
+	queue, err := newReportQueue(queueSizeFlag, queueMaxMemoryFlag, getSpoolDir(), spoolMaxSizeFlag)
+	if err != nil {
+		return err
+	}
+
+	exporter := &reportExporter{
+		srvName:     otlpSrvName,
+		diagnostics: diagnostics,
+		outputs:     outputs,
+		defaults:    &tenantProviders{res: res, tracerProvider: tracesProvides, meterProvider: meterProvider},
+	}
+	// registered after the default providers, so the tenants are shut down before the shared outputs
+	defer func() {
+		if err := exporter.shutdown(context.Background()); err != nil {
+			otel.Handle(err)
+		}
+	}()
+
+	if appConfig.ExpectedSuites.enabled() {
+		reporter, err := newHangingSuitesReporter(tracesProvides, meterProvider.Meter(Junit2otlp, metric.WithInstrumentationVersion(version)))
+		if err != nil {
+			return err
+		}
+
+		exporter.watcher = newSuiteWatcher(appConfig.ExpectedSuites, reporter.report)
+		defer exporter.watcher.stop()
+	}
+
+	server, err := newReportServer(queue, exporter.export)
+	if err != nil {
+		return err
+	}
+	// the tracer providers already validated the generator
+	server.idGenerator, _ = parseIDGenerator(idGeneratorFlag)
+
+	listener, err := net.Listen("tcp", serverAddressFlag)
+	if err != nil {
+		return err
+	}
+
+	httpServer := &http.Server{Handler: server.handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("failed to serve the reports: %v", err)
+		}
+	}()
+	log.Printf("listening for reports at http://%s%s", listener.Addr(), reportsPath)
+
+	var grpcServer *grpc.Server
+	if grpcAddressFlag != "" {
+		grpcListener, err := net.Listen("tcp", grpcAddressFlag)
+		if err != nil {
+			httpServer.Close()
+			return err
+		}
+
+		grpcServer = newGRPCServer(server)
+		go func() {
+			if err := grpcServer.Serve(grpcListener); err != nil {
+				log.Printf("failed to serve the gRPC ingestion service: %v", err)
+			}
+		}()
+		log.Printf("listening for reports with gRPC at %s", grpcListener.Addr())
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		server.run(ctx)
+	}()
+
+	<-ctx.Done()
+	log.Printf("interrupted, stopping the server")
+	server.ready.Store(false)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("failed to stop the server: %v", err)
+	}
+	if grpcServer != nil {
+		grpcServer.GracefulStop()
+	}
+
+	// the reports still in memory are spilled to disk, to be exported by the next process
+	if err := queue.close(); err != nil {
+		log.Printf("failed to keep the queued reports: %v", err)
+	}
+	<-done
+
+	return nil
+}
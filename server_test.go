@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportServer(t *testing.T) {
+	defer func(cfg *config) { appConfig = cfg }(appConfig)
+	appConfig = &config{Tenancy: tenancyConfig{Header: "X-Team", Tenants: map[string]*tenantConfig{"checkout": {}}}}
+
+	queue, err := newReportQueue(10, defaultQueueMaxMemory, t.TempDir(), 0)
+	require.NoError(t, err)
+
+	mu := sync.Mutex{}
+	exported := []string{}
+	server, err := newReportServer(queue, func(ctx context.Context, report *queuedReport) error {
+		mu.Lock()
+		defer mu.Unlock()
+		exported = append(exported, report.metadata.Tenant+":"+string(report.data))
+		if strings.Contains(string(report.data), "broken") {
+			return errors.New("broken report")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	httpServer := httptest.NewServer(server.handler())
+	defer httpServer.Close()
+
+	post := func(body string, team string) int {
+		req, err := http.NewRequest(http.MethodPost, httpServer.URL+reportsPath, strings.NewReader(body))
+		require.NoError(t, err)
+		if team != "" {
+			req.Header.Set("X-Team", team)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	get := func(path string) (int, string) {
+		resp, err := http.Get(httpServer.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	status, _ := get("/healthz")
+	require.Equal(t, http.StatusOK, status)
+	status, _ = get("/readyz")
+	require.Equal(t, http.StatusServiceUnavailable, status)
+
+	require.Equal(t, http.StatusAccepted, post(`<testsuite name="a"/>`, "checkout"))
+	require.Equal(t, http.StatusAccepted, post(`<testsuite name="broken"/>`, ""))
+	require.Equal(t, http.StatusBadRequest, post("  ", ""))
+	require.Equal(t, http.StatusBadRequest, post(`<testsuite name="b"/>`, "payments"))
+
+	status, _ = get(reportsPath)
+	require.Equal(t, http.StatusMethodNotAllowed, status)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		server.run(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(exported) == 2
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, []string{`checkout:<testsuite name="a"/>`, `:<testsuite name="broken"/>`}, exported)
+
+	status, _ = get("/readyz")
+	require.Equal(t, http.StatusOK, status)
+
+	status, metrics := get("/metrics")
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, metrics, "# TYPE junit2otlp_reports_received_total counter\n")
+	require.Contains(t, metrics, `junit2otlp_reports_received_total{outcome="accepted",tenant="checkout"} 1`)
+	require.Contains(t, metrics, `junit2otlp_reports_received_total{outcome="accepted",tenant=""} 1`)
+	require.Contains(t, metrics, `junit2otlp_reports_received_total{outcome="rejected",tenant=""} 2`)
+	require.Contains(t, metrics, `junit2otlp_reports_exported_total{outcome="failure",tenant=""} 1`)
+	require.Contains(t, metrics, `junit2otlp_export_duration_bucket{outcome="success",tenant="checkout",le="+Inf"} 1`)
+	require.Contains(t, metrics, `junit2otlp_export_duration_count{outcome="success",tenant="checkout"} 1`)
+	require.Contains(t, metrics, `junit2otlp_queue_depth{queue_storage="memory"} 0`)
+
+	cancel()
+	<-done
+
+	status, _ = get("/readyz")
+	require.Equal(t, http.StatusServiceUnavailable, status)
+}
+
+func TestReportServer_SpoolFull(t *testing.T) {
+	defer func(cfg *config) { appConfig = cfg }(appConfig)
+	appConfig = &config{}
+
+	// a report in memory, and a spilled one of 24 bytes with its empty metadata
+	queue, err := newReportQueue(1, defaultQueueMaxMemory, t.TempDir(), 24)
+	require.NoError(t, err)
+
+	server, err := newReportServer(queue, func(ctx context.Context, report *queuedReport) error { return nil })
+	require.NoError(t, err)
+
+	httpServer := httptest.NewServer(server.handler())
+	defer httpServer.Close()
+
+	post := func() (int, string) {
+		resp, err := http.Post(httpServer.URL+reportsPath, "application/xml", strings.NewReader(`<testsuite name="a"/>`))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	status, _ := post()
+	require.Equal(t, http.StatusAccepted, status)
+	status, _ = post()
+	require.Equal(t, http.StatusAccepted, status)
+
+	status, body := post()
+	require.Equal(t, http.StatusServiceUnavailable, status)
+	require.Contains(t, body, errSpoolFull.Error())
+
+	_, disk := queue.depth()
+	require.Equal(t, 1, disk)
+}
+
+func TestReportServer_Auth(t *testing.T) {
+	defer func(cfg *config) { appConfig = cfg }(appConfig)
+	appConfig = &config{
+		Tenancy: tenancyConfig{Header: "X-Team", Tenants: map[string]*tenantConfig{"checkout": {}, "payments": {}}},
+		Auth: authConfig{Tokens: []*apiToken{
+			{Name: "checkout", Token: "abc123", Tenants: []string{"checkout"}, Attributes: map[string]string{"team": "checkout"}},
+			{Name: "ci", Secret: "s3cr3t", Attributes: map[string]string{"team": "ci"}},
+		}},
+	}
+
+	queue, err := newReportQueue(10, defaultQueueMaxMemory, t.TempDir(), 0)
+	require.NoError(t, err)
+
+	server, err := newReportServer(queue, func(ctx context.Context, report *queuedReport) error { return nil })
+	require.NoError(t, err)
+
+	httpServer := httptest.NewServer(server.handler())
+	defer httpServer.Close()
+
+	post := func(authorization string, team string) int {
+		req, err := http.NewRequest(http.MethodPost, httpServer.URL+reportsPath, strings.NewReader(`<testsuite name="a"/>`))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", authorization)
+		req.Header.Set("X-Team", team)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	require.Equal(t, http.StatusUnauthorized, post("", ""))
+	require.Equal(t, http.StatusUnauthorized, post("Bearer wrong", ""))
+	require.Equal(t, http.StatusForbidden, post("Bearer abc123", "payments"))
+	require.Equal(t, http.StatusAccepted, post("Bearer abc123", ""))
+	require.Equal(t, http.StatusAccepted, post("Bearer abc123", "checkout"))
+
+	report, err := queue.tryPop()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"team": "checkout"}, report.metadata.Attributes)
+
+	report, err = queue.tryPop()
+	require.NoError(t, err)
+	require.Equal(t, "checkout", report.metadata.Tenant)
+
+	report, err = queue.tryPop()
+	require.NoError(t, err)
+	require.Nil(t, report)
+
+	t.Run("Body not read without credentials", func(t *testing.T) {
+		body := &countingReader{Reader: strings.NewReader(`<testsuite name="a"/>`)}
+		req := httptest.NewRequest(http.MethodPost, reportsPath, body)
+		req.Header.Set("Authorization", "Bearer wrong")
+
+		recorder := httptest.NewRecorder()
+		server.handler().ServeHTTP(recorder, req)
+
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+		require.Zero(t, body.read)
+	})
+
+	t.Run("Signed", func(t *testing.T) {
+		body := `<testsuite name="a"/>`
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+		signed := func(signature string) int {
+			req := httptest.NewRequest(http.MethodPost, reportsPath, strings.NewReader(body))
+			req.Header.Set(authTimestampHeader, timestamp)
+			req.Header.Set("Authorization", authSchemeHMAC+" ci:"+signature)
+
+			recorder := httptest.NewRecorder()
+			server.handler().ServeHTTP(recorder, req)
+			return recorder.Code
+		}
+
+		require.Equal(t, http.StatusUnauthorized, signed(signReport("other", timestamp, "", nil, []byte(body))))
+		require.Equal(t, http.StatusAccepted, signed(signReport("s3cr3t", timestamp, "", nil, []byte(body))))
+
+		report, err := queue.tryPop()
+		require.NoError(t, err)
+		require.Equal(t, body, string(report.data))
+		require.Equal(t, map[string]string{"team": "ci"}, report.metadata.Attributes)
+	})
+}
+
+// countingReader counts the bytes read from the reader
+type countingReader struct {
+	io.Reader
+	read int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.read += n
+	return n, err
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// persistedContext the trace context persisted in a context file, shared by multiple invocations
+// of the tool within the same session, i.e. the unit, integration and e2e stages of a pipeline
+type persistedContext struct {
+	SessionID   string `json:"session_id,omitempty"`
+	Traceparent string `json:"traceparent"`
+	Tracestate  string `json:"tracestate,omitempty"`
+	// LastSuiteEnd the end of the latest suite of the invocations of the session, to compute the idle time before
+	// the suites of the next one
+	LastSuiteEnd *time.Time `json:"last_suite_end,omitempty"`
+}
+
+// extract returns a context including the persisted trace context as the remote parent
+func (pc *persistedContext) extract(ctx context.Context) context.Context {
+	tc := propagation.TraceContext{}
+	return tc.Extract(ctx, &textMap{parent: pc.Traceparent, state: pc.Tracestate})
+}
+
+// readContextFile reads the persisted context from the file, returning nil if the file does not exist
+func readContextFile(path string) (*persistedContext, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the context file: %w", err)
+	}
+
+	pc := &persistedContext{}
+	if err := json.Unmarshal(data, pc); err != nil {
+		return nil, fmt.Errorf("failed to parse the context file %s: %w", path, err)
+	}
+
+	return pc, nil
+}
+
+// writeContextFile persists the trace context of the given context into the file. If exclusive, it's written only
+// if the file does not exist yet, so that the first invocation of the session wins, even if several invocations
+// run in parallel. Otherwise, the file is overwritten
+func writeContextFile(ctx context.Context, path string, sessionID string, exclusive bool) error {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+
+	data, err := json.MarshalIndent(persistedContext{
+		SessionID:   sessionID,
+		Traceparent: carrier.Get(traceparentHeader),
+		Tracestate:  carrier.Get(tracestateHeader),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if exclusive {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	}
+
+	file, err := os.OpenFile(path, flags, 0o644)
+	if exclusive && errors.Is(err, os.ErrExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create the context file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("failed to write the context file: %w", err)
+	}
+
+	return nil
+}
+
+// writeLastSuiteEnd records the end of the latest suite of the invocation in the context file, unless a previous
+// invocation of the session recorded a later one
+func writeLastSuiteEnd(path string, end time.Time) error {
+	pc, err := readContextFile(path)
+	if err != nil {
+		return err
+	}
+	if pc == nil || (pc.LastSuiteEnd != nil && !end.After(*pc.LastSuiteEnd)) {
+		return nil
+	}
+
+	end = end.UTC()
+	pc.LastSuiteEnd = &end
+	data, err := json.MarshalIndent(pc, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path+".tmp", data, 0o644); err != nil {
+		return fmt.Errorf("failed to write the context file: %w", err)
+	}
+
+	return os.Rename(path+".tmp", path)
+}
+
+// ciSessionID derives the session ID from the CI run, so that every invocation within the same pipeline
+// run shares it. It returns an empty string if the CI run cannot be identified
+func ciSessionID() string {
+	// Github Actions: a re-run of the workflow is a different session
+	if runID := os.Getenv("GITHUB_RUN_ID"); runID != "" {
+		attempt := os.Getenv("GITHUB_RUN_ATTEMPT")
+		if attempt == "" {
+			attempt = "1"
+		}
+
+		return fmt.Sprintf("github-%s-%s", runID, attempt)
+	}
+
+	candidates := []struct {
+		prefix string
+		envVar string
+	}{
+		{prefix: "gitlab", envVar: "CI_PIPELINE_ID"},
+		{prefix: "buildkite", envVar: "BUILDKITE_BUILD_ID"},
+		{prefix: "circleci", envVar: "CIRCLE_WORKFLOW_ID"},
+		{prefix: "azure", envVar: "BUILD_BUILDID"},
+		{prefix: "jenkins", envVar: "BUILD_TAG"},
+	}
+
+	for _, candidate := range candidates {
+		if value := os.Getenv(candidate.envVar); value != "" {
+			return candidate.prefix + "-" + value
+		}
+	}
+
+	return ""
+}
+
+// getSessionID the precedence order is: flag > context file > CI run
+func getSessionID(pc *persistedContext) string {
+	if sessionIDFlag != "" {
+		return sessionIDFlag
+	}
+
+	if pc != nil && pc.SessionID != "" {
+		return pc.SessionID
+	}
+
+	return ciSessionID()
+}
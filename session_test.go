@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestContextFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "context.json")
+
+	t.Run("Missing file", func(t *testing.T) {
+		pc, err := readContextFile(path)
+		require.NoError(t, err)
+		require.Nil(t, pc)
+	})
+
+	first := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x01},
+		SpanID:     trace.SpanID{0x02},
+		TraceFlags: trace.FlagsSampled,
+	}))
+	second := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x03},
+		SpanID:     trace.SpanID{0x04},
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	t.Run("First invocation wins", func(t *testing.T) {
+		require.NoError(t, writeContextFile(first, path, "session-1", true))
+		require.NoError(t, writeContextFile(second, path, "session-2", true))
+
+		pc, err := readContextFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "session-1", pc.SessionID)
+
+		parent := trace.SpanContextFromContext(pc.extract(context.Background()))
+		require.True(t, parent.IsRemote())
+		require.Equal(t, trace.TraceID{0x01}, parent.TraceID())
+		require.Equal(t, trace.SpanID{0x02}, parent.SpanID())
+	})
+
+	t.Run("Emitted context is overwritten", func(t *testing.T) {
+		require.NoError(t, writeContextFile(second, path, "session-2", false))
+
+		pc, err := readContextFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "session-2", pc.SessionID)
+		require.Equal(t, trace.TraceID{0x03}, trace.SpanContextFromContext(pc.extract(context.Background())).TraceID())
+	})
+
+	t.Run("The latest end of the suites is kept", func(t *testing.T) {
+		end := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+		require.NoError(t, writeLastSuiteEnd(path, end))
+		require.NoError(t, writeLastSuiteEnd(path, end.Add(-time.Minute)))
+
+		pc, err := readContextFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "session-2", pc.SessionID)
+		require.Equal(t, end, *pc.LastSuiteEnd)
+
+		require.NoError(t, writeLastSuiteEnd(path, end.Add(time.Minute)))
+		pc, err = readContextFile(path)
+		require.NoError(t, err)
+		require.Equal(t, end.Add(time.Minute), *pc.LastSuiteEnd)
+	})
+
+	t.Run("Without the context file", func(t *testing.T) {
+		missing := filepath.Join(t.TempDir(), "context.json")
+		require.NoError(t, writeLastSuiteEnd(missing, time.Now()))
+		require.NoFileExists(t, missing)
+	})
+}
+
+func TestGetSessionID(t *testing.T) {
+	t.Setenv("GITHUB_RUN_ID", "")
+	t.Setenv("CI_PIPELINE_ID", "")
+	t.Setenv("BUILDKITE_BUILD_ID", "")
+	t.Setenv("CIRCLE_WORKFLOW_ID", "")
+	t.Setenv("BUILD_BUILDID", "")
+	t.Setenv("BUILD_TAG", "")
+	sessionIDFlag = ""
+
+	t.Run("No CI run", func(t *testing.T) {
+		require.Equal(t, "", getSessionID(nil))
+	})
+
+	t.Run("Github run", func(t *testing.T) {
+		t.Setenv("GITHUB_RUN_ID", "123")
+		t.Setenv("GITHUB_RUN_ATTEMPT", "2")
+
+		require.Equal(t, "github-123-2", getSessionID(nil))
+		require.Equal(t, "persisted", getSessionID(&persistedContext{SessionID: "persisted"}))
+	})
+
+	t.Run("Flag", func(t *testing.T) {
+		sessionIDFlag = "flag"
+		defer func() { sessionIDFlag = "" }()
+
+		require.Equal(t, "flag", getSessionID(&persistedContext{SessionID: "persisted"}))
+	})
+}
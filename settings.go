@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// flagEnvVarPrefix the prefix of the environment variables of the flags
+const flagEnvVarPrefix = "JUNIT2OTLP_"
+
+// flagEnvVar returns the environment variable of the flag, i.e. JUNIT2OTLP_SERVICE_NAME for --service-name
+func flagEnvVar(name string) string {
+	return flagEnvVarPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// resolveFlags parses the arguments, and resolves the value of each flag in order of precedence: the command line,
+// its JUNIT2OTLP_ environment variable, the flags section of the configuration file, and its default value, so the
+// tool can be configured without arguments, i.e. from the ConfigMap of a Kubernetes deployment.
+// The configuration file can be set with the JUNIT2OTLP_CONFIG environment variable too
+func resolveFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	resolved := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		resolved[f.Name] = true
+	})
+
+	errs := []error{}
+	fs.VisitAll(func(f *flag.Flag) {
+		if resolved[f.Name] {
+			return
+		}
+
+		value := os.Getenv(flagEnvVar(f.Name))
+		if value == "" {
+			return
+		}
+
+		if err := fs.Set(f.Name, value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid value %q of the %s environment variable: %w", value, flagEnvVar(f.Name), err))
+			return
+		}
+		resolved[f.Name] = true
+	})
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	configPath := fs.Lookup("config")
+	if configPath == nil || configPath.Value.String() == "" {
+		return nil
+	}
+
+	cfg, err := loadConfig(configPath.Value.String())
+	if err != nil {
+		return err
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(cfg.Flags)) {
+		if fs.Lookup(name) == nil {
+			errs = append(errs, fmt.Errorf("unknown flag %q in the config file %s", name, configPath.Value))
+			continue
+		}
+
+		if resolved[name] {
+			continue
+		}
+
+		if err := fs.Set(name, cfg.Flags[name]); err != nil {
+			errs = append(errs, fmt.Errorf("invalid value %q of the %s flag in the config file %s: %w", cfg.Flags[name], name, configPath.Value, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
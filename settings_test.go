@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveFlags(t *testing.T) {
+	newFlagSet := func() (*flag.FlagSet, map[string]any) {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		values := map[string]any{
+			"service-name": fs.String("service-name", "", ""),
+			"batch-size":   fs.Int("batch-size", defaultMaxBatchSize, ""),
+			"verbose":      fs.Bool("verbose", false, ""),
+			"test-timeout": fs.Duration("test-timeout", 0, ""),
+			"config":       fs.String("config", "", ""),
+		}
+		return fs, values
+	}
+
+	config := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(config, []byte(`
+flags:
+  service-name: from-config
+  batch-size: 50
+  verbose: true
+  test-timeout: 30s
+`), 0o644))
+
+	t.Run("Precedence", func(t *testing.T) {
+		t.Setenv("JUNIT2OTLP_CONFIG", config)
+		t.Setenv("JUNIT2OTLP_BATCH_SIZE", "20")
+		t.Setenv("JUNIT2OTLP_SERVICE_NAME", "from-env")
+
+		fs, values := newFlagSet()
+		require.NoError(t, resolveFlags(fs, []string{"--service-name", "from-flag"}))
+
+		require.Equal(t, "from-flag", *values["service-name"].(*string))
+		require.Equal(t, 20, *values["batch-size"].(*int))
+		require.True(t, *values["verbose"].(*bool))
+		require.Equal(t, 30*time.Second, *values["test-timeout"].(*time.Duration))
+	})
+
+	t.Run("Defaults", func(t *testing.T) {
+		fs, values := newFlagSet()
+		require.NoError(t, resolveFlags(fs, nil))
+
+		require.Empty(t, *values["service-name"].(*string))
+		require.Equal(t, defaultMaxBatchSize, *values["batch-size"].(*int))
+	})
+
+	t.Run("Invalid environment variable", func(t *testing.T) {
+		t.Setenv("JUNIT2OTLP_BATCH_SIZE", "many")
+
+		fs, _ := newFlagSet()
+		require.ErrorContains(t, resolveFlags(fs, nil), `invalid value "many" of the JUNIT2OTLP_BATCH_SIZE environment variable`)
+	})
+
+	t.Run("Unknown flag in the config file", func(t *testing.T) {
+		unknown := filepath.Join(t.TempDir(), "config.yml")
+		require.NoError(t, os.WriteFile(unknown, []byte("flags:\n  batch: 50\n"), 0o644))
+
+		fs, _ := newFlagSet()
+		require.ErrorContains(t, resolveFlags(fs, []string{"--config", unknown}), `unknown flag "batch" in the config file`)
+	})
+
+	require.Equal(t, "JUNIT2OTLP_SCM_FILE_STATS", flagEnvVar("scm-file-stats"))
+}
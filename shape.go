@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/joshdk/go-junit"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// the shapes of the telemetry of the report: a span per run, suite and test case, or a wide event per test case
+const (
+	shapeSpans  = "spans"
+	shapeEvents = "events"
+)
+
+func parseShape(shape string) (string, error) {
+	switch s := strings.ToLower(strings.TrimSpace(shape)); s {
+	case "":
+		return shapeSpans, nil
+	case shapeSpans, shapeEvents:
+		return s, nil
+	default:
+		return "", fmt.Errorf("invalid shape %q: valid values are %s and %s", shape, shapeSpans, shapeEvents)
+	}
+}
+
+// createSuiteEvents creates a wide event per test case of the suite and its nested suites, without the spans of
+// the suites, which suits the columnar backends, i.e. Honeycomb, better than the hierarchy of spans. Each event
+// has the attributes of its suite and of the run, and the test cases are laid out as createSuiteSpans does. It
+// returns the number of tests failed by a timeout, including the nested suites
+func createSuiteEvents(ctx context.Context, tracer trace.Tracer, testMetrics *testCaseMetrics, suite junit.Suite, suiteAttributes []attribute.KeyValue, runAttributes []attribute.KeyValue, frameworkAttributes []attribute.KeyValue, startTime time.Time) int64 {
+	timeoutLimit := suiteTimeout(suite)
+	timeouts := int64(0)
+
+	eventAttributes := append(append([]attribute.KeyValue{}, suiteAttributes...), runAttributes...)
+
+	cursor := startTime
+	for _, test := range suite.Tests {
+		endTime := time.Time{}
+		if !cursor.IsZero() {
+			cursor = cursor.Add(test.Duration)
+			endTime = cursor
+		}
+
+		if createTestSpan(ctx, tracer, testMetrics, suite, eventAttributes, test, timeoutLimit, endTime) {
+			timeouts++
+		}
+	}
+
+	for _, nested := range suite.Suites {
+		timeouts += createSuiteEvents(ctx, tracer, testMetrics, nested, suiteSpanAttributes(nested, nil, frameworkAttributes), runAttributes, frameworkAttributes, cursor)
+		if !cursor.IsZero() {
+			cursor = cursor.Add(nested.Totals.Duration)
+		}
+	}
+
+	return timeouts
+}
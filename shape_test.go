@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestParseShape(t *testing.T) {
+	shape, err := parseShape("")
+	require.NoError(t, err)
+	require.Equal(t, shapeSpans, shape)
+
+	shape, err = parseShape("Events")
+	require.NoError(t, err)
+	require.Equal(t, shapeEvents, shape)
+
+	_, err = parseShape("logs")
+	require.Error(t, err)
+}
+
+func TestCreateSuiteEvents(t *testing.T) {
+	start := time.Date(2021, 11, 15, 5, 16, 16, 0, time.UTC)
+
+	exporter := tracetest.NewInMemoryExporter()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)).Tracer("test")
+	meter := sdkmetric.NewMeterProvider().Meter("test")
+
+	suite := junit.Suite{
+		Name: "a",
+		Tests: []junit.Test{
+			{Name: "first", Duration: time.Second, Status: junit.StatusPassed},
+		},
+		Suites: []junit.Suite{
+			{
+				Name: "b",
+				Tests: []junit.Test{
+					{Name: "second", Duration: 2 * time.Second, Status: junit.StatusFailed},
+				},
+				Totals: junit.Totals{Tests: 1, Failed: 1, Duration: 2 * time.Second},
+			},
+		},
+		Totals: junit.Totals{Tests: 2, Passed: 1, Failed: 1, Duration: 3 * time.Second},
+	}
+
+	runAttributes := []attribute.KeyValue{attribute.Int(TestsRunTotal, 2)}
+	createSuiteEvents(context.Background(), tracer, newTestCaseMetrics(meter, 0), suite, suiteSpanAttributes(suite, nil, nil), runAttributes, nil, start)
+
+	// a span per test case, without the spans of the suites
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+
+	require.Equal(t, "first", spans[0].Name)
+	require.False(t, spans[0].Parent.IsValid())
+	require.Equal(t, start, spans[0].StartTime)
+	require.Contains(t, spans[0].Attributes, attribute.String(TestsSuiteName, "a"))
+	require.Contains(t, spans[0].Attributes, attribute.Int(TestsRunTotal, 2))
+
+	require.Equal(t, "second", spans[1].Name)
+	require.False(t, spans[1].Parent.IsValid())
+	require.Equal(t, start.Add(time.Second), spans[1].StartTime)
+	require.Equal(t, start.Add(3*time.Second), spans[1].EndTime)
+	require.Contains(t, spans[1].Attributes, attribute.String(TestsSuiteName, "b"))
+	require.Contains(t, spans[1].Attributes, attribute.Int(TestsRunTotal, 2))
+}
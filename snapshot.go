@@ -0,0 +1,500 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// otlpSnapshot collects the traces and the metrics of a run, to write them as OTLP JSON normalized to be compared
+// across the versions of the tool: the IDs are numbered in order of appearance, the spans start at zero and end
+// after their duration, the timestamps of the data points are removed, and the metrics and their data points are
+// sorted. The file is written when the exporter of the traces is shut down, after the meter provider
+type otlpSnapshot struct {
+	mu      sync.Mutex
+	path    string
+	spans   []*tracepb.ResourceSpans
+	metrics []*metricpb.ResourceMetrics
+}
+
+// newOtlpSnapshot creates the snapshot written to the path, or kept in memory if it's empty
+func newOtlpSnapshot(path string) *otlpSnapshot {
+	return &otlpSnapshot{path: path}
+}
+
+func (s *otlpSnapshot) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop writes the snapshot, as the spans are the last signal to be exported
+func (s *otlpSnapshot) Stop(ctx context.Context) error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := s.marshal()
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(s.path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write the snapshot %s: %w", s.path, err)
+	}
+
+	return nil
+}
+
+func (s *otlpSnapshot) UploadTraces(ctx context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.spans = append(s.spans, protoSpans...)
+	return nil
+}
+
+// spanExporter returns the exporter of the spans to the snapshot, with the transformation of the OTLP exporters
+func (s *otlpSnapshot) spanExporter(ctx context.Context) (*otlptrace.Exporter, error) {
+	return otlptrace.New(ctx, s)
+}
+
+// metricReader returns the reader of the metrics of the snapshot, which keeps the cumulative ones of the last export
+func (s *otlpSnapshot) metricReader() sdkmetric.Reader {
+	return sdkmetric.NewPeriodicReader(snapshotMetricExporter{s})
+}
+
+// snapshotMetricExporter exports the metrics to the snapshot
+type snapshotMetricExporter struct {
+	snapshot *otlpSnapshot
+}
+
+func (e snapshotMetricExporter) Temporality(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+	return metricdata.CumulativeTemporality
+}
+
+func (e snapshotMetricExporter) Aggregation(kind sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	return sdkmetric.DefaultAggregationSelector(kind)
+}
+
+// Export converts the metrics right away, as the reader reuses them for the next collection
+func (e snapshotMetricExporter) Export(ctx context.Context, metrics *metricdata.ResourceMetrics) error {
+	converted, err := snapshotResourceMetrics(metrics)
+	if err != nil {
+		return err
+	}
+
+	e.snapshot.mu.Lock()
+	defer e.snapshot.mu.Unlock()
+
+	e.snapshot.metrics = []*metricpb.ResourceMetrics{converted}
+	return nil
+}
+
+func (e snapshotMetricExporter) ForceFlush(ctx context.Context) error {
+	return nil
+}
+
+func (e snapshotMetricExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// marshal returns the normalized OTLP JSON document of the snapshot, with the resourceSpans of the traces and the
+// resourceMetrics of the metrics, indented with its keys sorted
+func (s *otlpSnapshot) marshal() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the OTLP JSON encoding requires the enums as numbers
+	options := protojson.MarshalOptions{UseEnumNumbers: true}
+
+	traces, err := options.Marshal(&coltracepb.ExportTraceServiceRequest{ResourceSpans: s.spans})
+	if err != nil {
+		return nil, err
+	}
+
+	metrics, err := options.Marshal(&colmetricpb.ExportMetricsServiceRequest{ResourceMetrics: s.metrics})
+	if err != nil {
+		return nil, err
+	}
+
+	document := map[string]any{}
+	for _, data := range [][]byte{traces, metrics} {
+		if err := json.Unmarshal(data, &document); err != nil {
+			return nil, err
+		}
+	}
+
+	normalizeSnapshotSpans(document)
+	normalizeSnapshotMetrics(document)
+
+	data, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, '\n'), nil
+}
+
+// normalizeSnapshotSpans merges the spans of the batches by resource and scope, and replaces their IDs and times,
+// removing the modification time of the reports
+func normalizeSnapshotSpans(document map[string]any) {
+	resources := mergeByKey(jsonArray(document["resourceSpans"]), "resource", func(merged, other map[string]any) {
+		merged["scopeSpans"] = append(jsonArray(merged["scopeSpans"]), jsonArray(other["scopeSpans"])...)
+	})
+
+	traceIDs := snapshotIDs{size: 16}
+	spanIDs := snapshotIDs{size: 8}
+
+	for _, resource := range resources {
+		scopes := mergeByKey(jsonArray(resource["scopeSpans"]), "scope", func(merged, other map[string]any) {
+			merged["spans"] = append(jsonArray(merged["spans"]), jsonArray(other["spans"])...)
+		})
+		resource["scopeSpans"] = scopes
+
+		// the IDs of the spans are numbered before replacing the ones of their parents, which end after them
+		for _, scope := range scopes {
+			for _, span := range jsonObjects(scope["spans"]) {
+				traceIDs.number(span["traceId"])
+				spanIDs.number(span["spanId"])
+			}
+		}
+	}
+
+	for _, resource := range resources {
+		for _, scope := range jsonObjects(resource["scopeSpans"]) {
+			for _, span := range jsonObjects(scope["spans"]) {
+				replaceSnapshotIDs(span, traceIDs, spanIDs)
+				for _, link := range jsonObjects(span["links"]) {
+					replaceSnapshotIDs(link, traceIDs, spanIDs)
+				}
+
+				start := jsonUint(span["startTimeUnixNano"])
+				delete(span, "startTimeUnixNano")
+				span["endTimeUnixNano"] = strconv.FormatUint(jsonUint(span["endTimeUnixNano"])-start, 10)
+
+				for _, event := range jsonObjects(span["events"]) {
+					event["timeUnixNano"] = strconv.FormatUint(jsonUint(event["timeUnixNano"])-start, 10)
+				}
+
+				// the modification time of the report changes with each checkout, as the times of the spans
+				if attributes, ok := span["attributes"]; ok {
+					span["attributes"] = slices.DeleteFunc(jsonArray(attributes), func(attribute any) bool {
+						object, ok := attribute.(map[string]any)
+						return ok && object["key"] == ReportSourceModified
+					})
+				}
+			}
+		}
+	}
+
+	document["resourceSpans"] = resources
+}
+
+// normalizeSnapshotMetrics sorts the metrics by name and their data points by attributes, without timestamps
+func normalizeSnapshotMetrics(document map[string]any) {
+	resources := jsonObjects(document["resourceMetrics"])
+	for _, resource := range resources {
+		scopes := jsonObjects(resource["scopeMetrics"])
+		slices.SortStableFunc(scopes, func(a, b map[string]any) int {
+			return bytes.Compare(canonicalJSON(a["scope"]), canonicalJSON(b["scope"]))
+		})
+
+		for _, scope := range scopes {
+			metrics := jsonObjects(scope["metrics"])
+			slices.SortStableFunc(metrics, func(a, b map[string]any) int {
+				return bytes.Compare(canonicalJSON(a["name"]), canonicalJSON(b["name"]))
+			})
+
+			for _, metric := range metrics {
+				for _, kind := range []string{"sum", "gauge", "histogram"} {
+					data, ok := metric[kind].(map[string]any)
+					if !ok {
+						continue
+					}
+
+					points := jsonObjects(data["dataPoints"])
+					for _, point := range points {
+						delete(point, "startTimeUnixNano")
+						delete(point, "timeUnixNano")
+					}
+					slices.SortStableFunc(points, func(a, b map[string]any) int {
+						return bytes.Compare(canonicalJSON(a["attributes"]), canonicalJSON(b["attributes"]))
+					})
+					data["dataPoints"] = points
+				}
+			}
+			scope["metrics"] = metrics
+		}
+		resource["scopeMetrics"] = scopes
+	}
+
+	document["resourceMetrics"] = resources
+}
+
+// snapshotIDs numbers the IDs of the snapshot in order of appearance, encoded in hex as required by OTLP JSON
+type snapshotIDs struct {
+	size    int
+	numbers map[string]string
+}
+
+func (ids *snapshotIDs) number(id any) {
+	value, ok := id.(string)
+	if !ok || value == "" {
+		return
+	}
+
+	if ids.numbers == nil {
+		ids.numbers = map[string]string{}
+	}
+
+	if _, ok := ids.numbers[value]; !ok {
+		ids.numbers[value] = fmt.Sprintf("%0*x", ids.size*2, len(ids.numbers)+1)
+	}
+}
+
+// replace returns the numbered ID, or the ID in hex if it's not one of the snapshot, i.e. of a linked trace
+func (ids snapshotIDs) replace(id string) string {
+	if number, ok := ids.numbers[id]; ok {
+		return number
+	}
+
+	// protojson encodes the bytes in base64
+	decoded, err := base64.StdEncoding.DecodeString(id)
+	if err != nil {
+		return id
+	}
+
+	return hex.EncodeToString(decoded)
+}
+
+func replaceSnapshotIDs(object map[string]any, traceIDs snapshotIDs, spanIDs snapshotIDs) {
+	for key, ids := range map[string]snapshotIDs{"traceId": traceIDs, "spanId": spanIDs, "parentSpanId": spanIDs} {
+		if id, ok := object[key].(string); ok && id != "" {
+			object[key] = ids.replace(id)
+		}
+	}
+}
+
+// mergeByKey merges the objects with the same value of the key, in order of appearance
+func mergeByKey(objects []any, key string, merge func(merged, other map[string]any)) []map[string]any {
+	merged := []map[string]any{}
+	byKey := map[string]map[string]any{}
+
+	for _, object := range jsonObjects(objects) {
+		k := string(canonicalJSON(object[key])) + string(canonicalJSON(object["schemaUrl"]))
+		if existing, ok := byKey[k]; ok {
+			merge(existing, object)
+			continue
+		}
+
+		byKey[k] = object
+		merged = append(merged, object)
+	}
+
+	return merged
+}
+
+// canonicalJSON encodes the value with the keys of its objects sorted, to compare the values
+func canonicalJSON(value any) []byte {
+	data, _ := json.Marshal(value)
+	return data
+}
+
+func jsonArray(value any) []any {
+	switch v := value.(type) {
+	case []any:
+		return v
+	case []map[string]any:
+		array := make([]any, 0, len(v))
+		for _, object := range v {
+			array = append(array, object)
+		}
+		return array
+	}
+
+	return nil
+}
+
+func jsonObjects(value any) []map[string]any {
+	objects := []map[string]any{}
+	for _, item := range jsonArray(value) {
+		if object, ok := item.(map[string]any); ok {
+			objects = append(objects, object)
+		}
+	}
+
+	return objects
+}
+
+// jsonUint returns the value of an uint64 field, encoded as a string by protojson
+func jsonUint(value any) uint64 {
+	s, _ := value.(string)
+	n, _ := strconv.ParseUint(s, 10, 64)
+	return n
+}
+
+// snapshotResourceMetrics converts the metrics to OTLP, as the transformation of the OTLP exporters is internal.
+// The exemplars are left out, as they are sampled at random
+func snapshotResourceMetrics(metrics *metricdata.ResourceMetrics) (*metricpb.ResourceMetrics, error) {
+	converted := &metricpb.ResourceMetrics{Resource: &resourcepb.Resource{}}
+	if metrics.Resource != nil {
+		converted.Resource.Attributes = snapshotAttributes(metrics.Resource.Attributes())
+		converted.SchemaUrl = metrics.Resource.SchemaURL()
+	}
+
+	for _, scope := range metrics.ScopeMetrics {
+		scopeMetrics := &metricpb.ScopeMetrics{
+			Scope: &commonpb.InstrumentationScope{
+				Name:       scope.Scope.Name,
+				Version:    scope.Scope.Version,
+				Attributes: snapshotAttributes(scope.Scope.Attributes.ToSlice()),
+			},
+			SchemaUrl: scope.Scope.SchemaURL,
+		}
+
+		for _, m := range scope.Metrics {
+			metric := &metricpb.Metric{Name: m.Name, Description: m.Description, Unit: m.Unit}
+
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				metric.Data = &metricpb.Metric_Sum{Sum: &metricpb.Sum{AggregationTemporality: snapshotTemporality(data.Temporality), IsMonotonic: data.IsMonotonic, DataPoints: snapshotNumberPoints(data.DataPoints)}}
+			case metricdata.Sum[float64]:
+				metric.Data = &metricpb.Metric_Sum{Sum: &metricpb.Sum{AggregationTemporality: snapshotTemporality(data.Temporality), IsMonotonic: data.IsMonotonic, DataPoints: snapshotNumberPoints(data.DataPoints)}}
+			case metricdata.Gauge[int64]:
+				metric.Data = &metricpb.Metric_Gauge{Gauge: &metricpb.Gauge{DataPoints: snapshotNumberPoints(data.DataPoints)}}
+			case metricdata.Gauge[float64]:
+				metric.Data = &metricpb.Metric_Gauge{Gauge: &metricpb.Gauge{DataPoints: snapshotNumberPoints(data.DataPoints)}}
+			case metricdata.Histogram[int64]:
+				metric.Data = &metricpb.Metric_Histogram{Histogram: &metricpb.Histogram{AggregationTemporality: snapshotTemporality(data.Temporality), DataPoints: snapshotHistogramPoints(data.DataPoints)}}
+			case metricdata.Histogram[float64]:
+				metric.Data = &metricpb.Metric_Histogram{Histogram: &metricpb.Histogram{AggregationTemporality: snapshotTemporality(data.Temporality), DataPoints: snapshotHistogramPoints(data.DataPoints)}}
+			default:
+				return nil, fmt.Errorf("unsupported data of the metric %s in the snapshot: %T", m.Name, m.Data)
+			}
+
+			scopeMetrics.Metrics = append(scopeMetrics.Metrics, metric)
+		}
+
+		converted.ScopeMetrics = append(converted.ScopeMetrics, scopeMetrics)
+	}
+
+	return converted, nil
+}
+
+func snapshotTemporality(temporality metricdata.Temporality) metricpb.AggregationTemporality {
+	switch temporality {
+	case metricdata.CumulativeTemporality:
+		return metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE
+	case metricdata.DeltaTemporality:
+		return metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA
+	default:
+		return metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_UNSPECIFIED
+	}
+}
+
+func snapshotNumberPoints[N int64 | float64](points []metricdata.DataPoint[N]) []*metricpb.NumberDataPoint {
+	converted := make([]*metricpb.NumberDataPoint, 0, len(points))
+	for _, point := range points {
+		p := &metricpb.NumberDataPoint{
+			Attributes:        snapshotAttributes(point.Attributes.ToSlice()),
+			StartTimeUnixNano: uint64(point.StartTime.UnixNano()),
+			TimeUnixNano:      uint64(point.Time.UnixNano()),
+		}
+
+		switch v := any(point.Value).(type) {
+		case int64:
+			p.Value = &metricpb.NumberDataPoint_AsInt{AsInt: v}
+		case float64:
+			p.Value = &metricpb.NumberDataPoint_AsDouble{AsDouble: v}
+		}
+
+		converted = append(converted, p)
+	}
+
+	return converted
+}
+
+func snapshotHistogramPoints[N int64 | float64](points []metricdata.HistogramDataPoint[N]) []*metricpb.HistogramDataPoint {
+	converted := make([]*metricpb.HistogramDataPoint, 0, len(points))
+	for _, point := range points {
+		sum := float64(point.Sum)
+		p := &metricpb.HistogramDataPoint{
+			Attributes:        snapshotAttributes(point.Attributes.ToSlice()),
+			StartTimeUnixNano: uint64(point.StartTime.UnixNano()),
+			TimeUnixNano:      uint64(point.Time.UnixNano()),
+			Count:             point.Count,
+			Sum:               &sum,
+			BucketCounts:      point.BucketCounts,
+			ExplicitBounds:    point.Bounds,
+		}
+		if minimum, ok := point.Min.Value(); ok {
+			value := float64(minimum)
+			p.Min = &value
+		}
+		if maximum, ok := point.Max.Value(); ok {
+			value := float64(maximum)
+			p.Max = &value
+		}
+
+		converted = append(converted, p)
+	}
+
+	return converted
+}
+
+func snapshotAttributes(attrs []attribute.KeyValue) []*commonpb.KeyValue {
+	converted := make([]*commonpb.KeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		converted = append(converted, &commonpb.KeyValue{Key: string(attr.Key), Value: snapshotValue(attr.Value)})
+	}
+
+	return converted
+}
+
+func snapshotValue(value attribute.Value) *commonpb.AnyValue {
+	switch value.Type() {
+	case attribute.BOOL:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: value.AsBool()}}
+	case attribute.INT64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: value.AsInt64()}}
+	case attribute.FLOAT64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: value.AsFloat64()}}
+	case attribute.BOOLSLICE:
+		return snapshotArray(value.AsBoolSlice(), attribute.BoolValue)
+	case attribute.INT64SLICE:
+		return snapshotArray(value.AsInt64Slice(), attribute.Int64Value)
+	case attribute.FLOAT64SLICE:
+		return snapshotArray(value.AsFloat64Slice(), attribute.Float64Value)
+	case attribute.STRINGSLICE:
+		return snapshotArray(value.AsStringSlice(), attribute.StringValue)
+	default:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value.Emit()}}
+	}
+}
+
+func snapshotArray[T any](values []T, toValue func(T) attribute.Value) *commonpb.AnyValue {
+	array := &commonpb.ArrayValue{}
+	for _, v := range values {
+		array.Values = append(array.Values, snapshotValue(toValue(v)))
+	}
+
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: array}}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// updateGolden rewrites the golden files with the snapshots of the current version, to review their diff
+var updateGolden = flag.Bool("update", false, "Update the golden files of the snapshots in testdata/golden")
+
+// TestSnapshotGolden compares the snapshot of the traces and metrics of each sample report with its golden file,
+// so any change of the telemetry is reviewed. Run it with -update to rewrite them
+func TestSnapshotGolden(t *testing.T) {
+	defer func(cfg *config, path string, attrs []attribute.KeyValue, drops *dropLedger) {
+		appConfig, repositoryPathFlag, runtimeAttributes, droppedData = cfg, path, attrs, drops
+	}(appConfig, repositoryPathFlag, runtimeAttributes, droppedData)
+	appConfig = &config{}
+
+	// without a repository, nor the environment of a CI system
+	repositoryPathFlag = t.TempDir()
+	for _, env := range os.Environ() {
+		name, _, _ := strings.Cut(env, "=")
+		if strings.HasPrefix(name, "GITHUB_") || strings.HasPrefix(name, "BUILDKITE") || name == "CI" || name == "GITLAB_CI" || name == "JENKINS_URL" || name == "TEAMCITY_VERSION" {
+			t.Setenv(name, "")
+			os.Unsetenv(name)
+		}
+	}
+
+	for _, file := range []string{"TEST-sample.xml", "testdata/playwright-report.json", "testdata/mochawesome-report.json"} {
+		name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+
+		t.Run(name, func(t *testing.T) {
+			runtimeAttributes, droppedData = nil, &dropLedger{}
+			ctx := context.Background()
+
+			data, err := os.ReadFile(file)
+			require.NoError(t, err)
+
+			report, err := parseReport(reportFormatAuto, file, data)
+			require.NoError(t, err)
+
+			snapshot := newOtlpSnapshot("")
+			exporter, err := snapshot.spanExporter(ctx)
+			require.NoError(t, err)
+
+			res := resource.NewSchemaless(attribute.String("service.name", "golden"))
+			tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithResource(res), sdktrace.WithIDGenerator(assignedIDGenerator{}), sdktrace.WithSyncer(exporter))
+			meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithResource(res), sdkmetric.WithView(durationHistogramView(defaultHistogramBuckets)), sdkmetric.WithReader(snapshot.metricReader()))
+
+			require.NoError(t, createTracesAndSpans(ctx, "golden", "", tracerProvider, meterProvider, nil, report))
+			require.NoError(t, meterProvider.Shutdown(ctx))
+			require.NoError(t, tracerProvider.Shutdown(ctx))
+
+			actual, err := snapshot.marshal()
+			require.NoError(t, err)
+
+			golden := filepath.Join("testdata", "golden", name+".json")
+			if *updateGolden {
+				require.NoError(t, os.MkdirAll(filepath.Dir(golden), 0o755))
+				require.NoError(t, os.WriteFile(golden, actual, 0o644))
+			}
+
+			expected, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			require.Equal(t, string(expected), string(actual), "the snapshot differs from %s: review the changes and run the tests with -update", golden)
+		})
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	t.Run("Numbers the IDs in order of appearance", func(t *testing.T) {
+		document := map[string]any{}
+		require.NoError(t, json.Unmarshal([]byte(`{"resourceSpans": [
+			{"resource": {}, "scopeSpans": [{"scope": {"name": "a"}, "spans": [{"traceId": "q83vEjRWeJCrze8SNFZ4kA==", "spanId": "ESIzRFVmd4g=", "parentSpanId": "IiIiIiIiIiI=", "startTimeUnixNano": "1000", "endTimeUnixNano": "1500", "events": [{"timeUnixNano": "1200"}]}]}]},
+			{"resource": {}, "scopeSpans": [{"scope": {"name": "a"}, "spans": [{"traceId": "q83vEjRWeJCrze8SNFZ4kA==", "spanId": "IiIiIiIiIiI=", "startTimeUnixNano": "900", "endTimeUnixNano": "2000", "links": [{"traceId": "/////////////////////w==", "spanId": "//////////8="}]}]}]}
+		]}`), &document))
+
+		normalizeSnapshotSpans(document)
+
+		resources := jsonObjects(document["resourceSpans"])
+		require.Len(t, resources, 1)
+		scopes := jsonObjects(resources[0]["scopeSpans"])
+		require.Len(t, scopes, 1)
+		spans := jsonObjects(scopes[0]["spans"])
+		require.Len(t, spans, 2)
+
+		require.Equal(t, "00000000000000000000000000000001", spans[0]["traceId"])
+		require.Equal(t, "0000000000000001", spans[0]["spanId"])
+		require.Equal(t, "0000000000000002", spans[0]["parentSpanId"])
+		require.NotContains(t, spans[0], "startTimeUnixNano")
+		require.Equal(t, "500", spans[0]["endTimeUnixNano"])
+		require.Equal(t, "200", jsonObjects(spans[0]["events"])[0]["timeUnixNano"])
+
+		require.Equal(t, "0000000000000002", spans[1]["spanId"])
+		require.Equal(t, "1100", spans[1]["endTimeUnixNano"])
+
+		// the links to other traces keep their IDs, in hex
+		link := jsonObjects(spans[1]["links"])[0]
+		require.Equal(t, "ffffffffffffffffffffffffffffffff", link["traceId"])
+		require.Equal(t, "ffffffffffffffff", link["spanId"])
+	})
+
+	t.Run("Writes the file when the spans are shut down", func(t *testing.T) {
+		defer func(path string) { snapshotFlag = path }(snapshotFlag)
+		snapshotFlag = filepath.Join(t.TempDir(), "snapshot.json")
+
+		outputs, err := newSpanOutputs()
+		require.NoError(t, err)
+		require.Len(t, outputs.meterProviderOptions(), 1)
+		require.Nil(t, (*spanOutputs)(nil).meterProviderOptions())
+
+		require.NoError(t, outputs.shutdown(context.Background()))
+
+		data, err := os.ReadFile(snapshotFlag)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"resourceSpans": [], "resourceMetrics": []}`, string(data))
+	})
+}
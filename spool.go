@@ -0,0 +1,699 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// the extensions of the payloads spooled by the exporters, next to the reports spilled by the server: the traces
+// are the requests of OTLP, and the metrics are encoded as JSON, as the exporters don't expose their requests
+const (
+	spoolTracesExtension  = ".traces.otlp"
+	spoolMetricsExtension = ".metrics.json"
+)
+
+// invalidSpoolExtension the extension of the spooled payloads that could not be read, set aside to not retry them
+const invalidSpoolExtension = ".invalid"
+
+// claimedSpoolExtension the extension of the spooled payloads being sent by an invocation, so the other ones sharing
+// the spool directory don't send them too
+const claimedSpoolExtension = ".claimed"
+
+// staleClaimAge the age of the claims recovered by the flushes, as the invocation that claimed the payload died
+// before sending it
+const staleClaimAge = 10 * time.Minute
+
+// exportSpool the directory where the exporters spool the payloads they fail to send after their retries, i.e. when
+// the collector is down, so the next invocation sends them instead of losing the telemetry of the tests
+type exportSpool struct {
+	dir      string
+	sequence atomic.Int64
+	// audit records the payloads sent by the flushes, if any
+	audit *auditLog
+}
+
+// getExportSpool returns the spool of the exports, if the spool directory is set. Unlike the reports of the
+// server, which are spilled to the temporary directory by default, the exports are only spooled on demand
+func getExportSpool() (*exportSpool, error) {
+	if spoolDirFlag == "" {
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(spoolDirFlag, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create the spool directory: %w", err)
+	}
+
+	return &exportSpool{dir: spoolDirFlag}, nil
+}
+
+// write writes the payload to a new file, named after the time and a sequence number as the reports spilled by the
+// server, so they sort in the order they were spooled. It's renamed once written, so a partial file is never sent
+func (s *exportSpool) write(extension string, data []byte) error {
+	name := fmt.Sprintf("%020d-%010d%s", time.Now().UnixNano(), s.sequence.Add(1), extension)
+	path := filepath.Join(s.dir, name)
+
+	if err := os.WriteFile(path+".tmp", data, 0o600); err != nil {
+		return fmt.Errorf("failed to spool the payload: %w", err)
+	}
+
+	return os.Rename(path+".tmp", path)
+}
+
+// files returns the spooled payloads with the extension, in the order they were spooled, with the stale claims
+// released first
+func (s *exportSpool) files(extension string) ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the spool directory: %w", err)
+	}
+
+	files := []string{}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+
+		path := filepath.Join(s.dir, entry.Name())
+		switch {
+		case strings.HasSuffix(entry.Name(), extension):
+			files = append(files, path)
+		case strings.HasSuffix(entry.Name(), extension+claimedSpoolExtension):
+			if info, err := entry.Info(); err == nil && time.Since(info.ModTime()) > staleClaimAge && s.release(path) {
+				files = append(files, strings.TrimSuffix(path, claimedSpoolExtension))
+			}
+		}
+	}
+	slices.Sort(files)
+
+	return files, nil
+}
+
+// claim renames the payload before it's sent, so the invocations sharing the spool directory don't send it twice:
+// the rename only succeeds for one of them. The claim is dated, so it's released if the invocation dies
+func (s *exportSpool) claim(path string) (string, bool) {
+	claimed := path + claimedSpoolExtension
+	if err := os.Rename(path, claimed); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			otel.Handle(err)
+		}
+		return "", false
+	}
+
+	now := time.Now()
+	if err := os.Chtimes(claimed, now, now); err != nil {
+		otel.Handle(err)
+	}
+
+	return claimed, true
+}
+
+// release renames back the claimed payload, so it's sent by the next invocation
+func (s *exportSpool) release(claimed string) bool {
+	if err := os.Rename(claimed, strings.TrimSuffix(claimed, claimedSpoolExtension)); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			otel.Handle(err)
+		}
+		return false
+	}
+
+	return true
+}
+
+// setAside renames the claimed payload that could not be read, so it's kept for an inspection but not retried
+func (s *exportSpool) setAside(claimed string, err error) {
+	path := strings.TrimSuffix(claimed, claimedSpoolExtension)
+	log.Printf("the spooled payload %s is invalid, it's set aside: %v", path, err)
+
+	if err := os.Rename(claimed, path+invalidSpoolExtension); err != nil {
+		otel.Handle(err)
+	}
+}
+
+// flush sends the payloads spooled by the previous invocations, without retries, and stops at the first failure,
+// as the collector is still unavailable. Each payload is claimed before it's sent, skipping the ones claimed by
+// the other invocations, and removed from the spool once sent
+func (s *exportSpool) flush(ctx context.Context) error {
+	traces, err := s.files(spoolTracesExtension)
+	if err != nil {
+		return err
+	}
+
+	if len(traces) > 0 {
+		if err := s.flushTraces(ctx, traces); err != nil {
+			return err
+		}
+	}
+
+	metrics, err := s.files(spoolMetricsExtension)
+	if err != nil {
+		return err
+	}
+
+	if len(metrics) > 0 {
+		return s.flushMetrics(ctx, metrics)
+	}
+
+	return nil
+}
+
+func (s *exportSpool) flushTraces(ctx context.Context, files []string) error {
+	settings := resolveOtlpSettings(signalTraces, appConfig.Exporters.traceOptions(), []otlpOption{withoutOtlpRetry()})
+	otlpClient, err := newOtlpTraceClient(settings)
+	if err != nil {
+		return err
+	}
+
+	// the protocol was validated by the client
+	protocol, _ := getOtlpProtocol(signalTraces)
+	client := s.audit.traceClient(otlpClient, otlpDestination(signalTraces, settings, protocol), protocol)
+
+	if err := client.Start(ctx); err != nil {
+		return err
+	}
+	defer client.Stop(ctx)
+
+	sent := 0
+	for i, path := range files {
+		claimed, ok := s.claim(path)
+		if !ok {
+			continue
+		}
+
+		data, err := os.ReadFile(claimed)
+		if err != nil {
+			s.release(claimed)
+			return err
+		}
+
+		request := &coltracepb.ExportTraceServiceRequest{}
+		if err := proto.Unmarshal(data, request); err != nil {
+			s.setAside(claimed, err)
+			continue
+		}
+
+		if err := client.UploadTraces(ctx, request.GetResourceSpans()); err != nil {
+			s.release(claimed)
+			return fmt.Errorf("failed to send the spooled traces, %d payloads remain in %s: %w", len(files)-i, s.dir, err)
+		}
+
+		if err := os.Remove(claimed); err != nil {
+			return err
+		}
+		sent++
+	}
+
+	log.Printf("sent %d spooled trace payloads", sent)
+
+	return nil
+}
+
+func (s *exportSpool) flushMetrics(ctx context.Context, files []string) error {
+	// the temporality of the spooled metrics was already selected when they were collected
+	exporter, err := newOtlpMetricExporter(ctx, sdkmetric.DefaultTemporalitySelector, withoutOtlpRetry(), withOtlpAuditLog(s.audit))
+	if err != nil {
+		return err
+	}
+	defer exporter.Shutdown(ctx)
+
+	sent := 0
+	for i, path := range files {
+		claimed, ok := s.claim(path)
+		if !ok {
+			continue
+		}
+
+		data, err := os.ReadFile(claimed)
+		if err != nil {
+			s.release(claimed)
+			return err
+		}
+
+		spooled := spooledResourceMetrics{}
+		if err := json.Unmarshal(data, &spooled); err != nil {
+			s.setAside(claimed, err)
+			continue
+		}
+
+		metrics, err := spooled.resourceMetrics()
+		if err != nil {
+			s.setAside(claimed, err)
+			continue
+		}
+
+		if err := exporter.Export(ctx, metrics); err != nil {
+			s.release(claimed)
+			return fmt.Errorf("failed to send the spooled metrics, %d payloads remain in %s: %w", len(files)-i, s.dir, err)
+		}
+
+		if err := os.Remove(claimed); err != nil {
+			return err
+		}
+		sent++
+	}
+
+	log.Printf("sent %d spooled metric payloads", sent)
+
+	return nil
+}
+
+// spoolingTraceClient spools the requests of the traces that the client fails to send, after its retries. The
+// failure is still returned, so it's counted as an export error
+type spoolingTraceClient struct {
+	otlptrace.Client
+	spool *exportSpool
+}
+
+func (c *spoolingTraceClient) UploadTraces(ctx context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	err := c.Client.UploadTraces(ctx, protoSpans)
+	if err == nil {
+		return nil
+	}
+
+	data, marshalErr := proto.Marshal(&coltracepb.ExportTraceServiceRequest{ResourceSpans: protoSpans})
+	if marshalErr != nil {
+		return errors.Join(err, marshalErr)
+	}
+
+	if spoolErr := c.spool.write(spoolTracesExtension, data); spoolErr != nil {
+		return errors.Join(err, spoolErr)
+	}
+
+	return fmt.Errorf("the traces are spooled in %s for the next invocation: %w", c.spool.dir, err)
+}
+
+// spoolingMetricExporter spools the metrics that the exporter fails to send, after its retries. The failure is
+// still returned, so it's counted as an export error
+type spoolingMetricExporter struct {
+	sdkmetric.Exporter
+	spool *exportSpool
+}
+
+// spoolMetrics wraps the exporter with the spool, if any
+func spoolMetrics(exporter sdkmetric.Exporter, spool *exportSpool) sdkmetric.Exporter {
+	if spool == nil {
+		return exporter
+	}
+
+	return &spoolingMetricExporter{Exporter: exporter, spool: spool}
+}
+
+func (e *spoolingMetricExporter) Export(ctx context.Context, metrics *metricdata.ResourceMetrics) error {
+	err := e.Exporter.Export(ctx, metrics)
+	if err == nil {
+		return nil
+	}
+
+	data, marshalErr := json.Marshal(spoolResourceMetrics(metrics))
+	if marshalErr != nil {
+		return errors.Join(err, marshalErr)
+	}
+
+	if spoolErr := e.spool.write(spoolMetricsExtension, data); spoolErr != nil {
+		return errors.Join(err, spoolErr)
+	}
+
+	return fmt.Errorf("the metrics are spooled in %s for the next invocation: %w", e.spool.dir, err)
+}
+
+// the kinds of the spooled metrics, the aggregations of the instruments of the tool
+const (
+	spooledSum       = "sum"
+	spooledGauge     = "gauge"
+	spooledHistogram = "histogram"
+)
+
+// spooledResourceMetrics the metrics of a failed export, without their exemplars
+type spooledResourceMetrics struct {
+	SchemaURL string                `json:"schema_url,omitempty"`
+	Resource  []spooledAttribute    `json:"resource"`
+	Scopes    []spooledScopeMetrics `json:"scopes"`
+}
+
+type spooledScopeMetrics struct {
+	Name       string             `json:"name"`
+	Version    string             `json:"version,omitempty"`
+	SchemaURL  string             `json:"schema_url,omitempty"`
+	Attributes []spooledAttribute `json:"attributes,omitempty"`
+	Metrics    []spooledMetric    `json:"metrics"`
+}
+
+// spooledMetric a metric, with its temporality as a number, as metricdata.Temporality is only encoded as a text
+type spooledMetric struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Unit        string         `json:"unit,omitempty"`
+	Kind        string         `json:"kind"`
+	Integer     bool           `json:"integer,omitempty"`
+	Temporality uint8          `json:"temporality,omitempty"`
+	Monotonic   bool           `json:"monotonic,omitempty"`
+	Points      []spooledPoint `json:"points"`
+}
+
+// spooledPoint a data point, with its numbers as strings, so the integers keep their precision
+type spooledPoint struct {
+	Attributes   []spooledAttribute `json:"attributes,omitempty"`
+	Start        time.Time          `json:"start"`
+	Time         time.Time          `json:"time"`
+	Value        json.Number        `json:"value,omitempty"`
+	Count        uint64             `json:"count,omitempty"`
+	Bounds       []float64          `json:"bounds,omitempty"`
+	BucketCounts []uint64           `json:"bucket_counts,omitempty"`
+	Min          json.Number        `json:"min,omitempty"`
+	Max          json.Number        `json:"max,omitempty"`
+	Sum          json.Number        `json:"sum,omitempty"`
+}
+
+type spooledAttribute struct {
+	Key   string          `json:"key"`
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// spoolResourceMetrics encodes the metrics, skipping the aggregations not recorded by the tool
+func spoolResourceMetrics(metrics *metricdata.ResourceMetrics) spooledResourceMetrics {
+	spooled := spooledResourceMetrics{
+		SchemaURL: metrics.Resource.SchemaURL(),
+		Resource:  spoolAttributes(metrics.Resource.Attributes()),
+		Scopes:    []spooledScopeMetrics{},
+	}
+
+	for _, scope := range metrics.ScopeMetrics {
+		spooledScope := spooledScopeMetrics{
+			Name:       scope.Scope.Name,
+			Version:    scope.Scope.Version,
+			SchemaURL:  scope.Scope.SchemaURL,
+			Attributes: spoolAttributes(scope.Scope.Attributes.ToSlice()),
+			Metrics:    []spooledMetric{},
+		}
+
+		for _, m := range scope.Metrics {
+			metric := spooledMetric{Name: m.Name, Description: m.Description, Unit: m.Unit}
+
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				metric.Kind, metric.Integer, metric.Temporality, metric.Monotonic = spooledSum, true, uint8(data.Temporality), data.IsMonotonic
+				metric.Points = spoolDataPoints(data.DataPoints)
+			case metricdata.Sum[float64]:
+				metric.Kind, metric.Temporality, metric.Monotonic = spooledSum, uint8(data.Temporality), data.IsMonotonic
+				metric.Points = spoolDataPoints(data.DataPoints)
+			case metricdata.Gauge[int64]:
+				metric.Kind, metric.Integer = spooledGauge, true
+				metric.Points = spoolDataPoints(data.DataPoints)
+			case metricdata.Gauge[float64]:
+				metric.Kind = spooledGauge
+				metric.Points = spoolDataPoints(data.DataPoints)
+			case metricdata.Histogram[int64]:
+				metric.Kind, metric.Integer, metric.Temporality = spooledHistogram, true, uint8(data.Temporality)
+				metric.Points = spoolHistogramPoints(data.DataPoints)
+			case metricdata.Histogram[float64]:
+				metric.Kind, metric.Temporality = spooledHistogram, uint8(data.Temporality)
+				metric.Points = spoolHistogramPoints(data.DataPoints)
+			default:
+				continue
+			}
+
+			spooledScope.Metrics = append(spooledScope.Metrics, metric)
+		}
+
+		spooled.Scopes = append(spooled.Scopes, spooledScope)
+	}
+
+	return spooled
+}
+
+// resourceMetrics decodes the spooled metrics
+func (s spooledResourceMetrics) resourceMetrics() (*metricdata.ResourceMetrics, error) {
+	attributes, err := spooledAttributes(s.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := &metricdata.ResourceMetrics{Resource: resource.NewWithAttributes(s.SchemaURL, attributes...)}
+
+	for _, spooledScope := range s.Scopes {
+		scopeAttributes, err := spooledAttributes(spooledScope.Attributes)
+		if err != nil {
+			return nil, err
+		}
+
+		scope := metricdata.ScopeMetrics{Scope: instrumentation.Scope{
+			Name:       spooledScope.Name,
+			Version:    spooledScope.Version,
+			SchemaURL:  spooledScope.SchemaURL,
+			Attributes: attribute.NewSet(scopeAttributes...),
+		}}
+
+		for _, spooled := range spooledScope.Metrics {
+			m := metricdata.Metrics{Name: spooled.Name, Description: spooled.Description, Unit: spooled.Unit}
+
+			var err error
+			switch {
+			case spooled.Kind == spooledSum && spooled.Integer:
+				data := metricdata.Sum[int64]{Temporality: metricdata.Temporality(spooled.Temporality), IsMonotonic: spooled.Monotonic}
+				data.DataPoints, err = spooledDataPoints[int64](spooled.Points)
+				m.Data = data
+			case spooled.Kind == spooledSum:
+				data := metricdata.Sum[float64]{Temporality: metricdata.Temporality(spooled.Temporality), IsMonotonic: spooled.Monotonic}
+				data.DataPoints, err = spooledDataPoints[float64](spooled.Points)
+				m.Data = data
+			case spooled.Kind == spooledGauge && spooled.Integer:
+				data := metricdata.Gauge[int64]{}
+				data.DataPoints, err = spooledDataPoints[int64](spooled.Points)
+				m.Data = data
+			case spooled.Kind == spooledGauge:
+				data := metricdata.Gauge[float64]{}
+				data.DataPoints, err = spooledDataPoints[float64](spooled.Points)
+				m.Data = data
+			case spooled.Kind == spooledHistogram && spooled.Integer:
+				data := metricdata.Histogram[int64]{Temporality: metricdata.Temporality(spooled.Temporality)}
+				data.DataPoints, err = spooledHistogramPoints[int64](spooled.Points)
+				m.Data = data
+			case spooled.Kind == spooledHistogram:
+				data := metricdata.Histogram[float64]{Temporality: metricdata.Temporality(spooled.Temporality)}
+				data.DataPoints, err = spooledHistogramPoints[float64](spooled.Points)
+				m.Data = data
+			default:
+				err = fmt.Errorf("unknown kind %q of the metric %s", spooled.Kind, spooled.Name)
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			scope.Metrics = append(scope.Metrics, m)
+		}
+
+		metrics.ScopeMetrics = append(metrics.ScopeMetrics, scope)
+	}
+
+	return metrics, nil
+}
+
+func spoolDataPoints[N int64 | float64](points []metricdata.DataPoint[N]) []spooledPoint {
+	spooled := make([]spooledPoint, 0, len(points))
+	for _, point := range points {
+		spooled = append(spooled, spooledPoint{
+			Attributes: spoolAttributes(point.Attributes.ToSlice()),
+			Start:      point.StartTime,
+			Time:       point.Time,
+			Value:      spoolNumber(point.Value),
+		})
+	}
+
+	return spooled
+}
+
+func spooledDataPoints[N int64 | float64](spooled []spooledPoint) ([]metricdata.DataPoint[N], error) {
+	points := make([]metricdata.DataPoint[N], 0, len(spooled))
+	for _, s := range spooled {
+		attributes, err := spooledAttributes(s.Attributes)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := spooledNumber[N](s.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		points = append(points, metricdata.DataPoint[N]{Attributes: attribute.NewSet(attributes...), StartTime: s.Start, Time: s.Time, Value: value})
+	}
+
+	return points, nil
+}
+
+func spoolHistogramPoints[N int64 | float64](points []metricdata.HistogramDataPoint[N]) []spooledPoint {
+	spooled := make([]spooledPoint, 0, len(points))
+	for _, point := range points {
+		s := spooledPoint{
+			Attributes:   spoolAttributes(point.Attributes.ToSlice()),
+			Start:        point.StartTime,
+			Time:         point.Time,
+			Count:        point.Count,
+			Bounds:       point.Bounds,
+			BucketCounts: point.BucketCounts,
+			Sum:          spoolNumber(point.Sum),
+		}
+		if value, ok := point.Min.Value(); ok {
+			s.Min = spoolNumber(value)
+		}
+		if value, ok := point.Max.Value(); ok {
+			s.Max = spoolNumber(value)
+		}
+
+		spooled = append(spooled, s)
+	}
+
+	return spooled
+}
+
+func spooledHistogramPoints[N int64 | float64](spooled []spooledPoint) ([]metricdata.HistogramDataPoint[N], error) {
+	points := make([]metricdata.HistogramDataPoint[N], 0, len(spooled))
+	for _, s := range spooled {
+		attributes, err := spooledAttributes(s.Attributes)
+		if err != nil {
+			return nil, err
+		}
+
+		sum, err := spooledNumber[N](s.Sum)
+		if err != nil {
+			return nil, err
+		}
+
+		point := metricdata.HistogramDataPoint[N]{
+			Attributes:   attribute.NewSet(attributes...),
+			StartTime:    s.Start,
+			Time:         s.Time,
+			Count:        s.Count,
+			Bounds:       s.Bounds,
+			BucketCounts: s.BucketCounts,
+			Sum:          sum,
+		}
+
+		if s.Min != "" {
+			value, err := spooledNumber[N](s.Min)
+			if err != nil {
+				return nil, err
+			}
+			point.Min = metricdata.NewExtrema(value)
+		}
+
+		if s.Max != "" {
+			value, err := spooledNumber[N](s.Max)
+			if err != nil {
+				return nil, err
+			}
+			point.Max = metricdata.NewExtrema(value)
+		}
+
+		points = append(points, point)
+	}
+
+	return points, nil
+}
+
+func spoolNumber[N int64 | float64](n N) json.Number {
+	if value, ok := any(n).(int64); ok {
+		return json.Number(strconv.FormatInt(value, 10))
+	}
+
+	return json.Number(strconv.FormatFloat(float64(n), 'g', -1, 64))
+}
+
+func spooledNumber[N int64 | float64](number json.Number) (N, error) {
+	if number == "" {
+		return 0, nil
+	}
+
+	var zero N
+	if _, ok := any(zero).(int64); ok {
+		value, err := number.Int64()
+		return N(value), err
+	}
+
+	value, err := number.Float64()
+	return N(value), err
+}
+
+func spoolAttributes(attrs []attribute.KeyValue) []spooledAttribute {
+	spooled := make([]spooledAttribute, 0, len(attrs))
+	for _, attr := range attrs {
+		// the values of the attributes are always encodable
+		value, _ := json.Marshal(attr.Value.AsInterface())
+		spooled = append(spooled, spooledAttribute{Key: string(attr.Key), Type: attr.Value.Type().String(), Value: value})
+	}
+
+	return spooled
+}
+
+func spooledAttributes(spooled []spooledAttribute) ([]attribute.KeyValue, error) {
+	attrs := make([]attribute.KeyValue, 0, len(spooled))
+	for _, s := range spooled {
+		var attr attribute.KeyValue
+		var err error
+
+		switch s.Type {
+		case attribute.BOOL.String():
+			var v bool
+			err = json.Unmarshal(s.Value, &v)
+			attr = attribute.Bool(s.Key, v)
+		case attribute.INT64.String():
+			var v int64
+			err = json.Unmarshal(s.Value, &v)
+			attr = attribute.Int64(s.Key, v)
+		case attribute.FLOAT64.String():
+			var v float64
+			err = json.Unmarshal(s.Value, &v)
+			attr = attribute.Float64(s.Key, v)
+		case attribute.STRING.String():
+			var v string
+			err = json.Unmarshal(s.Value, &v)
+			attr = attribute.String(s.Key, v)
+		case attribute.BOOLSLICE.String():
+			var v []bool
+			err = json.Unmarshal(s.Value, &v)
+			attr = attribute.BoolSlice(s.Key, v)
+		case attribute.INT64SLICE.String():
+			var v []int64
+			err = json.Unmarshal(s.Value, &v)
+			attr = attribute.Int64Slice(s.Key, v)
+		case attribute.FLOAT64SLICE.String():
+			var v []float64
+			err = json.Unmarshal(s.Value, &v)
+			attr = attribute.Float64Slice(s.Key, v)
+		case attribute.STRINGSLICE.String():
+			var v []string
+			err = json.Unmarshal(s.Value, &v)
+			attr = attribute.StringSlice(s.Key, v)
+		default:
+			err = fmt.Errorf("unknown type %q", s.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid attribute %s: %w", s.Key, err)
+		}
+
+		attrs = append(attrs, attr)
+	}
+
+	return attrs, nil
+}
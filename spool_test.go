@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// unavailableTraceClient a client of a collector that is down
+type unavailableTraceClient struct {
+	otlptrace.Client
+}
+
+func (c unavailableTraceClient) UploadTraces(ctx context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	return errors.New("connection refused")
+}
+
+// unavailableMetricExporter an exporter to a collector that is down
+type unavailableMetricExporter struct {
+	sdkmetric.Exporter
+}
+
+func (e unavailableMetricExporter) Export(ctx context.Context, metrics *metricdata.ResourceMetrics) error {
+	return errors.New("connection refused")
+}
+
+func testResourceMetrics() *metricdata.ResourceMetrics {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	end := start.Add(time.Minute)
+	attrs := attribute.NewSet(attribute.String(TestsSuiteName, "checkout"), attribute.StringSlice("tags", []string{"e2e"}), attribute.Bool("ci", true))
+
+	return &metricdata.ResourceMetrics{
+		Resource: resource.NewSchemaless(attribute.String("service.name", "checkout")),
+		ScopeMetrics: []metricdata.ScopeMetrics{
+			{
+				Scope: instrumentation.Scope{Name: "junit2otlp", Version: "1.0.0", Attributes: attribute.NewSet(attribute.String("report.format", "junit"))},
+				Metrics: []metricdata.Metrics{
+					{
+						Name: TotalTestsCount,
+						Unit: "{test}",
+						Data: metricdata.Sum[int64]{
+							Temporality: metricdata.CumulativeTemporality,
+							IsMonotonic: true,
+							DataPoints:  []metricdata.DataPoint[int64]{{Attributes: attrs, StartTime: start, Time: end, Value: 9007199254740993}},
+						},
+					},
+					{
+						Name: "tests.case.duration.histogram",
+						Unit: "ms",
+						Data: metricdata.Histogram[float64]{
+							Temporality: metricdata.DeltaTemporality,
+							DataPoints: []metricdata.HistogramDataPoint[float64]{{
+								Attributes:   attrs,
+								StartTime:    start,
+								Time:         end,
+								Count:        3,
+								Bounds:       []float64{5, 10},
+								BucketCounts: []uint64{1, 1, 1},
+								Min:          metricdata.NewExtrema(1.5),
+								Max:          metricdata.NewExtrema(12.25),
+								Sum:          21.75,
+							}},
+						},
+					},
+					{
+						Name: "tests.queue.depth",
+						Data: metricdata.Gauge[int64]{DataPoints: []metricdata.DataPoint[int64]{{Attributes: attrs, Time: end, Value: 4}}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestSpooledMetrics(t *testing.T) {
+	metrics := testResourceMetrics()
+
+	decoded, err := spoolResourceMetrics(metrics).resourceMetrics()
+	require.NoError(t, err)
+	require.Equal(t, metrics.Resource.Attributes(), decoded.Resource.Attributes())
+	require.Equal(t, metrics.ScopeMetrics, decoded.ScopeMetrics)
+
+	_, err = spooledResourceMetrics{Scopes: []spooledScopeMetrics{{Metrics: []spooledMetric{{Name: "a", Kind: "summary"}}}}}.resourceMetrics()
+	require.Error(t, err)
+
+	_, err = spooledAttributes([]spooledAttribute{{Key: "a", Type: "INT64", Value: []byte(`"1"`)}})
+	require.Error(t, err)
+}
+
+func TestExportSpool(t *testing.T) {
+	defer func(cfg *config, dir string, endpoint string, protocol string) {
+		appConfig, spoolDirFlag, otlpEndpointFlag, otlpProtocolFlag = cfg, dir, endpoint, protocol
+	}(appConfig, spoolDirFlag, otlpEndpointFlag, otlpProtocolFlag)
+	appConfig = &config{}
+
+	spoolDirFlag = ""
+	spool, err := getExportSpool()
+	require.NoError(t, err)
+	require.Nil(t, spool)
+
+	spoolDirFlag = filepath.Join(t.TempDir(), "spool")
+	spool, err = getExportSpool()
+	require.NoError(t, err)
+
+	t.Run("Spools the failed exports", func(t *testing.T) {
+		client := &spoolingTraceClient{Client: unavailableTraceClient{}, spool: spool}
+		// the failures are still returned, so they're counted as export errors
+		require.ErrorContains(t, client.UploadTraces(context.Background(), []*tracepb.ResourceSpans{{SchemaUrl: "https://opentelemetry.io/schemas/1.4.0"}}), "the traces are spooled in")
+
+		exporter := spoolMetrics(unavailableMetricExporter{}, spool)
+		require.ErrorContains(t, exporter.Export(context.Background(), testResourceMetrics()), "the metrics are spooled in")
+
+		traces, err := spool.files(spoolTracesExtension)
+		require.NoError(t, err)
+		require.Len(t, traces, 1)
+
+		metrics, err := spool.files(spoolMetricsExtension)
+		require.NoError(t, err)
+		require.Len(t, metrics, 1)
+	})
+
+	t.Run("Collector still down", func(t *testing.T) {
+		otlpEndpointFlag, otlpProtocolFlag = "unix://"+filepath.Join(spool.dir, "missing.sock"), otlpProtocolHTTP
+
+		require.Error(t, spool.flush(context.Background()))
+
+		traces, err := spool.files(spoolTracesExtension)
+		require.NoError(t, err)
+		require.Len(t, traces, 1)
+	})
+
+	t.Run("Claimed by another invocation", func(t *testing.T) {
+		traces, err := spool.files(spoolTracesExtension)
+		require.NoError(t, err)
+		require.Len(t, traces, 1)
+
+		// the payload claimed by another invocation is skipped, until its claim is stale
+		claimed, ok := spool.claim(traces[0])
+		require.True(t, ok)
+		_, ok = spool.claim(traces[0])
+		require.False(t, ok)
+
+		files, err := spool.files(spoolTracesExtension)
+		require.NoError(t, err)
+		require.Empty(t, files)
+
+		stale := time.Now().Add(-2 * staleClaimAge)
+		require.NoError(t, os.Chtimes(claimed, stale, stale))
+		files, err = spool.files(spoolTracesExtension)
+		require.NoError(t, err)
+		require.Equal(t, traces, files)
+	})
+
+	t.Run("Flushes the spooled exports", func(t *testing.T) {
+		socket := unixSocket(t)
+		listener, err := net.Listen("unix", socket)
+		require.NoError(t, err)
+
+		paths := make(chan string, 2)
+		server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.Copy(io.Discard, r.Body)
+			paths <- r.URL.Path
+			w.Header().Set("Content-Type", "application/x-protobuf")
+		})}
+		go server.Serve(listener)
+		defer server.Close()
+
+		// an invalid payload is set aside, without stopping the flush
+		require.NoError(t, os.WriteFile(filepath.Join(spool.dir, "0"+spoolMetricsExtension), []byte("{"), 0o600))
+
+		otlpEndpointFlag, otlpProtocolFlag = "unix://"+socket, otlpProtocolHTTP
+		require.NoError(t, spool.flush(context.Background()))
+
+		require.Equal(t, "/v1/traces", <-paths)
+		require.Equal(t, "/v1/metrics", <-paths)
+
+		entries, err := os.ReadDir(spool.dir)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		require.Equal(t, "0"+spoolMetricsExtension+invalidSpoolExtension, entries[0].Name())
+	})
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// stateVersion the version of the format of the state
+const stateVersion = 1
+
+// defaultStateHistory the default number of runs kept in the state
+const defaultStateHistory = 100
+
+// toolState the state of the tool across its runs, persisted in a state backend shared by the CI builds, i.e. the
+// source files covered by each test, and the results of the last runs
+type toolState struct {
+	Version int `json:"version"`
+	// Coverage the source files covered by each test, by the selector of the test
+	Coverage map[string][]string `json:"coverage,omitempty"`
+	// Runs the results of the last runs, from the oldest to the newest one
+	Runs []*stateRun `json:"runs,omitempty"`
+}
+
+// stateRun the results of the tests in a run, by their selectors, and the branch and the commit it ran for
+type stateRun struct {
+	Time    time.Time               `json:"time"`
+	Service string                  `json:"service,omitempty"`
+	Branch  string                  `json:"branch,omitempty"`
+	Commit  string                  `json:"commit,omitempty"`
+	Tests   map[string]*stateResult `json:"tests"`
+}
+
+// stateResult the result of a test in a run
+type stateResult struct {
+	Status     string `json:"status"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// newStateRun returns the results of the tests of the report, for the branch and the commit of the SCM context.
+// The results of the tests with the same selector, i.e. retried, are the ones of the last of them
+func newStateRun(report *junitReport, service string, scmContext *ScmContext, now time.Time) *stateRun {
+	run := &stateRun{Time: now.UTC(), Service: service, Tests: map[string]*stateResult{}}
+	if scmContext != nil {
+		run.Branch = scmContext.Branch
+		run.Commit = scmContext.Commit
+	}
+
+	for _, suite := range report.suites {
+		for _, test := range suite.Tests {
+			run.Tests[newImpactedTest(suite, test).Selector] = &stateResult{
+				Status:     string(test.Status),
+				DurationMs: test.Duration.Milliseconds(),
+			}
+		}
+	}
+
+	return run
+}
+
+// addRun adds the run to the state, keeping the last runs up to the size of the history
+func (s *toolState) addRun(run *stateRun, historySize int) {
+	s.Runs = append(s.Runs, run)
+	if historySize > 0 && len(s.Runs) > historySize {
+		s.Runs = slices.Clone(s.Runs[len(s.Runs)-historySize:])
+	}
+}
+
+// maxStateAttempts the maximum number of attempts to update the state, when other runs modify it concurrently,
+// i.e. the parallel jobs of a pipeline
+const maxStateAttempts = 10
+
+// errStateConflict the state was modified by another run since it was loaded
+var errStateConflict = errors.New("the state was modified concurrently")
+
+// stateBackend stores the state with optimistic locking: the state is saved only if it was not modified since
+// it was loaded, so the concurrent writers retry their updates on the latest state instead of overwriting it
+type stateBackend interface {
+	// load returns the encoded state and its revision, or no data and an empty revision if it does not exist
+	load(ctx context.Context) ([]byte, string, error)
+	// save stores the encoded state if its revision is still the loaded one, or returns errStateConflict
+	save(ctx context.Context, data []byte, revision string) error
+	close() error
+}
+
+// openStateBackend opens the backend of the state location: a SQLite database by default, a JSON file if its
+// extension is .json, or the URL of an object in S3 (s3://bucket/key) or GCS (gs://bucket/object), or of a Redis
+// server (redis://host:port/db?key=name, or rediss:// with TLS). The file and sqlite schemes are supported too
+func openStateBackend(location string) (stateBackend, error) {
+	scheme, rest, found := strings.Cut(location, "://")
+	if !found {
+		if strings.EqualFold(filepath.Ext(location), ".json") {
+			return newFileStateBackend(location), nil
+		}
+
+		return newSQLiteStateBackend(location)
+	}
+
+	switch strings.ToLower(scheme) {
+	case "file":
+		return newFileStateBackend(rest), nil
+	case "sqlite":
+		return newSQLiteStateBackend(rest)
+	case "s3":
+		return newS3StateBackend(rest)
+	case "gs":
+		return newGCSStateBackend(rest)
+	case "redis", "rediss":
+		return newRedisStateBackend(location)
+	default:
+		return nil, fmt.Errorf("unsupported state backend %q: the supported ones are sqlite, file, s3, gs, redis and rediss", scheme)
+	}
+}
+
+// readState reads the state of the location, returning an empty state if it does not exist yet
+func readState(ctx context.Context, location string) (*toolState, error) {
+	backend, err := openStateBackend(location)
+	if err != nil {
+		return nil, err
+	}
+	defer backend.close()
+
+	data, _, err := backend.load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the state %s: %w", location, err)
+	}
+
+	return decodeState(data, location)
+}
+
+// updateState applies the update to the state of the location, and saves it. If another run modified the state
+// in the meantime, the update is applied again to the latest state, so it must only depend on the state it gets
+func updateState(ctx context.Context, location string, update func(state *toolState) error) error {
+	backend, err := openStateBackend(location)
+	if err != nil {
+		return err
+	}
+	defer backend.close()
+
+	for attempt := 1; ; attempt++ {
+		data, revision, err := backend.load(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to load the state %s: %w", location, err)
+		}
+
+		state, err := decodeState(data, location)
+		if err != nil {
+			return err
+		}
+
+		if err := update(state); err != nil {
+			return err
+		}
+
+		data, err = encodeState(state)
+		if err != nil {
+			return err
+		}
+
+		err = backend.save(ctx, data, revision)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, errStateConflict) || attempt == maxStateAttempts {
+			return fmt.Errorf("failed to save the state %s: %w", location, err)
+		}
+
+		// the jitter spreads the retries of the jobs that finished at the same time
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt)*50*time.Millisecond + time.Duration(rand.Int64N(int64(50*time.Millisecond)))):
+		}
+	}
+}
+
+func decodeState(data []byte, location string) (*toolState, error) {
+	state := &toolState{Version: stateVersion}
+	if len(data) == 0 {
+		return state, nil
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse the state %s: %w", location, err)
+	}
+
+	if state.Version > stateVersion {
+		return nil, fmt.Errorf("the state %s has the version %d, newer than the supported one: %d", location, state.Version, stateVersion)
+	}
+
+	return state, nil
+}
+
+func encodeState(state *toolState) ([]byte, error) {
+	state.Version = stateVersion
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the state: %w", err)
+	}
+
+	return data, nil
+}
+
+// contentRevision the revision of the backends without one, the hash of the content, or empty if there is none
+func contentRevision(data []byte) string {
+	if data == nil {
+		return ""
+	}
+
+	return sha256Hex(data)
+}
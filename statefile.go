@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// stateLockTimeout the maximum time waiting for the lock of a state file, held by other runs while they save it
+const stateLockTimeout = 10 * time.Second
+
+// fileStateBackend stores the state in a local JSON file, i.e. cached between the builds. The revision of the
+// state is the hash of the file, checked while holding a lock file, so the concurrent runs on the same host do
+// not overwrite each other
+type fileStateBackend struct {
+	path string
+}
+
+func newFileStateBackend(path string) *fileStateBackend {
+	return &fileStateBackend{path: path}
+}
+
+func (b *fileStateBackend) load(ctx context.Context) ([]byte, string, error) {
+	data, err := os.ReadFile(b.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	return data, contentRevision(data), nil
+}
+
+// save writes the state file atomically, so that an interrupted run does not leave it truncated
+func (b *fileStateBackend) save(ctx context.Context, data []byte, revision string) error {
+	if err := os.MkdirAll(filepath.Dir(b.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create the directory of the state file: %w", err)
+	}
+
+	unlock, err := b.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	_, currentRevision, err := b.load(ctx)
+	if err != nil {
+		return err
+	}
+	if currentRevision != revision {
+		return errStateConflict
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(b.path), filepath.Base(b.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create the state file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write the state file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write the state file: %w", err)
+	}
+
+	if err := os.Rename(file.Name(), b.path); err != nil {
+		return fmt.Errorf("failed to write the state file: %w", err)
+	}
+
+	return nil
+}
+
+// lock creates the lock file of the state file, waiting for the other runs to remove it
+func (b *fileStateBackend) lock(ctx context.Context) (func(), error) {
+	lockPath := b.path + ".lock"
+	deadline := time.Now().Add(stateLockTimeout)
+
+	for {
+		file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("failed to lock the state file: %w", err)
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("failed to lock the state file: %s exists for more than %s, remove it if no other run is saving the state", lockPath, stateLockTimeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
+
+func (b *fileStateBackend) close() error {
+	return nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStateBackend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "state.json")
+
+	testStateBackend(t, newFileStateBackend(path))
+
+	t.Run("Locked", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path+".lock", nil, 0o644))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		_, revision, err := newFileStateBackend(path).load(ctx)
+		require.NoError(t, err, "the state is loaded while another run saves it")
+		require.ErrorIs(t, newFileStateBackend(path).save(ctx, []byte(`{"version":1}`), revision), context.DeadlineExceeded)
+
+		require.NoError(t, os.Remove(path+".lock"))
+		require.NoError(t, newFileStateBackend(path).save(context.Background(), []byte(`{"version":1}`), revision))
+	})
+}
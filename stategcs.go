@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// defaultGCSEndpoint the endpoint of the JSON API of Google Cloud Storage
+const defaultGCSEndpoint = "https://storage.googleapis.com"
+
+// gcsStateBackend stores the state in an object of Google Cloud Storage. The revision of the state is the
+// generation of the object, and the uploads are conditional on it, so they only replace the loaded generation
+type gcsStateBackend struct {
+	endpoint string
+	bucket   string
+	object   string
+	token    string
+	client   *http.Client
+}
+
+// newGCSStateBackend creates the backend of the bucket/object object, reading the OAuth access token from the
+// GOOGLE_OAUTH_ACCESS_TOKEN environment variable, i.e. the output of gcloud auth print-access-token. The endpoint
+// of an emulator can be set with the STORAGE_EMULATOR_HOST environment variable
+func newGCSStateBackend(location string) (*gcsStateBackend, error) {
+	bucket, object, _ := strings.Cut(location, "/")
+	if bucket == "" || object == "" {
+		return nil, fmt.Errorf("invalid GCS state location %q: the format is gs://bucket/object", location)
+	}
+
+	backend := &gcsStateBackend{
+		endpoint: defaultGCSEndpoint,
+		bucket:   bucket,
+		object:   object,
+		token:    firstEnv("GOOGLE_OAUTH_ACCESS_TOKEN", "CLOUDSDK_AUTH_ACCESS_TOKEN"),
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+
+	if emulator := os.Getenv("STORAGE_EMULATOR_HOST"); emulator != "" {
+		if !strings.Contains(emulator, "://") {
+			emulator = "http://" + emulator
+		}
+		backend.endpoint = strings.TrimSuffix(emulator, "/")
+	} else if backend.token == "" {
+		return nil, fmt.Errorf("the GOOGLE_OAUTH_ACCESS_TOKEN environment variable is required to store the state in GCS")
+	}
+
+	return backend, nil
+}
+
+func (b *gcsStateBackend) load(ctx context.Context) ([]byte, string, error) {
+	objectURL := fmt.Sprintf("%s/storage/v1/b/%s/o/%s?alt=media", b.endpoint, url.PathEscape(b.bucket), url.PathEscape(b.object))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, objectURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp, err := b.do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, "", nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, "", fmt.Errorf("GCS returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return body, resp.Header.Get("X-Goog-Generation"), nil
+}
+
+// save uploads the object if its generation is still the loaded one, or, without a revision, if it does not
+// exist, which is the generation 0
+func (b *gcsStateBackend) save(ctx context.Context, data []byte, revision string) error {
+	if revision == "" {
+		revision = "0"
+	}
+
+	query := url.Values{
+		"uploadType":        {"media"},
+		"name":              {b.object},
+		"ifGenerationMatch": {revision},
+	}
+	uploadURL := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?%s", b.endpoint, url.PathEscape(b.bucket), query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadURL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := b.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusPreconditionFailed:
+		return errStateConflict
+	default:
+		return fmt.Errorf("GCS returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+}
+
+func (b *gcsStateBackend) do(req *http.Request) (*http.Response, error) {
+	if b.token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.token)
+	}
+
+	return b.client.Do(req)
+}
+
+func (b *gcsStateBackend) close() error {
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGCSStateBackend(t *testing.T) {
+	mu := sync.Mutex{}
+	var object []byte
+	generation := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.EscapedPath() == "/storage/v1/b/ci-cache/o/junit2otlp%2Fstate.json" && r.URL.Query().Get("alt") == "media":
+			if object == nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("X-Goog-Generation", strconv.Itoa(generation))
+			w.Write(object)
+		case r.Method == http.MethodPost && r.URL.Path == "/upload/storage/v1/b/ci-cache/o" && r.URL.Query().Get("name") == "junit2otlp/state.json":
+			if r.URL.Query().Get("ifGenerationMatch") != strconv.Itoa(generation) {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			object, _ = io.ReadAll(r.Body)
+			generation++
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("STORAGE_EMULATOR_HOST", server.URL)
+
+	backend, err := newGCSStateBackend("ci-cache/junit2otlp/state.json")
+	require.NoError(t, err)
+	testStateBackend(t, backend)
+
+	_, err = newGCSStateBackend("ci-cache")
+	require.ErrorContains(t, err, "gs://bucket/object")
+
+	t.Setenv("STORAGE_EMULATOR_HOST", "")
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "")
+	t.Setenv("CLOUDSDK_AUTH_ACCESS_TOKEN", "")
+	_, err = newGCSStateBackend("ci-cache/state.json")
+	require.ErrorContains(t, err, "GOOGLE_OAUTH_ACCESS_TOKEN")
+}
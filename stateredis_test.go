@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis a minimal Redis server, with the commands used by the state backend
+type fakeRedis struct {
+	mu       sync.Mutex
+	values   map[string]string
+	versions map[string]int
+	password string
+}
+
+func (f *fakeRedis) serve(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.handle(conn)
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func (f *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	authenticated := f.password == ""
+	watched := map[string]int{}
+	var queued [][]string
+	inMulti := false
+
+	for {
+		args, err := readRedisCommand(reader)
+		if err != nil {
+			return
+		}
+
+		command := strings.ToUpper(args[0])
+		if !authenticated && command != "AUTH" {
+			io.WriteString(conn, "-NOAUTH Authentication required.\r\n")
+			continue
+		}
+
+		if inMulti && command != "EXEC" {
+			queued = append(queued, args)
+			io.WriteString(conn, "+QUEUED\r\n")
+			continue
+		}
+
+		f.mu.Lock()
+		switch command {
+		case "AUTH":
+			if args[len(args)-1] != f.password {
+				io.WriteString(conn, "-WRONGPASS invalid password\r\n")
+				break
+			}
+			authenticated = true
+			io.WriteString(conn, "+OK\r\n")
+		case "SELECT":
+			io.WriteString(conn, "+OK\r\n")
+		case "WATCH":
+			watched[args[1]] = f.versions[args[1]]
+			io.WriteString(conn, "+OK\r\n")
+		case "GET":
+			io.WriteString(conn, f.bulk(args[1]))
+		case "MULTI":
+			inMulti = true
+			io.WriteString(conn, "+OK\r\n")
+		case "EXEC":
+			inMulti = false
+			aborted := false
+			for key, version := range watched {
+				if f.versions[key] != version {
+					aborted = true
+				}
+			}
+			if aborted {
+				io.WriteString(conn, "*-1\r\n")
+			} else {
+				fmt.Fprintf(conn, "*%d\r\n", len(queued))
+				for _, queuedArgs := range queued {
+					f.values[queuedArgs[1]] = queuedArgs[2]
+					f.versions[queuedArgs[1]]++
+					io.WriteString(conn, "+OK\r\n")
+				}
+			}
+			queued = nil
+			watched = map[string]int{}
+		default:
+			fmt.Fprintf(conn, "-ERR unknown command '%s'\r\n", args[0])
+		}
+		f.mu.Unlock()
+	}
+}
+
+func (f *fakeRedis) bulk(key string) string {
+	value, ok := f.values[key]
+	if !ok {
+		return "$-1\r\n"
+	}
+
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+}
+
+func readRedisCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := strconv.Atoi(strings.TrimSpace(line)[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, 0, size)
+	for range size {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		length, err := strconv.Atoi(strings.TrimSpace(line)[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		data := make([]byte, length+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		args = append(args, string(data[:length]))
+	}
+
+	return args, nil
+}
+
+func TestRedisStateBackend(t *testing.T) {
+	server := &fakeRedis{values: map[string]string{}, versions: map[string]int{}, password: "secret"}
+	address := server.serve(t)
+
+	t.Run("Backend", func(t *testing.T) {
+		backend, err := newRedisStateBackend("redis://:secret@" + address + "/2?key=ci:state")
+		require.NoError(t, err)
+		require.Equal(t, 2, backend.db)
+		require.Equal(t, "ci:state", backend.key)
+
+		testStateBackend(t, backend)
+
+		require.Contains(t, server.values, "ci:state")
+	})
+
+	t.Run("Default key and port", func(t *testing.T) {
+		backend, err := newRedisStateBackend("rediss://redis.example.com")
+		require.NoError(t, err)
+		require.Equal(t, "redis.example.com:6379", backend.address)
+		require.Equal(t, defaultRedisStateKey, backend.key)
+		require.True(t, backend.tls)
+	})
+
+	t.Run("Invalid database", func(t *testing.T) {
+		_, err := newRedisStateBackend("redis://localhost/cache")
+		require.ErrorContains(t, err, "invalid Redis database")
+	})
+
+	t.Run("Wrong password", func(t *testing.T) {
+		backend, err := newRedisStateBackend("redis://:wrong@" + address)
+		require.NoError(t, err)
+
+		_, _, err = backend.load(context.Background())
+		require.ErrorContains(t, err, "WRONGPASS")
+	})
+}
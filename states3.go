@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// s3StateBackend stores the state in an object of S3, or of a compatible storage with its endpoint in the
+// AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL environment variables, i.e. MinIO. The revision of the state is the
+// ETag of the object, and the conditional writes only replace the object if it did not change
+type s3StateBackend struct {
+	objectURL       string
+	region          string
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	client          *http.Client
+}
+
+// newS3StateBackend creates the backend of the bucket/key object, with the credentials of the AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables, in the AWS_REGION region
+func newS3StateBackend(location string) (*s3StateBackend, error) {
+	bucket, key, _ := strings.Cut(location, "/")
+	if bucket == "" || key == "" {
+		return nil, fmt.Errorf("invalid S3 state location %q: the format is s3://bucket/key", location)
+	}
+
+	backend := &s3StateBackend{
+		region:          firstEnv("AWS_REGION", "AWS_DEFAULT_REGION"),
+		accessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		client:          &http.Client{Timeout: 30 * time.Second},
+	}
+	if backend.region == "" {
+		backend.region = "us-east-1"
+	}
+	if backend.accessKeyID == "" || backend.secretAccessKey == "" {
+		return nil, fmt.Errorf("the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables are required to store the state in S3")
+	}
+
+	// the compatible storages use path-style URLs, and S3 virtual-hosted ones
+	if endpoint := firstEnv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"); endpoint != "" {
+		backend.objectURL = strings.TrimSuffix(endpoint, "/") + "/" + bucket + "/" + s3EscapePath(key)
+	} else {
+		backend.objectURL = fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, backend.region, s3EscapePath(key))
+	}
+
+	return backend, nil
+}
+
+func (b *s3StateBackend) load(ctx context.Context) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.objectURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp, err := b.do(req, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, "", nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, "", fmt.Errorf("S3 returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return body, resp.Header.Get("ETag"), nil
+}
+
+func (b *s3StateBackend) save(ctx context.Context, data []byte, revision string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, b.objectURL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if revision == "" {
+		req.Header.Set("If-None-Match", "*")
+	} else {
+		req.Header.Set("If-Match", revision)
+	}
+
+	resp, err := b.do(req, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	// a concurrent conditional write returns a conflict instead of a failed precondition
+	case http.StatusPreconditionFailed, http.StatusConflict:
+		return errStateConflict
+	default:
+		return fmt.Errorf("S3 returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+}
+
+func (b *s3StateBackend) do(req *http.Request, body []byte) (*http.Response, error) {
+	signAWSRequest(req, body, b.accessKeyID, b.secretAccessKey, b.sessionToken, b.region, "s3", time.Now())
+
+	return b.client.Do(req)
+}
+
+func (b *s3StateBackend) close() error {
+	return nil
+}
+
+// signAWSRequest signs the request with the AWS Signature Version 4, including all its headers
+// see https://docs.aws.amazon.com/IAM/latest/UserGuide/reference_sigv-create-signed-request.html
+func signAWSRequest(req *http.Request, body []byte, accessKeyID string, secretAccessKey string, sessionToken string, region string, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+	names := slices.Sorted(maps.Keys(headers))
+
+	canonicalHeaders := &strings.Builder{}
+	for _, name := range names {
+		fmt.Fprintf(canonicalHeaders, "%s:%s\n", name, strings.TrimSpace(headers[name]))
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalURI := req.URL.EscapedPath()
+	if canonicalURI == "" {
+		canonicalURI = "/"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := []byte("AWS4" + secretAccessKey)
+	for _, part := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", accessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalQuery returns the query sorted by name and value, with the values encoded as AWS expects them
+func canonicalQuery(query url.Values) string {
+	pairs := []string{}
+	for name, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, s3Escape(name)+"="+s3Escape(value))
+		}
+	}
+	slices.Sort(pairs)
+
+	return strings.Join(pairs, "&")
+}
+
+// s3EscapePath encodes each segment of the key, keeping the slashes
+func s3EscapePath(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = s3Escape(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// s3Escape encodes all the characters but the unreserved ones of RFC 3986, with spaces as %20
+func s3Escape(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	// the pure Go driver keeps the binary static
+	_ "modernc.org/sqlite"
+)
+
+// sqliteStateName the name of the row of the state in the table, the only one
+const sqliteStateName = "state"
+
+// sqliteStateBackend stores the state in a SQLite database, the default backend. The revision of the state is a
+// counter incremented by each update, which only succeeds if it did not change since the state was loaded
+type sqliteStateBackend struct {
+	db *sql.DB
+}
+
+func newSQLiteStateBackend(path string) (*sqliteStateBackend, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create the directory of the state database: %w", err)
+	}
+
+	// the concurrent writers wait for the lock of the database, instead of failing right away
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the state database: %w", err)
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS junit2otlp_state (
+		name TEXT PRIMARY KEY,
+		revision INTEGER NOT NULL,
+		data BLOB NOT NULL
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create the table of the state database %s: %w", path, err)
+	}
+
+	return &sqliteStateBackend{db: db}, nil
+}
+
+func (b *sqliteStateBackend) load(ctx context.Context) ([]byte, string, error) {
+	var revision int64
+	var data []byte
+
+	err := b.db.QueryRowContext(ctx, "SELECT revision, data FROM junit2otlp_state WHERE name = ?", sqliteStateName).Scan(&revision, &data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	return data, strconv.FormatInt(revision, 10), nil
+}
+
+func (b *sqliteStateBackend) save(ctx context.Context, data []byte, revision string) error {
+	var result sql.Result
+	var err error
+
+	if revision == "" {
+		result, err = b.db.ExecContext(ctx, "INSERT INTO junit2otlp_state (name, revision, data) VALUES (?, 1, ?) ON CONFLICT (name) DO NOTHING", sqliteStateName, data)
+	} else {
+		result, err = b.db.ExecContext(ctx, "UPDATE junit2otlp_state SET revision = revision + 1, data = ? WHERE name = ? AND revision = ?", data, sqliteStateName, revision)
+	}
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errStateConflict
+	}
+
+	return nil
+}
+
+func (b *sqliteStateBackend) close() error {
+	return b.db.Close()
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLiteStateBackend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "state.db")
+
+	backend, err := newSQLiteStateBackend(path)
+	require.NoError(t, err)
+	testStateBackend(t, backend)
+
+	// the state is kept in the database
+	backend, err = newSQLiteStateBackend(path)
+	require.NoError(t, err)
+	defer backend.close()
+
+	data, revision, err := backend.load(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, `{"version":1,"coverage":{"a":["b"]}}`, string(data))
+	require.Equal(t, "2", revision)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/joshdk/go-junit"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// the span statuses of the status mapping
+const (
+	spanStatusUnset = "unset"
+	spanStatusOk    = "ok"
+	spanStatusError = "error"
+)
+
+// statusMapping maps the status of the test cases, including the ones of the failure rules, to the status of
+// their spans and the test.result attribute, as the backends alert on the span status, and the teams disagree
+// on the right mapping, i.e. for the skipped tests
+type statusMapping map[string]*statusMappingRule
+
+type statusMappingRule struct {
+	SpanStatus string `yaml:"span_status"`
+	Result     string `yaml:"result"`
+}
+
+// defaultStatusMapping only the failed tests and the errors have an error status
+var defaultStatusMapping = statusMapping{
+	string(junit.StatusPassed):  {SpanStatus: spanStatusUnset, Result: "pass"},
+	passedOnRetryStatus:         {SpanStatus: spanStatusUnset, Result: "pass"},
+	string(junit.StatusSkipped): {SpanStatus: spanStatusUnset, Result: "skip"},
+	string(junit.StatusFailed):  {SpanStatus: spanStatusError, Result: "fail"},
+	string(junit.StatusError):   {SpanStatus: spanStatusError, Result: "error"},
+}
+
+func (m statusMapping) validate() error {
+	for status, rule := range m {
+		if rule == nil {
+			return fmt.Errorf("the status %q has no mapping", status)
+		}
+
+		switch strings.ToLower(rule.SpanStatus) {
+		case "", spanStatusUnset, spanStatusOk, spanStatusError:
+		default:
+			return fmt.Errorf("invalid span status %q of the status %q: valid values are %s, %s and %s", rule.SpanStatus, status, spanStatusUnset, spanStatusOk, spanStatusError)
+		}
+	}
+
+	return nil
+}
+
+// resolve returns the span status and the result of the test status. The fields not present in the mapping are
+// the default ones, and the statuses without a default mapping, i.e. the ones of the failure rules, are unset
+// with their status as result
+func (m statusMapping) resolve(status string) (codes.Code, string) {
+	spanStatus, result := spanStatusUnset, status
+	if rule, ok := defaultStatusMapping[status]; ok {
+		spanStatus, result = rule.SpanStatus, rule.Result
+	}
+
+	if rule, ok := m[status]; ok && rule != nil {
+		if rule.SpanStatus != "" {
+			spanStatus = rule.SpanStatus
+		}
+		if rule.Result != "" {
+			result = rule.Result
+		}
+	}
+
+	switch strings.ToLower(spanStatus) {
+	case spanStatusOk:
+		return codes.Ok, result
+	case spanStatusError:
+		return codes.Error, result
+	default:
+		return codes.Unset, result
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestStatusMapping(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		mapping := statusMapping(nil)
+
+		tests := []struct {
+			status string
+			code   codes.Code
+			result string
+		}{
+			{"passed", codes.Unset, "pass"},
+			{"skipped", codes.Unset, "skip"},
+			{"failed", codes.Error, "fail"},
+			{"error", codes.Error, "error"},
+			{"quarantined", codes.Unset, "quarantined"},
+		}
+
+		for _, test := range tests {
+			code, result := mapping.resolve(test.status)
+			require.Equal(t, test.code, code, test.status)
+			require.Equal(t, test.result, result, test.status)
+		}
+	})
+
+	t.Run("Config file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yml")
+		require.NoError(t, os.WriteFile(path, []byte(`
+status_mapping:
+  passed:
+    span_status: ok
+  skipped:
+    span_status: ok
+    result: ignored
+  error:
+    result: broken
+  quarantined:
+    span_status: error
+`), 0o644))
+
+		cfg, err := loadConfig(path)
+		require.NoError(t, err)
+
+		code, result := cfg.StatusMapping.resolve("passed")
+		require.Equal(t, codes.Ok, code)
+		require.Equal(t, "pass", result)
+
+		code, result = cfg.StatusMapping.resolve("skipped")
+		require.Equal(t, codes.Ok, code)
+		require.Equal(t, "ignored", result)
+
+		code, result = cfg.StatusMapping.resolve("error")
+		require.Equal(t, codes.Error, code)
+		require.Equal(t, "broken", result)
+
+		code, result = cfg.StatusMapping.resolve("quarantined")
+		require.Equal(t, codes.Error, code)
+		require.Equal(t, "quarantined", result)
+
+		require.NoError(t, os.WriteFile(path, []byte("status_mapping:\n  failed:\n    span_status: red\n"), 0o644))
+		_, err = loadConfig(path)
+		require.ErrorContains(t, err, `invalid span status "red" of the status "failed"`)
+	})
+
+	t.Run("Test spans", func(t *testing.T) {
+		exporter := tracetest.NewInMemoryExporter()
+		tracer := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)).Tracer("test")
+		meter := sdkmetric.NewMeterProvider().Meter("test")
+
+		suite := junit.Suite{
+			Name: "a",
+			Tests: []junit.Test{
+				{Name: "passed", Duration: time.Second, Status: junit.StatusPassed},
+				{Name: "failed", Duration: time.Second, Status: junit.StatusFailed, Message: "expected 1, got 2", Error: junit.Error{Type: "AssertionError", Message: "expected 1, got 2", Body: "at Test.failed"}},
+			},
+		}
+
+		createSuiteSpans(context.Background(), tracer, newTestCaseMetrics(meter, 0), suite, nil, nil, nil, time.Time{})
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 3)
+		require.Equal(t, sdktrace.Status{Code: codes.Unset}, spans[0].Status)
+		require.Contains(t, spans[0].Attributes, attribute.String(TestResult, "pass"))
+		require.Equal(t, sdktrace.Status{Code: codes.Error, Description: "expected 1, got 2"}, spans[1].Status)
+		require.Contains(t, spans[1].Attributes, attribute.String(TestResult, "fail"))
+		require.Contains(t, spans[1].Attributes, attribute.String("exception.type", "AssertionError"))
+		require.Len(t, spans[1].Events, 1)
+		require.Equal(t, "exception", spans[1].Events[0].Name)
+		require.Contains(t, spans[1].Events[0].Attributes, attribute.String("exception.stacktrace", "at Test.failed"))
+		require.Empty(t, spans[0].Events)
+	})
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// maxStepSummaryFailures the maximum number of failures in the job summary, which GitHub limits to 1MiB per step
+const maxStepSummaryFailures = 50
+
+// maxStepSummaryMessage the maximum length of the messages of the failures in the job summary
+const maxStepSummaryMessage = 200
+
+// getStepSummaryPath returns the job summary file of the GitHub Actions step, or empty if it's disabled or the
+// tool does not run in GitHub Actions
+func getStepSummaryPath() string {
+	if !stepSummaryFlag {
+		return ""
+	}
+
+	return os.Getenv("GITHUB_STEP_SUMMARY")
+}
+
+// stepSummaryWriter appends a Markdown summary of the run to the job summary of GitHub Actions, with the counts,
+// the failures with their messages, and the links to the traces, so the results are visible in the pull request
+type stepSummaryWriter struct {
+	htmlReportWriter
+}
+
+func newStepSummaryWriter(path string, traceURL string) *stepSummaryWriter {
+	return &stepSummaryWriter{htmlReportWriter{path: path, traceURL: traceURL}}
+}
+
+// close appends the summary to the file, as the previous steps of the job may have written their own
+func (w *stepSummaryWriter) close(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	slices.SortStableFunc(w.rows, func(a, b map[string]any) int {
+		return compareRowValues(a[rowTimestamp], b[rowTimestamp])
+	})
+
+	report := w.report()
+
+	file, err := os.OpenFile(w.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open the job summary: %w", err)
+	}
+	defer file.Close()
+
+	if err := stepSummaryTemplate.Execute(file, report); err != nil {
+		return fmt.Errorf("failed to write the job summary %s: %w", w.path, err)
+	}
+
+	return file.Close()
+}
+
+// markdownCell escapes the text for a cell of a Markdown table, in a single line, truncated to the maximum length
+func markdownCell(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) > maxStepSummaryMessage {
+		text = string([]rune(text)[:maxStepSummaryMessage]) + "…"
+	}
+
+	return strings.NewReplacer(`\`, `\\`, "|", `\|`, "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+var stepSummaryTemplate = template.Must(template.New("summary").Funcs(template.FuncMap{
+	"percent": func(ratio float64) string { return fmt.Sprintf("%.1f%%", ratio*100) },
+	"cell":    markdownCell,
+	"first": func(failures []htmlTest) []htmlTest {
+		return failures[:min(len(failures), maxStepSummaryFailures)]
+	},
+	"more": func(failures []htmlTest) int { return max(len(failures)-maxStepSummaryFailures, 0) },
+}).Parse(`### {{if or .Summary.Failed .Summary.Errors}}❌{{else}}✅{{end}} {{cell .Title}} test results
+
+| Tests | Passed | Failed | Errors | Skipped | Pass rate | Duration |
+| ----- | ------ | ------ | ------ | ------- | --------- | -------- |
+| {{.Summary.Total}} | {{.Summary.Passed}} | {{.Summary.Failed}} | {{.Summary.Errors}} | {{.Summary.Skipped}} | {{percent .Summary.PassRate}} | {{.Summary.Duration}} |
+{{range .TraceURLs}}
+[View the trace]({{.}})
+{{end}}{{if .Failures}}
+| Status | Suite | Test | Message |
+| ------ | ----- | ---- | ------- |
+{{range first .Failures}}| {{cell .Status}} | {{cell .Suite}} | {{if .URL}}[{{cell .Name}}]({{.URL}}){{else}}{{cell .Name}}{{end}} | {{cell .Message}} |
+{{end}}{{with more .Failures}}
+And {{.}} more failures.
+{{end}}{{end}}
+`))
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepSummaryWriter(t *testing.T) {
+	rows := []map[string]any{
+		{
+			rowTimestamp: "2024-01-01T12:00:00Z", rowTraceID: "0af7651916cd43dd8448eb211c80319c", rowSpanID: "b7ad6b7169203331",
+			rowName: "TestLogin", rowDurationMs: int64(1500), serviceNameColumn: "checkout",
+			flattenKey(TestsSuiteName): "auth", flattenKey(TestStatus): "passed",
+		},
+		{
+			rowTimestamp: "2024-01-01T12:00:01Z", rowTraceID: "0af7651916cd43dd8448eb211c80319c", rowSpanID: "00f067aa0ba902b7",
+			rowName: "TestPayment", rowDurationMs: int64(2000), serviceNameColumn: "checkout",
+			flattenKey(TestsSuiteName): "cart", flattenKey(TestStatus): "failed",
+			flattenKey(TestMessage): "expected <200> | got\n<500>",
+		},
+	}
+
+	t.Run("Appended to the job summary", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "summary.md")
+		require.NoError(t, os.WriteFile(path, []byte("## Build\n"), 0o644))
+
+		writer := newStepSummaryWriter(path, "https://jaeger.example.com/trace/{trace_id}?uiFind={span_id}")
+		require.NoError(t, writer.writeRows(context.Background(), rows))
+		require.NoError(t, writer.close(context.Background()))
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		summary := string(data)
+
+		require.True(t, strings.HasPrefix(summary, "## Build\n### ❌ checkout test results\n"))
+		require.Contains(t, summary, "| 2 | 1 | 1 | 0 | 0 | 50.0% | 3.5s |")
+		require.Contains(t, summary, "[View the trace](https://jaeger.example.com/trace/0af7651916cd43dd8448eb211c80319c?uiFind=)")
+		require.Contains(t, summary, `| failed | cart | [TestPayment](https://jaeger.example.com/trace/0af7651916cd43dd8448eb211c80319c?uiFind=00f067aa0ba902b7) | expected &lt;200&gt; \| got &lt;500&gt; |`)
+		require.NotContains(t, summary, "TestLogin")
+	})
+
+	t.Run("Many failures", func(t *testing.T) {
+		failures := []map[string]any{}
+		for range maxStepSummaryFailures + 2 {
+			failures = append(failures, map[string]any{rowName: "TestFlaky", flattenKey(TestStatus): "error"})
+		}
+
+		path := filepath.Join(t.TempDir(), "summary.md")
+		writer := newStepSummaryWriter(path, "")
+		require.NoError(t, writer.writeRows(context.Background(), failures))
+		require.NoError(t, writer.close(context.Background()))
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, maxStepSummaryFailures, strings.Count(string(data), "| error |"))
+		require.Contains(t, string(data), "And 2 more failures.")
+	})
+
+	t.Run("Enabled in GitHub Actions", func(t *testing.T) {
+		t.Setenv("GITHUB_STEP_SUMMARY", "/tmp/summary.md")
+		require.Equal(t, "/tmp/summary.md", getStepSummaryPath())
+
+		defer func() { stepSummaryFlag = true }()
+		stepSummaryFlag = false
+		require.Empty(t, getStepSummaryPath())
+	})
+
+	require.Equal(t, strings.Repeat("a", maxStepSummaryMessage)+"…", markdownCell(strings.Repeat("a", maxStepSummaryMessage+1)))
+}
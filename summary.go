@@ -0,0 +1,102 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// runSummary the aggregates of a test run, computed from the test cases in the report
+type runSummary struct {
+	Duration     time.Duration
+	Errors       int
+	Failed       int
+	Inconsistent bool
+	Passed       int
+	// PassedOnRetry the passed tests that failed in previous attempts, included in the passed ones
+	PassedOnRetry int
+	Skipped       int
+	Suites        int
+	Total         int
+}
+
+// PassRate the ratio of passed tests over the executed tests, skipped tests not included
+func (s runSummary) PassRate() float64 {
+	executed := s.Total - s.Skipped
+	if executed <= 0 {
+		return 0
+	}
+
+	return float64(s.Passed) / float64(executed)
+}
+
+func (s runSummary) attributes() []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.Key(TestsRunDuration).Int64(s.Duration.Milliseconds()),
+		attribute.Key(TestsRunError).Int(s.Errors),
+		attribute.Key(TestsRunFailed).Int(s.Failed),
+		attribute.Key(TestsRunPassed).Int(s.Passed),
+		attribute.Key(TestsRunPassedOnRetry).Int(s.PassedOnRetry),
+		attribute.Key(TestsRunPassRate).Float64(s.PassRate()),
+		attribute.Key(TestsRunSkipped).Int(s.Skipped),
+		attribute.Key(TestsRunSuites).Int(s.Suites),
+		attribute.Key(TestsRunTotal).Int(s.Total),
+		attribute.Key(ReportInconsistent).Bool(s.Inconsistent),
+	}
+}
+
+// summarize computes the aggregates of the report from its test cases, flagging the report as inconsistent
+// when the totals declared by any suite in the XML disagree with the ones computed from its test cases
+func summarize(report *junitReport) runSummary {
+	summary := runSummary{}
+
+	for i, suite := range report.suites {
+		// go-junit computes the totals from the test cases, including the nested suites
+		totals := suite.Totals
+
+		summary.Suites++
+		summary.Total += totals.Tests
+		summary.Passed += totals.Passed
+		summary.PassedOnRetry += countPassedOnRetry(suite)
+		summary.Failed += totals.Failed
+		summary.Errors += totals.Error
+		summary.Skipped += totals.Skipped
+		summary.Duration += totals.Duration
+
+		if !suiteIsConsistent(report.rawSuite(i), totals.Tests, totals.Failed, totals.Error, totals.Skipped) {
+			summary.Inconsistent = true
+		}
+	}
+
+	return summary
+}
+
+// suiteIsConsistent checks the totals declared in the attributes of the suite element against the computed ones.
+// Missing or unparseable attributes are not considered inconsistent, as most of them are optional
+func suiteIsConsistent(rawSuite *xmlElement, tests int, failed int, errors int, skipped int) bool {
+	expected := map[string]int{
+		"tests":    tests,
+		"failures": failed,
+		"errors":   errors,
+		"skipped":  skipped,
+	}
+
+	for attr, computed := range expected {
+		value, ok := rawSuite.Attr(attr)
+		if !ok {
+			continue
+		}
+
+		declared, err := strconv.Atoi(value)
+		if err != nil {
+			continue
+		}
+
+		if declared != computed {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarize(t *testing.T) {
+	t.Run("Consistent report", func(t *testing.T) {
+		data, err := os.ReadFile("TEST-sample.xml")
+		require.NoError(t, err)
+
+		report, err := ingestReport(data)
+		require.NoError(t, err)
+
+		summary := summarize(report)
+		require.Equal(t, 3, summary.Suites)
+		require.Equal(t, 11, summary.Total)
+		require.Equal(t, 11, summary.Passed)
+		require.Equal(t, 1.0, summary.PassRate())
+		require.False(t, summary.Inconsistent)
+	})
+
+	t.Run("Inconsistent report", func(t *testing.T) {
+		data := []byte(`<testsuites>
+	<testsuite name="suite" tests="3" failures="0">
+		<properties><property name="foo" value="bar"/></properties>
+		<testcase name="passed" time="1.5"/>
+		<testcase name="failed" time="0.5"><failure message="boom"/></testcase>
+		<testcase name="skipped"><skipped/></testcase>
+	</testsuite>
+</testsuites>`)
+
+		report, err := ingestReport(data)
+		require.NoError(t, err)
+
+		summary := summarize(report)
+		require.Equal(t, 3, summary.Total)
+		require.Equal(t, 1, summary.Passed)
+		require.Equal(t, 1, summary.Failed)
+		require.Equal(t, 1, summary.Skipped)
+		require.Equal(t, int64(2000), summary.Duration.Milliseconds())
+		require.Equal(t, 0.5, summary.PassRate())
+		require.True(t, summary.Inconsistent)
+	})
+}
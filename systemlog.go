@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"runtime"
+	"strings"
+
+	"github.com/coreos/go-systemd/v22/journal"
+)
+
+const (
+	systemLogNone     = "none"
+	systemLogAuto     = "auto"
+	systemLogJournald = "journald"
+	systemLogEventLog = "eventlog"
+)
+
+// systemLogIdentifier the identifier of the tool in the system logs: the syslog identifier of the journal entries,
+// and the source of the events of Windows
+const systemLogIdentifier = "junit2otlp"
+
+// systemLogger a logger of the operating system, where the failures of the exports are signaled, so the monitoring of
+// the nodes catches a broken telemetry pipeline even when the jobs running the tests pass
+type systemLogger interface {
+	logExportFailure(message string, fields map[string]string) error
+}
+
+// newSystemLogger returns the system logger of the flag, or nil if the failures are not signaled. Auto picks the
+// Event Log on Windows, and the journal of systemd if it's running, signaling nothing without any of them
+func newSystemLogger(kind string) (systemLogger, error) {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "", systemLogNone:
+		return nil, nil
+	case systemLogAuto:
+		if runtime.GOOS == "windows" {
+			return newEventLogger()
+		}
+
+		if journal.Enabled() {
+			return journaldLogger{}, nil
+		}
+
+		return nil, nil
+	case systemLogJournald:
+		if !journal.Enabled() {
+			return nil, errors.New("the journal of systemd is not available")
+		}
+
+		return journaldLogger{}, nil
+	case systemLogEventLog:
+		return newEventLogger()
+	default:
+		return nil, fmt.Errorf("invalid system log %q: valid values are %s, %s, %s and %s", kind, systemLogNone, systemLogAuto, systemLogJournald, systemLogEventLog)
+	}
+}
+
+// journaldLogger sends the export failures to the journal of systemd, with an error priority
+type journaldLogger struct{}
+
+func (journaldLogger) logExportFailure(message string, fields map[string]string) error {
+	vars := map[string]string{"SYSLOG_IDENTIFIER": systemLogIdentifier}
+	for name, value := range fields {
+		vars[name] = value
+	}
+
+	return journal.Send(message, journal.PriErr, vars)
+}
+
+// signalExportFailure logs the failure of the exports to the system logger, if any, in a single entry with the
+// service of the tests, so an outage of the collector is one alert per job. The failure of the logger itself is only
+// logged, as the exports already failed
+func signalExportFailure(logger systemLogger, service string, err error) {
+	if logger == nil || err == nil {
+		return
+	}
+
+	message := fmt.Sprintf("failed to export the telemetry of the tests of %s: %v", service, err)
+	fields := map[string]string{"JUNIT2OTLP_SERVICE": service}
+	if err := logger.logExportFailure(message, fields); err != nil {
+		log.Printf("the export failure could not be logged to the system log: %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSystemLogger records the entries logged to the system log
+type recordingSystemLogger struct {
+	messages []string
+	fields   []map[string]string
+	err      error
+}
+
+func (l *recordingSystemLogger) logExportFailure(message string, fields map[string]string) error {
+	l.messages = append(l.messages, message)
+	l.fields = append(l.fields, fields)
+	return l.err
+}
+
+func TestSystemLog(t *testing.T) {
+	t.Run("Validates the system logger", func(t *testing.T) {
+		logger, err := newSystemLogger(systemLogNone)
+		require.NoError(t, err)
+		require.Nil(t, logger)
+
+		logger, err = newSystemLogger("")
+		require.NoError(t, err)
+		require.Nil(t, logger)
+
+		_, err = newSystemLogger("syslog")
+		require.ErrorContains(t, err, `invalid system log "syslog": valid values are none, auto, journald and eventlog`)
+
+		if runtime.GOOS != "windows" {
+			_, err = newSystemLogger(systemLogEventLog)
+			require.ErrorContains(t, err, "only available on Windows")
+		}
+	})
+
+	t.Run("Logs a single entry for the failures of the exports", func(t *testing.T) {
+		diagnostics := &exportDiagnostics{}
+		diagnostics.Handle(errors.New("traces export: connection refused"))
+		diagnostics.Handle(errors.New("metrics export: connection refused"))
+
+		logger := &recordingSystemLogger{}
+		signalExportFailure(logger, "shop", diagnostics.err())
+
+		require.Equal(t, []string{"failed to export the telemetry of the tests of shop: traces export: connection refused\nmetrics export: connection refused"}, logger.messages)
+		require.Equal(t, []map[string]string{{"JUNIT2OTLP_SERVICE": "shop"}}, logger.fields)
+	})
+
+	t.Run("Logs the rejections of the collector", func(t *testing.T) {
+		diagnostics := &exportDiagnostics{}
+		diagnostics.Handle(fmt.Errorf("OTLP partial success: quota exceeded (3 spans rejected)"))
+
+		logger := &recordingSystemLogger{}
+		signalExportFailure(logger, "shop", diagnostics.err())
+		require.Equal(t, []string{"failed to export the telemetry of the tests of shop: the collector rejected 3 spans and 0 metric data points: quota exceeded"}, logger.messages)
+	})
+
+	t.Run("Logs nothing without failures", func(t *testing.T) {
+		logger := &recordingSystemLogger{}
+		signalExportFailure(logger, "shop", (&exportDiagnostics{}).err())
+		require.Empty(t, logger.messages)
+
+		// without a system logger
+		signalExportFailure(nil, "shop", errors.New("connection refused"))
+	})
+
+	t.Run("Ignores the failures of the system logger", func(t *testing.T) {
+		logger := &recordingSystemLogger{err: errors.New("permission denied")}
+		signalExportFailure(logger, "shop", errors.New("connection refused"))
+		require.Len(t, logger.messages, 1)
+	})
+}
@@ -0,0 +1,31 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// exportFailureEventID the ID of the events of the export failures
+const exportFailureEventID = 1
+
+// eventLogger writes the export failures as error events of the Application log of Windows
+type eventLogger struct {
+	log *eventlog.Log
+}
+
+func newEventLogger() (systemLogger, error) {
+	l, err := eventlog.Open(systemLogIdentifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the Windows Event Log: %w", err)
+	}
+
+	return &eventLogger{log: l}, nil
+}
+
+// logExportFailure writes the message as an error event, without the fields, which the events have no place for
+func (l *eventLogger) logExportFailure(message string, fields map[string]string) error {
+	return l.log.Error(exportFailureEventID, message)
+}
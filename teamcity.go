@@ -0,0 +1,367 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/joshdk/go-junit"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const teamcityCommand = "teamcity"
+
+// teamcityMessagePrefix the prefix of the service messages of TeamCity, written by the build tools to their
+// output, i.e. Gradle, Maven, Jest or pytest with their TeamCity reporters
+const teamcityMessagePrefix = "##teamcity["
+
+// teamcityTimestampLayout the layout of the timestamps of the service messages, i.e. 2008-09-03T14:02:34.287+0300
+const teamcityTimestampLayout = "2006-01-02T15:04:05.000-0700"
+
+// teamcityDefaultSuite the suite of the test cases reported outside of any suite
+const teamcityDefaultSuite = "teamcity"
+
+// teamcityMessage a service message of TeamCity: ##teamcity[name key='value' ...], or ##teamcity[name 'value']
+// for the messages with a single value
+type teamcityMessage struct {
+	name       string
+	value      string
+	attributes map[string]string
+}
+
+// parseTeamCityMessage parses the service message in the line, returning false if the line is not one
+func parseTeamCityMessage(line string) (teamcityMessage, bool) {
+	start := strings.Index(line, teamcityMessagePrefix)
+	end := strings.LastIndex(line, "]")
+	if start < 0 || end < start+len(teamcityMessagePrefix) {
+		return teamcityMessage{}, false
+	}
+	content := strings.TrimSpace(line[start+len(teamcityMessagePrefix) : end])
+
+	name, rest, _ := strings.Cut(content, " ")
+	if name == "" {
+		return teamcityMessage{}, false
+	}
+	message := teamcityMessage{name: name, attributes: map[string]string{}}
+
+	rest = strings.TrimSpace(rest)
+	if strings.HasPrefix(rest, "'") {
+		value, _, ok := readTeamCityValue(rest)
+		if !ok {
+			return teamcityMessage{}, false
+		}
+		message.value = value
+
+		return message, true
+	}
+
+	for rest != "" {
+		key, remaining, found := strings.Cut(rest, "=")
+		if !found || !strings.HasPrefix(remaining, "'") {
+			return teamcityMessage{}, false
+		}
+
+		value, remaining, ok := readTeamCityValue(remaining)
+		if !ok {
+			return teamcityMessage{}, false
+		}
+
+		message.attributes[strings.TrimSpace(key)] = value
+		rest = strings.TrimSpace(remaining)
+	}
+
+	return message, true
+}
+
+// readTeamCityValue reads the quoted value at the start of the string, unescaping it, and returns the rest
+func readTeamCityValue(s string) (string, string, bool) {
+	builder := strings.Builder{}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\'':
+			return builder.String(), s[i+1:], true
+		case '|':
+			i++
+			if i == len(s) {
+				return "", "", false
+			}
+
+			switch s[i] {
+			case 'n':
+				builder.WriteByte('\n')
+			case 'r':
+				builder.WriteByte('\r')
+			case 'x':
+				builder.WriteRune('\u0085')
+			case 'l':
+				builder.WriteRune('\u2028')
+			case 'p':
+				builder.WriteRune('\u2029')
+			case '0':
+				// the characters escaped by their code, as |0x00E9
+				if i+6 <= len(s) && s[i+1] == 'x' {
+					if code, err := strconv.ParseUint(s[i+2:i+6], 16, 32); err == nil {
+						builder.WriteRune(rune(code))
+						i += 5
+						continue
+					}
+				}
+				builder.WriteByte(s[i])
+			default:
+				// the quote, the brackets and the vertical bar
+				builder.WriteByte(s[i])
+			}
+		default:
+			builder.WriteByte(s[i])
+		}
+	}
+
+	return "", "", false
+}
+
+// timestamp returns the time of the message, or the current time if it has none
+func (m teamcityMessage) timestamp() time.Time {
+	if value := m.attributes["timestamp"]; value != "" {
+		if t, err := time.Parse(teamcityTimestampLayout, value); err == nil {
+			return t
+		}
+	}
+
+	return time.Now()
+}
+
+// teamcityTest a test case started and not finished yet
+type teamcityTest struct {
+	suite   string
+	test    junit.Test
+	start   time.Time
+	capture bool
+}
+
+// teamcityFlow the suites and the test case of a flow of the stream: the parallel tests report their messages
+// in distinct flows, identified by the flowId attribute
+type teamcityFlow struct {
+	suites []string
+	test   *teamcityTest
+}
+
+// teamcityStream converts the service messages of TeamCity into the spans of the live server, as the suites and
+// the test cases start and finish, with the timestamps of the messages
+type teamcityStream struct {
+	server *liveServer
+	output io.Writer
+	flows  map[string]*teamcityFlow
+}
+
+func newTeamCityStream(server *liveServer, output io.Writer) *teamcityStream {
+	return &teamcityStream{server: server, output: output, flows: map[string]*teamcityFlow{}}
+}
+
+// consume reads the output of the build, converting its service messages, and writing the other lines to the
+// output, so the log of the build is kept. The lines written by a test case capturing the standard output are
+// its output. The lines are read whatever their length, as a long line of the build must not end the stream
+func (ts *teamcityStream) consume(r io.Reader) error {
+	reader := bufio.NewReader(r)
+
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+
+			if message, ok := parseTeamCityMessage(line); ok {
+				ts.handle(message)
+			} else {
+				ts.writeOutput(line)
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// writeOutput writes the line to the output, or to the standard output of the test case capturing it
+func (ts *teamcityStream) writeOutput(line string) {
+	ts.server.mu.Lock()
+	defer ts.server.mu.Unlock()
+
+	for _, flow := range ts.flows {
+		if flow.test != nil && flow.test.capture {
+			flow.test.test.SystemOut += line + "\n"
+			return
+		}
+	}
+
+	if ts.output != nil {
+		fmt.Fprintln(ts.output, line)
+	}
+}
+
+// handle processes a service message, ignoring the ones not about the tests, i.e. the build statistics
+func (ts *teamcityStream) handle(message teamcityMessage) {
+	ts.server.mu.Lock()
+	defer ts.server.mu.Unlock()
+
+	flowID := message.attributes["flowId"]
+	flow, ok := ts.flows[flowID]
+	if !ok {
+		flow = &teamcityFlow{}
+		ts.flows[flowID] = flow
+	}
+
+	name := message.attributes["name"]
+
+	switch message.name {
+	case "flowStarted":
+		// the flow runs within the suites of its parent flow
+		if parent, ok := ts.flows[message.attributes["parent"]]; ok && parent != flow {
+			flow.suites = slices.Clone(parent.suites)
+		}
+	case "flowFinished":
+		ts.finishTest(flow, message.timestamp(), "")
+		delete(ts.flows, flowID)
+	case "testSuiteStarted":
+		flow.suites = append(flow.suites, name)
+		ts.server.startSuite(name, message.timestamp())
+	case "testSuiteFinished":
+		ts.finishTest(flow, message.timestamp(), "")
+		if i := len(flow.suites) - 1; i >= 0 && flow.suites[i] == name {
+			flow.suites = flow.suites[:i]
+		}
+		ts.server.endSuite(name, trace.WithTimestamp(message.timestamp()))
+	case "testStarted":
+		ts.startTest(flow, name, message).capture = message.attributes["captureStandardOutput"] == "true"
+	case "testFailed":
+		test := ts.currentTest(flow, name, message)
+		test.test.Status = junit.StatusFailed
+		test.test.Message = message.attributes["message"]
+		test.test.Error = junit.Error{Message: message.attributes["message"], Body: teamcityFailureDetails(message)}
+	case "testIgnored":
+		test := ts.currentTest(flow, name, message)
+		test.test.Status = junit.StatusSkipped
+		test.test.Message = message.attributes["message"]
+	case "testStdOut":
+		ts.currentTest(flow, name, message).test.SystemOut += message.attributes["out"]
+	case "testStdErr":
+		ts.currentTest(flow, name, message).test.SystemErr += message.attributes["out"]
+	case "testFinished":
+		ts.currentTest(flow, name, message)
+		ts.finishTest(flow, message.timestamp(), message.attributes["duration"])
+	}
+}
+
+// currentTest returns the started test case of the flow, starting it if the message is reported without its start,
+// i.e. the test cases ignored without running
+func (ts *teamcityStream) currentTest(flow *teamcityFlow, name string, message teamcityMessage) *teamcityTest {
+	if flow.test != nil && flow.test.test.Name == name {
+		return flow.test
+	}
+
+	return ts.startTest(flow, name, message)
+}
+
+// startTest starts the test case in the innermost suite of the flow, finishing the test case started before
+func (ts *teamcityStream) startTest(flow *teamcityFlow, name string, message teamcityMessage) *teamcityTest {
+	ts.finishTest(flow, message.timestamp(), "")
+
+	suite := teamcityDefaultSuite
+	if len(flow.suites) > 0 {
+		suite = flow.suites[len(flow.suites)-1]
+	}
+
+	flow.test = &teamcityTest{
+		suite: suite,
+		test:  junit.Test{Name: name, Classname: suite, Status: junit.StatusPassed},
+		start: message.timestamp(),
+	}
+
+	return flow.test
+}
+
+// finishTest creates the span of the test case of the flow, if any, ending at the end time. Its duration is the
+// one reported in milliseconds, or the time since it started
+func (ts *teamcityStream) finishTest(flow *teamcityFlow, endTime time.Time, duration string) {
+	if flow.test == nil {
+		return
+	}
+	test := flow.test
+	flow.test = nil
+
+	test.test.Duration = max(endTime.Sub(test.start), 0)
+	if ms, err := strconv.ParseInt(duration, 10, 64); err == nil && ms >= 0 {
+		test.test.Duration = time.Duration(ms) * time.Millisecond
+	}
+
+	ts.server.addTest(test.suite, test.test, endTime)
+}
+
+// teamcityFailureDetails returns the details of the failure, with the expected and actual values of the
+// comparison failures
+func teamcityFailureDetails(message teamcityMessage) string {
+	details := message.attributes["details"]
+	if message.attributes["type"] == "comparisonFailure" {
+		details = fmt.Sprintf("expected: %s\nactual: %s\n%s", message.attributes["expected"], message.attributes["actual"], details)
+	}
+
+	return details
+}
+
+// runTeamCity parses the flags of the teamcity command and converts the service messages read from the standard
+// input until it's closed, or the process is interrupted, exporting the span of each test case as it finishes
+func runTeamCity(args []string) {
+	if err := resolveFlags(flag.CommandLine, args); err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := serveTeamCity(ctx, os.Stdin, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func serveTeamCity(ctx context.Context, input io.Reader, output io.Writer) error {
+	return runLiveServer(ctx, func(server *liveServer) error {
+		stream := newTeamCityStream(server, output)
+
+		done := make(chan error, 1)
+		go func() {
+			done <- stream.consume(input)
+		}()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				return fmt.Errorf("failed to read the service messages: %w", err)
+			}
+		case <-ctx.Done():
+			log.Printf("interrupted, ending the TeamCity run")
+		}
+
+		// the test cases not finished when the stream ends are still exported
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		for _, flowID := range slices.Sorted(maps.Keys(stream.flows)) {
+			stream.finishTest(stream.flows[flowID], time.Now(), "")
+		}
+
+		return nil
+	})
+}
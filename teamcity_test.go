@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestParseTeamCityMessage(t *testing.T) {
+	t.Run("Attributes", func(t *testing.T) {
+		message, ok := parseTeamCityMessage(`##teamcity[testFailed name='should |'render|' |[1|]' message='a|nb' details='x||y |0x00e9' flowId='1']`)
+		require.True(t, ok)
+		require.Equal(t, "testFailed", message.name)
+		require.Equal(t, map[string]string{
+			"name":    "should 'render' [1]",
+			"message": "a\nb",
+			"details": "x|y é",
+			"flowId":  "1",
+		}, message.attributes)
+	})
+
+	t.Run("Single value", func(t *testing.T) {
+		message, ok := parseTeamCityMessage(`[Gradle] ##teamcity[progressMessage 'Running tests']`)
+		require.True(t, ok)
+		require.Equal(t, "progressMessage", message.name)
+		require.Equal(t, "Running tests", message.value)
+	})
+
+	t.Run("Not a service message", func(t *testing.T) {
+		for _, line := range []string{
+			"BUILD SUCCESSFUL in 12s",
+			"##teamcity[]",
+			"##teamcity[testStarted name='unterminated]",
+			"##teamcity[testStarted name=unquoted]",
+		} {
+			_, ok := parseTeamCityMessage(line)
+			require.False(t, ok, line)
+		}
+	})
+
+	t.Run("Timestamps", func(t *testing.T) {
+		message, ok := parseTeamCityMessage(`##teamcity[testStarted name='a' timestamp='2008-09-03T14:02:34.287+0300']`)
+		require.True(t, ok)
+		require.Equal(t, time.Date(2008, 9, 3, 11, 2, 34, 287000000, time.UTC), message.timestamp().UTC())
+	})
+}
+
+func TestTeamCityStream(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	meter := sdkmetric.NewMeterProvider().Meter("test")
+
+	tracer := tracerProvider.Tracer("test")
+	ctx, root := tracer.Start(context.Background(), "root")
+
+	output := &bytes.Buffer{}
+	server := newLiveServer(ctx, tracer, newTestCaseMetrics(meter, 0))
+	stream := newTeamCityStream(server, output)
+	require.NoError(t, stream.consume(strings.NewReader(strings.Join([]string{
+		"> Task :test",
+		"##teamcity[testSuiteStarted name='CheckoutTest' timestamp='2024-05-01T10:00:00.000+0000']",
+		"##teamcity[testStarted name='shouldPay' captureStandardOutput='true' timestamp='2024-05-01T10:00:00.100+0000']",
+		"paying 42 EUR",
+		"##teamcity[testFinished name='shouldPay' duration='1500' timestamp='2024-05-01T10:00:01.600+0000']",
+		"##teamcity[flowStarted flowId='2' parent='']",
+		"##teamcity[testStarted name='shouldRefund' flowId='2' timestamp='2024-05-01T10:00:01.000+0000']",
+		"##teamcity[testFailed name='shouldRefund' flowId='2' message='refund failed' type='comparisonFailure' expected='42' actual='0' details='at CheckoutTest.java:12']",
+		"##teamcity[testFinished name='shouldRefund' flowId='2' timestamp='2024-05-01T10:00:03.000+0000']",
+		"##teamcity[flowFinished flowId='2']",
+		"##teamcity[testIgnored name='shouldShip' message='not implemented']",
+		"##teamcity[testSuiteFinished name='CheckoutTest' timestamp='2024-05-01T10:00:04.000+0000']",
+		"##teamcity[buildStatisticValue key='coverage' value='80']",
+		"BUILD SUCCESSFUL",
+	}, "\n"))))
+
+	// the lines of the build are kept, but the ones captured as the output of a test
+	require.Equal(t, "> Task :test\nBUILD SUCCESSFUL\n", output.String())
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 4)
+
+	// the spans are timed by the timestamps of the messages
+	require.Equal(t, "shouldPay", spans[0].Name)
+	require.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 100000000, time.UTC), spans[0].StartTime.UTC())
+	require.Equal(t, 1500*time.Millisecond, spans[0].EndTime.Sub(spans[0].StartTime))
+	require.Contains(t, spans[0].Attributes, attribute.Key(TestSystemOut).String("paying 42 EUR\n"))
+	require.Contains(t, spans[0].Attributes, attribute.Key(TestClassName).String("CheckoutTest"))
+
+	require.Equal(t, "shouldRefund", spans[1].Name)
+	require.Equal(t, 2*time.Second, spans[1].EndTime.Sub(spans[1].StartTime))
+	require.Contains(t, spans[1].Attributes, attribute.Key(TestStatus).String("failed"))
+	require.Contains(t, spans[1].Attributes, attribute.Key(TestMessage).String("refund failed"))
+	require.Len(t, spans[1].Events, 1)
+
+	require.Equal(t, "shouldShip", spans[2].Name)
+	require.Contains(t, spans[2].Attributes, attribute.Key(TestStatus).String("skipped"))
+
+	require.Equal(t, "CheckoutTest", spans[3].Name)
+	require.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), spans[3].StartTime.UTC())
+	require.Equal(t, time.Date(2024, 5, 1, 10, 0, 4, 0, time.UTC), spans[3].EndTime.UTC())
+	require.Contains(t, spans[3].Attributes, attribute.Key(TotalTestsCount).Int(3))
+
+	report := server.close()
+	root.End()
+
+	summary := summarize(report)
+	require.Equal(t, 3, summary.Total)
+	require.Equal(t, 1, summary.Failed)
+	require.Equal(t, 1, summary.Skipped)
+}
+
+func TestTeamCityStream_LongLine(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)).Tracer("test")
+	meter := sdkmetric.NewMeterProvider().Meter("test")
+
+	// a line of the build longer than the default limit of a bufio.Scanner
+	long := strings.Repeat("x", 2<<20)
+
+	output := &bytes.Buffer{}
+	server := newLiveServer(context.Background(), tracer, newTestCaseMetrics(meter, 0))
+	stream := newTeamCityStream(server, output)
+	require.NoError(t, stream.consume(strings.NewReader(strings.Join([]string{
+		long,
+		"##teamcity[testStarted name='shouldPay']",
+		"##teamcity[testFinished name='shouldPay' duration='10']",
+		"BUILD SUCCESSFUL",
+	}, "\n"))))
+
+	require.Equal(t, long+"\nBUILD SUCCESSFUL\n", output.String())
+	require.Len(t, exporter.GetSpans(), 1)
+	require.Equal(t, "shouldPay", exporter.GetSpans()[0].Name)
+}
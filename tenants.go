@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// tenancyConfig the tenants of the server mode: the value of the header of the requests selects the configuration
+// of the exporters for the report, so that a shared server can export the reports of each team to its backend
+type tenancyConfig struct {
+	Header  string                   `yaml:"header"`
+	Tenants map[string]*tenantConfig `yaml:"tenants"`
+}
+
+// tenantConfig the configuration of the exporters of a tenant. The settings not present are the ones of the
+// environment variables
+type tenantConfig struct {
+	Endpoint           string            `yaml:"endpoint"`
+	Headers            map[string]string `yaml:"headers"`
+	ResourceAttributes map[string]string `yaml:"resource_attributes"`
+}
+
+func (t *tenancyConfig) validate() error {
+	if len(t.Tenants) > 0 && t.Header == "" {
+		return errors.New("the header of the tenants is not set")
+	}
+
+	for name, tenant := range t.Tenants {
+		if tenant == nil {
+			return fmt.Errorf("the tenant %q has no configuration", name)
+		}
+
+		if tenant.Endpoint == "" {
+			continue
+		}
+
+		if !validOtlpEndpoint(tenant.Endpoint) {
+			return fmt.Errorf("invalid endpoint %q of the tenant %q: it must be an URL, i.e. https://collector:4317, or a unix socket, i.e. unix:///var/run/otelcol.sock", tenant.Endpoint, name)
+		}
+	}
+
+	return nil
+}
+
+// tenant returns the name of the tenant in the value of the header, or an error if it's not configured.
+// The requests without the header are exported with the default configuration
+func (t *tenancyConfig) tenant(value string) (string, error) {
+	if t.Header == "" || value == "" {
+		return "", nil
+	}
+
+	if _, ok := t.Tenants[value]; !ok {
+		return "", fmt.Errorf("unknown tenant %q in the %s header", value, t.Header)
+	}
+
+	return value, nil
+}
+
+func (t *tenantConfig) traceOptions() []otlpOption {
+	return otlpEndpointOptions(t.Endpoint, t.Headers)
+}
+
+func (t *tenantConfig) metricOptions() []otlpOption {
+	return otlpEndpointOptions(t.Endpoint, t.Headers)
+}
+
+// resource returns the resource of the tenant, with its attributes merged into the default one
+func (t *tenantConfig) resource(res *resource.Resource) (*resource.Resource, error) {
+	names := make([]string, 0, len(t.ResourceAttributes))
+	for name := range t.ResourceAttributes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	attributes := make([]attribute.KeyValue, 0, len(names))
+	for _, name := range names {
+		attributes = append(attributes, attribute.Key(name).String(t.ResourceAttributes[name]))
+	}
+
+	return resource.Merge(res, resource.NewSchemaless(attributes...))
+}
+
+// tenantProviders the providers of a tenant, created with its first report, and shared by all its reports
+type tenantProviders struct {
+	res            *resource.Resource
+	tracerProvider *sdktrace.TracerProvider
+	meterProvider  *sdkmetric.MeterProvider
+	traceOptions   []otlpOption
+}
+
+func newTenantProviders(ctx context.Context, tenant *tenantConfig, res *resource.Resource, diagnostics *exportDiagnostics, outputs *spanOutputs) (*tenantProviders, error) {
+	tenantRes, err := tenant.resource(res)
+	if err != nil {
+		return nil, err
+	}
+
+	traceOptions := tenant.traceOptions()
+	tracerProvider, err := newTracerProvider(ctx, tenantRes, diagnostics, outputs, traceOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	meterProvider, err := newMeterProvider(ctx, tenantRes, nil, tenant.metricOptions()...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tenantProviders{res: tenantRes, tracerProvider: tracerProvider, meterProvider: meterProvider, traceOptions: traceOptions}, nil
+}
+
+func (p *tenantProviders) shutdown(ctx context.Context) error {
+	return errors.Join(p.tracerProvider.Shutdown(ctx), p.meterProvider.Shutdown(ctx))
+}
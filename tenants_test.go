@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+func TestTenancyConfig(t *testing.T) {
+	load := func(t *testing.T, content string) (*config, error) {
+		path := filepath.Join(t.TempDir(), "config.yml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return loadConfig(path)
+	}
+
+	t.Run("Tenants", func(t *testing.T) {
+		cfg, err := load(t, `
+tenancy:
+  header: X-Team
+  tenants:
+    checkout:
+      endpoint: https://otlp.checkout.example.com:4317
+      headers:
+        authorization: Bearer secret
+      resource_attributes:
+        team: checkout
+        deployment.environment: ci
+    payments: {}
+`)
+		require.NoError(t, err)
+
+		tenant, err := cfg.Tenancy.tenant("checkout")
+		require.NoError(t, err)
+		require.Equal(t, "checkout", tenant)
+
+		tenant, err = cfg.Tenancy.tenant("")
+		require.NoError(t, err)
+		require.Empty(t, tenant)
+
+		_, err = cfg.Tenancy.tenant("search")
+		require.EqualError(t, err, `unknown tenant "search" in the X-Team header`)
+
+		checkout := cfg.Tenancy.Tenants["checkout"]
+		require.Len(t, checkout.traceOptions(), 2)
+		require.Len(t, checkout.metricOptions(), 2)
+		require.Empty(t, cfg.Tenancy.Tenants["payments"].traceOptions())
+
+		res, err := checkout.resource(resource.NewSchemaless(attribute.String("service.name", "tests"), attribute.String("team", "platform")))
+		require.NoError(t, err)
+		require.ElementsMatch(t, []attribute.KeyValue{
+			attribute.String("deployment.environment", "ci"),
+			attribute.String("service.name", "tests"),
+			attribute.String("team", "checkout"),
+		}, res.Attributes())
+	})
+
+	t.Run("Without tenants", func(t *testing.T) {
+		cfg, err := load(t, `failure_rules: []`)
+		require.NoError(t, err)
+
+		tenant, err := cfg.Tenancy.tenant("checkout")
+		require.NoError(t, err)
+		require.Empty(t, tenant)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := load(t, `
+tenancy:
+  tenants:
+    checkout: {}
+`)
+		require.ErrorContains(t, err, "the header of the tenants is not set")
+
+		_, err = load(t, `
+tenancy:
+  header: X-Team
+  tenants:
+    checkout:
+      endpoint: collector:4317
+`)
+		require.ErrorContains(t, err, `invalid endpoint "collector:4317" of the tenant "checkout"`)
+	})
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joshdk/go-junit"
+)
+
+// suiteTimeoutProperty property of the suite with its time limit, in seconds or as a Go duration
+const suiteTimeoutProperty = "timeout"
+
+// timeoutMessageRegex matches the messages of the timeout-style failures
+var timeoutMessageRegex = regexp.MustCompile(`(?i)\btimed?[ -]?out\b|\btimeout\b|deadline exceeded`)
+
+// suiteTimeout returns the time limit of the tests in the suite: the timeout property of the suite,
+// or the --test-timeout flag. Zero if there is no limit
+func suiteTimeout(suite junit.Suite) time.Duration {
+	if value, ok := suite.Properties[suiteTimeoutProperty]; ok {
+		if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+			return time.Duration(seconds * float64(time.Second))
+		}
+
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+
+	return testTimeoutFlag
+}
+
+// isTimeout detects the timeout-style failures: from the message, from the type of the error
+// (i.e. java.util.concurrent.TimeoutException or org.junit.runners.model.TestTimedOutException),
+// or from a duration reaching the time limit of the suite
+func isTimeout(test junit.Test, limit time.Duration) bool {
+	if test.Status != junit.StatusFailed && test.Status != junit.StatusError {
+		return false
+	}
+
+	if limit > 0 && test.Duration >= limit {
+		return true
+	}
+
+	if timeoutMessageRegex.MatchString(test.Message) {
+		return true
+	}
+
+	if junitErr, ok := test.Error.(junit.Error); ok {
+		errType := strings.ToLower(junitErr.Type)
+		if strings.Contains(errType, "timeout") || strings.Contains(errType, "timedout") {
+			return true
+		}
+
+		return timeoutMessageRegex.MatchString(junitErr.Message)
+	}
+
+	return false
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsTimeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		test     junit.Test
+		limit    time.Duration
+		expected bool
+	}{
+		{"Timeout message", junit.Test{Status: junit.StatusFailed, Message: "Test timed out after 30 seconds"}, 0, true},
+		{"Deadline exceeded", junit.Test{Status: junit.StatusError, Message: "context deadline exceeded"}, 0, true},
+		{"Timeout error type", junit.Test{Status: junit.StatusError, Error: junit.Error{Type: "org.junit.runners.model.TestTimedOutException"}}, 0, true},
+		{"Timeout exception", junit.Test{Status: junit.StatusError, Error: junit.Error{Type: "java.util.concurrent.TimeoutException"}}, 0, true},
+		{"Duration reaching the limit", junit.Test{Status: junit.StatusFailed, Duration: 10 * time.Second}, 10 * time.Second, true},
+		{"Duration below the limit", junit.Test{Status: junit.StatusFailed, Duration: 9 * time.Second}, 10 * time.Second, false},
+		{"Assertion", junit.Test{Status: junit.StatusFailed, Message: "expected 1 but was 2", Error: junit.Error{Type: "AssertionError"}}, 0, false},
+		{"Passed test", junit.Test{Status: junit.StatusPassed, Message: "timeout", Duration: time.Minute}, time.Second, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, isTimeout(tt.test, tt.limit))
+		})
+	}
+}
+
+func TestSuiteTimeout(t *testing.T) {
+	testTimeoutFlag = time.Minute
+	defer func() { testTimeoutFlag = 0 }()
+
+	require.Equal(t, time.Minute, suiteTimeout(junit.Suite{}))
+	require.Equal(t, 90*time.Second, suiteTimeout(junit.Suite{Properties: map[string]string{"timeout": "90"}}))
+	require.Equal(t, 2*time.Minute, suiteTimeout(junit.Suite{Properties: map[string]string{"timeout": "2m"}}))
+	require.Equal(t, time.Minute, suiteTimeout(junit.Suite{Properties: map[string]string{"timeout": "never"}}))
+}
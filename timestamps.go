@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joshdk/go-junit"
+)
+
+// suiteTimestampAttribute attribute of the suite with the time when it started
+const suiteTimestampAttribute = "timestamp"
+
+// timestampLayoutsWithZone the layouts of the timestamps found in the reports, with a time zone
+var timestampLayoutsWithZone = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999Z07",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999 Z07:00",
+	"2006-01-02 15:04:05.999999999 -0700",
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.UnixDate,
+	time.RubyDate,
+}
+
+// abbreviatedZoneLayouts the layouts whose time zone is only an abbreviation, i.e. CET, without its offset
+var abbreviatedZoneLayouts = map[string]bool{
+	time.RFC1123:  true,
+	time.UnixDate: true,
+}
+
+// timestampLayoutsWithoutZone the layouts of the timestamps found in the reports, without a time zone, i.e. the
+// local time written by Ant. The dotted dates are written day first by the locales that use them, and the order of
+// the day and the month of the dates with slashes is only guessed when it's not ambiguous, see slashDateLayout
+var timestampLayoutsWithoutZone = []string{
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006/01/02 15:04:05.999999999",
+	"02.01.2006 15:04:05.999999999",
+	"2006-01-02T15:04",
+	"2006-01-02",
+	time.ANSIC,
+}
+
+// slashDateRegex matches the dates with slashes and the year last, i.e. 15/11/2021 or 11/15/2021 05:16:16, whose
+// day and month are in the order of the locale of the machine that wrote them
+var slashDateRegex = regexp.MustCompile(`^(\d{1,2})/(\d{1,2})/\d{4}( \d{2}:\d{2}:\d{2}(\.\d+)?)?$`)
+
+// commaFractionRegex matches the fractional seconds with a decimal comma, as written in some locales
+var commaFractionRegex = regexp.MustCompile(`(\d{2}:\d{2}:\d{2}),(\d+)`)
+
+// getAssumedTimezone returns the time zone of the timestamps without one: the local time zone by default,
+// or the one of the --assume-timezone flag, as an IANA name, UTC or Local
+func getAssumedTimezone() (*time.Location, error) {
+	name := strings.TrimSpace(assumeTimezoneFlag)
+	if name == "" || strings.EqualFold(name, "local") {
+		return time.Local, nil
+	}
+
+	if strings.EqualFold(name, "utc") || name == "Z" {
+		return time.UTC, nil
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q for the --assume-timezone flag: %w", name, err)
+	}
+
+	return location, nil
+}
+
+// parseTimestamp parses the timestamps of the reports in the formats found in the wild: RFC 3339 and its
+// variants, with a space separator, a decimal comma or an offset without colon, the ones of the HTTP and Unix
+// dates, the ones without a time zone, in the given location, and the Unix epochs in seconds or milliseconds.
+// The abbreviations of the time zones are the ones of the given location, UTC and GMT: the other ones are refused,
+// as their offset is unknown
+func parseTimestamp(value string, location *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, fmt.Errorf("empty timestamp")
+	}
+
+	if epoch, err := strconv.ParseInt(value, 10, 64); err == nil {
+		// the epochs in milliseconds have 13 digits until the year 2286
+		if len(value) >= 13 {
+			return time.UnixMilli(epoch), nil
+		}
+
+		return time.Unix(epoch, 0), nil
+	}
+
+	value = commaFractionRegex.ReplaceAllString(value, "$1.$2")
+
+	for _, layout := range timestampLayoutsWithZone {
+		t, err := time.ParseInLocation(layout, value, location)
+		if err != nil {
+			continue
+		}
+
+		// the unknown abbreviations are parsed in a zone with their name and a zero offset
+		if name, offset := t.Zone(); abbreviatedZoneLayouts[layout] && offset == 0 && t.Location() != time.UTC && t.Location() != location && name != "GMT" {
+			return time.Time{}, fmt.Errorf("unknown time zone %q in the timestamp %q: its offset is only known in the time zone of the --assume-timezone flag", name, value)
+		}
+
+		return t, nil
+	}
+
+	if matches := slashDateRegex.FindStringSubmatch(value); matches != nil {
+		layout, err := slashDateLayout(matches[1], matches[2])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w in the timestamp %q", err, value)
+		}
+
+		if matches[3] != "" {
+			layout += " 15:04:05.999999999"
+		}
+
+		return time.ParseInLocation(layout, value, location)
+	}
+
+	for _, layout := range timestampLayoutsWithoutZone {
+		if t, err := time.ParseInLocation(layout, value, location); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unsupported timestamp format %q", value)
+}
+
+// slashDateLayout returns the layout of the date with slashes whose first two numbers are the day and the month,
+// in any order: the day is the one greater than 12. Both orders are refused when the two numbers are 12 or less and
+// differ, i.e. 01/02/2021, as the date is ambiguous
+func slashDateLayout(first string, second string) (string, error) {
+	a, _ := strconv.Atoi(first)
+	b, _ := strconv.Atoi(second)
+
+	switch {
+	case a > 12 && b <= 12:
+		return "2/1/2006", nil
+	case b > 12 && a <= 12, a == b:
+		return "1/2/2006", nil
+	case a > 12 && b > 12:
+		return "", fmt.Errorf("invalid date %s/%s", first, second)
+	default:
+		return "", fmt.Errorf("ambiguous order of the day and the month %s/%s", first, second)
+	}
+}
+
+// suiteStartTime returns the time when the suite started, from the timestamp attribute of its raw XML element or
+// of its properties, or the zero time if it's not present. The timestamps in an unknown format are logged, so
+// they do not silently fall back to the time of the export
+func suiteStartTime(suite junit.Suite, rawSuite *xmlElement, location *time.Location) time.Time {
+	value, ok := rawSuite.Attr(suiteTimestampAttribute)
+	if !ok {
+		value, ok = suite.Properties[suiteTimestampAttribute]
+	}
+
+	if !ok || strings.TrimSpace(value) == "" {
+		return time.Time{}
+	}
+
+	t, err := parseTimestamp(value, location)
+	if err != nil {
+		log.Printf("the timestamp of the suite %s is ignored, using the time of the export: %v", suite.Name, err)
+		return time.Time{}
+	}
+
+	return t
+}
+
+// the anchoring modes of the spans of the suites
+const (
+	anchorEnd             = "end"
+	anchorStart           = "start"
+	anchorReportTimestamp = "report-timestamp"
+)
+
+// invocationStart the time when the tool was started
+var invocationStart = time.Now()
+
+func parseAnchor(anchor string) (string, error) {
+	switch a := strings.ToLower(strings.TrimSpace(anchor)); a {
+	case "":
+		return anchorEnd, nil
+	case anchorEnd, anchorStart, anchorReportTimestamp:
+		return a, nil
+	default:
+		return "", fmt.Errorf("invalid anchor %q: valid values are %s, %s and %s", anchor, anchorEnd, anchorStart, anchorReportTimestamp)
+	}
+}
+
+// suiteStartTimes lays out the suites one after the other: backwards from now, so the run ends at the time of the
+// export, forwards from the start of the invocation of the tool, or forwards from the timestamp of each suite.
+// The suites without a timestamp are laid out backwards from now
+func suiteStartTimes(report *junitReport, anchor string, location *time.Location, now time.Time) []time.Time {
+	total := time.Duration(0)
+	for i := range report.suites {
+		total += suiteSpanDuration(report, i)
+	}
+
+	cursor := now.Add(-total)
+	if anchor == anchorStart {
+		cursor = invocationStart
+	}
+
+	startTimes := make([]time.Time, len(report.suites))
+	for i, suite := range report.suites {
+		startTimes[i] = cursor
+		cursor = cursor.Add(suiteSpanDuration(report, i))
+
+		if anchor != anchorReportTimestamp {
+			continue
+		}
+
+		if timestamp := suiteStartTime(suite, report.rawSuite(i), location); !timestamp.IsZero() {
+			startTimes[i] = timestamp
+		}
+	}
+
+	return startTimes
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	require.NoError(t, err)
+
+	utc := time.Date(2021, 11, 15, 5, 16, 16, 0, time.UTC)
+	local := time.Date(2021, 11, 15, 5, 16, 16, 0, madrid)
+
+	tests := []struct {
+		value    string
+		expected time.Time
+	}{
+		{"2021-11-15T05:16:16Z", utc},
+		{"2021-11-15T06:16:16+01:00", utc},
+		{"2021-11-15T06:16:16+0100", utc},
+		{"2021-11-15 05:16:16Z", utc},
+		{"2021-11-15 06:16:16 +0100", utc},
+		{"2021-11-15T05:16:16,250Z", utc.Add(250 * time.Millisecond)},
+		{"Mon, 15 Nov 2021 05:16:16 GMT", utc},
+		// the abbreviations of the assumed time zone
+		{"Mon, 15 Nov 2021 06:16:16 CET", utc},
+		{"Mon Nov 15 06:16:16 CET 2021", utc},
+		{"1636953376", utc},
+		{"1636953376250", utc.Add(250 * time.Millisecond)},
+		// without a time zone, in the assumed one
+		{"2021-11-15T05:16:16", local},
+		{"2021-11-15 05:16:16,5", local.Add(500 * time.Millisecond)},
+		{"15.11.2021 05:16:16", local},
+		{"2021/11/15 05:16:16", local},
+		// the order of the day and the month is the one that is not ambiguous
+		{"15/11/2021 05:16:16", local},
+		{"11/15/2021 05:16:16", local},
+		{"11/11/2021", time.Date(2021, 11, 11, 0, 0, 0, 0, madrid)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			parsed, err := parseTimestamp(tt.value, madrid)
+			require.NoError(t, err)
+			require.True(t, tt.expected.Equal(parsed), "expected %s, got %s", tt.expected, parsed)
+		})
+	}
+
+	t.Run("Unsupported", func(t *testing.T) {
+		_, err := parseTimestamp("yesterday", madrid)
+		require.Error(t, err)
+
+		_, err = parseTimestamp("", madrid)
+		require.Error(t, err)
+	})
+
+	t.Run("Ambiguous day and month", func(t *testing.T) {
+		_, err := parseTimestamp("01/02/2021 05:16:16", madrid)
+		require.ErrorContains(t, err, "ambiguous order of the day and the month 01/02")
+
+		_, err = parseTimestamp("31/13/2021", madrid)
+		require.ErrorContains(t, err, "invalid date 31/13")
+	})
+
+	t.Run("Unknown time zone abbreviation", func(t *testing.T) {
+		_, err := parseTimestamp("Mon, 15 Nov 2021 05:16:16 XYZ", madrid)
+		require.ErrorContains(t, err, `unknown time zone "XYZ"`)
+
+		// the abbreviations are the ones of the assumed time zone
+		_, err = parseTimestamp("Mon, 15 Nov 2021 06:16:16 CET", time.UTC)
+		require.ErrorContains(t, err, `unknown time zone "CET"`)
+	})
+}
+
+func TestGetAssumedTimezone(t *testing.T) {
+	defer func(timezone string) { assumeTimezoneFlag = timezone }(assumeTimezoneFlag)
+
+	assumeTimezoneFlag = "Local"
+	location, err := getAssumedTimezone()
+	require.NoError(t, err)
+	require.Equal(t, time.Local, location)
+
+	assumeTimezoneFlag = "utc"
+	location, err = getAssumedTimezone()
+	require.NoError(t, err)
+	require.Equal(t, time.UTC, location)
+
+	assumeTimezoneFlag = "America/New_York"
+	location, err = getAssumedTimezone()
+	require.NoError(t, err)
+	require.Equal(t, "America/New_York", location.String())
+
+	assumeTimezoneFlag = "Mars/Olympus_Mons"
+	_, err = getAssumedTimezone()
+	require.Error(t, err)
+}
+
+func TestSuiteStartTime(t *testing.T) {
+	start := time.Date(2021, 11, 15, 5, 16, 16, 0, time.UTC)
+
+	t.Run("From the raw suite", func(t *testing.T) {
+		suite := junit.Suite{Name: "a", Properties: map[string]string{"browser": "chrome"}}
+		raw := &xmlElement{Attrs: map[string]string{"timestamp": "2021-11-15T05:16:16"}}
+
+		require.True(t, start.Equal(suiteStartTime(suite, raw, time.UTC)))
+	})
+
+	t.Run("Unknown format", func(t *testing.T) {
+		suite := junit.Suite{Name: "a", Properties: map[string]string{"timestamp": "yesterday"}}
+
+		require.True(t, suiteStartTime(suite, &xmlElement{Attrs: map[string]string{}}, time.UTC).IsZero())
+	})
+
+	t.Run("Spans laid out from the start of the suite", func(t *testing.T) {
+		exporter := tracetest.NewInMemoryExporter()
+		tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+		tracer := tracerProvider.Tracer("test")
+		meter := sdkmetric.NewMeterProvider().Meter("test")
+
+		suite := junit.Suite{
+			Name: "a",
+			Tests: []junit.Test{
+				{Name: "first", Duration: time.Second, Status: junit.StatusPassed},
+				{Name: "second", Duration: 2 * time.Second, Status: junit.StatusPassed},
+			},
+			Totals: junit.Totals{Tests: 2, Passed: 2, Duration: 3 * time.Second},
+		}
+
+		createSuiteSpans(context.Background(), tracer, newTestCaseMetrics(meter, 0), suite, nil, nil, nil, start)
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 3)
+		require.Equal(t, "first", spans[0].Name)
+		require.Equal(t, start, spans[0].StartTime)
+		require.Equal(t, start.Add(time.Second), spans[0].EndTime)
+		require.Equal(t, "second", spans[1].Name)
+		require.Equal(t, start.Add(time.Second), spans[1].StartTime)
+		require.Equal(t, start.Add(3*time.Second), spans[1].EndTime)
+		require.Equal(t, "a", spans[2].Name)
+		require.Equal(t, start, spans[2].StartTime)
+		require.Equal(t, start.Add(3*time.Second), spans[2].EndTime)
+	})
+}
+
+func TestSuiteStartTimes(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	report := &junitReport{
+		suites: []junit.Suite{
+			{Name: "a", Totals: junit.Totals{Duration: time.Minute}},
+			{Name: "b", Totals: junit.Totals{Duration: 2 * time.Minute}},
+		},
+		rawSuites: []*xmlElement{
+			{Attrs: map[string]string{"timestamp": "2024-05-01T10:00:00Z"}},
+			{Attrs: map[string]string{}},
+		},
+	}
+
+	t.Run("End", func(t *testing.T) {
+		require.Equal(t, []time.Time{now.Add(-3 * time.Minute), now.Add(-2 * time.Minute)}, suiteStartTimes(report, anchorEnd, time.UTC, now))
+	})
+
+	t.Run("Start", func(t *testing.T) {
+		require.Equal(t, []time.Time{invocationStart, invocationStart.Add(time.Minute)}, suiteStartTimes(report, anchorStart, time.UTC, now))
+	})
+
+	t.Run("Report timestamp", func(t *testing.T) {
+		require.Equal(t, []time.Time{time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), now.Add(-2 * time.Minute)}, suiteStartTimes(report, anchorReportTimestamp, time.UTC, now))
+	})
+
+	anchor, err := parseAnchor("Report-Timestamp")
+	require.NoError(t, err)
+	require.Equal(t, anchorReportTimestamp, anchor)
+
+	_, err = parseAnchor("middle")
+	require.Error(t, err)
+}
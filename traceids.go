@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// the generators of the trace IDs: the random ones of the W3C trace context, and the ones of AWS X-Ray, which are
+// also valid W3C trace IDs, so the spans exported through the ADOT collector land in X-Ray without rewriting them
+const (
+	idGeneratorW3C  = "w3c"
+	idGeneratorXRay = "xray"
+)
+
+type assignedTraceIDKey struct{}
+
+// withAssignedTraceID returns a context where the root spans get the trace ID, i.e. the one returned to the client
+// when the report was submitted, before it was exported
+func withAssignedTraceID(ctx context.Context, traceID trace.TraceID) context.Context {
+	return context.WithValue(ctx, assignedTraceIDKey{}, traceID)
+}
+
+// newTraceID returns a random trace ID
+func newTraceID() trace.TraceID {
+	traceID := trace.TraceID{}
+	_, _ = crand.Read(traceID[:])
+	return traceID
+}
+
+// newXRayTraceID returns a trace ID in the format of AWS X-Ray: the epoch time in seconds in its first 4 bytes,
+// followed by 12 random bytes
+func newXRayTraceID(now time.Time) trace.TraceID {
+	traceID := newTraceID()
+	binary.BigEndian.PutUint32(traceID[:4], uint32(now.Unix()))
+	return traceID
+}
+
+// parseIDGenerator returns the generator of the IDs of the spans with the format of its trace IDs
+func parseIDGenerator(name string) (assignedIDGenerator, error) {
+	switch g := strings.ToLower(strings.TrimSpace(name)); g {
+	case "", idGeneratorW3C:
+		return assignedIDGenerator{}, nil
+	case idGeneratorXRay:
+		return assignedIDGenerator{xray: true}, nil
+	default:
+		return assignedIDGenerator{}, fmt.Errorf("invalid ID generator %q: valid values are %s and %s", name, idGeneratorW3C, idGeneratorXRay)
+	}
+}
+
+// assignedIDGenerator generates random IDs, except for the trace ID of the root spans with an assigned one in
+// their context
+type assignedIDGenerator struct {
+	// xray generates the trace IDs in the format of AWS X-Ray
+	xray bool
+}
+
+// newTraceID returns a trace ID in the format of the generator
+func (g assignedIDGenerator) newTraceID() trace.TraceID {
+	if g.xray {
+		return newXRayTraceID(time.Now())
+	}
+
+	return newTraceID()
+}
+
+func (g assignedIDGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	traceID, ok := ctx.Value(assignedTraceIDKey{}).(trace.TraceID)
+	if !ok || !traceID.IsValid() {
+		traceID = g.newTraceID()
+	}
+
+	return traceID, g.NewSpanID(ctx, traceID)
+}
+
+func (assignedIDGenerator) NewSpanID(ctx context.Context, traceID trace.TraceID) trace.SpanID {
+	spanID := trace.SpanID{}
+	_, _ = crand.Read(spanID[:])
+	return spanID
+}
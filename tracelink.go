@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// the formats of the line with the link to the trace, for the UIs of the CI systems
+const (
+	traceLinkNone     = "none"
+	traceLinkAuto     = "auto"
+	traceLinkGitHub   = "github"
+	traceLinkTeamCity = "teamcity"
+	traceLinkJSON     = "json"
+)
+
+// traceLinkTitle the title of the link in the CI systems
+const traceLinkTitle = Junit2otlp + " trace"
+
+func parseTraceLinkFormat(format string) (string, error) {
+	switch f := strings.ToLower(strings.TrimSpace(format)); f {
+	case "":
+		return traceLinkNone, nil
+	case traceLinkAuto:
+		// the CI systems set these environment variables in their builds
+		if os.Getenv("GITHUB_ACTIONS") == "true" {
+			return traceLinkGitHub, nil
+		}
+		if os.Getenv("TEAMCITY_VERSION") != "" {
+			return traceLinkTeamCity, nil
+		}
+		return traceLinkJSON, nil
+	case traceLinkNone, traceLinkGitHub, traceLinkTeamCity, traceLinkJSON:
+		return f, nil
+	default:
+		return "", fmt.Errorf("invalid trace link format %q: valid values are %s, %s, %s, %s and %s", format, traceLinkNone, traceLinkAuto, traceLinkGitHub, traceLinkTeamCity, traceLinkJSON)
+	}
+}
+
+// writeTraceLink writes a line with the link to the trace of the run, in the format the CI system renders as a
+// clickable link: a GitHub Actions notice, a TeamCity service message, or a JSON object for the other tools.
+// Without a trace URL, the line contains the trace ID
+func writeTraceLink(w io.Writer, format string, traceURL string, sc trace.SpanContext) error {
+	if format == traceLinkNone || !sc.IsValid() {
+		return nil
+	}
+
+	link := sc.TraceID().String()
+	if traceURL != "" {
+		link = strings.NewReplacer("{trace_id}", sc.TraceID().String(), "{span_id}", sc.SpanID().String()).Replace(traceURL)
+	}
+
+	var err error
+	switch format {
+	case traceLinkGitHub:
+		// see https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions
+		escape := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+		property := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+		_, err = fmt.Fprintf(w, "::notice title=%s::%s\n", property.Replace(traceLinkTitle), escape.Replace(link))
+	case traceLinkTeamCity:
+		// see https://www.jetbrains.com/help/teamcity/service-messages.html#Escaped+Values
+		escape := strings.NewReplacer("|", "||", "'", "|'", "\n", "|n", "\r", "|r", "[", "|[", "]", "|]")
+		_, err = fmt.Fprintf(w, "##teamcity[message text='%s' status='NORMAL']\n", escape.Replace(traceLinkTitle+": "+link))
+	case traceLinkJSON:
+		line := struct {
+			TraceID string `json:"trace_id"`
+			SpanID  string `json:"span_id"`
+			URL     string `json:"url,omitempty"`
+		}{TraceID: sc.TraceID().String(), SpanID: sc.SpanID().String()}
+		if traceURL != "" {
+			line.URL = link
+		}
+		// the URL is kept readable in the logs
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		err = encoder.Encode(line)
+	}
+
+	return err
+}
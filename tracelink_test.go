@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestWriteTraceLink(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("0af7651916cd43dd8448eb211c80319c")
+	spanID, _ := trace.SpanIDFromHex("b7ad6b7169203331")
+	sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID})
+	traceURL := "https://grafana.example.com/explore?traceId={trace_id}&spanId={span_id}"
+
+	tests := []struct {
+		format   string
+		traceURL string
+		expected string
+	}{
+		{traceLinkGitHub, traceURL, "::notice title=junit2otlp trace::https://grafana.example.com/explore?traceId=0af7651916cd43dd8448eb211c80319c&spanId=b7ad6b7169203331\n"},
+		{traceLinkTeamCity, "https://tempo/[{trace_id}]", "##teamcity[message text='junit2otlp trace: https://tempo/|[0af7651916cd43dd8448eb211c80319c|]' status='NORMAL']\n"},
+		{traceLinkJSON, traceURL, `{"trace_id":"0af7651916cd43dd8448eb211c80319c","span_id":"b7ad6b7169203331","url":"https://grafana.example.com/explore?traceId=0af7651916cd43dd8448eb211c80319c&spanId=b7ad6b7169203331"}` + "\n"},
+		{traceLinkJSON, "", `{"trace_id":"0af7651916cd43dd8448eb211c80319c","span_id":"b7ad6b7169203331"}` + "\n"},
+		{traceLinkGitHub, "", "::notice title=junit2otlp trace::0af7651916cd43dd8448eb211c80319c\n"},
+		{traceLinkNone, traceURL, ""},
+	}
+
+	for _, test := range tests {
+		buf := &bytes.Buffer{}
+		require.NoError(t, writeTraceLink(buf, test.format, test.traceURL, sc))
+		require.Equal(t, test.expected, buf.String(), test.format)
+	}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, writeTraceLink(buf, traceLinkJSON, traceURL, trace.SpanContext{}))
+	require.Empty(t, buf.String())
+}
+
+func TestParseTraceLinkFormat(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "true")
+	format, err := parseTraceLinkFormat("auto")
+	require.NoError(t, err)
+	require.Equal(t, traceLinkGitHub, format)
+
+	t.Setenv("GITHUB_ACTIONS", "")
+	t.Setenv("TEAMCITY_VERSION", "2024.03")
+	format, err = parseTraceLinkFormat("auto")
+	require.NoError(t, err)
+	require.Equal(t, traceLinkTeamCity, format)
+
+	t.Setenv("TEAMCITY_VERSION", "")
+	format, err = parseTraceLinkFormat("auto")
+	require.NoError(t, err)
+	require.Equal(t, traceLinkJSON, format)
+
+	format, err = parseTraceLinkFormat("")
+	require.NoError(t, err)
+	require.Equal(t, traceLinkNone, format)
+
+	_, err = parseTraceLinkFormat("jenkins")
+	require.Error(t, err)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const trendsCommand = "trends"
+
+// the formats of the trends of a test
+const (
+	trendsFormatSparkline = "sparkline"
+	trendsFormatCSV       = "csv"
+)
+
+// sparkBlocks the blocks of the sparklines, from the lowest value to the highest one
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// resultSymbols the symbols of the results of the test in the trends
+var resultSymbols = map[string]string{
+	"passed":  "✓",
+	"failed":  "✗",
+	"error":   "E",
+	"skipped": "-",
+}
+
+// testTrendPoint the result of the test in a run of the state
+type testTrendPoint struct {
+	run    *stateRun
+	result *stateResult
+}
+
+// runTrends prints the trends of the duration and the pass rate of a test over the runs recorded in the state,
+// for a quick triage without access to the queries of the backend
+func runTrends(args []string) {
+	if err := resolveFlags(flag.CommandLine, args); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := trends(stateFlag, testFlag, trendsFormatFlag, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func trends(location string, selector string, format string, w io.Writer) error {
+	if location == "" {
+		return errors.New("the state is required for the trends: set it with --state")
+	}
+
+	if selector == "" {
+		return errors.New("the test is required for the trends: set its selector with --test, i.e. com.acme.CartTest#testTotal")
+	}
+
+	state, err := readState(context.Background(), location)
+	if err != nil {
+		return err
+	}
+
+	points := testTrend(state, selector)
+	if len(points) == 0 {
+		return fmt.Errorf("there are no runs of the test %s in the state %s", selector, location)
+	}
+
+	switch format {
+	case trendsFormatSparkline:
+		return writeSparklineTrends(w, selector, points)
+	case trendsFormatCSV:
+		return writeCSVTrends(w, points)
+	default:
+		return fmt.Errorf("invalid trends format %q: valid values are %s and %s", format, trendsFormatSparkline, trendsFormatCSV)
+	}
+}
+
+// testTrend returns the results of the test in the runs of the state, from the oldest to the newest one
+func testTrend(state *toolState, selector string) []testTrendPoint {
+	points := []testTrendPoint{}
+	for _, run := range state.Runs {
+		if result, ok := run.Tests[selector]; ok {
+			points = append(points, testTrendPoint{run: run, result: result})
+		}
+	}
+
+	return points
+}
+
+func writeSparklineTrends(w io.Writer, selector string, points []testTrendPoint) error {
+	durations := []time.Duration{}
+	results := &strings.Builder{}
+	passed, executed := 0, 0
+	for _, point := range points {
+		if point.result.Status != "skipped" {
+			durations = append(durations, time.Duration(point.result.DurationMs)*time.Millisecond)
+			executed++
+		}
+		if point.result.Status == "passed" {
+			passed++
+		}
+
+		symbol, ok := resultSymbols[point.result.Status]
+		if !ok {
+			symbol = "?"
+		}
+		results.WriteString(symbol)
+	}
+
+	passRate := 0.0
+	if executed > 0 {
+		passRate = float64(passed) / float64(executed)
+	}
+
+	fmt.Fprintf(w, "%s: %d runs, from %s to %s\n", selector, len(points), points[0].run.Time.Format(time.RFC3339), points[len(points)-1].run.Time.Format(time.RFC3339))
+	if len(durations) > 0 {
+		sorted := slices.Sorted(slices.Values(durations))
+		fmt.Fprintf(w, "duration   %s  min %s  p50 %s  max %s  last %s\n", sparkline(durations), sorted[0], percentile(sorted, 50), sorted[len(sorted)-1], durations[len(durations)-1])
+	}
+	_, err := fmt.Fprintf(w, "results    %s  pass rate %.1f%% (%d/%d)\n", results, passRate*100, passed, executed)
+
+	return err
+}
+
+// sparkline returns a block per duration, scaled between the minimum and the maximum durations
+func sparkline(durations []time.Duration) string {
+	lowest, highest := slices.Min(durations), slices.Max(durations)
+
+	line := make([]rune, 0, len(durations))
+	for _, duration := range durations {
+		index := 0
+		if highest > lowest {
+			index = int(int64(duration-lowest) * int64(len(sparkBlocks)-1) / int64(highest-lowest))
+		}
+		line = append(line, sparkBlocks[index])
+	}
+
+	return string(line)
+}
+
+func writeCSVTrends(w io.Writer, points []testTrendPoint) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"time", "branch", "commit", "status", "duration_ms"}); err != nil {
+		return err
+	}
+
+	for _, point := range points {
+		record := []string{point.run.Time.Format(time.RFC3339), point.run.Branch, point.run.Commit, point.result.Status, strconv.FormatInt(point.result.DurationMs, 10)}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
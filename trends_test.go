@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	results := []*stateResult{
+		{Status: "passed", DurationMs: 1000},
+		{Status: "passed", DurationMs: 1200},
+		{Status: "failed", DurationMs: 3000},
+		{Status: "skipped"},
+		{Status: "passed", DurationMs: 2000},
+	}
+
+	state := &toolState{}
+	for i, result := range results {
+		state.addRun(&stateRun{
+			Time:   start.Add(time.Duration(i) * time.Hour),
+			Branch: "main",
+			Commit: "c" + string(rune('0'+i)),
+			Tests:  map[string]*stateResult{"com.acme.CartTest#testTotal": result},
+		}, defaultStateHistory)
+	}
+	state.addRun(&stateRun{Time: start.Add(6 * time.Hour), Tests: map[string]*stateResult{"com.acme.CartTest#testEmpty": {Status: "passed"}}}, defaultStateHistory)
+	require.NoError(t, updateState(context.Background(), path, func(current *toolState) error {
+		*current = *state
+		return nil
+	}))
+
+	t.Run("Sparkline", func(t *testing.T) {
+		output := &bytes.Buffer{}
+		require.NoError(t, trends(path, "com.acme.CartTest#testTotal", trendsFormatSparkline, output))
+
+		require.Equal(t, `com.acme.CartTest#testTotal: 5 runs, from 2024-01-01T12:00:00Z to 2024-01-01T16:00:00Z
+duration   ▁▁█▄  min 1s  p50 1.2s  max 3s  last 2s
+results    ✓✓✗-✓  pass rate 75.0% (3/4)
+`, output.String())
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		output := &bytes.Buffer{}
+		require.NoError(t, trends(path, "com.acme.CartTest#testTotal", trendsFormatCSV, output))
+
+		require.Equal(t, `time,branch,commit,status,duration_ms
+2024-01-01T12:00:00Z,main,c0,passed,1000
+2024-01-01T13:00:00Z,main,c1,passed,1200
+2024-01-01T14:00:00Z,main,c2,failed,3000
+2024-01-01T15:00:00Z,main,c3,skipped,0
+2024-01-01T16:00:00Z,main,c4,passed,2000
+`, output.String())
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		require.ErrorContains(t, trends(path, "com.acme.CartTest#testMissing", trendsFormatCSV, &bytes.Buffer{}), "there are no runs of the test")
+		require.ErrorContains(t, trends(path, "", trendsFormatCSV, &bytes.Buffer{}), "--test")
+		require.ErrorContains(t, trends("", "com.acme.CartTest#testTotal", trendsFormatCSV, &bytes.Buffer{}), "--state")
+		require.ErrorContains(t, trends(path, "com.acme.CartTest#testTotal", "html", &bytes.Buffer{}), "invalid trends format")
+	})
+
+	require.Equal(t, "▁█", sparkline([]time.Duration{time.Second, 2 * time.Second}))
+	require.Equal(t, "▁▁", sparkline([]time.Duration{time.Second, time.Second}))
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"errors"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joshdk/go-junit"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const reportFormatTrx = "trx"
+
+// trxRootElement the root element of the TRX reports of Visual Studio, written by MSTest, VSTest and dotnet test
+const trxRootElement = "TestRun"
+
+// trxDurationRegex matches the durations of the TRX reports, as hh:mm:ss.fffffff
+var trxDurationRegex = regexp.MustCompile(`^(\d+):(\d{2}):(\d{2}(?:\.\d+)?)$`)
+
+// trxAdapters the test frameworks of the adapters of VSTest, from the URI of the adapter executing the tests
+var trxAdapters = map[string]string{
+	"mstestadapter":      "mstest",
+	"xunit":              "xunit",
+	"nunit3testexecutor": "nunit",
+	"nunittestexecutor":  "nunit",
+}
+
+// trxTestDefinition the definition of a test of the TRX report: its class and its assembly, by the ID of the test
+type trxTestDefinition struct {
+	className  string
+	storage    string
+	categories []string
+	owners     []string
+	adapter    string
+}
+
+// parseTrxReport converts the TRX report of Visual Studio to jUnit suites, one per test assembly, with a nested
+// suite for each test class, and a test case for each result, or for each result of the data-driven tests. The
+// name, the user and the outcome of the run are the attributes of the run, and the outputs, the categories, the
+// owners and the result files of the tests are kept
+func parseTrxReport(_ string, data []byte) (*junitReport, error) {
+	root, err := parseXMLElements(sanitizeXMLReport(data, droppedData))
+	if err != nil {
+		var parseErr *reportParseError
+		if errors.As(err, &parseErr) {
+			parseErr.format = "TRX"
+		}
+		return nil, err
+	}
+
+	report := &junitReport{}
+	run := firstChildNamed(root, trxRootElement)
+	if run == nil {
+		return report, nil
+	}
+
+	if name, ok := run.Attr("name"); ok {
+		report.attributes = append(report.attributes, attribute.Key(TrxRunName).String(name))
+	}
+	if user, ok := run.Attr("runUser"); ok {
+		report.attributes = append(report.attributes, attribute.Key(TrxRunUser).String(user))
+	}
+	if summary := firstChildNamed(run, "ResultSummary"); summary != nil {
+		if outcome, ok := summary.Attr("outcome"); ok {
+			report.attributes = append(report.attributes, attribute.Key(TrxRunOutcome).String(outcome))
+		}
+	}
+
+	definitions := trxDefinitions(run)
+
+	assemblies := []string{}
+	classes := map[string][]string{}
+	tests := map[[2]string][]junit.Test{}
+	hosts := map[string]string{}
+	starts := map[string]time.Time{}
+	for _, results := range run.ChildrenNamed("Results") {
+		for _, result := range results.Children {
+			definition := definitions[result.Attrs["testId"]]
+
+			assembly := path.Base(strings.ReplaceAll(definition.storage, `\`, "/"))
+			if definition.storage == "" {
+				assembly = reportFormatTrx
+			}
+			if _, ok := classes[assembly]; !ok {
+				assemblies = append(assemblies, assembly)
+			}
+
+			className := definition.className
+			key := [2]string{assembly, className}
+			if _, ok := tests[key]; !ok {
+				classes[assembly] = append(classes[assembly], className)
+			}
+
+			tests[key] = append(tests[key], trxTests(result, definition)...)
+
+			if host := result.Attrs["computerName"]; host != "" && hosts[assembly] == "" {
+				hosts[assembly] = host
+			}
+			if start, err := time.Parse(time.RFC3339Nano, result.Attrs["startTime"]); err == nil && (starts[assembly].IsZero() || start.Before(starts[assembly])) {
+				starts[assembly] = start
+			}
+		}
+	}
+
+	for _, assembly := range assemblies {
+		suite := junit.Suite{Name: assembly, Package: assembly, Properties: map[string]string{}}
+		if hosts[assembly] != "" {
+			suite.Properties["hostname"] = hosts[assembly]
+		}
+		if !starts[assembly].IsZero() {
+			suite.Properties[suiteTimestampAttribute] = starts[assembly].Format(time.RFC3339Nano)
+		}
+
+		for _, className := range classes[assembly] {
+			suite.Suites = append(suite.Suites, junit.Suite{Name: className, Package: className, Tests: tests[[2]string{assembly, className}]})
+		}
+		suite.Aggregate()
+
+		report.suites = append(report.suites, suite)
+	}
+
+	report.framework = trxFramework(definitions)
+	return report, nil
+}
+
+// trxDefinitions returns the definitions of the tests of the run, by their ID
+func trxDefinitions(run *xmlElement) map[string]trxTestDefinition {
+	definitions := map[string]trxTestDefinition{}
+	for _, group := range run.ChildrenNamed("TestDefinitions") {
+		for _, test := range group.Children {
+			definition := trxTestDefinition{storage: test.Attrs["storage"]}
+
+			if method := firstChildNamed(test, "TestMethod"); method != nil {
+				definition.className = method.Attrs["className"]
+				definition.adapter = method.Attrs["adapterTypeName"]
+				if definition.storage == "" {
+					definition.storage = method.Attrs["codeBase"]
+				}
+			}
+
+			for _, categories := range test.ChildrenNamed("TestCategory") {
+				for _, category := range categories.ChildrenNamed("TestCategoryItem") {
+					definition.categories = append(definition.categories, category.Attrs["TestCategory"])
+				}
+			}
+			for _, owners := range test.ChildrenNamed("Owners") {
+				for _, owner := range owners.ChildrenNamed("Owner") {
+					definition.owners = append(definition.owners, owner.Attrs["name"])
+				}
+			}
+
+			definitions[test.Attrs["id"]] = definition
+		}
+	}
+
+	return definitions
+}
+
+// trxTests returns the test case of the result, or the test cases of its inner results, i.e. the rows of the
+// data-driven tests
+func trxTests(result *xmlElement, definition trxTestDefinition) []junit.Test {
+	tests := []junit.Test{}
+	for _, inner := range result.ChildrenNamed("InnerResults") {
+		for _, innerResult := range inner.Children {
+			tests = append(tests, trxTests(innerResult, definition)...)
+		}
+	}
+	if len(tests) > 0 {
+		return tests
+	}
+
+	test := junit.Test{
+		Name:       result.Attrs["testName"],
+		Classname:  definition.className,
+		Duration:   parseTrxDuration(result.Attrs["duration"]),
+		Properties: map[string]string{},
+	}
+
+	outcome := result.Attrs["outcome"]
+	switch outcome {
+	case "Passed", "PassedButRunAborted", "Warning", "Completed":
+		test.Status = junit.StatusPassed
+	case "Failed", "Timeout":
+		test.Status = junit.StatusFailed
+	case "Error", "Aborted", "Disconnected":
+		test.Status = junit.StatusError
+	default:
+		// NotExecuted, Inconclusive, NotRunnable, Pending and InProgress
+		test.Status = junit.StatusSkipped
+	}
+
+	if output := firstChildNamed(result, "Output"); output != nil {
+		test.SystemOut = childText(output, "StdOut")
+		test.SystemErr = childText(output, "StdErr")
+
+		if info := firstChildNamed(output, "ErrorInfo"); info != nil {
+			test.Message = childText(info, "Message")
+			if test.Status != junit.StatusPassed && test.Status != junit.StatusSkipped {
+				test.Error = junit.Error{Message: test.Message, Body: childText(info, "StackTrace")}
+			}
+		}
+	}
+
+	// the tests exceeding their timeout have no error, or the one of the aborted test
+	if outcome == "Timeout" {
+		if test.Message == "" {
+			test.Message = outcome
+		}
+
+		junitErr, _ := test.Error.(junit.Error)
+		test.Error = junit.Error{Message: test.Message, Body: junitErr.Body, Type: outcome}
+	}
+
+	if len(definition.categories) > 0 {
+		test.Properties[TrxCategories] = strings.Join(definition.categories, ",")
+	}
+	if len(definition.owners) > 0 {
+		test.Properties[TrxOwners] = strings.Join(definition.owners, ",")
+	}
+
+	directory := result.Attrs["relativeResultsDirectory"]
+	for _, files := range result.ChildrenNamed("ResultFiles") {
+		for _, file := range files.ChildrenNamed("ResultFile") {
+			filePath := strings.ReplaceAll(file.Attrs["path"], `\`, "/")
+			name := strings.TrimSuffix(path.Base(filePath), path.Ext(filePath))
+			test.Properties[TestArtifactPrefix+name] = path.Join(directory, filePath)
+		}
+	}
+
+	return []junit.Test{test}
+}
+
+// trxFramework returns the test framework of the adapter of the tests, or VSTest if it's unknown
+func trxFramework(definitions map[string]trxTestDefinition) *testFramework {
+	for _, definition := range definitions {
+		adapter := strings.TrimPrefix(strings.ToLower(definition.adapter), "executor://")
+		name, _, _ := strings.Cut(adapter, "/")
+		if framework, ok := trxAdapters[name]; ok {
+			return &testFramework{Name: framework}
+		}
+	}
+
+	return &testFramework{Name: "vstest"}
+}
+
+// parseTrxDuration parses the duration of a result, as hh:mm:ss.fffffff, returning 0 if it's invalid
+func parseTrxDuration(value string) time.Duration {
+	matches := trxDurationRegex.FindStringSubmatch(strings.TrimSpace(value))
+	if matches == nil {
+		return 0
+	}
+
+	hours, _ := strconv.Atoi(matches[1])
+	minutes, _ := strconv.Atoi(matches[2])
+	seconds, _ := strconv.ParseFloat(matches[3], 64)
+
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds*float64(time.Second))
+}
+
+func firstChildNamed(element *xmlElement, name string) *xmlElement {
+	for _, child := range element.Children {
+		if child.Name == name {
+			return child
+		}
+	}
+
+	return nil
+}
+
+func childText(element *xmlElement, name string) string {
+	if child := firstChildNamed(element, name); child != nil {
+		return child.Text
+	}
+
+	return ""
+}
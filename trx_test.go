@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestParseTrxReport(t *testing.T) {
+	data, err := os.ReadFile("testdata/sample.trx")
+	require.NoError(t, err)
+
+	format, err := detectReportFormat(data)
+	require.NoError(t, err)
+	require.Equal(t, reportFormatTrx, format)
+
+	report, err := parseReport(reportFormatAuto, "testdata/sample.trx", data)
+	require.NoError(t, err)
+	require.Equal(t, &testFramework{Name: "mstest"}, report.framework)
+
+	// the run is described by the attributes of the run
+	require.Contains(t, report.attributes, attribute.Key(TrxRunName).String("builder@BUILD-07 2024-05-01 10:00:00"))
+	require.Contains(t, report.attributes, attribute.Key(TrxRunUser).String("builder"))
+	require.Contains(t, report.attributes, attribute.Key(TrxRunOutcome).String("Failed"))
+
+	// a suite per assembly, with a nested suite per class
+	require.Len(t, report.suites, 2)
+
+	calc := report.suites[0]
+	require.Equal(t, "calc.tests.dll", calc.Name)
+	require.Equal(t, "BUILD-07", calc.Properties["hostname"])
+	require.Equal(t, "2024-05-01T10:00:00.2Z", calc.Properties[suiteTimestampAttribute])
+	require.Len(t, calc.Suites, 1)
+	require.Equal(t, 4, calc.Totals.Tests)
+	require.Equal(t, 2, calc.Totals.Failed)
+
+	tests := calc.Suites[0].Tests
+	require.Equal(t, "Calc.Tests.CalculatorTests", calc.Suites[0].Name)
+	require.Len(t, tests, 4)
+
+	add := tests[0]
+	require.Equal(t, "Add_ReturnsSum", add.Name)
+	require.Equal(t, "Calc.Tests.CalculatorTests", add.Classname)
+	require.Equal(t, junit.StatusPassed, add.Status)
+	require.Equal(t, 12345600*time.Nanosecond, add.Duration)
+	require.Equal(t, "adding 1 and 2", add.SystemOut)
+	require.Equal(t, "Unit,Fast", add.Properties[TrxCategories])
+	require.Equal(t, "payments-team", add.Properties[TrxOwners])
+
+	divide := tests[1]
+	require.Equal(t, junit.StatusFailed, divide.Status)
+	require.Equal(t, "Assert.ThrowsException failed. No exception thrown.", divide.Message)
+	require.Equal(t, "warning: slow division", divide.SystemErr)
+	require.Contains(t, divide.Error.(junit.Error).Body, "CalculatorTests.cs:line 42")
+	require.Equal(t, "e2/BUILD-07/screenshot.png", divide.Properties[TestArtifactPrefix+"screenshot"])
+
+	// the rows of the data-driven test
+	require.Equal(t, "Multiply (2,3,6)", tests[2].Name)
+	require.Equal(t, junit.StatusPassed, tests[2].Status)
+	require.Equal(t, "Multiply (2,2,5)", tests[3].Name)
+	require.Equal(t, junit.StatusFailed, tests[3].Status)
+	require.Equal(t, "Assert.AreEqual failed. Expected:<5>. Actual:<4>.", tests[3].Message)
+
+	io := report.suites[1]
+	require.Equal(t, "io.tests.dll", io.Name)
+	require.Len(t, io.Suites, 2)
+
+	timeout := io.Suites[0].Tests[0]
+	require.Equal(t, "IO.Tests.ImportTests", io.Suites[0].Name)
+	require.Equal(t, junit.StatusFailed, timeout.Status)
+	require.Equal(t, time.Minute, timeout.Duration)
+	require.Equal(t, "Timeout", timeout.Error.(junit.Error).Type)
+
+	export := io.Suites[1].Tests[0]
+	require.Equal(t, junit.StatusSkipped, export.Status)
+	require.Equal(t, "Requires a printer", export.Message)
+	require.Nil(t, export.Error)
+}
+
+func TestTrxFramework(t *testing.T) {
+	for adapter, framework := range map[string]string{
+		"executor://mstestadapter/v2":               "mstest",
+		"executor://xunit/VsTestRunner2/netcoreapp": "xunit",
+		"executor://NUnit3TestExecutor":             "nunit",
+		"executor://custom/v1":                      "vstest",
+	} {
+		definitions := map[string]trxTestDefinition{"t1": {adapter: adapter}}
+		require.Equal(t, &testFramework{Name: framework}, trxFramework(definitions), adapter)
+	}
+}
+
+func TestParseTrxDuration(t *testing.T) {
+	require.Equal(t, 1500*time.Millisecond, parseTrxDuration("00:00:01.5000000"))
+	require.Equal(t, 2*time.Hour+3*time.Minute+4*time.Second, parseTrxDuration("02:03:04"))
+	require.Equal(t, time.Duration(0), parseTrxDuration("1.5s"))
+}
+
+func TestTrxInputs(t *testing.T) {
+	data, err := os.ReadFile("testdata/sample.trx")
+	require.NoError(t, err)
+
+	dir := filepath.Join(t.TempDir(), "TestResults")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "builder_BUILD-07.trx"), data, 0o644))
+
+	report, err := ingestInputs([]string{dir})
+	require.NoError(t, err)
+	require.Len(t, report.suites, 2)
+	require.Equal(t, reportFormatTrx, report.format)
+}
+
+func TestTrxInvalidReport(t *testing.T) {
+	_, err := parseReport(reportFormatTrx, "", []byte(`<TestRun><Results><UnitTestResult testId="t1">`))
+	require.Error(t, err)
+	require.Contains(t, fmt.Sprint(err), "TRX")
+}
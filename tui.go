@@ -0,0 +1,477 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/joshdk/go-junit"
+)
+
+const tuiCommand = "tui"
+
+// tuiView the level of the report shown by the terminal UI
+type tuiView int
+
+const (
+	tuiSuites tuiView = iota
+	tuiTests
+	tuiDetails
+)
+
+var (
+	tuiCursorStyle  = lipgloss.NewStyle().Reverse(true)
+	tuiFailedStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	tuiSkippedStyle = lipgloss.NewStyle().Faint(true)
+	tuiTitleStyle   = lipgloss.NewStyle().Bold(true)
+	tuiHelpStyle    = lipgloss.NewStyle().Faint(true)
+)
+
+// tuiModel the state of the terminal UI exploring a report: the suites, the test cases of a suite and the details of
+// a test case, the slowest first, and the suites and the test cases selected to be exported
+type tuiModel struct {
+	report *junitReport
+	// suites the indexes of the suites of the report, by duration
+	suites []int
+	// tests the test cases of the current suite, with the ones of its nested suites, by duration
+	tests []*junit.Test
+
+	view        tuiView
+	suiteCursor int
+	testCursor  int
+	// scroll the first line of the details of the test case shown
+	scroll int
+	height int
+	width  int
+
+	selectedSuites map[int]bool
+	selectedTests  map[*junit.Test]bool
+	// export is set when the selection is exported on exit
+	export bool
+}
+
+func newTuiModel(report *junitReport) *tuiModel {
+	suites := make([]int, len(report.suites))
+	for i := range suites {
+		suites[i] = i
+	}
+	sort.SliceStable(suites, func(a, b int) bool {
+		return report.suites[suites[a]].Totals.Duration > report.suites[suites[b]].Totals.Duration
+	})
+
+	return &tuiModel{
+		report:         report,
+		suites:         suites,
+		height:         24,
+		width:          80,
+		selectedSuites: map[int]bool{},
+		selectedTests:  map[*junit.Test]bool{},
+	}
+}
+
+// tuiSuiteTests returns the test cases of the suite and of its nested suites, by duration
+func tuiSuiteTests(suite *junit.Suite) []*junit.Test {
+	tests := appendSuiteTests(nil, suite)
+	sort.SliceStable(tests, func(a, b int) bool {
+		return tests[a].Duration > tests[b].Duration
+	})
+
+	return tests
+}
+
+func appendSuiteTests(tests []*junit.Test, suite *junit.Suite) []*junit.Test {
+	for i := range suite.Tests {
+		tests = append(tests, &suite.Tests[i])
+	}
+	for i := range suite.Suites {
+		tests = appendSuiteTests(tests, &suite.Suites[i])
+	}
+
+	return tests
+}
+
+func (m *tuiModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height, m.width = msg.Height, msg.Width
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c":
+			return m, tea.Quit
+		case "e":
+			m.export = true
+			return m, tea.Quit
+		case "up", "k":
+			m.move(-1)
+		case "down", "j":
+			m.move(1)
+		case "pgup":
+			m.move(-m.pageSize())
+		case "pgdown":
+			m.move(m.pageSize())
+		case "enter", "right", "l":
+			m.open()
+		case "esc", "left", "h", "backspace":
+			m.back()
+		case " ":
+			m.toggle()
+		}
+	}
+
+	return m, nil
+}
+
+// pageSize the number of lines of the lists and the details, without the title and the help
+func (m *tuiModel) pageSize() int {
+	return max(1, m.height-4)
+}
+
+func (m *tuiModel) move(delta int) {
+	switch m.view {
+	case tuiSuites:
+		m.suiteCursor = min(max(m.suiteCursor+delta, 0), max(len(m.suites)-1, 0))
+	case tuiTests:
+		m.testCursor = min(max(m.testCursor+delta, 0), max(len(m.tests)-1, 0))
+	case tuiDetails:
+		lines := testDetails(*m.tests[m.testCursor])
+		m.scroll = min(max(m.scroll+delta, 0), max(len(lines)-m.pageSize(), 0))
+	}
+}
+
+func (m *tuiModel) open() {
+	switch m.view {
+	case tuiSuites:
+		if len(m.suites) == 0 {
+			return
+		}
+		m.tests = tuiSuiteTests(&m.report.suites[m.suites[m.suiteCursor]])
+		m.testCursor = 0
+		m.view = tuiTests
+	case tuiTests:
+		if len(m.tests) == 0 {
+			return
+		}
+		m.scroll = 0
+		m.view = tuiDetails
+	}
+}
+
+func (m *tuiModel) back() {
+	if m.view > tuiSuites {
+		m.view--
+	}
+}
+
+// toggle selects the suite or the test case under the cursor, or unselects it
+func (m *tuiModel) toggle() {
+	switch m.view {
+	case tuiSuites:
+		if len(m.suites) > 0 {
+			index := m.suites[m.suiteCursor]
+			m.selectedSuites[index] = !m.selectedSuites[index]
+		}
+	case tuiTests, tuiDetails:
+		if len(m.tests) > 0 {
+			test := m.tests[m.testCursor]
+			m.selectedTests[test] = !m.selectedTests[test]
+		}
+	}
+}
+
+func (m *tuiModel) View() string {
+	var title string
+	var lines []string
+	var cursor int
+
+	switch m.view {
+	case tuiSuites:
+		title = fmt.Sprintf("%d suites", len(m.suites))
+		for _, index := range m.suites {
+			suite := m.report.suites[index]
+			line := fmt.Sprintf("%s %s %s  %s  %d tests, %d failed", m.selectionMark(m.selectedSuites[index]), suiteMark(suite.Totals), formatTuiDuration(suite.Totals.Duration), suite.Name, suite.Totals.Tests, suite.Totals.Failed+suite.Totals.Error)
+			lines = append(lines, statusStyle(suite.Totals).Render(line))
+		}
+		cursor = m.suiteCursor
+	case tuiTests:
+		suite := m.report.suites[m.suites[m.suiteCursor]]
+		title = fmt.Sprintf("%s: %d tests", suite.Name, len(m.tests))
+		for _, test := range m.tests {
+			line := fmt.Sprintf("%s %s %s  %s", m.selectionMark(m.selectedTests[test]), testMark(test.Status), formatTuiDuration(test.Duration), testDisplayName(*test))
+			lines = append(lines, testStyle(test.Status).Render(line))
+		}
+		cursor = m.testCursor
+	case tuiDetails:
+		test := m.tests[m.testCursor]
+		title = testDisplayName(*test)
+		lines = testDetails(*test)
+		cursor = -1
+	}
+
+	var b strings.Builder
+	b.WriteString(tuiTitleStyle.Render(title))
+	b.WriteString("\n\n")
+
+	first := m.scroll
+	if cursor >= 0 {
+		// the list scrolls to keep the cursor in the page
+		first = max(0, cursor-m.pageSize()+1)
+	}
+	for i := first; i < len(lines) && i < first+m.pageSize(); i++ {
+		// the long lines are cut, so the list keeps a line per item
+		line := lipgloss.NewStyle().MaxWidth(m.width).Render(lines[i])
+		if i == cursor {
+			line = tuiCursorStyle.Render(line)
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(tuiHelpStyle.Render(fmt.Sprintf("↑/↓ move • enter open • esc back • space select • e export %s • q quit", m.selectionSummary())))
+
+	return b.String()
+}
+
+func (m *tuiModel) selectionMark(selected bool) string {
+	if selected {
+		return "[x]"
+	}
+
+	return "[ ]"
+}
+
+// selectionSummary describes what the export would send: the selection, or the suite or the test under the cursor
+func (m *tuiModel) selectionSummary() string {
+	suites, tests := countSelected(m.selectedSuites), countSelected(m.selectedTests)
+	if suites == 0 && tests == 0 {
+		if m.view == tuiSuites {
+			return "the suite"
+		}
+
+		return "the test"
+	}
+
+	return fmt.Sprintf("%d suites and %d tests", suites, tests)
+}
+
+func countSelected[K comparable](selected map[K]bool) int {
+	count := 0
+	for _, ok := range selected {
+		if ok {
+			count++
+		}
+	}
+
+	return count
+}
+
+// selection returns the report with the selected suites and test cases, or with the suite or the test case under
+// the cursor if none is selected. The selected suites are kept with all their test cases
+func (m *tuiModel) selection() *junitReport {
+	suites, tests := m.selectedSuites, m.selectedTests
+	if countSelected(suites) == 0 && countSelected(tests) == 0 && len(m.suites) > 0 {
+		if m.view == tuiSuites {
+			suites = map[int]bool{m.suites[m.suiteCursor]: true}
+		} else if len(m.tests) > 0 {
+			tests = map[*junit.Test]bool{m.tests[m.testCursor]: true}
+		}
+	}
+
+	selected := &junitReport{rawRoot: m.report.rawRoot, format: m.report.format, framework: m.report.framework, attributes: m.report.attributes}
+	for i := range m.report.suites {
+		suite, ok := selectSuite(&m.report.suites[i], suites[i], tests)
+		if !ok {
+			continue
+		}
+
+		selected.suites = append(selected.suites, suite)
+		selected.rawSuites = append(selected.rawSuites, m.report.rawSuite(i))
+		selected.devices = append(selected.devices, m.report.device(i))
+		selected.sources = append(selected.sources, m.report.source(i))
+	}
+
+	return selected
+}
+
+// selectSuite returns the suite with its selected test cases and nested suites, and if it's kept, as the filters do
+func selectSuite(suite *junit.Suite, whole bool, tests map[*junit.Test]bool) (junit.Suite, bool) {
+	if whole {
+		return *suite, true
+	}
+
+	kept := *suite
+	kept.Tests = []junit.Test{}
+	for i := range suite.Tests {
+		if tests[&suite.Tests[i]] {
+			kept.Tests = append(kept.Tests, suite.Tests[i])
+		}
+	}
+
+	kept.Suites = []junit.Suite{}
+	for i := range suite.Suites {
+		if nested, ok := selectSuite(&suite.Suites[i], false, tests); ok {
+			kept.Suites = append(kept.Suites, nested)
+		}
+	}
+
+	if len(kept.Tests) == 0 && len(kept.Suites) == 0 {
+		return kept, false
+	}
+
+	kept.Aggregate()
+	return kept, true
+}
+
+// testDetails returns the lines of the details of the test case: its result, its failure and its outputs
+func testDetails(test junit.Test) []string {
+	lines := []string{
+		"Class:    " + test.Classname,
+		"Status:   " + string(test.Status),
+		"Duration: " + formatTuiDuration(test.Duration),
+	}
+	if test.Message != "" {
+		lines = append(lines, "Message:  "+test.Message)
+	}
+
+	var failure junit.Error
+	if errors.As(test.Error, &failure) {
+		if failure.Type != "" {
+			lines = append(lines, "Type:     "+failure.Type)
+		}
+		if failure.Body != "" {
+			lines = append(lines, "", "Failure:")
+			lines = append(lines, strings.Split(strings.TrimRight(failure.Body, "\n"), "\n")...)
+		}
+	}
+
+	for _, output := range []struct{ name, content string }{{"Output:", test.SystemOut}, {"Error output:", test.SystemErr}} {
+		if strings.TrimSpace(output.content) != "" {
+			lines = append(lines, "", output.name)
+			lines = append(lines, strings.Split(strings.TrimRight(output.content, "\n"), "\n")...)
+		}
+	}
+
+	if len(test.Properties) > 0 {
+		lines = append(lines, "", "Properties:")
+		names := make([]string, 0, len(test.Properties))
+		for name := range test.Properties {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			lines = append(lines, fmt.Sprintf("  %s: %s", name, test.Properties[name]))
+		}
+	}
+
+	return lines
+}
+
+func testDisplayName(test junit.Test) string {
+	if test.Classname == "" {
+		return test.Name
+	}
+
+	return test.Classname + " › " + test.Name
+}
+
+// formatTuiDuration formats the duration with a fixed width, so the names of the lists are aligned
+func formatTuiDuration(d time.Duration) string {
+	return fmt.Sprintf("%9s", d.Round(time.Millisecond))
+}
+
+func suiteMark(totals junit.Totals) string {
+	switch {
+	case totals.Failed+totals.Error > 0:
+		return "✗"
+	case totals.Tests > 0 && totals.Skipped == totals.Tests:
+		return "-"
+	default:
+		return "✓"
+	}
+}
+
+func testMark(status junit.Status) string {
+	switch status {
+	case junit.StatusFailed, junit.StatusError:
+		return "✗"
+	case junit.StatusSkipped:
+		return "-"
+	default:
+		return "✓"
+	}
+}
+
+func statusStyle(totals junit.Totals) lipgloss.Style {
+	switch suiteMark(totals) {
+	case "✗":
+		return tuiFailedStyle
+	case "-":
+		return tuiSkippedStyle
+	default:
+		return lipgloss.NewStyle()
+	}
+}
+
+func testStyle(status junit.Status) lipgloss.Style {
+	switch testMark(status) {
+	case "✗":
+		return tuiFailedStyle
+	case "-":
+		return tuiSkippedStyle
+	default:
+		return lipgloss.NewStyle()
+	}
+}
+
+// runTui parses the flags of the tui command, and explores the reports given as arguments in the terminal, exporting
+// the selected suites and test cases on exit if requested
+func runTui(args []string) {
+	if err := resolveFlags(flag.CommandLine, args); err != nil {
+		log.Fatal(err)
+	}
+
+	if flag.NArg() == 0 {
+		log.Fatal("the tui command needs the report files or directories to explore")
+	}
+
+	report, err := ingestInputs(flag.Args())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	report, err = selectReport(report)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	result, err := tea.NewProgram(newTuiModel(report), tea.WithAltScreen()).Run()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	model := result.(*tuiModel)
+	if !model.export {
+		return
+	}
+
+	selection := model.selection()
+	if err := exportReport(context.Background(), func() (*junitReport, error) {
+		return selection, nil
+	}); err != nil {
+		exit(err)
+	}
+
+	log.Printf("exported %d suites with %d tests", len(selection.suites), summarize(selection).Total)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTui(t *testing.T) {
+	newModel := func(t *testing.T) *tuiModel {
+		t.Helper()
+		report, err := parseReport(reportFormatJUnit, "", []byte(`<testsuites>
+	<testsuite name="fast" tests="1">
+		<testcase classname="Fast" name="quick" time="0.1"/>
+	</testsuite>
+	<testsuite name="slow" tests="3" failures="1">
+		<testcase classname="Slow" name="passing" time="1"/>
+		<testcase classname="Slow" name="failing" time="3">
+			<failure message="expected 1" type="AssertionError">at Slow.failing(Slow.java:12)</failure>
+			<system-out>connecting</system-out>
+		</testcase>
+		<testcase classname="Slow" name="skipped" time="0"><skipped/></testcase>
+	</testsuite>
+</testsuites>`))
+		require.NoError(t, err)
+		return newTuiModel(report)
+	}
+
+	press := func(m *tuiModel, keys ...string) {
+		for _, key := range keys {
+			msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+			switch key {
+			case "enter":
+				msg = tea.KeyMsg{Type: tea.KeyEnter}
+			case "esc":
+				msg = tea.KeyMsg{Type: tea.KeyEsc}
+			case " ":
+				msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}
+			}
+			m.Update(msg)
+		}
+	}
+
+	t.Run("Navigates from the slowest suites to the details of the tests", func(t *testing.T) {
+		m := newModel(t)
+
+		view := m.View()
+		require.Contains(t, view, "2 suites")
+		require.Less(t, strings.Index(view, "slow"), strings.Index(view, "fast"))
+		require.Contains(t, view, "3 tests, 1 failed")
+
+		press(m, "enter")
+		view = m.View()
+		require.Contains(t, view, "slow: 3 tests")
+		require.Less(t, strings.Index(view, "Slow › failing"), strings.Index(view, "Slow › passing"))
+		require.Less(t, strings.Index(view, "Slow › passing"), strings.Index(view, "Slow › skipped"))
+
+		press(m, "enter")
+		view = m.View()
+		require.Contains(t, view, "Status:   failed")
+		require.Contains(t, view, "Type:     AssertionError")
+		require.Contains(t, view, "at Slow.failing(Slow.java:12)")
+		require.Contains(t, view, "connecting")
+
+		press(m, "esc", "esc", "j", "enter")
+		require.Contains(t, m.View(), "fast: 1 tests")
+	})
+
+	t.Run("Exports the suite under the cursor without a selection", func(t *testing.T) {
+		m := newModel(t)
+		press(m, "j")
+
+		_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+		require.NotNil(t, cmd)
+		require.True(t, m.export)
+
+		selection := m.selection()
+		require.Len(t, selection.suites, 1)
+		require.Equal(t, "fast", selection.suites[0].Name)
+		require.Len(t, selection.rawSuites, 1)
+	})
+
+	t.Run("Exports the selected suites and tests", func(t *testing.T) {
+		m := newModel(t)
+		press(m, "j", " ", "k", "enter", " ", "j", "j", " ")
+		require.Contains(t, m.View(), "1 suites and 2 tests")
+
+		selection := m.selection()
+		require.Len(t, selection.suites, 2)
+		// in the order of the report
+		require.Equal(t, "fast", selection.suites[0].Name)
+		require.Equal(t, "slow", selection.suites[1].Name)
+		require.Len(t, selection.suites[1].Tests, 2)
+		require.Equal(t, "failing", selection.suites[1].Tests[0].Name)
+		require.Equal(t, "skipped", selection.suites[1].Tests[1].Name)
+		require.Equal(t, 1, selection.suites[1].Totals.Failed)
+	})
+
+	t.Run("Quits without exporting", func(t *testing.T) {
+		m := newModel(t)
+		_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+		require.NotNil(t, cmd)
+		require.False(t, m.export)
+	})
+}
@@ -0,0 +1,440 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strings"
+)
+
+const validateCommand = "validate"
+
+const (
+	schemaAuto     = "auto"
+	schemaAnt      = "ant"
+	schemaJenkins  = "jenkins"
+	schemaSurefire = "surefire"
+)
+
+const (
+	strictnessLenient = "lenient"
+	strictnessStrict  = "strict"
+)
+
+const (
+	issueError   = "error"
+	issueWarning = "warning"
+)
+
+// schemaElement the attributes and children allowed for an element by a JUnit XSD, and its required attributes
+type schemaElement struct {
+	attributes []string
+	required   []string
+	children   []string
+}
+
+// junitSchemas the common JUnit XSDs: the one of Ant, the one of the Maven Surefire reports, and the one
+// of the Jenkins JUnit plugin, which is the most permissive one
+var junitSchemas = map[string]map[string]schemaElement{
+	schemaAnt: {
+		"testsuites": {children: []string{"testsuite"}},
+		"testsuite": {
+			attributes: []string{"name", "timestamp", "hostname", "tests", "failures", "errors", "time", "package", "id"},
+			required:   []string{"name", "tests", "failures", "errors", "time"},
+			children:   []string{"properties", "testcase", "system-out", "system-err"},
+		},
+		"properties": {children: []string{"property"}},
+		"property":   {attributes: []string{"name", "value"}, required: []string{"name", "value"}},
+		"testcase": {
+			attributes: []string{"name", "classname", "time"},
+			required:   []string{"name", "classname", "time"},
+			children:   []string{"error", "failure"},
+		},
+		"failure":    {attributes: []string{"message", "type"}, required: []string{"type"}},
+		"error":      {attributes: []string{"message", "type"}, required: []string{"type"}},
+		"system-out": {},
+		"system-err": {},
+	},
+	schemaSurefire: {
+		"testsuite": {
+			attributes: []string{"name", "time", "tests", "errors", "skipped", "failures", "group", "version"},
+			required:   []string{"name", "tests", "errors", "skipped", "failures"},
+			children:   []string{"properties", "testcase"},
+		},
+		"properties": {children: []string{"property"}},
+		"property":   {attributes: []string{"name", "value"}, required: []string{"name", "value"}},
+		"testcase": {
+			attributes: []string{"name", "classname", "group", "time"},
+			required:   []string{"name"},
+			children:   []string{"failure", "rerunFailure", "rerunError", "flakyFailure", "flakyError", "skipped", "error", "system-out", "system-err"},
+		},
+		"failure":      {attributes: []string{"message", "type"}, required: []string{"type"}},
+		"error":        {attributes: []string{"message", "type"}, required: []string{"type"}},
+		"skipped":      {attributes: []string{"message"}},
+		"rerunFailure": {attributes: []string{"message", "type"}, required: []string{"type"}, children: []string{"stackTrace", "system-out", "system-err"}},
+		"rerunError":   {attributes: []string{"message", "type"}, required: []string{"type"}, children: []string{"stackTrace", "system-out", "system-err"}},
+		"flakyFailure": {attributes: []string{"message", "type"}, required: []string{"type"}, children: []string{"stackTrace", "system-out", "system-err"}},
+		"flakyError":   {attributes: []string{"message", "type"}, required: []string{"type"}, children: []string{"stackTrace", "system-out", "system-err"}},
+		"stackTrace":   {},
+		"system-out":   {},
+		"system-err":   {},
+	},
+	schemaJenkins: {
+		"testsuites": {
+			attributes: []string{"name", "time", "tests", "failures", "disabled", "errors"},
+			children:   []string{"testsuite"},
+		},
+		"testsuite": {
+			attributes: []string{"name", "tests", "failures", "errors", "time", "disabled", "skipped", "timestamp", "hostname", "id", "package", "file", "log", "url", "version"},
+			required:   []string{"name", "tests"},
+			children:   []string{"properties", "testcase", "system-out", "system-err"},
+		},
+		"properties": {children: []string{"property"}},
+		"property":   {attributes: []string{"name", "value"}, required: []string{"name", "value"}},
+		"testcase": {
+			attributes: []string{"name", "assertions", "time", "classname", "status"},
+			required:   []string{"name"},
+			children:   []string{"skipped", "error", "failure", "system-out", "system-err"},
+		},
+		"skipped":    {attributes: []string{"message"}},
+		"failure":    {attributes: []string{"message", "type"}},
+		"error":      {attributes: []string{"message", "type"}},
+		"system-out": {},
+		"system-err": {},
+	},
+}
+
+// xmlSchemaAttributes the attributes of the XML namespaces and schema locations, allowed in any element
+var xmlSchemaAttributes = []string{"xmlns", "xsi", "noNamespaceSchemaLocation", "schemaLocation"}
+
+// ingestedSuiteChildren and ingestedTestChildren the children of the suites and the test cases read by the tool
+var ingestedSuiteChildren = []string{"testsuite", "testcase", "properties", "system-out", "system-err"}
+var ingestedTestChildren = []string{"skipped", "failure", "error", "system-out", "system-err"}
+
+// rawSuiteAttributes the attributes of the suites read by the tool even if they are replaced by the properties
+var rawSuiteAttributes = []string{"name", "package", "tests", "failures", "errors", "skipped", "version", "noNamespaceSchemaLocation", "xmlns", "xsi"}
+
+// validationIssue an issue found in the report, with the path of the element where it was found, and whether it's
+// a field dropped by the tool rather than a deviation from the schema
+type validationIssue struct {
+	path     string
+	severity string
+	message  string
+	dropped  bool
+}
+
+func (i validationIssue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.severity, i.path, i.message)
+}
+
+// validationResult the issues found in the report, validated against the given schema
+type validationResult struct {
+	schema string
+	issues []validationIssue
+}
+
+// errors returns the number of issues making the report invalid
+func (r *validationResult) errors() int {
+	errors := 0
+	for _, issue := range r.issues {
+		if issue.severity == issueError {
+			errors++
+		}
+	}
+
+	return errors
+}
+
+// validateReport validates the jUnit report against the schema, or the closest one if it's auto, reporting the
+// deviations from the schema as errors if the strictness is strict, or as warnings if it's lenient. The fields that
+// the tool drops are reported too, so the users can find out why some attributes are missing: as errors with
+// --strict, or as warnings
+func validateReport(data []byte, schema string, strictness string) (*validationResult, error) {
+	severity := issueWarning
+	switch strictness {
+	case strictnessStrict:
+		severity = issueError
+	case strictnessLenient:
+	default:
+		return nil, fmt.Errorf("invalid strictness %q: valid values are %s and %s", strictness, strictnessLenient, strictnessStrict)
+	}
+
+	recovered := &dropLedger{}
+	root, err := parseXMLElements(sanitizeXMLReport(data, recovered))
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []string{schema}
+	if schema == schemaAuto {
+		candidates = []string{schemaJenkins, schemaSurefire, schemaAnt}
+	} else if _, ok := junitSchemas[schema]; !ok {
+		return nil, fmt.Errorf("invalid schema %q: valid values are %s, %s, %s and %s", schema, schemaAuto, schemaAnt, schemaJenkins, schemaSurefire)
+	}
+
+	var result *validationResult
+	for _, candidate := range candidates {
+		issues := schemaIssues(junitSchemas[candidate], root, severity)
+		if result == nil || len(issues) < len(result.issues) {
+			result = &validationResult{schema: candidate, issues: issues}
+		}
+	}
+
+	droppedSeverity := issueWarning
+	if strictFlag {
+		droppedSeverity = issueError
+	}
+
+	// the characters removed to parse the report are dropped too
+	for _, kind := range sortedDropKinds(recovered.counts) {
+		result.issues = append(result.issues, validationIssue{"report", droppedSeverity, fmt.Sprintf("%d %s", recovered.counts[kind], kind.description), true})
+	}
+	result.issues = append(result.issues, droppedFields(root, droppedSeverity)...)
+
+	return result, nil
+}
+
+// schemaIssues returns the deviations of the document from the schema
+func schemaIssues(schema map[string]schemaElement, root *xmlElement, severity string) []validationIssue {
+	issues := []validationIssue{}
+
+	var walk func(element *xmlElement, path string)
+	walk = func(element *xmlElement, path string) {
+		definition := schema[element.Name]
+
+		for _, name := range sortedAttributes(element) {
+			if !slices.Contains(definition.attributes, name) && !slices.Contains(xmlSchemaAttributes, name) {
+				issues = append(issues, validationIssue{path, severity, fmt.Sprintf("attribute %q is not allowed by the schema", name), false})
+			}
+		}
+
+		for _, name := range definition.required {
+			if _, ok := element.Attr(name); !ok {
+				issues = append(issues, validationIssue{path, severity, fmt.Sprintf("required attribute %q is missing", name), false})
+			}
+		}
+
+		for _, child := range element.Children {
+			childPath := path + " > " + describeElement(child)
+			if !slices.Contains(definition.children, child.Name) {
+				issues = append(issues, validationIssue{childPath, severity, fmt.Sprintf("element %q is not allowed in %q by the schema", child.Name, element.Name), false})
+				continue
+			}
+
+			walk(child, childPath)
+		}
+	}
+
+	for _, element := range root.Children {
+		if _, ok := schema[element.Name]; !ok || (element.Name != "testsuites" && element.Name != "testsuite") {
+			issues = append(issues, validationIssue{describeElement(element), severity, fmt.Sprintf("root element %q is not allowed by the schema", element.Name), false})
+			continue
+		}
+
+		walk(element, describeElement(element))
+	}
+
+	return issues
+}
+
+// droppedFields returns the fields of the report which are not exported by the tool, as go-junit does not read them,
+// with the severity
+func droppedFields(root *xmlElement, severity string) []validationIssue {
+	issues := []validationIssue{}
+	dropped := func(path string, format string, args ...any) {
+		issues = append(issues, validationIssue{path, severity, fmt.Sprintf(format, args...), true})
+	}
+
+	var walkTest func(test *xmlElement, path string)
+	walkTest = func(test *xmlElement, path string) {
+		if value, ok := test.Attr("time"); ok && value != "" {
+			if d, valid := testCaseDuration(value); !valid || d < 0 {
+				dropped(path, "time %q is not a valid duration, the duration of the test case will be 0", value)
+			}
+		}
+
+		for _, name := range sortedAttributes(test) {
+			if name != "name" && name != "classname" && name != "time" && !propertyAllowed(name) {
+				dropped(path, "attribute %q is dropped, as it's not in the --properties-allowed list", name)
+			}
+		}
+
+		results := 0
+		for _, child := range test.Children {
+			childPath := path + " > " + describeElement(child)
+			switch {
+			case !slices.Contains(ingestedTestChildren, child.Name):
+				dropped(childPath, "element %q is dropped, the test case keeps only its skipped, failure, error, system-out and system-err elements", child.Name)
+			case child.Name != "system-out" && child.Name != "system-err":
+				results++
+			}
+		}
+
+		if results > 1 {
+			dropped(path, "the test case has %d skipped, failure or error elements, only the last one is kept", results)
+		}
+	}
+
+	var walkSuite func(suite *xmlElement, path string)
+	walkSuite = func(suite *xmlElement, path string) {
+		properties := suite.ChildrenNamed("properties")
+		if len(properties) > 0 {
+			for _, name := range sortedAttributes(suite) {
+				if !slices.Contains(rawSuiteAttributes, name) {
+					dropped(path, "attribute %q is dropped, as the properties element of the suite replaces its attributes", name)
+				}
+			}
+		}
+
+		if len(properties) > 1 {
+			dropped(path, "the suite has %d properties elements, only the last one is kept", len(properties))
+		}
+
+		for _, child := range suite.Children {
+			childPath := path + " > " + describeElement(child)
+			switch child.Name {
+			case "testsuite":
+				walkSuite(child, childPath)
+			case "testcase":
+				walkTest(child, childPath)
+			case "properties":
+				for _, property := range child.ChildrenNamed("property") {
+					name, ok := property.Attr("name")
+					switch {
+					case !ok || name == "":
+						dropped(childPath+" > property", "the property has no name, it's dropped")
+					case !propertyAllowed(name):
+						dropped(childPath+" > "+describeElement(property), "property %q is dropped, as it's not in the --properties-allowed list", name)
+					}
+				}
+			default:
+				if !slices.Contains(ingestedSuiteChildren, child.Name) {
+					dropped(childPath, "element %q is dropped, the suite keeps only its testsuite, testcase, properties, system-out and system-err elements", child.Name)
+				}
+			}
+		}
+	}
+
+	for _, suite := range findRawSuites(root.Children) {
+		walkSuite(suite, describeElement(suite))
+	}
+
+	return issues
+}
+
+// describeElement describes the element in the paths of the issues, with its name attribute if present
+func describeElement(element *xmlElement) string {
+	if name, ok := element.Attr("name"); ok {
+		return fmt.Sprintf("%s[name=%q]", element.Name, name)
+	}
+
+	return element.Name
+}
+
+func sortedAttributes(element *xmlElement) []string {
+	names := make([]string, 0, len(element.Attrs))
+	for name := range element.Attrs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// checkStrictness validates the jUnit report before exporting it if the strictness is strict, or with --strict,
+// returning an error with its deviations from the schema, or with all the fields the tool would drop
+func checkStrictness(data []byte) error {
+	if strictnessFlag != strictnessStrict && !strictFlag {
+		return nil
+	}
+
+	result, err := validateReport(data, schemaFlag, strictnessFlag)
+	if err != nil {
+		return err
+	}
+
+	if result.errors() == 0 {
+		return nil
+	}
+
+	deviations, dropped := []string{}, []string{}
+	for _, issue := range result.issues {
+		switch {
+		case issue.severity != issueError:
+		case issue.dropped:
+			dropped = append(dropped, "  "+issue.path+": "+issue.message)
+		default:
+			deviations = append(deviations, issue.path+": "+issue.message)
+		}
+	}
+
+	errs := []error{}
+	if len(deviations) > 0 {
+		errs = append(errs, fmt.Errorf("the report is not valid against the %s schema: %s", result.schema, strings.Join(deviations, "; ")))
+	}
+	if len(dropped) > 0 {
+		errs = append(errs, fmt.Errorf("the report has %d fields the tool would drop, refused by --strict:\n%s", len(dropped), strings.Join(dropped, "\n")))
+	}
+
+	return errors.Join(errs...)
+}
+
+// validate validates the reports, or the standard input if there are none, writing the issues to the writer,
+// and returns the number of reports with errors
+func validate(paths []string, stdin io.Reader, out io.Writer) (int, error) {
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+
+	invalid := 0
+	for _, path := range paths {
+		var data []byte
+		var err error
+		if path == "-" {
+			data, err = io.ReadAll(stdin)
+		} else {
+			data, err = os.ReadFile(path)
+		}
+		if err != nil {
+			return invalid, fmt.Errorf("failed to read the report: %w", err)
+		}
+
+		result, err := validateReport(data, schemaFlag, strictnessFlag)
+		if err != nil {
+			return invalid, fmt.Errorf("%s: %w", path, err)
+		}
+
+		for _, issue := range result.issues {
+			fmt.Fprintf(out, "%s: %s\n", path, issue)
+		}
+
+		status := "valid"
+		if result.errors() > 0 {
+			status = "invalid"
+			invalid++
+		}
+		fmt.Fprintf(out, "%s: %s against the %s schema, %d errors and %d warnings\n", path, status, result.schema, result.errors(), len(result.issues)-result.errors())
+	}
+
+	return invalid, nil
+}
+
+// runValidate parses the flags of the validate command, and exits with an error if any report is not valid
+func runValidate(args []string) {
+	if err := resolveFlags(flag.CommandLine, args); err != nil {
+		log.Fatal(err)
+	}
+
+	invalid, err := validate(flag.Args(), os.Stdin, os.Stdout)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if invalid > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateReport(t *testing.T) {
+	surefireReport := []byte(`<testsuite name="checkout" tests="1" errors="0" skipped="0" failures="0">
+  <testcase name="TestLogin" classname="LoginTest" time="0.5" retries="1">
+    <flakyFailure message="timeout" type="java.lang.AssertionError"/>
+  </testcase>
+</testsuite>`)
+
+	messages := func(result *validationResult) []string {
+		messages := []string{}
+		for _, issue := range result.issues {
+			messages = append(messages, issue.String())
+		}
+		return messages
+	}
+
+	t.Run("Lenient", func(t *testing.T) {
+		result, err := validateReport(surefireReport, schemaSurefire, strictnessLenient)
+		require.NoError(t, err)
+
+		require.Equal(t, schemaSurefire, result.schema)
+		require.Equal(t, 0, result.errors())
+		require.Equal(t, []string{
+			`warning: testsuite[name="checkout"] > testcase[name="TestLogin"]: attribute "retries" is not allowed by the schema`,
+			`warning: testsuite[name="checkout"] > testcase[name="TestLogin"] > flakyFailure: element "flakyFailure" is dropped, the test case keeps only its skipped, failure, error, system-out and system-err elements`,
+		}, messages(result))
+	})
+
+	t.Run("Strict", func(t *testing.T) {
+		result, err := validateReport(surefireReport, schemaAnt, strictnessStrict)
+		require.NoError(t, err)
+
+		require.Equal(t, 4, result.errors())
+		require.Contains(t, messages(result), `error: testsuite[name="checkout"]: attribute "skipped" is not allowed by the schema`)
+		require.Contains(t, messages(result), `error: testsuite[name="checkout"]: required attribute "time" is missing`)
+		require.Contains(t, messages(result), `error: testsuite[name="checkout"] > testcase[name="TestLogin"] > flakyFailure: element "flakyFailure" is not allowed in "testcase" by the schema`)
+		// the dropped fields are always warnings
+		require.Contains(t, messages(result), `warning: testsuite[name="checkout"] > testcase[name="TestLogin"] > flakyFailure: element "flakyFailure" is dropped, the test case keeps only its skipped, failure, error, system-out and system-err elements`)
+	})
+
+	t.Run("Auto picks the closest schema", func(t *testing.T) {
+		result, err := validateReport(surefireReport, schemaAuto, strictnessStrict)
+		require.NoError(t, err)
+		require.Equal(t, schemaSurefire, result.schema)
+
+		result, err = validateReport([]byte(`<testsuites><testsuite name="a" tests="1" hostname="ci"><testcase name="b" status="run"/></testsuite></testsuites>`), schemaAuto, strictnessStrict)
+		require.NoError(t, err)
+		require.Equal(t, schemaJenkins, result.schema)
+		require.Equal(t, 0, result.errors())
+	})
+
+	t.Run("Suite attributes replaced by the properties", func(t *testing.T) {
+		result, err := validateReport([]byte(`<testsuite name="a" tests="1" hostname="ci">
+  <properties><property name="browser" value="chrome"/><property value="orphan"/></properties>
+  <testcase name="b" time="forever"><skipped/><failure type="x"/></testcase>
+</testsuite>`), schemaJenkins, strictnessLenient)
+		require.NoError(t, err)
+
+		require.Equal(t, []string{
+			`warning: testsuite[name="a"] > properties > property: required attribute "name" is missing`,
+			`warning: testsuite[name="a"]: attribute "hostname" is dropped, as the properties element of the suite replaces its attributes`,
+			`warning: testsuite[name="a"] > properties > property: the property has no name, it's dropped`,
+			`warning: testsuite[name="a"] > testcase[name="b"]: time "forever" is not a valid duration, the duration of the test case will be 0`,
+			`warning: testsuite[name="a"] > testcase[name="b"]: the test case has 2 skipped, failure or error elements, only the last one is kept`,
+		}, messages(result))
+	})
+
+	t.Run("Invalid input", func(t *testing.T) {
+		_, err := validateReport([]byte(`<testsuite`), schemaAuto, strictnessLenient)
+		require.Error(t, err)
+
+		_, err = validateReport(surefireReport, "xunit", strictnessLenient)
+		require.Error(t, err)
+
+		_, err = validateReport(surefireReport, schemaAuto, "pedantic")
+		require.Error(t, err)
+	})
+}
+
+func TestValidate(t *testing.T) {
+	defer func(strictness, schema string) { strictnessFlag, schemaFlag = strictness, schema }(strictnessFlag, schemaFlag)
+	schemaFlag = schemaAnt
+
+	report := `<testsuite name="a" tests="1" failures="0" errors="0"><testcase name="b" classname="c" time="1"/></testsuite>`
+
+	t.Run("Lenient", func(t *testing.T) {
+		strictnessFlag = strictnessLenient
+
+		out := &bytes.Buffer{}
+		invalid, err := validate(nil, strings.NewReader(report), out)
+		require.NoError(t, err)
+		require.Equal(t, 0, invalid)
+		require.Contains(t, out.String(), `-: warning: testsuite[name="a"]: required attribute "time" is missing`)
+		require.Contains(t, out.String(), "-: valid against the ant schema, 0 errors and 1 warnings")
+
+		require.NoError(t, checkStrictness([]byte(report)))
+	})
+
+	t.Run("Strict", func(t *testing.T) {
+		strictnessFlag = strictnessStrict
+
+		out := &bytes.Buffer{}
+		invalid, err := validate(nil, strings.NewReader(report), out)
+		require.NoError(t, err)
+		require.Equal(t, 1, invalid)
+		require.Contains(t, out.String(), "-: invalid against the ant schema, 1 errors and 0 warnings")
+
+		err = checkStrictness([]byte(report))
+		require.EqualError(t, err, `the report is not valid against the ant schema: testsuite[name="a"]: required attribute "time" is missing`)
+	})
+}
+
+func TestStrict(t *testing.T) {
+	defer func(strictness, schema string, strict bool) {
+		strictnessFlag, schemaFlag, strictFlag = strictness, schema, strict
+	}(strictnessFlag, schemaFlag, strictFlag)
+	defer func(allowed string) { propertiesAllowedString = allowed }(propertiesAllowedString)
+	strictnessFlag, schemaFlag = strictnessLenient, schemaAnt
+	propertiesAllowedString = "ci.build"
+
+	report := "<testsuite name=\"a\" tests=\"1\" failures=\"0\" errors=\"0\" time=\"1\">" +
+		"<properties><property name=\"ci.build\" value=\"1\"/><property name=\"secret\" value=\"x\"/></properties>" +
+		"<testcase name=\"b\" classname=\"c\" time=\"1\"><rerunFailure type=\"e\"/><system-out>a\x01b</system-out></testcase></testsuite>"
+
+	t.Run("The dropped fields are warnings without --strict", func(t *testing.T) {
+		strictFlag = false
+
+		require.NoError(t, checkStrictness([]byte(report)))
+	})
+
+	t.Run("The dropped fields are refused with --strict", func(t *testing.T) {
+		strictFlag = true
+
+		err := checkStrictness([]byte(report))
+		require.EqualError(t, err, `the report has 4 fields the tool would drop, refused by --strict:
+  report: 1 characters not allowed in XML removed
+  testsuite[name="a"]: attribute "time" is dropped, as the properties element of the suite replaces its attributes
+  testsuite[name="a"] > properties > property[name="secret"]: property "secret" is dropped, as it's not in the --properties-allowed list
+  testsuite[name="a"] > testcase[name="b"] > rerunFailure: element "rerunFailure" is dropped, the test case keeps only its skipped, failure, error, system-out and system-err elements`)
+
+		out := &bytes.Buffer{}
+		invalid, err := validate(nil, strings.NewReader(report), out)
+		require.NoError(t, err)
+		require.Equal(t, 1, invalid)
+		require.Contains(t, out.String(), "-: invalid against the ant schema, 4 errors and 2 warnings")
+	})
+
+	t.Run("Along with the deviations from the schema", func(t *testing.T) {
+		strictFlag, strictnessFlag = true, strictnessStrict
+
+		err := checkStrictness([]byte(report))
+		require.ErrorContains(t, err, `the report is not valid against the ant schema: testsuite[name="a"] > testcase[name="b"] > rerunFailure: element "rerunFailure" is not allowed in "testcase" by the schema`)
+		require.ErrorContains(t, err, "the report has 4 fields the tool would drop")
+	})
+}
@@ -0,0 +1,4 @@
+package main
+
+// version the version of the tool, populated by goreleaser at build time
+var version = "dev"
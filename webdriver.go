@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/joshdk/go-junit"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// webDriverSessionProperties the properties of the test cases holding the WebDriver session ID
+var webDriverSessionProperties = []string{"webdriver.session.id", "sessionId", "session_id", "session-id"}
+
+// defaultWebDriverSessionRegex matches the WebDriver session IDs logged by the tests, i.e. "session id: 4f0c..."
+var defaultWebDriverSessionRegex = regexp.MustCompile(`(?i)session[ _-]?id\s*[:=]\s*"?([0-9a-f]{8}-?[0-9a-f]{4}-?[0-9a-f]{4}-?[0-9a-f]{4}-?[0-9a-f]{12})`)
+
+// webDriverTraceparentRegex matches the W3C trace context of the browser session logged by the tests
+var webDriverTraceparentRegex = regexp.MustCompile(`(?i)traceparent\s*[:=]\s*"?(00-[0-9a-f]{32}-[0-9a-f]{16}-[0-9a-f]{2})`)
+
+// webDriverRules the rules extracting the WebDriver session IDs from the output of the tests, each one
+// a regular expression with a capture group for the session ID
+type webDriverRules struct {
+	SessionPatterns []string `yaml:"session_patterns"`
+
+	regexes []*regexp.Regexp
+}
+
+func (r *webDriverRules) compile() error {
+	r.regexes = nil
+	for _, pattern := range r.SessionPatterns {
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			return err
+		}
+
+		if regex.NumSubexp() < 1 {
+			return fmt.Errorf("the pattern %q does not have a capture group for the session ID", pattern)
+		}
+
+		r.regexes = append(r.regexes, regex)
+	}
+
+	return nil
+}
+
+// sessionRegexes returns the configured rules, or the default one
+func (r *webDriverRules) sessionRegexes() []*regexp.Regexp {
+	if len(r.regexes) > 0 {
+		return r.regexes
+	}
+
+	return []*regexp.Regexp{defaultWebDriverSessionRegex}
+}
+
+// webDriverSession returns the attributes of the WebDriver session of the test, read from its properties or parsed
+// from its output, and when links are enabled, a link to the trace of the browser session if its trace context is
+// present in the output, so the browser-side traces can be correlated with the test span
+func webDriverSession(rules *webDriverRules, test junit.Test, links bool) ([]attribute.KeyValue, []trace.Link) {
+	sessionID := ""
+	for _, property := range webDriverSessionProperties {
+		if value := test.Properties[property]; value != "" {
+			sessionID = value
+			break
+		}
+	}
+
+	outputs := []string{test.SystemOut, test.SystemErr}
+	if sessionID == "" {
+		sessionID = findWebDriverSession(rules.sessionRegexes(), outputs)
+	}
+
+	if sessionID == "" {
+		return nil, nil
+	}
+
+	attributes := []attribute.KeyValue{attribute.Key(WebDriverSessionID).String(sessionID)}
+	if !links {
+		return attributes, nil
+	}
+
+	for _, output := range outputs {
+		matches := webDriverTraceparentRegex.FindStringSubmatch(output)
+		if matches == nil {
+			continue
+		}
+
+		carrier := propagation.MapCarrier{traceparentHeader: matches[1]}
+		spanContext := trace.SpanContextFromContext(propagation.TraceContext{}.Extract(context.Background(), carrier))
+		if spanContext.IsValid() {
+			return attributes, []trace.Link{{SpanContext: spanContext, Attributes: attributes}}
+		}
+	}
+
+	return attributes, nil
+}
+
+// findWebDriverSession returns the session ID captured by the first regular expression matching any of the outputs
+func findWebDriverSession(regexes []*regexp.Regexp, outputs []string) string {
+	for _, regex := range regexes {
+		for _, output := range outputs {
+			if matches := regex.FindStringSubmatch(output); matches != nil {
+				return matches[1]
+			}
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/joshdk/go-junit"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestWebDriverSession(t *testing.T) {
+	sessionAttribute := attribute.Key(WebDriverSessionID).String("4f0c2a1e-8b6d-4c3a-9e7f-123456789abc")
+
+	t.Run("From the properties", func(t *testing.T) {
+		attrs, links := webDriverSession(&webDriverRules{}, junit.Test{
+			Properties: map[string]string{"sessionId": "4f0c2a1e-8b6d-4c3a-9e7f-123456789abc"},
+		}, true)
+		require.Equal(t, []attribute.KeyValue{sessionAttribute}, attrs)
+		require.Empty(t, links)
+	})
+
+	t.Run("From the output, with a link", func(t *testing.T) {
+		attrs, links := webDriverSession(&webDriverRules{}, junit.Test{
+			SystemOut: "Started session. Session ID: 4f0c2a1e-8b6d-4c3a-9e7f-123456789abc\n" +
+				"traceparent: 00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		}, true)
+		require.Equal(t, []attribute.KeyValue{sessionAttribute}, attrs)
+		require.Len(t, links, 1)
+		require.Equal(t, "0af7651916cd43dd8448eb211c80319c", links[0].SpanContext.TraceID().String())
+		require.Equal(t, "b7ad6b7169203331", links[0].SpanContext.SpanID().String())
+	})
+
+	t.Run("Links disabled", func(t *testing.T) {
+		_, links := webDriverSession(&webDriverRules{}, junit.Test{
+			SystemOut: "session_id=4f0c2a1e-8b6d-4c3a-9e7f-123456789abc traceparent=00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		}, false)
+		require.Empty(t, links)
+	})
+
+	t.Run("Configured patterns", func(t *testing.T) {
+		rules := &webDriverRules{SessionPatterns: []string{`grid session \[(\w+)\]`}}
+		require.NoError(t, rules.compile())
+
+		attrs, _ := webDriverSession(rules, junit.Test{SystemErr: "grid session [abc123] created"}, false)
+		require.Equal(t, []attribute.KeyValue{attribute.Key(WebDriverSessionID).String("abc123")}, attrs)
+
+		require.Error(t, (&webDriverRules{SessionPatterns: []string{`no group`}}).compile())
+	})
+
+	t.Run("No session", func(t *testing.T) {
+		attrs, links := webDriverSession(&webDriverRules{}, junit.Test{SystemOut: "hello"}, true)
+		require.Empty(t, attrs)
+		require.Empty(t, links)
+	})
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// zipkinExporter sends the spans of the run, the suites and the test cases to Zipkin with its JSON v2 API, for the
+// teams without an OpenTelemetry collector in front of their Zipkin. The spans are the same as the ones exported
+// with OTLP, mapped as the Zipkin exporter of OpenTelemetry does
+type zipkinExporter struct {
+	url    string
+	client *http.Client
+}
+
+// getZipkinURL the precedence order is: flag > OTEL_EXPORTER_ZIPKIN_ENDPOINT > disabled. The URL is the one of the
+// spans API, i.e. http://localhost:9411/api/v2/spans
+func getZipkinURL() string {
+	return getOtlpEnvVar(zipkinURLFlag, "OTEL_EXPORTER_ZIPKIN_ENDPOINT", "")
+}
+
+func newZipkinExporter(url string) *zipkinExporter {
+	return &zipkinExporter{url: url, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// zipkinSpan a span of the JSON v2 API of Zipkin
+type zipkinSpan struct {
+	TraceID       string             `json:"traceId"`
+	ID            string             `json:"id"`
+	ParentID      string             `json:"parentId,omitempty"`
+	Name          string             `json:"name"`
+	Kind          string             `json:"kind,omitempty"`
+	Timestamp     int64              `json:"timestamp"`
+	Duration      int64              `json:"duration"`
+	LocalEndpoint *zipkinEndpoint    `json:"localEndpoint,omitempty"`
+	Annotations   []zipkinAnnotation `json:"annotations,omitempty"`
+	Tags          map[string]string  `json:"tags,omitempty"`
+}
+
+type zipkinEndpoint struct {
+	ServiceName string `json:"serviceName"`
+}
+
+type zipkinAnnotation struct {
+	Timestamp int64  `json:"timestamp"`
+	Value     string `json:"value"`
+}
+
+func (e *zipkinExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	if len(spans) == 0 {
+		return nil
+	}
+
+	zipkinSpans := make([]zipkinSpan, 0, len(spans))
+	for _, span := range spans {
+		zipkinSpans = append(zipkinSpans, toZipkinSpan(span))
+	}
+
+	body, err := json.Marshal(zipkinSpans)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send the spans to zipkin: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("zipkin responded with %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	return nil
+}
+
+// Shutdown implements the span exporter, there is nothing to release
+func (e *zipkinExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// toZipkinSpan maps the span to Zipkin: the attributes are tags, the events are annotations, and the error status
+// is the error tag, with the status code in the otel.status_code tag
+func toZipkinSpan(span sdktrace.ReadOnlySpan) zipkinSpan {
+	zs := zipkinSpan{
+		TraceID:   span.SpanContext().TraceID().String(),
+		ID:        span.SpanContext().SpanID().String(),
+		Name:      span.Name(),
+		Kind:      zipkinKind(span.SpanKind()),
+		Timestamp: span.StartTime().UnixMicro(),
+		Duration:  span.EndTime().Sub(span.StartTime()).Microseconds(),
+		Tags:      map[string]string{},
+	}
+
+	if span.Parent().IsValid() {
+		zs.ParentID = span.Parent().SpanID().String()
+	}
+
+	if res := span.Resource(); res != nil {
+		if name, ok := res.Set().Value(semconv.ServiceNameKey); ok {
+			zs.LocalEndpoint = &zipkinEndpoint{ServiceName: name.AsString()}
+		}
+	}
+
+	for _, attr := range span.Attributes() {
+		zs.Tags[string(attr.Key)] = attr.Value.Emit()
+	}
+
+	for _, event := range span.Events() {
+		zs.Annotations = append(zs.Annotations, zipkinAnnotation{Timestamp: event.Time.UnixMicro(), Value: event.Name})
+	}
+
+	if scope := span.InstrumentationScope(); scope.Name != "" {
+		zs.Tags["otel.library.name"] = scope.Name
+		if scope.Version != "" {
+			zs.Tags["otel.library.version"] = scope.Version
+		}
+	}
+
+	switch span.Status().Code {
+	case codes.Error:
+		zs.Tags["otel.status_code"] = "ERROR"
+		zs.Tags["error"] = span.Status().Description
+	case codes.Ok:
+		zs.Tags["otel.status_code"] = "OK"
+	}
+
+	if len(zs.Tags) == 0 {
+		zs.Tags = nil
+	}
+
+	return zs
+}
+
+// zipkinKind returns the kind of the span in Zipkin, which has no internal kind
+func zipkinKind(kind trace.SpanKind) string {
+	switch kind {
+	case trace.SpanKindServer:
+		return "SERVER"
+	case trace.SpanKindClient:
+		return "CLIENT"
+	case trace.SpanKindProducer:
+		return "PRODUCER"
+	case trace.SpanKindConsumer:
+		return "CONSUMER"
+	default:
+		return ""
+	}
+}
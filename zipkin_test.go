@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestZipkinExporter(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	res := resource.NewSchemaless(semconv.ServiceNameKey.String("e2e"))
+
+	traceID := trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	suite := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: trace.SpanID{1, 1, 1, 1, 1, 1, 1, 1}})
+	test := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: trace.SpanID{2, 2, 2, 2, 2, 2, 2, 2}})
+
+	spans := tracetest.SpanStubs{
+		{
+			Name:        "suite",
+			SpanContext: suite,
+			SpanKind:    trace.SpanKindServer,
+			StartTime:   start,
+			EndTime:     start.Add(2 * time.Second),
+			Attributes:  []attribute.KeyValue{attribute.Key(TestsSuiteName).String("suite")},
+			Resource:    res,
+		},
+		{
+			Name:        "TestLogin",
+			SpanContext: test,
+			Parent:      suite,
+			StartTime:   start,
+			EndTime:     start.Add(1500 * time.Millisecond),
+			Attributes: []attribute.KeyValue{
+				attribute.Key(TestStatus).String("failed"),
+				attribute.Key(TestDuration).Int64(1500),
+			},
+			Events:   []sdktrace.Event{{Name: semconv.ExceptionEventName, Time: start.Add(time.Second)}},
+			Status:   sdktrace.Status{Code: codes.Error, Description: "expected true"},
+			Resource: res,
+		},
+	}.Snapshots()
+
+	t.Run("Sends the spans", func(t *testing.T) {
+		received := []map[string]any{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/api/v2/spans", r.URL.Path)
+			require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer server.Close()
+
+		exporter := newZipkinExporter(server.URL + "/api/v2/spans")
+		require.NoError(t, exporter.ExportSpans(context.Background(), spans))
+
+		require.Len(t, received, 2)
+
+		require.Equal(t, "0102030405060708090a0b0c0d0e0f10", received[0]["traceId"])
+		require.Equal(t, "0101010101010101", received[0]["id"])
+		require.NotContains(t, received[0], "parentId")
+		require.Equal(t, "SERVER", received[0]["kind"])
+		require.Equal(t, float64(start.UnixMicro()), received[0]["timestamp"])
+		require.Equal(t, float64(2000000), received[0]["duration"])
+		require.Equal(t, map[string]any{"serviceName": "e2e"}, received[0]["localEndpoint"])
+
+		require.Equal(t, "TestLogin", received[1]["name"])
+		require.Equal(t, "0101010101010101", received[1]["parentId"])
+		require.NotContains(t, received[1], "kind")
+		require.Equal(t, []any{map[string]any{"timestamp": float64(start.Add(time.Second).UnixMicro()), "value": "exception"}}, received[1]["annotations"])
+
+		tags := received[1]["tags"].(map[string]any)
+		require.Equal(t, "failed", tags[TestStatus])
+		require.Equal(t, "1500", tags[TestDuration])
+		require.Equal(t, "ERROR", tags["otel.status_code"])
+		require.Equal(t, "expected true", tags["error"])
+	})
+
+	t.Run("Rejected spans", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "malformed spans", http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		err := newZipkinExporter(server.URL).ExportSpans(context.Background(), spans)
+		require.ErrorContains(t, err, "malformed spans")
+	})
+}
+
+func TestGetZipkinURL(t *testing.T) {
+	defer func(url string) { zipkinURLFlag = url }(zipkinURLFlag)
+
+	zipkinURLFlag = ""
+	t.Setenv("OTEL_EXPORTER_ZIPKIN_ENDPOINT", "http://zipkin:9411/api/v2/spans")
+	require.Equal(t, "http://zipkin:9411/api/v2/spans", getZipkinURL())
+
+	zipkinURLFlag = "http://localhost:9411/api/v2/spans"
+	require.Equal(t, "http://localhost:9411/api/v2/spans", getZipkinURL())
+}